@@ -0,0 +1,157 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithAutoResolve makes the provider construct unregistered struct and pointer-to-struct types
+// using their default factories, as if they were registered with [RegisterType] as [Transient],
+// instead of returning an [UnknownType]. It is intended for prototyping; other unregistered types
+// are still unknown. Each resolution of an unregistered type is reported to the provider's
+// [Observer] as a [TypeAutoResolved], and [RootProvider.Verify] reports an [AutoResolved] for each
+// type that has been resolved this way so that it can be given an explicit registration.
+func WithAutoResolve() ProviderOption {
+	return func(options *providerOptions) {
+		options.autoResolve = true
+	}
+}
+
+// A TypeAutoResolved is an [Event] indicating that a provider constructed a type that is not
+// registered because it was built with [WithAutoResolve].
+type TypeAutoResolved struct {
+
+	// Type is the unregistered type that was resolved.
+	Type reflect.Type
+}
+
+func (TypeAutoResolved) event() {}
+
+// ErrAutoResolved is returned when verification finds a type that was resolved without a
+// registration because the provider was built with [WithAutoResolve].
+var ErrAutoResolved = errors.New("type was resolved without a registration")
+
+// An AutoResolved is an [error] indicating that verification found a type that was resolved
+// without a registration because the provider was built with [WithAutoResolve]. Calling
+// [errors.Is] with an AutoResolved and [ErrAutoResolved] returns true.
+type AutoResolved struct {
+
+	// Type is the unregistered type that was resolved.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err AutoResolved) Error() string {
+	return fmt.Sprintf("%v is not registered and was resolved by WithAutoResolve", err.Type)
+}
+
+// Is indicates that an [AutoResolved] is [ErrAutoResolved].
+func (AutoResolved) Is(target error) bool {
+	return target == ErrAutoResolved
+}
+
+// Code returns "auto_resolved", the code of an [AutoResolved] in [ErrorCodes].
+func (AutoResolved) Code() string {
+	return "auto_resolved"
+}
+
+// AutoResolvedTypes returns the unregistered types the provider has resolved because it was built
+// with [WithAutoResolve], ordered by type name.
+func (provider RootProvider) AutoResolvedTypes() []reflect.Type {
+	if !provider.initialized() {
+		return nil
+	}
+	return provider.autoResolved.types()
+}
+
+// autoRegistration returns the registration used to resolve typ when it is not registered, if the
+// provider was built with WithAutoResolve and typ is a struct or a pointer to a struct.
+func (provider RootProvider) autoRegistration(typ reflect.Type) (registration, bool, error) {
+	if !provider.options.autoResolve || !isAutoResolvable(typ) {
+		return registration{}, false, nil
+	}
+	registration_, err := provider.autoResolved.get(typ)
+	if err != nil {
+		return registration{}, false, err
+	}
+	provider.observe(TypeAutoResolved{
+		Type: typ,
+	})
+	return registration_, true, nil
+}
+
+func isAutoResolvable(typ reflect.Type) bool {
+	if isKeyType(typ) {
+		return false
+	}
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// autoRegistrations holds the registrations created for the types a provider has auto-resolved.
+type autoRegistrations struct {
+	mu            sync.Mutex
+	registrations map[reflect.Type]registration
+}
+
+// get returns the registration for typ, creating it if typ has not been auto-resolved before. The
+// caller must check that typ is auto-resolvable, so get returns an InternalError if there is no
+// default factory for it.
+func (a *autoRegistrations) get(typ reflect.Type) (registration, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if registration_, ok := a.registrations[typ]; ok {
+		return registration_, nil
+	}
+	factory, err := getDefaultFactory(typ, defaultFactoryOptions{})
+	if err != nil {
+		return registration{}, InternalError{
+			Type: typ,
+			Err:  err,
+		}
+	}
+	registration_ := registration{
+		lifetime:     Transient,
+		impl:         typ,
+		factory:      factory,
+		owned:        true,
+		source:       sourceDefault,
+		dependencies: defaultFactoryDependencies(typ),
+	}
+	if a.registrations == nil {
+		a.registrations = make(map[reflect.Type]registration)
+	}
+	a.registrations[typ] = registration_
+	return registration_, nil
+}
+
+func (a *autoRegistrations) types() []reflect.Type {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	types := make([]reflect.Type, 0, len(a.registrations))
+	for typ := range a.registrations {
+		types = append(types, typ)
+	}
+	slices.SortFunc(types, func(a, b reflect.Type) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return types
+}
+
+// autoResolvedProblems returns an AutoResolved for each type the provider has auto-resolved.
+func (provider RootProvider) autoResolvedProblems() []error {
+	types := provider.autoResolved.types()
+	problems := make([]error, 0, len(types))
+	for _, typ := range types {
+		problems = append(problems, AutoResolved{
+			Type: typ,
+		})
+	}
+	return problems
+}
@@ -0,0 +1,131 @@
+package di
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type autoConfig struct {
+	Name string
+}
+
+type autoHandler struct {
+	Config *autoConfig
+}
+
+func TestWithAutoResolve(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterInstance[*autoConfig](Registry{}, &autoConfig{Name: "registered"})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("constructs unregistered pointer-to-struct types as transients", func(t *testing.T) {
+		scope := newProvider(t, WithAutoResolve()).NewScope()
+		first, err := Resolve[*autoHandler](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if first.Config == nil || first.Config.Name != "registered" {
+			t.Fatalf("expected the registered dependency to be injected; got %+v", first.Config)
+		}
+		second, err := Resolve[*autoHandler](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if first == second {
+			t.Fatalf("expected a new instance for each resolution")
+		}
+	})
+
+	t.Run("constructs unregistered struct types", func(t *testing.T) {
+		handler, err := Resolve[autoHandler](newProvider(t, WithAutoResolve()))
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if handler.Config == nil {
+			t.Fatalf("expected the dependency to be injected")
+		}
+	})
+
+	t.Run("returns UnknownType for other kinds", func(t *testing.T) {
+		provider := newProvider(t, WithAutoResolve())
+		if _, err := Resolve[io.Reader](provider); !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+		if _, err := Resolve[*int](provider); !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+
+	t.Run("is off by default", func(t *testing.T) {
+		if _, err := Resolve[*autoHandler](newProvider(t)); !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+
+	t.Run("reports auto-resolved types to the observer", func(t *testing.T) {
+		var mu sync.Mutex
+		var resolved []reflect.Type
+		provider := newProvider(t,
+			WithAutoResolve(),
+			WithObserver(ObserverFunc(func(event Event) {
+				if autoResolved, ok := event.(TypeAutoResolved); ok {
+					mu.Lock()
+					defer mu.Unlock()
+					resolved = append(resolved, autoResolved.Type)
+				}
+			})))
+		if _, err := Resolve[*autoHandler](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		expected := []reflect.Type{reflect.TypeFor[*autoHandler]()}
+		if !reflect.DeepEqual(resolved, expected) {
+			t.Fatalf("expected %v; got %v", expected, resolved)
+		}
+	})
+
+	t.Run("Verify lists the auto-resolved types", func(t *testing.T) {
+		provider := newProvider(t, WithAutoResolve())
+		if err := provider.Verify(); err != nil {
+			t.Fatalf("unexpected error from Verify: %v", err)
+		}
+		if _, err := Resolve[*autoHandler](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		err := provider.Verify()
+		var autoResolved AutoResolved
+		if !errors.As(err, &autoResolved) || !errors.Is(err, ErrAutoResolved) {
+			t.Fatalf("expected %v to include an AutoResolved", err)
+		}
+		if expected := reflect.TypeFor[*autoHandler](); autoResolved.Type != expected {
+			t.Fatalf("expected %v; got %v", expected, autoResolved.Type)
+		}
+		expected := []reflect.Type{reflect.TypeFor[*autoHandler]()}
+		if actual := provider.AutoResolvedTypes(); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("returns an InternalError for types without a default factory", func(t *testing.T) {
+		typ := reflect.TypeFor[func()]()
+		_, err := (&autoRegistrations{}).get(typ)
+		var internalError InternalError
+		if !errors.As(err, &internalError) || internalError.Type != typ {
+			t.Fatalf("expected an InternalError for %v; got %v", typ, err)
+		}
+		if !errors.Is(err, ErrInternal) || !errors.Is(err, ErrNoDefaultFactory) {
+			t.Fatalf("expected %v to be %v and %v", err, ErrInternal, ErrNoDefaultFactory)
+		}
+	})
+}
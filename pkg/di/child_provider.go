@@ -0,0 +1,58 @@
+package di
+
+import (
+	"maps"
+	"reflect"
+)
+
+// NewChildProvider creates a [RootProvider] that resolves values using the registrations in
+// registry and falls back to the provider for the types the registry does not register. The child
+// shares the provider's options, and its registrations shadow the provider's.
+//
+// The [Singleton] values the child does not shadow are resolved by the provider, so the child and
+// the provider share their instances. Since those values are constructed by the provider they can
+// never capture a value the child registers. The [Transient] and [Scoped] values the child does not
+// shadow are constructed by the child, or by its scopes, using the provider's registrations, so
+// their dependencies resolve to the child's registrations where they exist.
+//
+// Closing the child only closes the values the child created and runs the cleanups deferred on
+// it; the provider's singletons are left for the provider to close. The child sees the provider's
+// registrations as they were when it was created.
+func (provider RootProvider) NewChildProvider(registry Registry) (RootProvider, error) {
+	if err := provider.checkInitialized("NewChildProvider"); err != nil {
+		return RootProvider{}, err
+	}
+	registrations := maps.Clone(registry.registrations)
+	if registrations == nil {
+		registrations = map[reflect.Type]registration{}
+	}
+	parent := provider
+	parent.constructing = nil
+	parent.path = nil
+	parent.ctx = nil
+	for typ, inherited := range parent.registrations.load() {
+		if _, ok := registrations[typ]; ok {
+			continue
+		}
+		if inherited.lifetime == Singleton {
+			inherited = registration{
+				lifetime: Singleton,
+				impl:     inherited.impl,
+				factory: func(Resolver) (any, error) {
+					return parent.Resolve(typ)
+				},
+				scopeName: inherited.scopeName,
+				inherited: true,
+				priority:  inherited.priority,
+				order:     inherited.order,
+
+				deprecated:  inherited.deprecated,
+				deprecation: inherited.deprecation,
+			}
+		}
+		registrations[typ] = inherited
+	}
+	child := newRootProvider(registrations, parent.options)
+	child.parent = &parent
+	return child, nil
+}
@@ -0,0 +1,170 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChildProvider(t *testing.T) {
+
+	newParent := func(t *testing.T, lifetime Lifetime) RootProvider {
+		registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, lifetime)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("shares the parent's singletons for unshadowed types", func(t *testing.T) {
+		parent := newParent(t, Singleton)
+		child, err := parent.NewChildProvider(Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		fromChild, err := Resolve[*mockCloser](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		fromParent, err := Resolve[*mockCloser](parent)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if fromChild != fromParent {
+			t.Fatalf("expected the child to share the parent's singleton")
+		}
+	})
+
+	t.Run("resolves shadowed types and their dependents using the child's registrations", func(t *testing.T) {
+		parent := newParent(t, Singleton)
+		expected := &mockCloser{}
+		registry, err := RegisterInstance[*mockCloser](Registry{}, expected)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		child, err := parent.NewChildProvider(registry)
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		transient, err := Resolve[*verifyTransient](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if transient.Scoped != expected {
+			t.Fatalf("expected the parent's transient to receive the child's instance")
+		}
+		fromParent, err := Resolve[*mockCloser](parent)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if fromParent == expected {
+			t.Fatalf("expected the parent to be unaffected by the child's registrations")
+		}
+	})
+
+	t.Run("scopes resolve the parent's scoped registrations", func(t *testing.T) {
+		parent := newParent(t, Scoped)
+		child, err := parent.NewChildProvider(Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		scope := child.NewScope()
+		value, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !value.closed {
+			t.Fatalf("expected the scope to close the scoped value")
+		}
+	})
+
+	t.Run("Close only closes the values the child created", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		parent, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		childRegistry, err := RegisterType[*mockContextCloser, *mockContextCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		child, err := parent.NewChildProvider(childRegistry)
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		inherited, err := Resolve[*mockCloser](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		own, err := Resolve[*mockContextCloser](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if errs := child.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !own.closed {
+			t.Errorf("expected the child's singleton to be closed")
+		}
+		if inherited.closed {
+			t.Errorf("expected the parent's singleton to be left open")
+		}
+	})
+
+	t.Run("Verify reports captive dependencies across the boundary", func(t *testing.T) {
+		parent := newParent(t, Scoped)
+		registry, err := RegisterType[*verifySingleton, *verifySingleton](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		child, err := parent.NewChildProvider(registry)
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		err = child.Verify()
+		var captive CaptiveDependency
+		if !errors.As(err, &captive) {
+			t.Fatalf("expected %v to contain %T", err, captive)
+		}
+		if captive.Dependency != reflect.TypeFor[*mockCloser]() {
+			t.Fatalf("expected captive dependency on %v; got %v", reflect.TypeFor[*mockCloser](), captive.Dependency)
+		}
+	})
+
+	t.Run("Verify reports the parent's problems", func(t *testing.T) {
+		registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		parent, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		childRegistry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		child, err := parent.NewChildProvider(childRegistry)
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		if !errors.Is(child.Verify(), ErrMissingDependency) {
+			t.Fatalf("expected the child to report the parent's missing dependency")
+		}
+	})
+}
@@ -0,0 +1,778 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A ContextCloser is a value that can be closed with a [context.Context].
+type ContextCloser interface {
+	Close(context.Context) error
+}
+
+// A Closer is a value that can be closed.
+type Closer interface {
+	Close() error
+}
+
+// A CloseOption configures optional behavior for a single call to Close.
+type CloseOption func(*closeOptions)
+
+type closeOptions struct {
+	perCloserTimeout time.Duration
+	concurrency      int
+	failFast         bool
+	delegateErrors   bool
+}
+
+func newCloseOptions(defaults *providerOptions, opts []CloseOption) closeOptions {
+	options := closeOptions{
+		concurrency: defaults.closeConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithPerCloserTimeout limits the time Close waits for each closer, and for each cleanup deferred
+// with [Scope.Defer] or [RootProvider.Defer], so that a single misbehaving closer cannot consume
+// the entire budget for closing. Each [ContextCloser] and deferred cleanup receives a context that
+// expires after timeout, and a closer that runs past its timeout is reported as a [CloserTimeout]
+// and abandoned. The context passed to Close still bounds the entire call.
+func WithPerCloserTimeout(timeout time.Duration) CloseOption {
+	return func(options *closeOptions) {
+		options.perCloserTimeout = timeout
+	}
+}
+
+// WithCloseConcurrency limits the number of closers, including the sequence of cleanups deferred
+// with [Scope.Defer] or [RootProvider.Defer], that Close runs at once. A limit of 1 closes values
+// sequentially in the reverse of the order they were created, and a limit of 0 or less runs every
+// closer at once. This overrides the default given by [WithDefaultCloseConcurrency].
+func WithCloseConcurrency(limit int) CloseOption {
+	return func(options *closeOptions) {
+		options.concurrency = limit
+	}
+}
+
+// FailFast makes Close stop at the first error from a closer or deferred cleanup rather than
+// closing everything else first, e.g. to capture state and crash when the first failure means the
+// process is wedged. Close returns immediately with that error and a [CloseHalted] listing the
+// values that were not attempted, which are reported as [NotAttempted]. Closers that were already
+// running are abandoned and reported as [AbandonedAtDeadline]. The scope or provider is closed
+// all the same, but a scope created by [Scope.Fork] that halts does not go on to close the
+// instances of its extra [Singleton] registrations.
+func FailFast() CloseOption {
+	return func(options *closeOptions) {
+		options.failFast = true
+	}
+}
+
+// ErrCloser is returned when a value being closed returns an error from its Close method.
+var ErrCloser = errors.New("closer failed")
+
+// A CloserError is an [error] indicating that a value being closed returned an error from its Close
+// method. Calling [errors.Is] with a CloserError and [ErrCloser] returns true.
+type CloserError struct {
+
+	// Type is the registered type of the value being closed.
+	Type reflect.Type
+
+	// Err is the error returned by the closer.
+	Err error
+}
+
+// Error implements [error].
+func (err CloserError) Error() string {
+	return fmt.Sprintf("closer for %v failed: %v", err.Type, err.Err)
+}
+
+// Is indicates that a [CloserError] is [ErrCloser].
+func (CloserError) Is(target error) bool {
+	return target == ErrCloser
+}
+
+// Unwrap gets the underlying [error] returned by the closer.
+func (err CloserError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "closer_error", the code of a [CloserError] in [ErrorCodes].
+func (CloserError) Code() string {
+	return "closer_error"
+}
+
+// ErrCloserTimeout is returned when a closer runs past the timeout given by
+// [WithPerCloserTimeout].
+var ErrCloserTimeout = errors.New("closer exceeded its timeout")
+
+// A CloserTimeout is an [error] indicating that a closer ran past the timeout given by
+// [WithPerCloserTimeout]. Calling [errors.Is] with a CloserTimeout and [ErrCloserTimeout] returns
+// true.
+type CloserTimeout struct {
+
+	// Type is the registered type of the value being closed, or of the value whose factory
+	// deferred the cleanup. Type is nil for cleanups deferred outside of a factory.
+	Type reflect.Type
+
+	// Timeout is the timeout the closer exceeded.
+	Timeout time.Duration
+
+	// Elapsed is the time the closer had been running when it returned or was abandoned.
+	Elapsed time.Duration
+
+	// Err is the error returned by the closer if it returned after exceeding its timeout.
+	Err error
+}
+
+// Error implements [error].
+func (err CloserTimeout) Error() string {
+	msg := fmt.Sprintf("closer for %v exceeded its %v timeout after %v", err.Type, err.Timeout, err.Elapsed)
+	if err.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, err.Err)
+	}
+	return msg
+}
+
+// Is indicates that a [CloserTimeout] is [ErrCloserTimeout].
+func (CloserTimeout) Is(target error) bool {
+	return target == ErrCloserTimeout
+}
+
+// Unwrap gets the underlying [error] returned by the closer, if any.
+func (err CloserTimeout) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "closer_timeout", the code of a [CloserTimeout] in [ErrorCodes].
+func (CloserTimeout) Code() string {
+	return "closer_timeout"
+}
+
+// ErrCloseTimeout is returned when the context passed to Close is done before every value has been
+// closed.
+var ErrCloseTimeout = errors.New("close timed out")
+
+// An IncompleteClose is an [error] indicating that the context passed to Close was done before
+// every value had been closed. Calling [errors.Is] with an IncompleteClose and [ErrCloseTimeout]
+// returns true.
+type IncompleteClose struct {
+
+	// Types are the registered types of the values that were not confirmed closed, including
+	// those whose closers had yet to start and will run after Close returns. Cleanups deferred with [Scope.Defer] or
+	// [RootProvider.Defer] that did not finish are reported as the type whose factory deferred
+	// them, or nil for cleanups deferred outside of a factory.
+	Types []reflect.Type
+
+	// Err is the error from the context passed to Close.
+	Err error
+}
+
+// Error implements [error].
+func (err IncompleteClose) Error() string {
+	return fmt.Sprintf("close timed out before %d closers finished (%v): %v", len(err.Types), err.Types, err.Err)
+}
+
+// Is indicates that an [IncompleteClose] is [ErrCloseTimeout].
+func (IncompleteClose) Is(target error) bool {
+	return target == ErrCloseTimeout
+}
+
+// Unwrap gets the error from the context passed to Close.
+func (err IncompleteClose) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "incomplete_close", the code of an [IncompleteClose] in [ErrorCodes].
+func (IncompleteClose) Code() string {
+	return "incomplete_close"
+}
+
+// ErrCloseHalted is returned when Close stops at the first error because it was given [FailFast].
+var ErrCloseHalted = errors.New("close halted after an error")
+
+// A CloseHalted is an [error] indicating that Close stopped at the first error from a closer or
+// deferred cleanup because it was given [FailFast]. It is returned in addition to that error.
+// Calling [errors.Is] with a CloseHalted and [ErrCloseHalted] returns true.
+type CloseHalted struct {
+
+	// NotAttempted are the registered types of the values whose closers were never started, and
+	// of the values whose factories deferred the cleanups that were never run.
+	NotAttempted []reflect.Type
+
+	// Abandoned are the registered types of the values whose closers were still running when
+	// Close halted.
+	Abandoned []reflect.Type
+}
+
+// Error implements [error].
+func (err CloseHalted) Error() string {
+	msg := fmt.Sprintf("close halted after an error before attempting %d closers (%v)", len(err.NotAttempted), err.NotAttempted)
+	if len(err.Abandoned) > 0 {
+		return fmt.Sprintf("%s and abandoned %d running closers (%v)", msg, len(err.Abandoned), err.Abandoned)
+	}
+	return msg
+}
+
+// Is indicates that a [CloseHalted] is [ErrCloseHalted].
+func (CloseHalted) Is(target error) bool {
+	return target == ErrCloseHalted
+}
+
+// Code returns "close_halted", the code of a [CloseHalted] in [ErrorCodes].
+func (CloseHalted) Code() string {
+	return "close_halted"
+}
+
+// ErrCloseContextAlreadyDone is returned when the context passed to Close was already done when
+// Close was called, so every closer was abandoned without being started.
+var ErrCloseContextAlreadyDone = errors.New("close context was already done")
+
+// A CloseContextAlreadyDone is an [error] indicating that the context passed to Close was already
+// done when Close was called, so every closer was abandoned without being started. This usually
+// means that Close was passed a context that had been cancelled, such as the context of a request
+// that has finished; see [Scope.CloseWithDefault]. It is returned in addition to the
+// [IncompleteClose] listing the abandoned closers. Calling [errors.Is] with a
+// CloseContextAlreadyDone and [ErrCloseContextAlreadyDone] returns true.
+type CloseContextAlreadyDone struct {
+
+	// Err is the error from the context passed to Close.
+	Err error
+}
+
+// Error implements [error].
+func (err CloseContextAlreadyDone) Error() string {
+	return fmt.Sprintf("close context was already done when Close was called: %v", err.Err)
+}
+
+// Is indicates that a [CloseContextAlreadyDone] is [ErrCloseContextAlreadyDone].
+func (CloseContextAlreadyDone) Is(target error) bool {
+	return target == ErrCloseContextAlreadyDone
+}
+
+// Unwrap gets the error from the context passed to Close.
+func (err CloseContextAlreadyDone) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "close_context_already_done", the code of a [CloseContextAlreadyDone] in [ErrorCodes].
+func (CloseContextAlreadyDone) Code() string {
+	return "close_context_already_done"
+}
+
+// An AbandonedCloser describes a closer, or a cleanup deferred with [Scope.Defer] or
+// [RootProvider.Defer], that was still running when Close gave up on it and has not finished
+// since.
+type AbandonedCloser struct {
+
+	// Type is the registered type of the value being closed, or of the value whose factory
+	// deferred the cleanup. Type is nil for cleanups deferred outside of a factory.
+	Type reflect.Type
+
+	// Since is the time at which Close gave up on the closer.
+	Since time.Time
+}
+
+// abandonedClosers tracks the closers that Close gave up on until they finish.
+type abandonedClosers struct {
+	mu      sync.Mutex
+	next    uint64
+	closers map[uint64]AbandonedCloser
+}
+
+func (a *abandonedClosers) add(typ reflect.Type, since time.Time) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closers == nil {
+		a.closers = make(map[uint64]AbandonedCloser)
+	}
+	id := a.next
+	a.next++
+	a.closers[id] = AbandonedCloser{
+		Type:  typ,
+		Since: since,
+	}
+	return id
+}
+
+func (a *abandonedClosers) remove(id uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.closers, id)
+}
+
+func (a *abandonedClosers) snapshot() []AbandonedCloser {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	closers := make([]AbandonedCloser, 0, len(a.closers))
+	for _, closer := range a.closers {
+		closers = append(closers, closer)
+	}
+	slices.SortFunc(closers, func(a, b AbandonedCloser) int {
+		return a.Since.Compare(b.Since)
+	})
+	return closers
+}
+
+// ownedEntries returns the entries whose registrations indicate that the provider owns the
+// instances they produce.
+func ownedEntries(
+	entries []instanceEntry,
+	registrations map[reflect.Type]registration,
+) []instanceEntry {
+	owned := make([]instanceEntry, 0, len(entries))
+	for _, entry := range entries {
+		if registration, ok := registrations[entry.typ]; ok && registration.owned {
+			owned = append(owned, entry)
+		}
+	}
+	return owned
+}
+
+// unownedClosers returns the entries whose registrations indicate that the provider does not own
+// the instances they produce and whose instances could otherwise be closed.
+func unownedClosers(
+	entries []instanceEntry,
+	registrations map[reflect.Type]registration,
+) []instanceEntry {
+	unowned := []instanceEntry{}
+	for _, entry := range entries {
+		if registration, ok := registrations[entry.typ]; ok && !registration.owned && isCloser(entry.value) {
+			unowned = append(unowned, entry)
+		}
+	}
+	return unowned
+}
+
+func isCloser(value any) bool {
+	switch value.(type) {
+	case ContextCloser, Closer:
+		return true
+	}
+	return false
+}
+
+// A closeJob is a single closer or deferred cleanup run by Close.
+type closeJob struct {
+	typ     reflect.Type
+	run     func(context.Context) error
+	outcome CloseOutcome
+
+	// index is the position of the job's entry in the CloseReport.
+	index int
+}
+
+// A closeResult is the result of a single closeJob.
+type closeResult struct {
+	job       *closeJob
+	started   bool
+	err       error
+	elapsed   time.Duration
+	abandoned bool
+}
+
+// closeSequences returns the jobs required to close the entries and run the cleanups. The jobs in
+// each sequence must be run in order but the sequences can be run concurrently. The sequences are
+// returned in the order they should be started: the entries in reverse followed by the cleanups.
+func closeSequences(entries []instanceEntry, cleanups []deferredCleanup) [][]closeJob {
+	sequences := make([][]closeJob, 0, len(entries)+1)
+	for _, entry := range slices.Backward(entries) {
+		var closer func(context.Context) error
+		var outcome CloseOutcome
+		switch value := entry.value.(type) {
+		case ContextCloser:
+			closer = value.Close
+			outcome = ClosedByContextCloser
+		case Closer:
+			closer = func(context.Context) error {
+				return value.Close()
+			}
+			outcome = ClosedByCloser
+		default:
+			continue
+		}
+		sequences = append(sequences, []closeJob{{
+			typ:     entry.typ,
+			outcome: outcome,
+			run: func(ctx context.Context) error {
+				if err := closer(ctx); err != nil {
+					return CloserError{
+						Type: entry.typ,
+						Err:  err,
+					}
+				}
+				return nil
+			},
+		}})
+	}
+	if len(cleanups) > 0 {
+		sequence := make([]closeJob, 0, len(cleanups))
+		for _, cleanup := range cleanups {
+			sequence = append(sequence, closeJob{
+				typ:     cleanup.typ,
+				outcome: CleanupRan,
+				run: func(ctx context.Context) error {
+					if err := cleanup.cleanup(ctx); err != nil {
+						return DeferredCleanupError{
+							Type: cleanup.typ,
+							Err:  err,
+						}
+					}
+					return nil
+				},
+			})
+		}
+		sequences = append(sequences, sequence)
+	}
+	return sequences
+}
+
+// closeTracker records which of the jobs started by a single call to Close are still pending so
+// they can be recorded as abandoned if Close gives up on them.
+type closeTracker struct {
+	mu        sync.Mutex
+	abandoned *abandonedClosers
+	gaveUp    bool
+	stopped   bool
+	pending   map[*closeJob]time.Time
+	finished  map[*closeJob]struct{}
+	handles   map[*closeJob]uint64
+}
+
+// start records a job as pending and reports whether it may run. Jobs may not be started once
+// closing has been stopped by a failure, but jobs that Close gave up on at its deadline are still
+// started so that every value is closed even if Close does not wait for it.
+func (t *closeTracker) start(job *closeJob) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		if id, ok := t.handles[job]; ok {
+			delete(t.handles, job)
+			t.abandoned.remove(id)
+		}
+		return false
+	}
+	t.pending[job] = time.Now()
+	return true
+}
+
+// stop prevents any more jobs from starting.
+func (t *closeTracker) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+}
+
+func (t *closeTracker) finish(job *closeJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, job)
+	t.finished[job] = struct{}{}
+	if id, ok := t.handles[job]; ok {
+		t.abandoned.remove(id)
+	}
+}
+
+// abandon records a single pending job as abandoned.
+func (t *closeTracker) abandon(job *closeJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.abandonLocked(job, time.Now())
+}
+
+// giveUp records every job in sequences that has not finished as abandoned, including the jobs
+// that are yet to start unless closing was stopped, and returns those jobs along with the time
+// each one that was started has been running.
+func (t *closeTracker) giveUp(sequences [][]closeJob) ([]*closeJob, map[*closeJob]time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gaveUp = true
+	now := time.Now()
+	running := make(map[*closeJob]time.Duration, len(t.pending))
+	for job, start := range t.pending {
+		running[job] = now.Sub(start)
+	}
+	unfinished := []*closeJob{}
+	for _, sequence := range sequences {
+		for i := range sequence {
+			job := &sequence[i]
+			if _, ok := t.finished[job]; ok {
+				continue
+			}
+			unfinished = append(unfinished, job)
+			if _, ok := t.pending[job]; ok || !t.stopped {
+				t.abandonLocked(job, now)
+			}
+		}
+	}
+	return unfinished, running
+}
+
+func (t *closeTracker) abandonLocked(job *closeJob, now time.Time) {
+	if _, ok := t.handles[job]; ok {
+		return
+	}
+	t.handles[job] = t.abandoned.add(job.typ, now)
+}
+
+// closeValues closes the entries and runs the cleanups and returns the errors from doing so; see
+// closeReport.
+func closeValues(
+	ctx context.Context,
+	entries []instanceEntry,
+	cleanups []deferredCleanup,
+	abandoned *abandonedClosers,
+	options closeOptions,
+) []error {
+	return closeReport(ctx, entries, nil, cleanups, abandoned, options).Errors()
+}
+
+// closeReport closes the entries, runs the cleanups, and returns a report describing each of
+// them. The skipped entries are not closed but are included in the report as [SkippedNotOwned].
+func closeReport(
+	ctx context.Context,
+	entries []instanceEntry,
+	skipped []instanceEntry,
+	cleanups []deferredCleanup,
+	abandoned *abandonedClosers,
+	options closeOptions,
+) (report CloseReport) {
+
+	start := time.Now()
+	alreadyDone := ctx.Err()
+	sequences := closeSequences(entries, cleanups)
+	for _, sequence := range sequences {
+		for i := range sequence {
+			sequence[i].index = len(report.Entries)
+			report.Entries = append(report.Entries, CloseReportEntry{
+				Type:    sequence[i].typ,
+				Outcome: sequence[i].outcome,
+			})
+		}
+	}
+	defer func() {
+		for _, entry := range skipped {
+			report.Entries = append(report.Entries, CloseReportEntry{
+				Type:    entry.typ,
+				Outcome: SkippedNotOwned,
+			})
+		}
+		report.Elapsed = time.Since(start)
+	}()
+	if len(sequences) == 0 {
+		return report
+	}
+
+	// Closers receive a context that is cancelled when Close returns so that cooperative closers
+	// stop even if Close gave up on them before ctx was done.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tracker := closeTracker{
+		abandoned: abandoned,
+		pending:   make(map[*closeJob]time.Time),
+		finished:  make(map[*closeJob]struct{}),
+		handles:   make(map[*closeJob]uint64),
+	}
+
+	results := make(chan closeResult, len(report.Entries))
+
+	queue := make(chan []closeJob, len(sequences))
+	for _, sequence := range sequences {
+		queue <- sequence
+	}
+	close(queue)
+
+	workers := len(sequences)
+	if options.concurrency > 0 && options.concurrency < workers {
+		workers = options.concurrency
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	go func() {
+		// Every job sends exactly one result before its worker calls wg.Done so once the wait is
+		// over the channel holds every remaining result and can be closed to mark the end of them.
+		wg.Wait()
+		close(results)
+	}()
+
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for sequence := range queue {
+				for i := range sequence {
+					job := &sequence[i]
+					// Jobs are started even when ctx is done since Close runs only once and a
+					// value that is never closed would leak; ctx only limits how long Close
+					// waits for them.
+					if !tracker.start(job) {
+						results <- closeResult{job: job}
+						continue
+					}
+					result := runCloseJob(ctx, job, &tracker, options.perCloserTimeout)
+					if options.failFast && result.err != nil {
+						// Stop other jobs from starting before Close receives the result.
+						tracker.stop()
+					}
+					results <- result
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return report
+			}
+			report.record(result)
+			if options.failFast && result.err != nil {
+				report.halt(&tracker, sequences, drainResults(results))
+				return report
+			}
+		case <-ctx.Done():
+			// The deadline may have passed while results were waiting to be received so keep every
+			// result that is available without waiting on the closers that haven't finished.
+			for _, result := range drainResults(results) {
+				report.record(result)
+			}
+			unfinished, running := tracker.giveUp(sequences)
+			if len(unfinished) == 0 {
+				return report
+			}
+			report.DeadlineExceeded = true
+			if alreadyDone != nil {
+				report.errs = append(report.errs, CloseContextAlreadyDone{
+					Err: alreadyDone,
+				})
+			}
+			types := make([]reflect.Type, 0, len(unfinished))
+			for _, job := range unfinished {
+				types = append(types, job.typ)
+				report.Entries[job.index].Outcome = AbandonedAtDeadline
+				report.Entries[job.index].Duration = running[job]
+			}
+			report.errs = append(report.errs, IncompleteClose{
+				Types: types,
+				Err:   ctx.Err(),
+			})
+			return report
+		}
+	}
+}
+
+// halt stops closing after a failure: it records the results that are available, abandons the
+// jobs that are running, and records the jobs that were never started as not attempted.
+func (report *CloseReport) halt(tracker *closeTracker, sequences [][]closeJob, available []closeResult) {
+	for _, result := range available {
+		report.record(result)
+	}
+	unfinished, running := tracker.giveUp(sequences)
+	report.Halted = true
+	halted := CloseHalted{
+		NotAttempted: []reflect.Type{},
+		Abandoned:    []reflect.Type{},
+	}
+	for _, job := range unfinished {
+		entry := &report.Entries[job.index]
+		if elapsed, ok := running[job]; ok {
+			entry.Outcome = AbandonedAtDeadline
+			entry.Duration = elapsed
+			halted.Abandoned = append(halted.Abandoned, job.typ)
+			continue
+		}
+		entry.Outcome = NotAttempted
+		halted.NotAttempted = append(halted.NotAttempted, job.typ)
+	}
+	report.errs = append(report.errs, halted)
+}
+
+// runCloseJob runs a job and returns its result. When timeout is positive the job is given a
+// context that expires after timeout and is abandoned if it has not finished by then, allowing
+// the rest of the jobs in its sequence to run.
+func runCloseJob(
+	ctx context.Context,
+	job *closeJob,
+	tracker *closeTracker,
+	timeout time.Duration,
+) closeResult {
+
+	start := time.Now()
+	result := closeResult{
+		job:     job,
+		started: true,
+	}
+
+	if timeout <= 0 {
+		result.err = job.run(ctx)
+		tracker.finish(job)
+		result.elapsed = time.Since(start)
+		return result
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		err := job.run(jobCtx)
+		tracker.finish(job)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		result.elapsed = time.Since(start)
+		result.err = err
+		if result.elapsed > timeout {
+			result.err = CloserTimeout{
+				Type:    job.typ,
+				Timeout: timeout,
+				Elapsed: result.elapsed,
+				Err:     err,
+			}
+		}
+		return result
+	case <-jobCtx.Done():
+		if ctx.Err() != nil {
+			// The deadline for the whole call to Close has passed so the job's result is only
+			// useful if it arrives before Close gives up on it.
+			result.err = <-done
+			result.elapsed = time.Since(start)
+			return result
+		}
+		tracker.abandon(job)
+		result.elapsed = time.Since(start)
+		result.abandoned = true
+		result.err = CloserTimeout{
+			Type:    job.typ,
+			Timeout: timeout,
+			Elapsed: result.elapsed,
+		}
+		return result
+	}
+}
+
+// drainResults receives the results available on ch without blocking.
+func drainResults(ch <-chan closeResult) []closeResult {
+	results := []closeResult{}
+	for {
+		select {
+		case result, ok := <-ch:
+			if !ok {
+				return results
+			}
+			results = append(results, result)
+		default:
+			return results
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package di
+
+// WithCloseErrorHandler sets a function to call with the [CloseReport] of each close of a [Scope]
+// created from the provider, or of the provider itself, that ends with errors no caller will
+// handle: the automatic closes of scopes created with [RootProvider.NewScopeWithContext], the
+// evictions of a [ScopeCache] without an eviction error handler, the scopes of [RunJobs] without
+// an error handler, and any call to Close given [DelegateErrors]. scopeID is the [Scope.ID] of the
+// scope, or "root" for the provider. The handler is called at most once per close, after the close
+// has finished, so it is never called concurrently for the same scope. A panic in the handler is
+// recovered and reported to the provider's [Observer] as a [CloseErrorHandlerPanicked].
+// WithCloseErrorHandler can only be given once.
+func WithCloseErrorHandler(handler func(scopeID string, report CloseReport)) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithCloseErrorHandler") {
+			options.conflict("given more than one close error handler", "WithCloseErrorHandler")
+		}
+		options.closeErrorHandler = handler
+	}
+}
+
+// DelegateErrors makes Close pass its report to the handler given to [WithCloseErrorHandler] if
+// it ends with errors, e.g. for a call site that would otherwise only log them. The errors are
+// still returned. DelegateErrors has no effect if the provider has no close error handler.
+func DelegateErrors() CloseOption {
+	return func(options *closeOptions) {
+		options.delegateErrors = true
+	}
+}
+
+// withoutDelegation undoes DelegateErrors for the close of a forked scope's provider, whose report
+// is delegated as part of the scope's.
+func withoutDelegation(options *closeOptions) {
+	options.delegateErrors = false
+}
+
+// A CloseErrorHandlerPanicked is an [Event] indicating that the handler given to
+// [WithCloseErrorHandler] panicked.
+type CloseErrorHandlerPanicked struct {
+
+	// ScopeID is the ID the handler was called with.
+	ScopeID string
+
+	// Value is the value the handler panicked with.
+	Value any
+}
+
+func (CloseErrorHandlerPanicked) event() {}
+
+// handleCloseErrors passes report to the provider's close error handler if the close was
+// delegated and ended with errors.
+func (provider RootProvider) handleCloseErrors(scopeID string, options closeOptions, report CloseReport) {
+	handler := provider.options.closeErrorHandler
+	if handler == nil || !options.delegateErrors || len(report.Errors()) == 0 {
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			provider.observe(CloseErrorHandlerPanicked{
+				ScopeID: scopeID,
+				Value:   v,
+			})
+		}
+	}()
+	handler(scopeID, report)
+}
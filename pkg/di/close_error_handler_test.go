@@ -0,0 +1,208 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type handledClose struct {
+	ScopeID string
+	Report  CloseReport
+}
+
+type closeHandlerRecorder struct {
+	mu     sync.Mutex
+	closes []handledClose
+	called chan struct{}
+}
+
+func newCloseHandlerRecorder() *closeHandlerRecorder {
+	return &closeHandlerRecorder{
+		called: make(chan struct{}, 16),
+	}
+}
+
+func (rec *closeHandlerRecorder) handle(scopeID string, report CloseReport) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.closes = append(rec.closes, handledClose{
+		ScopeID: scopeID,
+		Report:  report,
+	})
+	rec.called <- struct{}{}
+}
+
+func (rec *closeHandlerRecorder) handled() []handledClose {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]handledClose{}, rec.closes...)
+}
+
+func TestWithCloseErrorHandler(t *testing.T) {
+
+	closeErr := errors.New("close failed")
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterFactory[*errorCloser](Registry{}, Scoped, func(Resolver) (*errorCloser, error) {
+			return &errorCloser{err: closeErr}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*errorContextCloser](registry, Singleton, func(Resolver) (*errorContextCloser, error) {
+			return &errorContextCloser{err: closeErr}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	newFailingScope := func(t *testing.T, provider RootProvider) Scope {
+		scope := provider.NewScope()
+		if _, err := Resolve[*errorCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		return scope
+	}
+
+	t.Run("is not called for explicit closes", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		if errs := newFailingScope(t, provider).Close(context.Background()); len(errs) != 1 {
+			t.Fatalf("expected 1 error from Close; got %v", errs)
+		}
+		if handled := rec.handled(); len(handled) != 0 {
+			t.Fatalf("expected the handler not to be called; got %v", handled)
+		}
+	})
+
+	t.Run("is called once for delegated closes with errors", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		scope := newFailingScope(t, provider)
+		for range 2 {
+			scope.Close(context.Background(), DelegateErrors())
+		}
+		if errs := provider.NewScope().Close(context.Background(), DelegateErrors()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		handled := rec.handled()
+		if len(handled) != 1 {
+			t.Fatalf("expected the handler to be called once; got %v", handled)
+		}
+		if handled[0].ScopeID != scope.ID() {
+			t.Errorf("expected scope ID %s; got %s", scope.ID(), handled[0].ScopeID)
+		}
+		if errs := handled[0].Report.Errors(); len(errs) != 1 || !errors.Is(errs[0], closeErr) {
+			t.Errorf("expected the report to hold %v; got %v", closeErr, errs)
+		}
+	})
+
+	t.Run("still returns the errors of delegated closes", func(t *testing.T) {
+		provider := newProvider(t, WithCloseErrorHandler(newCloseHandlerRecorder().handle))
+		if errs := newFailingScope(t, provider).Close(context.Background(), DelegateErrors()); len(errs) != 1 {
+			t.Fatalf("expected 1 error from Close; got %v", errs)
+		}
+	})
+
+	t.Run("reports the provider as root", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		if _, err := Resolve[*errorContextCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		provider.Close(context.Background(), DelegateErrors())
+		if handled := rec.handled(); len(handled) != 1 || handled[0].ScopeID != "root" {
+			t.Fatalf("expected the handler to be called for root; got %v", handled)
+		}
+	})
+
+	t.Run("is called once for a forked scope", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		forked, err := provider.NewScope().Fork()
+		if err != nil {
+			t.Fatalf("unexpected error from Fork: %v", err)
+		}
+		if _, err := Resolve[*errorCloser](forked); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		forked.Close(context.Background(), DelegateErrors())
+		if handled := rec.handled(); len(handled) != 1 || handled[0].ScopeID != forked.ID() {
+			t.Fatalf("expected the handler to be called once for %s; got %v", forked.ID(), handled)
+		}
+	})
+
+	t.Run("is called for scopes closed automatically", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		ctx, cancel := context.WithCancel(context.Background())
+		scope := provider.NewScopeWithContext(ctx, time.Second)
+		if _, err := Resolve[*errorCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		cancel()
+		select {
+		case <-rec.called:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the handler to be called")
+		}
+		if handled := rec.handled(); handled[0].ScopeID != scope.ID() {
+			t.Errorf("expected scope ID %s; got %s", scope.ID(), handled[0].ScopeID)
+		}
+	})
+
+	t.Run("is called for job scopes without an error handler", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider := newProvider(t, WithCloseErrorHandler(rec.handle))
+		jobs := make(chan int, 1)
+		jobs <- 1
+		close(jobs)
+		err := RunJobs(context.Background(), provider, jobs, 1, func(_ context.Context, scope Scope, _ int) error {
+			_, err := Resolve[*errorCloser](scope)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if handled := rec.handled(); len(handled) != 1 {
+			t.Fatalf("expected the handler to be called once; got %v", handled)
+		}
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		events := []CloseErrorHandlerPanicked{}
+		provider := newProvider(t,
+			WithCloseErrorHandler(func(string, CloseReport) {
+				panic("handler failed")
+			}),
+			WithObserver(ObserverFunc(func(event Event) {
+				if event, ok := event.(CloseErrorHandlerPanicked); ok {
+					events = append(events, event)
+				}
+			})))
+		scope := newFailingScope(t, provider)
+		if errs := scope.Close(context.Background(), DelegateErrors()); len(errs) != 1 {
+			t.Fatalf("expected 1 error from Close; got %v", errs)
+		}
+		if len(events) != 1 || events[0].ScopeID != scope.ID() || events[0].Value != "handler failed" {
+			t.Fatalf("expected a CloseErrorHandlerPanicked for %s; got %v", scope.ID(), events)
+		}
+	})
+
+	t.Run("conflicts when given twice", func(t *testing.T) {
+		handler := func(string, CloseReport) {}
+		_, err := Registry{}.BuildRootProvider(WithCloseErrorHandler(handler), WithCloseErrorHandler(handler))
+		if !errors.Is(err, ErrConflictingOptions) {
+			t.Fatalf("expected %v to be %v", err, ErrConflictingOptions)
+		}
+	})
+}
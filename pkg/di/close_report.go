@@ -0,0 +1,158 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A CloseOutcome describes how Close handled a single value or deferred cleanup.
+type CloseOutcome int
+
+const (
+
+	// ClosedByContextCloser indicates that a value was closed using [ContextCloser].
+	ClosedByContextCloser CloseOutcome = iota
+
+	// ClosedByCloser indicates that a value was closed using [Closer].
+	ClosedByCloser
+
+	// CleanupRan indicates that a cleanup deferred with [Scope.Defer] or [RootProvider.Defer] was
+	// run.
+	CleanupRan
+
+	// SkippedNotOwned indicates that a value implementing [ContextCloser] or [Closer] was not
+	// closed because the provider does not own it; see [WithoutOwnership].
+	SkippedNotOwned
+
+	// AbandonedAtDeadline indicates that Close gave up on a closer or cleanup, either because it
+	// did not finish before the context passed to Close was done, in which case it may not have
+	// started yet and will run after Close returns, because it ran past the timeout given by
+	// [WithPerCloserTimeout], or because it was still running when Close halted after an error;
+	// see [FailFast].
+	AbandonedAtDeadline
+
+	// NotAttempted indicates that a closer or cleanup was never started because Close halted
+	// after an error; see [FailFast].
+	NotAttempted
+)
+
+// String implements [fmt.Stringer].
+func (outcome CloseOutcome) String() string {
+	switch outcome {
+	case ClosedByContextCloser:
+		return "ContextCloser"
+	case ClosedByCloser:
+		return "Closer"
+	case CleanupRan:
+		return "cleanup"
+	case SkippedNotOwned:
+		return "skipped (not owned)"
+	case AbandonedAtDeadline:
+		return "abandoned"
+	case NotAttempted:
+		return "not attempted"
+	}
+	return fmt.Sprintf("CloseOutcome(%d)", int(outcome))
+}
+
+// A CloseReportEntry describes how Close handled a single value or deferred cleanup.
+type CloseReportEntry struct {
+
+	// Type is the registered type of the value, or of the value whose factory deferred the
+	// cleanup. Type is nil for cleanups deferred outside of a factory.
+	Type reflect.Type
+
+	// Outcome is how the value or cleanup was handled.
+	Outcome CloseOutcome
+
+	// Duration is how long the closer or cleanup ran, or had been running when Close gave up on
+	// it.
+	Duration time.Duration
+
+	// Err is the error from closing the value or running the cleanup, if any.
+	Err error
+}
+
+// A CloseReport describes the result of closing a [Scope] or [RootProvider]; see
+// [Scope.CloseReport] and [RootProvider.CloseReport].
+type CloseReport struct {
+
+	// Entries describe each value and deferred cleanup, in the order they were started: the
+	// values in the reverse of the order they were created followed by the cleanups, with the
+	// values that were skipped at the end.
+	Entries []CloseReportEntry
+
+	// Elapsed is how long closing took.
+	Elapsed time.Duration
+
+	// DeadlineExceeded indicates that the context passed to Close was done before every value
+	// had been closed.
+	DeadlineExceeded bool
+
+	// Halted indicates that Close stopped at the first error because it was given [FailFast].
+	Halted bool
+
+	// errs are the errors Close returns.
+	errs []error
+}
+
+// Errors returns the errors that Close returns for the same call, including an [IncompleteClose]
+// when the deadline was exceeded and an [OnClosePanic] for each callback registered with OnClose
+// that panicked, and a [CloseHalted] when Close was given [FailFast] and stopped at an error.
+func (report CloseReport) Errors() []error {
+	return report.errs
+}
+
+// String implements [fmt.Stringer] by formatting the report with one line per entry for logging.
+func (report CloseReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "closed %d entries in %v", len(report.Entries), report.Elapsed)
+	if report.DeadlineExceeded {
+		b.WriteString(" (deadline exceeded)")
+	}
+	if report.Halted {
+		b.WriteString(" (halted)")
+	}
+	if n := len(report.errs); n > 0 {
+		fmt.Fprintf(&b, " with %d error(s)", n)
+	}
+	for _, entry := range report.Entries {
+		fmt.Fprintf(&b, "\n  %v: %v", entry.Type, entry.Outcome)
+		if entry.Outcome != SkippedNotOwned && entry.Outcome != NotAttempted {
+			fmt.Fprintf(&b, " after %v", entry.Duration)
+		}
+		if entry.Err != nil {
+			fmt.Fprintf(&b, ": %v", entry.Err)
+		}
+	}
+	return b.String()
+}
+
+// merge returns the report with the entries and errors from other appended, e.g. for a scope
+// that closes its own provider.
+func (report CloseReport) merge(other CloseReport) CloseReport {
+	report.Entries = append(report.Entries, other.Entries...)
+	report.Elapsed += other.Elapsed
+	report.DeadlineExceeded = report.DeadlineExceeded || other.DeadlineExceeded
+	report.Halted = report.Halted || other.Halted
+	report.errs = append(report.errs, other.errs...)
+	return report
+}
+
+// record updates the report with the result of a job.
+func (report *CloseReport) record(result closeResult) {
+	if !result.started {
+		return
+	}
+	entry := &report.Entries[result.job.index]
+	entry.Duration = result.elapsed
+	entry.Err = result.err
+	if result.abandoned {
+		entry.Outcome = AbandonedAtDeadline
+	}
+	if result.err != nil {
+		report.errs = append(report.errs, result.err)
+	}
+}
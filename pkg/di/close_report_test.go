@@ -0,0 +1,196 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseReport(t *testing.T) {
+
+	expected := errors.New("expected error")
+
+	newProvider := func(t *testing.T, lifetime Lifetime) RootProvider {
+		registry, err := RegisterType[*mockContextCloser, *mockContextCloser](Registry{}, lifetime)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*errorCloser](registry, lifetime, func(Resolver) (*errorCloser, error) {
+			return &errorCloser{err: expected}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, lifetime, WithoutOwnership())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*blockingContextCloser](registry, lifetime, func(Resolver) (*blockingContextCloser, error) {
+			return &blockingContextCloser{blockTime: time.Second}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	resolve := func(t *testing.T, resolver Resolver) {
+		if _, err := Resolve[*mockContextCloser](resolver); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := Resolve[*errorCloser](resolver); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := Resolve[*mockCloser](resolver); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+	}
+
+	t.Run("Scope", func(t *testing.T) {
+
+		t.Run("describes how each value and cleanup was closed", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			resolve(t, scope)
+			scope.Defer(func(context.Context) error {
+				return nil
+			})
+			report := scope.CloseReport(context.Background())
+			outcomes := map[reflect.Type]CloseOutcome{}
+			for _, entry := range report.Entries {
+				outcomes[entry.Type] = entry.Outcome
+			}
+			expectedOutcomes := map[reflect.Type]CloseOutcome{
+				reflect.TypeFor[*mockContextCloser](): ClosedByContextCloser,
+				reflect.TypeFor[*errorCloser]():       ClosedByCloser,
+				reflect.TypeFor[*mockCloser]():        SkippedNotOwned,
+				nil:                                   CleanupRan,
+			}
+			if !reflect.DeepEqual(outcomes, expectedOutcomes) {
+				t.Fatalf("expected outcomes %v; got %v", expectedOutcomes, outcomes)
+			}
+			if report.DeadlineExceeded {
+				t.Fatalf("expected the deadline not to be exceeded")
+			}
+			errs := report.Errors()
+			if len(errs) != 1 || !errors.Is(errs[0], expected) {
+				t.Fatalf("expected only %v; got %v", expected, errs)
+			}
+			for _, entry := range report.Entries {
+				if entry.Type == reflect.TypeFor[*errorCloser]() && !errors.Is(entry.Err, expected) {
+					t.Fatalf("expected the entry for %v to have error %v; got %v", entry.Type, expected, entry.Err)
+				}
+			}
+			for _, line := range []string{
+				"closed 4 entries in ",
+				"with 1 error(s)",
+				"*di.errorCloser: Closer after ",
+				"*di.mockCloser: skipped (not owned)\n",
+			} {
+				if !strings.Contains(report.String()+"\n", line) {
+					t.Fatalf("expected the report to contain %q; got:\n%v", line, report)
+				}
+			}
+		})
+
+		t.Run("reports closers abandoned at the deadline", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			if _, err := Resolve[*blockingContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			report := scope.CloseReport(ctx)
+			if !report.DeadlineExceeded {
+				t.Fatalf("expected the deadline to be exceeded")
+			}
+			if len(report.Entries) != 1 || report.Entries[0].Outcome != AbandonedAtDeadline {
+				t.Fatalf("expected the closer to be abandoned; got %v", report)
+			}
+			if report.Entries[0].Duration <= 0 {
+				t.Fatalf("expected the time the closer had been running; got %v", report.Entries[0].Duration)
+			}
+			if errs := report.Errors(); len(errs) != 1 || !errors.Is(errs[0], ErrCloseTimeout) {
+				t.Fatalf("expected an IncompleteClose; got %v", errs)
+			}
+		})
+
+		t.Run("halts at the first error with FailFast", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			// Values are closed in the reverse of the order they were created so the failing closer
+			// runs first.
+			resolve(t, scope)
+			scope.Defer(func(context.Context) error {
+				t.Errorf("expected the cleanup not to run")
+				return nil
+			})
+			report := scope.CloseReport(context.Background(), FailFast(), WithCloseConcurrency(1))
+			if !report.Halted {
+				t.Fatalf("expected the report to be halted")
+			}
+			outcomes := map[reflect.Type]CloseOutcome{}
+			for _, entry := range report.Entries {
+				outcomes[entry.Type] = entry.Outcome
+			}
+			expectedOutcomes := map[reflect.Type]CloseOutcome{
+				reflect.TypeFor[*mockContextCloser](): NotAttempted,
+				reflect.TypeFor[*errorCloser]():       ClosedByCloser,
+				reflect.TypeFor[*mockCloser]():        SkippedNotOwned,
+				nil:                                   NotAttempted,
+			}
+			if !reflect.DeepEqual(outcomes, expectedOutcomes) {
+				t.Fatalf("expected outcomes %v; got %v", expectedOutcomes, outcomes)
+			}
+			errs := report.Errors()
+			if len(errs) != 2 || !errors.Is(errs[0], expected) {
+				t.Fatalf("expected %v and a CloseHalted; got %v", expected, errs)
+			}
+			halted := CloseHalted{}
+			if !errors.As(errs[1], &halted) {
+				t.Fatalf("expected a CloseHalted; got %v", errs[1])
+			}
+			expectedTypes := []reflect.Type{reflect.TypeFor[*mockContextCloser](), nil}
+			if !reflect.DeepEqual(halted.NotAttempted, expectedTypes) || len(halted.Abandoned) != 0 {
+				t.Fatalf("expected %v not to be attempted; got %v", expectedTypes, halted)
+			}
+			if !strings.Contains(report.String(), "(halted)") {
+				t.Fatalf("expected the report to be marked halted; got:\n%v", report)
+			}
+			if report := scope.CloseReport(context.Background()); len(report.Entries) != 0 {
+				t.Fatalf("expected the scope to be closed; got %v", report)
+			}
+		})
+
+		t.Run("returns an empty report after the first call", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			resolve(t, scope)
+			if errs := scope.Close(context.Background()); len(errs) != 1 {
+				t.Fatalf("expected 1 error from Close; got %v", errs)
+			}
+			if report := scope.CloseReport(context.Background()); len(report.Entries) != 0 || len(report.Errors()) != 0 {
+				t.Fatalf("expected an empty report; got %v", report)
+			}
+		})
+	})
+
+	t.Run("RootProvider", func(t *testing.T) {
+
+		t.Run("describes how each value was closed", func(t *testing.T) {
+			provider := newProvider(t, Singleton)
+			resolve(t, provider)
+			report := provider.CloseReport(context.Background())
+			if len(report.Entries) != 3 {
+				t.Fatalf("expected 3 entries; got %v", report)
+			}
+			if errs := report.Errors(); len(errs) != 1 || !errors.Is(errs[0], expected) {
+				t.Fatalf("expected only %v; got %v", expected, errs)
+			}
+		})
+	})
+}
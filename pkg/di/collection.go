@@ -0,0 +1,117 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WithEmptyCollections makes the provider resolve a slice or map type that is neither registered
+// nor has any registrations for its elements as an empty collection rather than failing with an
+// [UnknownType]; see [RootProvider.Resolve].
+func WithEmptyCollections() ProviderOption {
+	return func(options *providerOptions) {
+		options.emptyCollections = true
+	}
+}
+
+// ErrDuplicateKeyName is returned when a map is resolved from keys that have the same name.
+var ErrDuplicateKeyName = errors.New("more than one key has the same name")
+
+// A DuplicateKeyName is an [error] indicating that a map could not be resolved from the keyed
+// registrations for its element type because more than one [Key] has the same name. Calling
+// [errors.Is] with a DuplicateKeyName and [ErrDuplicateKeyName] returns true.
+type DuplicateKeyName struct {
+
+	// Type is the requested map type.
+	Type reflect.Type
+
+	// Name is the name shared by the keys.
+	Name string
+}
+
+// Error implements [error].
+func (err DuplicateKeyName) Error() string {
+	return fmt.Sprintf("cannot resolve %v: more than one key is named %q", err.Type, err.Name)
+}
+
+// Is indicates that a [DuplicateKeyName] is [ErrDuplicateKeyName].
+func (DuplicateKeyName) Is(target error) bool {
+	return target == ErrDuplicateKeyName
+}
+
+// Code returns "duplicate_key_name", the code of a [DuplicateKeyName] in [ErrorCodes].
+func (DuplicateKeyName) Code() string {
+	return "duplicate_key_name"
+}
+
+// resolveCollection resolves typ as a collection of the registrations for its element type if it
+// is a slice or a map with string keys, and reports whether it was. A slice holds an instance
+// from each registration for its element type, as [ResolveAll] returns them, and a map holds an
+// instance from each [Key] for its element type under the key's name. Each element is resolved
+// by resolver, so it has the lifetime of its own registration.
+func (provider RootProvider) resolveCollection(resolver Resolver, typ reflect.Type) (any, bool, error) {
+	switch {
+	case typ.Kind() == reflect.Slice:
+		types := registeredFor(provider.registrations.load(), typ.Elem())
+		if len(types) == 0 && !provider.options.emptyCollections {
+			return nil, false, nil
+		}
+		slice := reflect.MakeSlice(typ, 0, len(types))
+		for _, registered := range types {
+			value, err := resolver.Resolve(registered)
+			if err != nil {
+				return nil, false, err
+			}
+			if value == nil {
+				return nil, false, NilResolution{
+					Type: registered,
+				}
+			}
+			slice = reflect.Append(slice, reflect.ValueOf(value))
+		}
+		return slice.Interface(), true, nil
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		types := []reflect.Type{}
+		for _, registered := range registeredFor(provider.registrations.load(), typ.Elem()) {
+			if isKeyType(registered) {
+				types = append(types, registered)
+			}
+		}
+		if len(types) == 0 && !provider.options.emptyCollections {
+			return nil, false, nil
+		}
+		names := map[string]bool{}
+		for _, registered := range types {
+			name := keyName(registered)
+			if names[name] {
+				return nil, false, DuplicateKeyName{
+					Type: typ,
+					Name: name,
+				}
+			}
+			names[name] = true
+		}
+		collection := reflect.MakeMapWithSize(typ, len(types))
+		for _, registered := range types {
+			value, err := resolver.Resolve(registered)
+			if err != nil {
+				return nil, false, err
+			}
+			if value == nil {
+				return nil, false, NilResolution{
+					Type: registered,
+				}
+			}
+			name := reflect.ValueOf(keyName(registered)).Convert(typ.Key())
+			collection.SetMapIndex(name, reflect.ValueOf(value))
+		}
+		return collection.Interface(), true, nil
+	}
+	return nil, false, nil
+}
+
+// keyName returns the name of the key identified by typ.
+func keyName(typ reflect.Type) string {
+	return typ.Field(0).Tag.Get("name")
+}
@@ -0,0 +1,154 @@
+package di
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+type collectionChecker interface {
+	Check() string
+}
+
+type namedChecker struct {
+	name string
+}
+
+func (checker *namedChecker) Check() string {
+	return checker.name
+}
+
+func TestResolveCollections(t *testing.T) {
+
+	database := NewKey[collectionChecker]("database")
+	cache := NewKey[collectionChecker]("cache")
+
+	registerChecker := func(t *testing.T, registry Registry, key Key[collectionChecker], lifetime Lifetime) Registry {
+		registry, err := RegisterKeyFactory(registry, key, lifetime, func(Resolver) (*namedChecker, error) {
+			return &namedChecker{name: key.Name()}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterKeyFactory: %v", err)
+		}
+		return registry
+	}
+
+	newRegistry := func(t *testing.T) Registry {
+		registry := registerChecker(t, Registry{}, database, Singleton)
+		return registerChecker(t, registry, cache, Scoped)
+	}
+
+	build := func(t *testing.T, registry Registry, opts ...ProviderOption) RootProvider {
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("resolves a slice of the registrations for its element type", func(t *testing.T) {
+		scope := build(t, newRegistry(t)).NewScope()
+		checkers, err := Resolve[[]collectionChecker](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		names := []string{}
+		for _, checker := range checkers {
+			names = append(names, checker.Check())
+		}
+		if expected := []string{"database", "cache"}; !slices.Equal(names, expected) {
+			t.Errorf("expected %v; got %v", expected, names)
+		}
+	})
+
+	t.Run("resolves a map of the keyed registrations by name", func(t *testing.T) {
+		scope := build(t, newRegistry(t)).NewScope()
+		checkers, err := Resolve[map[string]collectionChecker](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		names := slices.Sorted(maps.Keys(checkers))
+		if expected := []string{"cache", "database"}; !slices.Equal(names, expected) {
+			t.Fatalf("expected %v; got %v", expected, names)
+		}
+		for name, checker := range checkers {
+			if checker.Check() != name {
+				t.Errorf("expected %q to hold the %[1]q checker; got %q", name, checker.Check())
+			}
+		}
+	})
+
+	t.Run("elements keep their lifetimes", func(t *testing.T) {
+		provider := build(t, newRegistry(t))
+		first, err := Resolve[map[string]collectionChecker](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		second, err := Resolve[map[string]collectionChecker](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if first["database"] != second["database"] {
+			t.Errorf("expected the singleton to be shared between scopes")
+		}
+		if first["cache"] == second["cache"] {
+			t.Errorf("expected each scope to have its own scoped instance")
+		}
+	})
+
+	t.Run("exact registrations take precedence", func(t *testing.T) {
+		registry, err := RegisterFactory[[]collectionChecker](
+			newRegistry(t),
+			Transient,
+			func(Resolver) ([]collectionChecker, error) {
+				return []collectionChecker{}, nil
+			})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		checkers, err := Resolve[[]collectionChecker](build(t, registry).NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if len(checkers) != 0 {
+			t.Errorf("expected the registered slice; got %v", checkers)
+		}
+	})
+
+	t.Run("fails with an UnknownType when nothing matches", func(t *testing.T) {
+		_, err := Resolve[[]collectionChecker](build(t, Registry{}))
+		if !errors.Is(err, ErrUnknownType) {
+			t.Errorf("expected %q; got %q", ErrUnknownType, err)
+		}
+	})
+
+	t.Run("resolves empty collections WithEmptyCollections", func(t *testing.T) {
+		provider := build(t, Registry{}, WithEmptyCollections())
+		checkers, err := Resolve[[]collectionChecker](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if checkers == nil || len(checkers) != 0 {
+			t.Errorf("expected an empty non-nil slice; got %#v", checkers)
+		}
+		named, err := Resolve[map[string]collectionChecker](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if named == nil || len(named) != 0 {
+			t.Errorf("expected an empty non-nil map; got %#v", named)
+		}
+		if !provider.Settings().EmptyCollections {
+			t.Errorf("expected the settings to include EmptyCollections")
+		}
+	})
+
+	t.Run("fails when keys share a name", func(t *testing.T) {
+		registry := registerChecker(t, newRegistry(t), NewKey[collectionChecker]("cache"), Transient)
+		_, err := Resolve[map[string]collectionChecker](build(t, registry))
+		if !errors.Is(err, ErrDuplicateKeyName) {
+			t.Errorf("expected %q; got %q", ErrDuplicateKeyName, err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// ErrNoScopeInContext is returned when an attempt is made to resolve a value from the [Scope] in a
+// [context.Context] but the context does not carry a scope.
+var ErrNoScopeInContext = errors.New("context does not carry a scope")
+
+// A NoScopeInContext is an [error] indicating that an attempt was made to resolve a value from the
+// [Scope] in a [context.Context] but the context did not carry a scope. Calling [errors.Is] with a
+// NoScopeInContext and [ErrNoScopeInContext] returns true.
+type NoScopeInContext struct {
+
+	// Type is the type that was requested.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NoScopeInContext) Error() string {
+	return fmt.Sprintf("cannot resolve %v: context does not carry a scope", err.Type)
+}
+
+// Is indicates that a [NoScopeInContext] is [ErrNoScopeInContext].
+func (NoScopeInContext) Is(target error) bool {
+	return target == ErrNoScopeInContext
+}
+
+// Code returns "no_scope_in_context", the code of a [NoScopeInContext] in [ErrorCodes].
+func (NoScopeInContext) Code() string {
+	return "no_scope_in_context"
+}
+
+type scopeContextKey struct{}
+
+// NewContext returns a copy of ctx that carries scope. The scope can be retrieved with
+// [FromContext], which returns the scope from the innermost call to NewContext when contexts are
+// nested, e.g. when a child scope is stored in a context derived from one carrying its parent.
+func NewContext(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// FromContext returns the [Scope] carried by ctx, if any; see [NewContext].
+func FromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeContextKey{}).(Scope)
+	return scope, ok
+}
+
+// ResolveFromContext obtains an instance of T from the [Scope] carried by ctx; see [Resolve]. A
+// [NoScopeInContext] is returned if ctx does not carry a scope.
+func ResolveFromContext[T any](ctx context.Context) (T, error) {
+	scope, ok := FromContext(ctx)
+	if !ok {
+		var zero T
+		return zero, NoScopeInContext{
+			Type: reflect.TypeFor[T](),
+		}
+	}
+	return Resolve[T](scope)
+}
+
+// ResolveCtx obtains an instance of T from the [Scope] carried by ctx, or from the default
+// provider if ctx does not carry a scope and one was set with [SetDefaultProvider]; see
+// [Resolve]. The factories of [ContextFactory] registrations are given ctx, including those of
+// dependencies, so that they can observe its cancellation and values. A [NoScopeInContext] is
+// returned if ctx does not carry a scope and there is no default provider.
+func ResolveCtx[T any](ctx context.Context) (T, error) {
+	if scope, ok := FromContext(ctx); ok {
+		scope.ctx = ctx
+		return Resolve[T](scope)
+	}
+	if provider := defaultProvider.Load(); provider != nil {
+		resolver := *provider
+		resolver.ctx = ctx
+		return Resolve[T](resolver)
+	}
+	var zero T
+	return zero, NoScopeInContext{
+		Type: reflect.TypeFor[T](),
+	}
+}
+
+// defaultProvider is the provider set with SetDefaultProvider, if any.
+var defaultProvider atomic.Pointer[RootProvider]
+
+// ErrDefaultProviderSet is returned when the default provider is set more than once.
+var ErrDefaultProviderSet = errors.New("default provider is already set")
+
+// A DefaultProviderSet is an [error] indicating that [SetDefaultProvider] was called after the
+// default provider had been set. Calling [errors.Is] with a DefaultProviderSet and
+// [ErrDefaultProviderSet] returns true.
+type DefaultProviderSet struct{}
+
+// Error implements [error].
+func (DefaultProviderSet) Error() string {
+	return ErrDefaultProviderSet.Error()
+}
+
+// Is indicates that a [DefaultProviderSet] is [ErrDefaultProviderSet].
+func (DefaultProviderSet) Is(target error) bool {
+	return target == ErrDefaultProviderSet
+}
+
+// Code returns "default_provider_set", the code of a [DefaultProviderSet] in [ErrorCodes].
+func (DefaultProviderSet) Code() string {
+	return "default_provider_set"
+}
+
+// SetDefaultProvider makes provider the process-wide provider that [ResolveCtx] resolves from when
+// the context it is given does not carry a scope, e.g. for code that runs outside of any request.
+// There is no default provider unless SetDefaultProvider is called, and it can only be set once;
+// later calls return a [DefaultProviderSet]. It is safe to call SetDefaultProvider concurrently
+// with ResolveCtx.
+func SetDefaultProvider(provider RootProvider) error {
+	if err := provider.checkInitialized("SetDefaultProvider"); err != nil {
+		return err
+	}
+	provider.constructing = nil
+	provider.path = nil
+	provider.ctx = nil
+	if !defaultProvider.CompareAndSwap(nil, &provider) {
+		return DefaultProviderSet{}
+	}
+	return nil
+}
+
+// resolutionContext returns the context given to ResolveCtx for the resolution resolver is part
+// of, or the background context if there is none.
+func resolutionContext(resolver Resolver) context.Context {
+	var ctx context.Context
+	switch resolver := unrestricted(resolver).(type) {
+	case Scope:
+		ctx = resolver.ctx
+	case RootProvider:
+		ctx = resolver.ctx
+	}
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
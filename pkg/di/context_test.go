@@ -0,0 +1,241 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("FromContext returns the scope stored with NewContext", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		actual, ok := FromContext(NewContext(context.Background(), scope))
+		if !ok {
+			t.Fatalf("expected the context to carry a scope")
+		}
+		if actual.ID() != scope.ID() {
+			t.Fatalf("expected scope %s; got %s", scope.ID(), actual.ID())
+		}
+	})
+
+	t.Run("FromContext returns false when there is no scope", func(t *testing.T) {
+		if _, ok := FromContext(context.Background()); ok {
+			t.Fatalf("expected the context not to carry a scope")
+		}
+	})
+
+	t.Run("FromContext returns the innermost scope", func(t *testing.T) {
+		parent := newProvider(t).NewScope()
+		child := parent.NewScope()
+		ctx := NewContext(NewContext(context.Background(), parent), child)
+		actual, ok := FromContext(ctx)
+		if !ok {
+			t.Fatalf("expected the context to carry a scope")
+		}
+		if actual.ID() != child.ID() {
+			t.Fatalf("expected scope %s; got %s", child.ID(), actual.ID())
+		}
+	})
+
+	t.Run("ResolveFromContext resolves from the scope in the context", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		expected, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		actual, err := ResolveFromContext[*mockCloser](NewContext(context.Background(), scope))
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveFromContext: %v", err)
+		}
+		if actual != expected {
+			t.Fatalf("expected the scope's instance")
+		}
+	})
+
+	t.Run("ResolveFromContext returns NoScopeInContext when there is no scope", func(t *testing.T) {
+		_, err := ResolveFromContext[*mockCloser](context.Background())
+		if !errors.Is(err, ErrNoScopeInContext) {
+			t.Fatalf("expected %v to be %v", err, ErrNoScopeInContext)
+		}
+		var noScope NoScopeInContext
+		if !errors.As(err, &noScope) {
+			t.Fatalf("expected %v to be a NoScopeInContext", err)
+		}
+	})
+}
+
+type requestKey struct{}
+
+type requestValue struct {
+	ID string
+}
+
+type requestHandler struct {
+	Value *requestValue
+}
+
+func TestResolveCtx(t *testing.T) {
+
+	newProvider := func(t *testing.T, timeout time.Duration) RootProvider {
+		registry, err := RegisterContextFactory[*requestValue](
+			Registry{},
+			Transient,
+			func(ctx context.Context, _ Resolver) (*requestValue, error) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				id, _ := ctx.Value(requestKey{}).(string)
+				return &requestValue{ID: id}, nil
+			},
+			WithFactoryTimeout(timeout))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterContextFactory: %v", err)
+		}
+		registry, err = RegisterType[*requestHandler, *requestHandler](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	withDefaultProvider := func(t *testing.T, provider RootProvider) {
+		t.Cleanup(func() {
+			defaultProvider.Store(nil)
+		})
+		if err := SetDefaultProvider(provider); err != nil {
+			t.Fatalf("unexpected error from SetDefaultProvider: %v", err)
+		}
+	}
+
+	t.Run("resolves from the innermost scope in the context", func(t *testing.T) {
+		parent := newProvider(t, 0).NewScope()
+		child := parent.NewScope()
+		expected, err := Resolve[*mockCloser](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		ctx := NewContext(NewContext(context.Background(), parent), child)
+		actual, err := ResolveCtx[*mockCloser](ctx)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveCtx: %v", err)
+		}
+		if actual != expected {
+			t.Fatalf("expected the child scope's instance")
+		}
+	})
+
+	t.Run("gives the context to context factories of dependencies", func(t *testing.T) {
+		for _, timeout := range []time.Duration{0, time.Minute} {
+			scope := newProvider(t, timeout).NewScope()
+			ctx := context.WithValue(NewContext(context.Background(), scope), requestKey{}, "r-1")
+			handler, err := ResolveCtx[*requestHandler](ctx)
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveCtx: %v", err)
+			}
+			if handler.Value.ID != "r-1" {
+				t.Errorf("expected the factory with timeout %v to see r-1; got %q", timeout, handler.Value.ID)
+			}
+		}
+	})
+
+	t.Run("gives other resolutions the background context", func(t *testing.T) {
+		scope := newProvider(t, 0).NewScope()
+		ctx := context.WithValue(NewContext(context.Background(), scope), requestKey{}, "r-1")
+		if _, err := ResolveCtx[*requestHandler](ctx); err != nil {
+			t.Fatalf("unexpected error from ResolveCtx: %v", err)
+		}
+		value, err := Resolve[*requestValue](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if value.ID != "" {
+			t.Errorf("expected the factory not to see the earlier context; got %q", value.ID)
+		}
+	})
+
+	t.Run("fails with the context's error when it is cancelled", func(t *testing.T) {
+		scope := newProvider(t, time.Minute).NewScope()
+		ctx, cancel := context.WithCancel(NewContext(context.Background(), scope))
+		cancel()
+		_, err := ResolveCtx[*requestValue](ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %v to be %v", err, context.Canceled)
+		}
+		if errors.Is(err, ErrFactoryTimeout) {
+			t.Fatalf("expected %v not to be %v", err, ErrFactoryTimeout)
+		}
+	})
+
+	t.Run("returns NoScopeInContext without a scope or default provider", func(t *testing.T) {
+		_, err := ResolveCtx[*requestValue](context.Background())
+		if !errors.Is(err, ErrNoScopeInContext) {
+			t.Fatalf("expected %v to be %v", err, ErrNoScopeInContext)
+		}
+	})
+
+	t.Run("falls back to the default provider", func(t *testing.T) {
+		withDefaultProvider(t, newProvider(t, 0))
+		ctx := context.WithValue(context.Background(), requestKey{}, "job-7")
+		value, err := ResolveCtx[*requestValue](ctx)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveCtx: %v", err)
+		}
+		if value.ID != "job-7" {
+			t.Errorf("expected the factory to see job-7; got %q", value.ID)
+		}
+	})
+
+	t.Run("prefers the scope in the context to the default provider", func(t *testing.T) {
+		withDefaultProvider(t, newProvider(t, 0))
+		scope := newProvider(t, 0).NewScope()
+		expected, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		actual, err := ResolveCtx[*mockCloser](NewContext(context.Background(), scope))
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveCtx: %v", err)
+		}
+		if actual != expected {
+			t.Fatalf("expected the scope's instance")
+		}
+	})
+
+	t.Run("SetDefaultProvider can only be called once", func(t *testing.T) {
+		withDefaultProvider(t, newProvider(t, 0))
+		if err := SetDefaultProvider(newProvider(t, 0)); !errors.Is(err, ErrDefaultProviderSet) {
+			t.Fatalf("expected %v to be %v", err, ErrDefaultProviderSet)
+		}
+	})
+
+	t.Run("SetDefaultProvider rejects an uninitialized provider", func(t *testing.T) {
+		if err := SetDefaultProvider(RootProvider{}); !errors.Is(err, ErrUninitializedProvider) {
+			t.Fatalf("expected %v to be %v", err, ErrUninitializedProvider)
+		}
+		if defaultProvider.Load() != nil {
+			t.Fatalf("expected no default provider to be set")
+		}
+	})
+}
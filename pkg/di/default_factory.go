@@ -8,8 +8,20 @@ import (
 // GetDefaultFactory returns the default factory for the requested type, or [ErrNoDefaultFactory]
 // if the type has no default factory.
 func GetDefaultFactory[T any]() (Factory[T], error) {
+	return defaultFactory[T](defaultFactoryOptions{})
+}
+
+// defaultFactoryOptions configures the default factories built by getDefaultFactory.
+type defaultFactoryOptions struct {
+
+	// nilInterfaceFields allows struct factories to leave an interface field nil when the
+	// [Resolver] returns an untyped nil for it.
+	nilInterfaceFields bool
+}
+
+func defaultFactory[T any](options defaultFactoryOptions) (Factory[T], error) {
 	typ := reflect.TypeFor[T]()
-	factory, err := getDefaultFactory(typ)
+	factory, err := getDefaultFactory(typ, options)
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +35,7 @@ func GetDefaultFactory[T any]() (Factory[T], error) {
 	}, nil
 }
 
-func getDefaultFactory(typ reflect.Type) (factoryFunc, error) {
+func getDefaultFactory(typ reflect.Type, options defaultFactoryOptions) (factoryFunc, error) {
 	switch typ.Kind() {
 	case
 		reflect.Bool,
@@ -46,17 +58,18 @@ func getDefaultFactory(typ reflect.Type) (factoryFunc, error) {
 			return reflect.MakeChan(typ, 0).Interface(), nil
 		}, nil
 	case reflect.Struct:
-		return getDefaultStructFactory(typ)
+		return getDefaultStructFactory(typ, options)
 	case reflect.Pointer:
-		return getDefaultPointerFactory(typ)
+		return getDefaultPointerFactory(typ, options)
 	}
 
 	return nil, NoDefaultFactory{
-		Type: typ,
+		Type:        typ,
+		ElementType: typ,
 	}
 }
 
-func getDefaultStructFactory(typ reflect.Type) (factoryFunc, error) {
+func getDefaultStructFactory(typ reflect.Type, options defaultFactoryOptions) (factoryFunc, error) {
 	return func(r Resolver) (any, error) {
 		val := reflect.New(typ)
 		for i := 0; i < typ.NumField(); i++ {
@@ -66,13 +79,23 @@ func getDefaultStructFactory(typ reflect.Type) (factoryFunc, error) {
 			}
 			resolved, err := r.Resolve(field.Type)
 			if err != nil {
+				if skipUnresolvedField(r, typ, field, err) {
+					continue
+				}
 				return nil, resolverError{wrapped: err}
 			}
-			resolvedType := reflect.TypeOf(resolved)
-			if resolvedType == nil || !resolvedType.AssignableTo(field.Type) {
+			if resolved == nil {
+				if options.nilInterfaceFields && field.Type.Kind() == reflect.Interface {
+					continue
+				}
+				return nil, NilResolution{
+					Type: field.Type,
+				}
+			}
+			if resolvedType := reflect.TypeOf(resolved); !resolvedType.AssignableTo(field.Type) {
 				return nil, InvalidResolution{
 					Requested: field.Type,
-					Returned:  reflect.TypeOf(resolved),
+					Returned:  resolvedType,
 				}
 			}
 			val.Elem().Field(i).Set(reflect.ValueOf(resolved))
@@ -81,11 +104,13 @@ func getDefaultStructFactory(typ reflect.Type) (factoryFunc, error) {
 	}, nil
 }
 
-func getDefaultPointerFactory(typ reflect.Type) (factoryFunc, error) {
-	elemFactory, err := getDefaultFactory(typ.Elem())
-	if errors.Is(err, ErrNoDefaultFactory) {
+func getDefaultPointerFactory(typ reflect.Type, options defaultFactoryOptions) (factoryFunc, error) {
+	elemFactory, err := getDefaultFactory(typ.Elem(), options)
+	var noDefaultFactory NoDefaultFactory
+	if errors.As(err, &noDefaultFactory) {
 		return nil, NoDefaultFactory{
-			Type: typ,
+			Type:        typ,
+			ElementType: noDefaultFactory.ElementType,
 		}
 	}
 	if err != nil {
@@ -77,6 +77,35 @@ func Test_getDefaultFactory(t *testing.T) {
 				}
 			})
 		}
+
+		t.Run("returns the innermost type as ElementType", func(t *testing.T) {
+			_, err := GetDefaultFactory[***unsafe.Pointer]()
+			var noDefaultFactory NoDefaultFactory
+			if !errors.As(err, &noDefaultFactory) {
+				t.Fatalf("expected %v to be %T", err, noDefaultFactory)
+			}
+			if typ := reflect.TypeFor[***unsafe.Pointer](); noDefaultFactory.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, noDefaultFactory.Type)
+			}
+			if typ := reflect.TypeFor[unsafe.Pointer](); noDefaultFactory.ElementType != typ {
+				t.Errorf("expected err.ElementType to be %v; got %v", typ, noDefaultFactory.ElementType)
+			}
+			expected := "implementation type ***unsafe.Pointer has no default factory because element type unsafe.Pointer has none"
+			if err.Error() != expected {
+				t.Errorf("expected %q; got %q", expected, err.Error())
+			}
+		})
+
+		t.Run("returns the type itself as ElementType for non-pointers", func(t *testing.T) {
+			_, err := GetDefaultFactory[func()]()
+			var noDefaultFactory NoDefaultFactory
+			if !errors.As(err, &noDefaultFactory) {
+				t.Fatalf("expected %v to be %T", err, noDefaultFactory)
+			}
+			if typ := reflect.TypeFor[func()](); noDefaultFactory.ElementType != typ {
+				t.Errorf("expected err.ElementType to be %v; got %v", typ, noDefaultFactory.ElementType)
+			}
+		})
 	})
 
 	t.Run("default factory for", func(t *testing.T) {
@@ -344,6 +373,71 @@ func Test_getDefaultFactory(t *testing.T) {
 			}
 		})
 
+		t.Run("struct returns NilResolution when the resolver returns nil for a field", func(t *testing.T) {
+			resolver := testResolver{
+				resolutions: map[reflect.Type]testResolverResolution{
+					reflect.TypeFor[fmt.Stringer](): {},
+				},
+			}
+			factory, _ := GetDefaultFactory[optionalStringer]()
+			_, err := factory(resolver)
+			var nilResolution NilResolution
+			if !errors.As(err, &nilResolution) || !errors.Is(err, ErrNilResolution) {
+				t.Fatalf("expected %v to be a NilResolution", err)
+			}
+			if expected := reflect.TypeFor[fmt.Stringer](); nilResolution.Type != expected {
+				t.Fatalf("expected type %v; got %v", expected, nilResolution.Type)
+			}
+		})
+
+		t.Run("struct returns InvalidResolution when the resolver returns a mistyped field", func(t *testing.T) {
+			resolver := testResolver{
+				resolutions: map[reflect.Type]testResolverResolution{
+					reflect.TypeFor[fmt.Stringer](): {
+						val: 42,
+					},
+				},
+			}
+			factory, _ := GetDefaultFactory[optionalStringer]()
+			_, err := factory(resolver)
+			if !errors.Is(err, ErrInvalidResolution) {
+				t.Fatalf("expected %v to be %v", err, ErrInvalidResolution)
+			}
+		})
+
+		t.Run("struct leaves nil interface fields nil when allowed", func(t *testing.T) {
+			resolver := testResolver{
+				resolutions: map[reflect.Type]testResolverResolution{
+					reflect.TypeFor[fmt.Stringer](): {},
+				},
+			}
+			factory, _ := defaultFactory[*optionalStringer](defaultFactoryOptions{
+				nilInterfaceFields: true,
+			})
+			v, err := factory(resolver)
+			if err != nil {
+				t.Fatalf("unexpected error from factory: %v", err)
+			}
+			if v.Stringer != nil {
+				t.Fatalf("expected the field to be nil; got %v", v.Stringer)
+			}
+		})
+
+		t.Run("struct returns NilResolution for nil non-interface fields when nil interface fields are allowed", func(t *testing.T) {
+			resolver := testResolver{
+				resolutions: map[reflect.Type]testResolverResolution{
+					reflect.TypeFor[widget]():  {},
+					reflect.TypeFor[*gadget](): {},
+				},
+			}
+			factory, _ := defaultFactory[thing](defaultFactoryOptions{
+				nilInterfaceFields: true,
+			})
+			if _, err := factory(resolver); !errors.Is(err, ErrNilResolution) {
+				t.Fatalf("expected %v to be %v", err, ErrNilResolution)
+			}
+		})
+
 	})
 }
 
@@ -385,6 +479,10 @@ type unexportedFieldsOnly struct {
 	gadget *gadget
 }
 
+type optionalStringer struct {
+	Stringer fmt.Stringer
+}
+
 type recursiveStruct struct {
 	Thing thing
 }
@@ -0,0 +1,108 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrDeferredCleanup is returned when a cleanup function registered with [Scope.Defer] or
+// [RootProvider.Defer] returns an error.
+var ErrDeferredCleanup = errors.New("deferred cleanup failed")
+
+// A DeferredCleanupError is an [error] indicating that a cleanup function registered with
+// [Scope.Defer] or [RootProvider.Defer] returned an error. Calling [errors.Is] with a
+// DeferredCleanupError and [ErrDeferredCleanup] returns true.
+type DeferredCleanupError struct {
+
+	// Type is the registered type whose factory deferred the cleanup, or nil if the cleanup was
+	// deferred outside of a factory.
+	Type reflect.Type
+
+	// Err is the error returned by the cleanup function.
+	Err error
+}
+
+// Error implements [error].
+func (err DeferredCleanupError) Error() string {
+	if err.Type == nil {
+		return fmt.Sprintf("deferred cleanup failed: %v", err.Err)
+	}
+	return fmt.Sprintf("deferred cleanup for %v failed: %v", err.Type, err.Err)
+}
+
+// Is indicates that a [DeferredCleanupError] is [ErrDeferredCleanup].
+func (DeferredCleanupError) Is(target error) bool {
+	return target == ErrDeferredCleanup
+}
+
+// Unwrap gets the underlying [error] returned by the cleanup function.
+func (err DeferredCleanupError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "deferred_cleanup_error", the code of a [DeferredCleanupError] in [ErrorCodes].
+func (DeferredCleanupError) Code() string {
+	return "deferred_cleanup_error"
+}
+
+type deferredCleanup struct {
+	typ     reflect.Type
+	cleanup func(context.Context) error
+
+	// release is the instance resolved with ResolveReleasable that the cleanup releases, if any.
+	release *releaseTracker
+}
+
+type deferredCleanups struct {
+	mu       sync.Mutex
+	cleanups []deferredCleanup
+}
+
+func (d *deferredCleanups) add(typ reflect.Type, cleanup func(context.Context) error) {
+	if cleanup == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cleanups = append(d.cleanups, deferredCleanup{
+		typ:     typ,
+		cleanup: cleanup,
+	})
+}
+
+// addRelease adds a cleanup that releases an instance resolved with ResolveReleasable in case the
+// caller never releases it.
+func (d *deferredCleanups) addRelease(release *releaseTracker) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cleanups = append(d.cleanups, deferredCleanup{
+		typ:     release.typ,
+		cleanup: release.run,
+		release: release,
+	})
+}
+
+// removeRelease removes the cleanup added by addRelease once the caller has released the instance.
+func (d *deferredCleanups) removeRelease(release *releaseTracker) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cleanups = slices.DeleteFunc(d.cleanups, func(cleanup deferredCleanup) bool {
+		return cleanup.release == release
+	})
+}
+
+// take removes and returns the deferred cleanups in the order they should be run.
+func (d *deferredCleanups) take() []deferredCleanup {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cleanups := make([]deferredCleanup, 0, len(d.cleanups))
+	for i := len(d.cleanups) - 1; i >= 0; i-- {
+		cleanups = append(cleanups, d.cleanups[i])
+	}
+	d.cleanups = nil
+	return cleanups
+}
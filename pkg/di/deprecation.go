@@ -0,0 +1,203 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithDeprecated marks a registration as deprecated with a message describing what to use
+// instead, e.g. "use NewPaymentsClient instead", to retire it gradually. Resolving a deprecated
+// registration still works but is reported to the provider's [Observer] as a
+// [DeprecatedResolved], logged if the provider was built [WithDeprecationLog], and fails with a
+// [DeprecatedResolution] if the provider was built [WithDeprecationErrors]. Verification warns
+// about each registration that depends on a deprecated one with a [DeprecatedDependency].
+func WithDeprecated(message string) RegistrationOption {
+	return func(registration *registration) {
+		registration.deprecated = true
+		registration.deprecation = message
+	}
+}
+
+// WithDeprecationErrors makes resolving a registration marked [WithDeprecated] fail with a
+// [DeprecatedResolution], e.g. in a provider built for tests that must not use deprecated
+// registrations.
+func WithDeprecationErrors() ProviderOption {
+	return func(options *providerOptions) {
+		options.deprecationErrors = true
+	}
+}
+
+// WithDeprecationLog makes the provider log a warning to logger when a registration marked
+// [WithDeprecated] is resolved, with the deprecation message and the resolution path. Each type
+// is logged at most once per interval; an interval of 0 or less logs every resolution. Giving
+// WithDeprecationLog more than once is a conflict.
+func WithDeprecationLog(logger *slog.Logger, interval time.Duration) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithDeprecationLog") {
+			options.conflict("given more than one deprecation log", "WithDeprecationLog")
+		}
+		options.deprecationLog = &deprecationLog{
+			logger:   logger,
+			interval: interval,
+			logged:   map[reflect.Type]time.Time{},
+		}
+	}
+}
+
+// A DeprecatedResolved is an [Event] indicating that a registration marked [WithDeprecated] was
+// resolved.
+type DeprecatedResolved struct {
+
+	// Type is the deprecated registered type.
+	Type reflect.Type
+
+	// Message is the message given to WithDeprecated.
+	Message string
+
+	// Path holds the types being constructed when Type was requested, starting with the type
+	// requested from the provider, if the provider was built [WithProvenance]. Otherwise it holds
+	// only the type whose factory requested Type. Path is empty when Type was requested directly.
+	Path []reflect.Type
+}
+
+func (DeprecatedResolved) event() {}
+
+// ErrDeprecated is returned when a registration marked [WithDeprecated] is resolved by a provider
+// built [WithDeprecationErrors].
+var ErrDeprecated = errors.New("registration is deprecated")
+
+// A DeprecatedResolution is an [error] indicating that a registration marked [WithDeprecated] was
+// resolved by a provider built [WithDeprecationErrors]. Calling [errors.Is] with a
+// DeprecatedResolution and [ErrDeprecated] returns true.
+type DeprecatedResolution struct {
+
+	// Type is the deprecated registered type.
+	Type reflect.Type
+
+	// Message is the message given to WithDeprecated.
+	Message string
+}
+
+// Error implements [error].
+func (err DeprecatedResolution) Error() string {
+	return fmt.Sprintf("%v is deprecated: %s", err.Type, err.Message)
+}
+
+// Is indicates that a [DeprecatedResolution] is [ErrDeprecated].
+func (DeprecatedResolution) Is(target error) bool {
+	return target == ErrDeprecated
+}
+
+// Code returns "deprecated_resolution", the code of a [DeprecatedResolution] in [ErrorCodes].
+func (DeprecatedResolution) Code() string {
+	return "deprecated_resolution"
+}
+
+// ErrDeprecatedDependency is returned when verification finds a registration that depends on a
+// registration marked [WithDeprecated].
+var ErrDeprecatedDependency = errors.New("dependency is deprecated")
+
+// A DeprecatedDependency is an [error] indicating that verification found a registration that
+// depends on a registration marked [WithDeprecated]. It is reported as a warning. Calling
+// [errors.Is] with a DeprecatedDependency and [ErrDeprecatedDependency] returns true.
+type DeprecatedDependency struct {
+
+	// Type is the registered type with the deprecated dependency.
+	Type reflect.Type
+
+	// Dependency is the deprecated type.
+	Dependency reflect.Type
+
+	// Message is the message given to WithDeprecated.
+	Message string
+}
+
+// Error implements [error].
+func (err DeprecatedDependency) Error() string {
+	return fmt.Sprintf("%v depends on %v which is deprecated: %s", err.Type, err.Dependency, err.Message)
+}
+
+// Is indicates that a [DeprecatedDependency] is [ErrDeprecatedDependency].
+func (DeprecatedDependency) Is(target error) bool {
+	return target == ErrDeprecatedDependency
+}
+
+// Code returns "deprecated_dependency", the code of a [DeprecatedDependency] in [ErrorCodes].
+func (DeprecatedDependency) Code() string {
+	return "deprecated_dependency"
+}
+
+// findDeprecatedDependencies returns a DeprecatedDependency for each known dependency on a
+// deprecated registration.
+func findDeprecatedDependencies(registrations map[reflect.Type]registration) []error {
+	warnings := []error{}
+	for _, typ := range sortedTypes(registrations) {
+		for _, dependency := range registrations[typ].dependencies {
+			if registration, ok := registrations[dependency]; ok && registration.deprecated {
+				warnings = append(warnings, DeprecatedDependency{
+					Type:       typ,
+					Dependency: dependency,
+					Message:    registration.deprecation,
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// deprecationLog limits how often the resolutions of each deprecated type are logged.
+type deprecationLog struct {
+	logger   *slog.Logger
+	interval time.Duration
+	mu       sync.Mutex
+	logged   map[reflect.Type]time.Time
+}
+
+// due reports whether a resolution of typ at now should be logged, and records that it was.
+func (log *deprecationLog) due(typ reflect.Type, now time.Time) bool {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if last, ok := log.logged[typ]; ok && log.interval > 0 && now.Sub(last) < log.interval {
+		return false
+	}
+	log.logged[typ] = now
+	return true
+}
+
+// resolveDeprecated reports the resolution of a deprecated registration and returns a
+// DeprecatedResolution if the provider was built WithDeprecationErrors.
+func (provider RootProvider) resolveDeprecated(
+	typ reflect.Type,
+	registration registration,
+	constructing reflect.Type,
+	path *resolutionPath,
+) error {
+	options := provider.options
+	resolvedPath := path.types()
+	if path == nil && constructing != nil {
+		resolvedPath = []reflect.Type{constructing}
+	}
+	provider.observe(DeprecatedResolved{
+		Type:    typ,
+		Message: registration.deprecation,
+		Path:    resolvedPath,
+	})
+	if log := options.deprecationLog; log != nil && log.logger != nil && log.due(typ, time.Now()) {
+		log.logger.Warn(
+			"resolved a deprecated registration",
+			slog.String("type", typ.String()),
+			slog.String("deprecation", registration.deprecation),
+			slog.Any("path", resolvedPath))
+	}
+	if options.deprecationErrors {
+		return DeprecatedResolution{
+			Type:    typ,
+			Message: registration.deprecation,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package di
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type legacyPaymentsClient struct{}
+
+type checkoutService struct {
+	Payments *legacyPaymentsClient
+}
+
+func TestWithDeprecated(t *testing.T) {
+
+	clientType := reflect.TypeFor[*legacyPaymentsClient]()
+	checkoutType := reflect.TypeFor[*checkoutService]()
+
+	newRegistry := func(t *testing.T) Registry {
+		registry, err := RegisterType[*legacyPaymentsClient, *legacyPaymentsClient](
+			Registry{},
+			Transient,
+			WithDeprecated("use NewPaymentsClient instead"))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*checkoutService, *checkoutService](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		return registry
+	}
+
+	t.Run("reports resolutions with the path", func(t *testing.T) {
+		events := []DeprecatedResolved{}
+		provider, err := newRegistry(t).BuildRootProvider(WithObserver(ObserverFunc(func(event Event) {
+			if event, ok := event.(DeprecatedResolved); ok {
+				events = append(events, event)
+			}
+		})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*legacyPaymentsClient](provider.NewScope()); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := Resolve[*checkoutService](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		expected := []DeprecatedResolved{
+			{Type: clientType, Message: "use NewPaymentsClient instead"},
+			{Type: clientType, Message: "use NewPaymentsClient instead", Path: []reflect.Type{checkoutType}},
+		}
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("expected events %v; got %v", expected, events)
+		}
+	})
+
+	t.Run("logs resolutions at most once per interval", func(t *testing.T) {
+		b := bytes.Buffer{}
+		provider, err := newRegistry(t).BuildRootProvider(
+			WithDeprecationLog(slog.New(slog.NewTextHandler(&b, nil)), time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		for range 3 {
+			if _, err := Resolve[*checkoutService](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		if lines := strings.Count(b.String(), "\n"); lines != 1 {
+			t.Fatalf("expected 1 line to be logged; got %d:\n%s", lines, b.String())
+		}
+		for _, part := range []string{"level=WARN", "deprecation=\"use NewPaymentsClient instead\"", "*di.checkoutService"} {
+			if !strings.Contains(b.String(), part) {
+				t.Errorf("expected the log to contain %q; got %s", part, b.String())
+			}
+		}
+	})
+
+	t.Run("fails resolutions WithDeprecationErrors", func(t *testing.T) {
+		provider, err := newRegistry(t).BuildRootProvider(WithDeprecationErrors())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, err = Resolve[*checkoutService](provider)
+		deprecated := DeprecatedResolution{}
+		if !errors.As(err, &deprecated) || !errors.Is(err, ErrDeprecated) {
+			t.Fatalf("expected a DeprecatedResolution; got %v", err)
+		}
+		if deprecated.Type != clientType || deprecated.Message != "use NewPaymentsClient instead" {
+			t.Errorf("expected the deprecation of %v; got %v", clientType, deprecated)
+		}
+	})
+
+	t.Run("warns about dependencies on deprecated registrations", func(t *testing.T) {
+		err := newRegistry(t).Verify()
+		failed := VerificationFailed{}
+		if !errors.As(err, &failed) || !failed.WarningsOnly() {
+			t.Fatalf("expected only warnings; got %v", err)
+		}
+		expected := []error{DeprecatedDependency{
+			Type:       checkoutType,
+			Dependency: clientType,
+			Message:    "use NewPaymentsClient instead",
+		}}
+		if !reflect.DeepEqual(failed.Warnings, expected) {
+			t.Errorf("expected %v; got %v", expected, failed.Warnings)
+		}
+	})
+
+	t.Run("exposes the deprecation", func(t *testing.T) {
+		for _, info := range newRegistry(t).Registrations() {
+			deprecated := info.Type == clientType
+			if info.Deprecated != deprecated || deprecated && info.Deprecation != "use NewPaymentsClient instead" {
+				t.Errorf("unexpected deprecation for %v: %v %q", info.Type, info.Deprecated, info.Deprecation)
+			}
+		}
+	})
+}
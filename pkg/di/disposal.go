@@ -0,0 +1,130 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// WithStrictDisposal makes [Registry.BuildRootProvider] return a [VerificationFailed] listing an
+// [UndisposedTransient] for each [Transient] registration whose instances would have to be closed
+// by the code that resolves them, instead of building the provider. Registrations that use
+// [AllowUndisposedTransient] are not reported.
+func WithStrictDisposal() ProviderOption {
+	return func(options *providerOptions) {
+		options.strictDisposal = true
+	}
+}
+
+// ErrUndisposedTransient is reported when verification finds a [Transient] registration whose
+// instances implement [Closer] or [ContextCloser], which no provider closes.
+var ErrUndisposedTransient = errors.New("transient value must be closed by its caller")
+
+// An UndisposedTransient is an [error] indicating that verification found a [Transient]
+// registration whose implementation type, or a pointer to it, implements [Closer] or
+// [ContextCloser]. Providers never close Transient values so each one must be closed by the code
+// that resolved it, which is easy to forget. [Registry.Verify] reports it as a warning rather than
+// a problem, and [WithStrictDisposal] makes it fail [Registry.BuildRootProvider]. Calling
+// [errors.Is] with an UndisposedTransient and [ErrUndisposedTransient] returns true.
+type UndisposedTransient struct {
+
+	// Type is the registered type.
+	Type reflect.Type
+
+	// Impl is the implementation type that implements Closer or ContextCloser.
+	Impl reflect.Type
+}
+
+// Error implements [error].
+func (err UndisposedTransient) Error() string {
+	return fmt.Sprintf(
+		"transient %v is implemented by %v which must be closed by whatever resolves it; "+
+			"use another lifetime or AllowUndisposedTransient if callers close it",
+		err.Type,
+		err.Impl)
+}
+
+// Is indicates that an [UndisposedTransient] is [ErrUndisposedTransient].
+func (UndisposedTransient) Is(target error) bool {
+	return target == ErrUndisposedTransient
+}
+
+// Code returns "undisposed_transient", the code of an [UndisposedTransient] in [ErrorCodes].
+func (UndisposedTransient) Code() string {
+	return "undisposed_transient"
+}
+
+// A TransientCloserCreated is an [Event] indicating that a provider created a [Transient] value
+// that implements [Closer] or [ContextCloser], which it will not close. It is only reported the
+// first time each provider creates such a value for a registered type, and not for registrations
+// that use [AllowUndisposedTransient].
+type TransientCloserCreated struct {
+
+	// Type is the registered type.
+	Type reflect.Type
+
+	// Impl is the type of the value that was created.
+	Impl reflect.Type
+}
+
+func (TransientCloserCreated) event() {}
+
+var (
+	closerType        = reflect.TypeFor[Closer]()
+	contextCloserType = reflect.TypeFor[ContextCloser]()
+)
+
+// implementsCloser reports whether typ or a pointer to it implements Closer or ContextCloser.
+func implementsCloser(typ reflect.Type) bool {
+	for _, candidate := range []reflect.Type{typ, reflect.PointerTo(typ)} {
+		if candidate.Implements(closerType) || candidate.Implements(contextCloserType) {
+			return true
+		}
+	}
+	return false
+}
+
+// undisposedTransient reports whether registration is a Transient registration whose instances
+// the caller must close and that has not been allowed with AllowUndisposedTransient.
+func undisposedTransient(registration registration) bool {
+	return registration.lifetime == Transient &&
+		registration.source != sourceInstance &&
+		!registration.allowUndisposed
+}
+
+// findUndisposedTransients returns an UndisposedTransient for each Transient registration in
+// registrations whose implementation type must be closed by its caller.
+func findUndisposedTransients(registrations map[reflect.Type]registration) []error {
+	problems := []error{}
+	for _, typ := range sortedTypes(registrations) {
+		registration := registrations[typ]
+		if undisposedTransient(registration) && registration.impl != nil && implementsCloser(registration.impl) {
+			problems = append(problems, UndisposedTransient{
+				Type: typ,
+				Impl: registration.impl,
+			})
+		}
+	}
+	return problems
+}
+
+// transientClosers records the types for which a TransientCloserCreated has been reported.
+type transientClosers struct {
+	reported sync.Map
+}
+
+// observeTransient reports a TransientCloserCreated if value is the first value the provider has
+// created for typ that its caller must close.
+func (provider RootProvider) observeTransient(typ reflect.Type, registration registration, value any) {
+	if provider.options.observer == nil || !undisposedTransient(registration) || !isCloser(value) {
+		return
+	}
+	if _, reported := provider.transientClosers.reported.LoadOrStore(typ, struct{}{}); reported {
+		return
+	}
+	provider.observe(TransientCloserCreated{
+		Type: typ,
+		Impl: reflect.TypeOf(value),
+	})
+}
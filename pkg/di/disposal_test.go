@@ -0,0 +1,133 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestUndisposedTransients(t *testing.T) {
+
+	closerRegistry := func(t *testing.T, opts ...RegistrationOption) Registry {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Transient, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		return registry
+	}
+
+	t.Run("Verify warns about transients that implement Closer", func(t *testing.T) {
+		err := closerRegistry(t).Verify()
+		var failed VerificationFailed
+		if !errors.As(err, &failed) {
+			t.Fatalf("expected %v to be %T", err, failed)
+		}
+		if !failed.WarningsOnly() {
+			t.Fatalf("expected only warnings; got %v", err)
+		}
+		var undisposed UndisposedTransient
+		if !errors.As(err, &undisposed) {
+			t.Fatalf("expected %v to include an %T", err, undisposed)
+		}
+		if typ := reflect.TypeFor[*mockCloser](); undisposed.Type != typ {
+			t.Errorf("expected err.Type to be %v; got %v", typ, undisposed.Type)
+		}
+		if !errors.Is(err, ErrUndisposedTransient) {
+			t.Errorf("expected %q; got %q", ErrUndisposedTransient, err)
+		}
+	})
+
+	t.Run("Verify warns when only a pointer to Impl implements ContextCloser", func(t *testing.T) {
+		registry, err := RegisterType[errorContextCloser, errorContextCloser](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		if err := registry.Verify(); !errors.Is(err, ErrUndisposedTransient) {
+			t.Fatalf("expected %q; got %q", ErrUndisposedTransient, err)
+		}
+	})
+
+	t.Run("Verify does not warn about other lifetimes or allowed transients", func(t *testing.T) {
+		if err := closerRegistry(t, AllowUndisposedTransient()).Verify(); err != nil {
+			t.Fatalf("unexpected error from Verify: %v", err)
+		}
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		if err := registry.Verify(); err != nil {
+			t.Fatalf("unexpected error from Verify: %v", err)
+		}
+	})
+
+	t.Run("Freeze ignores warnings", func(t *testing.T) {
+		provider, err := closerRegistry(t).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.Freeze(); err != nil {
+			t.Fatalf("unexpected error from Freeze: %v", err)
+		}
+	})
+
+	t.Run("WithStrictDisposal fails BuildRootProvider", func(t *testing.T) {
+		_, err := closerRegistry(t).BuildRootProvider(WithStrictDisposal())
+		if !errors.Is(err, ErrVerificationFailed) || !errors.Is(err, ErrUndisposedTransient) {
+			t.Fatalf("expected %q and %q; got %q", ErrVerificationFailed, ErrUndisposedTransient, err)
+		}
+		if _, err := closerRegistry(t, AllowUndisposedTransient()).BuildRootProvider(WithStrictDisposal()); err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+	})
+
+	t.Run("reports the first transient closer created for each type", func(t *testing.T) {
+		var mu sync.Mutex
+		events := []TransientCloserCreated{}
+		provider, err := closerRegistry(t).BuildRootProvider(WithObserver(ObserverFunc(func(event Event) {
+			if created, ok := event.(TransientCloserCreated); ok {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, created)
+			}
+		})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		scope := provider.NewScope()
+		for range 3 {
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		if _, err := Resolve[*mockCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event; got %v", events)
+		}
+		if typ := reflect.TypeFor[*mockCloser](); events[0].Type != typ || events[0].Impl != typ {
+			t.Errorf("expected an event for %v; got %+v", typ, events[0])
+		}
+	})
+
+	t.Run("does not report allowed transients", func(t *testing.T) {
+		reported := false
+		provider, err := closerRegistry(t, AllowUndisposedTransient()).BuildRootProvider(WithObserver(ObserverFunc(func(event Event) {
+			if _, ok := event.(TransientCloserCreated); ok {
+				reported = true
+			}
+		})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*mockCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if reported {
+			t.Fatalf("expected no TransientCloserCreated")
+		}
+	})
+}
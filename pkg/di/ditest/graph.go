@@ -0,0 +1,120 @@
+package ditest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// A GraphOption configures optional behavior for [VerifyGraphWith].
+type GraphOption func(*graphOptions)
+
+type graphOptions struct {
+	construct bool
+}
+
+// WithConstruction makes graph verification also resolve each root from a throwaway [di.Scope] so
+// that the factories in the graph run. The scope and provider are closed afterwards and errors from
+// closing them fail the test too.
+func WithConstruction() GraphOption {
+	return func(options *graphOptions) {
+		options.construct = true
+	}
+}
+
+// VerifyGraph fails t with a report of every problem found in the dependency graph of registry:
+// roots that are not registered and the problems reported by [di.RootProvider.Verify], such as
+// missing dependencies, cycles, and captive dependencies.
+func VerifyGraph(t testing.TB, registry di.Registry, roots ...reflect.Type) {
+	t.Helper()
+	VerifyGraphWith(t, registry, nil, roots...)
+}
+
+// VerifyGraphWith is like [VerifyGraph] but accepts options, e.g. [WithConstruction].
+func VerifyGraphWith(t testing.TB, registry di.Registry, opts []GraphOption, roots ...reflect.Type) {
+	t.Helper()
+	options := graphOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	provider, err := registry.BuildRootProvider()
+	if err != nil {
+		t.Errorf("dependency graph verification failed: cannot build the provider: %v", err)
+		return
+	}
+	problems := graphProblems(provider, roots)
+	if len(problems) == 0 && options.construct {
+		problems = append(problems, constructRoots(provider, roots)...)
+	}
+	if len(problems) > 0 {
+		t.Errorf("%s", graphReport(problems))
+	}
+}
+
+func graphProblems(provider di.RootProvider, roots []reflect.Type) []error {
+	registered := map[reflect.Type]bool{}
+	for _, info := range provider.Registrations() {
+		registered[info.Type] = true
+	}
+	problems := []error{}
+	for _, root := range roots {
+		if !registered[root] {
+			problems = append(problems, di.UnknownType{
+				Type: root,
+			})
+		}
+	}
+	var failed di.VerificationFailed
+	if err := provider.Verify(); errors.As(err, &failed) {
+		problems = append(problems, failed.Problems...)
+	} else if err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
+
+// constructRoots resolves each root from a throwaway scope, using a scope with the right name for
+// roots restricted to a named scope, then closes the scopes and the provider.
+func constructRoots(provider di.RootProvider, roots []reflect.Type) []error {
+	scopeNames := map[reflect.Type]string{}
+	for _, info := range provider.Registrations() {
+		scopeNames[info.Type] = info.ScopeName
+	}
+	scopes := map[string]di.Scope{}
+	problems := []error{}
+	for _, root := range roots {
+		name := scopeNames[root]
+		scope, ok := scopes[name]
+		if !ok {
+			scope = provider.NewNamedScope(name)
+			scopes[name] = scope
+		}
+		if _, err := scope.Resolve(root); err != nil {
+			problems = append(problems, fmt.Errorf("constructing %v: %w", root, err))
+		}
+	}
+	ctx := context.Background()
+	for _, scope := range scopes {
+		for _, err := range scope.Close(ctx) {
+			problems = append(problems, fmt.Errorf("closing scope: %w", err))
+		}
+	}
+	for _, err := range provider.Close(ctx) {
+		problems = append(problems, fmt.Errorf("closing provider: %w", err))
+	}
+	return problems
+}
+
+func graphReport(problems []error) string {
+	msg := strings.Builder{}
+	fmt.Fprintf(&msg, "dependency graph verification found %d problem(s):", len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(&msg, "\n  - %v", problem)
+	}
+	return msg.String()
+}
@@ -0,0 +1,99 @@
+package ditest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type apiServer struct {
+	Repo *repo
+}
+
+type failingCloser struct{}
+
+func (failingCloser) Close(context.Context) error {
+	return errors.New("close failed")
+}
+
+func TestVerifyGraph(t *testing.T) {
+
+	register := func(t *testing.T) func(di.Registry, error) di.Registry {
+		return func(registry di.Registry, err error) di.Registry {
+			t.Helper()
+			if err != nil {
+				t.Fatalf("unexpected error from registration: %v", err)
+			}
+			return registry
+		}
+	}
+
+	validRegistry := func(t *testing.T) di.Registry {
+		registry := register(t)(di.RegisterType[*repo, *repo](di.Registry{}, di.Scoped))
+		return register(t)(di.RegisterType[*apiServer, *apiServer](registry, di.Scoped))
+	}
+
+	t.Run("passes for a valid graph", func(t *testing.T) {
+		tb := &recordingTB{}
+		VerifyGraph(tb, validRegistry(t), reflect.TypeFor[*apiServer]())
+		if len(tb.errors) > 0 {
+			t.Fatalf("expected no failures; got %v", tb.errors)
+		}
+	})
+
+	t.Run("reports missing dependencies and unregistered roots", func(t *testing.T) {
+		tb := &recordingTB{}
+		registry := register(t)(di.RegisterType[*apiServer, *apiServer](di.Registry{}, di.Scoped))
+		VerifyGraph(tb, registry, reflect.TypeFor[*apiServer](), reflect.TypeFor[*cache]())
+		if len(tb.errors) != 1 {
+			t.Fatalf("expected one failure; got %v", tb.errors)
+		}
+		report := tb.errors[0]
+		if !strings.Contains(report, "2 problem(s)") ||
+			!strings.Contains(report, "*ditest.cache is unknown") ||
+			!strings.Contains(report, "depends on *ditest.repo which is not registered") {
+			t.Fatalf("expected the report to list both problems; got %s", report)
+		}
+	})
+
+	t.Run("reports captive dependencies", func(t *testing.T) {
+		tb := &recordingTB{}
+		registry := register(t)(di.RegisterType[*repo, *repo](di.Registry{}, di.Scoped))
+		registry = register(t)(di.RegisterType[*apiServer, *apiServer](registry, di.Singleton))
+		VerifyGraph(tb, registry, reflect.TypeFor[*apiServer]())
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "singleton *ditest.apiServer depends on scoped *ditest.repo") {
+			t.Fatalf("expected a captive dependency failure; got %v", tb.errors)
+		}
+	})
+
+	t.Run("WithConstruction reports factory errors", func(t *testing.T) {
+		tb := &recordingTB{}
+		registry := register(t)(di.RegisterFactory[*cache](validRegistry(t), di.Scoped, func(di.Resolver) (*cache, error) {
+			return nil, errors.New("cache unavailable")
+		}))
+		roots := []reflect.Type{reflect.TypeFor[*apiServer](), reflect.TypeFor[*cache]()}
+		VerifyGraph(tb, registry, roots...)
+		if len(tb.errors) > 0 {
+			t.Fatalf("expected no failures without construction; got %v", tb.errors)
+		}
+		VerifyGraphWith(tb, registry, []GraphOption{WithConstruction()}, roots...)
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "cache unavailable") {
+			t.Fatalf("expected a construction failure; got %v", tb.errors)
+		}
+	})
+
+	t.Run("WithConstruction reports close errors", func(t *testing.T) {
+		tb := &recordingTB{}
+		registry := register(t)(di.RegisterFactory[*failingCloser](di.Registry{}, di.Singleton, func(di.Resolver) (*failingCloser, error) {
+			return &failingCloser{}, nil
+		}))
+		VerifyGraphWith(tb, registry, []GraphOption{WithConstruction()}, reflect.TypeFor[*failingCloser]())
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "close failed") {
+			t.Fatalf("expected a close failure; got %v", tb.errors)
+		}
+	})
+}
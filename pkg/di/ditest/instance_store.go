@@ -0,0 +1,267 @@
+package ditest
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// storeDeadline is how long TestInstanceStore waits for a call that should return before it
+// assumes the store is deadlocked.
+const storeDeadline = 5 * time.Second
+
+type storeKeyA struct{}
+
+type storeKeyB struct{}
+
+type storeKeyC struct{}
+
+// TestInstanceStore runs a conformance suite checking that the stores created by newStore meet the
+// requirements of [di.InstanceStore], e.g. from a test for a custom store given to
+// [di.WithInstanceStore]. Each subtest uses a new store.
+func TestInstanceStore(t *testing.T, newStore func() di.InstanceStore) {
+
+	keyA := reflect.TypeFor[storeKeyA]()
+	keyB := reflect.TypeFor[storeKeyB]()
+	keyC := reflect.TypeFor[storeKeyC]()
+
+	constant := func(value any) func(di.Resolver) (any, error) {
+		return func(di.Resolver) (any, error) {
+			return value, nil
+		}
+	}
+
+	// within fails t if fn does not return before the deadline.
+	within := func(t *testing.T, what string, fn func()) {
+		t.Helper()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fn()
+		}()
+		select {
+		case <-done:
+		case <-time.After(storeDeadline):
+			t.Fatalf("%s did not return within %v", what, storeDeadline)
+		}
+	}
+
+	t.Run("Get returns false for a key with no instance", func(t *testing.T) {
+		if value, ok := newStore().Get(keyA); ok {
+			t.Fatalf("expected no instance; got %v", value)
+		}
+	})
+
+	t.Run("GetOrCreate stores the instance the factory creates", func(t *testing.T) {
+		store := newStore()
+		expected := &storeKeyA{}
+		resolver := NewResolver()
+		var received di.Resolver
+		value, err := store.GetOrCreate(keyA, func(resolver di.Resolver) (any, error) {
+			received = resolver
+			return expected, nil
+		}, resolver)
+		if err != nil {
+			t.Fatalf("unexpected error from GetOrCreate: %v", err)
+		}
+		if value != expected {
+			t.Fatalf("expected %p; got %v", expected, value)
+		}
+		if received != resolver {
+			t.Fatalf("expected the factory to receive the resolver passed to GetOrCreate")
+		}
+		if value, ok := store.Get(keyA); !ok || value != expected {
+			t.Fatalf("expected Get to return %p; got %v, %v", expected, value, ok)
+		}
+		value, err = store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+			t.Errorf("expected the factory not to be called for a stored instance")
+			return nil, nil
+		}, resolver)
+		if err != nil || value != expected {
+			t.Fatalf("expected GetOrCreate to return %p; got %v, %v", expected, value, err)
+		}
+	})
+
+	t.Run("GetOrCreate does not store errors", func(t *testing.T) {
+		store := newStore()
+		expected := errors.New("expected error")
+		if _, err := store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+			return nil, expected
+		}, nil); !errors.Is(err, expected) {
+			t.Fatalf("expected %v; got %v", expected, err)
+		}
+		if value, ok := store.Get(keyA); ok {
+			t.Fatalf("expected no instance after an error; got %v", value)
+		}
+		if _, err := store.GetOrCreate(keyA, constant(&storeKeyA{}), nil); err != nil {
+			t.Fatalf("expected a later call to try again; got %v", err)
+		}
+	})
+
+	t.Run("GetOrCreate calls the factory once for concurrent callers", func(t *testing.T) {
+		store := newStore()
+		const callers = 16
+		release := make(chan struct{})
+		calls := 0
+		var mu sync.Mutex
+		values := make([]any, callers)
+		var wg sync.WaitGroup
+		for i := range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				values[i], _ = store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+					mu.Lock()
+					calls++
+					mu.Unlock()
+					<-release
+					return &storeKeyA{}, nil
+				}, nil)
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		within(t, "GetOrCreate", wg.Wait)
+		if calls != 1 {
+			t.Fatalf("expected the factory to be called once; got %d", calls)
+		}
+		for _, value := range values {
+			if value == nil || value != values[0] {
+				t.Fatalf("expected every caller to receive the same instance; got %v", values)
+			}
+		}
+	})
+
+	t.Run("GetOrCreate allows the factory to create other instances", func(t *testing.T) {
+		store := newStore()
+		within(t, "GetOrCreate", func() {
+			_, err := store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+				if _, err := store.GetOrCreate(keyB, constant(&storeKeyB{}), nil); err != nil {
+					return nil, err
+				}
+				if _, ok := store.Get(keyB); !ok {
+					return nil, errors.New("expected the nested instance to be stored")
+				}
+				return &storeKeyA{}, nil
+			}, nil)
+			if err != nil {
+				t.Errorf("unexpected error from GetOrCreate: %v", err)
+			}
+		})
+	})
+
+	t.Run("GetOrCreate returns an instance stored while the factory was running", func(t *testing.T) {
+		store := newStore()
+		replacement := &storeKeyA{}
+		value, err := store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+			store.Store(keyA, replacement)
+			return &storeKeyA{}, nil
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error from GetOrCreate: %v", err)
+		}
+		if value != replacement {
+			t.Fatalf("expected the stored instance %p; got %v", replacement, value)
+		}
+		if value, _ := store.Get(keyA); value != replacement {
+			t.Fatalf("expected the stored instance %p to remain; got %v", replacement, value)
+		}
+	})
+
+	t.Run("GetOrCreate propagates panics and releases waiting callers", func(t *testing.T) {
+		store := newStore()
+		started := make(chan struct{})
+		waiterErr := make(chan error, 1)
+		go func() {
+			<-started
+			_, err := store.GetOrCreate(keyA, constant(&storeKeyA{}), nil)
+			waiterErr <- err
+		}()
+		within(t, "GetOrCreate", func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected the panic to propagate")
+				}
+			}()
+			_, _ = store.GetOrCreate(keyA, func(di.Resolver) (any, error) {
+				close(started)
+				// Give the waiting caller time to start waiting on this construction.
+				time.Sleep(10 * time.Millisecond)
+				panic("expected panic")
+			}, nil)
+		})
+		within(t, "the waiting GetOrCreate", func() {
+			<-waiterErr
+		})
+		if _, err := store.GetOrCreate(keyA, constant(&storeKeyA{}), nil); err != nil {
+			t.Fatalf("expected a later call to try again; got %v", err)
+		}
+	})
+
+	t.Run("Snapshot returns the instances in the order they were first stored", func(t *testing.T) {
+		store := newStore()
+		a, b, c := &storeKeyA{}, &storeKeyB{}, &storeKeyC{}
+		if _, err := store.GetOrCreate(keyB, constant(b), nil); err != nil {
+			t.Fatalf("unexpected error from GetOrCreate: %v", err)
+		}
+		store.Store(keyA, a)
+		if _, err := store.GetOrCreate(keyC, constant(c), nil); err != nil {
+			t.Fatalf("unexpected error from GetOrCreate: %v", err)
+		}
+		replacement := &storeKeyA{}
+		store.Store(keyA, replacement)
+		snapshot := store.Snapshot()
+		expected := []di.StoredInstance{{Type: keyB, Value: b}, {Type: keyA, Value: replacement}, {Type: keyC, Value: c}}
+		if len(snapshot) != len(expected) {
+			t.Fatalf("expected %d instances; got %v", len(expected), snapshot)
+		}
+		for i := range expected {
+			if snapshot[i].Type != expected[i].Type || snapshot[i].Value != expected[i].Value {
+				t.Fatalf("expected instance %d to be %v; got %v", i, expected[i], snapshot[i])
+			}
+			if snapshot[i].Created.IsZero() {
+				t.Fatalf("expected instance %d to have a creation time", i)
+			}
+		}
+		snapshot[0].Value = nil
+		if again := store.Snapshot(); again[0].Value != b {
+			t.Fatalf("expected Snapshot to return a copy")
+		}
+	})
+
+	t.Run("Store returns the instance it replaced", func(t *testing.T) {
+		store := newStore()
+		first, second := &storeKeyA{}, &storeKeyA{}
+		if previous, replaced := store.Store(keyA, first); replaced {
+			t.Fatalf("expected nothing to be replaced; got %v", previous)
+		}
+		if previous, replaced := store.Store(keyA, second); !replaced || previous != first {
+			t.Fatalf("expected %p to be replaced; got %v, %v", first, previous, replaced)
+		}
+		if value, _ := store.Get(keyA); value != second {
+			t.Fatalf("expected %p; got %v", second, value)
+		}
+	})
+
+	t.Run("Delete removes the instance", func(t *testing.T) {
+		store := newStore()
+		expected := &storeKeyA{}
+		store.Store(keyA, expected)
+		if value, ok := store.Delete(keyA); !ok || value != expected {
+			t.Fatalf("expected %p to be deleted; got %v, %v", expected, value, ok)
+		}
+		if value, ok := store.Get(keyA); ok {
+			t.Fatalf("expected no instance after Delete; got %v", value)
+		}
+		if len(store.Snapshot()) != 0 {
+			t.Fatalf("expected the snapshot to be empty; got %v", store.Snapshot())
+		}
+		if value, ok := store.Delete(keyA); ok {
+			t.Fatalf("expected nothing to delete; got %v", value)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package ditest
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// countingStore is a custom di.InstanceStore that counts the instances it creates.
+type countingStore struct {
+	di.InstanceStore
+	created atomic.Int64
+}
+
+func (s *countingStore) GetOrCreate(key reflect.Type, factory func(di.Resolver) (any, error), resolver di.Resolver) (any, error) {
+	return s.InstanceStore.GetOrCreate(key, func(resolver di.Resolver) (any, error) {
+		s.created.Add(1)
+		return factory(resolver)
+	}, resolver)
+}
+
+func TestTestInstanceStore(t *testing.T) {
+
+	t.Run("default store", func(t *testing.T) {
+		TestInstanceStore(t, di.NewInstanceStore)
+	})
+
+	t.Run("custom store", func(t *testing.T) {
+		TestInstanceStore(t, func() di.InstanceStore {
+			return &countingStore{InstanceStore: di.NewInstanceStore()}
+		})
+	})
+}
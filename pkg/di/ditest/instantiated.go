@@ -0,0 +1,70 @@
+package ditest
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// AssertInstantiated fails t for each of types that has not been instantiated by scope, meaning it
+// is not one of the [di.Scope.InstantiatedTypes] or, if the provider was built with
+// [di.WithTransientTracking], of the [di.Scope.InstantiatedTransients].
+func AssertInstantiated(t testing.TB, scope di.Scope, types ...reflect.Type) {
+	t.Helper()
+	instantiated := scopeInstantiated(scope)
+	for _, typ := range types {
+		if !slices.Contains(instantiated, typ) {
+			t.Errorf("expected %v to have been instantiated by scope %s; instantiated %v", typ, scope.ID(), instantiated)
+		}
+	}
+}
+
+// AssertNotInstantiated fails t for each of types that has been instantiated by scope; see
+// [AssertInstantiated]. It is useful for checking that resolving a value did not construct
+// dependencies it should not need, e.g. that a handler does not touch a payments client.
+func AssertNotInstantiated(t testing.TB, scope di.Scope, types ...reflect.Type) {
+	t.Helper()
+	instantiated := scopeInstantiated(scope)
+	for _, typ := range types {
+		if slices.Contains(instantiated, typ) {
+			t.Errorf("expected %v not to have been instantiated by scope %s", typ, scope.ID())
+		}
+	}
+}
+
+// AssertSingletonsInstantiated fails t for each of types that is not one of the
+// [di.RootProvider.InstantiatedSingletons] or, if the provider was built with
+// [di.WithTransientTracking], of the [di.RootProvider.InstantiatedTransients].
+func AssertSingletonsInstantiated(t testing.TB, provider di.RootProvider, types ...reflect.Type) {
+	t.Helper()
+	instantiated := providerInstantiated(provider)
+	for _, typ := range types {
+		if !slices.Contains(instantiated, typ) {
+			t.Errorf("expected %v to have been instantiated by the provider; instantiated %v", typ, instantiated)
+		}
+	}
+}
+
+// AssertSingletonsNotInstantiated fails t for each of types that has been instantiated by the
+// provider; see [AssertSingletonsInstantiated].
+func AssertSingletonsNotInstantiated(t testing.TB, provider di.RootProvider, types ...reflect.Type) {
+	t.Helper()
+	instantiated := providerInstantiated(provider)
+	for _, typ := range types {
+		if slices.Contains(instantiated, typ) {
+			t.Errorf("expected %v not to have been instantiated by the provider", typ)
+		}
+	}
+}
+
+func scopeInstantiated(scope di.Scope) []reflect.Type {
+	transients, _ := scope.InstantiatedTransients()
+	return append(scope.InstantiatedTypes(), transients...)
+}
+
+func providerInstantiated(provider di.RootProvider) []reflect.Type {
+	transients, _ := provider.InstantiatedTransients()
+	return append(provider.InstantiatedSingletons(), transients...)
+}
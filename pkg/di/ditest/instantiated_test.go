@@ -0,0 +1,83 @@
+package ditest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type handler struct {
+	Session *session
+}
+
+type paymentsClient struct{}
+
+func TestAssertInstantiated(t *testing.T) {
+
+	newProvider := func(t *testing.T) di.RootProvider {
+		registry, err := di.RegisterType[*session, *session](di.Registry{}, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*handler, *handler](registry, di.Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*paymentsClient, *paymentsClient](registry, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(di.WithTransientTracking())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("checks the scoped and transient types a scope instantiated", func(t *testing.T) {
+		provider := newProvider(t)
+		scope := provider.NewScope()
+		if _, err := di.Resolve[*handler](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		tb := &recordingTB{}
+		AssertInstantiated(tb, scope, reflect.TypeFor[*handler](), reflect.TypeFor[*session]())
+		AssertNotInstantiated(tb, scope, reflect.TypeFor[*paymentsClient]())
+		AssertSingletonsNotInstantiated(tb, provider, reflect.TypeFor[*paymentsClient]())
+		if len(tb.errors) != 0 {
+			t.Fatalf("expected no failures; got %v", tb.errors)
+		}
+	})
+
+	t.Run("fails for each unexpected type", func(t *testing.T) {
+		provider := newProvider(t)
+		scope := provider.NewScope()
+		if _, err := di.Resolve[*paymentsClient](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		tb := &recordingTB{}
+		AssertInstantiated(tb, scope, reflect.TypeFor[*handler](), reflect.TypeFor[*session]())
+		AssertSingletonsNotInstantiated(tb, provider, reflect.TypeFor[*paymentsClient]())
+		if len(tb.errors) != 3 {
+			t.Fatalf("expected 3 failures; got %v", tb.errors)
+		}
+		if !strings.Contains(tb.errors[2], "*ditest.paymentsClient not to have been instantiated") {
+			t.Fatalf("unexpected failure %q", tb.errors[2])
+		}
+	})
+
+	t.Run("checks the singletons the provider instantiated", func(t *testing.T) {
+		provider := newProvider(t)
+		if _, err := di.Resolve[*paymentsClient](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		tb := &recordingTB{}
+		AssertSingletonsInstantiated(tb, provider, reflect.TypeFor[*paymentsClient]())
+		AssertSingletonsInstantiated(tb, provider, reflect.TypeFor[*handler]())
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "*ditest.handler to have been instantiated") {
+			t.Fatalf("expected one failure for the handler; got %v", tb.errors)
+		}
+	})
+}
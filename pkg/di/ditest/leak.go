@@ -0,0 +1,58 @@
+package ditest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// AssertNoLeakedScopes runs the garbage collector and fails t with a report of every scope created
+// from provider that was collected without having been closed, including the types of the values
+// it created and the stack it was created from. The provider must have been built with
+// [di.WithLeakDetection]; otherwise no scopes are tracked and AssertNoLeakedScopes always passes.
+//
+// Only scopes that are no longer reachable can be detected, so AssertNoLeakedScopes is typically
+// called at the end of a test, e.g. using [testing.TB.Cleanup].
+func AssertNoLeakedScopes(t testing.TB, provider di.RootProvider) {
+	t.Helper()
+	collectGarbage()
+	leaked := provider.LeakedScopes()
+	if len(leaked) > 0 {
+		t.Errorf("%s", leakReport(leaked))
+	}
+}
+
+// collectGarbage runs the garbage collector and waits for the finalizers it queues, which report
+// leaked scopes, to have had a chance to run. It collects twice since the finalizers queued by a
+// collection do not run in a specified order.
+func collectGarbage() {
+	for range 2 {
+		done := make(chan struct{})
+		sentinel := new([32]byte)
+		runtime.SetFinalizer(sentinel, func(*[32]byte) {
+			close(done)
+		})
+		sentinel = nil
+		runtime.GC()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func leakReport(leaked []di.ScopeLeaked) string {
+	msg := strings.Builder{}
+	fmt.Fprintf(&msg, "found %d scope(s) that were not closed:", len(leaked))
+	for _, scope := range leaked {
+		fmt.Fprintf(&msg, "\n  - scope %s created values of types %v and was created by:", scope.ID, scope.Types)
+		for _, line := range strings.Split(strings.TrimSpace(scope.Stack), "\n") {
+			fmt.Fprintf(&msg, "\n      %s", line)
+		}
+	}
+	return msg.String()
+}
@@ -0,0 +1,65 @@
+package ditest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type session struct {
+	id string
+}
+
+func TestAssertNoLeakedScopes(t *testing.T) {
+
+	newProvider := func(t *testing.T) di.RootProvider {
+		registry, err := di.RegisterType[*session, *session](di.Registry{}, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(di.WithLeakDetection())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("passes when every scope was closed", func(t *testing.T) {
+		provider := newProvider(t)
+		func() {
+			scope := provider.NewScope()
+			defer scope.Close(context.Background())
+			if _, err := di.Resolve[*session](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}()
+		tb := &recordingTB{}
+		AssertNoLeakedScopes(tb, provider)
+		if len(tb.errors) != 0 {
+			t.Fatalf("expected no failures; got %v", tb.errors)
+		}
+	})
+
+	t.Run("fails listing the scopes that were not closed", func(t *testing.T) {
+		provider := newProvider(t)
+		id := func() string {
+			scope := provider.NewScope()
+			if _, err := di.Resolve[*session](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			return scope.ID()
+		}()
+		tb := &recordingTB{}
+		AssertNoLeakedScopes(tb, provider)
+		if len(tb.errors) != 1 {
+			t.Fatalf("expected 1 failure; got %v", tb.errors)
+		}
+		for _, expected := range []string{"scope " + id, "*ditest.session", "TestAssertNoLeakedScopes"} {
+			if !strings.Contains(tb.errors[0], expected) {
+				t.Fatalf("expected the failure to contain %q; got:\n%s", expected, tb.errors[0])
+			}
+		}
+	})
+}
@@ -0,0 +1,2 @@
+// Package ditest provides helpers for testing code that uses the di package.
+package ditest
@@ -0,0 +1,126 @@
+package ditest
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// An Outcome describes how a resolution recorded by a [Recorder] ended.
+type Outcome int
+
+const (
+
+	// Hit indicates that the requested type was resolved.
+	Hit Outcome = iota
+
+	// Miss indicates that the requested type was unknown to the resolver.
+	Miss
+
+	// Failed indicates that resolving the requested type returned an error other than a
+	// [di.UnknownType].
+	Failed
+)
+
+// String implements [fmt.Stringer].
+func (outcome Outcome) String() string {
+	switch outcome {
+	case Hit:
+		return "Hit"
+	case Miss:
+		return "Miss"
+	case Failed:
+		return "Failed"
+	}
+	return "Outcome(?)"
+}
+
+// A ResolutionEvent is a resolution recorded by a [Recorder].
+type ResolutionEvent struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Outcome is how the resolution ended.
+	Outcome Outcome
+
+	// Err is the error the resolution returned, if any.
+	Err error
+}
+
+// A Recorder is a [di.Resolver] that delegates to another resolver and records each resolution
+// for assertions in tests. Only the resolutions requested from the Recorder are recorded; the
+// dependencies that factories resolve from the underlying resolver are not. A Recorder must be
+// created using [Record] and is safe for concurrent use.
+type Recorder struct {
+	resolver di.Resolver
+	mu       sync.Mutex
+	events   []ResolutionEvent
+}
+
+// Record creates a [Recorder] that delegates to resolver, e.g. a [di.Scope].
+func Record(resolver di.Resolver) *Recorder {
+	return &Recorder{
+		resolver: resolver,
+	}
+}
+
+// Resolve implements [di.Resolver] by delegating to the underlying resolver and recording the
+// outcome.
+func (rec *Recorder) Resolve(typ reflect.Type) (any, error) {
+	value, err := rec.resolver.Resolve(typ)
+	event := ResolutionEvent{
+		Type:    typ,
+		Outcome: Hit,
+		Err:     err,
+	}
+	if errors.Is(err, di.ErrUnknownType) {
+		event.Outcome = Miss
+	} else if err != nil {
+		event.Outcome = Failed
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.events = append(rec.events, event)
+	return value, err
+}
+
+// Events returns the recorded resolutions in the order they completed.
+func (rec *Recorder) Events() []ResolutionEvent {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return slices.Clone(rec.events)
+}
+
+// AssertResolved fails t unless each of the given types was resolved with an outcome of [Hit] at
+// least once.
+func (rec *Recorder) AssertResolved(t testing.TB, types ...reflect.Type) {
+	t.Helper()
+	for _, typ := range types {
+		if !rec.resolved(typ) {
+			t.Errorf("expected %v to be resolved; it was not", typ)
+		}
+	}
+}
+
+// AssertNotResolved fails t if any of the given types was resolved with an outcome of [Hit].
+func (rec *Recorder) AssertNotResolved(t testing.TB, types ...reflect.Type) {
+	t.Helper()
+	for _, typ := range types {
+		if rec.resolved(typ) {
+			t.Errorf("expected %v not to be resolved; it was", typ)
+		}
+	}
+}
+
+func (rec *Recorder) resolved(typ reflect.Type) bool {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return slices.ContainsFunc(rec.events, func(event ResolutionEvent) bool {
+		return event.Type == typ && event.Outcome == Hit
+	})
+}
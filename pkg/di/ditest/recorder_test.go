@@ -0,0 +1,100 @@
+package ditest
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type repo struct{}
+
+type cache struct{}
+
+func TestRecorder(t *testing.T) {
+
+	newScope := func(t *testing.T) di.Scope {
+		registry, err := di.RegisterType[*repo, *repo](di.Registry{}, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterFactory[*cache](registry, di.Scoped, func(di.Resolver) (*cache, error) {
+			return nil, errors.New("cache unavailable")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider.NewScope()
+	}
+
+	t.Run("records the outcome of each resolution in order", func(t *testing.T) {
+		rec := Record(newScope(t))
+		if _, err := di.Resolve[*repo](rec); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		_, _ = di.Resolve[string](rec)
+		_, _ = di.Resolve[*cache](rec)
+		events := rec.Events()
+		expected := []struct {
+			typ     reflect.Type
+			outcome Outcome
+		}{
+			{reflect.TypeFor[*repo](), Hit},
+			{reflect.TypeFor[string](), Miss},
+			{reflect.TypeFor[*cache](), Failed},
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events; got %d", len(expected), len(events))
+		}
+		for i, event := range events {
+			if event.Type != expected[i].typ || event.Outcome != expected[i].outcome {
+				t.Fatalf("expected event %d to be %v %v; got %v %v",
+					i, expected[i].typ, expected[i].outcome, event.Type, event.Outcome)
+			}
+		}
+		if events[2].Err == nil {
+			t.Fatalf("expected the failed event to have an error")
+		}
+	})
+
+	t.Run("AssertResolved and AssertNotResolved", func(t *testing.T) {
+		rec := Record(newScope(t))
+		_, _ = di.Resolve[*repo](rec)
+		_, _ = di.Resolve[*cache](rec)
+		rec.AssertResolved(t, reflect.TypeFor[*repo]())
+		rec.AssertNotResolved(t, reflect.TypeFor[*cache](), reflect.TypeFor[string]())
+
+		tb := &recordingTB{}
+		rec.AssertResolved(tb, reflect.TypeFor[*cache]())
+		rec.AssertNotResolved(tb, reflect.TypeFor[*repo]())
+		if len(tb.errors) != 2 {
+			t.Fatalf("expected 2 failures; got %v", tb.errors)
+		}
+		if !strings.Contains(tb.errors[0], "*ditest.cache") || !strings.Contains(tb.errors[1], "*ditest.repo") {
+			t.Fatalf("expected the failures to name the types; got %v", tb.errors)
+		}
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		rec := Record(newScope(t))
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = di.Resolve[*repo](rec)
+			}()
+		}
+		wg.Wait()
+		if events := rec.Events(); len(events) != 10 {
+			t.Fatalf("expected 10 events; got %d", len(events))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package ditest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// A Resolver is a fake [di.Resolver] for unit testing factories and the values built by default
+// factories. It resolves the values and errors it was configured with and records every type it is
+// asked to resolve. A Resolver must be created using [NewResolver] and is safe for concurrent use.
+type Resolver struct {
+	mu          sync.Mutex
+	resolutions map[reflect.Type]resolution
+	strict      testing.TB
+	requested   []reflect.Type
+}
+
+type resolution struct {
+	value any
+	err   error
+}
+
+// NewResolver creates an empty [Resolver]. Unless [Resolver.Strict] is called, the Resolver
+// returns a [di.UnknownType] for any type it was not configured to resolve.
+func NewResolver() *Resolver {
+	return &Resolver{
+		resolutions: map[reflect.Type]resolution{},
+	}
+}
+
+// With configures the resolver to resolve value for its dynamic type and returns the resolver. Use
+// [Resolver.WithType] to resolve a value for an interface type. With panics if value is nil.
+func (r *Resolver) With(value any) *Resolver {
+	if value == nil {
+		panic("ditest: With cannot infer the type of nil; use WithType")
+	}
+	return r.WithType(reflect.TypeOf(value), value)
+}
+
+// WithType configures the resolver to resolve value for typ and returns the resolver. WithType
+// panics if value is not assignable to typ.
+func (r *Resolver) WithType(typ reflect.Type, value any) *Resolver {
+	if value != nil && !reflect.TypeOf(value).AssignableTo(typ) {
+		panic(fmt.Sprintf("ditest: value of type %T is not assignable to %v", value, typ))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolutions[typ] = resolution{
+		value: value,
+	}
+	return r
+}
+
+// WithErr configures the resolver to return err when typ is requested and returns the resolver.
+func (r *Resolver) WithErr(typ reflect.Type, err error) *Resolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolutions[typ] = resolution{
+		err: err,
+	}
+	return r
+}
+
+// Strict makes a request for a type the resolver was not configured to resolve fail t, in addition
+// to returning a [di.UnknownType], and returns the resolver.
+func (r *Resolver) Strict(t testing.TB) *Resolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strict = t
+	return r
+}
+
+// Resolve implements [di.Resolver].
+func (r *Resolver) Resolve(typ reflect.Type) (any, error) {
+	r.mu.Lock()
+	r.requested = append(r.requested, typ)
+	resolution, ok := r.resolutions[typ]
+	strict := r.strict
+	r.mu.Unlock()
+	if ok {
+		return resolution.value, resolution.err
+	}
+	if strict != nil {
+		strict.Helper()
+		strict.Errorf("ditest: unexpected request to resolve %v", typ)
+	}
+	return nil, di.UnknownType{
+		Type: typ,
+	}
+}
+
+// Requested returns the types the resolver has been asked to resolve, in the order they were
+// requested and including repeats.
+func (r *Resolver) Requested() []reflect.Type {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.requested)
+}
@@ -0,0 +1,113 @@
+package ditest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+type server struct {
+	Name   string
+	Writer io.Writer
+}
+
+func TestResolver(t *testing.T) {
+
+	t.Run("With resolves the value for its dynamic type", func(t *testing.T) {
+		resolver := NewResolver().With("example")
+		actual, err := di.Resolve[string](resolver)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != "example" {
+			t.Fatalf("expected %q; got %q", "example", actual)
+		}
+	})
+
+	t.Run("WithType resolves the value for an interface type", func(t *testing.T) {
+		writer := &strings.Builder{}
+		resolver := NewResolver().WithType(reflect.TypeFor[io.Writer](), writer)
+		actual, err := di.Resolve[io.Writer](resolver)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != writer {
+			t.Fatalf("expected the configured writer")
+		}
+	})
+
+	t.Run("WithErr returns the error for the type", func(t *testing.T) {
+		expected := errors.New("expected error")
+		resolver := NewResolver().WithErr(reflect.TypeFor[string](), expected)
+		if _, err := di.Resolve[string](resolver); !errors.Is(err, expected) {
+			t.Fatalf("expected %v to be %v", err, expected)
+		}
+	})
+
+	t.Run("returns UnknownType for unexpected types", func(t *testing.T) {
+		if _, err := di.Resolve[string](NewResolver()); !errors.Is(err, di.ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, di.ErrUnknownType)
+		}
+	})
+
+	t.Run("Strict fails the test for unexpected types", func(t *testing.T) {
+		tb := &recordingTB{}
+		_, err := di.Resolve[string](NewResolver().Strict(tb))
+		if !errors.Is(err, di.ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, di.ErrUnknownType)
+		}
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "string") {
+			t.Fatalf("expected one failure naming string; got %v", tb.errors)
+		}
+	})
+
+	t.Run("works with default factories", func(t *testing.T) {
+		factory, err := di.GetDefaultFactory[server]()
+		if err != nil {
+			t.Fatalf("unexpected error from GetDefaultFactory: %v", err)
+		}
+		writer := &strings.Builder{}
+		resolver := NewResolver().
+			With("api").
+			WithType(reflect.TypeFor[io.Writer](), writer).
+			Strict(t)
+		actual, err := factory(resolver)
+		if err != nil {
+			t.Fatalf("unexpected error from factory: %v", err)
+		}
+		if actual.Name != "api" || actual.Writer != writer {
+			t.Fatalf("expected the configured values; got %+v", actual)
+		}
+	})
+
+	t.Run("Requested records the requested types in order", func(t *testing.T) {
+		resolver := NewResolver().With("example")
+		_, _ = di.Resolve[string](resolver)
+		_, _ = di.Resolve[int](resolver)
+		_, _ = di.Resolve[string](resolver)
+		expected := []reflect.Type{
+			reflect.TypeFor[string](),
+			reflect.TypeFor[int](),
+			reflect.TypeFor[string](),
+		}
+		if actual := resolver.Requested(); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package ditest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// updateSnapshots is the -update flag, which makes SnapshotGraph rewrite the snapshots. Packages
+// that use SnapshotGraph share the flag, so they must not define an -update flag of their own.
+var updateSnapshots = flag.Bool("update", false, "rewrite the dependency graph snapshots")
+
+// A SnapshotOption configures optional behavior for [SnapshotGraph].
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	sites bool
+}
+
+// WithSites makes [SnapshotGraph] include the site of each registration, which changes whenever
+// the code around a registration moves, in the snapshot.
+func WithSites() SnapshotOption {
+	return func(options *snapshotOptions) {
+		options.sites = true
+	}
+}
+
+// SnapshotGraph compares the [di.Graph] of the registrations in registry, as written by
+// [di.RootProvider.GraphJSON], with the golden file at path, and fails t with a diff if they
+// differ so that wiring changes can be reviewed. When the test is run with -update the file is
+// written instead. The snapshot is the same every time for the same registrations; the sites of
+// the registrations are left out unless [WithSites] is given.
+func SnapshotGraph(t testing.TB, registry di.Registry, path string, opts ...SnapshotOption) {
+	t.Helper()
+	options := snapshotOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	actual, err := graphSnapshot(registry, options)
+	if err != nil {
+		t.Errorf("dependency graph snapshot failed: %v", err)
+		return
+	}
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("dependency graph snapshot failed: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Errorf("dependency graph snapshot failed: %v", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("dependency graph snapshot %s does not exist; run the test with -update to create it", path)
+		return
+	}
+	if err != nil {
+		t.Errorf("dependency graph snapshot failed: %v", err)
+		return
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf(
+			"dependency graph differs from snapshot %s; run the test with -update to accept the changes:\n%s",
+			path,
+			lineDiff(string(expected), string(actual)))
+	}
+}
+
+func graphSnapshot(registry di.Registry, options snapshotOptions) ([]byte, error) {
+	provider, err := registry.BuildRootProvider()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build the provider: %w", err)
+	}
+	var b bytes.Buffer
+	if err := provider.GraphJSON(&b); err != nil {
+		return nil, err
+	}
+	if options.sites {
+		return b.Bytes(), nil
+	}
+	var graph di.Graph
+	if err := json.Unmarshal(b.Bytes(), &graph); err != nil {
+		return nil, err
+	}
+	for i := range graph.Nodes {
+		graph.Nodes[i].Site = ""
+	}
+	snapshot, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(snapshot, '\n'), nil
+}
+
+// lineDiff describes the changes from expected to actual line by line, prefixing removed lines
+// with "-", added lines with "+", and unchanged lines with a space.
+func lineDiff(expected string, actual string) string {
+	a := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	diff := strings.Builder{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&diff, "  %s\n", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&diff, "+ %s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(&diff, "- %s\n", a[i])
+			i++
+		}
+	}
+	return diff.String()
+}
@@ -0,0 +1,78 @@
+package ditest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+func TestSnapshotGraph(t *testing.T) {
+
+	register := func(t *testing.T) func(di.Registry, error) di.Registry {
+		return func(registry di.Registry, err error) di.Registry {
+			t.Helper()
+			if err != nil {
+				t.Fatalf("unexpected error from registration: %v", err)
+			}
+			return registry
+		}
+	}
+
+	update := func(t *testing.T) {
+		*updateSnapshots = true
+		t.Cleanup(func() {
+			*updateSnapshots = false
+		})
+	}
+
+	t.Run("writes the snapshot with -update and then matches it", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "testdata", "graph.json")
+		registry := register(t)(di.RegisterType[*apiServer, *apiServer](di.Registry{}, di.Scoped))
+		t.Run("update", func(t *testing.T) {
+			update(t)
+			SnapshotGraph(t, registry, path)
+		})
+		snapshot, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error from ReadFile: %v", err)
+		}
+		if strings.Contains(string(snapshot), "site") {
+			t.Errorf("expected the snapshot to leave out the registration sites; got %s", snapshot)
+		}
+		tb := &recordingTB{}
+		SnapshotGraph(tb, registry, path)
+		if len(tb.errors) > 0 {
+			t.Fatalf("expected no failures; got %v", tb.errors)
+		}
+	})
+
+	t.Run("reports a diff when the graph changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "graph.json")
+		registry := register(t)(di.RegisterType[*apiServer, *apiServer](di.Registry{}, di.Scoped))
+		t.Run("update", func(t *testing.T) {
+			update(t)
+			SnapshotGraph(t, registry, path)
+		})
+		registry = register(t)(di.RegisterType[*repo, *repo](registry, di.Singleton))
+		tb := &recordingTB{}
+		SnapshotGraph(tb, registry, path)
+		if len(tb.errors) != 1 {
+			t.Fatalf("expected one failure; got %v", tb.errors)
+		}
+		if report := tb.errors[0]; !strings.Contains(report, "run the test with -update") ||
+			!strings.Contains(report, `+       "type": "*ditest.repo",`) {
+			t.Fatalf("expected a diff adding the repo; got %s", report)
+		}
+	})
+
+	t.Run("reports a missing snapshot", func(t *testing.T) {
+		tb := &recordingTB{}
+		SnapshotGraph(tb, di.Registry{}, filepath.Join(t.TempDir(), "missing.json"))
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "does not exist") {
+			t.Fatalf("expected the snapshot to be reported missing; got %v", tb.errors)
+		}
+	})
+}
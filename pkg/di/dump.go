@@ -0,0 +1,213 @@
+package di
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxDumpTypeWidth is the number of characters a type name may use in a dump before it is trimmed.
+const maxDumpTypeWidth = 48
+
+// String implements [fmt.Stringer] by summarizing the registry for debugging with the number of
+// registrations for each lifetime.
+func (r Registry) String() string {
+	counts := map[Lifetime]int{}
+	for _, registration := range r.registrations {
+		counts[registration.lifetime]++
+	}
+	lifetimes := make([]Lifetime, 0, len(counts))
+	for lifetime := range counts {
+		lifetimes = append(lifetimes, lifetime)
+	}
+	slices.Sort(lifetimes)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Registry(registrations=%d", len(r.registrations))
+	for _, lifetime := range lifetimes {
+		fmt.Fprintf(&b, ", %v=%d", lifetime, counts[lifetime])
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// A DumpOption configures the output of [RootProvider.Dump] and [Scope.Dump].
+type DumpOption func(*dumpOptions)
+
+type dumpOptions struct {
+	verbose    bool
+	timestamps bool
+}
+
+func newDumpOptions(opts []DumpOption) dumpOptions {
+	options := dumpOptions{
+		timestamps: true,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithVerboseTypes makes a dump use the full names of types, including their package paths, and
+// the full paths of registration sites. By default type names are qualified by package name only
+// and trimmed to a bounded width, and registration sites only include the file name.
+func WithVerboseTypes() DumpOption {
+	return func(options *dumpOptions) {
+		options.verbose = true
+	}
+}
+
+// WithoutTimestamps omits the creation times of instances from a dump so that the output can be
+// compared with golden files.
+func WithoutTimestamps() DumpOption {
+	return func(options *dumpOptions) {
+		options.timestamps = false
+	}
+}
+
+// Dump writes a table describing each registration the provider uses to w for debugging, ordered
+// by type name. Each row has the target type, the implementation type, the lifetime, whether
+// instances come from a factory, a default factory, an instance, a target-aware factory shared
+// with other rows, or the parent of a child provider, and the site of the registration if it is
+// known. Dump does not resolve anything.
+func (provider RootProvider) Dump(w io.Writer, opts ...DumpOption) error {
+	if err := provider.checkInitialized("Dump"); err != nil {
+		return err
+	}
+	options := newDumpOptions(opts)
+	registrations := provider.registrations.load()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tIMPL\tLIFETIME\tSOURCE\tSITE")
+	for _, typ := range sortedTypes(registrations) {
+		registration := registrations[typ]
+		source := registration.source.String()
+		if registration.inherited {
+			source = "inherited"
+		}
+		site := registration.site
+		if site == "" {
+			site = "-"
+		} else if !options.verbose {
+			site = filepath.Base(site)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\t%s\n",
+			options.typeName(typ),
+			options.typeName(registration.impl),
+			registration.lifetime,
+			source,
+			site)
+	}
+	return tw.Flush()
+}
+
+// Dump writes a table describing each [Scoped] value the scope has created to w for debugging, in
+// the order they were created. Each row has the registered type and the type of the value and, if
+// the provider was built with [WithProvenance], the time the value was created unless
+// [WithoutTimestamps] is given and the resolution path that constructed the value. The values
+// inherited from a parent scope are not included. Dump does not resolve anything.
+func (scope Scope) Dump(w io.Writer, opts ...DumpOption) error {
+	if err := scope.checkInitialized("Dump"); err != nil {
+		return err
+	}
+	if err := scope.acquireState(); err != nil {
+		return err
+	}
+	defer scope.releaseState()
+	options := newDumpOptions(opts)
+	provenance := scope.root.options.provenance
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "TYPE\tVALUE")
+	if provenance {
+		if options.timestamps {
+			fmt.Fprint(tw, "\tCREATED")
+		}
+		fmt.Fprint(tw, "\tPATH")
+	}
+	fmt.Fprintln(tw)
+	for _, entry := range scope.state.scopedValues.entries() {
+		fmt.Fprintf(tw, "%s\t%s",
+			options.typeName(entry.typ),
+			options.typeName(reflect.TypeOf(entry.value)))
+		if provenance {
+			record, ok := scope.state.scopedValues.provenance.get(entry.typ, entry.value)
+			if options.timestamps {
+				fmt.Fprintf(tw, "\t%s", options.created(record, ok))
+			}
+			fmt.Fprintf(tw, "\t%s", options.path(record, ok))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// typeName returns the name of typ to include in a dump.
+func (options dumpOptions) typeName(typ reflect.Type) string {
+	if typ == nil {
+		return "<nil>"
+	}
+	if options.verbose {
+		return qualifiedTypeName(typ)
+	}
+	name := typ.String()
+	if len(name) > maxDumpTypeWidth {
+		return name[:maxDumpTypeWidth-3] + "..."
+	}
+	return name
+}
+
+// created returns the creation time of an instance with the given provenance to include in a
+// dump, or "-" if ok is false.
+func (options dumpOptions) created(provenance Provenance, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return provenance.Created.Format(time.RFC3339Nano)
+}
+
+// path returns the resolution path of an instance with the given provenance to include in a dump,
+// or "-" if ok is false.
+func (options dumpOptions) path(provenance Provenance, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	names := make([]string, 0, len(provenance.Path))
+	for _, typ := range provenance.Path {
+		names = append(names, options.typeName(typ))
+	}
+	return strings.Join(names, " -> ")
+}
+
+// qualifiedTypeName returns the name of typ with the full paths of the packages of the named types
+// it is composed of.
+func qualifiedTypeName(typ reflect.Type) string {
+	if typ.Name() != "" {
+		if typ.PkgPath() == "" {
+			return typ.Name()
+		}
+		return typ.PkgPath() + "." + typ.Name()
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return "*" + qualifiedTypeName(typ.Elem())
+	case reflect.Slice:
+		return "[]" + qualifiedTypeName(typ.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", typ.Len(), qualifiedTypeName(typ.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", qualifiedTypeName(typ.Key()), qualifiedTypeName(typ.Elem()))
+	case reflect.Chan:
+		switch typ.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + qualifiedTypeName(typ.Elem())
+		case reflect.SendDir:
+			return "chan<- " + qualifiedTypeName(typ.Elem())
+		}
+		return "chan " + qualifiedTypeName(typ.Elem())
+	}
+	return typ.String()
+}
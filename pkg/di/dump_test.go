@@ -0,0 +1,182 @@
+package di
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpA struct{}
+
+type dumpB struct{}
+
+type dumpWithAVeryLongNameThatDoesNotFitInTheColumnWidth struct{}
+
+func TestRegistryString(t *testing.T) {
+
+	t.Run("summarizes the registrations by lifetime", func(t *testing.T) {
+		registry, err := RegisterType[*dumpA, *dumpA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*dumpB, *dumpB](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterInstance[string](registry, "value", AllowSharedValue())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		expected := "Registry(registrations=3, Transient=1, Singleton=2)"
+		if actual := registry.String(); actual != expected {
+			t.Fatalf("expected %q; got %q", expected, actual)
+		}
+	})
+
+	t.Run("summarizes an empty registry", func(t *testing.T) {
+		expected := "Registry(registrations=0)"
+		if actual := (Registry{}).String(); actual != expected {
+			t.Fatalf("expected %q; got %q", expected, actual)
+		}
+	})
+}
+
+func TestDump(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[*dumpA, *dumpA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*dumpB](registry, Scoped, func(Resolver) (*dumpB, error) {
+			t.Errorf("expected Dump not to call factories")
+			return &dumpB{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterInstance[string](registry, "value", AllowSharedValue())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, err = RegisterType[
+			*dumpWithAVeryLongNameThatDoesNotFitInTheColumnWidth,
+			*dumpWithAVeryLongNameThatDoesNotFitInTheColumnWidth,
+		](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("RootProvider", func(t *testing.T) {
+
+		t.Run("lists the registrations in a stable order", func(t *testing.T) {
+			provider := newProvider(t)
+			var first, second bytes.Buffer
+			if err := provider.Dump(&first); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			if err := provider.Dump(&second); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			if first.String() != second.String() {
+				t.Fatalf("expected the output to be deterministic:\n%s\n%s", &first, &second)
+			}
+			checkGolden(t, "dump_provider.golden", strings.TrimSuffix(first.String(), "\n"))
+		})
+
+		t.Run("WithVerboseTypes includes package paths and untrimmed names", func(t *testing.T) {
+			var b bytes.Buffer
+			if err := newProvider(t).Dump(&b, WithVerboseTypes()); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			checkGolden(t, "dump_provider_verbose.golden", strings.TrimSuffix(b.String(), "\n"))
+		})
+
+		t.Run("returns UninitializedProvider for an uninitialized provider", func(t *testing.T) {
+			var b bytes.Buffer
+			if err := (RootProvider{}).Dump(&b); !errors.Is(err, ErrUninitializedProvider) {
+				t.Fatalf("expected %v to be %v", err, ErrUninitializedProvider)
+			}
+		})
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+
+		newScope := func(t *testing.T, opts ...ProviderOption) Scope {
+			registry, err := RegisterType[*dumpA, *dumpA](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*dumpB, *dumpB](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider(opts...)
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := Resolve[*dumpB](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if _, err := Resolve[*dumpA](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			return scope
+		}
+
+		t.Run("lists the scoped values in the order they were created", func(t *testing.T) {
+			var b bytes.Buffer
+			if err := newScope(t).Dump(&b, WithoutTimestamps()); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			checkGolden(t, "dump_scope.golden", strings.TrimSuffix(b.String(), "\n"))
+		})
+
+		t.Run("includes the creation times when provenance is recorded", func(t *testing.T) {
+			before := time.Now()
+			scope := newScope(t, WithProvenance())
+			var b bytes.Buffer
+			if err := scope.Dump(&b); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("expected a header and 2 rows; got:\n%s", &b)
+			}
+			fields := strings.Fields(lines[1])
+			created, err := time.Parse(time.RFC3339Nano, fields[len(fields)-2])
+			if err != nil {
+				t.Fatalf("unexpected error from Parse: %v", err)
+			}
+			if created.Before(before.Truncate(time.Second)) {
+				t.Fatalf("expected the creation time %v to be after %v", created, before)
+			}
+		})
+
+		t.Run("includes the resolution paths when provenance is recorded", func(t *testing.T) {
+			var b bytes.Buffer
+			if err := newScope(t, WithProvenance()).Dump(&b, WithoutTimestamps()); err != nil {
+				t.Fatalf("unexpected error from Dump: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("expected a header and 2 rows; got:\n%s", &b)
+			}
+			if fields := strings.Fields(lines[0]); fields[len(fields)-1] != "PATH" {
+				t.Fatalf("expected a PATH column; got %q", lines[0])
+			}
+			if fields := strings.Fields(lines[1]); fields[len(fields)-1] != "*di.dumpB" {
+				t.Fatalf("expected the path of *di.dumpB to be itself; got %q", lines[1])
+			}
+		})
+	})
+}
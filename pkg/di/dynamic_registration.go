@@ -0,0 +1,109 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNilType is returned when a nil [reflect.Type] is given to a function that registers types
+// known only at runtime, such as [RegisterDynamicType].
+var ErrNilType = errors.New("type must not be nil")
+
+// A NilType is an [error] indicating that a nil [reflect.Type] was given to a function that
+// registers types known only at runtime. Calling [errors.Is] with a NilType and [ErrNilType]
+// returns true.
+type NilType struct {
+
+	// Parameter is the name of the parameter that was nil.
+	Parameter string
+}
+
+// Error implements [error].
+func (err NilType) Error() string {
+	return fmt.Sprintf("type %s must not be nil", err.Parameter)
+}
+
+// Is indicates that a [NilType] is [ErrNilType].
+func (NilType) Is(target error) bool {
+	return target == ErrNilType
+}
+
+// Code returns "nil_type", the code of a [NilType] in [ErrorCodes].
+func (NilType) Code() string {
+	return "nil_type"
+}
+
+// RegisterDynamicType registers impl as the implementation of target using its default factory,
+// like [RegisterType] for types that are only known at runtime, e.g. implementations discovered by
+// a plugin host. It validates the types in the same way and makes the same registration as
+// RegisterType[Target, Impl], and returns the same errors, or a [NilType] if target or impl is nil.
+func RegisterDynamicType(
+	registry Registry,
+	target reflect.Type,
+	impl reflect.Type,
+	lifetime Lifetime,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	if target == nil {
+		return registry, NilType{
+			Parameter: "target",
+		}
+	}
+	if impl == nil {
+		return registry, NilType{
+			Parameter: "impl",
+		}
+	}
+	return registerDefaultFactory(registry, target, target, impl, lifetime, opts)
+}
+
+// RegisterDynamicFactory registers factory as the implementation of target, like
+// [RegisterFactory] for types that are only known at runtime, e.g. to build a registry from
+// configuration. The factory declares that it produces values of the concrete type produces, which
+// is validated against target and lifetime in the same way as the Impl of RegisterFactory, and
+// RegisterDynamicFactory returns the same errors, or a [NilType] if target or produces is nil.
+//
+// Each value the factory returns is checked against produces: resolving target fails with an
+// [InvalidResolution] if the value has another type, or a [NilResolution] if it is an untyped nil.
+func RegisterDynamicFactory(
+	registry Registry,
+	target reflect.Type,
+	lifetime Lifetime,
+	factory func(Resolver) (any, error),
+	produces reflect.Type,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	if target == nil {
+		return registry, NilType{
+			Parameter: "target",
+		}
+	}
+	if produces == nil {
+		return registry, NilType{
+			Parameter: "produces",
+		}
+	}
+	var checked factoryFunc
+	if factory != nil {
+		checked = func(resolver Resolver) (any, error) {
+			value, err := factory(resolver)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				return nil, NilResolution{
+					Type: produces,
+				}
+			}
+			if typ := reflect.TypeOf(value); typ != produces {
+				return nil, InvalidResolution{
+					Requested: produces,
+					Returned:  typ,
+				}
+			}
+			return value, nil
+		}
+	}
+	return registerFactoryFunc(registry, target, target, produces, lifetime, checked, opts)
+}
@@ -0,0 +1,353 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type dynamicPlugin struct {
+	Greeter forkGreeter
+}
+
+func TestRegisterDynamicType(t *testing.T) {
+
+	t.Run("matches RegisterType", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			generic  func(Registry) (Registry, error)
+			target   reflect.Type
+			impl     reflect.Type
+			lifetime Lifetime
+		}{
+			{
+				name: "no default factory",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[interface{}, func(int) int](registry, Transient)
+				},
+				target:   reflect.TypeFor[interface{}](),
+				impl:     reflect.TypeFor[func(int) int](),
+				lifetime: Transient,
+			},
+			{
+				name: "invalid implementation",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[string, struct{}](registry, Transient)
+				},
+				target:   reflect.TypeFor[string](),
+				impl:     reflect.TypeFor[struct{}](),
+				lifetime: Transient,
+			},
+			{
+				name: "pointer receiver implementation",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[forkGreeter, englishGreeter](registry, Transient)
+				},
+				target:   reflect.TypeFor[forkGreeter](),
+				impl:     reflect.TypeFor[englishGreeter](),
+				lifetime: Transient,
+			},
+			{
+				name: "pointer to interface",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[any, **io.Reader](registry, Transient)
+				},
+				target:   reflect.TypeFor[any](),
+				impl:     reflect.TypeFor[**io.Reader](),
+				lifetime: Transient,
+			},
+			{
+				name: "undefined lifetime",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[interface{}, struct{}](registry, Lifetime(13))
+				},
+				target:   reflect.TypeFor[interface{}](),
+				impl:     reflect.TypeFor[struct{}](),
+				lifetime: Lifetime(13),
+			},
+			{
+				name: "scoped struct",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[interface{}, struct{}](registry, Scoped)
+				},
+				target:   reflect.TypeFor[interface{}](),
+				impl:     reflect.TypeFor[struct{}](),
+				lifetime: Scoped,
+			},
+			{
+				name: "singleton slice",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[[]int, []int](registry, Singleton)
+				},
+				target:   reflect.TypeFor[[]int](),
+				impl:     reflect.TypeFor[[]int](),
+				lifetime: Singleton,
+			},
+			{
+				name: "value receiver implementation",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[fmt.Stringer, *time.Duration](registry, Transient)
+				},
+				target:   reflect.TypeFor[fmt.Stringer](),
+				impl:     reflect.TypeFor[*time.Duration](),
+				lifetime: Transient,
+			},
+			{
+				name: "scoped *struct",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[*dynamicPlugin, *dynamicPlugin](registry, Scoped)
+				},
+				target:   reflect.TypeFor[*dynamicPlugin](),
+				impl:     reflect.TypeFor[*dynamicPlugin](),
+				lifetime: Scoped,
+			},
+			{
+				name: "singleton chan",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterType[chan int, chan int](registry, Singleton)
+				},
+				target:   reflect.TypeFor[chan int](),
+				impl:     reflect.TypeFor[chan int](),
+				lifetime: Singleton,
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				generic, genericErr := tc.generic(Registry{})
+				dynamic, dynamicErr := RegisterDynamicType(Registry{}, tc.target, tc.impl, tc.lifetime)
+				if !reflect.DeepEqual(dynamicErr, genericErr) {
+					t.Fatalf("expected error %v; got %v", genericErr, dynamicErr)
+				}
+				expected, actual := generic.Registrations(), dynamic.Registrations()
+				if !reflect.DeepEqual(actual, expected) {
+					t.Fatalf("expected registrations %+v; got %+v", expected, actual)
+				}
+			})
+		}
+	})
+
+	t.Run("registrations resolve like RegisterType", func(t *testing.T) {
+		registry, err := RegisterType[forkGreeter, *englishGreeter](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterDynamicType(
+			registry,
+			reflect.TypeFor[*dynamicPlugin](),
+			reflect.TypeFor[*dynamicPlugin](),
+			Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterDynamicType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		plugin, err := Resolve[*dynamicPlugin](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok := plugin.Greeter.(*englishGreeter); !ok {
+			t.Errorf("expected the Greeter field to be injected; got %T", plugin.Greeter)
+		}
+		if err := registry.Verify(); err != nil {
+			t.Errorf("unexpected error from Verify: %v", err)
+		}
+	})
+
+	t.Run("returns NilType for nil types", func(t *testing.T) {
+		typ := reflect.TypeFor[*dynamicPlugin]()
+		for _, tc := range []struct {
+			target    reflect.Type
+			impl      reflect.Type
+			parameter string
+		}{
+			{nil, typ, "target"},
+			{typ, nil, "impl"},
+		} {
+			_, err := RegisterDynamicType(Registry{}, tc.target, tc.impl, Transient)
+			if !errors.Is(err, ErrNilType) {
+				t.Fatalf("expected %v to be %v", err, ErrNilType)
+			}
+			if nilType := (NilType{}); !errors.As(err, &nilType) || nilType.Parameter != tc.parameter {
+				t.Errorf("expected a NilType for %s; got %v", tc.parameter, err)
+			}
+		}
+	})
+}
+
+func TestRegisterDynamicFactory(t *testing.T) {
+
+	pluginFactory := func(Resolver) (any, error) {
+		return &dynamicPlugin{}, nil
+	}
+
+	t.Run("matches RegisterFactory", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			generic  func(Registry) (Registry, error)
+			target   reflect.Type
+			lifetime Lifetime
+			factory  func(Resolver) (any, error)
+			produces reflect.Type
+		}{
+			{
+				name: "non-concrete implementation",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[fmt.Stringer](registry, Transient, func(Resolver) (fmt.Stringer, error) {
+						return nil, nil
+					})
+				},
+				target:   reflect.TypeFor[fmt.Stringer](),
+				lifetime: Transient,
+				factory:  pluginFactory,
+				produces: reflect.TypeFor[fmt.Stringer](),
+			},
+			{
+				name: "invalid implementation",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[string](registry, Transient, func(Resolver) (struct{}, error) {
+						return struct{}{}, nil
+					})
+				},
+				target:   reflect.TypeFor[string](),
+				lifetime: Transient,
+				factory:  pluginFactory,
+				produces: reflect.TypeFor[struct{}](),
+			},
+			{
+				name: "undefined lifetime",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[*dynamicPlugin](registry, Lifetime(13), func(Resolver) (*dynamicPlugin, error) {
+						return nil, nil
+					})
+				},
+				target:   reflect.TypeFor[*dynamicPlugin](),
+				lifetime: Lifetime(13),
+				factory:  pluginFactory,
+				produces: reflect.TypeFor[*dynamicPlugin](),
+			},
+			{
+				name: "scoped struct",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[any](registry, Scoped, func(Resolver) (struct{}, error) {
+						return struct{}{}, nil
+					})
+				},
+				target:   reflect.TypeFor[any](),
+				lifetime: Scoped,
+				factory:  pluginFactory,
+				produces: reflect.TypeFor[struct{}](),
+			},
+			{
+				name: "nil factory",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[*dynamicPlugin, *dynamicPlugin](registry, Singleton, nil)
+				},
+				target:   reflect.TypeFor[*dynamicPlugin](),
+				lifetime: Singleton,
+				produces: reflect.TypeFor[*dynamicPlugin](),
+			},
+			{
+				name: "singleton pointer",
+				generic: func(registry Registry) (Registry, error) {
+					return RegisterFactory[*dynamicPlugin](registry, Singleton, func(Resolver) (*dynamicPlugin, error) {
+						return &dynamicPlugin{}, nil
+					})
+				},
+				target:   reflect.TypeFor[*dynamicPlugin](),
+				lifetime: Singleton,
+				factory:  pluginFactory,
+				produces: reflect.TypeFor[*dynamicPlugin](),
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				generic, genericErr := tc.generic(Registry{})
+				dynamic, dynamicErr := RegisterDynamicFactory(Registry{}, tc.target, tc.lifetime, tc.factory, tc.produces)
+				if !reflect.DeepEqual(dynamicErr, genericErr) {
+					t.Fatalf("expected error %v; got %v", genericErr, dynamicErr)
+				}
+				expected, actual := generic.Registrations(), dynamic.Registrations()
+				if !reflect.DeepEqual(actual, expected) {
+					t.Fatalf("expected registrations %+v; got %+v", expected, actual)
+				}
+			})
+		}
+	})
+
+	newProvider := func(t *testing.T, factory func(Resolver) (any, error)) RootProvider {
+		registry, err := RegisterDynamicFactory(
+			Registry{},
+			reflect.TypeFor[any](),
+			Singleton,
+			factory,
+			reflect.TypeFor[*dynamicPlugin]())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterDynamicFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("resolves and caches the factory's values", func(t *testing.T) {
+		provider := newProvider(t, pluginFactory)
+		first, err := Resolve[any](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		second, err := Resolve[any](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok := first.(*dynamicPlugin); !ok || first != second {
+			t.Fatalf("expected the same *dynamicPlugin twice; got %v and %v", first, second)
+		}
+	})
+
+	t.Run("returns InvalidResolution for values of another type", func(t *testing.T) {
+		provider := newProvider(t, func(Resolver) (any, error) {
+			return "plugin", nil
+		})
+		_, err := Resolve[any](provider)
+		var invalid InvalidResolution
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected %v to be an InvalidResolution", err)
+		}
+		if invalid.Returned != reflect.TypeFor[string]() || invalid.Requested != reflect.TypeFor[*dynamicPlugin]() {
+			t.Errorf("expected a string where *dynamicPlugin was declared; got %v", invalid)
+		}
+	})
+
+	t.Run("returns NilResolution for untyped nils", func(t *testing.T) {
+		provider := newProvider(t, func(Resolver) (any, error) {
+			return nil, nil
+		})
+		if _, err := Resolve[any](provider); !errors.Is(err, ErrNilResolution) {
+			t.Fatalf("expected %v to be %v", err, ErrNilResolution)
+		}
+	})
+
+	t.Run("returns NilType for nil types", func(t *testing.T) {
+		typ := reflect.TypeFor[*dynamicPlugin]()
+		for _, tc := range []struct {
+			target    reflect.Type
+			produces  reflect.Type
+			parameter string
+		}{
+			{nil, typ, "target"},
+			{typ, nil, "produces"},
+		} {
+			_, err := RegisterDynamicFactory(Registry{}, tc.target, Transient, pluginFactory, tc.produces)
+			if nilType := (NilType{}); !errors.As(err, &nilType) || nilType.Parameter != tc.parameter {
+				t.Errorf("expected a NilType for %s; got %v", tc.parameter, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package di
+
+import "reflect"
+
+// An ErrorCode describes one of the stable, machine-readable codes returned by the Code methods
+// of the package's error types, e.g. for mapping errors to API responses without matching their
+// messages. Codes are part of the package's API and do not change once released.
+type ErrorCode struct {
+
+	// Code is the code returned by the error type's Code method.
+	Code string
+
+	// Type is the error type.
+	Type reflect.Type
+
+	// Sentinel is the error that [errors.Is] matches the error type against, or nil if it has
+	// none.
+	Sentinel error
+}
+
+// ErrorCodes returns a description of the code of each of the package's error types, ordered by
+// code. An error that wraps another, such as a [ResolutionError], has its own code so handlers
+// that need the code of the underlying error should look past it with [errors.As].
+func ErrorCodes() []ErrorCode {
+	return []ErrorCode{
+		{Code: "already_resolved", Type: reflect.TypeFor[AlreadyResolved](), Sentinel: ErrAlreadyResolved},
+		{Code: "auto_resolved", Type: reflect.TypeFor[AutoResolved](), Sentinel: ErrAutoResolved},
+		{Code: "captive_dependency", Type: reflect.TypeFor[CaptiveDependency](), Sentinel: ErrCaptiveDependency},
+		{Code: "close_context_already_done", Type: reflect.TypeFor[CloseContextAlreadyDone](), Sentinel: ErrCloseContextAlreadyDone},
+		{Code: "close_halted", Type: reflect.TypeFor[CloseHalted](), Sentinel: ErrCloseHalted},
+		{Code: "closer_error", Type: reflect.TypeFor[CloserError](), Sentinel: ErrCloser},
+		{Code: "closer_timeout", Type: reflect.TypeFor[CloserTimeout](), Sentinel: ErrCloserTimeout},
+		{Code: "conflicting_options", Type: reflect.TypeFor[ConflictingOptions](), Sentinel: ErrConflictingOptions},
+		{Code: "construction_error", Type: reflect.TypeFor[ConstructionError](), Sentinel: ErrConstructionFailed},
+		{Code: "default_provider_set", Type: reflect.TypeFor[DefaultProviderSet](), Sentinel: ErrDefaultProviderSet},
+		{Code: "deferred_cleanup_error", Type: reflect.TypeFor[DeferredCleanupError](), Sentinel: ErrDeferredCleanup},
+		{Code: "dependency_cycle", Type: reflect.TypeFor[DependencyCycle](), Sentinel: ErrDependencyCycle},
+		{Code: "deprecated_dependency", Type: reflect.TypeFor[DeprecatedDependency](), Sentinel: ErrDeprecatedDependency},
+		{Code: "deprecated_resolution", Type: reflect.TypeFor[DeprecatedResolution](), Sentinel: ErrDeprecated},
+		{Code: "duplicate_key_name", Type: reflect.TypeFor[DuplicateKeyName](), Sentinel: ErrDuplicateKeyName},
+		{Code: "duplicate_supply", Type: reflect.TypeFor[DuplicateSupply](), Sentinel: ErrDuplicateSupply},
+		{Code: "factory_timeout", Type: reflect.TypeFor[FactoryTimeout](), Sentinel: ErrFactoryTimeout},
+		{Code: "fallback_error", Type: reflect.TypeFor[FallbackError](), Sentinel: ErrFallbackFailed},
+		{Code: "hosted_service_cycle", Type: reflect.TypeFor[HostedServiceCycle](), Sentinel: ErrHostedServiceCycle},
+		{Code: "hosted_service_failed", Type: reflect.TypeFor[HostedServiceFailed](), Sentinel: ErrHostedServiceFailed},
+		{Code: "import_error", Type: reflect.TypeFor[ImportError](), Sentinel: ErrImportFailed},
+		{Code: "incomplete_close", Type: reflect.TypeFor[IncompleteClose](), Sentinel: ErrCloseTimeout},
+		{Code: "internal_error", Type: reflect.TypeFor[InternalError](), Sentinel: ErrInternal},
+		{Code: "invalid_hosted_service", Type: reflect.TypeFor[InvalidHostedService](), Sentinel: ErrInvalidHostedService},
+		{Code: "invalid_implementation", Type: reflect.TypeFor[InvalidImplementation](), Sentinel: ErrInvalidImplementation},
+		{Code: "invalid_resolution", Type: reflect.TypeFor[InvalidResolution](), Sentinel: ErrInvalidResolution},
+		{Code: "invalid_supply", Type: reflect.TypeFor[InvalidSupply]()},
+		{Code: "invalid_type_rewrite", Type: reflect.TypeFor[InvalidTypeRewrite](), Sentinel: ErrInvalidTypeRewrite},
+		{Code: "job_panicked", Type: reflect.TypeFor[JobPanicked](), Sentinel: ErrJobPanicked},
+		{Code: "missing_dependency", Type: reflect.TypeFor[MissingDependency](), Sentinel: ErrMissingDependency},
+		{Code: "nil_instance", Type: reflect.TypeFor[NilInstance](), Sentinel: ErrNilInstance},
+		{Code: "nil_resolution", Type: reflect.TypeFor[NilResolution](), Sentinel: ErrNilResolution},
+		{Code: "nil_supply", Type: reflect.TypeFor[NilSupply](), Sentinel: ErrNilSupply},
+		{Code: "nil_type", Type: reflect.TypeFor[NilType](), Sentinel: ErrNilType},
+		{Code: "no_default_factory", Type: reflect.TypeFor[NoDefaultFactory](), Sentinel: ErrNoDefaultFactory},
+		{Code: "no_identity", Type: reflect.TypeFor[NoIdentity](), Sentinel: ErrNoIdentity},
+		{Code: "no_scope_in_context", Type: reflect.TypeFor[NoScopeInContext](), Sentinel: ErrNoScopeInContext},
+		{Code: "non_concrete_implementation", Type: reflect.TypeFor[NonConcreteImplementation](), Sentinel: ErrNonConcreteImplementation},
+		{Code: "not_cached", Type: reflect.TypeFor[NotCached](), Sentinel: ErrNotCached},
+		{Code: "not_func", Type: reflect.TypeFor[NotFunc](), Sentinel: ErrNotFunc},
+		{Code: "not_refreshable", Type: reflect.TypeFor[NotRefreshable](), Sentinel: ErrNotRefreshable},
+		{Code: "not_singleton", Type: reflect.TypeFor[NotSingleton](), Sentinel: ErrNotSingleton},
+		{Code: "not_transient", Type: reflect.TypeFor[NotTransient](), Sentinel: ErrNotTransient},
+		{Code: "on_close_panic", Type: reflect.TypeFor[OnClosePanic](), Sentinel: ErrOnClosePanic},
+		{Code: "pointer_receiver_implementation", Type: reflect.TypeFor[PointerReceiverImplementation](), Sentinel: ErrInvalidImplementation},
+		{Code: "pointer_to_interface", Type: reflect.TypeFor[PointerToInterface](), Sentinel: ErrPointerToInterface},
+		{Code: "provider_frozen", Type: reflect.TypeFor[ProviderFrozen](), Sentinel: ErrProviderFrozen},
+		{Code: "resolution_budget_exceeded", Type: reflect.TypeFor[ResolutionBudgetExceeded](), Sentinel: ErrResolutionBudgetExceeded},
+		{Code: "resolution_error", Type: reflect.TypeFor[ResolutionError](), Sentinel: ErrResolutionFailed},
+		{Code: "resolve_all_unsupported", Type: reflect.TypeFor[ResolveAllUnsupported](), Sentinel: ErrResolveAllUnsupported},
+		{Code: "resolve_new_unsupported", Type: reflect.TypeFor[ResolveNewUnsupported](), Sentinel: ErrResolveNewUnsupported},
+		{Code: "scope_closed", Type: reflect.TypeFor[ScopeClosed](), Sentinel: ErrScopeClosed},
+		{Code: "scope_name_mismatch", Type: reflect.TypeFor[ScopeNameMismatch](), Sentinel: ErrScopeNameMismatch},
+		{Code: "scoped_value_requested_from_root_provider", Type: reflect.TypeFor[ScopedValueRequestedFromRootProvider](), Sentinel: ErrScopedValueRequestedFromRootProvider},
+		{Code: "singleton_constructed", Type: reflect.TypeFor[SingletonConstructed](), Sentinel: ErrSingletonConstructed},
+		{Code: "type_rewrite_cycle", Type: reflect.TypeFor[TypeRewriteCycle](), Sentinel: ErrTypeRewriteCycle},
+		{Code: "undefined_lifetime", Type: reflect.TypeFor[UndefinedLifetime](), Sentinel: ErrUndefinedLifetime},
+		{Code: "undisposed_transient", Type: reflect.TypeFor[UndisposedTransient](), Sentinel: ErrUndisposedTransient},
+		{Code: "uninitialized_provider", Type: reflect.TypeFor[UninitializedProvider](), Sentinel: ErrUninitializedProvider},
+		{Code: "uninitialized_scope", Type: reflect.TypeFor[UninitializedScope](), Sentinel: ErrUninitializedScope},
+		{Code: "unknown_type", Type: reflect.TypeFor[UnknownType](), Sentinel: ErrUnknownType},
+		{Code: "unsharable_type", Type: reflect.TypeFor[UnsharableType](), Sentinel: ErrUnsharableType},
+		{Code: "verification_failed", Type: reflect.TypeFor[VerificationFailed](), Sentinel: ErrVerificationFailed},
+		{Code: "warm_up_failed", Type: reflect.TypeFor[WarmUpFailed](), Sentinel: ErrWarmUpFailed},
+	}
+}
@@ -0,0 +1,159 @@
+package di
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+
+	t.Run("the codes are unchanged", func(t *testing.T) {
+		// Codes are part of the API; changing this table is a breaking change.
+		expected := map[string]string{
+			"already_resolved":                          "AlreadyResolved",
+			"auto_resolved":                             "AutoResolved",
+			"captive_dependency":                        "CaptiveDependency",
+			"close_context_already_done":                "CloseContextAlreadyDone",
+			"close_halted":                              "CloseHalted",
+			"closer_error":                              "CloserError",
+			"closer_timeout":                            "CloserTimeout",
+			"conflicting_options":                       "ConflictingOptions",
+			"construction_error":                        "ConstructionError",
+			"default_provider_set":                      "DefaultProviderSet",
+			"deferred_cleanup_error":                    "DeferredCleanupError",
+			"dependency_cycle":                          "DependencyCycle",
+			"deprecated_dependency":                     "DeprecatedDependency",
+			"deprecated_resolution":                     "DeprecatedResolution",
+			"duplicate_key_name":                        "DuplicateKeyName",
+			"duplicate_supply":                          "DuplicateSupply",
+			"factory_timeout":                           "FactoryTimeout",
+			"fallback_error":                            "FallbackError",
+			"hosted_service_cycle":                      "HostedServiceCycle",
+			"hosted_service_failed":                     "HostedServiceFailed",
+			"import_error":                              "ImportError",
+			"incomplete_close":                          "IncompleteClose",
+			"internal_error":                            "InternalError",
+			"invalid_hosted_service":                    "InvalidHostedService",
+			"invalid_implementation":                    "InvalidImplementation",
+			"invalid_resolution":                        "InvalidResolution",
+			"invalid_supply":                            "InvalidSupply",
+			"invalid_type_rewrite":                      "InvalidTypeRewrite",
+			"job_panicked":                              "JobPanicked",
+			"missing_dependency":                        "MissingDependency",
+			"nil_instance":                              "NilInstance",
+			"nil_resolution":                            "NilResolution",
+			"nil_supply":                                "NilSupply",
+			"nil_type":                                  "NilType",
+			"no_default_factory":                        "NoDefaultFactory",
+			"no_identity":                               "NoIdentity",
+			"no_scope_in_context":                       "NoScopeInContext",
+			"non_concrete_implementation":               "NonConcreteImplementation",
+			"not_cached":                                "NotCached",
+			"not_func":                                  "NotFunc",
+			"not_refreshable":                           "NotRefreshable",
+			"not_singleton":                             "NotSingleton",
+			"not_transient":                             "NotTransient",
+			"on_close_panic":                            "OnClosePanic",
+			"pointer_receiver_implementation":           "PointerReceiverImplementation",
+			"pointer_to_interface":                      "PointerToInterface",
+			"provider_frozen":                           "ProviderFrozen",
+			"resolution_budget_exceeded":                "ResolutionBudgetExceeded",
+			"resolution_error":                          "ResolutionError",
+			"resolve_all_unsupported":                   "ResolveAllUnsupported",
+			"resolve_new_unsupported":                   "ResolveNewUnsupported",
+			"scope_closed":                              "ScopeClosed",
+			"scope_name_mismatch":                       "ScopeNameMismatch",
+			"scoped_value_requested_from_root_provider": "ScopedValueRequestedFromRootProvider",
+			"singleton_constructed":                     "SingletonConstructed",
+			"type_rewrite_cycle":                        "TypeRewriteCycle",
+			"undefined_lifetime":                        "UndefinedLifetime",
+			"undisposed_transient":                      "UndisposedTransient",
+			"uninitialized_provider":                    "UninitializedProvider",
+			"uninitialized_scope":                       "UninitializedScope",
+			"unknown_type":                              "UnknownType",
+			"unsharable_type":                           "UnsharableType",
+			"verification_failed":                       "VerificationFailed",
+			"warm_up_failed":                            "WarmUpFailed",
+		}
+		actual := map[string]string{}
+		for _, code := range ErrorCodes() {
+			if _, ok := actual[code.Code]; ok {
+				t.Errorf("expected code %q to be unique", code.Code)
+			}
+			actual[code.Code] = code.Type.Name()
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected codes %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("each type returns its code", func(t *testing.T) {
+		for _, code := range ErrorCodes() {
+			err, ok := reflect.Zero(code.Type).Interface().(interface{ Code() string })
+			if !ok {
+				t.Errorf("expected %v to have a Code method", code.Type)
+				continue
+			}
+			if actual := err.Code(); actual != code.Code {
+				t.Errorf("expected %v to return code %q; got %q", code.Type, code.Code, actual)
+			}
+		}
+		if code := (UnknownTypeNearMisses{}).Code(); code != "unknown_type" {
+			t.Errorf("expected UnknownTypeNearMisses to return code %q; got %q", "unknown_type", code)
+		}
+	})
+
+	t.Run("each type is its sentinel", func(t *testing.T) {
+		for _, code := range ErrorCodes() {
+			if code.Sentinel == nil {
+				continue
+			}
+			if err := reflect.Zero(code.Type).Interface().(error); !errors.Is(err, code.Sentinel) {
+				t.Errorf("expected %v to be %v", code.Type, code.Sentinel)
+			}
+		}
+	})
+
+	t.Run("every exported error type has a code", func(t *testing.T) {
+		paths, err := filepath.Glob("*.go")
+		if err != nil {
+			t.Fatalf("unexpected error from Glob: %v", err)
+		}
+		coded := map[string]bool{
+			// UnknownTypeNearMisses shares the code of the UnknownType it describes.
+			"UnknownTypeNearMisses": true,
+		}
+		for _, code := range ErrorCodes() {
+			coded[code.Type.Name()] = true
+		}
+		for _, path := range paths {
+			if strings.HasSuffix(path, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+			if err != nil {
+				t.Fatalf("unexpected error from ParseFile: %v", err)
+			}
+			for _, decl := range file.Decls {
+				method, ok := decl.(*ast.FuncDecl)
+				if !ok || method.Recv == nil || method.Name.Name != "Error" {
+					continue
+				}
+				receiver := method.Recv.List[0].Type
+				if star, ok := receiver.(*ast.StarExpr); ok {
+					receiver = star.X
+				}
+				name := receiver.(*ast.Ident).Name
+				if ast.IsExported(name) && !coded[name] {
+					t.Errorf("expected error type %s to be in ErrorCodes", name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// evictCloseTimeout is the deadline for closing an evicted instance.
+const evictCloseTimeout = 5 * time.Second
+
+// ErrNotCached is returned when an attempt is made to evict a type that has no cached instance.
+var ErrNotCached = errors.New("type has no cached instance")
+
+// A NotCached is an [error] indicating that an attempt was made to evict a type that has no cached
+// instance. Calling [errors.Is] with a NotCached and [ErrNotCached] returns true.
+type NotCached struct {
+
+	// Type is the type that was to be evicted.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NotCached) Error() string {
+	return fmt.Sprintf("no cached instance of %v to evict", err.Type)
+}
+
+// Is indicates that a [NotCached] is [ErrNotCached].
+func (NotCached) Is(target error) bool {
+	return target == ErrNotCached
+}
+
+// Code returns "not_cached", the code of a [NotCached] in [ErrorCodes].
+func (NotCached) Code() string {
+	return "not_cached"
+}
+
+// Evict removes the scope's cached instance of typ so that the next resolution of typ from the
+// scope constructs a new one, e.g. to rebuild a client whose credentials have changed. The
+// registration is left in place. If the scope owns the instance Evict closes it, giving a
+// [ContextCloser] a short deadline, and returns the errors from closing it joined with
+// [errors.Join].
+//
+// The instance is removed before it is closed, and Evict waits for the resolutions from the
+// provider and its scopes that were in progress when it was removed to return before closing it,
+// so a concurrent resolution gets either the evicted instance before it is closed or a new one,
+// never a closed instance. If those resolutions have not returned by the deadline, Evict returns
+// an [IncompleteClose] and the instance is closed in the background once they have, where
+// [RootProvider.AbandonedClosers] reports it until it is closed. Evict does not wait when it is
+// called by a factory with the [Resolver] the factory was given, since the resolution the factory
+// is part of cannot return until Evict does.
+//
+// Evict returns a [NotCached] if the scope has no instance of typ, including when the only
+// instance is one the scope inherited from its parent.
+func (scope Scope) Evict(typ reflect.Type) error {
+	if err := scope.checkInitialized("Evict"); err != nil {
+		return err
+	}
+	if err := scope.acquireState(); err != nil {
+		return err
+	}
+	defer scope.releaseState()
+	if scope.constructing == nil {
+		scope.state.resetting.RLock()
+		defer scope.state.resetting.RUnlock()
+	}
+	return scope.root.evict(&scope.state.scopedValues, typ, scope.constructing == nil)
+}
+
+// EvictSingleton removes the provider's [Singleton] instance of typ so that the next resolution
+// of typ constructs a new one; see [Scope.Evict]. Evicting a singleton that a child provider
+// inherits evicts the parent's instance. EvictSingleton returns a [NotCached] if the provider has
+// no instance of typ.
+func (provider RootProvider) EvictSingleton(typ reflect.Type) error {
+	if err := provider.checkInitialized("EvictSingleton"); err != nil {
+		return err
+	}
+	if registration, ok := provider.registrations.get(typ); ok && registration.inherited {
+		return provider.parent.EvictSingleton(typ)
+	}
+	return provider.evict(provider.singletonsFor(typ), typ, provider.constructing == nil)
+}
+
+// evict removes the instance of typ from instances and closes it if the provider's registration
+// for typ owns it, once the resolutions in progress have returned if wait is true.
+func (provider RootProvider) evict(instances *instanceMap, typ reflect.Type, wait bool) error {
+	value, ok := instances.remove(typ)
+	if !ok {
+		return NotCached{
+			Type: typ,
+		}
+	}
+	if registration, ok := provider.registrations.get(typ); !ok || !registration.owned {
+		return nil
+	}
+	entries := []instanceEntry{{
+		typ:   typ,
+		value: value,
+	}}
+	options := newCloseOptions(provider.options, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), evictCloseTimeout)
+	defer cancel()
+	if wait {
+		resolved := provider.inFlight.wait()
+		select {
+		case <-resolved:
+		case <-ctx.Done():
+			id := provider.abandoned.add(typ, time.Now())
+			go func() {
+				defer provider.abandoned.remove(id)
+				<-resolved
+				_ = closeValues(context.Background(), entries, nil, provider.abandoned, options)
+			}()
+			return IncompleteClose{
+				Types: []reflect.Type{typ},
+				Err:   ctx.Err(),
+			}
+		}
+	}
+	return errors.Join(closeValues(ctx, entries, nil, provider.abandoned, options)...)
+}
+
+// resolutionsInFlight counts the top-level resolutions in progress so that an eviction can wait
+// for the resolutions that may have resolved the evicted instance before closing it. Resolutions
+// never wait for it: each is counted in the current phase, and an eviction starts a new phase and
+// waits for the resolutions counted in the previous one.
+type resolutionsInFlight struct {
+
+	// mu is held by an eviction until the resolutions it waits for have returned so that each
+	// eviction waits for every resolution that began before it.
+	mu     sync.Mutex
+	phase  atomic.Uint64
+	counts [2]atomic.Int64
+}
+
+// enter counts a resolution in the current phase and returns the phase to pass to exit once the
+// resolution returns.
+func (r *resolutionsInFlight) enter() uint64 {
+	for {
+		phase := r.phase.Load()
+		r.counts[phase%2].Add(1)
+		// Counting in a phase that has ended would let an eviction that already waited miss us.
+		if r.phase.Load() == phase {
+			return phase
+		}
+		r.counts[phase%2].Add(-1)
+	}
+}
+
+func (r *resolutionsInFlight) exit(phase uint64) {
+	r.counts[phase%2].Add(-1)
+}
+
+// wait starts a new phase and returns a channel that is closed once the resolutions counted in
+// the previous phase have returned.
+func (r *resolutionsInFlight) wait() <-chan struct{} {
+	r.mu.Lock()
+	previous := r.phase.Add(1) - 1
+	resolved := make(chan struct{})
+	go func() {
+		defer r.mu.Unlock()
+		defer close(resolved)
+		for r.counts[previous%2].Load() > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	return resolved
+}
@@ -0,0 +1,230 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// An evictHolder is constructed from a *slowClient, which must stay open while the holder is
+// being constructed.
+type evictHolder struct{}
+
+func TestEvict(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, opts ...RegistrationOption) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, lifetime, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	typ := reflect.TypeFor[*mockCloser]()
+
+	// evictDuringResolutions evicts a *slowClient with the given lifetime repeatedly while other
+	// goroutines resolve an *evictHolder, whose factory fails if the client it resolved is closed
+	// before the factory returns.
+	evictDuringResolutions := func(t *testing.T, lifetime Lifetime, evict func(Resolver) error) {
+		registry, err := RegisterFactory[*slowClient](Registry{}, lifetime, func(Resolver) (*slowClient, error) {
+			return &slowClient{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*evictHolder](registry, Transient, func(resolver Resolver) (*evictHolder, error) {
+			client, err := Resolve[*slowClient](resolver)
+			if err != nil {
+				return nil, err
+			}
+			time.Sleep(time.Millisecond)
+			if client.closed.Load() {
+				return nil, fmt.Errorf("resolved a closed client")
+			}
+			return &evictHolder{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		var resolver Resolver = provider
+		if lifetime == Scoped {
+			resolver = provider.NewScope()
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 20 {
+					if _, err := Resolve[*evictHolder](resolver); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for evicting := true; evicting; {
+			select {
+			case <-done:
+				evicting = false
+			default:
+				if err := evict(resolver); err != nil && !errors.Is(err, ErrNotCached) {
+					t.Fatalf("unexpected error from evicting: %v", err)
+				}
+			}
+		}
+		close(errs)
+		for err := range errs {
+			t.Errorf("unexpected error from Resolve: %v", err)
+		}
+	}
+
+	t.Run("Scope", func(t *testing.T) {
+
+		t.Run("closes the cached instance and constructs a new one on the next resolution", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			evicted, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if err := scope.Evict(typ); err != nil {
+				t.Fatalf("unexpected error from Evict: %v", err)
+			}
+			if !evicted.closed {
+				t.Fatalf("expected the evicted instance to be closed")
+			}
+			replacement, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if replacement == evicted || replacement.closed {
+				t.Fatalf("expected a new open instance after eviction")
+			}
+		})
+
+		t.Run("does not close instances the scope does not own", func(t *testing.T) {
+			scope := newProvider(t, Scoped, WithoutOwnership()).NewScope()
+			evicted, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if err := scope.Evict(typ); err != nil {
+				t.Fatalf("unexpected error from Evict: %v", err)
+			}
+			if evicted.closed {
+				t.Fatalf("expected the unowned instance not to be closed")
+			}
+		})
+
+		t.Run("returns NotCached when there is no cached instance", func(t *testing.T) {
+			scope := newProvider(t, Scoped).NewScope()
+			err := scope.Evict(typ)
+			var notCached NotCached
+			if !errors.As(err, &notCached) || !errors.Is(err, ErrNotCached) {
+				t.Fatalf("expected %v to be a NotCached", err)
+			}
+			if notCached.Type != typ {
+				t.Fatalf("expected type %v; got %v", typ, notCached.Type)
+			}
+		})
+
+		t.Run("never closes an instance a concurrent resolution is using", func(t *testing.T) {
+			evictDuringResolutions(t, Scoped, func(resolver Resolver) error {
+				return resolver.(Scope).Evict(reflect.TypeFor[*slowClient]())
+			})
+		})
+
+		t.Run("returns the errors from closing the instance", func(t *testing.T) {
+			expected := errors.New("expected error")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{err: expected}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			err = scope.Evict(reflect.TypeFor[*errorContextCloser]())
+			if !errors.Is(err, ErrCloser) || !errors.Is(err, expected) {
+				t.Fatalf("expected %v to be %v wrapping %v", err, ErrCloser, expected)
+			}
+		})
+	})
+
+	t.Run("RootProvider", func(t *testing.T) {
+
+		t.Run("closes the singleton and constructs a new one on the next resolution", func(t *testing.T) {
+			provider := newProvider(t, Singleton)
+			evicted, err := Resolve[*mockCloser](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if err := provider.EvictSingleton(typ); err != nil {
+				t.Fatalf("unexpected error from EvictSingleton: %v", err)
+			}
+			if !evicted.closed {
+				t.Fatalf("expected the evicted instance to be closed")
+			}
+			replacement, err := Resolve[*mockCloser](provider.NewScope())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if replacement == evicted {
+				t.Fatalf("expected a new instance after eviction")
+			}
+		})
+
+		t.Run("never closes an instance a concurrent resolution is using", func(t *testing.T) {
+			evictDuringResolutions(t, Singleton, func(resolver Resolver) error {
+				return resolver.(RootProvider).EvictSingleton(reflect.TypeFor[*slowClient]())
+			})
+		})
+
+		t.Run("evicts the parent's instance of an inherited singleton", func(t *testing.T) {
+			parent := newProvider(t, Singleton)
+			child, err := parent.NewChildProvider(Registry{})
+			if err != nil {
+				t.Fatalf("unexpected error from NewChildProvider: %v", err)
+			}
+			evicted, err := Resolve[*mockCloser](child)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if err := child.EvictSingleton(typ); err != nil {
+				t.Fatalf("unexpected error from EvictSingleton: %v", err)
+			}
+			if !evicted.closed {
+				t.Fatalf("expected the evicted instance to be closed")
+			}
+		})
+
+		t.Run("returns NotCached when there is no cached instance", func(t *testing.T) {
+			if err := newProvider(t, Singleton).EvictSingleton(typ); !errors.Is(err, ErrNotCached) {
+				t.Fatalf("expected %v to be %v", err, ErrNotCached)
+			}
+		})
+	})
+}
@@ -0,0 +1,297 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithFactoryTimeout limits how long the registration's factory may run before the resolution
+// fails with a [FactoryTimeout], overriding any [WithDefaultFactoryTimeout] given to the provider.
+// A timeout of zero or less uses the provider's default. The factory of a [ContextFactory]
+// registration is given a context with the deadline; other factories are not interrupted but are
+// left to finish in the background, where they are reported by [RootProvider.AbandonedFactories]
+// until they return. A value returned after the timeout is closed if it is a [Closer] or
+// [ContextCloser] and discarded, so a timed-out [Singleton] is constructed again by the next
+// resolution unless the provider was built [WithFailFastFactoryTimeouts].
+func WithFactoryTimeout(d time.Duration) RegistrationOption {
+	return func(registration *registration) {
+		registration.factoryTimeout = d
+	}
+}
+
+// WithDefaultFactoryTimeout limits how long the factory of each registration that was not given
+// its own [WithFactoryTimeout] may run. A timeout of zero or less means factories may run for as
+// long as they like, which is the default.
+func WithDefaultFactoryTimeout(d time.Duration) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithDefaultFactoryTimeout") && options.factoryTimeout != d {
+			options.conflict(
+				fmt.Sprintf("given timeouts of %v and %v", options.factoryTimeout, d),
+				"WithDefaultFactoryTimeout")
+		}
+		options.factoryTimeout = d
+	}
+}
+
+// WithFailFastFactoryTimeouts makes the resolutions of a type whose factory exceeded its timeout
+// fail with the same [FactoryTimeout], without calling the factory again, for as long as the
+// abandoned factory is still running, e.g. so that a factory blocked on a dead network endpoint
+// doesn't leave another blocked goroutine behind for every retry. Once the abandoned factory
+// returns the next resolution calls the factory again. By default every resolution after a
+// timeout calls the factory again.
+func WithFailFastFactoryTimeouts() ProviderOption {
+	return func(options *providerOptions) {
+		options.failFastFactoryTimeouts = true
+	}
+}
+
+// A ContextFactory is a [Factory] that is also given a context: the context given to [ResolveCtx]
+// for the resolution, or [context.Background] for other resolutions, limited by the
+// registration's factory timeout, if any; see [WithFactoryTimeout].
+type ContextFactory[T any] func(context.Context, Resolver) (T, error)
+
+// RegisterContextFactory is [RegisterFactory] for a [ContextFactory].
+func RegisterContextFactory[Target any, Impl any](
+	registry Registry,
+	lifetime Lifetime,
+	factory ContextFactory[Impl],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	var withoutContext Factory[Impl]
+	if factory != nil {
+		withoutContext = func(resolver Resolver) (Impl, error) {
+			return factory(resolutionContext(resolver), resolver)
+		}
+	}
+	registry, err := RegisterFactory[Target](registry, lifetime, withoutContext, opts...)
+	if err != nil {
+		return registry, err
+	}
+	target := reflect.TypeFor[Target]()
+	registration := registry.registrations[target]
+	registration.contextFactory = func(ctx context.Context, resolver Resolver) (any, error) {
+		return factory(ctx, resolver)
+	}
+	registry.registrations[target] = registration
+	return registry, nil
+}
+
+// ErrFactoryTimeout is returned when the factory for a registered type exceeds its timeout.
+var ErrFactoryTimeout = errors.New("factory exceeded its timeout")
+
+// A FactoryTimeout is an [error] indicating that the factory for a registered type ran for longer
+// than its timeout; see [WithFactoryTimeout]. It is wrapped in a [ConstructionError]. Calling
+// [errors.Is] with a FactoryTimeout and [ErrFactoryTimeout] returns true.
+type FactoryTimeout struct {
+
+	// Type is the registered type whose factory timed out.
+	Type reflect.Type
+
+	// Timeout is the timeout the factory exceeded.
+	Timeout time.Duration
+
+	// Elapsed is the time the factory had been running when it was abandoned.
+	Elapsed time.Duration
+}
+
+// Error implements [error].
+func (err FactoryTimeout) Error() string {
+	return fmt.Sprintf("factory for %v was abandoned after %v (timeout %v)", err.Type, err.Elapsed, err.Timeout)
+}
+
+// Is indicates that a [FactoryTimeout] is [ErrFactoryTimeout].
+func (FactoryTimeout) Is(target error) bool {
+	return target == ErrFactoryTimeout
+}
+
+// Code returns "factory_timeout", the code of a [FactoryTimeout] in [ErrorCodes].
+func (FactoryTimeout) Code() string {
+	return "factory_timeout"
+}
+
+// AbandonedFactories returns the factories that exceeded their timeouts and have not returned
+// since, with the time they were abandoned; see [WithFactoryTimeout].
+func (provider RootProvider) AbandonedFactories() []AbandonedCloser {
+	if !provider.initialized() {
+		return nil
+	}
+	return provider.abandonedFactories.snapshot()
+}
+
+// timed returns registration with its factory limited to the registration's timeout or the
+// provider's default, given a restricted resolver if the provider restricts resolvers, and
+// measured for the provider's metrics sinks. Inherited registrations are limited by the parent.
+func (provider RootProvider) timed(typ reflect.Type, registration registration) registration {
+	return provider.measured(typ, provider.restricted(provider.limited(typ, registration)))
+}
+
+// limited returns registration with its factory limited to the registration's timeout or the
+// provider's default.
+func (provider RootProvider) limited(typ reflect.Type, registration registration) registration {
+	timeout := registration.factoryTimeout
+	if timeout <= 0 {
+		timeout = provider.options.factoryTimeout
+	}
+	if timeout <= 0 || registration.inherited {
+		return registration
+	}
+	factory := registration.contextFactory
+	if factory == nil {
+		withoutContext := registration.factory
+		factory = func(_ context.Context, resolver Resolver) (any, error) {
+			return withoutContext(resolver)
+		}
+	}
+	abandoned := provider.abandonedFactories
+	timedOut := provider.timedOutFactories
+	registration.factory = func(resolver Resolver) (any, error) {
+		if err, ok := timedOut.get(typ); ok {
+			return nil, err
+		}
+		return runWithTimeout(resolutionContext(resolver), typ, timeout, abandoned, timedOut, func(ctx context.Context) (any, error) {
+			return factory(ctx, resolver)
+		})
+	}
+	return registration
+}
+
+// factoryResult is the outcome of a factory run by runWithTimeout.
+type factoryResult struct {
+	value     any
+	err       error
+	panicked  bool
+	recovered any
+}
+
+// runWithTimeout runs factory in a goroutine and waits for it for at most timeout, or until parent
+// is done. A factory that runs for longer is recorded in abandoned, and its timeout in timedOut,
+// until it returns, and its value is closed. Panics are raised again in the calling goroutine.
+func runWithTimeout(
+	parent context.Context,
+	typ reflect.Type,
+	timeout time.Duration,
+	abandoned *abandonedClosers,
+	timedOut *timedOutFactories,
+	factory func(context.Context) (any, error),
+) (any, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeoutCause(parent, timeout, errFactoryTimedOut)
+	done := make(chan factoryResult, 1)
+	go func() {
+		result := factoryResult{}
+		defer func() {
+			if v := recover(); v != nil {
+				result.panicked = true
+				result.recovered = v
+			}
+			done <- result
+		}()
+		result.value, result.err = factory(ctx)
+	}()
+	select {
+	case result := <-done:
+		cancel()
+		if result.panicked {
+			panic(result.recovered)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		now := time.Now()
+		id := abandoned.add(typ, now)
+		err := context.Cause(ctx)
+		exceeded := err == errFactoryTimedOut
+		if exceeded {
+			timeoutErr := FactoryTimeout{
+				Type:    typ,
+				Timeout: timeout,
+				Elapsed: now.Sub(start),
+			}
+			timedOut.add(typ, timeoutErr)
+			err = timeoutErr
+		}
+		go func() {
+			defer cancel()
+			defer abandoned.remove(id)
+			result := <-done
+			if exceeded {
+				timedOut.remove(typ)
+			}
+			if result.err == nil {
+				if closer := closeFunc(result.value); closer != nil {
+					_ = closer(context.Background())
+				}
+			}
+		}()
+		return nil, err
+	}
+}
+
+// errFactoryTimedOut is the cause of the cancellation of the context given to a factory that
+// exceeds its timeout, which distinguishes it from the cancellation of the resolution's context.
+var errFactoryTimedOut = errors.New("factory timed out")
+
+// timedOutFactories holds the timeouts of the factories that are still running after exceeding
+// them for a provider built WithFailFastFactoryTimeouts. A nil timedOutFactories holds nothing.
+type timedOutFactories struct {
+	mu       sync.Mutex
+	timeouts map[reflect.Type]timedOutFactory
+}
+
+// A timedOutFactory is the timeout of the factory for a type and the number of its calls that are
+// still running after exceeding it.
+type timedOutFactory struct {
+	err     FactoryTimeout
+	running int
+}
+
+// newTimedOutFactories returns the timedOutFactories for a provider, which are nil unless failFast
+// is true.
+func newTimedOutFactories(failFast bool) *timedOutFactories {
+	if !failFast {
+		return nil
+	}
+	return &timedOutFactories{}
+}
+
+func (f *timedOutFactories) get(typ reflect.Type) (FactoryTimeout, bool) {
+	if f == nil {
+		return FactoryTimeout{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	timedOut, ok := f.timeouts[typ]
+	return timedOut.err, ok
+}
+
+func (f *timedOutFactories) add(typ reflect.Type, err FactoryTimeout) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timeouts == nil {
+		f.timeouts = make(map[reflect.Type]timedOutFactory)
+	}
+	timedOut := f.timeouts[typ]
+	timedOut.err = err
+	timedOut.running++
+	f.timeouts[typ] = timedOut
+}
+
+func (f *timedOutFactories) remove(typ reflect.Type) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	timedOut := f.timeouts[typ]
+	if timedOut.running--; timedOut.running > 0 {
+		f.timeouts[typ] = timedOut
+		return
+	}
+	delete(f.timeouts, typ)
+}
@@ -0,0 +1,225 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowClient struct {
+	closed atomic.Bool
+}
+
+func (client *slowClient) Close() error {
+	client.closed.Store(true)
+	return nil
+}
+
+type slowDependency struct{}
+
+func TestFactoryTimeout(t *testing.T) {
+
+	waitForAbandoned := func(t *testing.T, provider RootProvider) {
+		deadline := time.Now().Add(time.Second)
+		for len(provider.AbandonedFactories()) > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if abandoned := provider.AbandonedFactories(); len(abandoned) != 0 {
+			t.Fatalf("expected the abandoned factory to finish; got %v", abandoned)
+		}
+	}
+
+	t.Run("abandons factories that exceed their timeout and retries singletons", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls atomic.Int32
+		var late *slowClient
+		registry, err := RegisterFactory[*slowClient](Registry{}, Singleton, func(Resolver) (*slowClient, error) {
+			if calls.Add(1) == 1 {
+				<-release
+				late = &slowClient{}
+				return late, nil
+			}
+			return &slowClient{}, nil
+		}, WithFactoryTimeout(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+
+		_, err = Resolve[*slowClient](provider)
+		var timeout FactoryTimeout
+		if !errors.As(err, &timeout) || !errors.Is(err, ErrFactoryTimeout) {
+			t.Fatalf("expected %T; got %v", timeout, err)
+		}
+		if timeout.Timeout != 10*time.Millisecond || timeout.Elapsed < timeout.Timeout {
+			t.Errorf("unexpected timeout %+v", timeout)
+		}
+		if abandoned := provider.AbandonedFactories(); len(abandoned) != 1 || abandoned[0].Type != timeout.Type {
+			t.Errorf("expected the factory to be abandoned; got %v", abandoned)
+		}
+
+		close(release)
+		deadline := time.Now().Add(time.Second)
+		for len(provider.AbandonedFactories()) > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if abandoned := provider.AbandonedFactories(); len(abandoned) != 0 {
+			t.Fatalf("expected the abandoned factory to finish; got %v", abandoned)
+		}
+		if !late.closed.Load() {
+			t.Errorf("expected the value returned after the timeout to be closed")
+		}
+
+		first, err := Resolve[*slowClient](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		second, err := Resolve[*slowClient](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if first != second || first == late || calls.Load() != 2 {
+			t.Errorf("expected one new singleton after the timeout; got %d calls", calls.Load())
+		}
+	})
+
+	t.Run("fails fast while an abandoned factory is running", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls atomic.Int32
+		registry, err := RegisterFactory[*slowClient](Registry{}, Singleton, func(Resolver) (*slowClient, error) {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+			return &slowClient{}, nil
+		}, WithFactoryTimeout(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithFailFastFactoryTimeouts())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if !provider.Settings().FailFastFactoryTimeouts {
+			t.Errorf("expected the settings to report WithFailFastFactoryTimeouts")
+		}
+
+		var first, second FactoryTimeout
+		if _, err := Resolve[*slowClient](provider); !errors.As(err, &first) {
+			t.Fatalf("expected %T; got %v", first, err)
+		}
+		if _, err := Resolve[*slowClient](provider); !errors.As(err, &second) {
+			t.Fatalf("expected %T; got %v", second, err)
+		}
+		if second != first || calls.Load() != 1 {
+			t.Fatalf("expected the first timeout without calling the factory again; got %v after %d calls", second, calls.Load())
+		}
+
+		close(release)
+		waitForAbandoned(t, provider)
+		if _, err := Resolve[*slowClient](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Fatalf("expected the factory to be called again once the abandoned factory returned; got %d calls", calls.Load())
+		}
+	})
+
+	t.Run("discards values constructed for a scope that was reset", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls atomic.Int32
+		registry, err := RegisterFactory[*slowDependency](Registry{}, Scoped, func(Resolver) (*slowDependency, error) {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+			return &slowDependency{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*slowClient](registry, Scoped, func(resolver Resolver) (*slowClient, error) {
+			if _, err := Resolve[*slowDependency](resolver); err != nil {
+				return nil, err
+			}
+			return &slowClient{}, nil
+		}, WithFactoryTimeout(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		scope := provider.NewScope()
+		if _, err := Resolve[*slowClient](scope); !errors.Is(err, ErrFactoryTimeout) {
+			t.Fatalf("expected %q; got %q", ErrFactoryTimeout, err)
+		}
+		if errs := scope.Reset(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Reset: %v", errs)
+		}
+
+		close(release)
+		waitForAbandoned(t, provider)
+		if late, ok := Peek[*slowDependency](scope); ok {
+			t.Fatalf("expected the value constructed before the reset to be discarded; got %v", late)
+		}
+		if _, err := Resolve[*slowDependency](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Fatalf("expected the dependency to be constructed again after the reset; got %d calls", calls.Load())
+		}
+	})
+
+	t.Run("gives context factories the deadline", func(t *testing.T) {
+		registry, err := RegisterContextFactory[*slowClient](Registry{}, Transient, func(ctx context.Context, _ Resolver) (*slowClient, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}, AllowUndisposedTransient())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterContextFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithDefaultFactoryTimeout(10 * time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*slowClient](provider); !errors.Is(err, ErrFactoryTimeout) {
+			t.Fatalf("expected %q; got %q", ErrFactoryTimeout, err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for len(provider.AbandonedFactories()) > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if abandoned := provider.AbandonedFactories(); len(abandoned) != 0 {
+			t.Errorf("expected the context factory to stop; got %v", abandoned)
+		}
+	})
+
+	t.Run("prefers the registration's timeout to the provider's default", func(t *testing.T) {
+		registry, err := RegisterFactory[*slowClient](Registry{}, Singleton, func(Resolver) (*slowClient, error) {
+			time.Sleep(20 * time.Millisecond)
+			return &slowClient{}, nil
+		}, WithFactoryTimeout(time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithDefaultFactoryTimeout(time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*slowClient](provider.NewScope()); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+	})
+
+	t.Run("returns ConflictingOptions for different default timeouts", func(t *testing.T) {
+		_, err := Registry{}.BuildRootProvider(WithDefaultFactoryTimeout(time.Second), WithDefaultFactoryTimeout(time.Minute))
+		if !errors.Is(err, ErrConflictingOptions) {
+			t.Fatalf("expected %q; got %q", ErrConflictingOptions, err)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WithFallbackResolver makes the provider ask resolver for types that are not registered before
+// giving up, e.g. to embed the provider in a framework with a container of its own. Types missing
+// from the registrations, including the fields of structs built by default factories, are
+// requested from resolver before [WithAutoResolve] is considered. If resolver returns an
+// [UnknownType] for the requested type the provider returns its own, and any other error is
+// returned as a [FallbackError]. Values from resolver are not owned by the provider and are
+// requested every time unless [CacheFallbackValues] is given. [RootProvider.Verify] does not
+// consult resolver, so it still reports the dependencies only resolver provides.
+func WithFallbackResolver(resolver Resolver, opts ...FallbackOption) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithFallbackResolver") {
+			options.conflict("given more than one fallback resolver", "WithFallbackResolver")
+		}
+		options.fallback = &fallbackOptions{
+			resolver: resolver,
+		}
+		for _, opt := range opts {
+			opt(options.fallback)
+		}
+	}
+}
+
+// A FallbackOption configures how a provider uses the resolver given to [WithFallbackResolver].
+type FallbackOption func(*fallbackOptions)
+
+type fallbackOptions struct {
+	resolver Resolver
+
+	// cache is true if the values from resolver are cached by the provider.
+	cache bool
+}
+
+// CacheFallbackValues makes the provider keep the first value the fallback resolver returns for
+// each type and return it for every later request from the provider or its scopes, as if it were
+// a [Singleton].
+func CacheFallbackValues() FallbackOption {
+	return func(options *fallbackOptions) {
+		options.cache = true
+	}
+}
+
+// ErrFallbackFailed is returned when the resolver given to [WithFallbackResolver] fails to
+// resolve a type.
+var ErrFallbackFailed = errors.New("fallback resolver failed")
+
+// A FallbackError is an [error] indicating that the resolver given to [WithFallbackResolver]
+// returned an error other than an [UnknownType] for the requested type, or returned a value that
+// is nil or not assignable to it. Calling [errors.Is] with a FallbackError and
+// [ErrFallbackFailed] returns true, and [errors.Is] and [errors.As] also match the underlying
+// error.
+type FallbackError struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Err is the error from the fallback resolver, or a [NilResolution] or [InvalidResolution]
+	// describing the value it returned.
+	Err error
+}
+
+// Error implements [error].
+func (err FallbackError) Error() string {
+	return fmt.Sprintf("fallback resolver failed to resolve %v: %v", err.Type, err.Err)
+}
+
+// Is indicates that a [FallbackError] is [ErrFallbackFailed].
+func (FallbackError) Is(target error) bool {
+	return target == ErrFallbackFailed
+}
+
+// Unwrap returns the underlying error.
+func (err FallbackError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "fallback_error", the code of a [FallbackError] in [ErrorCodes].
+func (FallbackError) Code() string {
+	return "fallback_error"
+}
+
+// resolveFallback requests typ from the fallback resolver, if any, and reports whether it was
+// found.
+func (provider RootProvider) resolveFallback(typ reflect.Type) (any, bool, error) {
+	fallback := provider.options.fallback
+	if fallback == nil || fallback.resolver == nil {
+		return nil, false, nil
+	}
+	if fallback.cache {
+		if value, ok := provider.fallbackValues.Load(typ); ok {
+			return value, true, nil
+		}
+	}
+	value, err := fallback.resolver.Resolve(typ)
+	if isMiss(err, typ) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, FallbackError{
+			Type: typ,
+			Err:  err,
+		}
+	}
+	if value == nil {
+		return nil, false, FallbackError{
+			Type: typ,
+			Err: NilResolution{
+				Type: typ,
+			},
+		}
+	}
+	if impl := reflect.TypeOf(value); !impl.AssignableTo(typ) {
+		return nil, false, FallbackError{
+			Type: typ,
+			Err: InvalidResolution{
+				Requested: typ,
+				Returned:  impl,
+			},
+		}
+	}
+	if fallback.cache {
+		value, _ = provider.fallbackValues.LoadOrStore(typ, value)
+	}
+	return value, true, nil
+}
@@ -0,0 +1,125 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type frameworkClock struct {
+	now string
+}
+
+type frameworkMissing struct{}
+
+type frameworkConsumer struct {
+	Clock *frameworkClock
+}
+
+func TestWithFallbackResolver(t *testing.T) {
+
+	errFramework := errors.New("framework container failed")
+
+	newProvider := func(t *testing.T, fallback Resolver, opts ...FallbackOption) RootProvider {
+		registry, err := RegisterType[*frameworkConsumer, *frameworkConsumer](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithFallbackResolver(fallback, opts...))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	framework := func(calls *int) Resolver {
+		return ResolverFunc(func(typ reflect.Type) (any, error) {
+			*calls++
+			if typ == reflect.TypeFor[*frameworkClock]() {
+				return &frameworkClock{now: "now"}, nil
+			}
+			return nil, UnknownType{
+				Type: typ,
+			}
+		})
+	}
+
+	t.Run("resolves unregistered types and fields from the fallback", func(t *testing.T) {
+		var calls int
+		scope := newProvider(t, framework(&calls)).NewScope()
+		consumer, err := Resolve[*frameworkConsumer](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if consumer.Clock == nil || consumer.Clock.now != "now" {
+			t.Errorf("expected the clock from the fallback; got %v", consumer.Clock)
+		}
+		if _, err := Resolve[*frameworkClock](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected the fallback to be asked every time; got %d calls", calls)
+		}
+	})
+
+	t.Run("caches values from the fallback with CacheFallbackValues", func(t *testing.T) {
+		var calls int
+		provider := newProvider(t, framework(&calls), CacheFallbackValues())
+		first, err := Resolve[*frameworkClock](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		second, err := Resolve[*frameworkClock](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if first != second || calls != 1 {
+			t.Errorf("expected one cached clock; got %d calls", calls)
+		}
+		if settings := provider.Settings(); !settings.FallbackResolver || !settings.CacheFallbackValues {
+			t.Errorf("expected the settings to describe the fallback; got %v", settings)
+		}
+	})
+
+	t.Run("returns its own UnknownType when the fallback misses", func(t *testing.T) {
+		var calls int
+		_, err := Resolve[*frameworkMissing](newProvider(t, framework(&calls)))
+		var unknownType UnknownType
+		if !errors.As(err, &unknownType) || unknownType.Type != reflect.TypeFor[*frameworkMissing]() {
+			t.Fatalf("expected %T for the requested type; got %v", unknownType, err)
+		}
+		if errors.Is(err, ErrFallbackFailed) {
+			t.Errorf("expected a miss not to be a fallback failure")
+		}
+	})
+
+	t.Run("returns FallbackError for other errors", func(t *testing.T) {
+		fallback := ResolverFunc(func(reflect.Type) (any, error) {
+			return nil, errFramework
+		})
+		_, err := Resolve[*frameworkConsumer](newProvider(t, fallback).NewScope())
+		if !errors.Is(err, ErrFallbackFailed) || !errors.Is(err, errFramework) {
+			t.Fatalf("expected a FallbackError wrapping %q; got %q", errFramework, err)
+		}
+		if !errors.Is(err, ErrResolutionFailed) {
+			t.Errorf("expected the failure to be reported as a failed field resolution; got %q", err)
+		}
+	})
+
+	t.Run("returns FallbackError for values of the wrong type", func(t *testing.T) {
+		fallback := ResolverFunc(func(reflect.Type) (any, error) {
+			return "not a clock", nil
+		})
+		_, err := Resolve[*frameworkClock](newProvider(t, fallback))
+		if !errors.Is(err, ErrFallbackFailed) || !errors.Is(err, ErrInvalidResolution) {
+			t.Fatalf("expected a FallbackError wrapping %q; got %q", ErrInvalidResolution, err)
+		}
+	})
+
+	t.Run("returns ConflictingOptions for two fallback resolvers", func(t *testing.T) {
+		_, err := Registry{}.BuildRootProvider(WithFallbackResolver(nil), WithFallbackResolver(nil))
+		if !errors.Is(err, ErrConflictingOptions) {
+			t.Fatalf("expected %q; got %q", ErrConflictingOptions, err)
+		}
+	})
+}
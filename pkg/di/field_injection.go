@@ -0,0 +1,136 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithBestEffortFieldInjection makes the default factories for struct types leave a field at its
+// zero value when its type is not registered instead of failing, e.g. to adopt the package
+// incrementally in a codebase whose structs have exported fields that cannot be resolved yet. Each
+// skipped field is reported to the provider's [Observer] as a [FieldSkipped] and recorded for
+// [RootProvider.SkippedFields].
+//
+// Only unregistered field types are skipped; any other error from resolving a field, including an
+// [UnknownType] for a dependency of a registered field type, still fails the factory. A field
+// tagged with `di:"required"` is never skipped.
+func WithBestEffortFieldInjection() ProviderOption {
+	return func(options *providerOptions) {
+		options.bestEffortFields = true
+	}
+}
+
+// A FieldSkipped is an [Event] indicating that the default factory for a struct type left a field
+// at its zero value because its type is not registered; see [WithBestEffortFieldInjection].
+type FieldSkipped struct {
+
+	// Struct is the struct type whose field was skipped.
+	Struct reflect.Type
+
+	// Field is the name of the field that was skipped.
+	Field string
+
+	// Type is the type of the field that was skipped.
+	Type reflect.Type
+}
+
+func (FieldSkipped) event() {}
+
+// SkippedFields returns the fields the default factories for struct types have left at their zero
+// values because their types are not registered, ordered by struct type name and field name. It
+// returns nil unless the provider was built with [WithBestEffortFieldInjection].
+func (provider RootProvider) SkippedFields() []FieldSkipped {
+	if !provider.initialized() {
+		return nil
+	}
+	return provider.skippedFields.list()
+}
+
+// skippedFields records the fields skipped by best-effort field injection.
+type skippedFields struct {
+	mu     sync.Mutex
+	fields map[FieldSkipped]struct{}
+}
+
+// add records skipped and reports whether it had not been recorded before.
+func (s *skippedFields) add(skipped FieldSkipped) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.fields[skipped]; ok {
+		return false
+	}
+	if s.fields == nil {
+		s.fields = make(map[FieldSkipped]struct{})
+	}
+	s.fields[skipped] = struct{}{}
+	return true
+}
+
+func (s *skippedFields) list() []FieldSkipped {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.fields) == 0 {
+		return nil
+	}
+	fields := make([]FieldSkipped, 0, len(s.fields))
+	for skipped := range s.fields {
+		fields = append(fields, skipped)
+	}
+	slices.SortFunc(fields, func(a, b FieldSkipped) int {
+		if c := strings.Compare(a.Struct.String(), b.Struct.String()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Field, b.Field)
+	})
+	return fields
+}
+
+// skipUnresolvedField reports whether the default factory for the struct type typ may leave field
+// at its zero value after resolving it from r failed with err, and records the skipped field if so.
+func skipUnresolvedField(r Resolver, typ reflect.Type, field reflect.StructField, err error) bool {
+	var provider RootProvider
+	switch r := unrestricted(r).(type) {
+	case Scope:
+		provider = r.root
+	case RootProvider:
+		provider = r
+	default:
+		return false
+	}
+	if !provider.initialized() || !provider.options.bestEffortFields {
+		return false
+	}
+	if fieldRequired(field) || !isUnregistered(err, field.Type) {
+		return false
+	}
+	skipped := FieldSkipped{
+		Struct: typ,
+		Field:  field.Name,
+		Type:   field.Type,
+	}
+	provider.skippedFields.add(skipped)
+	provider.observe(skipped)
+	return true
+}
+
+// fieldRequired reports whether field is tagged with `di:"required"`.
+func fieldRequired(field reflect.StructField) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("di"), ","), "required")
+}
+
+// isUnregistered reports whether err indicates that typ is not registered with the provider that
+// returned it, as opposed to a failure to resolve one of its dependencies. A provider resolving a
+// type for a factory wraps the UnknownType in a ResolutionError whose path is just typ.
+func isUnregistered(err error, typ reflect.Type) bool {
+	var resolutionErr ResolutionError
+	if errors.As(err, &resolutionErr) {
+		return len(resolutionErr.Path) == 1 &&
+			resolutionErr.Path[0].Type == typ &&
+			resolutionErr.Path[0].Lifetime == 0 &&
+			isMiss(resolutionErr.Err, typ)
+	}
+	return isMiss(err, typ)
+}
@@ -0,0 +1,138 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type legacyLogger struct{}
+
+type legacyDB struct{}
+
+type legacyCache struct{}
+
+type legacyService struct {
+	Logger *legacyLogger
+	DB     *legacyDB
+	Cache  *legacyCache
+}
+
+type requiredLegacyService struct {
+	Logger *legacyLogger
+	DB     *legacyDB `di:"required"`
+}
+
+func TestWithBestEffortFieldInjection(t *testing.T) {
+
+	newProvider := func(t *testing.T, registry Registry, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*legacyLogger, *legacyLogger](registry, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*legacyService, *legacyService](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*requiredLegacyService, *requiredLegacyService](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("leaves fields of unregistered types at their zero values", func(t *testing.T) {
+		provider := newProvider(t, Registry{}, WithBestEffortFieldInjection())
+		service, err := Resolve[*legacyService](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if service.Logger == nil {
+			t.Fatalf("expected the registered field to be resolved")
+		}
+		if service.DB != nil || service.Cache != nil {
+			t.Fatalf("expected the unregistered fields to be nil; got %+v", service)
+		}
+	})
+
+	t.Run("records each skipped field once", func(t *testing.T) {
+		provider := newProvider(t, Registry{}, WithBestEffortFieldInjection())
+		for range 2 {
+			if _, err := Resolve[*legacyService](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		structType := reflect.TypeFor[legacyService]()
+		expected := []FieldSkipped{
+			{Struct: structType, Field: "Cache", Type: reflect.TypeFor[*legacyCache]()},
+			{Struct: structType, Field: "DB", Type: reflect.TypeFor[*legacyDB]()},
+		}
+		if actual := provider.SkippedFields(); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("reports skipped fields to the observer", func(t *testing.T) {
+		var mu sync.Mutex
+		var events []FieldSkipped
+		provider := newProvider(t, Registry{},
+			WithBestEffortFieldInjection(),
+			WithObserver(ObserverFunc(func(event Event) {
+				if skipped, ok := event.(FieldSkipped); ok {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, skipped)
+				}
+			})))
+		if _, err := Resolve[*legacyService](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("expected 2 FieldSkipped events; got %v", events)
+		}
+	})
+
+	t.Run("does not skip fields tagged as required", func(t *testing.T) {
+		provider := newProvider(t, Registry{}, WithBestEffortFieldInjection())
+		_, err := Resolve[*requiredLegacyService](provider)
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+
+	t.Run("does not skip fields whose registered type fails to resolve", func(t *testing.T) {
+		registry, err := RegisterFactory[*legacyDB](Registry{}, Singleton, func(resolver Resolver) (*legacyDB, error) {
+			if _, err := Resolve[*legacyCache](resolver); err != nil {
+				return nil, err
+			}
+			return &legacyDB{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider := newProvider(t, registry, WithBestEffortFieldInjection())
+		_, err = Resolve[*legacyService](provider)
+		if !errors.Is(err, ErrConstructionFailed) {
+			t.Fatalf("expected %v to be %v", err, ErrConstructionFailed)
+		}
+		if skipped := provider.SkippedFields(); len(skipped) != 0 {
+			t.Fatalf("expected no skipped fields; got %v", skipped)
+		}
+	})
+
+	t.Run("fails for unregistered field types without the option", func(t *testing.T) {
+		provider := newProvider(t, Registry{})
+		_, err := Resolve[*legacyService](provider)
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+		if skipped := provider.SkippedFields(); skipped != nil {
+			t.Fatalf("expected no skipped fields; got %v", skipped)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package di
+
+// A RegistrationFunc adds registrations to a [Registry], e.g. by calling [RegisterType] or
+// [RegisterFactory], and returns the updated registry.
+type RegistrationFunc func(Registry) (Registry, error)
+
+// Fork creates a new [Scope] whose parent is the scope and which can resolve the registrations
+// added by regs in addition to those of the scope's [RootProvider]. The extra registrations are
+// validated like any other, shadow the provider's registrations for the same types, and are
+// visible to the forked scope and the scopes created from it but not to the scope itself. The
+// provider's registrations that are not shadowed resolve their dependencies from the forked scope
+// so they use the extra registrations too, and the provider's [Singleton] values are shared.
+//
+// Instances of the extra [Singleton] registrations are created once for the forked scope and its
+// descendants and are closed when the forked scope is closed, after the forked scope's own values.
+// Like a scope created by [Scope.NewScope] without options, the forked scope inherits the scope's
+// overrides but creates its own [Scoped] values. Closing the scope does not close the forked scope.
+func (scope Scope) Fork(regs ...RegistrationFunc) (Scope, error) {
+	if err := scope.checkInitialized("Fork"); err != nil {
+		return Scope{}, err
+	}
+	if err := scope.acquireState(); err != nil {
+		return Scope{}, err
+	}
+	defer scope.releaseState()
+	registry := Registry{}
+	for _, reg := range regs {
+		if reg == nil {
+			continue
+		}
+		var err error
+		if registry, err = reg(registry); err != nil {
+			return Scope{}, err
+		}
+	}
+	root, err := scope.root.NewChildProvider(registry)
+	if err != nil {
+		return Scope{}, err
+	}
+	forked := root.newScope(&scope, scopeOptions{
+		name: scope.name,
+	})
+	forked.ownsRoot = true
+	return forked, nil
+}
@@ -0,0 +1,160 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type forkGreeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (*englishGreeter) Greet() string { return "hello" }
+
+type pirateGreeter struct{}
+
+func (*pirateGreeter) Greet() string { return "ahoy" }
+
+type forkHandler struct {
+	Greeter forkGreeter
+}
+
+func TestScopeFork(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[forkGreeter, *englishGreeter](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*forkHandler, *forkHandler](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	pirate := func(registry Registry) (Registry, error) {
+		return RegisterType[forkGreeter, *pirateGreeter](registry, Scoped)
+	}
+
+	t.Run("shadows the provider's registrations within the fork", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		forked, err := scope.Fork(pirate)
+		if err != nil {
+			t.Fatalf("unexpected error from Fork: %v", err)
+		}
+		handler, err := Resolve[*forkHandler](forked)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if greeting := handler.Greeter.Greet(); greeting != "ahoy" {
+			t.Fatalf("expected the fork's registration to be used; got %q", greeting)
+		}
+		handler, err = Resolve[*forkHandler](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if greeting := handler.Greeter.Greet(); greeting != "hello" {
+			t.Fatalf("expected the scope not to see the fork's registration; got %q", greeting)
+		}
+	})
+
+	t.Run("makes the extra registrations visible to the fork's descendants", func(t *testing.T) {
+		forked, err := newProvider(t).NewScope().Fork(pirate)
+		if err != nil {
+			t.Fatalf("unexpected error from Fork: %v", err)
+		}
+		greeter, err := Resolve[forkGreeter](forked.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if greeting := greeter.Greet(); greeting != "ahoy" {
+			t.Fatalf("expected the fork's registration to be used; got %q", greeting)
+		}
+	})
+
+	t.Run("shares the provider's singletons", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		forked, err := scope.Fork()
+		if err != nil {
+			t.Fatalf("unexpected error from Fork: %v", err)
+		}
+		shared, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		fromFork, err := Resolve[*mockCloser](forked)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if shared != fromFork {
+			t.Fatalf("expected the fork to share the provider's singleton")
+		}
+		if err := forked.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if shared.closed {
+			t.Fatalf("expected closing the fork not to close the provider's singleton")
+		}
+	})
+
+	t.Run("closes the instances of extra singletons with the fork", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		forked, err := scope.Fork(func(registry Registry) (Registry, error) {
+			return RegisterType[*mockCloser, *mockCloser](registry, Singleton)
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from Fork: %v", err)
+		}
+		extra, err := Resolve[*mockCloser](forked)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		fromDescendant, err := Resolve[*mockCloser](forked.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if extra != fromDescendant {
+			t.Fatalf("expected the fork's descendants to share the extra singleton")
+		}
+		shared, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if extra == shared {
+			t.Fatalf("expected the extra singleton to shadow the provider's")
+		}
+		if err := forked.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if !extra.closed {
+			t.Fatalf("expected the extra singleton to be closed with the fork")
+		}
+	})
+
+	t.Run("returns errors from the registrations", func(t *testing.T) {
+		_, err := newProvider(t).NewScope().Fork(func(registry Registry) (Registry, error) {
+			return RegisterType[englishGreeter, englishGreeter](registry, Scoped)
+		})
+		if !errors.Is(err, ErrUnsharableType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnsharableType)
+		}
+	})
+
+	t.Run("returns UninitializedScope for an uninitialized scope", func(t *testing.T) {
+		if _, err := (Scope{}).Fork(); !errors.Is(err, ErrUninitializedScope) {
+			t.Fatalf("expected %v to be %v", err, ErrUninitializedScope)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrProviderFrozen is returned when an attempt is made to mutate a [RootProvider], or a [Scope]
+// created from it, after the provider was frozen with [RootProvider.Freeze].
+var ErrProviderFrozen = errors.New("provider is frozen")
+
+// A ProviderFrozen is an [error] indicating that an attempt was made to mutate a [RootProvider], or
+// a [Scope] created from it, after the provider was frozen with [RootProvider.Freeze]. Calling
+// [errors.Is] with a ProviderFrozen and [ErrProviderFrozen] returns true.
+type ProviderFrozen struct {
+
+	// Operation is the name of the mutating operation, e.g. "Swap".
+	Operation string
+}
+
+// Error implements [error].
+func (err ProviderFrozen) Error() string {
+	return fmt.Sprintf("cannot %s: provider is frozen", err.Operation)
+}
+
+// Is indicates that a [ProviderFrozen] is [ErrProviderFrozen].
+func (ProviderFrozen) Is(target error) bool {
+	return target == ErrProviderFrozen
+}
+
+// Code returns "provider_frozen", the code of a [ProviderFrozen] in [ErrorCodes].
+func (ProviderFrozen) Code() string {
+	return "provider_frozen"
+}
+
+// Freeze verifies the provider's registrations with [RootProvider.Verify] and, if verification
+// finds no problems other than warnings, prevents any further mutation of the provider and the
+// scopes created from it. Once frozen, operations such as [MutableProvider.Swap] and
+// [Scope.WithInstance] for a registered type return a [ProviderFrozen], while resolving values and
+// closing scopes and the provider work as before. Freeze returns the verification error and leaves the provider
+// unfrozen if verification fails.
+func (provider RootProvider) Freeze() error {
+	if err := provider.checkInitialized("Freeze"); err != nil {
+		return err
+	}
+	var failed VerificationFailed
+	if err := provider.Verify(); err != nil && !(errors.As(err, &failed) && failed.WarningsOnly()) {
+		return err
+	}
+	provider.frozen.Store(true)
+	return nil
+}
+
+// Frozen reports whether the provider has been frozen with [RootProvider.Freeze].
+func (provider RootProvider) Frozen() bool {
+	return provider.initialized() && provider.frozen.Load()
+}
+
+// checkMutable returns a [ProviderFrozen] for operation if the provider is frozen.
+func (provider RootProvider) checkMutable(operation string) error {
+	if provider.frozen.Load() {
+		return ProviderFrozen{
+			Operation: operation,
+		}
+	}
+	return nil
+}
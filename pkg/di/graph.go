@@ -0,0 +1,158 @@
+package di
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// GraphSchemaVersion is the version of the schema of the [Graph] written by
+// [RootProvider.GraphJSON]. It is incremented whenever a change to the schema could break a
+// consumer, but fields may be added without incrementing it.
+const GraphSchemaVersion = 1
+
+// The mechanisms by which a [GraphEdge] can connect two types.
+const (
+
+	// GraphEdgeField is an edge from a struct type to the type of one of its exported fields,
+	// which the default factory used by [RegisterType] and [RegisterPointerTo] resolves.
+	GraphEdgeField = "field"
+)
+
+// A Graph describes the registrations a provider uses and the known dependencies between them; see
+// [RootProvider.GraphJSON].
+type Graph struct {
+
+	// Version is the [GraphSchemaVersion] the graph was written with.
+	Version int `json:"version"`
+
+	// Nodes describe the registrations, ordered by type name.
+	Nodes []GraphNode `json:"nodes"`
+
+	// Edges describe the known dependencies, ordered by the type name of the dependent type and
+	// then in the order the dependencies are resolved.
+	Edges []GraphEdge `json:"edges"`
+}
+
+// A GraphNode describes a registration in a [Graph].
+type GraphNode struct {
+
+	// ID identifies the registered type by its name qualified with the full paths of the packages
+	// it is composed of, e.g. "*example.com/app/payments.Client".
+	ID string `json:"id"`
+
+	// Type is the name of the registered type as formatted by [reflect.Type.String], e.g.
+	// "*payments.Client".
+	Type string `json:"type"`
+
+	// Package is the path of the package that declares the registered type, or of the named type
+	// it points to, if any.
+	Package string `json:"package,omitempty"`
+
+	// Impl is the ID of the implementation type.
+	Impl string `json:"impl"`
+
+	// Lifetime is the name of the registration's [Lifetime].
+	Lifetime string `json:"lifetime"`
+
+	// Factory is where instances come from: "factory" for a factory given to [RegisterFactory],
+	// "default" for the default factory used by [RegisterType], "instance" for an instance given
+	// to [RegisterInstance], "target-aware" for a factory given to [RegisterTargetAwareFactory],
+	// or "inherited" for a [Singleton] a child provider inherits from its parent.
+	Factory string `json:"factory"`
+
+	// Site is the file and line of the call that registered the type, if it is known.
+	Site string `json:"site,omitempty"`
+
+	// Deprecated indicates whether the registration was marked [WithDeprecated], and Deprecation
+	// is the message it was given.
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+}
+
+// A GraphEdge describes a dependency in a [Graph]. An edge to a type that is not registered has
+// no corresponding node.
+type GraphEdge struct {
+
+	// From is the ID of the dependent type.
+	From string `json:"from"`
+
+	// To is the ID of the dependency.
+	To string `json:"to"`
+
+	// Mechanism is how From depends on To, e.g. [GraphEdgeField].
+	Mechanism string `json:"mechanism"`
+
+	// Field is the name of the field of From that holds To for a [GraphEdgeField] edge.
+	Field string `json:"field,omitempty"`
+}
+
+// GraphJSON writes the [Graph] of the registrations the provider uses to w as indented JSON, e.g.
+// for ingestion by other tools. The output is the same every time for the same registrations.
+// GraphJSON does not resolve anything.
+func (provider RootProvider) GraphJSON(w io.Writer) error {
+	if err := provider.checkInitialized("GraphJSON"); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(provider.graph())
+}
+
+func (provider RootProvider) graph() Graph {
+	registrations := provider.registrations.load()
+	graph := Graph{
+		Version: GraphSchemaVersion,
+		Nodes:   []GraphNode{},
+		Edges:   []GraphEdge{},
+	}
+	for _, typ := range sortedTypes(registrations) {
+		registration := registrations[typ]
+		factory := registration.source.String()
+		if registration.inherited {
+			factory = "inherited"
+		}
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:       qualifiedTypeName(typ),
+			Type:     typ.String(),
+			Package:  packagePath(typ),
+			Impl:     qualifiedTypeName(registration.impl),
+			Lifetime: registration.lifetime.String(),
+			Factory:  factory,
+			Site:     registration.site,
+
+			Deprecated:  registration.deprecated,
+			Deprecation: registration.deprecation,
+		})
+		if registration.source != sourceDefault || registration.inherited {
+			continue
+		}
+		for _, field := range exportedFields(registration.impl) {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From:      qualifiedTypeName(typ),
+				To:        qualifiedTypeName(field.Type),
+				Mechanism: GraphEdgeField,
+				Field:     field.Name,
+			})
+		}
+	}
+	return graph
+}
+
+// exportedFields returns the exported fields of typ, or of the struct it points to, which the
+// default factory resolves.
+func exportedFields(typ reflect.Type) []reflect.StructField {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := []reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
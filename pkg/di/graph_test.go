@@ -0,0 +1,102 @@
+package di
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type graphConfig struct{}
+
+type graphClient struct {
+	Config  *graphConfig
+	Timeout int
+	secret  string
+}
+
+func TestGraphJSON(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[*graphClient, *graphClient](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterInstance[*graphConfig](registry, &graphConfig{})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("round-trips through the exported types", func(t *testing.T) {
+		provider := newProvider(t)
+		var b bytes.Buffer
+		if err := provider.GraphJSON(&b); err != nil {
+			t.Fatalf("unexpected error from GraphJSON: %v", err)
+		}
+		var graph Graph
+		decoder := json.NewDecoder(&b)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&graph); err != nil {
+			t.Fatalf("unexpected error from Decode: %v", err)
+		}
+		if !reflect.DeepEqual(graph, provider.graph()) {
+			t.Fatalf("expected %+v; got %+v", provider.graph(), graph)
+		}
+	})
+
+	t.Run("describes the registrations and their fields", func(t *testing.T) {
+		graph := newProvider(t).graph()
+		if graph.Version != GraphSchemaVersion {
+			t.Errorf("expected version %d; got %d", GraphSchemaVersion, graph.Version)
+		}
+		const pkg = "github.com/ttd2089/garlic/pkg/di"
+		if len(graph.Nodes) != 2 {
+			t.Fatalf("expected 2 nodes; got %+v", graph.Nodes)
+		}
+		client := graph.Nodes[0]
+		if client.ID != "*"+pkg+".graphClient" || client.Type != "*di.graphClient" || client.Package != pkg {
+			t.Errorf("unexpected client node %+v", client)
+		}
+		if client.Lifetime != "Singleton" || client.Factory != "default" || client.Site == "" {
+			t.Errorf("unexpected client node %+v", client)
+		}
+		if config := graph.Nodes[1]; config.Factory != "instance" {
+			t.Errorf("expected the config to come from an instance; got %+v", config)
+		}
+		expected := []GraphEdge{
+			{From: "*" + pkg + ".graphClient", To: "*" + pkg + ".graphConfig", Mechanism: GraphEdgeField, Field: "Config"},
+			{From: "*" + pkg + ".graphClient", To: "int", Mechanism: GraphEdgeField, Field: "Timeout"},
+		}
+		if !reflect.DeepEqual(graph.Edges, expected) {
+			t.Errorf("expected edges %+v; got %+v", expected, graph.Edges)
+		}
+	})
+
+	t.Run("is deterministic", func(t *testing.T) {
+		provider := newProvider(t)
+		var first, second bytes.Buffer
+		if err := provider.GraphJSON(&first); err != nil {
+			t.Fatalf("unexpected error from GraphJSON: %v", err)
+		}
+		if err := provider.GraphJSON(&second); err != nil {
+			t.Fatalf("unexpected error from GraphJSON: %v", err)
+		}
+		if first.String() != second.String() {
+			t.Fatalf("expected the same output; got:\n%s\nand:\n%s", &first, &second)
+		}
+	})
+
+	t.Run("returns UninitializedProvider for an uninitialized provider", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := (RootProvider{}).GraphJSON(&b); !errors.Is(err, ErrUninitializedProvider) {
+			t.Fatalf("expected %v to be %v", err, ErrUninitializedProvider)
+		}
+	})
+}
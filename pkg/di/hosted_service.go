@@ -0,0 +1,349 @@
+package di
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A HostedService is a long-running part of an application, such as a server or a background
+// worker, that is started once the provider is built and stopped before it is closed; see
+// [AsHostedService].
+type HostedService interface {
+
+	// Start starts the service. It should return once the service is running rather than block
+	// for as long as the service runs.
+	Start(ctx context.Context) error
+
+	// Stop stops the service.
+	Stop(ctx context.Context) error
+}
+
+var hostedServiceType = reflect.TypeFor[HostedService]()
+
+// AsHostedService marks a [Singleton] registration as a hosted service, which
+// [RootProvider.StartHostedServices] resolves and starts and [RootProvider.StopHostedServices]
+// stops. The registered type or its implementation type must implement [HostedService]. Hosted
+// services start in the order they were registered unless [WithStartAfter] says otherwise.
+func AsHostedService() RegistrationOption {
+	return func(r *registration) {
+		r.hosted = true
+	}
+}
+
+// WithStartAfter marks a registration as a hosted service like [AsHostedService] that starts
+// after the hosted services registered for types, and stops before them, e.g. so that a migration
+// runner registered in one module starts before an HTTP listener registered in another.
+// WithStartAfter can be given more than once to add more constraints.
+// [Registry.BuildRootProvider] returns an [InvalidHostedService] if any of types is not registered
+// as a hosted service, and a [HostedServiceCycle] if the constraints form a cycle.
+func WithStartAfter(types ...reflect.Type) RegistrationOption {
+	return func(r *registration) {
+		r.hosted = true
+		r.startAfter = append(slices.Clip(r.startAfter), types...)
+	}
+}
+
+// ErrInvalidHostedService is returned when a registration marked as a hosted service cannot be
+// one.
+var ErrInvalidHostedService = errors.New("invalid hosted service")
+
+// An InvalidHostedService is an [error] indicating that a registration marked [AsHostedService]
+// or [WithStartAfter] is not a [Singleton], does not implement [HostedService], or must start
+// after a type that is not registered as a hosted service. Calling [errors.Is] with an
+// InvalidHostedService and [ErrInvalidHostedService] returns true.
+type InvalidHostedService struct {
+
+	// Type is the registered type.
+	Type reflect.Type
+
+	// Reason describes why the registration cannot be a hosted service.
+	Reason string
+}
+
+// Error implements [error].
+func (err InvalidHostedService) Error() string {
+	return fmt.Sprintf("%v cannot be a hosted service: %s", err.Type, err.Reason)
+}
+
+// Is indicates that an [InvalidHostedService] is [ErrInvalidHostedService].
+func (InvalidHostedService) Is(target error) bool {
+	return target == ErrInvalidHostedService
+}
+
+// Code returns "invalid_hosted_service", the code of an [InvalidHostedService] in [ErrorCodes].
+func (InvalidHostedService) Code() string {
+	return "invalid_hosted_service"
+}
+
+// ErrHostedServiceCycle is returned when the start order of hosted services forms a cycle.
+var ErrHostedServiceCycle = errors.New("hosted services must start after each other")
+
+// A HostedServiceCycle is an [error] indicating that the constraints given to [WithStartAfter]
+// form a cycle. Calling [errors.Is] with a HostedServiceCycle and [ErrHostedServiceCycle] returns
+// true.
+type HostedServiceCycle struct {
+
+	// Types are the hosted services in the cycle, each of which must start after the next, starting
+	// and ending with the same type.
+	Types []reflect.Type
+}
+
+// Error implements [error].
+func (err HostedServiceCycle) Error() string {
+	names := make([]string, 0, len(err.Types))
+	for _, typ := range err.Types {
+		names = append(names, typ.String())
+	}
+	return fmt.Sprintf("hosted services must start after each other: %s", strings.Join(names, " -> "))
+}
+
+// Is indicates that a [HostedServiceCycle] is [ErrHostedServiceCycle].
+func (HostedServiceCycle) Is(target error) bool {
+	return target == ErrHostedServiceCycle
+}
+
+// Code returns "hosted_service_cycle", the code of a [HostedServiceCycle] in [ErrorCodes].
+func (HostedServiceCycle) Code() string {
+	return "hosted_service_cycle"
+}
+
+// ErrHostedServiceFailed is returned when a hosted service cannot be resolved, started, or
+// stopped.
+var ErrHostedServiceFailed = errors.New("hosted service failed")
+
+// A HostedServiceFailed is an [error] indicating that a hosted service could not be resolved,
+// started, or stopped. Calling [errors.Is] with a HostedServiceFailed and [ErrHostedServiceFailed]
+// returns true.
+type HostedServiceFailed struct {
+
+	// Type is the registered type of the hosted service.
+	Type reflect.Type
+
+	// Operation is "start" if the service could not be resolved or started and "stop" if it could
+	// not be stopped.
+	Operation string
+
+	// Err is the error that occurred.
+	Err error
+}
+
+// Error implements [error].
+func (err HostedServiceFailed) Error() string {
+	return fmt.Sprintf("failed to %s hosted service %v: %v", err.Operation, err.Type, err.Err)
+}
+
+// Is indicates that a [HostedServiceFailed] is [ErrHostedServiceFailed].
+func (HostedServiceFailed) Is(target error) bool {
+	return target == ErrHostedServiceFailed
+}
+
+// Unwrap returns the error that occurred.
+func (err HostedServiceFailed) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "hosted_service_failed", the code of a [HostedServiceFailed] in [ErrorCodes].
+func (HostedServiceFailed) Code() string {
+	return "hosted_service_failed"
+}
+
+// hostedServiceOrder returns the hosted services in registrations in the order they start: each
+// after the services it must start after, and otherwise in registration order.
+func hostedServiceOrder(registrations map[reflect.Type]registration) ([]reflect.Type, error) {
+	pending := []reflect.Type{}
+	for typ, registration := range registrations {
+		if registration.hosted {
+			pending = append(pending, typ)
+		}
+	}
+	slices.SortFunc(pending, func(a, b reflect.Type) int {
+		return cmp.Compare(registrations[a].order, registrations[b].order)
+	})
+	for _, typ := range pending {
+		registration := registrations[typ]
+		if registration.lifetime != Singleton {
+			return nil, InvalidHostedService{
+				Type:   typ,
+				Reason: fmt.Sprintf("its lifetime is %v rather than Singleton", registration.lifetime),
+			}
+		}
+		if !typ.Implements(hostedServiceType) &&
+			(registration.impl == nil || !registration.impl.Implements(hostedServiceType)) {
+			return nil, InvalidHostedService{
+				Type:   typ,
+				Reason: fmt.Sprintf("it does not implement %v", hostedServiceType),
+			}
+		}
+		for _, after := range registration.startAfter {
+			if dependency, ok := registrations[after]; !ok || !dependency.hosted {
+				return nil, InvalidHostedService{
+					Type:   typ,
+					Reason: fmt.Sprintf("it must start after %v, which is not a hosted service", after),
+				}
+			}
+		}
+	}
+	order := make([]reflect.Type, 0, len(pending))
+	started := map[reflect.Type]bool{}
+	for len(pending) > 0 {
+		i := slices.IndexFunc(pending, func(typ reflect.Type) bool {
+			return !slices.ContainsFunc(registrations[typ].startAfter, func(after reflect.Type) bool {
+				return !started[after]
+			})
+		})
+		if i < 0 {
+			return nil, hostedServiceCycle(registrations, pending, started)
+		}
+		order = append(order, pending[i])
+		started[pending[i]] = true
+		pending = slices.Delete(pending, i, i+1)
+	}
+	return order, nil
+}
+
+// hostedServiceCycle returns a HostedServiceCycle found by following the constraints of the
+// pending services, each of which must start after another pending service.
+func hostedServiceCycle(
+	registrations map[reflect.Type]registration,
+	pending []reflect.Type,
+	started map[reflect.Type]bool,
+) HostedServiceCycle {
+	chain := []reflect.Type{pending[0]}
+	for {
+		current := chain[len(chain)-1]
+		i := slices.IndexFunc(registrations[current].startAfter, func(after reflect.Type) bool {
+			return !started[after]
+		})
+		next := registrations[current].startAfter[i]
+		if j := slices.Index(chain, next); j >= 0 {
+			return HostedServiceCycle{
+				Types: append(chain[j:], next),
+			}
+		}
+		chain = append(chain, next)
+	}
+}
+
+// hostedServices holds the start order of a provider's hosted services and the services that
+// have been started.
+type hostedServices struct {
+	order   []reflect.Type
+	mu      sync.Mutex
+	started []startedService
+}
+
+type startedService struct {
+	typ     reflect.Type
+	service HostedService
+}
+
+// HostedServices returns the registered types of the provider's hosted services in the order
+// [RootProvider.StartHostedServices] starts them, e.g. for logging. The order is computed when the
+// provider is built from the constraints given to [WithStartAfter]. Providers created with
+// [RootProvider.NewChildProvider] have no hosted services.
+func (provider RootProvider) HostedServices() []reflect.Type {
+	if !provider.initialized() {
+		return nil
+	}
+	return slices.Clone(provider.hosted.order)
+}
+
+// StartHostedServices resolves and starts the provider's hosted services in the order reported by
+// [RootProvider.HostedServices], skipping those that have already been started. If a service
+// cannot be resolved or started, StartHostedServices stops the services that were started, in
+// reverse order, and returns a [HostedServiceFailed] for the failure joined with any errors from
+// stopping the others.
+func (provider RootProvider) StartHostedServices(ctx context.Context) error {
+	if err := provider.checkInitialized("StartHostedServices"); err != nil {
+		return err
+	}
+	provider.constructing = nil
+	provider.path = nil
+	provider.ctx = nil
+	hosted := provider.hosted
+	hosted.mu.Lock()
+	defer hosted.mu.Unlock()
+	for _, typ := range hosted.order {
+		if slices.ContainsFunc(hosted.started, func(started startedService) bool {
+			return started.typ == typ
+		}) {
+			continue
+		}
+		service, err := provider.startHostedService(ctx, typ)
+		if err != nil {
+			return errors.Join(append([]error{err}, hosted.stop(ctx)...)...)
+		}
+		hosted.started = append(hosted.started, startedService{
+			typ:     typ,
+			service: service,
+		})
+	}
+	return nil
+}
+
+func (provider RootProvider) startHostedService(ctx context.Context, typ reflect.Type) (HostedService, error) {
+	value, err := provider.Resolve(typ)
+	if err != nil {
+		return nil, HostedServiceFailed{
+			Type:      typ,
+			Operation: "start",
+			Err:       err,
+		}
+	}
+	service, ok := value.(HostedService)
+	if !ok {
+		return nil, HostedServiceFailed{
+			Type:      typ,
+			Operation: "start",
+			Err:       fmt.Errorf("%T does not implement %v", value, hostedServiceType),
+		}
+	}
+	if err := service.Start(ctx); err != nil {
+		return nil, HostedServiceFailed{
+			Type:      typ,
+			Operation: "start",
+			Err:       err,
+		}
+	}
+	return service, nil
+}
+
+// StopHostedServices stops the hosted services that have been started in the reverse of the
+// order they were started, and returns a [HostedServiceFailed] for each service that could not be
+// stopped, joined with [errors.Join]. Every started service is stopped even if others fail.
+func (provider RootProvider) StopHostedServices(ctx context.Context) error {
+	if err := provider.checkInitialized("StopHostedServices"); err != nil {
+		return err
+	}
+	return errors.Join(provider.stopHostedServices(ctx)...)
+}
+
+// stopHostedServices stops the hosted services that have been started and returns the errors.
+func (provider RootProvider) stopHostedServices(ctx context.Context) []error {
+	hosted := provider.hosted
+	hosted.mu.Lock()
+	defer hosted.mu.Unlock()
+	return hosted.stop(ctx)
+}
+
+// stop stops the started services in reverse order. The caller must hold mu.
+func (hosted *hostedServices) stop(ctx context.Context) []error {
+	errs := []error{}
+	for _, started := range slices.Backward(hosted.started) {
+		if err := started.service.Stop(ctx); err != nil {
+			errs = append(errs, HostedServiceFailed{
+				Type:      started.typ,
+				Operation: "stop",
+				Err:       err,
+			})
+		}
+	}
+	hosted.started = nil
+	return errs
+}
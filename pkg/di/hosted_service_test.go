@@ -0,0 +1,309 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type hostedRecord struct {
+	Type      reflect.Type
+	Operation string
+	At        time.Time
+}
+
+type hostedRecorder struct {
+	mu      sync.Mutex
+	records []hostedRecord
+	fail    map[string]reflect.Type
+}
+
+func (rec *hostedRecorder) record(typ reflect.Type, operation string) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.fail[operation] == typ {
+		return errors.New(operation + " failed")
+	}
+	rec.records = append(rec.records, hostedRecord{
+		Type:      typ,
+		Operation: operation,
+		At:        time.Now(),
+	})
+	return nil
+}
+
+func (rec *hostedRecorder) types(operation string) []reflect.Type {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	types := []reflect.Type{}
+	var last time.Time
+	for _, record := range rec.records {
+		if record.Operation != operation {
+			continue
+		}
+		if record.At.Before(last) {
+			panic("records out of order")
+		}
+		last = record.At
+		types = append(types, record.Type)
+	}
+	return types
+}
+
+type migrator struct {
+	Recorder *hostedRecorder
+}
+
+func (m *migrator) Start(context.Context) error {
+	return m.Recorder.record(reflect.TypeFor[*migrator](), "start")
+}
+
+func (m *migrator) Stop(context.Context) error {
+	return m.Recorder.record(reflect.TypeFor[*migrator](), "stop")
+}
+
+type httpListener struct {
+	Recorder *hostedRecorder
+}
+
+func (l *httpListener) Start(context.Context) error {
+	return l.Recorder.record(reflect.TypeFor[*httpListener](), "start")
+}
+
+func (l *httpListener) Stop(context.Context) error {
+	return l.Recorder.record(reflect.TypeFor[*httpListener](), "stop")
+}
+
+type readinessReporter struct {
+	Recorder *hostedRecorder
+}
+
+func (r *readinessReporter) Start(context.Context) error {
+	return r.Recorder.record(reflect.TypeFor[*readinessReporter](), "start")
+}
+
+func (r *readinessReporter) Stop(context.Context) error {
+	return r.Recorder.record(reflect.TypeFor[*readinessReporter](), "stop")
+}
+
+func TestHostedServices(t *testing.T) {
+
+	migratorType := reflect.TypeFor[*migrator]()
+	listenerType := reflect.TypeFor[*httpListener]()
+	readinessType := reflect.TypeFor[*readinessReporter]()
+
+	// newRegistry registers the services in the reverse of the order they must start in.
+	newRegistry := func(t *testing.T, recorder *hostedRecorder) Registry {
+		registry, err := RegisterInstance[*hostedRecorder](Registry{}, recorder)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, err = RegisterType[*readinessReporter, *readinessReporter](
+			registry,
+			Singleton,
+			WithStartAfter(listenerType))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*httpListener, *httpListener](
+			registry,
+			Singleton,
+			WithStartAfter(migratorType))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*migrator, *migrator](registry, Singleton, AsHostedService())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		return registry
+	}
+
+	t.Run("starts in dependency order and stops in reverse", func(t *testing.T) {
+		recorder := &hostedRecorder{}
+		provider, err := newRegistry(t, recorder).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		expected := []reflect.Type{migratorType, listenerType, readinessType}
+		if actual := provider.HostedServices(); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected order %v; got %v", expected, actual)
+		}
+		if err := provider.StartHostedServices(context.Background()); err != nil {
+			t.Fatalf("unexpected error from StartHostedServices: %v", err)
+		}
+		if actual := recorder.types("start"); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected starts %v; got %v", expected, actual)
+		}
+		if err := provider.StartHostedServices(context.Background()); err != nil {
+			t.Fatalf("unexpected error from StartHostedServices: %v", err)
+		}
+		if starts := len(recorder.types("start")); starts != 3 {
+			t.Fatalf("expected started services not to start again; got %d starts", starts)
+		}
+		if err := provider.StopHostedServices(context.Background()); err != nil {
+			t.Fatalf("unexpected error from StopHostedServices: %v", err)
+		}
+		reversed := []reflect.Type{readinessType, listenerType, migratorType}
+		if actual := recorder.types("stop"); !reflect.DeepEqual(actual, reversed) {
+			t.Fatalf("expected stops %v; got %v", reversed, actual)
+		}
+	})
+
+	t.Run("stops the started services in reverse when the provider shuts down", func(t *testing.T) {
+		for name, shutdown := range map[string]func(RootProvider) []error{
+			"Close": func(provider RootProvider) []error {
+				return provider.Close(context.Background())
+			},
+			"CloseOnSignal": func(provider RootProvider) []error {
+				var errs []error
+				provider.OnClose(func(closeErrors []error) {
+					errs = closeErrors
+				})
+				CloseOnSignal(provider, WithShutdownSignals())()
+				return errs
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				recorder := &hostedRecorder{}
+				provider, err := newRegistry(t, recorder).BuildRootProvider()
+				if err != nil {
+					t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+				}
+				if err := provider.StartHostedServices(context.Background()); err != nil {
+					t.Fatalf("unexpected error from StartHostedServices: %v", err)
+				}
+				if errs := shutdown(provider); len(errs) != 0 {
+					t.Fatalf("unexpected errors from the shutdown: %v", errs)
+				}
+				expected := []reflect.Type{readinessType, listenerType, migratorType}
+				if actual := recorder.types("stop"); !reflect.DeepEqual(actual, expected) {
+					t.Fatalf("expected stops %v; got %v", expected, actual)
+				}
+			})
+		}
+	})
+
+	t.Run("Close returns the errors from stopping the services", func(t *testing.T) {
+		recorder := &hostedRecorder{
+			fail: map[string]reflect.Type{"stop": listenerType},
+		}
+		provider, err := newRegistry(t, recorder).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.StartHostedServices(context.Background()); err != nil {
+			t.Fatalf("unexpected error from StartHostedServices: %v", err)
+		}
+		err = errors.Join(provider.Close(context.Background())...)
+		var failed HostedServiceFailed
+		if !errors.As(err, &failed) || failed.Type != listenerType || failed.Operation != "stop" {
+			t.Fatalf("expected %v to include the failure to stop %v", err, listenerType)
+		}
+	})
+
+	t.Run("stops the started services when one fails to start", func(t *testing.T) {
+		recorder := &hostedRecorder{
+			fail: map[string]reflect.Type{"start": readinessType},
+		}
+		provider, err := newRegistry(t, recorder).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		err = provider.StartHostedServices(context.Background())
+		var failed HostedServiceFailed
+		if !errors.As(err, &failed) {
+			t.Fatalf("expected %v to be a HostedServiceFailed", err)
+		}
+		if failed.Type != readinessType || failed.Operation != "start" {
+			t.Errorf("expected the start of %v to fail; got %v", readinessType, failed)
+		}
+		expected := []reflect.Type{listenerType, migratorType}
+		if actual := recorder.types("stop"); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected stops %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("stops every service when one fails to stop", func(t *testing.T) {
+		recorder := &hostedRecorder{
+			fail: map[string]reflect.Type{"stop": listenerType},
+		}
+		provider, err := newRegistry(t, recorder).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.StartHostedServices(context.Background()); err != nil {
+			t.Fatalf("unexpected error from StartHostedServices: %v", err)
+		}
+		err = provider.StopHostedServices(context.Background())
+		if !errors.Is(err, ErrHostedServiceFailed) {
+			t.Fatalf("expected %v to be %v", err, ErrHostedServiceFailed)
+		}
+		expected := []reflect.Type{readinessType, migratorType}
+		if actual := recorder.types("stop"); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected stops %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("BuildRootProvider rejects a cycle", func(t *testing.T) {
+		registry, err := RegisterType[*migrator, *migrator](
+			newRegistry(t, &hostedRecorder{}),
+			Singleton,
+			WithStartAfter(readinessType))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		_, err = registry.BuildRootProvider()
+		var cycle HostedServiceCycle
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected %v to be a HostedServiceCycle", err)
+		}
+		expected := []reflect.Type{readinessType, listenerType, migratorType, readinessType}
+		if !reflect.DeepEqual(cycle.Types, expected) {
+			t.Errorf("expected cycle %v; got %v", expected, cycle.Types)
+		}
+	})
+
+	t.Run("BuildRootProvider rejects invalid hosted services", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			register func(Registry) (Registry, error)
+		}{
+			{
+				name: "not a singleton",
+				register: func(registry Registry) (Registry, error) {
+					return RegisterType[*migrator, *migrator](registry, Transient, AsHostedService())
+				},
+			},
+			{
+				name: "not a HostedService",
+				register: func(registry Registry) (Registry, error) {
+					return RegisterType[*mockCloser, *mockCloser](registry, Singleton, AsHostedService())
+				},
+			},
+			{
+				name: "after a type that is not hosted",
+				register: func(registry Registry) (Registry, error) {
+					return RegisterType[*migrator, *migrator](
+						registry,
+						Singleton,
+						WithStartAfter(reflect.TypeFor[*hostedRecorder]()))
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				registry, err := tt.register(newRegistry(t, &hostedRecorder{}))
+				if err != nil {
+					t.Fatalf("unexpected error from RegisterType: %v", err)
+				}
+				if _, err := registry.BuildRootProvider(); !errors.Is(err, ErrInvalidHostedService) {
+					t.Fatalf("expected %v to be %v", err, ErrInvalidHostedService)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package di
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithIdleTimeout makes a new scope close itself once it has gone unused for the timeout, e.g. for
+// a scope that holds a logged-in user's session between requests. A scope is in use while it is
+// resolving a value, and each resolution restarts the timeout; see [Scope.LastUsed].
+//
+// Idle scopes are closed by a single goroutine per provider that stops when the provider is
+// closed, after which the provider's scopes are no longer closed when idle. The close is given the
+// deadline set with [WithCloseTimeout], if any, and is reported to the provider's [Observer] as a
+// [ScopeIdleClosed] and to its [WithCloseErrorHandler] if it fails. A resolution that races with
+// the close either keeps the scope open, restarting its timeout, or fails with a [ScopeClosed];
+// the scope's values are never closed while it is resolving. A scope that is abandoned without
+// being closed is still reported by [WithLeakDetection] once it is garbage collected, and is closed
+// once it is idle. A timeout of 0 or less, which is the default, means the scope is not closed when
+// idle.
+func WithIdleTimeout(timeout time.Duration) ScopeOption {
+	return func(options *scopeOptions) {
+		options.idleTimeout = timeout
+	}
+}
+
+// A ScopeIdleClosed is an [Event] indicating that a [Scope] was closed because it went unused for
+// longer than its [WithIdleTimeout].
+type ScopeIdleClosed struct {
+
+	// ID is the identifier of the closed scope.
+	ID string
+
+	// LastUsed is when the scope was last used.
+	LastUsed time.Time
+
+	// Timeout is the scope's idle timeout.
+	Timeout time.Duration
+
+	// Errors are the errors from closing the scope.
+	Errors []error
+}
+
+func (ScopeIdleClosed) event() {}
+
+// LastUsed returns when the scope last started or finished resolving a value, or when it was
+// created if it has not resolved anything. Only scopes created [WithIdleTimeout] track their use;
+// LastUsed returns the zero time for other scopes.
+func (scope Scope) LastUsed() time.Time {
+	return scope.idle.lastUse()
+}
+
+// idleTracker tracks the use of a scope created WithIdleTimeout.
+type idleTracker struct {
+	timeout  time.Duration
+	mu       sync.Mutex
+	active   int
+	lastUsed time.Time
+	closed   bool
+}
+
+func newIdleTracker(timeout time.Duration) *idleTracker {
+	if timeout <= 0 {
+		return nil
+	}
+	return &idleTracker{
+		timeout:  timeout,
+		lastUsed: time.Now(),
+	}
+}
+
+// enter records that the scope with the given ID started resolving a value, or returns a
+// ScopeClosed if the scope has expired.
+func (idle *idleTracker) enter(id string) error {
+	if idle == nil {
+		return nil
+	}
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	if idle.closed {
+		return ScopeClosed{
+			ID: id,
+		}
+	}
+	idle.active++
+	idle.lastUsed = time.Now()
+	return nil
+}
+
+// exit records that the scope finished resolving a value.
+func (idle *idleTracker) exit() {
+	if idle == nil {
+		return
+	}
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	idle.active--
+	idle.lastUsed = time.Now()
+}
+
+// expire marks the scope closed if it has been idle for its timeout at now and reports whether it
+// did. Otherwise it returns when the scope should be checked again.
+func (idle *idleTracker) expire(now time.Time) (time.Time, bool) {
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	if idle.closed {
+		return time.Time{}, false
+	}
+	if idle.active > 0 {
+		return now.Add(idle.timeout), false
+	}
+	if due := idle.lastUsed.Add(idle.timeout); now.Before(due) {
+		return due, false
+	}
+	idle.closed = true
+	return time.Time{}, true
+}
+
+// close marks the scope closed so that it rejects further resolutions.
+func (idle *idleTracker) close() {
+	if idle == nil {
+		return
+	}
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	idle.closed = true
+}
+
+func (idle *idleTracker) lastUse() time.Time {
+	if idle == nil {
+		return time.Time{}
+	}
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	return idle.lastUsed
+}
+
+// idleSweeper closes a provider's idle scopes from a single goroutine, which is started when the
+// first scope created WithIdleTimeout is tracked. The scopes are keyed by their state and held
+// without their leak trackers so that the sweeper doesn't stop leak detection from reporting a
+// scope that was abandoned without being closed.
+type idleSweeper struct {
+	mu      sync.Mutex
+	scopes  map[*scopeState]Scope
+	running bool
+	stopped bool
+	wake    chan struct{}
+	stop    chan struct{}
+	swept   atomic.Uint64
+}
+
+func newIdleSweeper() *idleSweeper {
+	return &idleSweeper{
+		scopes: map[*scopeState]Scope{},
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+// trackIdle starts tracking a scope created WithIdleTimeout.
+func (provider RootProvider) trackIdle(scope Scope) {
+	if scope.idle == nil {
+		return
+	}
+	sweeper := provider.idleScopes
+	sweeper.mu.Lock()
+	defer sweeper.mu.Unlock()
+	if sweeper.stopped {
+		return
+	}
+	scope.leak = nil
+	sweeper.scopes[scope.state] = scope
+	if !sweeper.running {
+		sweeper.running = true
+		go provider.sweepIdleScopes()
+	}
+	select {
+	case sweeper.wake <- struct{}{}:
+	default:
+	}
+}
+
+// untrack stops tracking a scope that has been closed.
+func (sweeper *idleSweeper) untrack(scope Scope) {
+	if scope.idle == nil {
+		return
+	}
+	sweeper.mu.Lock()
+	defer sweeper.mu.Unlock()
+	delete(sweeper.scopes, scope.state)
+}
+
+// shutdown stops the sweeper's goroutine, if it was started, and stops tracking scopes.
+func (sweeper *idleSweeper) shutdown() {
+	sweeper.mu.Lock()
+	defer sweeper.mu.Unlock()
+	if sweeper.stopped {
+		return
+	}
+	sweeper.stopped = true
+	sweeper.scopes = nil
+	close(sweeper.stop)
+}
+
+// expired returns the scopes that have been idle for their timeout at now, which are no longer
+// tracked, and when the remaining scopes should next be checked.
+func (sweeper *idleSweeper) expired(now time.Time) ([]Scope, time.Time) {
+	sweeper.mu.Lock()
+	defer sweeper.mu.Unlock()
+	var expired []Scope
+	var next time.Time
+	for state, scope := range sweeper.scopes {
+		due, ok := scope.idle.expire(now)
+		if ok {
+			delete(sweeper.scopes, state)
+			expired = append(expired, scope)
+			continue
+		}
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return expired, next
+}
+
+// sweepIdleScopes closes the provider's idle scopes until the provider is closed.
+func (provider RootProvider) sweepIdleScopes() {
+	sweeper := provider.idleScopes
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		expired, next := sweeper.expired(time.Now())
+		for _, scope := range expired {
+			provider.closeIdle(scope)
+		}
+		var wait <-chan time.Time
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+			wait = timer.C
+		}
+		select {
+		case <-wait:
+		case <-sweeper.wake:
+		case <-sweeper.stop:
+			return
+		}
+	}
+}
+
+// closeIdle closes a scope that has expired, delegating its errors to the provider's close error
+// handler.
+func (provider RootProvider) closeIdle(scope Scope) {
+	ctx := context.Background()
+	if scope.closeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, scope.closeTimeout)
+		defer cancel()
+	}
+	errs := scope.Close(ctx, DelegateErrors())
+	provider.idleScopes.swept.Add(1)
+	provider.observe(ScopeIdleClosed{
+		ID:       scope.id,
+		LastUsed: scope.idle.lastUse(),
+		Timeout:  scope.idle.timeout,
+		Errors:   errs,
+	})
+}
@@ -0,0 +1,168 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type sessionState struct{}
+
+func TestWithIdleTimeout(t *testing.T) {
+
+	closeErr := errors.New("close failed")
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) (RootProvider, chan ScopeIdleClosed) {
+		registry, err := RegisterFactory[*errorCloser](Registry{}, Scoped, func(Resolver) (*errorCloser, error) {
+			return &errorCloser{err: closeErr}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*sessionState](registry, Transient, func(Resolver) (*sessionState, error) {
+			return &sessionState{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		events := make(chan ScopeIdleClosed, 16)
+		opts = append(opts, WithObserver(ObserverFunc(func(event Event) {
+			if event, ok := event.(ScopeIdleClosed); ok {
+				events <- event
+			}
+		})))
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		t.Cleanup(func() {
+			provider.Close(context.Background())
+		})
+		return provider, events
+	}
+
+	awaitClosed := func(t *testing.T, events chan ScopeIdleClosed) ScopeIdleClosed {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the idle scope to be closed")
+		}
+		return ScopeIdleClosed{}
+	}
+
+	t.Run("closes idle scopes", func(t *testing.T) {
+		rec := newCloseHandlerRecorder()
+		provider, events := newProvider(t, WithCloseErrorHandler(rec.handle))
+		scope := provider.NewScope(WithIdleTimeout(20 * time.Millisecond))
+		if _, err := Resolve[*errorCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		event := awaitClosed(t, events)
+		if event.ID != scope.ID() || event.Timeout != 20*time.Millisecond {
+			t.Errorf("expected an event for %s with a 20ms timeout; got %v", scope.ID(), event)
+		}
+		if len(event.Errors) != 1 || !errors.Is(event.Errors[0], closeErr) {
+			t.Errorf("expected the event to hold %v; got %v", closeErr, event.Errors)
+		}
+		if !event.LastUsed.Equal(scope.LastUsed()) {
+			t.Errorf("expected LastUsed %v; got %v", scope.LastUsed(), event.LastUsed)
+		}
+		if handled := rec.handled(); len(handled) != 1 || handled[0].ScopeID != scope.ID() {
+			t.Errorf("expected the close error handler to be called for %s; got %v", scope.ID(), handled)
+		}
+		if _, err := Resolve[*errorCloser](scope); !errors.Is(err, ErrScopeClosed) {
+			t.Errorf("expected %v to be %v", err, ErrScopeClosed)
+		}
+		if stats := provider.Stats(); stats.SweptScopes != 1 || stats.IdleScopes != 0 {
+			t.Errorf("expected 1 swept scope and no idle scopes; got %+v", stats)
+		}
+	})
+
+	t.Run("resolving restarts the timeout", func(t *testing.T) {
+		provider, events := newProvider(t)
+		scope := provider.NewScope(WithIdleTimeout(100 * time.Millisecond))
+		created := scope.LastUsed()
+		deadline := time.Now().Add(300 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			if _, err := Resolve[*sessionState](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		select {
+		case event := <-events:
+			t.Fatalf("expected the scope in use not to be closed; got %v", event)
+		default:
+		}
+		if !scope.LastUsed().After(created) {
+			t.Errorf("expected LastUsed to advance past %v; got %v", created, scope.LastUsed())
+		}
+		awaitClosed(t, events)
+	})
+
+	t.Run("does not close scopes while they are resolving", func(t *testing.T) {
+		registry, err := RegisterFactory[*sessionState](Registry{}, Transient, func(Resolver) (*sessionState, error) {
+			time.Sleep(100 * time.Millisecond)
+			return &sessionState{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		defer provider.Close(context.Background())
+		scope := provider.NewScope(WithIdleTimeout(10 * time.Millisecond))
+		if _, err := Resolve[*sessionState](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if stats := provider.Stats(); stats.SweptScopes != 0 {
+			t.Fatalf("expected no swept scopes; got %+v", stats)
+		}
+	})
+
+	t.Run("explicitly closed scopes are no longer tracked", func(t *testing.T) {
+		provider, _ := newProvider(t)
+		scope := provider.NewScope(WithIdleTimeout(time.Hour))
+		if idle := provider.Stats().IdleScopes; idle != 1 {
+			t.Fatalf("expected 1 idle scope; got %d", idle)
+		}
+		scope.Close(context.Background())
+		if stats := provider.Stats(); stats.IdleScopes != 0 || stats.SweptScopes != 0 {
+			t.Fatalf("expected no idle or swept scopes; got %+v", stats)
+		}
+		if _, err := Resolve[*sessionState](scope); !errors.Is(err, ErrScopeClosed) {
+			t.Errorf("expected %v to be %v", err, ErrScopeClosed)
+		}
+	})
+
+	t.Run("stops sweeping when the provider is closed", func(t *testing.T) {
+		provider, events := newProvider(t)
+		provider.NewScope(WithIdleTimeout(time.Hour))
+		provider.Close(context.Background())
+		provider.NewScope(WithIdleTimeout(time.Millisecond))
+		time.Sleep(50 * time.Millisecond)
+		select {
+		case event := <-events:
+			t.Fatalf("expected no scopes to be closed; got %v", event)
+		default:
+		}
+		if stats := provider.Stats(); stats.IdleScopes != 0 {
+			t.Fatalf("expected no idle scopes; got %+v", stats)
+		}
+	})
+
+	t.Run("scopes without a timeout do not track their use", func(t *testing.T) {
+		provider, _ := newProvider(t)
+		scope := provider.NewScope()
+		if _, err := Resolve[*sessionState](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if lastUsed := scope.LastUsed(); !lastUsed.IsZero() {
+			t.Errorf("expected a zero LastUsed; got %v", lastUsed)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ImportResolver registers each of types as a [Singleton] whose value is requested from external,
+// e.g. to move services from another container one at a time while the rest are still built by
+// it. The first value external returns for each type is kept by the provider but not owned by it,
+// so it is not closed when the provider is closed. Resolving an imported type fails with an
+// [ImportError] if external returns an error, an untyped nil, or a value that is not assignable to
+// the type, which distinguishes failures of the external container from those of the provider.
+//
+// Imported registrations are registrations like any other: a later registration of the same type
+// replaces an imported one and a later import replaces a native one. They are marked Imported in
+// the [RegistrationInfo] values returned by [Registry.Registrations].
+//
+// Like [RegisterFactory], ImportResolver returns an [UnsharableType] for a type that cannot be
+// shared as a [Singleton], such as a struct type, except that interface types are accepted since
+// external chooses the implementation behind them. It returns [ErrNilResolver] if external is nil,
+// or a [NilType] if any of types is nil. If it returns an error none of the types are registered.
+func ImportResolver(registry Registry, external Resolver, types ...reflect.Type) (Registry, error) {
+	if external == nil {
+		return registry, ErrNilResolver
+	}
+	for _, typ := range types {
+		if typ == nil {
+			return registry, NilType{
+				Parameter: "types",
+			}
+		}
+		sharedValue := isFunc(typ) || typ.Kind() == reflect.Interface
+		if err := validateLifetime(typ, Singleton, sharedValue); err != nil {
+			return registry, err
+		}
+	}
+	for _, typ := range types {
+		registration_ := newRegistration(Singleton, typ, importFactory(external, typ), false, nil)
+		registration_.source = sourceImported
+		registry = addRegistration(registry, typ, registration_)
+	}
+	return registry, nil
+}
+
+// importFactory returns a factory that requests typ from external and checks the value it returns.
+func importFactory(external Resolver, typ reflect.Type) factoryFunc {
+	return func(Resolver) (any, error) {
+		value, err := external.Resolve(typ)
+		if err != nil {
+			return nil, ImportError{
+				Type: typ,
+				Err:  err,
+			}
+		}
+		if value == nil {
+			return nil, ImportError{
+				Type: typ,
+				Err: NilResolution{
+					Type: typ,
+				},
+			}
+		}
+		if impl := reflect.TypeOf(value); !impl.AssignableTo(typ) {
+			return nil, ImportError{
+				Type: typ,
+				Err: InvalidResolution{
+					Requested: typ,
+					Returned:  impl,
+				},
+			}
+		}
+		return value, nil
+	}
+}
+
+// ErrImportFailed is returned when the resolver given to [ImportResolver] fails to resolve an
+// imported type.
+var ErrImportFailed = errors.New("imported resolver failed")
+
+// An ImportError is an [error] indicating that the resolver given to [ImportResolver] returned an
+// error for an imported type, or returned a value that is nil or not assignable to it. Calling
+// [errors.Is] with an ImportError and [ErrImportFailed] returns true, and [errors.Is] and
+// [errors.As] also match the underlying error.
+type ImportError struct {
+
+	// Type is the imported type.
+	Type reflect.Type
+
+	// Err is the error from the imported resolver, or a [NilResolution] or [InvalidResolution]
+	// describing the value it returned.
+	Err error
+}
+
+// Error implements [error].
+func (err ImportError) Error() string {
+	return fmt.Sprintf("imported resolver failed to resolve %v: %v", err.Type, err.Err)
+}
+
+// Is indicates that an [ImportError] is [ErrImportFailed].
+func (ImportError) Is(target error) bool {
+	return target == ErrImportFailed
+}
+
+// Unwrap returns the underlying error.
+func (err ImportError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "import_error", the code of an [ImportError] in [ErrorCodes].
+func (ImportError) Code() string {
+	return "import_error"
+}
@@ -0,0 +1,182 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type legacyClock interface {
+	Now() string
+}
+
+type legacyClockImpl struct{}
+
+func (legacyClockImpl) Now() string {
+	return "now"
+}
+
+type legacyStore struct{}
+
+type migratedService struct {
+	Clock legacyClock
+	Store *legacyStore
+}
+
+func TestImportResolver(t *testing.T) {
+
+	errLegacy := errors.New("legacy container failed")
+
+	legacy := func(calls *int) Resolver {
+		return ResolverFunc(func(typ reflect.Type) (any, error) {
+			*calls++
+			switch typ {
+			case reflect.TypeFor[legacyClock]():
+				return legacyClockImpl{}, nil
+			case reflect.TypeFor[*legacyStore]():
+				return &legacyStore{}, nil
+			}
+			return nil, UnknownType{
+				Type: typ,
+			}
+		})
+	}
+
+	newProvider := func(t *testing.T, external Resolver, types ...reflect.Type) RootProvider {
+		registry, err := RegisterType[*migratedService, *migratedService](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = ImportResolver(registry, external, types...)
+		if err != nil {
+			t.Fatalf("unexpected error from ImportResolver: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	imported := []reflect.Type{reflect.TypeFor[legacyClock](), reflect.TypeFor[*legacyStore]()}
+
+	t.Run("resolves imported types from the external resolver once", func(t *testing.T) {
+		var calls int
+		provider := newProvider(t, legacy(&calls), imported...)
+		for range 2 {
+			service, err := Resolve[*migratedService](provider.NewScope())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if service.Clock == nil || service.Clock.Now() != "now" || service.Store == nil {
+				t.Fatalf("expected the imported values to be injected; got %+v", service)
+			}
+		}
+		if calls != 2 {
+			t.Fatalf("expected each imported type to be requested once; got %d calls", calls)
+		}
+		if err := provider.Verify(); err != nil {
+			t.Fatalf("unexpected error from Verify: %v", err)
+		}
+	})
+
+	t.Run("wraps errors from the external resolver", func(t *testing.T) {
+		provider := newProvider(t, ResolverFunc(func(reflect.Type) (any, error) {
+			return nil, errLegacy
+		}), imported...)
+		_, err := Resolve[legacyClock](provider)
+		var importErr ImportError
+		if !errors.As(err, &importErr) || importErr.Type != reflect.TypeFor[legacyClock]() {
+			t.Fatalf("expected an ImportError for legacyClock; got %v", err)
+		}
+		if !errors.Is(err, ErrImportFailed) || !errors.Is(err, errLegacy) {
+			t.Fatalf("expected %v to be %v and %v", err, ErrImportFailed, errLegacy)
+		}
+	})
+
+	t.Run("validates the values from the external resolver", func(t *testing.T) {
+		for _, test := range []struct {
+			name  string
+			value any
+			want  error
+		}{
+			{name: "nil", value: nil, want: ErrNilResolution},
+			{name: "unassignable", value: &legacyStore{}, want: ErrInvalidResolution},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				provider := newProvider(t, ResolverFunc(func(reflect.Type) (any, error) {
+					return test.value, nil
+				}), reflect.TypeFor[legacyClock]())
+				_, err := Resolve[legacyClock](provider)
+				if !errors.Is(err, ErrImportFailed) || !errors.Is(err, test.want) {
+					t.Fatalf("expected %v to be %v and %v", err, ErrImportFailed, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("replaces and is replaced by other registrations", func(t *testing.T) {
+		var calls int
+		registry, err := RegisterInstance[*legacyStore](Registry{}, &legacyStore{})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, err = ImportResolver(registry, legacy(&calls), imported...)
+		if err != nil {
+			t.Fatalf("unexpected error from ImportResolver: %v", err)
+		}
+		registry, err = RegisterInstance[legacyClock](registry, legacyClockImpl{}, AllowSharedValue())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		imports := map[reflect.Type]bool{}
+		for _, info := range registry.Registrations() {
+			imports[info.Type] = info.Imported
+		}
+		if !imports[reflect.TypeFor[*legacyStore]()] || imports[reflect.TypeFor[legacyClock]()] {
+			t.Fatalf("expected only the store to be imported; got %v", imports)
+		}
+	})
+
+	t.Run("marks imported registrations", func(t *testing.T) {
+		var calls int
+		provider := newProvider(t, legacy(&calls), imported...)
+		for _, info := range provider.Registrations() {
+			if want := info.Type != reflect.TypeFor[*migratedService](); info.Imported != want {
+				t.Errorf("expected Imported to be %v for %v", want, info.Type)
+			}
+			if info.Imported && (info.Lifetime != Singleton || info.Owned) {
+				t.Errorf("expected an unowned Singleton for %v; got %+v", info.Type, info)
+			}
+		}
+	})
+
+	t.Run("returns UnsharableType for unsharable types", func(t *testing.T) {
+		var calls int
+		registry, err := ImportResolver(Registry{}, legacy(&calls), reflect.TypeFor[legacyClock](), reflect.TypeFor[legacyStore]())
+		var unsharable UnsharableType
+		if !errors.As(err, &unsharable) || unsharable.Type != reflect.TypeFor[legacyStore]() {
+			t.Fatalf("expected an UnsharableType for legacyStore; got %v", err)
+		}
+		if unsharable.Lifetime != Singleton || unsharable.SuggestedTarget != reflect.TypeFor[*legacyStore]() {
+			t.Fatalf("unexpected %+v", unsharable)
+		}
+		if len(registry.Registrations()) != 0 {
+			t.Fatalf("expected no registrations; got %v", registry.Registrations())
+		}
+	})
+
+	t.Run("returns errors for nil arguments", func(t *testing.T) {
+		if _, err := ImportResolver(Registry{}, nil, imported...); !errors.Is(err, ErrNilResolver) {
+			t.Fatalf("expected %v to be %v", err, ErrNilResolver)
+		}
+		var calls int
+		registry, err := ImportResolver(Registry{}, legacy(&calls), reflect.TypeFor[legacyClock](), nil)
+		if !errors.Is(err, ErrNilType) {
+			t.Fatalf("expected %v to be %v", err, ErrNilType)
+		}
+		if len(registry.Registrations()) != 0 {
+			t.Fatalf("expected no registrations; got %v", registry.Registrations())
+		}
+	})
+}
@@ -2,12 +2,57 @@ package di
 
 import (
 	"reflect"
-	"sync"
+	"sync/atomic"
 )
 
+// instanceMap caches the instances of a provider, scope, or resolution in an InstanceStore and
+// tracks the expiry of the instances whose registrations use WithTTL.
 type instanceMap struct {
-	mu        sync.RWMutex
-	instances map[reflect.Type]any
+
+	// defaults is the store used until the map is reset unless a custom store is used, so the
+	// zero value is ready to use.
+	defaults mapStore
+
+	// current is the store used instead of defaults, if any: the store created by the function
+	// given to WithInstanceStore, or the store that replaced defaults when the map was reset. It
+	// is replaced when the map is reset while other goroutines may be reading it, e.g. to describe
+	// a scope, so it is only accessed atomically.
+	current atomic.Pointer[InstanceStore]
+
+	// newCustom creates a store to replace the current store when the map is reset, if the map
+	// uses custom stores.
+	newCustom func() InstanceStore
+
+	// expiry tracks the age of the instances whose registrations use WithTTL.
+	expiry expiryState
+
+	// provenance records the origin of the instances when provenance is recorded.
+	provenance provenanceRecords
+}
+
+// newInstanceMap returns an instanceMap that uses the store created by newStore, or the default
+// store with storage allocated for capacity instances if newStore is nil.
+func newInstanceMap(newStore func() InstanceStore, capacity int) *instanceMap {
+	m := &instanceMap{}
+	m.init(newStore, capacity)
+	return m
+}
+
+// init prepares a new or reset instanceMap; see newInstanceMap.
+func (m *instanceMap) init(newStore func() InstanceStore, capacity int) {
+	m.defaults.capacity = capacity
+	m.newCustom = newStore
+	if newStore != nil {
+		custom := newStore()
+		m.current.Store(&custom)
+	}
+}
+
+func (m *instanceMap) store() InstanceStore {
+	if current := m.current.Load(); current != nil {
+		return *current
+	}
+	return &m.defaults
 }
 
 func (m *instanceMap) resolve(
@@ -18,37 +63,106 @@ func (m *instanceMap) resolve(
 	if v, ok := m.get(typ); ok {
 		return v, nil
 	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	// We may have resolved and saved a singleton instance while we were waiting for a lock so check again.
-	if service, ok := m.instances[typ]; ok {
-		return service, nil
+	created := false
+	value, err := m.store().GetOrCreate(typ, func(resolver Resolver) (any, error) {
+		value, err := factory(resolver)
+		created = err == nil
+		return value, err
+	}, resolver)
+	if created {
+		m.expiry.forget(typ)
+	}
+	return value, err
+}
+
+func (m *instanceMap) get(typ reflect.Type) (any, bool) {
+	return m.store().Get(typ)
+}
+
+type instanceEntry struct {
+	typ   reflect.Type
+	value any
+}
+
+// entries returns the instances in the order they were created.
+func (m *instanceMap) entries() []instanceEntry {
+	stored := m.store().Snapshot()
+	entries := make([]instanceEntry, 0, len(stored))
+	for _, instance := range stored {
+		entries = append(entries, instanceEntry{
+			typ:   instance.Type,
+			value: instance.Value,
+		})
 	}
-	// Build, save, and return the scoped instance.
-	service, err := factory(resolver)
-	if err != nil {
-		return nil, err
+	return entries
+}
+
+func (m *instanceMap) len() int {
+	store := m.store()
+	if store, ok := store.(*mapStore); ok {
+		return store.len()
 	}
-	if m.instances == nil {
-		m.instances = make(map[reflect.Type]any)
+	return len(store.Snapshot())
+}
+
+// reset removes every instance by replacing the store with a new one, so that a construction that
+// was in progress when the map was reset, such as one started by a factory that was abandoned
+// after its timeout, finishes in the discarded store rather than caching its instance in the new
+// one.
+func (m *instanceMap) reset() {
+	m.expiry.reset()
+	m.provenance.reset()
+	var store InstanceStore
+	if m.newCustom != nil {
+		store = m.newCustom()
+	} else {
+		store = &mapStore{
+			capacity: m.defaults.capacity,
+		}
 	}
-	m.instances[typ] = service
-	return service, nil
+	m.current.Store(&store)
 }
 
-func (m *instanceMap) get(typ reflect.Type) (any, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	v, ok := m.instances[typ]
-	return v, ok
+// recycle removes every instance while keeping the storage of the default store so that the map
+// can be reused by another scope. Unlike reset, it must only be called once nothing else can use
+// the map.
+func (m *instanceMap) recycle() {
+	m.expiry.reset()
+	m.provenance.reset()
+	m.current.Store(nil)
+	m.defaults.clear()
 }
 
-func (m *instanceMap) values() []any {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	values := make([]any, 0, len(m.instances))
-	for _, v := range m.instances {
-		values = append(values, v)
+// remove removes the instance of typ and returns it, if there was one.
+func (m *instanceMap) remove(typ reflect.Type) (any, bool) {
+	value, ok := m.store().Delete(typ)
+	if ok {
+		m.expiry.forget(typ)
+		m.provenance.forget(typ)
+	}
+	return value, ok
+}
+
+// replace stores value as the instance of typ and returns the instance it replaced, if any. If
+// there is already an instance and force is false the instance is left in place and replace
+// returns it with stored set to false.
+func (m *instanceMap) replace(typ reflect.Type, value any, force bool) (old any, replaced bool, stored bool) {
+	if force {
+		old, replaced = m.store().Store(typ, value)
+		m.expiry.forget(typ)
+		m.provenance.forget(typ)
+		return old, replaced, true
+	}
+	old, err := m.store().GetOrCreate(typ, func(Resolver) (any, error) {
+		stored = true
+		return value, nil
+	}, nil)
+	if err != nil || stored {
+		// The value may have been replaced while it was being stored in which case the
+		// replacement is left in place.
+		m.expiry.forget(typ)
+		m.provenance.forget(typ)
+		return nil, false, stored
 	}
-	return values
+	return old, true, false
 }
@@ -0,0 +1,322 @@
+package di
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// An InstanceStore holds the instances cached by a [RootProvider] for [Singleton] registrations or
+// by a [Scope] for [Scoped] registrations, keyed by registered type. A provider uses the store
+// returned by [NewInstanceStore] unless it was built with [WithInstanceStore]. The
+// ditest.TestInstanceStore conformance suite checks that a custom store meets the requirements
+// below.
+//
+// An InstanceStore must be safe for concurrent use and must not hold any lock it needs while it
+// calls a factory, since factories resolve their dependencies from the same store.
+type InstanceStore interface {
+
+	// Get returns the instance stored for key, if any.
+	Get(key reflect.Type) (any, bool)
+
+	// GetOrCreate returns the instance stored for key, calling factory with resolver to create and
+	// store one if there isn't one. Concurrent calls for the same key must call factory at most
+	// once and the others must wait for and return its result. An error from factory is returned
+	// to every waiting caller and nothing is stored, so a later call tries again. If an instance is
+	// stored for key using Store while factory is running, the stored instance is returned instead
+	// of the one factory created. If factory panics the panic is propagated and the waiting callers
+	// receive an error.
+	GetOrCreate(key reflect.Type, factory func(Resolver) (any, error), resolver Resolver) (any, error)
+
+	// Snapshot returns a copy of the stored instances in the order they were first stored.
+	// Replacing an instance using Store keeps its position but updates its creation time.
+	Snapshot() []StoredInstance
+
+	// Store stores value as the instance for key, returning the instance it replaced, if any.
+	Store(key reflect.Type, value any) (previous any, replaced bool)
+
+	// Delete removes the instance stored for key and returns it, if there was one.
+	Delete(key reflect.Type) (any, bool)
+}
+
+// A StoredInstance is an instance held by an [InstanceStore].
+type StoredInstance struct {
+
+	// Type is the registered type the instance was stored for.
+	Type reflect.Type
+
+	// Value is the instance.
+	Value any
+
+	// Created is the time the instance was stored.
+	Created time.Time
+}
+
+// WithInstanceStore makes the provider, and every [Scope] created from it, cache their instances
+// in stores created by newStore instead of the store returned by [NewInstanceStore], e.g. to
+// experiment with other caching strategies. newStore is called once for the provider's
+// [Singleton] instances and once for each scope. The [PerResolution] instances of a single
+// resolution and the instances shared using [WithSharedSingletons] always use the default store.
+func WithInstanceStore(newStore func() InstanceStore) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithInstanceStore") {
+			options.conflict("given more than once, but a provider uses a single kind of store", "WithInstanceStore")
+		}
+		options.newInstanceStore = newStore
+	}
+}
+
+// NewInstanceStore returns the [InstanceStore] providers use by default, which is a map guarded by
+// a lock.
+func NewInstanceStore() InstanceStore {
+	return &mapStore{
+		timestamps: true,
+	}
+}
+
+// mapStore is the default InstanceStore. Its zero value is ready to use. A mapStore allocates
+// nothing until the first instance is created and nothing more for each instance unless
+// resolutions contend for it, so that a scope that resolves a few values stays cheap.
+type mapStore struct {
+	mu        sync.RWMutex
+	instances map[reflect.Type]storedInstance
+
+	// capacity is the number of instances to allocate storage for when the first instance is
+	// created.
+	capacity int
+
+	// next is the position of the next new instance in the order of the snapshot.
+	next uint64
+
+	// timestamps makes the store record the time each instance was stored. The stores a provider
+	// creates for itself leave it unset since only Snapshot reports the times and they don't use
+	// them.
+	timestamps bool
+}
+
+// A storedInstance is an instance held by a mapStore, or a placeholder for an instance that is
+// being constructed.
+type storedInstance struct {
+	value   any
+	created time.Time
+
+	// position orders the instance in the snapshot.
+	position uint64
+
+	// ready is true once the entry holds an instance.
+	ready bool
+
+	// constructing is true while a factory for the instance is running.
+	constructing bool
+
+	// waiting is created by the first resolution that waits for the construction, if any.
+	waiting *construction
+}
+
+// A construction is the result of a call to a factory shared by every resolution waiting on it.
+type construction struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// Get implements [InstanceStore].
+func (m *mapStore) Get(typ reflect.Type) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stored := m.instances[typ]
+	return stored.value, stored.ready
+}
+
+// GetOrCreate implements [InstanceStore].
+func (m *mapStore) GetOrCreate(
+	typ reflect.Type,
+	factory func(Resolver) (any, error),
+	resolver Resolver,
+) (any, error) {
+	if v, ok := m.Get(typ); ok {
+		return v, nil
+	}
+	m.mu.Lock()
+	stored := m.instances[typ]
+	// We may have resolved and saved an instance while we were waiting for a lock so check again.
+	if stored.ready {
+		m.mu.Unlock()
+		return stored.value, nil
+	}
+	// Another resolution may already be constructing the instance in which case we use its result.
+	if stored.constructing {
+		if stored.waiting == nil {
+			stored.waiting = &construction{
+				done: make(chan struct{}),
+			}
+			m.instances[typ] = stored
+		}
+		m.mu.Unlock()
+		<-stored.waiting.done
+		return stored.waiting.value, stored.waiting.err
+	}
+	if m.instances == nil {
+		m.instances = make(map[reflect.Type]storedInstance, m.capacity)
+	}
+	m.instances[typ] = storedInstance{
+		constructing: true,
+	}
+	m.mu.Unlock()
+
+	// Build the instance without holding the lock so the factory can resolve other instances from
+	// the same map, then save it and release the resolutions waiting for it.
+	defer func() {
+		if v := recover(); v != nil {
+			m.finish(typ, nil, fmt.Errorf("factory for %v panicked: %v", typ, v))
+			panic(v)
+		}
+	}()
+	value, err := factory(resolver)
+	return m.finish(typ, value, err)
+}
+
+// finish records the result of the construction of the instance of typ and releases the
+// resolutions waiting for it. If an instance was stored while the factory was running it is
+// returned instead of value.
+func (m *mapStore) finish(typ reflect.Type, value any, err error) (any, error) {
+	m.mu.Lock()
+	stored := m.instances[typ]
+	waiting := stored.waiting
+	stored.constructing = false
+	stored.waiting = nil
+	switch {
+	case stored.ready && err == nil:
+		// The instance was replaced while it was being constructed so the replacement wins.
+		value = stored.value
+		m.instances[typ] = stored
+	case stored.ready:
+		m.instances[typ] = stored
+	case err == nil:
+		m.instances[typ] = m.newInstance(value)
+	default:
+		delete(m.instances, typ)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		value = nil
+	}
+	if waiting != nil {
+		waiting.value, waiting.err = value, err
+		close(waiting.done)
+	}
+	return value, err
+}
+
+// newInstance returns value as a new instance placed after every existing one. The caller must
+// hold the lock.
+func (m *mapStore) newInstance(value any) storedInstance {
+	stored := storedInstance{
+		value:    value,
+		position: m.next,
+		ready:    true,
+	}
+	m.next++
+	if m.timestamps {
+		stored.created = time.Now()
+	}
+	return stored
+}
+
+// Snapshot implements [InstanceStore].
+func (m *mapStore) Snapshot() []StoredInstance {
+	type positioned struct {
+		position uint64
+		instance StoredInstance
+	}
+	m.mu.RLock()
+	stored := make([]positioned, 0, len(m.instances))
+	for typ, instance := range m.instances {
+		if instance.ready {
+			stored = append(stored, positioned{
+				position: instance.position,
+				instance: StoredInstance{
+					Type:    typ,
+					Value:   instance.value,
+					Created: instance.created,
+				},
+			})
+		}
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(stored, func(a, b positioned) int {
+		return cmp.Compare(a.position, b.position)
+	})
+	instances := make([]StoredInstance, 0, len(stored))
+	for _, s := range stored {
+		instances = append(instances, s.instance)
+	}
+	return instances
+}
+
+// Store implements [InstanceStore].
+func (m *mapStore) Store(typ reflect.Type, value any) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.instances == nil {
+		m.instances = make(map[reflect.Type]storedInstance, m.capacity)
+	}
+	previous := m.instances[typ]
+	stored := previous
+	if previous.ready {
+		// Replacing an instance keeps its position.
+		stored.value = value
+		if m.timestamps {
+			stored.created = time.Now()
+		}
+	} else {
+		stored = m.newInstance(value)
+		stored.constructing = previous.constructing
+		stored.waiting = previous.waiting
+	}
+	m.instances[typ] = stored
+	return previous.value, previous.ready
+}
+
+// Delete implements [InstanceStore].
+func (m *mapStore) Delete(typ reflect.Type) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.instances[typ]
+	if !stored.ready {
+		return nil, false
+	}
+	if stored.constructing {
+		// Keep the placeholder so that the construction in progress is still shared.
+		m.instances[typ] = storedInstance{
+			constructing: true,
+			waiting:      stored.waiting,
+		}
+	} else {
+		delete(m.instances, typ)
+	}
+	return stored.value, true
+}
+
+// clear removes every instance while keeping the storage allocated for them.
+func (m *mapStore) clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.instances)
+	m.next = 0
+}
+
+func (m *mapStore) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := 0
+	for _, stored := range m.instances {
+		if stored.ready {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingStore is an InstanceStore that records the keys of the instances it creates.
+type recordingStore struct {
+	InstanceStore
+	mu      sync.Mutex
+	created []reflect.Type
+}
+
+func (s *recordingStore) GetOrCreate(key reflect.Type, factory func(Resolver) (any, error), resolver Resolver) (any, error) {
+	return s.InstanceStore.GetOrCreate(key, func(resolver Resolver) (any, error) {
+		s.mu.Lock()
+		s.created = append(s.created, key)
+		s.mu.Unlock()
+		return factory(resolver)
+	}, resolver)
+}
+
+func TestWithInstanceStore(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) (RootProvider, *[]*recordingStore) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockContextCloser, *mockContextCloser](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		stores := &[]*recordingStore{}
+		provider, err := registry.BuildRootProvider(append(opts, WithInstanceStore(func() InstanceStore {
+			store := &recordingStore{InstanceStore: NewInstanceStore()}
+			*stores = append(*stores, store)
+			return store
+		}))...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider, stores
+	}
+
+	t.Run("caches singletons and scoped values in the stores", func(t *testing.T) {
+		provider, stores := newProvider(t)
+		scope := provider.NewScope()
+		if _, err := Resolve[*mockCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		scoped, err := Resolve[*mockContextCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if len(*stores) != 2 {
+			t.Fatalf("expected a store for the provider and one for the scope; got %d", len(*stores))
+		}
+		singletons, scopedValues := (*stores)[0], (*stores)[1]
+		if !reflect.DeepEqual(singletons.created, []reflect.Type{reflect.TypeFor[*mockCloser]()}) {
+			t.Fatalf("expected the provider's store to create the singleton; got %v", singletons.created)
+		}
+		if !reflect.DeepEqual(scopedValues.created, []reflect.Type{reflect.TypeFor[*mockContextCloser]()}) {
+			t.Fatalf("expected the scope's store to create the scoped value; got %v", scopedValues.created)
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !scoped.closed {
+			t.Fatalf("expected the scoped value in the custom store to be closed")
+		}
+	})
+}
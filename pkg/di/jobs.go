@@ -0,0 +1,218 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrJobPanicked is reported when a job handler run by [RunJobs] panics.
+var ErrJobPanicked = errors.New("job handler panicked")
+
+// A JobPanicked is an [error] indicating that a job handler run by [RunJobs] panicked. Calling
+// [errors.Is] with a JobPanicked and [ErrJobPanicked] returns true.
+type JobPanicked struct {
+
+	// Job is the job the handler was called with.
+	Job any
+
+	// Value is the value the handler panicked with.
+	Value any
+}
+
+// Error implements [error].
+func (err JobPanicked) Error() string {
+	return fmt.Sprintf("job handler panicked: %v", err.Value)
+}
+
+// Is indicates that a [JobPanicked] is [ErrJobPanicked].
+func (JobPanicked) Is(target error) bool {
+	return target == ErrJobPanicked
+}
+
+// Code returns "job_panicked", the code of a [JobPanicked] in [ErrorCodes].
+func (JobPanicked) Code() string {
+	return "job_panicked"
+}
+
+// A JobOption configures optional behavior for [RunJobs].
+type JobOption func(*jobOptions)
+
+type jobOptions struct {
+	closeTimeout time.Duration
+	drainTimeout time.Duration
+	onError      func(job any, err error)
+	onPanic      func(job any, recovered any)
+}
+
+func newJobOptions(opts []JobOption) jobOptions {
+	options := jobOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithJobCloseTimeout sets the deadline for closing the [Scope] created for each job. By default,
+// or if timeout is 0 or less, closing a job's scope has no deadline.
+func WithJobCloseTimeout(timeout time.Duration) JobOption {
+	return func(options *jobOptions) {
+		options.closeTimeout = timeout
+	}
+}
+
+// WithDrainTimeout sets how long the jobs that are in flight when the context given to [RunJobs]
+// is done are given to finish before the context passed to their handlers is cancelled. By
+// default, or if timeout is 0 or less, in-flight jobs are given as long as they need.
+func WithDrainTimeout(timeout time.Duration) JobOption {
+	return func(options *jobOptions) {
+		options.drainTimeout = timeout
+	}
+}
+
+// WithJobErrorHandler sets a callback to be invoked with each job whose handler returned an error
+// or whose [Scope] failed to close. The error is the handler's error joined with the errors from
+// closing the scope using [errors.Join]. The callback may be invoked concurrently. Without a
+// callback the errors from closing the scopes are passed to the provider's
+// [WithCloseErrorHandler].
+func WithJobErrorHandler(callback func(job any, err error)) JobOption {
+	return func(options *jobOptions) {
+		options.onError = callback
+	}
+}
+
+// WithJobPanicHandler sets a callback to be invoked with each job whose handler panicked and the
+// value it panicked with. Without a panic handler a [JobPanicked] is reported to the error handler
+// instead; see [WithJobErrorHandler]. The callback may be invoked concurrently.
+func WithJobPanicHandler(callback func(job any, recovered any)) JobOption {
+	return func(options *jobOptions) {
+		options.onPanic = callback
+	}
+}
+
+// RunJobs receives jobs from the jobs channel and calls handler with each of them and a new [Scope]
+// created from provider for the job, running at most workers handlers at once. Each job's scope
+// is closed once its handler returns, including when the handler panics, in which case the panic
+// is recovered and reported; see [WithJobPanicHandler]. The context passed to handler carries the
+// job's scope; see [FromContext].
+//
+// RunJobs returns nil once the jobs channel is closed and every job has finished. When ctx is done
+// RunJobs stops receiving jobs, waits for the jobs in flight to finish, and returns the error from
+// ctx. The context passed to the handlers is not cancelled with ctx so in-flight jobs can finish;
+// see [WithDrainTimeout].
+func RunJobs[J any](
+	ctx context.Context,
+	provider RootProvider,
+	jobs <-chan J,
+	workers int,
+	handler func(ctx context.Context, scope Scope, job J) error,
+	opts ...JobOption,
+) error {
+	if err := provider.checkInitialized("RunJobs"); err != nil {
+		return err
+	}
+	options := newJobOptions(opts)
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-finished:
+			return
+		}
+		if options.drainTimeout <= 0 {
+			return
+		}
+		timer := time.NewTimer(options.drainTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancelJobs()
+		case <-finished:
+		}
+	}()
+
+	var stopped atomic.Bool
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ctx.Err() != nil {
+					stopped.Store(true)
+					return
+				}
+				select {
+				case <-ctx.Done():
+					stopped.Store(true)
+					return
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+					runJob(jobCtx, provider, job, handler, options)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(finished)
+
+	if stopped.Load() {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// runJob calls handler with job and a new scope, then closes the scope and reports any errors or
+// panics.
+func runJob[J any](
+	ctx context.Context,
+	provider RootProvider,
+	job J,
+	handler func(context.Context, Scope, J) error,
+	options jobOptions,
+) {
+	scope := provider.NewScope()
+	var err error
+	defer func() {
+		recovered := recover()
+		err = errors.Join(err, closeJobScope(ctx, scope, options))
+		if recovered != nil {
+			if options.onPanic != nil {
+				options.onPanic(job, recovered)
+			} else {
+				err = errors.Join(JobPanicked{
+					Job:   job,
+					Value: recovered,
+				}, err)
+			}
+		}
+		if err != nil && options.onError != nil {
+			options.onError(job, err)
+		}
+	}()
+	err = handler(NewContext(ctx, scope), scope, job)
+}
+
+func closeJobScope(ctx context.Context, scope Scope, options jobOptions) error {
+	ctx = context.WithoutCancel(ctx)
+	if options.closeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.closeTimeout)
+		defer cancel()
+	}
+	if options.onError == nil {
+		return scope.CloseJoined(ctx, DelegateErrors())
+	}
+	return scope.CloseJoined(ctx)
+}
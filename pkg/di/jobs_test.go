@@ -0,0 +1,218 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunJobs(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	queue := func(jobs ...int) <-chan int {
+		ch := make(chan int, len(jobs))
+		for _, job := range jobs {
+			ch <- job
+		}
+		close(ch)
+		return ch
+	}
+
+	t.Run("runs each job in its own scope and closes it", func(t *testing.T) {
+		var mu sync.Mutex
+		closers := map[int]*mockCloser{}
+		err := RunJobs(context.Background(), newProvider(t), queue(1, 2, 3), 2,
+			func(ctx context.Context, scope Scope, job int) error {
+				closer, err := ResolveFromContext[*mockCloser](ctx)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				closers[job] = closer
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if len(closers) != 3 {
+			t.Fatalf("expected 3 jobs to run; got %d", len(closers))
+		}
+		if closers[1] == closers[2] || closers[2] == closers[3] || closers[1] == closers[3] {
+			t.Fatalf("expected each job to have its own scope")
+		}
+		for job, closer := range closers {
+			if !closer.closed {
+				t.Fatalf("expected the scope for job %d to be closed", job)
+			}
+		}
+	})
+
+	t.Run("runs at most workers jobs at once", func(t *testing.T) {
+		var running, peak atomic.Int32
+		err := RunJobs(context.Background(), newProvider(t), queue(1, 2, 3, 4, 5, 6), 2,
+			func(ctx context.Context, scope Scope, job int) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					current := peak.Load()
+					if n <= current || peak.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if peak.Load() > 2 {
+			t.Fatalf("expected at most 2 concurrent jobs; got %d", peak.Load())
+		}
+	})
+
+	t.Run("passes errors to the error handler", func(t *testing.T) {
+		expected := errors.New("expected error")
+		var failed []any
+		err := RunJobs(context.Background(), newProvider(t), queue(1, 2), 1,
+			func(ctx context.Context, scope Scope, job int) error {
+				if job == 2 {
+					return expected
+				}
+				return nil
+			},
+			WithJobErrorHandler(func(job any, err error) {
+				if !errors.Is(err, expected) {
+					t.Errorf("expected %v to be %v", err, expected)
+				}
+				failed = append(failed, job)
+			}))
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if len(failed) != 1 || failed[0] != 2 {
+			t.Fatalf("expected job 2 to fail; got %v", failed)
+		}
+	})
+
+	t.Run("recovers panics, closes the scope, and reports them", func(t *testing.T) {
+		var closer *mockCloser
+		var recovered any
+		err := RunJobs(context.Background(), newProvider(t), queue(1), 1,
+			func(ctx context.Context, scope Scope, job int) error {
+				var err error
+				closer, err = Resolve[*mockCloser](scope)
+				if err != nil {
+					return err
+				}
+				panic("boom")
+			},
+			WithJobPanicHandler(func(job any, value any) {
+				recovered = value
+			}))
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if recovered != "boom" {
+			t.Fatalf("expected the panic to be reported; got %v", recovered)
+		}
+		if !closer.closed {
+			t.Fatalf("expected the scope to be closed")
+		}
+	})
+
+	t.Run("reports panics as JobPanicked without a panic handler", func(t *testing.T) {
+		var reported error
+		err := RunJobs(context.Background(), newProvider(t), queue(1), 1,
+			func(ctx context.Context, scope Scope, job int) error {
+				panic("boom")
+			},
+			WithJobErrorHandler(func(job any, err error) {
+				reported = err
+			}))
+		if err != nil {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		var panicked JobPanicked
+		if !errors.As(reported, &panicked) {
+			t.Fatalf("expected %v to be a JobPanicked", reported)
+		}
+		if panicked.Job != 1 || panicked.Value != "boom" {
+			t.Fatalf("expected job 1 to panic with boom; got %+v", panicked)
+		}
+	})
+
+	t.Run("stops receiving jobs when ctx is done and lets in-flight jobs finish", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		jobs := make(chan int, 2)
+		jobs <- 1
+		jobs <- 2
+		var finished []int
+		err := RunJobs(ctx, newProvider(t), jobs, 1,
+			func(jobCtx context.Context, scope Scope, job int) error {
+				cancel()
+				time.Sleep(10 * time.Millisecond)
+				if jobCtx.Err() != nil {
+					t.Errorf("expected the job's context not to be cancelled")
+				}
+				finished = append(finished, job)
+				return nil
+			})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %v to be %v", err, context.Canceled)
+		}
+		if len(finished) != 1 || finished[0] != 1 {
+			t.Fatalf("expected only job 1 to run; got %v", finished)
+		}
+	})
+
+	t.Run("cancels in-flight jobs after the drain timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var jobErr error
+		err := RunJobs(ctx, newProvider(t), queue(1), 1,
+			func(jobCtx context.Context, scope Scope, job int) error {
+				cancel()
+				select {
+				case <-jobCtx.Done():
+					return jobCtx.Err()
+				case <-time.After(time.Second):
+					return nil
+				}
+			},
+			WithDrainTimeout(10*time.Millisecond),
+			WithJobErrorHandler(func(job any, err error) {
+				jobErr = err
+			}))
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error from RunJobs: %v", err)
+		}
+		if !errors.Is(jobErr, context.Canceled) {
+			t.Fatalf("expected %v to be %v", jobErr, context.Canceled)
+		}
+	})
+
+	t.Run("returns UninitializedProvider for an uninitialized provider", func(t *testing.T) {
+		err := RunJobs(context.Background(), RootProvider{}, queue(1), 1,
+			func(context.Context, Scope, int) error {
+				t.Errorf("expected the handler not to be called")
+				return nil
+			})
+		if !errors.Is(err, ErrUninitializedProvider) {
+			t.Fatalf("expected %v to be %v", err, ErrUninitializedProvider)
+		}
+	})
+}
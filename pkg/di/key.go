@@ -0,0 +1,143 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrZeroKey is returned when an attempt is made to register or resolve a [Key] that was not
+// created with [NewKey].
+var ErrZeroKey = errors.New("key was not created with NewKey")
+
+// A Key identifies one of several registrations of values of type T, e.g. a primary and a replica
+// *sql.DB. Keys are declared once, typically as package variables, and compared by identity: two
+// calls to [NewKey] return distinct keys even if they are given the same name, so keys declared in
+// different packages never collide. The zero Key is not usable.
+//
+//	var PrimaryDB = di.NewKey[*sql.DB]("primary")
+type Key[T any] struct {
+	name string
+	typ  reflect.Type
+}
+
+// keyIDs is the source of the identities of the keys created by NewKey.
+var keyIDs atomic.Uint64
+
+// keyTypes holds the types that identify keys, which are not assignable from the values
+// registered under them.
+var keyTypes sync.Map
+
+// NewKey creates a new [Key] for values of type T. The name is used to describe the key in errors
+// and diagnostics but does not identify it.
+func NewKey[T any](name string) Key[T] {
+	id := strconv.FormatUint(keyIDs.Add(1), 10)
+	// Registrations are identified by type so each key is given a type of its own that describes
+	// it in the registrations, the graph, and errors.
+	typ := reflect.StructOf([]reflect.StructField{{
+		Name: "Key",
+		Type: reflect.TypeFor[T](),
+		Tag:  reflect.StructTag(fmt.Sprintf("name:%q id:%q", name, id)),
+	}})
+	keyTypes.Store(typ, true)
+	return Key[T]{
+		name: name,
+		typ:  typ,
+	}
+}
+
+// Name returns the name the key was created with.
+func (key Key[T]) Name() string {
+	return key.name
+}
+
+// String implements [fmt.Stringer].
+func (key Key[T]) String() string {
+	return fmt.Sprintf("%v(%q)", reflect.TypeFor[T](), key.name)
+}
+
+// isKeyType reports whether typ identifies a key rather than being the type of the values
+// registered for it.
+func isKeyType(typ reflect.Type) bool {
+	_, ok := keyTypes.Load(typ)
+	return ok
+}
+
+// RegisterKeyType is [RegisterType] for the registration identified by key. The registration
+// fails with an [InvalidImplementation] if Impl cannot be assigned to T.
+func RegisterKeyType[Impl any, T any](
+	registry Registry,
+	key Key[T],
+	lifetime Lifetime,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	if key.typ == nil {
+		return registry, ErrZeroKey
+	}
+	return registerType[Impl](registry, key.typ, reflect.TypeFor[T](), lifetime, opts)
+}
+
+// RegisterKeyFactory is [RegisterFactory] for the registration identified by key. The
+// registration fails with an [InvalidImplementation] if Impl cannot be assigned to T.
+func RegisterKeyFactory[Impl any, T any](
+	registry Registry,
+	key Key[T],
+	lifetime Lifetime,
+	factory Factory[Impl],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	if key.typ == nil {
+		return registry, ErrZeroKey
+	}
+	return registerFactory(registry, key.typ, reflect.TypeFor[T](), lifetime, factory, opts)
+}
+
+// RegisterKeyInstance is [RegisterInstance] for the registration identified by key. The
+// registration fails with an [InvalidImplementation] if Impl cannot be assigned to T.
+func RegisterKeyInstance[Impl any, T any](
+	registry Registry,
+	key Key[T],
+	instance Impl,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	if key.typ == nil {
+		return registry, ErrZeroKey
+	}
+	return registerInstance(registry, key.typ, reflect.TypeFor[T](), instance, opts)
+}
+
+// ResolveKey obtains the instance registered under key from a [Resolver]. It returns the same
+// errors as [Resolve] and [ErrZeroKey] for a zero key.
+func ResolveKey[T any](resolver Resolver, key Key[T]) (T, error) {
+	var zero T
+	if resolver == nil {
+		return zero, ErrNilResolver
+	}
+	if key.typ == nil {
+		return zero, ErrZeroKey
+	}
+
+	resolved, err := resolver.Resolve(key.typ)
+	if err != nil {
+		return zero, resolverError{wrapped: err}
+	}
+
+	if resolved == nil {
+		return zero, NilResolution{
+			Type: key.typ,
+		}
+	}
+
+	typed, ok := resolved.(T)
+	if !ok {
+		return zero, InvalidResolution{
+			Requested: reflect.TypeFor[T](),
+			Returned:  reflect.TypeOf(resolved),
+		}
+	}
+
+	return typed, nil
+}
@@ -0,0 +1,127 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type keyedDB struct {
+	name   string
+	closed bool
+}
+
+func (db *keyedDB) Close() error {
+	db.closed = true
+	return nil
+}
+
+type keyedStore interface {
+	Get() string
+}
+
+func TestKey(t *testing.T) {
+
+	t.Run("resolves the registration for each key", func(t *testing.T) {
+		primary := NewKey[*keyedDB]("primary")
+		replica := NewKey[*keyedDB]("replica")
+		registry, err := RegisterKeyInstance[*keyedDB](Registry{}, primary, &keyedDB{name: "primary"})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterKeyInstance: %v", err)
+		}
+		registry, err = RegisterKeyFactory(registry, replica, Singleton, func(Resolver) (*keyedDB, error) {
+			return &keyedDB{name: "replica"}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterKeyFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		for _, key := range []Key[*keyedDB]{primary, replica} {
+			db, err := ResolveKey(provider.NewScope(), key)
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveKey: %v", err)
+			}
+			if db.name != key.Name() {
+				t.Errorf("expected the %s database; got %s", key.Name(), db.name)
+			}
+		}
+	})
+
+	t.Run("does not confuse keys with the same name", func(t *testing.T) {
+		first := NewKey[*keyedDB]("db")
+		second := NewKey[*keyedDB]("db")
+		registry, err := RegisterKeyType[*keyedDB](Registry{}, first, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterKeyType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithAutoResolve())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := ResolveKey(provider, first); err != nil {
+			t.Fatalf("unexpected error from ResolveKey: %v", err)
+		}
+		if _, err := ResolveKey(provider, second); !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %q; got %q", ErrUnknownType, err)
+		}
+		provider, err = registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*keyedDB](provider); !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %q; got %q", ErrUnknownType, err)
+		}
+	})
+
+	t.Run("returns InvalidImplementation for an Impl that is not assignable to the key's type", func(t *testing.T) {
+		key := NewKey[keyedStore]("store")
+		_, err := RegisterKeyType[*keyedDB](Registry{}, key, Singleton)
+		var invalid InvalidImplementation
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected %T; got %v", invalid, err)
+		}
+		if invalid.Target != reflect.TypeFor[keyedStore]() || invalid.Type != reflect.TypeFor[*keyedDB]() {
+			t.Errorf("unexpected error %+v", invalid)
+		}
+	})
+
+	t.Run("closes the instances it owns", func(t *testing.T) {
+		key := NewKey[*keyedDB]("owned")
+		registry, err := RegisterKeyType[*keyedDB](Registry{}, key, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterKeyType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		db, err := ResolveKey(provider, key)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveKey: %v", err)
+		}
+		if errs := provider.Close(context.Background()); len(errs) > 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !db.closed {
+			t.Errorf("expected the database to be closed")
+		}
+	})
+
+	t.Run("returns ErrZeroKey for a zero key", func(t *testing.T) {
+		var key Key[*keyedDB]
+		if _, err := RegisterKeyType[*keyedDB](Registry{}, key, Singleton); !errors.Is(err, ErrZeroKey) {
+			t.Errorf("expected %q from RegisterKeyType; got %q", ErrZeroKey, err)
+		}
+		provider, err := Registry{}.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := ResolveKey(provider, key); !errors.Is(err, ErrZeroKey) {
+			t.Errorf("expected %q from ResolveKey; got %q", ErrZeroKey, err)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package di
+
+import (
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+// WithLeakDetection makes the provider report scopes that are garbage collected without having been
+// closed, e.g. when an early return skips the deferred call to [Scope.Close] and the scope's values
+// are never closed. Each leaked scope is reported to the provider's [Observer] as a [ScopeLeaked]
+// and recorded for [RootProvider.LeakedScopes]. The stack of the goroutine that created each scope
+// is captured so the report can say where the scope came from, which makes creating scopes more
+// expensive; without WithLeakDetection scopes are not tracked at all.
+//
+// Leaks are only detected when the garbage collector runs, so they may be reported long after the
+// scope was abandoned, or not at all if the program exits first.
+func WithLeakDetection() ProviderOption {
+	return func(options *providerOptions) {
+		options.leakDetection = true
+	}
+}
+
+// A ScopeLeaked is an [Event] indicating that a [Scope] was garbage collected without having been
+// closed; see [WithLeakDetection].
+type ScopeLeaked struct {
+
+	// ID is the identifier of the leaked scope.
+	ID string
+
+	// Stack is the stack of the goroutine that created the scope, as formatted by [debug.Stack].
+	Stack string
+
+	// Types are the types of the [Scoped] values the scope had created, in the order they were
+	// created. Their values were never closed.
+	Types []reflect.Type
+}
+
+func (ScopeLeaked) event() {}
+
+// LeakedScopes returns the scopes created from the provider that have been garbage collected
+// without having been closed, in the order they were detected. It returns nil unless the provider
+// was built with [WithLeakDetection].
+func (provider RootProvider) LeakedScopes() []ScopeLeaked {
+	if !provider.initialized() {
+		return nil
+	}
+	return provider.leakedScopes.list()
+}
+
+// leakedScopes records the scopes found by leak detection.
+type leakedScopes struct {
+	mu     sync.Mutex
+	scopes []ScopeLeaked
+}
+
+func (l *leakedScopes) add(leaked ScopeLeaked) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.scopes = append(l.scopes, leaked)
+}
+
+func (l *leakedScopes) list() []ScopeLeaked {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.scopes)
+}
+
+// leakTracker is shared by every copy of a scope created with leak detection enabled. Its
+// finalizer runs once every copy is unreachable and reports the scope if it was not closed.
+type leakTracker struct {
+	root  RootProvider
+	id    string
+	stack []byte
+	state *scopeState
+}
+
+// trackLeaks returns a tracker that reports scope if it is garbage collected without being closed,
+// or nil if leak detection is disabled.
+func (provider RootProvider) trackLeaks(scope Scope) *leakTracker {
+	if !provider.options.leakDetection {
+		return nil
+	}
+	tracker := &leakTracker{
+		root:  provider,
+		id:    scope.id,
+		stack: debug.Stack(),
+		state: scope.state,
+	}
+	runtime.SetFinalizer(tracker, (*leakTracker).finalize)
+	return tracker
+}
+
+// untrack stops tracking a scope that has been closed.
+func (t *leakTracker) untrack() {
+	if t != nil {
+		runtime.SetFinalizer(t, nil)
+	}
+}
+
+func (t *leakTracker) finalize() {
+	t.state.closeState.mu.Lock()
+	closed := t.state.closeState.closing
+	t.state.closeState.mu.Unlock()
+	if closed {
+		return
+	}
+	leaked := ScopeLeaked{
+		ID:    t.id,
+		Stack: string(t.stack),
+	}
+	for _, entry := range t.state.scopedValues.entries() {
+		leaked.Types = append(leaked.Types, entry.typ)
+	}
+	t.root.untrack(t.id)
+	t.root.leakedScopes.add(leaked)
+	t.root.observe(leaked)
+}
@@ -0,0 +1,123 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetection(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	// leakScope creates a scope that resolves a value and is never closed, returning its ID.
+	leakScope := func(t *testing.T, provider RootProvider) string {
+		scope := provider.NewScope()
+		if _, err := Resolve[*mockCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		return scope.ID()
+	}
+
+	// collect runs the garbage collector until want scopes have been reported or a deadline passes.
+	collect := func(provider RootProvider, want int) []ScopeLeaked {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			runtime.GC()
+			leaked := provider.LeakedScopes()
+			if len(leaked) >= want || time.Now().After(deadline) {
+				return leaked
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("reports scopes that are collected without being closed", func(t *testing.T) {
+		events := make(chan ScopeLeaked, 1)
+		provider := newProvider(t, WithLeakDetection(), WithObserver(ObserverFunc(func(event Event) {
+			if event, ok := event.(ScopeLeaked); ok {
+				events <- event
+			}
+		})))
+		id := leakScope(t, provider)
+		leaked := collect(provider, 1)
+		if len(leaked) != 1 {
+			t.Fatalf("expected 1 leaked scope; got %v", leaked)
+		}
+		if leaked[0].ID != id {
+			t.Fatalf("expected leaked scope %s; got %s", id, leaked[0].ID)
+		}
+		if !strings.Contains(leaked[0].Stack, "TestLeakDetection") {
+			t.Fatalf("expected the stack to include the test that created the scope; got:\n%s", leaked[0].Stack)
+		}
+		expected := []reflect.Type{reflect.TypeFor[*mockCloser]()}
+		if !reflect.DeepEqual(leaked[0].Types, expected) {
+			t.Fatalf("expected types %v; got %v", expected, leaked[0].Types)
+		}
+		if event := <-events; event.ID != id {
+			t.Fatalf("expected the observer to receive leaked scope %s; got %s", id, event.ID)
+		}
+	})
+
+	t.Run("does not report closed scopes", func(t *testing.T) {
+		provider := newProvider(t, WithLeakDetection())
+		func() {
+			scope := provider.NewScope()
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+		}()
+		id := leakScope(t, provider)
+		leaked := collect(provider, 1)
+		if len(leaked) != 1 || leaked[0].ID != id {
+			t.Fatalf("expected only scope %s to be reported; got %v", id, leaked)
+		}
+	})
+
+	t.Run("reports abandoned scopes created WithIdleTimeout", func(t *testing.T) {
+		provider := newProvider(t, WithLeakDetection())
+		id := func() string {
+			scope := provider.NewScope(WithIdleTimeout(time.Hour))
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			return scope.ID()
+		}()
+		leaked := collect(provider, 1)
+		if len(leaked) != 1 || leaked[0].ID != id {
+			t.Fatalf("expected scope %s to be reported; got %v", id, leaked)
+		}
+		if errs := provider.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+	})
+
+	t.Run("does not track scopes unless enabled", func(t *testing.T) {
+		provider := newProvider(t)
+		scope := provider.NewScope()
+		if scope.leak != nil {
+			t.Fatalf("expected the scope not to be tracked")
+		}
+		leakScope(t, provider)
+		runtime.GC()
+		if leaked := provider.LeakedScopes(); leaked != nil {
+			t.Fatalf("expected no leaked scopes; got %v", leaked)
+		}
+	})
+}
@@ -1,7 +1,8 @@
 package di
 
 // A Lifetime expresses the conditions under which an instance of a type will be instantiated or
-// reused across distinct resolutions.
+// reused across distinct resolutions. Each Lifetime is implemented by a [LifetimeStrategy]; custom
+// lifetimes can be defined with [RegisterLifetime].
 type Lifetime int
 
 const (
@@ -23,17 +24,24 @@ const (
 	//
 	// [Sharable Types]: https://github.com/ttd2089/garlic?tab=readme-ov-file#sharable-types
 	Singleton
-)
 
-var knownLifetimes map[Lifetime]string = map[Lifetime]string{
-	Transient: "Transient",
-	Scoped:    "Scoped",
-	Singleton: "Singleton",
-}
+	// PerResolution means that the type is instantiated once per top-level resolution and the same
+	// instance is shared by every value constructed to satisfy that resolution, including through
+	// nested factory calls. Instances are discarded when the top-level call to Resolve returns and
+	// any that implement [ContextCloser] or [Closer] are closed at that point, so a PerResolution
+	// value must only be used while constructing the values that depend on it.
+	//
+	// NOTE: The [PerResolution] [Lifetime] can only be used for [Sharable Types].
+	//
+	// [Sharable Types]: https://github.com/ttd2089/garlic?tab=readme-ov-file#sharable-types
+	PerResolution
+)
 
+// String returns the name of the lifetime, which is the name given to [RegisterLifetime] for
+// custom lifetimes, or "Unknown" if the lifetime is not defined.
 func (lifetime Lifetime) String() string {
-	if name, ok := knownLifetimes[lifetime]; ok {
-		return name
+	if definition, ok := lookupLifetime(lifetime); ok {
+		return definition.name
 	}
 	return "Unknown"
 }
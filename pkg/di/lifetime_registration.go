@@ -0,0 +1,43 @@
+package di
+
+// RegisterSingleton is a shorthand for calling [RegisterType] with [Singleton].
+func RegisterSingleton[Target any, Impl any](registry Registry, opts ...RegistrationOption) (Registry, error) {
+	return RegisterType[Target, Impl](registry, Singleton, opts...)
+}
+
+// RegisterScoped is a shorthand for calling [RegisterType] with [Scoped].
+func RegisterScoped[Target any, Impl any](registry Registry, opts ...RegistrationOption) (Registry, error) {
+	return RegisterType[Target, Impl](registry, Scoped, opts...)
+}
+
+// RegisterTransient is a shorthand for calling [RegisterType] with [Transient].
+func RegisterTransient[Target any, Impl any](registry Registry, opts ...RegistrationOption) (Registry, error) {
+	return RegisterType[Target, Impl](registry, Transient, opts...)
+}
+
+// RegisterSingletonFactory is a shorthand for calling [RegisterFactory] with [Singleton].
+func RegisterSingletonFactory[Target any, Impl any](
+	registry Registry,
+	factory Factory[Impl],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	return RegisterFactory[Target](registry, Singleton, factory, opts...)
+}
+
+// RegisterScopedFactory is a shorthand for calling [RegisterFactory] with [Scoped].
+func RegisterScopedFactory[Target any, Impl any](
+	registry Registry,
+	factory Factory[Impl],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	return RegisterFactory[Target](registry, Scoped, factory, opts...)
+}
+
+// RegisterTransientFactory is a shorthand for calling [RegisterFactory] with [Transient].
+func RegisterTransientFactory[Target any, Impl any](
+	registry Registry,
+	factory Factory[Impl],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	return RegisterFactory[Target](registry, Transient, factory, opts...)
+}
@@ -0,0 +1,129 @@
+package di
+
+import (
+	"reflect"
+	"testing"
+)
+
+type shorthandService interface {
+	Serve() string
+}
+
+type shorthandImpl struct{}
+
+func (shorthandImpl) Serve() string {
+	return "served"
+}
+
+type shorthandOther struct{}
+
+func TestLifetimeRegistration(t *testing.T) {
+
+	factory := func(Resolver) (*shorthandImpl, error) {
+		return &shorthandImpl{}, nil
+	}
+	unsharableFactory := func(Resolver) (shorthandImpl, error) {
+		return shorthandImpl{}, nil
+	}
+
+	tests := []struct {
+		name      string
+		shorthand func() (Registry, error)
+		longForm  func() (Registry, error)
+	}{
+		{
+			name: "RegisterSingleton",
+			shorthand: func() (Registry, error) {
+				return RegisterSingleton[shorthandService, *shorthandImpl](Registry{}, WithoutOwnership())
+			},
+			longForm: func() (Registry, error) {
+				return RegisterType[shorthandService, *shorthandImpl](Registry{}, Singleton, WithoutOwnership())
+			},
+		},
+		{
+			name: "RegisterSingleton with an unsharable Impl",
+			shorthand: func() (Registry, error) {
+				return RegisterSingleton[shorthandService, shorthandImpl](Registry{})
+			},
+			longForm: func() (Registry, error) {
+				return RegisterType[shorthandService, shorthandImpl](Registry{}, Singleton)
+			},
+		},
+		{
+			name: "RegisterScoped",
+			shorthand: func() (Registry, error) {
+				return RegisterScoped[shorthandService, *shorthandImpl](Registry{}, WithScopeName("request"))
+			},
+			longForm: func() (Registry, error) {
+				return RegisterType[shorthandService, *shorthandImpl](Registry{}, Scoped, WithScopeName("request"))
+			},
+		},
+		{
+			name: "RegisterScoped with an invalid Impl",
+			shorthand: func() (Registry, error) {
+				return RegisterScoped[shorthandService, *shorthandOther](Registry{})
+			},
+			longForm: func() (Registry, error) {
+				return RegisterType[shorthandService, *shorthandOther](Registry{}, Scoped)
+			},
+		},
+		{
+			name: "RegisterTransient",
+			shorthand: func() (Registry, error) {
+				return RegisterTransient[shorthandService, shorthandImpl](Registry{})
+			},
+			longForm: func() (Registry, error) {
+				return RegisterType[shorthandService, shorthandImpl](Registry{}, Transient)
+			},
+		},
+		{
+			name: "RegisterSingletonFactory",
+			shorthand: func() (Registry, error) {
+				return RegisterSingletonFactory[shorthandService](Registry{}, factory)
+			},
+			longForm: func() (Registry, error) {
+				return RegisterFactory[shorthandService](Registry{}, Singleton, factory)
+			},
+		},
+		{
+			name: "RegisterScopedFactory with an unsharable Impl",
+			shorthand: func() (Registry, error) {
+				return RegisterScopedFactory[shorthandService](Registry{}, unsharableFactory)
+			},
+			longForm: func() (Registry, error) {
+				return RegisterFactory[shorthandService](Registry{}, Scoped, unsharableFactory)
+			},
+		},
+		{
+			name: "RegisterScopedFactory with a nil factory",
+			shorthand: func() (Registry, error) {
+				return RegisterScopedFactory[shorthandService, *shorthandImpl](Registry{}, nil)
+			},
+			longForm: func() (Registry, error) {
+				return RegisterFactory[shorthandService, *shorthandImpl](Registry{}, Scoped, nil)
+			},
+		},
+		{
+			name: "RegisterTransientFactory",
+			shorthand: func() (Registry, error) {
+				return RegisterTransientFactory[shorthandService](Registry{}, unsharableFactory)
+			},
+			longForm: func() (Registry, error) {
+				return RegisterFactory[shorthandService](Registry{}, Transient, unsharableFactory)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" matches the long form", func(t *testing.T) {
+			shorthand, shorthandErr := tt.shorthand()
+			longForm, longFormErr := tt.longForm()
+			if !reflect.DeepEqual(shorthandErr, longFormErr) {
+				t.Fatalf("expected error %v; got %v", longFormErr, shorthandErr)
+			}
+			if !reflect.DeepEqual(shorthand.Registrations(), longForm.Registrations()) {
+				t.Errorf("expected registrations %+v; got %+v", longForm.Registrations(), shorthand.Registrations())
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// A LifetimeStrategy implements a [Lifetime] by deciding whether a resolution creates a new
+// instance or reuses an existing one. The built-in lifetimes are implemented as LifetimeStrategy
+// values and custom lifetimes can be added with [RegisterLifetime].
+type LifetimeStrategy interface {
+
+	// Resolve returns an instance for entry, either by calling [Registration.New] with resolver
+	// or by using one of the caches in caches.
+	Resolve(entry Registration, caches CacheSet, resolver Resolver) (any, error)
+
+	// OnScopeClose is called when a [Scope] created by a provider that uses the strategy closes,
+	// after the values it owns have been closed, so the strategy can discard any state it keeps
+	// for the scope.
+	OnScopeClose(scope Scope)
+}
+
+// A Registration describes a registration being resolved to a [LifetimeStrategy].
+type Registration struct {
+
+	// Type is the registered target type.
+	Type reflect.Type
+
+	// Impl is the registered implementation type.
+	Impl reflect.Type
+
+	// Lifetime is the registered lifetime.
+	Lifetime Lifetime
+
+	// ScopeName is the scope name the registration was restricted to using [WithScopeName], if
+	// any.
+	ScopeName string
+
+	registration registration
+}
+
+// New creates a new instance using the registered factory, passing it resolver to resolve the
+// instance's dependencies. Errors from the factory are wrapped in a [ConstructionError].
+func (entry Registration) New(resolver Resolver) (any, error) {
+	return entry.registration.construct(entry.Type, resolver)
+}
+
+func newEntry(typ reflect.Type, registration registration) Registration {
+	return Registration{
+		Type:         typ,
+		Impl:         registration.impl,
+		Lifetime:     registration.lifetime,
+		ScopeName:    registration.scopeName,
+		registration: registration,
+	}
+}
+
+// A CacheSet gives a [LifetimeStrategy] access to the caches available to a resolution: the
+// [Singleton] cache of the [RootProvider], the [Scoped] cache of the [Scope] the value was
+// requested from, if any, and the [PerResolution] cache of the current top-level resolution. Values
+// created by the caches are closed along with the cache's owner.
+type CacheSet struct {
+	root  RootProvider
+	scope *Scope
+}
+
+// InScope reports whether the value was requested from a [Scope] rather than a [RootProvider].
+func (caches CacheSet) InScope() bool {
+	return caches.scope != nil
+}
+
+// ScopeID returns the [Scope.ID] of the scope the value was requested from, or an empty string if
+// it was requested from a [RootProvider].
+func (caches CacheSet) ScopeID() string {
+	if caches.scope == nil {
+		return ""
+	}
+	return caches.scope.id
+}
+
+// Singleton returns the provider's instance for entry, creating it if there isn't one. Singleton
+// instances are created using the [RootProvider] as the resolver so they can never capture a value
+// from a scope.
+func (caches CacheSet) Singleton(entry Registration) (any, error) {
+	provider := caches.root
+	if entry.registration.inherited {
+		return entry.New(provider)
+	}
+	constructing := provider.constructingType(entry.Type)
+	// Convert the copy of the provider to a Resolver once since it may be passed to a factory and
+	// to expire, and each conversion copies it to the heap.
+	var resolver Resolver = constructing
+	if store, ok := provider.options.sharedSingletons[entry.Type]; ok {
+		value, err := store.resolve(entry.Type, entry.registration, resolver)
+		return provider.expire(&store.instances, entry, resolver, value, err)
+	}
+	value, err := provider.singletons.resolve(
+		entry.Type,
+		provider.traced(provider.singletons, entry, rootProvenanceScope, constructing.path),
+		resolver)
+	return provider.expire(provider.singletons, entry, resolver, value, err)
+}
+
+// Scoped returns the scope's instance for entry, creating it if there isn't one. Scoped returns a
+// [ScopedValueRequestedFromRootProvider] if the value was not requested from a [Scope] and a
+// [ScopeNameMismatch] if the registration is restricted to a different scope name.
+func (caches CacheSet) Scoped(entry Registration) (any, error) {
+	scope := caches.scope
+	if scope == nil {
+		return nil, ScopedValueRequestedFromRootProvider{
+			Type: entry.Type,
+		}
+	}
+	if entry.ScopeName != "" && entry.ScopeName != scope.name {
+		return nil, ScopeNameMismatch{
+			Type:     entry.Type,
+			Required: entry.ScopeName,
+			Actual:   scope.name,
+		}
+	}
+	if value, ok := scope.inheritedValue(entry.Type); ok {
+		return value, nil
+	}
+	constructing := scope.constructingType(entry.Type)
+	// Convert the copy of the scope to a Resolver once; see Singleton.
+	var resolver Resolver = constructing
+	value, err := scope.state.scopedValues.resolve(
+		entry.Type,
+		scope.root.traced(&scope.state.scopedValues, entry, scope.id, constructing.path),
+		resolver)
+	return scope.root.expire(&scope.state.scopedValues, entry, resolver, value, err)
+}
+
+// PerResolution returns the current top-level resolution's instance for entry, creating it if
+// there isn't one.
+func (caches CacheSet) PerResolution(entry Registration) (any, error) {
+	if scope := caches.scope; scope != nil {
+		return scope.resolution.resolve(entry.Type, entry.New, scope.constructingType(entry.Type))
+	}
+	provider := caches.root
+	return provider.resolution.resolve(entry.Type, entry.New, provider.constructingType(entry.Type))
+}
+
+type transientStrategy struct{}
+
+func (transientStrategy) Resolve(entry Registration, _ CacheSet, resolver Resolver) (any, error) {
+	return entry.New(resolver)
+}
+
+func (transientStrategy) OnScopeClose(Scope) {}
+
+type scopedStrategy struct{}
+
+func (scopedStrategy) Resolve(entry Registration, caches CacheSet, _ Resolver) (any, error) {
+	return caches.Scoped(entry)
+}
+
+func (scopedStrategy) OnScopeClose(Scope) {}
+
+type singletonStrategy struct{}
+
+func (singletonStrategy) Resolve(entry Registration, caches CacheSet, _ Resolver) (any, error) {
+	return caches.Singleton(entry)
+}
+
+func (singletonStrategy) OnScopeClose(Scope) {}
+
+type perResolutionStrategy struct{}
+
+func (perResolutionStrategy) Resolve(entry Registration, caches CacheSet, _ Resolver) (any, error) {
+	return caches.PerResolution(entry)
+}
+
+func (perResolutionStrategy) OnScopeClose(Scope) {}
+
+// A lifetimeDefinition is the name and strategy of a [Lifetime].
+type lifetimeDefinition struct {
+	name     string
+	strategy LifetimeStrategy
+}
+
+var (
+	// lifetimesMu serializes calls to RegisterLifetime.
+	lifetimesMu sync.Mutex
+
+	// lifetimes holds the defined lifetimes. It is replaced rather than modified so that it can be
+	// read without locking.
+	lifetimes = newLifetimes()
+)
+
+// newLifetimes returns the storage for the defined lifetimes initialized with the built-in ones.
+// It is called from a variable initializer rather than init so that other package variables can
+// be initialized using RegisterLifetime.
+func newLifetimes() *atomic.Pointer[map[Lifetime]lifetimeDefinition] {
+	definitions := &atomic.Pointer[map[Lifetime]lifetimeDefinition]{}
+	definitions.Store(&map[Lifetime]lifetimeDefinition{
+		Transient:     {name: "Transient", strategy: transientStrategy{}},
+		Scoped:        {name: "Scoped", strategy: scopedStrategy{}},
+		Singleton:     {name: "Singleton", strategy: singletonStrategy{}},
+		PerResolution: {name: "PerResolution", strategy: perResolutionStrategy{}},
+	})
+	return definitions
+}
+
+// lookupLifetime returns the definition of lifetime, if it is defined.
+func lookupLifetime(lifetime Lifetime) (lifetimeDefinition, bool) {
+	definition, ok := (*lifetimes.Load())[lifetime]
+	return definition, ok
+}
+
+// RegisterLifetime defines a new [Lifetime] with the given name that is implemented by strategy
+// and returns it. The returned Lifetime can be used with the registration functions like the
+// built-in lifetimes and, since its values may be shared, it can only be used for
+// [Sharable Types]. RegisterLifetime is intended to be called during program initialization, and
+// it panics if strategy is nil or if a lifetime with the same name is already defined.
+//
+// [Sharable Types]: https://github.com/ttd2089/garlic?tab=readme-ov-file#sharable-types
+func RegisterLifetime(name string, strategy LifetimeStrategy) Lifetime {
+	if strategy == nil {
+		panic("di: RegisterLifetime called with a nil strategy")
+	}
+	lifetimesMu.Lock()
+	defer lifetimesMu.Unlock()
+	current := *lifetimes.Load()
+	next := Lifetime(0)
+	for lifetime, definition := range current {
+		if definition.name == name {
+			panic(fmt.Sprintf("di: RegisterLifetime called twice for lifetime %q", name))
+		}
+		next = max(next, lifetime)
+	}
+	next++
+	updated := make(map[Lifetime]lifetimeDefinition, len(current)+1)
+	for lifetime, definition := range current {
+		updated[lifetime] = definition
+	}
+	updated[next] = lifetimeDefinition{
+		name:     name,
+		strategy: strategy,
+	}
+	lifetimes.Store(&updated)
+	return next
+}
@@ -0,0 +1,112 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// perScopeIDStrategy shares an instance between the scopes with the same ID, which is equivalent
+// to Scoped, to show that a strategy can keep its own state for each scope.
+type perScopeIDStrategy struct {
+	mu        sync.Mutex
+	instances map[string]any
+	closed    []string
+}
+
+func (s *perScopeIDStrategy) Resolve(entry Registration, caches CacheSet, resolver Resolver) (any, error) {
+	if !caches.InScope() {
+		return nil, ScopedValueRequestedFromRootProvider{
+			Type: entry.Type,
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if instance, ok := s.instances[caches.ScopeID()]; ok {
+		return instance, nil
+	}
+	instance, err := entry.New(resolver)
+	if err != nil {
+		return nil, err
+	}
+	s.instances[caches.ScopeID()] = instance
+	return instance, nil
+}
+
+func (s *perScopeIDStrategy) OnScopeClose(scope Scope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.instances[scope.ID()]; ok {
+		delete(s.instances, scope.ID())
+		s.closed = append(s.closed, scope.ID())
+	}
+}
+
+var (
+	testStrategy = &perScopeIDStrategy{
+		instances: map[string]any{},
+	}
+	perScopeID = RegisterLifetime("PerScopeID", testStrategy)
+)
+
+func TestLifetimeStrategy(t *testing.T) {
+
+	t.Run("String returns the registered name", func(t *testing.T) {
+		if name := perScopeID.String(); name != "PerScopeID" {
+			t.Fatalf("expected %q; got %q", "PerScopeID", name)
+		}
+	})
+
+	t.Run("RegisterLifetime panics for duplicate names", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected RegisterLifetime to panic")
+			}
+		}()
+		RegisterLifetime("Scoped", transientStrategy{})
+	})
+
+	t.Run("registered lifetimes must be used with sharable types", func(t *testing.T) {
+		_, err := RegisterFactory[struct{}](Registry{}, perScopeID, func(Resolver) (struct{}, error) {
+			return struct{}{}, nil
+		})
+		if !errors.Is(err, ErrUnsharableType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnsharableType)
+		}
+	})
+
+	t.Run("resolves values using the strategy", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, perScopeID)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*mockCloser](provider); !errors.Is(err, ErrScopedValueRequestedFromRootProvider) {
+			t.Fatalf("expected %v to be %v", err, ErrScopedValueRequestedFromRootProvider)
+		}
+		scope := provider.NewScope()
+		a, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		b, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if a != b {
+			t.Fatalf("expected the strategy to share the instance within the scope")
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		testStrategy.mu.Lock()
+		defer testStrategy.mu.Unlock()
+		if len(testStrategy.closed) != 1 || testStrategy.closed[0] != scope.ID() {
+			t.Fatalf("expected OnScopeClose to be called for %s; got %v", scope.ID(), testStrategy.closed)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package di
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// typeAttr returns an attribute holding the name of typ.
+func typeAttr(key string, typ reflect.Type) slog.Attr {
+	return slog.String(key, fmt.Sprint(typ))
+}
+
+// LogValue implements [slog.LogValuer] by logging the unknown type as an attribute.
+func (err UnknownType) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("requested", err.Type))
+}
+
+// LogValue implements [slog.LogValuer] by logging the requested type as an attribute.
+func (err ScopedValueRequestedFromRootProvider) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("requested", err.Type))
+}
+
+// LogValue implements [slog.LogValuer] by logging the requested and returned types as
+// attributes.
+func (err InvalidResolution) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("requested", err.Requested),
+		typeAttr("returned", err.Returned))
+}
+
+// LogValue implements [slog.LogValuer] by logging the requested type as an attribute.
+func (err NilResolution) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("requested", err.Type))
+}
+
+// LogValue implements [slog.LogValuer] by logging the error from the [Resolver] as an attribute.
+func (err resolverError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("cause", err.wrapped))
+}
+
+// LogValue implements [slog.LogValuer] by logging the implementation type as an attribute.
+func (err NonConcreteImplementation) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type))
+}
+
+// LogValue implements [slog.LogValuer] by logging the implementation and target types as
+// attributes.
+func (err InvalidImplementation) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type),
+		typeAttr("target", err.Target))
+}
+
+// LogValue implements [slog.LogValuer] by logging the implementation, target, and suggested
+// types as attributes.
+func (err PointerReceiverImplementation) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type),
+		typeAttr("target", err.Target),
+		typeAttr("suggested", reflect.PointerTo(err.Type)))
+}
+
+// LogValue implements [slog.LogValuer] by logging the implementation, target, and interface
+// types as attributes.
+func (err PointerToInterface) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type),
+		typeAttr("target", err.Target),
+		typeAttr("interface", err.Interface))
+}
+
+// LogValue implements [slog.LogValuer] by logging the type, lifetime, and suggested target as
+// attributes.
+func (err UnsharableType) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type),
+		slog.String("lifetime", err.Lifetime.String()),
+		typeAttr("suggested", err.SuggestedTarget))
+}
+
+// LogValue implements [slog.LogValuer] by logging the implementation type and the element type
+// without a default factory as attributes.
+func (err NoDefaultFactory) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("impl", err.Type),
+		typeAttr("element", err.ElementType))
+}
+
+// LogValue implements [slog.LogValuer] by logging the type and its missing dependency as
+// attributes.
+func (err MissingDependency) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("type", err.Type),
+		typeAttr("dependency", err.Dependency))
+}
+
+// LogValue implements [slog.LogValuer] by logging the registered and implementation types as
+// attributes.
+func (err UndisposedTransient) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("type", err.Type),
+		typeAttr("impl", err.Impl))
+}
+
+// LogValue implements [slog.LogValuer] by logging the types in the cycle as a list attribute.
+func (err DependencyCycle) LogValue() slog.Value {
+	path := make([]string, 0, len(err.Cycle))
+	for _, typ := range err.Cycle {
+		path = append(path, typ.String())
+	}
+	return slog.GroupValue(
+		slog.Any("path", path))
+}
+
+// LogValue implements [slog.LogValuer] by logging the types and their lifetimes as attributes.
+func (err CaptiveDependency) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("type", err.Type),
+		slog.String("lifetime", err.Lifetime.String()),
+		typeAttr("dependency", err.Dependency),
+		slog.String("dependencyLifetime", err.DependencyLifetime.String()))
+}
@@ -0,0 +1,205 @@
+package di
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+
+	// logAttrs logs err through a JSON handler and returns the attributes logged for it.
+	logAttrs := func(t *testing.T, err error) map[string]any {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", err)
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("unexpected error from Unmarshal: %v", err)
+		}
+		attrs, ok := record["err"].(map[string]any)
+		if !ok {
+			t.Fatalf("expected err to be logged as a group; got %#v", record["err"])
+		}
+		return attrs
+	}
+
+	stringType := reflect.TypeFor[string]()
+	intType := reflect.TypeFor[int]()
+	pointerType := reflect.TypeFor[*int]()
+
+	cases := []struct {
+		name     string
+		err      error
+		expected map[string]any
+	}{
+		{
+			name: "UnknownType",
+			err:  UnknownType{Type: stringType},
+			expected: map[string]any{
+				"requested": "string",
+			},
+		},
+		{
+			name: "ScopedValueRequestedFromRootProvider",
+			err:  ScopedValueRequestedFromRootProvider{Type: pointerType},
+			expected: map[string]any{
+				"requested": "*int",
+			},
+		},
+		{
+			name: "InvalidResolution",
+			err:  InvalidResolution{Requested: stringType, Returned: intType},
+			expected: map[string]any{
+				"requested": "string",
+				"returned":  "int",
+			},
+		},
+		{
+			name: "InvalidResolution with nil returned type",
+			err:  InvalidResolution{Requested: stringType},
+			expected: map[string]any{
+				"requested": "string",
+				"returned":  "<nil>",
+			},
+		},
+		{
+			name: "NilResolution",
+			err:  NilResolution{Type: stringType},
+			expected: map[string]any{
+				"requested": "string",
+			},
+		},
+		{
+			name: "resolverError",
+			err:  resolverError{wrapped: UnknownType{Type: stringType}},
+			expected: map[string]any{
+				"cause": map[string]any{
+					"requested": "string",
+				},
+			},
+		},
+		{
+			name: "ResolutionError",
+			err: ResolutionError{
+				Path: []ResolutionStep{{Type: pointerType, Lifetime: Singleton}, {Type: stringType}},
+				Err:  resolverError{wrapped: UnknownType{Type: stringType}},
+			},
+			expected: map[string]any{
+				"path": []any{"*int (Singleton)", "string (unregistered)"},
+				"cause": map[string]any{
+					"requested": "string",
+				},
+			},
+		},
+		{
+			name: "NonConcreteImplementation",
+			err:  NonConcreteImplementation{Type: reflect.TypeFor[error]()},
+			expected: map[string]any{
+				"impl": "error",
+			},
+		},
+		{
+			name: "InvalidImplementation",
+			err:  InvalidImplementation{Type: intType, Target: stringType},
+			expected: map[string]any{
+				"impl":   "int",
+				"target": "string",
+			},
+		},
+		{
+			name: "PointerReceiverImplementation",
+			err:  PointerReceiverImplementation{Type: intType, Target: stringType},
+			expected: map[string]any{
+				"impl":      "int",
+				"target":    "string",
+				"suggested": "*int",
+			},
+		},
+		{
+			name: "PointerToInterface",
+			err:  PointerToInterface{Type: reflect.TypeFor[*error](), Target: stringType, Interface: reflect.TypeFor[error]()},
+			expected: map[string]any{
+				"impl":      "*error",
+				"target":    "string",
+				"interface": "error",
+			},
+		},
+		{
+			name: "UndisposedTransient",
+			err:  UndisposedTransient{Type: stringType, Impl: intType},
+			expected: map[string]any{
+				"type": "string",
+				"impl": "int",
+			},
+		},
+		{
+			name: "UnsharableType",
+			err:  UnsharableType{Type: intType, Lifetime: Singleton, SuggestedTarget: pointerType},
+			expected: map[string]any{
+				"impl":      "int",
+				"lifetime":  "Singleton",
+				"suggested": "*int",
+			},
+		},
+		{
+			name: "NoDefaultFactory",
+			err:  NoDefaultFactory{Type: reflect.TypeFor[*func()](), ElementType: reflect.TypeFor[func()]()},
+			expected: map[string]any{
+				"impl":    "*func()",
+				"element": "func()",
+			},
+		},
+		{
+			name: "MissingDependency",
+			err:  MissingDependency{Type: pointerType, Dependency: stringType},
+			expected: map[string]any{
+				"type":       "*int",
+				"dependency": "string",
+			},
+		},
+		{
+			name: "DependencyCycle",
+			err:  DependencyCycle{Cycle: []reflect.Type{pointerType, stringType, pointerType}},
+			expected: map[string]any{
+				"path": []any{"*int", "string", "*int"},
+			},
+		},
+		{
+			name: "CaptiveDependency",
+			err: CaptiveDependency{
+				Type:               pointerType,
+				Lifetime:           Singleton,
+				Dependency:         stringType,
+				DependencyLifetime: Scoped,
+			},
+			expected: map[string]any{
+				"type":               "*int",
+				"lifetime":           "Singleton",
+				"dependency":         "string",
+				"dependencyLifetime": "Scoped",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := logAttrs(t, c.err); !reflect.DeepEqual(actual, c.expected) {
+				t.Fatalf("expected %v; got %v", c.expected, actual)
+			}
+		})
+	}
+
+	t.Run("errors resolved through Resolve are structured", func(t *testing.T) {
+		_, err := Resolve[string](testResolver{})
+		var wrapped resolverError
+		if !errors.As(err, &wrapped) {
+			t.Fatalf("expected %v to be a resolverError", err)
+		}
+		if _, ok := logAttrs(t, err)["cause"]; !ok {
+			t.Fatalf("expected the cause to be logged")
+		}
+	})
+}
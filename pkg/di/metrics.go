@@ -0,0 +1,121 @@
+package di
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A MetricsSink receives measurements from a [RootProvider] and the scopes created from it, e.g.
+// to export them to a metrics system; see [RootProvider.AddMetricsSink]. Its methods are called
+// synchronously, possibly concurrently, and must return quickly.
+type MetricsSink interface {
+
+	// Resolved is called when a resolution of typ finishes, including the resolutions of
+	// dependencies made by factories, with the error it failed with, if any.
+	Resolved(typ reflect.Type, err error)
+
+	// Constructed is called when the factory for typ returns, with the time it ran for and the
+	// error it returned, if any.
+	Constructed(typ reflect.Type, elapsed time.Duration, err error)
+
+	// ScopeOpened is called when a scope is created.
+	ScopeOpened()
+
+	// Closed is called when a scope, or the provider itself if scope is false, has closed, with
+	// its [CloseReport].
+	Closed(scope bool, report CloseReport)
+}
+
+// AddMetricsSink makes the provider and its scopes report measurements to sink until the returned
+// function is called. Sinks can be added and removed at any time; a provider without sinks does
+// not take measurements.
+func (provider RootProvider) AddMetricsSink(sink MetricsSink) (remove func()) {
+	if !provider.initialized() || sink == nil {
+		return func() {}
+	}
+	return provider.metrics.add(sink)
+}
+
+// metricsSinks holds the sinks added to a provider.
+type metricsSinks struct {
+	mu    sync.Mutex
+	next  uint64
+	sinks atomic.Pointer[[]metricsSinkEntry]
+}
+
+type metricsSinkEntry struct {
+	id   uint64
+	sink MetricsSink
+}
+
+func (m *metricsSinks) add(sink MetricsSink) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.next
+	m.next++
+	sinks := append(slices.Clone(m.load()), metricsSinkEntry{
+		id:   id,
+		sink: sink,
+	})
+	m.sinks.Store(&sinks)
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		sinks := slices.DeleteFunc(slices.Clone(m.load()), func(entry metricsSinkEntry) bool {
+			return entry.id == id
+		})
+		m.sinks.Store(&sinks)
+	}
+}
+
+// load returns the sinks, or nil if there are none.
+func (m *metricsSinks) load() []metricsSinkEntry {
+	if m == nil {
+		return nil
+	}
+	if sinks := m.sinks.Load(); sinks != nil {
+		return *sinks
+	}
+	return nil
+}
+
+func (m *metricsSinks) resolved(typ reflect.Type, err error) {
+	for _, entry := range m.load() {
+		entry.sink.Resolved(typ, err)
+	}
+}
+
+func (m *metricsSinks) scopeOpened() {
+	for _, entry := range m.load() {
+		entry.sink.ScopeOpened()
+	}
+}
+
+func (m *metricsSinks) closed(scope bool, report CloseReport) {
+	for _, entry := range m.load() {
+		entry.sink.Closed(scope, report)
+	}
+}
+
+// measured returns registration with its factory reporting how long it runs to the provider's
+// metrics sinks.
+func (provider RootProvider) measured(typ reflect.Type, registration registration) registration {
+	metrics := provider.metrics
+	if len(metrics.load()) == 0 {
+		return registration
+	}
+	factory := registration.factory
+	registration.factory = func(resolver Resolver) (any, error) {
+		start := time.Now()
+		value, err := factory(resolver)
+		elapsed := time.Since(start)
+		for _, entry := range metrics.load() {
+			entry.sink.Constructed(typ, elapsed, err)
+		}
+		return value, err
+	}
+	return registration
+}
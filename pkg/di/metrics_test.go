@@ -0,0 +1,145 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type meteredService struct{}
+
+type meteredHandler struct {
+	Service *meteredService
+}
+
+type recordingSink struct {
+	mu          sync.Mutex
+	resolved    []reflect.Type
+	failed      []reflect.Type
+	constructed []reflect.Type
+	opened      int
+	closed      []bool
+}
+
+func (sink *recordingSink) Resolved(typ reflect.Type, err error) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if err != nil {
+		sink.failed = append(sink.failed, typ)
+		return
+	}
+	sink.resolved = append(sink.resolved, typ)
+}
+
+func (sink *recordingSink) Constructed(typ reflect.Type, elapsed time.Duration, err error) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.constructed = append(sink.constructed, typ)
+}
+
+func (sink *recordingSink) ScopeOpened() {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.opened++
+}
+
+func (sink *recordingSink) Closed(scope bool, report CloseReport) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.closed = append(sink.closed, scope)
+}
+
+func TestRootProvider_AddMetricsSink(t *testing.T) {
+
+	serviceType := reflect.TypeFor[*meteredService]()
+	handlerType := reflect.TypeFor[*meteredHandler]()
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterType[*meteredService, *meteredService](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*meteredHandler, *meteredHandler](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("reports resolutions and constructions", func(t *testing.T) {
+		provider := newProvider(t)
+		sink := &recordingSink{}
+		provider.AddMetricsSink(sink)
+		scope := provider.NewScope()
+		for range 2 {
+			if _, err := Resolve[*meteredHandler](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		if _, err := Resolve[*meteredHandler](provider); err == nil {
+			t.Fatalf("expected an error resolving a Scoped type from the provider")
+		}
+		expectedResolved := []reflect.Type{serviceType, handlerType, handlerType}
+		if !reflect.DeepEqual(sink.resolved, expectedResolved) {
+			t.Errorf("expected resolutions %v; got %v", expectedResolved, sink.resolved)
+		}
+		if expected := []reflect.Type{handlerType}; !reflect.DeepEqual(sink.failed, expected) {
+			t.Errorf("expected failures %v; got %v", expected, sink.failed)
+		}
+		expectedConstructed := []reflect.Type{serviceType, handlerType}
+		if !reflect.DeepEqual(sink.constructed, expectedConstructed) {
+			t.Errorf("expected constructions %v; got %v", expectedConstructed, sink.constructed)
+		}
+	})
+
+	t.Run("reports scopes and closes once", func(t *testing.T) {
+		provider := newProvider(t)
+		sink := &recordingSink{}
+		provider.AddMetricsSink(sink)
+		scope := provider.NewScope()
+		provider.NewScope()
+		for range 2 {
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+		}
+		if errs := provider.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if sink.opened != 2 {
+			t.Errorf("expected 2 scopes to be opened; got %d", sink.opened)
+		}
+		if expected := []bool{true, false}; !reflect.DeepEqual(sink.closed, expected) {
+			t.Errorf("expected closes %v; got %v", expected, sink.closed)
+		}
+	})
+
+	t.Run("stops reporting once removed", func(t *testing.T) {
+		provider := newProvider(t)
+		sink := &recordingSink{}
+		remove := provider.AddMetricsSink(sink)
+		remove()
+		if _, err := Resolve[*meteredService](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		provider.NewScope()
+		if len(sink.resolved) != 0 || len(sink.constructed) != 0 || sink.opened != 0 {
+			t.Errorf("expected no measurements; got %+v", sink)
+		}
+	})
+
+	t.Run("ignores an uninitialized provider", func(t *testing.T) {
+		remove := RootProvider{}.AddMetricsSink(&recordingSink{})
+		remove()
+		if _, err := (RootProvider{}).Resolve(serviceType); !errors.Is(err, ErrUninitializedProvider) {
+			t.Errorf("expected ErrUninitializedProvider; got %v", err)
+		}
+	})
+}
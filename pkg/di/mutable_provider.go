@@ -0,0 +1,107 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// A MutableProvider is a [RootProvider] whose registrations can be replaced while it is in use,
+// e.g. to roll out an alternative implementation of an interface without restarting. A
+// MutableProvider must be built using [Registry.BuildMutableProvider].
+type MutableProvider struct {
+	RootProvider
+}
+
+// BuildMutableProvider creates a [MutableProvider] that resolves values using the registrations in
+// the registry. Registrations added to the registry afterwards do not affect the provider.
+func (r Registry) BuildMutableProvider(opts ...ProviderOption) (MutableProvider, error) {
+	provider, err := r.BuildRootProvider(opts...)
+	if err != nil {
+		return MutableProvider{}, err
+	}
+	return MutableProvider{
+		RootProvider: provider,
+	}, nil
+}
+
+// A SwapOption configures optional behavior for a single call to [MutableProvider.Swap].
+type SwapOption func(*swapOptions)
+
+type swapOptions struct {
+	closeDelay time.Duration
+}
+
+// WithDisplacedCloseDelay delays closing the [Singleton] instance displaced by
+// [MutableProvider.Swap] to give code that resolved it before the swap time to finish using it.
+func WithDisplacedCloseDelay(delay time.Duration) SwapOption {
+	return func(options *swapOptions) {
+		options.closeDelay = delay
+	}
+}
+
+// Swap replaces the registration for target with the registration for target in registry, which
+// is validated when it is added to registry like any other registration. Resolutions that are
+// already in progress finish using the old registration and later resolutions use the new one.
+// Swap returns an [UnknownType] if registry has no registration for target and a [ProviderFrozen]
+// if the provider has been frozen with [RootProvider.Freeze].
+//
+// If the provider had created a [Singleton] instance from the old registration the instance is
+// displaced so the next resolution creates a new one. A displaced instance the provider owns is
+// closed in the background, after the delay given by [WithDisplacedCloseDelay] if any, and the
+// result is reported to the provider's [Observer] as a [DisplacedSingletonClosed]. Instances that
+// [Scope] values created from the old registration are not affected.
+func (provider MutableProvider) Swap(target reflect.Type, registry Registry, opts ...SwapOption) error {
+	if err := provider.checkInitialized("Swap"); err != nil {
+		return err
+	}
+	if err := provider.checkMutable("Swap"); err != nil {
+		return err
+	}
+	registration_, ok := registry.registrations[target]
+	if !ok {
+		return UnknownType{
+			Type: target,
+		}
+	}
+	options := swapOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	old, replaced := provider.registrations.swap(target, registration_)
+	event := RegistrationSwapped{
+		Target:  target,
+		NewImpl: registration_.impl,
+	}
+	if replaced {
+		event.OldImpl = old.impl
+	}
+	provider.observe(event)
+	if !replaced || old.lifetime != Singleton {
+		return nil
+	}
+	if value, ok := provider.singletonsFor(target).remove(target); ok && old.owned {
+		go provider.closeDisplaced(target, value, options.closeDelay)
+	}
+	return nil
+}
+
+// closeDisplaced closes a displaced Singleton instance and reports the result to the observer.
+func (provider RootProvider) closeDisplaced(target reflect.Type, value any, delay time.Duration) {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	errs := closeValues(
+		context.Background(),
+		[]instanceEntry{{
+			typ:   target,
+			value: value,
+		}},
+		nil,
+		provider.abandoned,
+		newCloseOptions(provider.options, nil))
+	provider.observe(DisplacedSingletonClosed{
+		Target: target,
+		Errors: errs,
+	})
+}
@@ -0,0 +1,103 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMutableProvider(t *testing.T) {
+
+	t.Run("Swap", func(t *testing.T) {
+
+		t.Run("replaces the registration for later resolutions", func(t *testing.T) {
+			registry, err := RegisterType[Closer, *mockCloser](Registry{}, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildMutableProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildMutableProvider: %v", err)
+			}
+			if closer, err := Resolve[Closer](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			} else if _, ok := closer.(*mockCloser); !ok {
+				t.Fatalf("expected Resolve to return %T; got %T", &mockCloser{}, closer)
+			}
+			swap, err := RegisterType[Closer, *errorCloser](Registry{}, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			if err := provider.Swap(reflect.TypeFor[Closer](), swap); err != nil {
+				t.Fatalf("unexpected error from Swap: %v", err)
+			}
+			if closer, err := Resolve[Closer](provider.NewScope()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			} else if _, ok := closer.(*errorCloser); !ok {
+				t.Fatalf("expected Resolve to return %T; got %T", &errorCloser{}, closer)
+			}
+		})
+
+		t.Run("closes the displaced singleton and notifies the observer", func(t *testing.T) {
+			events := make(chan Event, 2)
+			registry, err := RegisterType[Closer, *mockCloser](Registry{}, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildMutableProvider(WithObserver(ObserverFunc(func(event Event) {
+				events <- event
+			})))
+			if err != nil {
+				t.Fatalf("unexpected error from BuildMutableProvider: %v", err)
+			}
+			displaced, err := Resolve[Closer](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			swap, err := RegisterType[Closer, *errorCloser](Registry{}, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			if err := provider.Swap(reflect.TypeFor[Closer](), swap, WithDisplacedCloseDelay(time.Millisecond)); err != nil {
+				t.Fatalf("unexpected error from Swap: %v", err)
+			}
+			expected := RegistrationSwapped{
+				Target:  reflect.TypeFor[Closer](),
+				OldImpl: reflect.TypeFor[*mockCloser](),
+				NewImpl: reflect.TypeFor[*errorCloser](),
+			}
+			if event := <-events; event != expected {
+				t.Fatalf("expected event %v; got %v", expected, event)
+			}
+			select {
+			case event := <-events:
+				closed, ok := event.(DisplacedSingletonClosed)
+				if !ok || closed.Target != expected.Target || len(closed.Errors) != 0 {
+					t.Fatalf("expected %T for %v; got %v", closed, expected.Target, event)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected the displaced singleton to be closed")
+			}
+			if !displaced.(*mockCloser).closed {
+				t.Fatalf("closer was not closed")
+			}
+			if closer, err := Resolve[Closer](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			} else if _, ok := closer.(*errorCloser); !ok {
+				t.Fatalf("expected Resolve to return %T; got %T", &errorCloser{}, closer)
+			}
+		})
+
+		t.Run("returns UnknownType when the registry has no registration for the target", func(t *testing.T) {
+			provider, err := Registry{}.BuildMutableProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildMutableProvider: %v", err)
+			}
+			err = provider.Swap(reflect.TypeFor[Closer](), Registry{})
+			if !errors.Is(err, ErrUnknownType) {
+				t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+			}
+		})
+	})
+}
@@ -0,0 +1,108 @@
+package di
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxNearMisses is the maximum number of near misses an [UnknownTypeNearMisses] lists.
+const maxNearMisses = 5
+
+// An UnknownTypeNearMisses is an [UnknownType] returned by a provider along with the registered
+// types that the caller may have intended to request: the pointer or element counterpart of the
+// requested type, and other types from the same package. Calling [errors.Is] with an
+// UnknownTypeNearMisses and [ErrUnknownType] returns true, and [errors.As] can extract the
+// [UnknownType] from it.
+type UnknownTypeNearMisses struct {
+	UnknownType
+
+	// Near are the registered types that are similar to the requested type, with the pointer or
+	// element counterpart first.
+	Near []reflect.Type
+}
+
+// Error implements [error].
+func (err UnknownTypeNearMisses) Error() string {
+	near := make([]string, 0, len(err.Near))
+	for _, typ := range err.Near {
+		near = append(near, typ.String())
+	}
+	return fmt.Sprintf("%v; similar registered types: %s", err.UnknownType, strings.Join(near, ", "))
+}
+
+// Unwrap gets the [UnknownType].
+func (err UnknownTypeNearMisses) Unwrap() error {
+	return err.UnknownType
+}
+
+// Code returns "unknown_type", the code of the [UnknownType] the near misses were found for.
+func (UnknownTypeNearMisses) Code() string {
+	return "unknown_type"
+}
+
+// LogValue implements [slog.LogValuer] by logging the requested type and the near misses as
+// attributes.
+func (err UnknownTypeNearMisses) LogValue() slog.Value {
+	near := make([]string, 0, len(err.Near))
+	for _, typ := range err.Near {
+		near = append(near, typ.String())
+	}
+	return slog.GroupValue(
+		typeAttr("requested", err.Type),
+		slog.Any("near", near))
+}
+
+// unknownType returns the error for a request for typ, which has no registration in the table. The
+// error is an [UnknownTypeNearMisses] if the table has registrations that are similar to typ and an
+// [UnknownType] otherwise.
+func (t *registrationTable) unknownType(typ reflect.Type) error {
+	err := UnknownType{
+		Type: typ,
+	}
+	near := nearMisses(t.load(), typ)
+	if len(near) == 0 {
+		return err
+	}
+	return UnknownTypeNearMisses{
+		UnknownType: err,
+		Near:        near,
+	}
+}
+
+// nearMisses returns up to maxNearMisses registered types similar to typ.
+func nearMisses(registrations map[reflect.Type]registration, typ reflect.Type) []reflect.Type {
+	near := []reflect.Type{}
+	counterpart := reflect.PointerTo(typ)
+	if typ.Kind() == reflect.Pointer {
+		counterpart = typ.Elem()
+	}
+	if _, ok := registrations[counterpart]; ok {
+		near = append(near, counterpart)
+	}
+	pkgPath := packagePath(typ)
+	if pkgPath == "" {
+		return near
+	}
+	samePackage := []reflect.Type{}
+	for registered := range registrations {
+		if registered != counterpart && packagePath(registered) == pkgPath {
+			samePackage = append(samePackage, registered)
+		}
+	}
+	slices.SortFunc(samePackage, func(a, b reflect.Type) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	near = append(near, samePackage...)
+	return near[:min(len(near), maxNearMisses)]
+}
+
+// packagePath returns the path of the package that defines typ, or the type it points to.
+func packagePath(typ reflect.Type) string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.PkgPath()
+}
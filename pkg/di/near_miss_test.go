@@ -0,0 +1,118 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type nearMissA struct{}
+
+type nearMissB struct{}
+
+type nearMissC struct{}
+
+type nearMissD struct{}
+
+type nearMissE struct{}
+
+func TestNearMisses(t *testing.T) {
+
+	t.Run("lists the pointer counterpart first and then types from the same package", func(t *testing.T) {
+		registry, err := RegisterType[*nearMissA, *nearMissA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*nearMissC, *nearMissC](registry, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterInstance[*strings.Builder](registry, &strings.Builder{})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		for name, resolver := range map[string]Resolver{"RootProvider": provider, "Scope": provider.NewScope()} {
+			t.Run(name, func(t *testing.T) {
+				_, err := resolver.Resolve(reflect.TypeFor[nearMissC]())
+				var nearMisses UnknownTypeNearMisses
+				if !errors.As(err, &nearMisses) {
+					t.Fatalf("expected %v to be an UnknownTypeNearMisses", err)
+				}
+				expected := []reflect.Type{reflect.TypeFor[*nearMissC](), reflect.TypeFor[*nearMissA]()}
+				if !reflect.DeepEqual(nearMisses.Near, expected) {
+					t.Fatalf("expected %v; got %v", expected, nearMisses.Near)
+				}
+				if !strings.Contains(err.Error(), "similar registered types: *di.nearMissC, *di.nearMissA") {
+					t.Fatalf("expected the message to list the near misses; got %q", err)
+				}
+			})
+		}
+	})
+
+	t.Run("still works as an UnknownType", func(t *testing.T) {
+		registry, err := RegisterType[*nearMissA, *nearMissA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		requested := reflect.TypeFor[*nearMissB]()
+		_, err = provider.Resolve(requested)
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+		var unknownType UnknownType
+		if !errors.As(err, &unknownType) {
+			t.Fatalf("expected %v to be an UnknownType", err)
+		}
+		if unknownType.Type != requested {
+			t.Fatalf("expected the type to be %v; got %v", requested, unknownType.Type)
+		}
+	})
+
+	t.Run("returns an UnknownType when there are no near misses", func(t *testing.T) {
+		registry, err := RegisterType[*nearMissA, *nearMissA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, err = provider.Resolve(reflect.TypeFor[*strings.Builder]())
+		if _, ok := err.(UnknownType); !ok {
+			t.Fatalf("expected an UnknownType; got %T", err)
+		}
+	})
+
+	t.Run("lists at most 5 near misses", func(t *testing.T) {
+		registrations := map[reflect.Type]registration{}
+		for i := range 8 {
+			registrations[reflect.ArrayOf(i+1, reflect.TypeFor[nearMissA]())] = registration{}
+		}
+		registrations[reflect.TypeFor[nearMissB]()] = registration{}
+		registrations[reflect.TypeFor[*nearMissC]()] = registration{}
+		registrations[reflect.TypeFor[nearMissD]()] = registration{}
+		registrations[reflect.TypeFor[*nearMissE]()] = registration{}
+		registrations[reflect.TypeFor[*nearMissA]()] = registration{}
+		near := nearMisses(registrations, reflect.TypeFor[nearMissA]())
+		if len(near) != maxNearMisses {
+			t.Fatalf("expected %d near misses; got %v", maxNearMisses, near)
+		}
+		if near[0] != reflect.TypeFor[*nearMissA]() {
+			t.Fatalf("expected the counterpart first; got %v", near)
+		}
+		for _, typ := range near {
+			if typ.Kind() == reflect.Array {
+				t.Fatalf("expected types from other packages to be excluded; got %v", near)
+			}
+		}
+	})
+}
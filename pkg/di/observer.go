@@ -0,0 +1,68 @@
+package di
+
+import "reflect"
+
+// An Observer receives [Event] values describing what a provider is doing, e.g. to log or record
+// metrics about it. Observers are given to a provider using [WithObserver] and may be called
+// concurrently.
+type Observer interface {
+	Observe(Event)
+}
+
+// An ObserverFunc is a function that implements [Observer].
+type ObserverFunc func(Event)
+
+// Observe implements [Observer] by calling the function.
+func (f ObserverFunc) Observe(event Event) {
+	f(event)
+}
+
+// An Event describes something a provider did. The concrete types of events are defined by this
+// package.
+type Event interface {
+	event()
+}
+
+// A RegistrationSwapped is an [Event] indicating that the registration for a type was replaced
+// using [MutableProvider.Swap].
+type RegistrationSwapped struct {
+
+	// Target is the type whose registration was replaced.
+	Target reflect.Type
+
+	// OldImpl is the implementation type of the replaced registration, or nil if the type was not
+	// registered.
+	OldImpl reflect.Type
+
+	// NewImpl is the implementation type of the new registration.
+	NewImpl reflect.Type
+}
+
+func (RegistrationSwapped) event() {}
+
+// A DisplacedSingletonClosed is an [Event] indicating that a [Singleton] instance displaced by
+// [MutableProvider.Swap], [RootProvider.SetSingleton], or [RootProvider.Refresh], or a Singleton
+// or [Scoped] instance that expired because of [WithTTL], was closed.
+type DisplacedSingletonClosed struct {
+
+	// Target is the type whose registration was replaced.
+	Target reflect.Type
+
+	// Errors are the errors from closing the instance, if any.
+	Errors []error
+}
+
+func (DisplacedSingletonClosed) event() {}
+
+// A PerResolutionCloseFailed is an [Event] indicating that closing the [PerResolution] values
+// created for a resolution failed.
+type PerResolutionCloseFailed struct {
+
+	// Type is the type whose resolution created the values.
+	Type reflect.Type
+
+	// Errors are the errors from closing the values.
+	Errors []error
+}
+
+func (PerResolutionCloseFailed) event() {}
@@ -0,0 +1,143 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrOnClosePanic is returned when a callback registered with [Scope.OnClose] or
+// [RootProvider.OnClose] panics.
+var ErrOnClosePanic = errors.New("OnClose callback panicked")
+
+// An OnClosePanic is an [error] indicating that a callback registered with [Scope.OnClose] or
+// [RootProvider.OnClose] panicked. Calling [errors.Is] with an OnClosePanic and [ErrOnClosePanic]
+// returns true.
+type OnClosePanic struct {
+
+	// Value is the value the callback panicked with.
+	Value any
+}
+
+// Error implements [error].
+func (err OnClosePanic) Error() string {
+	return fmt.Sprintf("OnClose callback panicked: %v", err.Value)
+}
+
+// Is indicates that an [OnClosePanic] is [ErrOnClosePanic].
+func (OnClosePanic) Is(target error) bool {
+	return target == ErrOnClosePanic
+}
+
+// Code returns "on_close_panic", the code of an [OnClosePanic] in [ErrorCodes].
+func (OnClosePanic) Code() string {
+	return "on_close_panic"
+}
+
+// closeState tracks whether a scope or provider has been closed and the callbacks to invoke when
+// it is.
+type closeState struct {
+	mu sync.Mutex
+
+	closing   bool
+	closed    bool
+	done      chan struct{}
+	errs      []error
+	callbacks []func([]error)
+}
+
+// begin marks the owner as closing and reports whether the caller is responsible for closing it.
+func (s *closeState) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.beginLocked()
+}
+
+func (s *closeState) beginLocked() bool {
+	if s.closing {
+		return false
+	}
+	s.closing = true
+	if s.done != nil {
+		close(s.done)
+	}
+	return true
+}
+
+// unlessClosing calls fn while holding the lock unless the owner has begun closing, and reports
+// whether it called fn.
+func (s *closeState) unlessClosing(fn func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	fn()
+	return true
+}
+
+// isClosing reports whether the owner has begun closing.
+func (s *closeState) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// closingDone returns a channel that is closed once the owner begins closing.
+func (s *closeState) closingDone() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+		if s.closing {
+			close(s.done)
+		}
+	}
+	return s.done
+}
+
+// finish marks the owner as closed and invokes the callbacks registered with onClose. The errors
+// are returned with an additional [OnClosePanic] for each callback that panicked.
+func (s *closeState) finish(errs []error) []error {
+	s.mu.Lock()
+	callbacks := s.callbacks
+	s.callbacks = nil
+	s.closed = true
+	s.errs = errs
+	s.mu.Unlock()
+	for _, callback := range callbacks {
+		if err := invokeOnClose(callback, errs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// onClose registers a callback to be invoked by finish, or invokes it immediately if finish has
+// already been called.
+func (s *closeState) onClose(callback func([]error)) {
+	if callback == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.callbacks = append(s.callbacks, callback)
+		s.mu.Unlock()
+		return
+	}
+	errs := s.errs
+	s.mu.Unlock()
+	_ = invokeOnClose(callback, errs)
+}
+
+func invokeOnClose(callback func([]error), errs []error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = OnClosePanic{
+				Value: v,
+			}
+		}
+	}()
+	callback(errs)
+	return nil
+}
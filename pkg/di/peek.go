@@ -0,0 +1,70 @@
+package di
+
+import "reflect"
+
+// Peek returns the instance of T that resolver has already constructed, if any, without
+// constructing one; see [Scope.Peek]. It reports false if resolver is not a [Scope], a
+// [RootProvider], or another type with a Peek method like theirs.
+func Peek[T any](resolver Resolver) (T, bool) {
+	var zero T
+	peeker, ok := resolver.(interface {
+		Peek(reflect.Type) (any, bool)
+	})
+	if !ok {
+		return zero, false
+	}
+	value, ok := peeker.Peek(reflect.TypeFor[T]())
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// Peek returns the [Singleton] instance of typ if the provider, or for an inherited registration
+// its parent, has already constructed it, e.g. to check the health of a database connection pool
+// without dialing the database. Peek never calls a factory. An instance being constructed
+// concurrently is not returned until its construction has finished. Peek reports false for
+// [Transient], [Scoped], and custom lifetimes.
+func (provider RootProvider) Peek(typ reflect.Type) (any, bool) {
+	if !provider.initialized() {
+		return nil, false
+	}
+	registration, ok := provider.registrations.get(typ)
+	if !ok || registration.lifetime != Singleton {
+		return nil, false
+	}
+	if registration.inherited {
+		return provider.parent.Peek(typ)
+	}
+	return provider.singletonsFor(typ).get(typ)
+}
+
+// Peek returns the instance of typ the scope would resolve if it has already been constructed: an
+// instance given to [Scope.WithInstance], a [Scoped] instance cached by the scope or one of the
+// ancestors it inherits from, or a [Singleton] instance; see [RootProvider.Peek]. Peek never
+// calls a factory and reports false for a scope that has been closed.
+func (scope Scope) Peek(typ reflect.Type) (any, bool) {
+	if !scope.initialized() || scope.acquireState() != nil {
+		return nil, false
+	}
+	defer scope.releaseState()
+	if scope.state.closeState.isClosing() {
+		return nil, false
+	}
+	if scope.constructing == nil {
+		scope.state.resetting.RLock()
+		defer scope.state.resetting.RUnlock()
+	}
+	if instance, ok := scope.state.overrides.peek(typ); ok {
+		return instance, true
+	}
+	registration, ok := scope.root.registrations.get(typ)
+	if !ok || registration.lifetime != Scoped {
+		return scope.root.Peek(typ)
+	}
+	if value, ok := scope.state.scopedValues.get(typ); ok {
+		return value, true
+	}
+	return scope.inheritedValue(typ)
+}
@@ -0,0 +1,143 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type peekedPool struct{}
+
+type peekedSession struct {
+	Pool *peekedPool
+}
+
+func TestPeek(t *testing.T) {
+
+	newProvider := func(t *testing.T, poolFactory Factory[*peekedPool]) RootProvider {
+		registry, err := RegisterFactory[*peekedPool](Registry{}, Singleton, poolFactory)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterType[*peekedSession, *peekedSession](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("returns singletons only once they have been constructed", func(t *testing.T) {
+		var calls atomic.Int32
+		provider := newProvider(t, func(Resolver) (*peekedPool, error) {
+			calls.Add(1)
+			return &peekedPool{}, nil
+		})
+		if _, ok := Peek[*peekedPool](provider); ok {
+			t.Fatalf("expected Peek to report false before construction")
+		}
+		if calls.Load() != 0 {
+			t.Fatalf("expected Peek not to call the factory")
+		}
+		pool, err := Resolve[*peekedPool](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if peeked, ok := Peek[*peekedPool](provider.NewScope()); !ok || peeked != pool {
+			t.Errorf("expected Peek to return the constructed pool; got %v, %v", peeked, ok)
+		}
+	})
+
+	t.Run("returns scoped values only from the scope that constructed them", func(t *testing.T) {
+		provider := newProvider(t, func(Resolver) (*peekedPool, error) {
+			return &peekedPool{}, nil
+		})
+		scope := provider.NewScope()
+		if _, ok := Peek[*peekedSession](scope); ok {
+			t.Fatalf("expected Peek to report false before construction")
+		}
+		session, err := Resolve[*peekedSession](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if peeked, ok := Peek[*peekedSession](scope); !ok || peeked != session {
+			t.Errorf("expected Peek to return the session; got %v, %v", peeked, ok)
+		}
+		if _, ok := Peek[*peekedSession](provider.NewScope()); ok {
+			t.Errorf("expected Peek to report false for another scope")
+		}
+		if _, ok := Peek[*peekedSession](provider); ok {
+			t.Errorf("expected Peek to report false for the provider")
+		}
+	})
+
+	t.Run("reports false for closed scopes", func(t *testing.T) {
+		provider := newProvider(t, func(Resolver) (*peekedPool, error) {
+			return &peekedPool{}, nil
+		})
+		scope := provider.NewScope()
+		if _, err := Resolve[*peekedSession](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if _, ok := Peek[*peekedSession](scope); ok {
+			t.Errorf("expected Peek to report false for a closed scope")
+		}
+	})
+
+	t.Run("returns overrides without marking them resolved", func(t *testing.T) {
+		provider := newProvider(t, func(Resolver) (*peekedPool, error) {
+			return &peekedPool{}, nil
+		})
+		scope := provider.NewScope()
+		session := &peekedSession{}
+		if err := scope.WithInstance(reflect.TypeFor[*peekedSession](), session); err != nil {
+			t.Fatalf("unexpected error from WithInstance: %v", err)
+		}
+		if peeked, ok := Peek[*peekedSession](scope); !ok || peeked != session {
+			t.Errorf("expected Peek to return the override; got %v, %v", peeked, ok)
+		}
+		if err := scope.WithInstance(reflect.TypeFor[*peekedSession](), &peekedSession{}); err != nil {
+			t.Errorf("expected the override to be replaceable after Peek; got %v", err)
+		}
+	})
+
+	t.Run("reports false while a construction is in progress", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		provider := newProvider(t, func(Resolver) (*peekedPool, error) {
+			close(started)
+			<-release
+			return &peekedPool{}, nil
+		})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = Resolve[*peekedPool](provider)
+		}()
+		<-started
+		if _, ok := Peek[*peekedPool](provider); ok {
+			t.Errorf("expected Peek to report false during construction")
+		}
+		close(release)
+		<-done
+		if _, ok := Peek[*peekedPool](provider); !ok {
+			t.Errorf("expected Peek to report true after construction")
+		}
+	})
+
+	t.Run("reports false for resolvers that cannot peek", func(t *testing.T) {
+		resolver := ResolverFunc(func(reflect.Type) (any, error) {
+			return &peekedPool{}, nil
+		})
+		if _, ok := Peek[*peekedPool](resolver); ok {
+			t.Errorf("expected Peek to report false")
+		}
+	})
+}
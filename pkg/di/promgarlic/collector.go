@@ -0,0 +1,179 @@
+package promgarlic
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// A ProviderCollector is a [prometheus.Collector] for the measurements of a [di.RootProvider];
+// see [Collector].
+type ProviderCollector struct {
+	provider di.RootProvider
+	options  options
+	remove   func()
+
+	// labels caches the type label of each type.
+	labels sync.Map
+
+	// opened and closed count the scopes opened and closed since the collector was created.
+	opened atomic.Int64
+	closed atomic.Int64
+
+	resolutions   *prometheus.CounterVec
+	constructions *prometheus.HistogramVec
+	openScopes    *prometheus.Desc
+	closes        *prometheus.HistogramVec
+	abandoned     *prometheus.CounterVec
+}
+
+// Collector returns a [prometheus.Collector] that measures provider and the scopes created from
+// it using [di.RootProvider.AddMetricsSink]. It exports the following metrics:
+//
+//   - garlic_resolutions_total: the resolutions, labelled by type and by outcome, which is
+//     "success" or "error".
+//   - garlic_construction_duration_seconds: a histogram of how long factories run, labelled by
+//     type.
+//   - garlic_open_scopes: the number of scopes that have not been closed. It is exact when the
+//     provider was built with [di.WithScopeTracking]; otherwise it counts the scopes opened since
+//     Collector was called.
+//   - garlic_close_duration_seconds: a histogram of how long closing took, labelled by kind,
+//     which is "scope" or "provider".
+//   - garlic_abandoned_closers_total: the closers and cleanups that Close gave up on, labelled by
+//     type.
+//
+// Types are labelled with their names as formatted by [reflect.Type.String] unless
+// [WithAllowedTypes] or [WithDeniedTypes] exclude them. Collector panics if a pattern given to
+// them is malformed. The collector measures the provider until [ProviderCollector.Detach] is
+// called.
+func Collector(provider di.RootProvider, opts ...Option) *ProviderCollector {
+	options := options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	for _, pattern := range append(append([]string{}, options.allowed...), options.denied...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic(fmt.Sprintf("promgarlic: Collector given malformed type pattern %q: %v", pattern, err))
+		}
+	}
+	collector := &ProviderCollector{
+		provider: provider,
+		options:  options,
+		resolutions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "garlic_resolutions_total",
+			Help: "The number of resolutions by type and outcome.",
+		}, []string{"type", "outcome"}),
+		constructions: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "garlic_construction_duration_seconds",
+			Help:    "How long factories ran by type.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type"}),
+		openScopes: prometheus.NewDesc(
+			"garlic_open_scopes",
+			"The number of scopes that have not been closed.",
+			nil,
+			nil),
+		closes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "garlic_close_duration_seconds",
+			Help:    "How long closing scopes and the provider took.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"kind"}),
+		abandoned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "garlic_abandoned_closers_total",
+			Help: "The number of closers and cleanups that Close gave up on by type.",
+		}, []string{"type"}),
+	}
+	collector.remove = provider.AddMetricsSink(sink{collector})
+	return collector
+}
+
+// Detach stops the collector from measuring the provider. The metrics collected so far are still
+// exported.
+func (collector *ProviderCollector) Detach() {
+	collector.remove()
+}
+
+// Describe implements [prometheus.Collector].
+func (collector *ProviderCollector) Describe(descs chan<- *prometheus.Desc) {
+	collector.resolutions.Describe(descs)
+	collector.constructions.Describe(descs)
+	descs <- collector.openScopes
+	collector.closes.Describe(descs)
+	collector.abandoned.Describe(descs)
+}
+
+// Collect implements [prometheus.Collector].
+func (collector *ProviderCollector) Collect(metrics chan<- prometheus.Metric) {
+	collector.resolutions.Collect(metrics)
+	collector.constructions.Collect(metrics)
+	metrics <- prometheus.MustNewConstMetric(
+		collector.openScopes,
+		prometheus.GaugeValue,
+		float64(collector.countOpenScopes()))
+	collector.closes.Collect(metrics)
+	collector.abandoned.Collect(metrics)
+}
+
+// countOpenScopes returns the number of open scopes.
+func (collector *ProviderCollector) countOpenScopes() int64 {
+	if scopes, ok := collector.provider.OpenScopes(); ok {
+		return int64(len(scopes))
+	}
+	// Scopes opened before the collector was created may be closed after it, so the difference
+	// can be negative.
+	return max(collector.opened.Load()-collector.closed.Load(), 0)
+}
+
+// label returns the type label for typ.
+func (collector *ProviderCollector) label(typ reflect.Type) string {
+	if typ == nil {
+		return OtherType
+	}
+	if label, ok := collector.labels.Load(typ); ok {
+		return label.(string)
+	}
+	label, _ := collector.labels.LoadOrStore(typ, collector.options.label(typ))
+	return label.(string)
+}
+
+// sink receives the measurements for a ProviderCollector without exporting the methods of
+// di.MetricsSink from it.
+type sink struct {
+	collector *ProviderCollector
+}
+
+func (sink sink) Resolved(typ reflect.Type, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	sink.collector.resolutions.WithLabelValues(sink.collector.label(typ), outcome).Inc()
+}
+
+func (sink sink) Constructed(typ reflect.Type, elapsed time.Duration, err error) {
+	sink.collector.constructions.WithLabelValues(sink.collector.label(typ)).Observe(elapsed.Seconds())
+}
+
+func (sink sink) ScopeOpened() {
+	sink.collector.opened.Add(1)
+}
+
+func (sink sink) Closed(scope bool, report di.CloseReport) {
+	kind := "provider"
+	if scope {
+		kind = "scope"
+		sink.collector.closed.Add(1)
+	}
+	sink.collector.closes.WithLabelValues(kind).Observe(report.Elapsed.Seconds())
+	for _, entry := range report.Entries {
+		if entry.Outcome == di.AbandonedAtDeadline {
+			sink.collector.abandoned.WithLabelValues(sink.collector.label(entry.Type)).Inc()
+		}
+	}
+}
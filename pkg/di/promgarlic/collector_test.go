@@ -0,0 +1,149 @@
+package promgarlic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type clock struct{}
+
+type session struct {
+	Clock *clock
+}
+
+type secret struct{}
+
+func TestCollector(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...di.ProviderOption) di.RootProvider {
+		registry, err := di.RegisterType[*clock, *clock](di.Registry{}, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*session, *session](registry, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*secret, *secret](registry, di.Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("counts resolutions by type and outcome", func(t *testing.T) {
+		provider := newProvider(t)
+		collector := Collector(provider, WithDeniedTypes("*promgarlic.secret"))
+		scope := provider.NewScope()
+		if _, err := di.Resolve[*session](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := di.Resolve[*session](provider); err == nil {
+			t.Fatalf("expected an error resolving a Scoped type from the provider")
+		}
+		if _, err := di.Resolve[*secret](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		expected := `
+# HELP garlic_resolutions_total The number of resolutions by type and outcome.
+# TYPE garlic_resolutions_total counter
+garlic_resolutions_total{outcome="error",type="*promgarlic.session"} 1
+garlic_resolutions_total{outcome="success",type="*promgarlic.clock"} 1
+garlic_resolutions_total{outcome="success",type="*promgarlic.session"} 1
+garlic_resolutions_total{outcome="success",type="other"} 1
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "garlic_resolutions_total"); err != nil {
+			t.Error(err)
+		}
+		if n := testutil.CollectAndCount(collector, "garlic_construction_duration_seconds"); n != 3 {
+			t.Errorf("expected construction durations for 3 types; got %d", n)
+		}
+	})
+
+	t.Run("labels unallowed types as other", func(t *testing.T) {
+		provider := newProvider(t)
+		collector := Collector(provider, WithAllowedTypes("*promgarlic.clock"))
+		if _, err := di.Resolve[*clock](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := di.Resolve[*secret](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		expected := `
+# HELP garlic_resolutions_total The number of resolutions by type and outcome.
+# TYPE garlic_resolutions_total counter
+garlic_resolutions_total{outcome="success",type="*promgarlic.clock"} 1
+garlic_resolutions_total{outcome="success",type="other"} 1
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "garlic_resolutions_total"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("reports open scopes and closes", func(t *testing.T) {
+		for _, tracking := range []bool{false, true} {
+			opts := []di.ProviderOption{}
+			if tracking {
+				opts = append(opts, di.WithScopeTracking())
+			}
+			provider := newProvider(t, opts...)
+			collector := Collector(provider)
+			scope := provider.NewScope()
+			provider.NewScope()
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			expected := `
+# HELP garlic_open_scopes The number of scopes that have not been closed.
+# TYPE garlic_open_scopes gauge
+garlic_open_scopes 1
+`
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "garlic_open_scopes"); err != nil {
+				t.Errorf("with tracking %v: %v", tracking, err)
+			}
+			if n := testutil.CollectAndCount(collector, "garlic_close_duration_seconds"); n != 1 {
+				t.Errorf("expected close durations for 1 kind; got %d", n)
+			}
+		}
+	})
+
+	t.Run("stops measuring once detached", func(t *testing.T) {
+		provider := newProvider(t)
+		collector := Collector(provider)
+		collector.Detach()
+		if _, err := di.Resolve[*clock](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if n := testutil.CollectAndCount(collector, "garlic_resolutions_total"); n != 0 {
+			t.Errorf("expected no resolutions; got %d", n)
+		}
+	})
+
+	t.Run("registers with a pedantic registry", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
+		if err := registry.Register(Collector(newProvider(t))); err != nil {
+			t.Fatalf("unexpected error from Register: %v", err)
+		}
+		if _, err := registry.Gather(); err != nil {
+			t.Fatalf("unexpected error from Gather: %v", err)
+		}
+	})
+
+	t.Run("panics on a malformed pattern", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected Collector to panic")
+			}
+		}()
+		Collector(newProvider(t), WithAllowedTypes("["))
+	})
+}
@@ -0,0 +1,60 @@
+package promgarlic
+
+import (
+	"path"
+	"reflect"
+)
+
+// OtherType is the type label given to the types that [WithAllowedTypes] and [WithDeniedTypes]
+// exclude, and to closers and cleanups that have no type.
+const OtherType = "other"
+
+// An Option configures optional behavior for [Collector].
+type Option func(*options)
+
+type options struct {
+	allowed []string
+	denied  []string
+}
+
+// WithAllowedTypes restricts the type labels [Collector] uses to the types whose names match at
+// least one of patterns, to limit the cardinality of the metrics; the other types are labelled
+// [OtherType]. Patterns use the syntax of [path.Match] and are matched against the name of the
+// type as formatted by [reflect.Type.String], e.g. "*payments.Client". WithAllowedTypes can be
+// given more than once to allow more types.
+func WithAllowedTypes(patterns ...string) Option {
+	return func(options *options) {
+		options.allowed = append(options.allowed, patterns...)
+	}
+}
+
+// WithDeniedTypes labels the types whose names match any of patterns [OtherType], e.g. to keep
+// generated or per-tenant types out of the metrics. Patterns are matched the same way as for
+// [WithAllowedTypes], and a type that matches both is denied.
+func WithDeniedTypes(patterns ...string) Option {
+	return func(options *options) {
+		options.denied = append(options.denied, patterns...)
+	}
+}
+
+// label returns the type label for typ.
+func (options options) label(typ reflect.Type) string {
+	if typ == nil {
+		return OtherType
+	}
+	name := typ.String()
+	for _, pattern := range options.denied {
+		if ok, _ := path.Match(pattern, name); ok {
+			return OtherType
+		}
+	}
+	if len(options.allowed) == 0 {
+		return name
+	}
+	for _, pattern := range options.allowed {
+		if ok, _ := path.Match(pattern, name); ok {
+			return name
+		}
+	}
+	return OtherType
+}
@@ -0,0 +1,3 @@
+// Package promgarlic exports the measurements of a [di.RootProvider] as Prometheus metrics. It is
+// a separate module so that programs that do not import it do not depend on Prometheus.
+package promgarlic
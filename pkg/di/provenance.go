@@ -0,0 +1,293 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// rootProvenanceScope is the [Provenance.Scope] of the instances cached by a [RootProvider].
+const rootProvenanceScope = "root"
+
+// WithProvenance makes the provider record where each [Singleton] and [Scoped] instance it and its
+// scopes cache came from for [RootProvider.Provenance] and [Scope.Provenance], e.g. to find out
+// why there are two connection pools. The records are kept with the cached instances and are
+// discarded along with them, so they never keep an instance from being garbage collected.
+// Without it provenance is not recorded at all.
+func WithProvenance() ProviderOption {
+	return func(options *providerOptions) {
+		options.provenance = true
+	}
+}
+
+// ErrNoIdentity is returned when an attempt is made to look up the provenance of an instance that
+// has no identity to look it up by.
+var ErrNoIdentity = errors.New("instance has no identity")
+
+// A NoIdentity is an [error] indicating that an attempt was made to look up the provenance of an
+// instance that is not a pointer, map, or channel, so it cannot be told apart from an equal copy.
+// Calling [errors.Is] with a NoIdentity and [ErrNoIdentity] returns true.
+type NoIdentity struct {
+
+	// Type is the type of the instance, which is nil for a nil instance.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NoIdentity) Error() string {
+	return fmt.Sprintf(
+		"instance of type %v has no identity: only the provenance of pointers, maps, and channels can be looked up",
+		err.Type)
+}
+
+// Is indicates that a [NoIdentity] is [ErrNoIdentity].
+func (NoIdentity) Is(target error) bool {
+	return target == ErrNoIdentity
+}
+
+// Code returns "no_identity", the code of a [NoIdentity] in [ErrorCodes].
+func (NoIdentity) Code() string {
+	return "no_identity"
+}
+
+// A Provenance describes where a cached instance came from; see [WithProvenance].
+type Provenance struct {
+
+	// Scope is the [Scope.ID] of the scope that cached the instance, or "root" for an instance
+	// cached by a [RootProvider].
+	Scope string
+
+	// Created is the time the instance was constructed.
+	Created time.Time
+
+	// Registration is the registration the instance was constructed from.
+	Registration RegistrationInfo
+
+	// Path is the types that were being resolved when the instance was constructed, starting with
+	// the requested type and ending with [RegistrationInfo.Type].
+	Path []reflect.Type
+}
+
+// Provenance returns the provenance of instance if it is a [Singleton] instance cached by the
+// provider or, for a child provider, by its parent. Instances are identified by pointer identity
+// so Provenance returns a [NoIdentity] if instance is not a pointer, map, or channel, and pointers
+// to distinct zero-size values may be indistinguishable. It reports false if the provider was not
+// built with [WithProvenance] or did not construct instance, which includes [Transient] values,
+// values given to [RegisterInstance], and instances replaced by [RootProvider.SetSingleton] or
+// refreshed after their TTL.
+func (provider RootProvider) Provenance(instance any) (Provenance, bool, error) {
+	if err := provider.checkInitialized("Provenance"); err != nil {
+		return Provenance{}, false, err
+	}
+	if !hasIdentity(instance) {
+		return Provenance{}, false, NoIdentity{
+			Type: reflect.TypeOf(instance),
+		}
+	}
+	if !provider.options.provenance {
+		return Provenance{}, false, nil
+	}
+	provenance, ok := provider.findProvenance(instance)
+	return provenance, ok, nil
+}
+
+func (provider RootProvider) findProvenance(instance any) (Provenance, bool) {
+	if provenance, ok := provider.singletons.provenance.find(instance); ok {
+		return provenance, true
+	}
+	if provider.parent != nil {
+		return provider.parent.findProvenance(instance)
+	}
+	return Provenance{}, false
+}
+
+// Provenances returns the provenance of each [Singleton] instance the provider has cached, in the
+// order they were created. It reports false if the provider was not built with [WithProvenance].
+func (provider RootProvider) Provenances() ([]Provenance, bool) {
+	if !provider.initialized() || !provider.options.provenance {
+		return nil, false
+	}
+	return provider.singletons.provenance.list(), true
+}
+
+// Provenance returns the provenance of instance if it is a [Scoped] instance cached by the scope
+// or one of the ancestors it inherits from, or a [Singleton] instance; see
+// [RootProvider.Provenance].
+func (scope Scope) Provenance(instance any) (Provenance, bool, error) {
+	if err := scope.checkInitialized("Provenance"); err != nil {
+		return Provenance{}, false, err
+	}
+	if err := scope.acquireState(); err != nil {
+		return Provenance{}, false, err
+	}
+	defer scope.releaseState()
+	if !hasIdentity(instance) {
+		return Provenance{}, false, NoIdentity{
+			Type: reflect.TypeOf(instance),
+		}
+	}
+	if !scope.root.options.provenance {
+		return Provenance{}, false, nil
+	}
+	if provenance, ok := scope.state.scopedValues.provenance.find(instance); ok {
+		return provenance, true, nil
+	}
+	for _, ancestor := range scope.inherited {
+		if ancestor.closeState.isClosing() {
+			continue
+		}
+		if provenance, ok := ancestor.scopedValues.provenance.find(instance); ok {
+			return provenance, true, nil
+		}
+	}
+	provenance, ok := scope.root.findProvenance(instance)
+	return provenance, ok, nil
+}
+
+// hasIdentity reports whether instance can be identified by its pointer.
+func hasIdentity(instance any) bool {
+	switch reflect.ValueOf(instance).Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// sameInstance reports whether a and b are the same instance; both must have identity.
+func sameInstance(a any, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// A resolutionPath is a type being constructed by a resolution, linked to the path of the types
+// being constructed by the resolution that requested it. Each copy of a scope or provider passed
+// to a factory extends the path it was copied from, so the paths share their prefixes.
+type resolutionPath struct {
+	typ    reflect.Type
+	parent *resolutionPath
+}
+
+// extend returns the path to typ from the resolution at the end of path, which may be nil.
+func (path *resolutionPath) extend(typ reflect.Type) *resolutionPath {
+	return &resolutionPath{
+		typ:    typ,
+		parent: path,
+	}
+}
+
+// types returns the types on the path, starting with the requested type, or nil for a nil path.
+func (path *resolutionPath) types() []reflect.Type {
+	var types []reflect.Type
+	for ; path != nil; path = path.parent {
+		types = append(types, path.typ)
+	}
+	slices.Reverse(types)
+	return types
+}
+
+// traced returns a factory for entry that records the provenance of the instances it creates in
+// m when provenance is recorded, or entry.New otherwise.
+func (provider RootProvider) traced(
+	m *instanceMap,
+	entry Registration,
+	scope string,
+	path *resolutionPath,
+) factoryFunc {
+	if !provider.options.provenance {
+		return entry.New
+	}
+	return tracingFactory(m, entry, scope, path)
+}
+
+// tracingFactory returns the factory traced returns when provenance is recorded. It is separate
+// from traced so that the variables its factory captures are only moved to the heap when
+// provenance is recorded.
+func tracingFactory(m *instanceMap, entry Registration, scope string, path *resolutionPath) factoryFunc {
+	return func(resolver Resolver) (any, error) {
+		value, err := entry.New(resolver)
+		if err == nil && hasIdentity(value) {
+			m.provenance.record(entry.Type, value, Provenance{
+				Scope:        scope,
+				Created:      time.Now(),
+				Registration: registrationInfo(entry.Type, entry.registration),
+				Path:         path.types(),
+			})
+		}
+		return value, err
+	}
+}
+
+// provenanceRecords holds the provenance of the instances in an instanceMap. Its zero value is
+// ready to use.
+type provenanceRecords struct {
+	mu      sync.Mutex
+	records map[reflect.Type]provenanceRecord
+}
+
+type provenanceRecord struct {
+	value      any
+	provenance Provenance
+}
+
+func (r *provenanceRecords) record(typ reflect.Type, value any, provenance Provenance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.records == nil {
+		r.records = make(map[reflect.Type]provenanceRecord)
+	}
+	r.records[typ] = provenanceRecord{
+		value:      value,
+		provenance: provenance,
+	}
+}
+
+// get returns the provenance of the instance of typ if it is value.
+func (r *provenanceRecords) get(typ reflect.Type, value any) (Provenance, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[typ]
+	if !ok || !hasIdentity(value) || !sameInstance(record.value, value) {
+		return Provenance{}, false
+	}
+	return record.provenance, true
+}
+
+func (r *provenanceRecords) find(instance any) (Provenance, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range r.records {
+		if sameInstance(record.value, instance) {
+			return record.provenance, true
+		}
+	}
+	return Provenance{}, false
+}
+
+// list returns the recorded provenance ordered by creation time.
+func (r *provenanceRecords) list() []Provenance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provenance := make([]Provenance, 0, len(r.records))
+	for _, record := range r.records {
+		provenance = append(provenance, record.provenance)
+	}
+	slices.SortFunc(provenance, func(a, b Provenance) int {
+		return a.Created.Compare(b.Created)
+	})
+	return provenance
+}
+
+func (r *provenanceRecords) forget(typ reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, typ)
+}
+
+func (r *provenanceRecords) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.records)
+}
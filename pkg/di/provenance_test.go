@@ -0,0 +1,165 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type provenancePool struct {
+	size int
+}
+
+type provenanceRepo struct {
+	Pool *provenancePool
+}
+
+type provenanceSession struct {
+	Repo *provenanceRepo
+}
+
+func TestProvenance(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*provenancePool, *provenancePool](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*provenanceRepo, *provenanceRepo](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*provenanceSession, *provenanceSession](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("records the scope, registration, and path of cached instances", func(t *testing.T) {
+		before := time.Now()
+		scope := newProvider(t, WithProvenance()).NewScope()
+		session, err := Resolve[*provenanceSession](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+
+		repo, ok, err := scope.Provenance(session.Repo)
+		if err != nil {
+			t.Fatalf("unexpected error from Provenance: %v", err)
+		}
+		if !ok {
+			t.Fatalf("expected the repo to have provenance")
+		}
+		if repo.Scope != scope.ID() {
+			t.Errorf("expected the repo to come from scope %s; got %s", scope.ID(), repo.Scope)
+		}
+		if repo.Registration.Lifetime != Scoped || repo.Registration.Type != reflect.TypeFor[*provenanceRepo]() {
+			t.Errorf("expected the scoped *provenanceRepo registration; got %+v", repo.Registration)
+		}
+		if repo.Created.Before(before) {
+			t.Errorf("expected the creation time %v to be after %v", repo.Created, before)
+		}
+
+		pool, ok, err := scope.Provenance(session.Repo.Pool)
+		if err != nil {
+			t.Fatalf("unexpected error from Provenance: %v", err)
+		}
+		if !ok {
+			t.Fatalf("expected the pool to have provenance")
+		}
+		if pool.Scope != "root" {
+			t.Errorf("expected the pool to come from the root; got %s", pool.Scope)
+		}
+		expected := []reflect.Type{
+			reflect.TypeFor[*provenanceSession](),
+			reflect.TypeFor[*provenanceRepo](),
+			reflect.TypeFor[*provenancePool](),
+		}
+		if !slices.Equal(pool.Path, expected) {
+			t.Errorf("expected path %v; got %v", expected, pool.Path)
+		}
+	})
+
+	t.Run("finds singletons from the provider but not scoped instances", func(t *testing.T) {
+		provider := newProvider(t, WithProvenance())
+		scope := provider.NewScope()
+		repo, err := Resolve[*provenanceRepo](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok, _ := provider.Provenance(repo.Pool); !ok {
+			t.Errorf("expected the provider to know the provenance of the pool")
+		}
+		if _, ok, _ := provider.Provenance(repo); ok {
+			t.Errorf("expected the provider not to know the provenance of a scoped instance")
+		}
+		records, ok := provider.Provenances()
+		if !ok || len(records) != 1 || records[0].Registration.Type != reflect.TypeFor[*provenancePool]() {
+			t.Errorf("expected the provenance of the pool; got %v (%v)", records, ok)
+		}
+	})
+
+	t.Run("reports false for transient and unknown instances", func(t *testing.T) {
+		scope := newProvider(t, WithProvenance()).NewScope()
+		session, err := Resolve[*provenanceSession](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok, err := scope.Provenance(session); ok || err != nil {
+			t.Errorf("expected no provenance for a transient instance; got %v, %v", ok, err)
+		}
+		if _, ok, err := scope.Provenance(&provenancePool{}); ok || err != nil {
+			t.Errorf("expected no provenance for an unknown instance; got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("reports false when provenance is not recorded", func(t *testing.T) {
+		provider := newProvider(t)
+		pool, err := Resolve[*provenancePool](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok, err := provider.Provenance(pool); ok || err != nil {
+			t.Errorf("expected no provenance; got %v, %v", ok, err)
+		}
+		if _, ok := provider.Provenances(); ok {
+			t.Errorf("expected Provenances to report false")
+		}
+	})
+
+	t.Run("returns NoIdentity for instances without identity", func(t *testing.T) {
+		provider := newProvider(t, WithProvenance())
+		_, _, err := provider.Provenance(provenancePool{})
+		if !errors.Is(err, ErrNoIdentity) {
+			t.Fatalf("expected %q; got %q", ErrNoIdentity, err)
+		}
+		var noIdentity NoIdentity
+		if !errors.As(err, &noIdentity) {
+			t.Fatalf("expected %v to be %T", err, noIdentity)
+		}
+		if typ := reflect.TypeFor[provenancePool](); noIdentity.Type != typ {
+			t.Errorf("expected err.Type to be %v; got %v", typ, noIdentity.Type)
+		}
+	})
+
+	t.Run("forgets evicted instances", func(t *testing.T) {
+		provider := newProvider(t, WithProvenance())
+		pool, err := Resolve[*provenancePool](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if err := provider.EvictSingleton(reflect.TypeFor[*provenancePool]()); err != nil {
+			t.Fatalf("unexpected error from EvictSingleton: %v", err)
+		}
+		if _, ok, _ := provider.Provenance(pool); ok {
+			t.Errorf("expected no provenance for an evicted instance")
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A ProviderOption configures optional behavior for a [RootProvider] built by
+// [Registry.BuildRootProvider]. The options are applied in order, and options that conflict with
+// each other make BuildRootProvider return a [ConflictingOptions]. The options a provider was
+// built with are described by [RootProvider.Settings].
+//
+// The options are [WithAutoResolve], [WithBestEffortFieldInjection], [WithCloseErrorHandler],
+// [WithDefaultCloseConcurrency], [WithDefaultFactoryTimeout], [WithDeprecationErrors],
+// [WithDeprecationLog], [WithEmptyCollections], [WithFailFastFactoryTimeouts],
+// [WithFallbackResolver], [WithInstanceStore], [WithLeakDetection], [WithObserver],
+// [WithProvenance], [WithResolutionCounts], [WithRestrictedResolvers], [WithScopePooling],
+// [WithScopeTracking], [WithSharedSingletons], [WithStrictDisposal], [WithTransientTracking],
+// [WithTypeRewrite], [WithTypeRewriteFunc], and [WithWarmUpConcurrency].
+type ProviderOption func(*providerOptions)
+
+type providerOptions struct {
+	closeConcurrency int
+	scopePooling     bool
+	observer         Observer
+
+	// bestEffortFields is true if default struct factories skip fields of unregistered types.
+	bestEffortFields bool
+
+	// autoResolve is true if unregistered struct types are resolved using their default factories.
+	autoResolve bool
+
+	// warmUpConcurrency is the number of singletons WarmUp resolves at once, where 0 means one at a
+	// time and -1 means all of them.
+	warmUpConcurrency int
+
+	// leakDetection is true if scopes that are garbage collected without being closed are reported.
+	leakDetection bool
+
+	// scopeTracking is true if the scopes that have not been closed are recorded.
+	scopeTracking bool
+
+	// resolutionCounts is true if the resolutions of each type are counted.
+	resolutionCounts bool
+
+	// transientTracking is true if the Transient types that have been resolved are recorded.
+	transientTracking bool
+
+	// strictDisposal is true if building the provider fails for Transient registrations whose
+	// instances must be closed by their callers.
+	strictDisposal bool
+
+	// provenance is true if the origin of each cached instance is recorded.
+	provenance bool
+
+	// factoryTimeout limits how long factories may run by default, if positive.
+	factoryTimeout time.Duration
+
+	// failFastFactoryTimeouts is true if the resolutions of a type fail while its factory is still
+	// running after exceeding its timeout.
+	failFastFactoryTimeouts bool
+
+	// emptyCollections is true if unmatched slice and map types are resolved as empty
+	// collections.
+	emptyCollections bool
+
+	// rewrites maps requested types to the types resolved instead, and rewriteFuncs compute the
+	// rewrites of other types.
+	rewrites     map[reflect.Type]reflect.Type
+	rewriteFuncs []func(reflect.Type) reflect.Type
+
+	// restrictedResolvers is true if factories are given restricted resolvers.
+	restrictedResolvers bool
+
+	// deprecationErrors is true if resolving deprecated registrations fails, and deprecationLog
+	// logs their resolutions, if set.
+	deprecationErrors bool
+	deprecationLog    *deprecationLog
+
+	// closeErrorHandler receives the reports of delegated closes that end with errors, if set.
+	closeErrorHandler func(scopeID string, report CloseReport)
+
+	// fallback configures the resolver asked for unregistered types, if any.
+	fallback *fallbackOptions
+
+	// newInstanceStore creates the stores for the instances of the provider and its scopes, or is
+	// nil to use the default store.
+	newInstanceStore func() InstanceStore
+
+	// sharedSingletons holds the stores for the Singleton types that are shared with other
+	// providers.
+	sharedSingletons map[reflect.Type]*SingletonStore
+
+	// applied records the single-valued options that have been applied, and conflicts the
+	// conflicts found while applying them.
+	applied   map[string]bool
+	conflicts []OptionConflict
+}
+
+// reapplied reports whether the single-valued option named name has been applied before, and
+// records that it has been applied.
+func (options *providerOptions) reapplied(name string) bool {
+	if options.applied == nil {
+		options.applied = make(map[string]bool)
+	}
+	reapplied := options.applied[name]
+	options.applied[name] = true
+	return reapplied
+}
+
+func (options *providerOptions) conflict(reason string, names ...string) {
+	options.conflicts = append(options.conflicts, OptionConflict{
+		Options: names,
+		Reason:  reason,
+	})
+}
+
+// ErrConflictingOptions is returned when a provider is built with options that conflict with each
+// other.
+var ErrConflictingOptions = errors.New("provider options conflict")
+
+// A ConflictingOptions is an [error] indicating that [Registry.BuildRootProvider] was given
+// options that conflict with each other, e.g. two observers. Calling [errors.Is] with a
+// ConflictingOptions and [ErrConflictingOptions] returns true.
+type ConflictingOptions struct {
+
+	// Conflicts are the conflicts that were found, in the order the options were given.
+	Conflicts []OptionConflict
+}
+
+// Error implements [error].
+func (err ConflictingOptions) Error() string {
+	msg := strings.Builder{}
+	fmt.Fprintf(&msg, "%d provider option conflict(s):", len(err.Conflicts))
+	for _, conflict := range err.Conflicts {
+		fmt.Fprintf(&msg, "\n  - %v", conflict)
+	}
+	return msg.String()
+}
+
+// Is indicates that a [ConflictingOptions] is [ErrConflictingOptions].
+func (ConflictingOptions) Is(target error) bool {
+	return target == ErrConflictingOptions
+}
+
+// Code returns "conflicting_options", the code of a [ConflictingOptions] in [ErrorCodes].
+func (ConflictingOptions) Code() string {
+	return "conflicting_options"
+}
+
+// An OptionConflict describes options given to [Registry.BuildRootProvider] that conflict with
+// each other; see [ConflictingOptions].
+type OptionConflict struct {
+
+	// Options are the names of the conflicting options, e.g. "WithObserver". An option that
+	// conflicts with another use of itself is named once.
+	Options []string
+
+	// Reason explains the conflict.
+	Reason string
+}
+
+// String implements [fmt.Stringer] by describing the conflict.
+func (conflict OptionConflict) String() string {
+	return fmt.Sprintf("%s: %s", strings.Join(conflict.Options, " and "), conflict.Reason)
+}
+
+// WithDefaultCloseConcurrency sets the default limit on the number of closers that Close runs at
+// once for the provider and every [Scope] created from it. See [WithCloseConcurrency].
+func WithDefaultCloseConcurrency(limit int) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithDefaultCloseConcurrency") && options.closeConcurrency != limit {
+			options.conflict(
+				fmt.Sprintf("given limits of %d and %d", options.closeConcurrency, limit),
+				"WithDefaultCloseConcurrency")
+		}
+		options.closeConcurrency = limit
+	}
+}
+
+// WithObserver sets an [Observer] to receive the events from the provider and every [Scope]
+// created from it. A provider has one observer, so WithObserver can only be given once; an
+// observer that fans the events out to several others can be given instead.
+func WithObserver(observer Observer) ProviderOption {
+	return func(options *providerOptions) {
+		if options.reapplied("WithObserver") {
+			options.conflict("given more than once, but a provider has a single observer", "WithObserver")
+		}
+		options.observer = observer
+	}
+}
+
+// WithSharedSingletons makes the provider keep the [Singleton] values for types in store instead
+// of its own cache so that every provider built with the same store shares their instances. Each
+// shared value is constructed exactly once across all of the providers, by whichever provider
+// resolves it first, and store owns the instances; see [SingletonStore.Close]. WithSharedSingletons
+// can be given more than once to share types from several stores, but a type can only be shared
+// from one store.
+func WithSharedSingletons(store *SingletonStore, types ...reflect.Type) ProviderOption {
+	return func(options *providerOptions) {
+		if options.sharedSingletons == nil {
+			options.sharedSingletons = make(map[reflect.Type]*SingletonStore, len(types))
+		}
+		for _, typ := range types {
+			if shared, ok := options.sharedSingletons[typ]; ok && shared != store {
+				options.conflict(fmt.Sprintf("%v is shared from two stores", typ), "WithSharedSingletons")
+			}
+			options.sharedSingletons[typ] = store
+		}
+	}
+}
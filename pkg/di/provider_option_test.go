@@ -0,0 +1,93 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProviderOptions(t *testing.T) {
+
+	t.Run("returns ConflictingOptions listing each conflict", func(t *testing.T) {
+		observer := ObserverFunc(func(Event) {})
+		first, second := NewSingletonStore(), NewSingletonStore()
+		typ := reflect.TypeFor[*mockCloser]()
+		_, err := Registry{}.BuildRootProvider(
+			WithObserver(observer),
+			WithDefaultCloseConcurrency(2),
+			WithSharedSingletons(first, typ),
+			WithObserver(observer),
+			WithDefaultCloseConcurrency(4),
+			WithSharedSingletons(second, typ))
+		if !errors.Is(err, ErrConflictingOptions) {
+			t.Fatalf("expected %q; got %q", ErrConflictingOptions, err)
+		}
+		var conflicting ConflictingOptions
+		if !errors.As(err, &conflicting) {
+			t.Fatalf("expected %v to be %T", err, conflicting)
+		}
+		var names []string
+		for _, conflict := range conflicting.Conflicts {
+			names = append(names, strings.Join(conflict.Options, ","))
+		}
+		expected := []string{"WithObserver", "WithDefaultCloseConcurrency", "WithSharedSingletons"}
+		if !slices.Equal(names, expected) {
+			t.Fatalf("expected conflicts for %v; got %v", expected, conflicting.Conflicts)
+		}
+		for _, expected := range []string{"3 provider option conflict(s)", "given limits of 2 and 4", "*di.mockCloser is shared from two stores"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %q to contain %q", err, expected)
+			}
+		}
+	})
+
+	t.Run("allows single-valued options repeated with the same value", func(t *testing.T) {
+		store := NewSingletonStore()
+		typ := reflect.TypeFor[*mockCloser]()
+		_, err := Registry{}.BuildRootProvider(
+			WithDefaultCloseConcurrency(2),
+			WithDefaultCloseConcurrency(2),
+			WithWarmUpConcurrency(0),
+			WithWarmUpConcurrency(-5),
+			WithSharedSingletons(store, typ),
+			WithSharedSingletons(store, typ))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+	})
+
+	t.Run("Settings describes the effective options", func(t *testing.T) {
+		provider, err := Registry{}.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		settings := provider.Settings()
+		if settings.String() != "defaults" || settings.WarmUpConcurrency != 1 {
+			t.Fatalf("expected the default settings; got %+v", settings)
+		}
+		typ := reflect.TypeFor[*mockCloser]()
+		provider, err = Registry{}.BuildRootProvider(
+			WithScopePooling(),
+			WithWarmUpConcurrency(0),
+			WithSharedSingletons(NewSingletonStore(), typ),
+			WithObserver(ObserverFunc(func(Event) {})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		child, err := provider.NewChildProvider(Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		settings = child.Settings()
+		if !settings.ScopePooling || !settings.Observer || settings.WarmUpConcurrency != 0 ||
+			!slices.Equal(settings.SharedSingletons, []reflect.Type{typ}) {
+			t.Fatalf("unexpected settings %+v", settings)
+		}
+		expected := "WarmUpConcurrency=0, SharedSingletons=[*di.mockCloser], Observer, ScopePooling"
+		if s := settings.String(); s != expected {
+			t.Fatalf("expected %q; got %q", expected, s)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package di
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ProviderSettings describes the effective options a [RootProvider] was built with; see
+// [RootProvider.Settings].
+type ProviderSettings struct {
+
+	// DefaultCloseConcurrency is the default limit on the number of closers Close runs at once,
+	// where 0 or less means every closer at once; see [WithDefaultCloseConcurrency].
+	DefaultCloseConcurrency int
+
+	// DefaultFactoryTimeout is the default limit on how long factories may run, where 0 means no
+	// limit; see [WithDefaultFactoryTimeout].
+	DefaultFactoryTimeout time.Duration
+
+	// FailFastFactoryTimeouts is true if the provider was built [WithFailFastFactoryTimeouts].
+	FailFastFactoryTimeouts bool
+
+	// WarmUpConcurrency is the number of singletons [RootProvider.WarmUp] resolves at once, where 0
+	// means all of them; see [WithWarmUpConcurrency].
+	WarmUpConcurrency int
+
+	// Observer is true if the provider has an [Observer]; see [WithObserver].
+	Observer bool
+
+	// InstanceStore is true if the provider caches instances in custom stores; see
+	// [WithInstanceStore].
+	InstanceStore bool
+
+	// SharedSingletons are the types whose [Singleton] values are shared with other providers,
+	// ordered by type name; see [WithSharedSingletons].
+	SharedSingletons []reflect.Type
+
+	// ScopePooling is true if the provider was built [WithScopePooling].
+	ScopePooling bool
+
+	// BestEffortFieldInjection is true if the provider was built [WithBestEffortFieldInjection].
+	BestEffortFieldInjection bool
+
+	// AutoResolve is true if the provider was built [WithAutoResolve].
+	AutoResolve bool
+
+	// LeakDetection is true if the provider was built [WithLeakDetection].
+	LeakDetection bool
+
+	// ScopeTracking is true if the provider was built [WithScopeTracking].
+	ScopeTracking bool
+
+	// ResolutionCounts is true if the provider was built [WithResolutionCounts].
+	ResolutionCounts bool
+
+	// TransientTracking is true if the provider was built [WithTransientTracking].
+	TransientTracking bool
+
+	// StrictDisposal is true if the provider was built [WithStrictDisposal].
+	StrictDisposal bool
+
+	// Provenance is true if the provider was built [WithProvenance].
+	Provenance bool
+
+	// EmptyCollections is true if the provider was built [WithEmptyCollections].
+	EmptyCollections bool
+
+	// TypeRewrites is true if the provider was given [WithTypeRewrite] or [WithTypeRewriteFunc].
+	TypeRewrites bool
+
+	// DeprecationErrors is true if the provider was built [WithDeprecationErrors], and
+	// DeprecationLog is true if it was built [WithDeprecationLog].
+	DeprecationErrors bool
+	DeprecationLog    bool
+
+	// RestrictedResolvers is true if the provider was built [WithRestrictedResolvers].
+	RestrictedResolvers bool
+
+	// CloseErrorHandler is true if the provider was built [WithCloseErrorHandler].
+	CloseErrorHandler bool
+
+	// FallbackResolver is true if the provider was built [WithFallbackResolver], and
+	// CacheFallbackValues is true if it was also given [CacheFallbackValues].
+	FallbackResolver    bool
+	CacheFallbackValues bool
+}
+
+// Settings returns the effective options the provider was built with, e.g. for debug output. A
+// child provider has the settings of the provider it was created from.
+func (provider RootProvider) Settings() ProviderSettings {
+	if !provider.initialized() {
+		return ProviderSettings{}
+	}
+	options := provider.options
+	warmUpConcurrency := options.warmUpConcurrency
+	switch warmUpConcurrency {
+	case 0:
+		warmUpConcurrency = 1
+	case -1:
+		warmUpConcurrency = 0
+	}
+	shared := slices.SortedFunc(maps.Keys(options.sharedSingletons), compareTypes)
+	if shared == nil {
+		shared = []reflect.Type{}
+	}
+	return ProviderSettings{
+		DefaultCloseConcurrency:  options.closeConcurrency,
+		DefaultFactoryTimeout:    max(options.factoryTimeout, 0),
+		FailFastFactoryTimeouts:  options.failFastFactoryTimeouts,
+		WarmUpConcurrency:        warmUpConcurrency,
+		Observer:                 options.observer != nil,
+		InstanceStore:            options.newInstanceStore != nil,
+		SharedSingletons:         shared,
+		ScopePooling:             options.scopePooling,
+		BestEffortFieldInjection: options.bestEffortFields,
+		AutoResolve:              options.autoResolve,
+		LeakDetection:            options.leakDetection,
+		ScopeTracking:            options.scopeTracking,
+		ResolutionCounts:         options.resolutionCounts,
+		TransientTracking:        options.transientTracking,
+		StrictDisposal:           options.strictDisposal,
+		Provenance:               options.provenance,
+		EmptyCollections:         options.emptyCollections,
+		TypeRewrites:             len(options.rewrites) > 0 || len(options.rewriteFuncs) > 0,
+		DeprecationErrors:        options.deprecationErrors,
+		DeprecationLog:           options.deprecationLog != nil,
+		RestrictedResolvers:      options.restrictedResolvers,
+		CloseErrorHandler:        options.closeErrorHandler != nil,
+		FallbackResolver:         options.fallback != nil,
+		CacheFallbackValues:      options.fallback != nil && options.fallback.cache,
+	}
+}
+
+// String implements [fmt.Stringer] by listing the settings that differ from those of a provider
+// built without options, e.g. "Observer, WarmUpConcurrency=4", or "defaults" if there are
+// none.
+func (settings ProviderSettings) String() string {
+	var set []string
+	if settings.DefaultCloseConcurrency != 0 {
+		set = append(set, fmt.Sprintf("DefaultCloseConcurrency=%d", settings.DefaultCloseConcurrency))
+	}
+	if settings.DefaultFactoryTimeout != 0 {
+		set = append(set, fmt.Sprintf("DefaultFactoryTimeout=%v", settings.DefaultFactoryTimeout))
+	}
+	if settings.WarmUpConcurrency != 1 {
+		set = append(set, fmt.Sprintf("WarmUpConcurrency=%d", settings.WarmUpConcurrency))
+	}
+	if len(settings.SharedSingletons) > 0 {
+		set = append(set, fmt.Sprintf("SharedSingletons=%v", settings.SharedSingletons))
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"FailFastFactoryTimeouts", settings.FailFastFactoryTimeouts},
+		{"Observer", settings.Observer},
+		{"InstanceStore", settings.InstanceStore},
+		{"ScopePooling", settings.ScopePooling},
+		{"BestEffortFieldInjection", settings.BestEffortFieldInjection},
+		{"AutoResolve", settings.AutoResolve},
+		{"LeakDetection", settings.LeakDetection},
+		{"ScopeTracking", settings.ScopeTracking},
+		{"ResolutionCounts", settings.ResolutionCounts},
+		{"TransientTracking", settings.TransientTracking},
+		{"StrictDisposal", settings.StrictDisposal},
+		{"Provenance", settings.Provenance},
+		{"EmptyCollections", settings.EmptyCollections},
+		{"TypeRewrites", settings.TypeRewrites},
+		{"DeprecationErrors", settings.DeprecationErrors},
+		{"DeprecationLog", settings.DeprecationLog},
+		{"RestrictedResolvers", settings.RestrictedResolvers},
+		{"CloseErrorHandler", settings.CloseErrorHandler},
+		{"FallbackResolver", settings.FallbackResolver},
+		{"CacheFallbackValues", settings.CacheFallbackValues},
+	} {
+		if flag.set {
+			set = append(set, flag.name)
+		}
+	}
+	if len(set) == 0 {
+		return "defaults"
+	}
+	return strings.Join(set, ", ")
+}
@@ -0,0 +1,28 @@
+package di
+
+// ProviderStats holds counts describing the activity of a [RootProvider]; see
+// [RootProvider.Stats].
+type ProviderStats struct {
+
+	// IdleScopes is the number of open scopes created [WithIdleTimeout] that the provider will
+	// close once they are idle.
+	IdleScopes int
+
+	// SweptScopes is the number of scopes the provider closed because they went unused for longer
+	// than their [WithIdleTimeout].
+	SweptScopes uint64
+}
+
+// Stats returns counts describing the activity of the provider, e.g. for exporting as metrics.
+func (provider RootProvider) Stats() ProviderStats {
+	if !provider.initialized() {
+		return ProviderStats{}
+	}
+	provider.idleScopes.mu.Lock()
+	idle := len(provider.idleScopes.scopes)
+	provider.idleScopes.mu.Unlock()
+	return ProviderStats{
+		IdleScopes:  idle,
+		SweptScopes: provider.idleScopes.swept.Load(),
+	}
+}
@@ -0,0 +1,104 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrNotRefreshable is returned when an attempt is made to refresh a [Singleton] that was not
+// registered using [WithRefresh].
+var ErrNotRefreshable = errors.New("singleton was not registered as refreshable")
+
+// A NotRefreshable is an [error] indicating that an attempt was made to refresh a [Singleton] that
+// was not registered using [WithRefresh]. Calling [errors.Is] with a NotRefreshable and
+// [ErrNotRefreshable] returns true.
+type NotRefreshable struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NotRefreshable) Error() string {
+	return fmt.Sprintf("singleton of type %v was not registered using WithRefresh", err.Type)
+}
+
+// Is indicates that a [NotRefreshable] is [ErrNotRefreshable].
+func (NotRefreshable) Is(target error) bool {
+	return target == ErrNotRefreshable
+}
+
+// Code returns "not_refreshable", the code of a [NotRefreshable] in [ErrorCodes].
+func (NotRefreshable) Code() string {
+	return "not_refreshable"
+}
+
+// A RefreshOption configures optional behavior for a single call to [RootProvider.Refresh].
+type RefreshOption func(*refreshOptions)
+
+type refreshOptions struct {
+	drainDelay time.Duration
+}
+
+// WithDrainDelay delays closing the [Singleton] instance replaced by [RootProvider.Refresh] to give
+// code that resolved it before the refresh time to finish using it.
+func WithDrainDelay(delay time.Duration) RefreshOption {
+	return func(options *refreshOptions) {
+		options.drainDelay = delay
+	}
+}
+
+// Refresh rebuilds the [Singleton] value for typ, which must have been registered using
+// [WithRefresh]. Refresh constructs a new instance using the registered factory and then replaces
+// the provider's instance with it, so resolutions that happen during the refresh get either the
+// old instance or the complete new one. If the factory fails Refresh returns its error and the
+// old instance stays in place.
+//
+// A replaced instance the provider owns is closed in the background, after the delay given by
+// [WithDrainDelay] if any, and the result is reported to the provider's [Observer] as a
+// [DisplacedSingletonClosed]. Refreshing a singleton that a child provider inherits refreshes the
+// parent's instance. Refresh rebuilds a value rather than changing how it is registered so it is
+// allowed after [RootProvider.Freeze].
+//
+// Refresh returns an [UnknownType] if typ is not registered, a [NotSingleton] if it is not
+// registered as a Singleton, and a [NotRefreshable] if it was not registered using WithRefresh.
+func (provider RootProvider) Refresh(typ reflect.Type, opts ...RefreshOption) error {
+	if err := provider.checkInitialized("Refresh"); err != nil {
+		return err
+	}
+	registration, ok := provider.registrations.get(typ)
+	if !ok {
+		return UnknownType{
+			Type: typ,
+		}
+	}
+	if registration.lifetime != Singleton {
+		return NotSingleton{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+		}
+	}
+	if registration.inherited {
+		return provider.parent.Refresh(typ, opts...)
+	}
+	if !registration.refreshable {
+		return NotRefreshable{
+			Type: typ,
+		}
+	}
+	options := refreshOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	value, err := registration.construct(typ, provider.constructingType(typ))
+	if err != nil {
+		return err
+	}
+	old, replaced, _ := provider.singletonsFor(typ).replace(typ, value, true)
+	if replaced && registration.owned {
+		go provider.closeDisplaced(typ, old, options.drainDelay)
+	}
+	return nil
+}
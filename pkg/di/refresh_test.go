@@ -0,0 +1,108 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefresh(t *testing.T) {
+
+	newProvider := func(t *testing.T, fail *atomic.Bool, opts ...RegistrationOption) (RootProvider, chan Event) {
+		events := make(chan Event, 1)
+		registry, err := RegisterFactory[*mockCloser](Registry{}, Singleton, func(Resolver) (*mockCloser, error) {
+			if fail.Load() {
+				return nil, errors.New("factory failed")
+			}
+			return &mockCloser{}, nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithObserver(ObserverFunc(func(event Event) {
+			events <- event
+		})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider, events
+	}
+
+	t.Run("replaces the instance and closes the old one", func(t *testing.T) {
+		provider, events := newProvider(t, &atomic.Bool{}, WithRefresh())
+		old, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if err := provider.Refresh(reflect.TypeFor[*mockCloser](), WithDrainDelay(time.Millisecond)); err != nil {
+			t.Fatalf("unexpected error from Refresh: %v", err)
+		}
+		refreshed, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if refreshed == old {
+			t.Fatalf("expected Refresh to replace the instance")
+		}
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the old instance to be closed")
+		}
+		if !old.closed {
+			t.Fatalf("expected the old instance to be closed")
+		}
+	})
+
+	t.Run("keeps the old instance when the factory fails", func(t *testing.T) {
+		fail := &atomic.Bool{}
+		provider, _ := newProvider(t, fail, WithRefresh())
+		old, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		fail.Store(true)
+		if err := provider.Refresh(reflect.TypeFor[*mockCloser]()); err == nil {
+			t.Fatalf("expected an error from Refresh")
+		}
+		actual, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != old || old.closed {
+			t.Fatalf("expected the old instance to stay in place")
+		}
+	})
+
+	t.Run("returns NotRefreshable without WithRefresh", func(t *testing.T) {
+		provider, _ := newProvider(t, &atomic.Bool{})
+		err := provider.Refresh(reflect.TypeFor[*mockCloser]())
+		if !errors.Is(err, ErrNotRefreshable) {
+			t.Fatalf("expected %v to be %v", err, ErrNotRefreshable)
+		}
+	})
+
+	t.Run("refreshes the parent's instance from a child provider", func(t *testing.T) {
+		parent, _ := newProvider(t, &atomic.Bool{}, WithRefresh())
+		child, err := parent.NewChildProvider(Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		old, err := Resolve[*mockCloser](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if err := child.Refresh(reflect.TypeFor[*mockCloser]()); err != nil {
+			t.Fatalf("unexpected error from Refresh: %v", err)
+		}
+		refreshed, err := Resolve[*mockCloser](parent)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if refreshed == old {
+			t.Fatalf("expected Refresh to replace the parent's instance")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotFunc is returned when an attempt is made to register a type that is not a function with
+// [RegisterFunc].
+var ErrNotFunc = errors.New("type is not a function type")
+
+// A NotFunc is an [error] indicating that an attempt was made to register a type that is not a
+// function type with [RegisterFunc]. Calling [errors.Is] with a NotFunc and [ErrNotFunc] returns
+// true.
+type NotFunc struct {
+
+	// Type is the type that was registered.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NotFunc) Error() string {
+	return fmt.Sprintf("type %v is not a function type", err.Type)
+}
+
+// Is indicates that a [NotFunc] is [ErrNotFunc].
+func (NotFunc) Is(target error) bool {
+	return target == ErrNotFunc
+}
+
+// Code returns "not_func", the code of a [NotFunc] in [ErrorCodes].
+func (NotFunc) Code() string {
+	return "not_func"
+}
+
+// RegisterFunc registers a [Factory] that provides the function values of the function type F,
+// e.g. a `type SendEmail func(context.Context, Message) error` implemented by a method of a
+// service; see [BindMethod]. F is resolved, cached, and injected into struct fields like any other
+// type. Since the factory decides what a function value closes over, function values from a
+// factory may be registered with any [Lifetime]; the lifetime should not outlive the values the
+// function uses. RegisterFunc returns a [NotFunc] if F is not a function type and otherwise the
+// same errors as [RegisterFactory].
+func RegisterFunc[F any](
+	registry Registry,
+	lifetime Lifetime,
+	factory Factory[F],
+	opts ...RegistrationOption,
+) (Registry, error) {
+	typ := reflect.TypeFor[F]()
+	if typ.Kind() != reflect.Func {
+		return registry, NotFunc{
+			Type: typ,
+		}
+	}
+	return registerFactory(registry, typ, typ, lifetime, factory, opts)
+}
+
+// BindMethod returns a [Factory] for the function type F that resolves S and returns the function
+// value selector picks from it, usually a method value, e.g.
+//
+//	di.RegisterFunc[SendEmail](registry, di.Scoped, di.BindMethod[SendEmail](
+//		func(mailer *Mailer) SendEmail { return mailer.Send }))
+//
+// The factory returns a [NilResolution] if selector returns a nil function. [Registry.Verify]
+// does not know that the factory resolves S, so it cannot report a missing or captive S.
+func BindMethod[F any, S any](selector func(S) F) Factory[F] {
+	return func(resolver Resolver) (F, error) {
+		var zero F
+		service, err := Resolve[S](resolver)
+		if err != nil {
+			return zero, err
+		}
+		fn := selector(service)
+		if value := reflect.ValueOf(fn); value.Kind() == reflect.Func && value.IsNil() {
+			return zero, NilResolution{
+				Type: reflect.TypeFor[F](),
+			}
+		}
+		return fn, nil
+	}
+}
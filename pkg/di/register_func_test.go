@@ -0,0 +1,130 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sendEmail func(ctx context.Context, to string) error
+
+type mailer struct {
+	sent []string
+}
+
+func (m *mailer) Send(_ context.Context, to string) error {
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+type notifier struct {
+	Send sendEmail
+}
+
+func TestRegisterFunc(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, factory Factory[sendEmail]) RootProvider {
+		registry, err := RegisterType[*mailer, *mailer](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFunc[sendEmail](registry, lifetime, factory)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFunc: %v", err)
+		}
+		registry, err = RegisterType[*notifier, *notifier](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	bound := BindMethod[sendEmail](func(m *mailer) sendEmail {
+		return m.Send
+	})
+
+	t.Run("binds a method of a resolved service", func(t *testing.T) {
+		scope := newProvider(t, Scoped, bound).NewScope()
+		send, err := Resolve[sendEmail](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if err := send(context.Background(), "a@example.com"); err != nil {
+			t.Fatalf("unexpected error from send: %v", err)
+		}
+		m, err := Resolve[*mailer](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if len(m.sent) != 1 || m.sent[0] != "a@example.com" {
+			t.Fatalf("expected the scope's mailer to send; got %v", m.sent)
+		}
+	})
+
+	t.Run("injects functions into struct fields", func(t *testing.T) {
+		scope := newProvider(t, Scoped, bound).NewScope()
+		n, err := Resolve[*notifier](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if n.Send == nil {
+			t.Fatalf("expected the Send field to be injected")
+		}
+		if err := n.Send(context.Background(), "b@example.com"); err != nil {
+			t.Fatalf("unexpected error from Send: %v", err)
+		}
+	})
+
+	t.Run("caches scoped functions", func(t *testing.T) {
+		calls := 0
+		provider := newProvider(t, Scoped, func(resolver Resolver) (sendEmail, error) {
+			calls++
+			return bound(resolver)
+		})
+		scope := provider.NewScope()
+		for range 2 {
+			if _, err := Resolve[sendEmail](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		if _, err := Resolve[sendEmail](provider.NewScope()); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if calls != 2 {
+			t.Fatalf("expected the factory to be called once per scope; got %d calls", calls)
+		}
+	})
+
+	t.Run("allows functions from factories to be shared", func(t *testing.T) {
+		for _, lifetime := range []Lifetime{Singleton, Scoped, Transient} {
+			_, err := RegisterFactory[sendEmail](Registry{}, lifetime, func(Resolver) (sendEmail, error) {
+				return func(context.Context, string) error { return nil }, nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error from RegisterFactory for %v: %v", lifetime, err)
+			}
+		}
+	})
+
+	t.Run("returns NotFunc for other types", func(t *testing.T) {
+		_, err := RegisterFunc[*mailer](Registry{}, Scoped, func(Resolver) (*mailer, error) {
+			return &mailer{}, nil
+		})
+		if !errors.Is(err, ErrNotFunc) {
+			t.Fatalf("expected %v to be %v", err, ErrNotFunc)
+		}
+	})
+
+	t.Run("BindMethod returns NilResolution for nil functions", func(t *testing.T) {
+		scope := newProvider(t, Scoped, BindMethod[sendEmail](func(*mailer) sendEmail {
+			return nil
+		})).NewScope()
+		if _, err := Resolve[sendEmail](scope); !errors.Is(err, ErrNilResolution) {
+			t.Fatalf("expected %v to be %v", err, ErrNilResolution)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package di
+
+import (
+	"reflect"
+	"slices"
+)
+
+// A RegistrationInfo describes a registration, e.g. for auditing the registrations in a
+// [Registry] or [RootProvider].
+type RegistrationInfo struct {
+
+	// Type is the registered target type.
+	Type reflect.Type
+
+	// Impl is the registered implementation type.
+	Impl reflect.Type
+
+	// Lifetime is the registered lifetime.
+	Lifetime Lifetime
+
+	// ScopeName is the scope name the registration was restricted to using [WithScopeName], if
+	// any.
+	ScopeName string
+
+	// Owned indicates whether the provider owns, and closes, the registration's instances.
+	Owned bool
+
+	// SharedValue indicates whether the registration used [AllowSharedValue] to share an
+	// unsharable type.
+	SharedValue bool
+
+	// Dependencies are the types the registration's factory is known to resolve, which are only
+	// known for the default factories used by [RegisterType] and [RegisterPointerTo].
+	Dependencies []reflect.Type
+
+	// Deprecated indicates whether the registration was marked [WithDeprecated], and Deprecation
+	// is the message it was given.
+	Deprecated  bool
+	Deprecation string
+
+	// ScopeAccess indicates whether the registration was given [WithScopeAccess], so that its
+	// factory receives the full [Scope] or [RootProvider] when resolvers are restricted.
+	ScopeAccess bool
+
+	// Imported indicates whether the registration was made by [ImportResolver], so that its value
+	// comes from another container.
+	Imported bool
+}
+
+// Registrations returns a description of each registration in the registry, ordered by type name.
+func (r Registry) Registrations() []RegistrationInfo {
+	return registrationInfos(r.registrations)
+}
+
+// Registrations returns a description of each registration the provider uses, ordered by type
+// name. For a child provider this includes the registrations it inherits from its parent.
+func (provider RootProvider) Registrations() []RegistrationInfo {
+	if !provider.initialized() {
+		return nil
+	}
+	return registrationInfos(provider.registrations.load())
+}
+
+func registrationInfos(registrations map[reflect.Type]registration) []RegistrationInfo {
+	infos := make([]RegistrationInfo, 0, len(registrations))
+	for _, typ := range sortedTypes(registrations) {
+		infos = append(infos, registrationInfo(typ, registrations[typ]))
+	}
+	return infos
+}
+
+func registrationInfo(typ reflect.Type, registration registration) RegistrationInfo {
+	return RegistrationInfo{
+		Type:         typ,
+		Impl:         registration.impl,
+		Lifetime:     registration.lifetime,
+		ScopeName:    registration.scopeName,
+		Owned:        registration.owned,
+		SharedValue:  registration.sharedValue,
+		Dependencies: slices.Clone(registration.dependencies),
+		Deprecated:   registration.deprecated,
+		Deprecation:  registration.deprecation,
+		ScopeAccess:  registration.scopeAccess,
+		Imported:     registration.source == sourceImported,
+	}
+}
@@ -0,0 +1,84 @@
+package di
+
+// A RegistrationOption configures optional behavior for a single registration.
+type RegistrationOption func(*registration)
+
+// WithOwnership indicates that the provider owns the instances produced by a registration and
+// should close them when the [Scope] or [RootProvider] holding them is closed. This is the default
+// for registrations whose instances are created by a [Factory], and it must be given explicitly
+// for instances registered with [RegisterInstance].
+func WithOwnership() RegistrationOption {
+	return func(r *registration) {
+		r.owned = true
+	}
+}
+
+// WithoutOwnership indicates that the provider does not own the instances produced by a
+// registration and must not close them. This is the default for instances registered with
+// [RegisterInstance].
+func WithoutOwnership() RegistrationOption {
+	return func(r *registration) {
+		r.owned = false
+	}
+}
+
+// WithScopeName restricts a [Scoped] registration to scopes created with [RootProvider.NewNamedScope]
+// using the given name. Resolving the registration from any other scope, including an unnamed one,
+// returns a [ScopeNameMismatch]. WithScopeName has no effect on registrations with other lifetimes.
+func WithScopeName(name string) RegistrationOption {
+	return func(r *registration) {
+		r.scopeName = name
+	}
+}
+
+// WithRefresh allows the [Singleton] value for a registration to be rebuilt with
+// [RootProvider.Refresh], e.g. for clients whose state should be reloaded without restarting the
+// process. WithRefresh has no effect on registrations with other lifetimes.
+func WithRefresh() RegistrationOption {
+	return func(r *registration) {
+		r.refreshable = true
+	}
+}
+
+// WithPriority sets the priority of a registration in the results of [ResolveAll] and in a slice
+// resolved from the registrations for its element type. Registrations are sorted by priority, lowest
+// first, and then in the order they were made, so a registration with a negative priority comes
+// before every registration made without WithPriority, whose priority is 0, and one with a positive
+// priority comes after them.
+func WithPriority(priority int) RegistrationOption {
+	return func(r *registration) {
+		r.priority = priority
+	}
+}
+
+// AllowSharedValue allows a registration to use a [Lifetime] other than [Transient] for an
+// unsharable type, such as an immutable configuration struct or a map that is never modified after
+// it is built, instead of returning an [UnsharableType]. Every resolution returns the same stored
+// value, so a struct or array is copied each time it is resolved while the contents of a map or
+// slice are shared. The registration's [RegistrationInfo] records that the check was suppressed.
+func AllowSharedValue() RegistrationOption {
+	return func(r *registration) {
+		r.sharedValue = true
+	}
+}
+
+// AllowUndisposedTransient suppresses the [UndisposedTransient] reported for a [Transient]
+// registration whose instances implement [Closer] or [ContextCloser], and the
+// [TransientCloserCreated] events for it, when the code that resolves the instances is intended to
+// close them. AllowUndisposedTransient has no effect on registrations with other lifetimes.
+func AllowUndisposedTransient() RegistrationOption {
+	return func(r *registration) {
+		r.allowUndisposed = true
+	}
+}
+
+// AllowNilInterfaceFields allows the default factory used by [RegisterType] and
+// [RegisterPointerTo] to leave an interface field of Impl, or of a struct Impl points to, nil when
+// the [Resolver] returns an untyped nil for it, e.g. for an optional dependency supplied with
+// [Scope.WithInstance]. Without it the factory returns a [NilResolution]. AllowNilInterfaceFields
+// has no effect on other registrations or on fields of other kinds.
+func AllowNilInterfaceFields() RegistrationOption {
+	return func(r *registration) {
+		r.nilInterfaceFields = true
+	}
+}
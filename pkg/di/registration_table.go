@@ -0,0 +1,68 @@
+package di
+
+import (
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// registrationTable holds the registrations used by a provider. Registrations are read without
+// locking and replaced by swapping in a new copy of the table so resolutions that are in progress
+// are not affected by a swap.
+type registrationTable struct {
+	mu      sync.Mutex
+	current atomic.Pointer[map[reflect.Type]registration]
+
+	// perResolution is set once the table contains a registration whose lifetime may use the
+	// PerResolution cache, which includes custom lifetimes, so that resolutions can skip creating
+	// the cache when there are none.
+	perResolution atomic.Bool
+}
+
+func newRegistrationTable(registrations map[reflect.Type]registration) *registrationTable {
+	if registrations == nil {
+		registrations = map[reflect.Type]registration{}
+	}
+	table := &registrationTable{}
+	table.current.Store(&registrations)
+	for _, registration := range registrations {
+		if usesResolutionCache(registration.lifetime) {
+			table.perResolution.Store(true)
+		}
+	}
+	return table
+}
+
+func (t *registrationTable) load() map[reflect.Type]registration {
+	return *t.current.Load()
+}
+
+func (t *registrationTable) get(typ reflect.Type) (registration, bool) {
+	registration, ok := t.load()[typ]
+	return registration, ok
+}
+
+// hasPerResolution reports whether the table has ever contained a PerResolution registration.
+func (t *registrationTable) hasPerResolution() bool {
+	return t.perResolution.Load()
+}
+
+// usesResolutionCache reports whether values with lifetime may use the PerResolution cache.
+func usesResolutionCache(lifetime Lifetime) bool {
+	return lifetime != Transient && lifetime != Scoped && lifetime != Singleton
+}
+
+// swap replaces the registration for typ and returns the registration it replaced, if any.
+func (t *registrationTable) swap(typ reflect.Type, registration_ registration) (registration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	registrations := maps.Clone(t.load())
+	old, ok := registrations[typ]
+	registrations[typ] = registration_
+	t.current.Store(&registrations)
+	if usesResolutionCache(registration_.lifetime) {
+		t.perResolution.Store(true)
+	}
+	return old, ok
+}
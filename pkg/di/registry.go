@@ -1,10 +1,15 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"maps"
 	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ErrNonConcreteImplementation is returned when an attempt is made to register an implementation
@@ -30,6 +35,11 @@ func (err NonConcreteImplementation) Is(target error) bool {
 	return target == ErrNonConcreteImplementation
 }
 
+// Code returns "non_concrete_implementation", the code of a [NonConcreteImplementation] in [ErrorCodes].
+func (NonConcreteImplementation) Code() string {
+	return "non_concrete_implementation"
+}
+
 // ErrInvalidImplementation is returned when an attempt is made to register an implementation type
 // for a target type is not assignable to.
 var ErrInvalidImplementation = errors.New("implementation type is not assignable to target type")
@@ -59,12 +69,95 @@ func (err InvalidImplementation) Is(target error) bool {
 	return target == ErrInvalidImplementation
 }
 
+// Code returns "invalid_implementation", the code of an [InvalidImplementation] in [ErrorCodes].
+func (InvalidImplementation) Code() string {
+	return "invalid_implementation"
+}
+
+// A PointerReceiverImplementation is an [error] indicating that an attempt was made to register an
+// implementation type for a target type that only a pointer to the implementation type is
+// assignable to, typically because the implementation's methods have pointer receivers. Calling
+// [errors.Is] with a PointerReceiverImplementation and [ErrInvalidImplementation] returns true.
+//
+// The reverse is never an error: when a type T satisfies an interface, *T satisfies it too because
+// the method set of *T includes the methods declared on T, so registering *T is always valid.
+type PointerReceiverImplementation struct {
+
+	// Type is the type that cannot be assigned to [PointerReceiverImplementation.Target].
+	Type reflect.Type
+
+	// Target is the type to which a pointer to [PointerReceiverImplementation.Type] can be
+	// assigned.
+	Target reflect.Type
+}
+
+// Error implements [error].
+func (err PointerReceiverImplementation) Error() string {
+	return fmt.Sprintf(
+		"implementation type %v is not assignable to target type %v but %v is; register %v as the implementation instead",
+		err.Type,
+		err.Target,
+		reflect.PointerTo(err.Type),
+		reflect.PointerTo(err.Type))
+}
+
+// Is indicates that a [PointerReceiverImplementation] is [ErrInvalidImplementation].
+func (err PointerReceiverImplementation) Is(target error) bool {
+	return target == ErrInvalidImplementation
+}
+
+// Code returns "pointer_receiver_implementation", the code of a [PointerReceiverImplementation] in [ErrorCodes].
+func (PointerReceiverImplementation) Code() string {
+	return "pointer_receiver_implementation"
+}
+
+// ErrPointerToInterface is returned when an attempt is made to register an implementation type
+// that is a pointer to an interface.
+var ErrPointerToInterface = errors.New("implementation type is a pointer to an interface")
+
+// A PointerToInterface is an [error] indicating that an attempt was made to register an
+// implementation type that is a pointer to an interface, or a pointer to such a pointer, e.g.
+// *io.Reader. A pointer to an interface is almost never intended; it is usually left behind by a
+// refactoring that turned a struct into an interface. Calling [errors.Is] with a
+// PointerToInterface and [ErrPointerToInterface] returns true.
+type PointerToInterface struct {
+
+	// Type is the pointer type.
+	Type reflect.Type
+
+	// Target is the target type the implementation was registered for.
+	Target reflect.Type
+
+	// Interface is the interface the pointers lead to.
+	Interface reflect.Type
+}
+
+// Error implements [error].
+func (err PointerToInterface) Error() string {
+	return fmt.Sprintf(
+		"implementation type %v for target type %v is a pointer to interface %v, which is almost never intended; register %v as the target type or use a concrete implementation type",
+		err.Type,
+		err.Target,
+		err.Interface,
+		err.Interface)
+}
+
+// Is indicates that a [PointerToInterface] is [ErrPointerToInterface].
+func (PointerToInterface) Is(target error) bool {
+	return target == ErrPointerToInterface
+}
+
+// Code returns "pointer_to_interface", the code of a [PointerToInterface] in [ErrorCodes].
+func (PointerToInterface) Code() string {
+	return "pointer_to_interface"
+}
+
 // ErrUndefinedLifetime is returned when an attempt is made to register a type with a [Lifetime]
-// whose value is not one of the defined values [Transient], [Scoped], or [Singleton].
+// that is neither one of the built-in lifetimes nor defined with [RegisterLifetime].
 var ErrUndefinedLifetime = errors.New("undefined lifetime")
 
 // An UndefinedLifetime is an [error] indicating that an attempt was made to register a type with a
-// [Lifetime] whose value is not one of the defined values [Transient], [Scoped], or [Singleton].
+// [Lifetime] that is neither one of the built-in lifetimes nor defined with [RegisterLifetime].
 // Calling [errors.Is] with an [UndefinedLifetime] and [ErrUndefinedLifetime] returns true.
 type UndefinedLifetime struct {
 
@@ -82,6 +175,11 @@ func (err UndefinedLifetime) Is(target error) bool {
 	return target == ErrUndefinedLifetime
 }
 
+// Code returns "undefined_lifetime", the code of an [UndefinedLifetime] in [ErrorCodes].
+func (UndefinedLifetime) Code() string {
+	return "undefined_lifetime"
+}
+
 // ErrUnsharableType is returned when an unsharable type is registered with a [Lifetime] other than
 // [Transient].
 var ErrUnsharableType = errors.New("unsharable type cannot be registered with non-Transient lifetime")
@@ -96,14 +194,21 @@ type UnsharableType struct {
 
 	// Lifetime is the non-transient lifetime.
 	Lifetime Lifetime
+
+	// SuggestedTarget is the pointer to Type, which can be registered with any lifetime, e.g.
+	// using [RegisterPointerTo].
+	SuggestedTarget reflect.Type
 }
 
 // Error implements [error].
 func (err UnsharableType) Error() string {
 	return fmt.Sprintf(
-		"unsharable type %v cannot be registered with non-Transient Lifetime %v",
+		"unsharable type %v cannot be registered with non-Transient Lifetime %v; "+
+			"register %v instead, e.g. using RegisterPointerTo, "+
+			"or use AllowSharedValue if sharing copies of the value is safe",
 		err.Type,
-		err.Lifetime)
+		err.Lifetime,
+		err.SuggestedTarget)
 }
 
 // Is indicates that a [UnsharableType] is [ErrUnsharableType].
@@ -111,6 +216,11 @@ func (err UnsharableType) Is(target error) bool {
 	return target == ErrUnsharableType
 }
 
+// Code returns "unsharable_type", the code of an [UnsharableType] in [ErrorCodes].
+func (UnsharableType) Code() string {
+	return "unsharable_type"
+}
+
 // ErrNoDefaultFactory is returned when an attempt is made to register an implementation type for
 // which the package cannot provide a default factory to obtain instances from.
 var ErrNoDefaultFactory = errors.New("implementation type has no default factory")
@@ -122,10 +232,20 @@ type NoDefaultFactory struct {
 
 	// Type is the type for which the package cannot provide a default factory.
 	Type reflect.Type
+
+	// ElementType is the innermost type that has no default factory, which differs from Type when
+	// Type is a pointer, e.g. unsafe.Pointer for ***unsafe.Pointer.
+	ElementType reflect.Type
 }
 
 // Error implements [error].
 func (err NoDefaultFactory) Error() string {
+	if err.ElementType != nil && err.ElementType != err.Type {
+		return fmt.Sprintf(
+			"implementation type %v has no default factory because element type %v has none",
+			err.Type,
+			err.ElementType)
+	}
 	return fmt.Sprintf("implementation type %v has no default factory", err.Type)
 }
 
@@ -134,6 +254,11 @@ func (NoDefaultFactory) Is(target error) bool {
 	return target == ErrNoDefaultFactory
 }
 
+// Code returns "no_default_factory", the code of a [NoDefaultFactory] in [ErrorCodes].
+func (NoDefaultFactory) Code() string {
+	return "no_default_factory"
+}
+
 // ErrNilFactory is returned when an attempt is made to register a nil factory.
 var ErrNilFactory = errors.New("factory cannot be nil")
 
@@ -143,40 +268,205 @@ type Registry struct {
 	registrations map[reflect.Type]registration
 }
 
-func (r Registry) BuildRootProvider() (RootProvider, error) {
+// BuildRootProvider creates a [RootProvider] that resolves values using the registrations in the
+// registry. Registrations added to the registry afterwards do not affect the provider. The
+// provider's optional behavior is configured by opts; see [ProviderOption]. BuildRootProvider
+// returns a [ConflictingOptions] if opts conflict with each other, an [InvalidTypeRewrite] or a
+// [TypeRewriteCycle] if the rewrites given to [WithTypeRewrite] are invalid, an
+// [InvalidHostedService] or a [HostedServiceCycle] if the registry's hosted services cannot be
+// ordered, and a [VerificationFailed] if the provider is built [WithStrictDisposal] and the
+// registry has problems it reports.
+func (r Registry) BuildRootProvider(opts ...ProviderOption) (RootProvider, error) {
+	options := providerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.conflicts) > 0 {
+		return RootProvider{}, ConflictingOptions{
+			Conflicts: options.conflicts,
+		}
+	}
+	if err := validateRewrites(options.rewrites, r.registrations); err != nil {
+		return RootProvider{}, err
+	}
+	if options.strictDisposal {
+		if problems := findUndisposedTransients(r.registrations); len(problems) > 0 {
+			return RootProvider{}, VerificationFailed{
+				Problems: problems,
+			}
+		}
+	}
+	hosted, err := hostedServiceOrder(r.registrations)
+	if err != nil {
+		return RootProvider{}, err
+	}
+	provider := newRootProvider(maps.Clone(r.registrations), &options)
+	provider.hosted.order = hosted
+	return provider, nil
+}
+
+// newRootProvider creates a provider that resolves values using registrations, which the provider
+// takes ownership of.
+func newRootProvider(registrations map[reflect.Type]registration, options *providerOptions) RootProvider {
+	lifetimes := map[Lifetime]int{}
+	for _, registration := range registrations {
+		if !registration.inherited {
+			lifetimes[registration.lifetime]++
+		}
+	}
 	return RootProvider{
-		registrations: maps.Clone(r.registrations),
-		singletons:    &instanceMap{},
-	}, nil
+		id:            strconv.FormatUint(providerIDs.Add(1), 10),
+		scopeIDs:      &atomic.Uint64{},
+		options:       options,
+		registrations: newRegistrationTable(registrations),
+		singletons:    newInstanceMap(options.newInstanceStore, lifetimes[Singleton]),
+		cleanups:      &deferredCleanups{},
+		closeState:    &closeState{},
+		abandoned:     &abandonedClosers{},
+		frozen:        &atomic.Bool{},
+		skippedFields: &skippedFields{},
+		autoResolved:  &autoRegistrations{},
+		leakedScopes:  &leakedScopes{},
+		openScopes:    &openScopes{},
+		counts:        &resolutionCounts{},
+
+		transientClosers: &transientClosers{},
+		transients:       &typeSet{},
+		fallbackValues:   &sync.Map{},
+
+		abandonedFactories: &abandonedClosers{},
+		inFlight:           &resolutionsInFlight{},
+		timedOutFactories:  newTimedOutFactories(options.failFastFactoryTimeouts),
+		scopePool:          newScopePool(options),
+		metrics:            &metricsSinks{},
+		hosted:             &hostedServices{},
+		idleScopes:         newIdleSweeper(),
+
+		expectedScopedInstances: lifetimes[Scoped],
+	}
 }
 
 // RegisterType is a shorthand for calling [RegisterFactory] using the result of calling
-// [GetDefaultFactory] for the [Impl] type.
-func RegisterType[Target any, Impl any](registry Registry, lifetime Lifetime) (Registry, error) {
-	factory, err := GetDefaultFactory[Impl]()
+// [GetDefaultFactory] for the [Impl] type. The default factory honors [AllowNilInterfaceFields].
+func RegisterType[Target any, Impl any](
+	registry Registry,
+	lifetime Lifetime,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	target := reflect.TypeFor[Target]()
+	return registerType[Impl](registry, target, target, lifetime, opts)
+}
+
+// registerType registers the default factory for Impl under key as an implementation of target.
+func registerType[Impl any](
+	registry Registry,
+	key reflect.Type,
+	target reflect.Type,
+	lifetime Lifetime,
+	opts []RegistrationOption,
+) (Registry, error) {
+	return registerDefaultFactory(registry, key, target, reflect.TypeFor[Impl](), lifetime, opts)
+}
+
+// registerDefaultFactory registers the default factory for impl under key as an implementation of
+// target.
+func registerDefaultFactory(
+	registry Registry,
+	key reflect.Type,
+	target reflect.Type,
+	impl reflect.Type,
+	lifetime Lifetime,
+	opts []RegistrationOption,
+) (Registry, error) {
+	// Check for pointers to interfaces first since they have no default factories either.
+	if err := validatePointerToInterface(target, impl); err != nil {
+		return registry, err
+	}
+	var options registration
+	for _, opt := range opts {
+		opt(&options)
+	}
+	factory, err := getDefaultFactory(impl, defaultFactoryOptions{
+		nilInterfaceFields: options.nilInterfaceFields,
+	})
+	if err != nil {
+		return registry, err
+	}
+	registry, err = registerFactoryFunc(registry, key, target, impl, lifetime, factory, opts)
 	if err != nil {
 		return registry, err
 	}
-	return RegisterFactory[Target](registry, lifetime, factory)
+	// The dependencies of the default factory are known so record them for verification.
+	registration := registry.registrations[key]
+	registration.dependencies = defaultFactoryDependencies(impl)
+	registration.source = sourceDefault
+	registry.registrations[key] = registration
+	return registry, nil
+}
+
+// RegisterPointerTo registers *Impl as the implementation of Target using [RegisterType], which
+// is the usual way to give an unsharable Impl, such as a struct, a lifetime other than [Transient].
+// Instances are pointers to values created by the default factory for Impl.
+func RegisterPointerTo[Target any, Impl any](
+	registry Registry,
+	lifetime Lifetime,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	return RegisterType[Target, *Impl](registry, lifetime, opts...)
 }
 
 // A Factory is a function that makes instances of T using a Resolver to initialize dependencies.
 type Factory[T any] func(Resolver) (T, error)
 
+// RegisterFactory registers a [Factory] that provides instances of Impl when Target is resolved.
+// Instances produced by the factory are owned by the provider unless [WithoutOwnership] is given.
 func RegisterFactory[Target any, Impl any](
 	registry Registry,
 	lifetime Lifetime,
 	factory Factory[Impl],
+	opts ...RegistrationOption,
 ) (Registry, error) {
-
 	target := reflect.TypeFor[Target]()
-	impl := reflect.TypeFor[Impl]()
+	return registerFactory(registry, target, target, lifetime, factory, opts)
+}
+
+// registerFactory registers factory under key as an implementation of target.
+func registerFactory[Impl any](
+	registry Registry,
+	key reflect.Type,
+	target reflect.Type,
+	lifetime Lifetime,
+	factory Factory[Impl],
+	opts []RegistrationOption,
+) (Registry, error) {
+	var factory_ factoryFunc
+	if factory != nil {
+		factory_ = func(resolver Resolver) (any, error) {
+			return factory(resolver)
+		}
+	}
+	return registerFactoryFunc(registry, key, target, reflect.TypeFor[Impl](), lifetime, factory_, opts)
+}
+
+// registerFactoryFunc registers factory, which makes instances of impl, under key as an
+// implementation of target.
+func registerFactoryFunc(
+	registry Registry,
+	key reflect.Type,
+	target reflect.Type,
+	impl reflect.Type,
+	lifetime Lifetime,
+	factory factoryFunc,
+	opts []RegistrationOption,
+) (Registry, error) {
 
 	if err := validateRegistrationTypes(target, impl); err != nil {
 		return registry, err
 	}
 
-	if err := validateLifetime(impl, lifetime); err != nil {
+	registration_ := newRegistration(lifetime, impl, factory, true, opts)
+
+	if err := validateLifetime(impl, lifetime, registration_.sharedValue || isFunc(impl)); err != nil {
 		return registry, err
 	}
 
@@ -184,16 +474,59 @@ func RegisterFactory[Target any, Impl any](
 		return registry, ErrNilFactory
 	}
 
-	return addRegistration(registry, target, registration{
-		lifetime: lifetime,
-		factory: func(resolver Resolver) (any, error) {
-			return factory(resolver)
+	return addRegistration(registry, key, registration_), nil
+}
+
+// RegisterInstance registers an existing instance of Impl as the [Singleton] value for Target.
+// Since the instance was created outside of the provider it is not owned by the provider and will
+// not be closed when the provider is closed unless [WithOwnership] is given.
+func RegisterInstance[Target any, Impl any](
+	registry Registry,
+	instance Impl,
+	opts ...RegistrationOption,
+) (Registry, error) {
+	target := reflect.TypeFor[Target]()
+	return registerInstance(registry, target, target, instance, opts)
+}
+
+// registerInstance registers instance under key as the Singleton value for target.
+func registerInstance[Impl any](
+	registry Registry,
+	key reflect.Type,
+	target reflect.Type,
+	instance Impl,
+	opts []RegistrationOption,
+) (Registry, error) {
+
+	impl := reflect.TypeFor[Impl]()
+
+	if err := validateRegistrationTypes(target, impl); err != nil {
+		return registry, err
+	}
+
+	registration_ := newRegistration(
+		Singleton,
+		impl,
+		func(Resolver) (any, error) {
+			return instance, nil
 		},
-	}), nil
+		false,
+		opts)
+	registration_.source = sourceInstance
+
+	if err := validateLifetime(impl, Singleton, registration_.sharedValue); err != nil {
+		return registry, err
+	}
+
+	return addRegistration(registry, key, registration_), nil
 }
 
 func validateRegistrationTypes(target reflect.Type, impl reflect.Type) error {
 
+	if err := validatePointerToInterface(target, impl); err != nil {
+		return err
+	}
+
 	if !isConcrete(impl) {
 		return NonConcreteImplementation{
 			Type: impl,
@@ -201,6 +534,12 @@ func validateRegistrationTypes(target reflect.Type, impl reflect.Type) error {
 	}
 
 	if !impl.AssignableTo(target) {
+		if reflect.PointerTo(impl).AssignableTo(target) {
+			return PointerReceiverImplementation{
+				Target: target,
+				Type:   impl,
+			}
+		}
 		return InvalidImplementation{
 			Target: target,
 			Type:   impl,
@@ -210,28 +549,52 @@ func validateRegistrationTypes(target reflect.Type, impl reflect.Type) error {
 	return nil
 }
 
-func validateLifetime(impl reflect.Type, lifetime Lifetime) error {
+func validateLifetime(impl reflect.Type, lifetime Lifetime, sharedValue bool) error {
 
-	if _, ok := knownLifetimes[lifetime]; !ok {
+	if _, ok := lookupLifetime(lifetime); !ok {
 		return UndefinedLifetime{
 			Value: lifetime,
 		}
 	}
 
-	if lifetime != Transient && !isSharable(impl) {
+	if lifetime != Transient && !sharedValue && !isSharable(impl) {
 		return UnsharableType{
-			Type:     impl,
-			Lifetime: lifetime,
+			Type:            impl,
+			Lifetime:        lifetime,
+			SuggestedTarget: reflect.PointerTo(impl),
 		}
 	}
 
 	return nil
 }
 
+// validatePointerToInterface returns a PointerToInterface if impl is a chain of pointers ending in
+// an interface.
+func validatePointerToInterface(target reflect.Type, impl reflect.Type) error {
+	elem := impl
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem == impl || elem.Kind() != reflect.Interface {
+		return nil
+	}
+	return PointerToInterface{
+		Type:      impl,
+		Target:    target,
+		Interface: elem,
+	}
+}
+
 func isConcrete(typ reflect.Type) bool {
 	return typ.Kind() != reflect.Interface
 }
 
+// isFunc reports whether typ is a function type. Function values from factories are sharable since
+// the factory decides what they close over.
+func isFunc(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Func
+}
+
 func isSharable(typ reflect.Type) bool {
 	kind := typ.Kind()
 	if kind == reflect.Pointer {
@@ -245,9 +608,132 @@ func isSharable(typ reflect.Type) bool {
 
 type factoryFunc func(Resolver) (any, error)
 
+// factorySource describes where a registration's factory came from.
+type factorySource int
+
+const (
+	// sourceFactory is a factory given to RegisterFactory.
+	sourceFactory factorySource = iota
+
+	// sourceDefault is the default factory used by RegisterType.
+	sourceDefault
+
+	// sourceInstance is a factory returning the instance given to RegisterInstance.
+	sourceInstance
+
+	// sourceTargetAware is a factory given to RegisterTargetAwareFactory.
+	sourceTargetAware
+
+	// sourceImported is a factory requesting the value from the resolver given to ImportResolver.
+	sourceImported
+)
+
+func (source factorySource) String() string {
+	switch source {
+	case sourceDefault:
+		return "default"
+	case sourceInstance:
+		return "instance"
+	case sourceTargetAware:
+		return "target-aware"
+	case sourceImported:
+		return "imported"
+	}
+	return "factory"
+}
+
 type registration struct {
-	lifetime Lifetime
-	factory  factoryFunc
+	lifetime  Lifetime
+	impl      reflect.Type
+	factory   factoryFunc
+	owned     bool
+	scopeName string
+
+	// sharedValue is true for registrations that may share an unsharable type.
+	sharedValue bool
+
+	// ttl is the age after which Singleton and Scoped instances are replaced, if positive.
+	ttl time.Duration
+
+	// expiryGrace is the delay before closing an instance that was replaced because it expired.
+	expiryGrace time.Duration
+
+	// backgroundRefresh is true for registrations whose instances are replaced before they expire.
+	backgroundRefresh bool
+
+	// refreshable is true for Singleton registrations that can be rebuilt with Refresh.
+	refreshable bool
+
+	// dependencies are the types the factory resolves, if they are known.
+	dependencies []reflect.Type
+
+	// inherited is true for the Singleton registrations a child provider inherits from its parent,
+	// whose factories resolve the parent's instance rather than constructing one.
+	inherited bool
+
+	// site is the file and line of the call that registered the type, if it is known.
+	site string
+
+	// source describes where the factory came from.
+	source factorySource
+
+	// nilInterfaceFields indicates that the default factory used by RegisterType may leave
+	// interface fields nil.
+	nilInterfaceFields bool
+
+	// allowUndisposed is true for Transient registrations whose instances are closed by the code
+	// that resolves them.
+	allowUndisposed bool
+
+	// factoryTimeout limits how long the factory may run, if positive.
+	factoryTimeout time.Duration
+
+	// contextFactory is the factory of a ContextFactory registration, which factory calls with the
+	// context of the resolution.
+	contextFactory func(context.Context, Resolver) (any, error)
+
+	// priority and order sort the results of ResolveAll: order is the position of the registration
+	// among all registrations, which breaks ties between registrations with the same priority.
+	priority int
+	order    uint64
+
+	// deprecated is true for registrations marked WithDeprecated, and deprecation is the message
+	// they were given.
+	deprecated  bool
+	deprecation string
+
+	// hosted is true for registrations marked as hosted services, and startAfter holds the hosted
+	// services they must start after.
+	hosted     bool
+	startAfter []reflect.Type
+
+	// scopeAccess is true if the factory receives the full scope or provider when the provider
+	// restricts resolvers.
+	scopeAccess bool
+}
+
+// registrationOrder is the source of the order of registrations.
+var registrationOrder atomic.Uint64
+
+func newRegistration(
+	lifetime Lifetime,
+	impl reflect.Type,
+	factory factoryFunc,
+	owned bool,
+	opts []RegistrationOption,
+) registration {
+	registration_ := registration{
+		lifetime: lifetime,
+		impl:     impl,
+		factory:  factory,
+		owned:    owned,
+		site:     registrationSite(),
+		order:    registrationOrder.Add(1),
+	}
+	for _, opt := range opts {
+		opt(&registration_)
+	}
+	return registration_
 }
 
 func addRegistration(registry Registry, target reflect.Type, registration_ registration) Registry {
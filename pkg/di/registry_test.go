@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegistry(t *testing.T) {
@@ -44,6 +46,88 @@ func TestRegistry(t *testing.T) {
 			}
 		})
 
+		t.Run("returns PointerReceiverImplementation when only *Impl implements an interface Target", func(t *testing.T) {
+			_, err := RegisterType[forkGreeter, englishGreeter](Registry{}, Transient)
+			if !errors.Is(err, ErrInvalidImplementation) {
+				t.Fatalf("expected %q; got %q", ErrInvalidImplementation, err)
+			}
+			var pointerReceiver PointerReceiverImplementation
+			if !errors.As(err, &pointerReceiver) {
+				t.Fatalf("expected %v to be %T", err, pointerReceiver)
+			}
+			if typ := reflect.TypeFor[forkGreeter](); pointerReceiver.Target != typ {
+				t.Errorf("expected err.Target to be %v; got %v", typ, pointerReceiver.Target)
+			}
+			if typ := reflect.TypeFor[englishGreeter](); pointerReceiver.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, pointerReceiver.Type)
+			}
+			if msg := err.Error(); !strings.Contains(msg, "register *di.englishGreeter") {
+				t.Errorf("expected the error to suggest registering *di.englishGreeter; got %q", msg)
+			}
+		})
+
+		t.Run("returns PointerReceiverImplementation when only *Impl can be assigned to a non-interface Target", func(t *testing.T) {
+			_, err := RegisterType[*widget, widget](Registry{}, Transient)
+			var pointerReceiver PointerReceiverImplementation
+			if !errors.As(err, &pointerReceiver) {
+				t.Fatalf("expected %v to be %T", err, pointerReceiver)
+			}
+			if typ := reflect.TypeFor[widget](); pointerReceiver.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, pointerReceiver.Type)
+			}
+			if !errors.Is(err, ErrInvalidImplementation) {
+				t.Fatalf("expected %q; got %q", ErrInvalidImplementation, err)
+			}
+		})
+
+		t.Run("returns PointerToInterface when Impl is a pointer to an interface", func(t *testing.T) {
+			testCases := []struct {
+				name     string
+				register func() (Registry, error)
+				impl     reflect.Type
+			}{
+				{
+					name: "single pointer",
+					register: func() (Registry, error) {
+						return RegisterType[*io.Reader, *io.Reader](Registry{}, Transient)
+					},
+					impl: reflect.TypeFor[*io.Reader](),
+				},
+				{
+					name: "multiple pointers",
+					register: func() (Registry, error) {
+						return RegisterType[any, **io.Reader](Registry{}, Transient)
+					},
+					impl: reflect.TypeFor[**io.Reader](),
+				},
+			}
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					_, err := tc.register()
+					var pointerToInterface PointerToInterface
+					if !errors.As(err, &pointerToInterface) || !errors.Is(err, ErrPointerToInterface) {
+						t.Fatalf("expected %v to be %T", err, pointerToInterface)
+					}
+					if pointerToInterface.Type != tc.impl {
+						t.Errorf("expected err.Type to be %v; got %v", tc.impl, pointerToInterface.Type)
+					}
+					if typ := reflect.TypeFor[io.Reader](); pointerToInterface.Interface != typ {
+						t.Errorf("expected err.Interface to be %v; got %v", typ, pointerToInterface.Interface)
+					}
+					if msg := err.Error(); !strings.Contains(msg, "register io.Reader as the target type") {
+						t.Errorf("expected the error to suggest registering io.Reader; got %q", msg)
+					}
+				})
+			}
+		})
+
+		t.Run("accepts *Impl when Impl implements Target with value receivers", func(t *testing.T) {
+			_, err := RegisterType[fmt.Stringer, *time.Duration](Registry{}, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+		})
+
 		t.Run("returns UndefinedLifetime when lifetime is undefined", func(t *testing.T) {
 			undefinedValue := Lifetime(13)
 			_, err := RegisterType[interface{}, struct{}](Registry{}, undefinedValue)
@@ -163,6 +247,12 @@ func TestRegistry(t *testing.T) {
 					if lifetime := tt.expectedErr.Lifetime; unsharableType.Lifetime != lifetime {
 						t.Errorf("expected err.Lifetime to be %v; got %v", lifetime, unsharableType.Lifetime)
 					}
+					if typ := reflect.PointerTo(tt.expectedErr.Type); unsharableType.SuggestedTarget != typ {
+						t.Errorf("expected err.SuggestedTarget to be %v; got %v", typ, unsharableType.SuggestedTarget)
+					}
+					if !strings.Contains(err.Error(), "register "+unsharableType.SuggestedTarget.String()+" instead") {
+						t.Errorf("expected the message to suggest %v; got %q", unsharableType.SuggestedTarget, err)
+					}
 				})
 			}
 		})
@@ -210,8 +300,135 @@ func TestRegistry(t *testing.T) {
 		})
 	})
 
+	t.Run("RegisterPointerTo", func(t *testing.T) {
+
+		type counter struct {
+			count int
+		}
+
+		t.Run("registers a pointer to the implementation", func(t *testing.T) {
+			registry, err := RegisterPointerTo[*counter, counter](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterPointerTo: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			first, err := Resolve[*counter](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if first == nil || first.count != 0 {
+				t.Fatalf("expected a non-nil pointer to a zero value; got %v", first)
+			}
+			second, err := Resolve[*counter](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if first != second {
+				t.Fatalf("expected the scope to share the instance")
+			}
+		})
+
+		t.Run("returns InvalidImplementation when the pointer cannot be assigned to Target", func(t *testing.T) {
+			_, err := RegisterPointerTo[io.Reader, counter](Registry{}, Scoped)
+			var invalidImplementation InvalidImplementation
+			if !errors.As(err, &invalidImplementation) {
+				t.Fatalf("expected %v to be %T", err, invalidImplementation)
+			}
+			if typ := reflect.TypeFor[*counter](); invalidImplementation.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, invalidImplementation.Type)
+			}
+		})
+	})
+
+	t.Run("AllowNilInterfaceFields", func(t *testing.T) {
+
+		type service struct {
+			Reader io.Reader
+		}
+
+		// The resolver returns an untyped nil for the field, which the provider's own resolvers
+		// never do, so the registered factory is given one directly.
+		newResolver := func(t *testing.T, opts ...RegistrationOption) Resolver {
+			registry, err := RegisterPointerTo[*service, service](Registry{}, Transient, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterPointerTo: %v", err)
+			}
+			factory := registry.registrations[reflect.TypeFor[*service]()].factory
+			return ResolverFunc(func(typ reflect.Type) (any, error) {
+				if typ == reflect.TypeFor[*service]() {
+					return factory(ResolverFunc(func(reflect.Type) (any, error) {
+						return nil, nil
+					}))
+				}
+				return nil, UnknownType{
+					Type: typ,
+				}
+			})
+		}
+
+		t.Run("leaves interface fields resolved as nil unset", func(t *testing.T) {
+			svc, err := Resolve[*service](newResolver(t, AllowNilInterfaceFields()))
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if svc.Reader != nil {
+				t.Fatalf("expected the field to be nil; got %v", svc.Reader)
+			}
+		})
+
+		t.Run("returns NilResolution without the option", func(t *testing.T) {
+			_, err := Resolve[*service](newResolver(t))
+			if !errors.Is(err, ErrNilResolution) {
+				t.Fatalf("expected %v to be %v", err, ErrNilResolution)
+			}
+		})
+	})
+
 	t.Run("RegisterFactory", func(t *testing.T) {
 
+		t.Run("returns PointerToInterface when Impl is a pointer to an interface", func(t *testing.T) {
+			testCases := []struct {
+				name     string
+				register func() (Registry, error)
+				impl     reflect.Type
+			}{
+				{
+					name: "single pointer",
+					register: func() (Registry, error) {
+						return RegisterFactory[*io.Reader](Registry{}, Transient, func(Resolver) (*io.Reader, error) {
+							return nil, nil
+						})
+					},
+					impl: reflect.TypeFor[*io.Reader](),
+				},
+				{
+					name: "multiple pointers",
+					register: func() (Registry, error) {
+						return RegisterFactory[any](Registry{}, Transient, func(Resolver) (**io.Reader, error) {
+							return nil, nil
+						})
+					},
+					impl: reflect.TypeFor[**io.Reader](),
+				},
+			}
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					_, err := tc.register()
+					var pointerToInterface PointerToInterface
+					if !errors.As(err, &pointerToInterface) || !errors.Is(err, ErrPointerToInterface) {
+						t.Fatalf("expected %v to be %T", err, pointerToInterface)
+					}
+					if pointerToInterface.Type != tc.impl {
+						t.Errorf("expected err.Type to be %v; got %v", tc.impl, pointerToInterface.Type)
+					}
+				})
+			}
+		})
+
 		t.Run("returns NonConcreteImplementation when Impl is an interface", func(t *testing.T) {
 			_, err := RegisterFactory[io.Reader](Registry{}, Transient, func(r Resolver) (io.ReadWriter, error) {
 				return bytes.NewBuffer([]byte{}), nil
@@ -294,6 +511,18 @@ func TestRegistry(t *testing.T) {
 						Lifetime: Singleton,
 					},
 				},
+				{
+					name: "per-resolution struct",
+					fn: func() (Registry, error) {
+						return RegisterFactory[interface{}](Registry{}, PerResolution, func(Resolver) (struct{}, error) {
+							return struct{}{}, nil
+						})
+					},
+					expectedErr: UnsharableType{
+						Type:     reflect.TypeFor[struct{}](),
+						Lifetime: PerResolution,
+					},
+				},
 				{
 					name: "scoped array",
 					fn: func() (Registry, error) {
@@ -511,4 +740,116 @@ func TestRegistry(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("RegisterInstance", func(t *testing.T) {
+
+		t.Run("returns InvalidImplementation when Impl cannot be assigned to Target", func(t *testing.T) {
+			_, err := RegisterInstance[io.Reader](Registry{}, &struct{}{})
+			if !errors.Is(err, ErrInvalidImplementation) {
+				t.Fatalf("expected %q; got %q", ErrInvalidImplementation, err)
+			}
+		})
+
+		t.Run("returns UnsharableType when Impl is unsharable", func(t *testing.T) {
+			_, err := RegisterInstance[interface{}](Registry{}, struct{}{})
+			if !errors.Is(err, ErrUnsharableType) {
+				t.Fatalf("expected %q; got %q", ErrUnsharableType, err)
+			}
+			var unsharableType UnsharableType
+			if !errors.As(err, &unsharableType) {
+				t.Fatalf("expected %v to be %T", err, unsharableType)
+			}
+			if unsharableType.Lifetime != Singleton {
+				t.Errorf("expected err.Lifetime to be %v; got %v", Singleton, unsharableType.Lifetime)
+			}
+		})
+
+		t.Run("resolves the registered instance", func(t *testing.T) {
+			expected := bytes.NewBuffer([]byte{})
+			registry, err := RegisterInstance[io.Reader](Registry{}, expected)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterInstance: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			actual, err := provider.Resolve(reflect.TypeFor[io.Reader]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if actual != expected {
+				t.Fatalf("expected %p; got %p", expected, actual)
+			}
+		})
+	})
+
+	t.Run("AllowSharedValue", func(t *testing.T) {
+
+		type config struct {
+			Name string
+		}
+
+		t.Run("allows unsharable types to be shared", func(t *testing.T) {
+			routes := map[string]string{"/": "index"}
+			registry, err := RegisterFactory[map[string]string](Registry{}, Singleton, func(Resolver) (map[string]string, error) {
+				return routes, nil
+			}, AllowSharedValue())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = RegisterInstance[config](registry, config{Name: "app"}, AllowSharedValue())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterInstance: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			first, err := Resolve[map[string]string](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			first["/about"] = "about"
+			second, err := Resolve[map[string]string](provider.NewScope())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if len(second) != 2 {
+				t.Fatalf("expected every resolution to return the stored map; got %v", second)
+			}
+			if value, err := Resolve[config](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			} else if value.Name != "app" {
+				t.Fatalf("expected the stored value; got %v", value)
+			}
+		})
+
+		t.Run("is recorded in RegistrationInfo", func(t *testing.T) {
+			registry, err := RegisterInstance[config](Registry{}, config{}, AllowSharedValue())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterInstance: %v", err)
+			}
+			registry, err = RegisterType[*mockCloser, *mockCloser](registry, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			infos := registry.Registrations()
+			if len(infos) != 2 {
+				t.Fatalf("expected 2 registrations; got %v", infos)
+			}
+			for _, info := range infos {
+				if expected := info.Type == reflect.TypeFor[config](); info.SharedValue != expected {
+					t.Errorf("expected SharedValue for %v to be %v", info.Type, expected)
+				}
+			}
+		})
+
+		t.Run("is suggested by UnsharableType", func(t *testing.T) {
+			_, err := RegisterInstance[config](Registry{}, config{})
+			if err == nil || !strings.Contains(err.Error(), "AllowSharedValue") {
+				t.Fatalf("expected %v to mention AllowSharedValue", err)
+			}
+		})
+	})
 }
@@ -0,0 +1,179 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrNotTransient is returned when an attempt is made to resolve a releasable instance of a type
+// that is not registered as [Transient].
+var ErrNotTransient = errors.New("type is not registered as transient")
+
+// A NotTransient is an [error] indicating that an attempt was made to resolve a releasable instance
+// of a type that is not registered as [Transient]. Calling [errors.Is] with a NotTransient and
+// [ErrNotTransient] returns true.
+type NotTransient struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Lifetime is the lifetime the type is registered with.
+	Lifetime Lifetime
+}
+
+// Error implements [error].
+func (err NotTransient) Error() string {
+	return fmt.Sprintf("type %v is registered as %v, not as transient", err.Type, err.Lifetime)
+}
+
+// Is indicates that a [NotTransient] is [ErrNotTransient].
+func (NotTransient) Is(target error) bool {
+	return target == ErrNotTransient
+}
+
+// Code returns "not_transient", the code of a [NotTransient] in [ErrorCodes].
+func (NotTransient) Code() string {
+	return "not_transient"
+}
+
+// ResolveReleasable obtains a new [Transient] instance of T from resolver along with a function
+// that releases it. Releasing the instance closes it if it implements [ContextCloser] or [Closer]
+// and runs the cleanups its factory deferred, in the reverse of the order they were deferred.
+// Releasing an instance more than once has no effect.
+//
+// When resolver is a [Scope] or [RootProvider] the cleanups the factory defers are held for the
+// instance instead of being added to the resolver, and an instance that is never released is
+// released when the resolver is closed instead. An instance supplied by [Scope.WithInstance] is not
+// owned by the scope so releasing it does not close it.
+//
+// ResolveReleasable returns a [NotTransient] if T is registered with another lifetime since its
+// instances are shared. The release function is never nil, even when an error is returned, and
+// runs any cleanups the factory deferred before it failed.
+func ResolveReleasable[T any](resolver Resolver) (T, func(context.Context) error, error) {
+	var zero T
+	typ := reflect.TypeFor[T]()
+	release := &releaseTracker{
+		typ: typ,
+	}
+	var cleanups *deferredCleanups
+	owned := true
+	switch r := unrestricted(resolver).(type) {
+	case Scope:
+		if err := checkTransient(r.root, typ); err != nil {
+			return zero, release.run, err
+		}
+		if r.initialized() {
+			if err := r.acquireState(); err != nil {
+				return zero, release.run, err
+			}
+			defer r.releaseState()
+			cleanups = &r.state.cleanups
+			_, overridden := r.state.overrides.peek(typ)
+			owned = !overridden
+		}
+		r.release = release
+		resolver = r
+	case RootProvider:
+		if err := checkTransient(r, typ); err != nil {
+			return zero, release.run, err
+		}
+		if r.initialized() {
+			cleanups = r.cleanups
+		}
+		r.release = release
+		resolver = r
+	}
+
+	value, err := Resolve[T](resolver)
+	if err == nil && owned {
+		release.value = value
+	}
+	if cleanups != nil {
+		cleanups.addRelease(release)
+	}
+	return value, func(ctx context.Context) error {
+		if cleanups != nil {
+			cleanups.removeRelease(release)
+		}
+		return release.run(ctx)
+	}, err
+}
+
+// checkTransient returns a [NotTransient] if typ is registered with provider with a lifetime other
+// than [Transient].
+func checkTransient(provider RootProvider, typ reflect.Type) error {
+	if !provider.initialized() {
+		return nil
+	}
+	if registration, ok := provider.registrations.get(typ); ok && registration.lifetime != Transient {
+		return NotTransient{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+		}
+	}
+	return nil
+}
+
+// releaseTracker holds an instance resolved with ResolveReleasable and the cleanups its factory
+// deferred until the instance is released.
+type releaseTracker struct {
+	typ      reflect.Type
+	mu       sync.Mutex
+	value    any
+	cleanups []func(context.Context) error
+	released bool
+}
+
+// add holds a cleanup deferred by the instance's factory.
+func (r *releaseTracker) add(cleanup func(context.Context) error) {
+	if cleanup == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanups = append(r.cleanups, cleanup)
+}
+
+// run closes the instance and runs its cleanups the first time it is called.
+func (r *releaseTracker) run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.released {
+		r.mu.Unlock()
+		return nil
+	}
+	r.released = true
+	value, cleanups := r.value, r.cleanups
+	r.value, r.cleanups = nil, nil
+	r.mu.Unlock()
+
+	var errs []error
+	switch closer := value.(type) {
+	case ContextCloser:
+		if err := closer.Close(ctx); err != nil {
+			errs = append(errs, CloserError{
+				Type: r.typ,
+				Err:  err,
+			})
+		}
+	case Closer:
+		if err := closer.Close(); err != nil {
+			errs = append(errs, CloserError{
+				Type: r.typ,
+				Err:  err,
+			})
+		}
+	}
+	for _, cleanup := range slices.Backward(cleanups) {
+		if err := cleanup(ctx); err != nil {
+			errs = append(errs, DeferredCleanupError{
+				Type: r.typ,
+				Err:  err,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
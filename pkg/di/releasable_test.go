@@ -0,0 +1,171 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveReleasable(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, cleanups *int) RootProvider {
+		registry, err := RegisterFactory[*mockCloser](Registry{}, lifetime, func(resolver Resolver) (*mockCloser, error) {
+			if scope, ok := resolver.(Scope); ok {
+				scope.Defer(func(context.Context) error {
+					*cleanups++
+					return nil
+				})
+			}
+			return &mockCloser{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("release closes the instance and runs its deferred cleanups", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope()
+		closer, release, err := ResolveReleasable[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		if closer.closed || cleanups != 0 {
+			t.Fatalf("expected the instance not to be released before release is called")
+		}
+		if err := release(context.Background()); err != nil {
+			t.Fatalf("unexpected error from release: %v", err)
+		}
+		if !closer.closed {
+			t.Fatalf("expected release to close the instance")
+		}
+		if cleanups != 1 {
+			t.Fatalf("expected release to run 1 deferred cleanup; got %d", cleanups)
+		}
+	})
+
+	t.Run("releasing twice is a no-op", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope()
+		_, release, err := ResolveReleasable[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		_ = release(context.Background())
+		if err := release(context.Background()); err != nil {
+			t.Fatalf("unexpected error from second release: %v", err)
+		}
+		if cleanups != 1 {
+			t.Fatalf("expected the deferred cleanup to run once; got %d", cleanups)
+		}
+	})
+
+	t.Run("closing the scope does not release a released instance again", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope()
+		closer, release, err := ResolveReleasable[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		_ = release(context.Background())
+		closer.closed = false
+		if err := scope.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if closer.closed || cleanups != 1 {
+			t.Fatalf("expected closing the scope not to release the instance again")
+		}
+	})
+
+	t.Run("closing the scope releases an instance that was not released", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope()
+		closer, _, err := ResolveReleasable[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		if err := scope.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if !closer.closed || cleanups != 1 {
+			t.Fatalf("expected closing the scope to release the instance")
+		}
+	})
+
+	t.Run("does not close instances supplied with WithInstance", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope()
+		instance := &mockCloser{}
+		if err := WithInstance(scope, instance); err != nil {
+			t.Fatalf("unexpected error from WithInstance: %v", err)
+		}
+		closer, release, err := ResolveReleasable[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		if closer != instance {
+			t.Fatalf("expected the override to be resolved")
+		}
+		_ = release(context.Background())
+		if instance.closed {
+			t.Fatalf("expected release not to close the override")
+		}
+	})
+
+	t.Run("does not record a failed resolution as resolving the type", func(t *testing.T) {
+		var cleanups int
+		scope := newProvider(t, Transient, &cleanups).NewScope(WithResolutionBudget(1))
+		_, _ = Resolve[string](scope)
+		if _, _, err := ResolveReleasable[*mockCloser](scope); !errors.Is(err, ErrResolutionBudgetExceeded) {
+			t.Fatalf("expected ResolveReleasable to exceed the budget; got %v", err)
+		}
+		if err := WithInstance(scope, &mockCloser{}); err != nil {
+			t.Fatalf("unexpected error from WithInstance: %v", err)
+		}
+	})
+
+	t.Run("wraps closer errors in CloserError", func(t *testing.T) {
+		expected := errors.New("expected error")
+		registry, err := RegisterFactory[*errorContextCloser](Registry{}, Transient, func(Resolver) (*errorContextCloser, error) {
+			return &errorContextCloser{err: expected}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, release, err := ResolveReleasable[*errorContextCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveReleasable: %v", err)
+		}
+		err = release(context.Background())
+		if !errors.Is(err, ErrCloser) || !errors.Is(err, expected) {
+			t.Fatalf("expected %v to be %v wrapping %v", err, ErrCloser, expected)
+		}
+	})
+
+	for _, lifetime := range []Lifetime{Singleton, Scoped} {
+		t.Run("returns NotTransient for "+lifetime.String(), func(t *testing.T) {
+			var cleanups int
+			scope := newProvider(t, lifetime, &cleanups).NewScope()
+			_, release, err := ResolveReleasable[*mockCloser](scope)
+			var notTransient NotTransient
+			if !errors.As(err, &notTransient) || !errors.Is(err, ErrNotTransient) {
+				t.Fatalf("expected %v to be a NotTransient", err)
+			}
+			if notTransient.Lifetime != lifetime {
+				t.Fatalf("expected lifetime %v; got %v", lifetime, notTransient.Lifetime)
+			}
+			if release == nil {
+				t.Fatalf("expected release not to be nil")
+			}
+		})
+	}
+}
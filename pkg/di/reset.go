@@ -0,0 +1,85 @@
+package di
+
+import "context"
+
+// A ScopeReset is an [Event] indicating that a [Scope] was reset with [Scope.Reset].
+type ScopeReset struct {
+
+	// ID is the identifier of the reset scope.
+	ID string
+
+	// Report describes how each of the scope's values and deferred cleanups was closed.
+	Report CloseReport
+}
+
+func (ScopeReset) event() {}
+
+// Reset closes the scope's [Scoped] values and runs its deferred cleanups in the same way as
+// [Scope.Close], then leaves the scope open with an empty cache so the next resolution of each
+// Scoped type constructs a new instance, e.g. to isolate the iterations of a batch job that reuses
+// one scope. Instances given to [Scope.WithInstance] are kept, as are the scope's callbacks
+// registered with [Scope.OnClose].
+//
+// Reset returns the errors from [Scope.ResetReport].
+func (scope Scope) Reset(ctx context.Context, opts ...CloseOption) []error {
+	return scope.ResetReport(ctx, opts...).Errors()
+}
+
+// ResetReport resets the scope in the same way as [Scope.Reset] and returns a [CloseReport]
+// describing how each value and deferred cleanup was closed. The reset is reported to the
+// provider's [Observer] as a [ScopeReset].
+//
+// Reset waits for the resolutions in progress on the scope to finish and resolutions that start
+// while the scope is being reset wait for the cache to be cleared, so a resolution never sees a
+// partly reset scope. The values are closed after the cache is cleared, while new resolutions
+// construct their replacements. ResetReport returns a report holding a [ScopeClosed] if the scope
+// has begun closing.
+func (scope Scope) ResetReport(ctx context.Context, opts ...CloseOption) CloseReport {
+	if err := scope.checkInitialized("Reset"); err != nil {
+		return CloseReport{
+			errs: []error{err},
+		}
+	}
+	if err := scope.acquireState(); err != nil {
+		return CloseReport{
+			errs: []error{err},
+		}
+	}
+	defer scope.releaseState()
+	var entries []instanceEntry
+	var cleanups []deferredCleanup
+	state := scope.state
+	state.resetting.Lock()
+	reset := state.closeState.unlessClosing(func() {
+		state.scopedValues.expiry.stop()
+		entries = state.scopedValues.entries()
+		cleanups = state.cleanups.take()
+		state.scopedValues.reset()
+		state.transients.reset()
+	})
+	state.resetting.Unlock()
+	if !reset {
+		return CloseReport{
+			errs: []error{
+				ScopeClosed{
+					ID: scope.id,
+				},
+			},
+		}
+	}
+	registrations := scope.root.registrations.load()
+	options := newCloseOptions(scope.root.options, opts)
+	report := closeReport(
+		ctx,
+		ownedEntries(entries, registrations),
+		unownedClosers(entries, registrations),
+		cleanups,
+		scope.root.abandoned,
+		options)
+	scope.root.handleCloseErrors(scope.id, options, report)
+	scope.root.observe(ScopeReset{
+		ID:     scope.id,
+		Report: report,
+	})
+	return report
+}
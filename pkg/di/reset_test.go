@@ -0,0 +1,188 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type resetValue struct {
+	closed bool
+}
+
+func (v *resetValue) Close() error {
+	v.closed = true
+	return nil
+}
+
+type resetPair struct{}
+
+func TestScopeReset(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*resetValue, *resetValue](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*resetPair](registry, Scoped, func(resolver Resolver) (*resetPair, error) {
+			first, err := Resolve[*resetValue](resolver)
+			if err != nil {
+				return nil, err
+			}
+			time.Sleep(time.Millisecond)
+			second, err := Resolve[*resetValue](resolver)
+			if err != nil {
+				return nil, err
+			}
+			if first != second {
+				return nil, fmt.Errorf("resolved two instances of a scoped value")
+			}
+			return &resetPair{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("closes the scoped values and keeps the scope open", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		before, err := Resolve[*resetValue](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		cleanedUp := false
+		scope.Defer(func(context.Context) error {
+			cleanedUp = true
+			return nil
+		})
+		if errs := scope.Reset(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Reset: %v", errs)
+		}
+		if !before.closed || !cleanedUp {
+			t.Fatalf("expected Reset to close the value and run the cleanup")
+		}
+		after, err := Resolve[*resetValue](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if after == before {
+			t.Fatalf("expected a new instance after Reset")
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !after.closed {
+			t.Errorf("expected Close to close the new instance")
+		}
+	})
+
+	t.Run("reports the reset to the observer", func(t *testing.T) {
+		events := []ScopeReset{}
+		provider := newProvider(t, WithObserver(ObserverFunc(func(event Event) {
+			if event, ok := event.(ScopeReset); ok {
+				events = append(events, event)
+			}
+		})))
+		scope := provider.NewScope()
+		if _, err := Resolve[*resetValue](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		report := scope.ResetReport(context.Background())
+		if len(report.Entries) != 1 {
+			t.Fatalf("expected the report to describe 1 value; got %v", report)
+		}
+		if len(events) != 1 || events[0].ID != scope.ID() || len(events[0].Report.Entries) != 1 {
+			t.Fatalf("expected a ScopeReset for %s; got %v", scope.ID(), events)
+		}
+	})
+
+	t.Run("returns ScopeClosed for closed scopes", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		scope.Close(context.Background())
+		errs := scope.Reset(context.Background())
+		if len(errs) != 1 || !errors.Is(errs[0], ErrScopeClosed) {
+			t.Fatalf("expected a ScopeClosed; got %v", errs)
+		}
+	})
+
+	t.Run("resolutions never see a partly reset scope", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					if _, err := Resolve[*resetPair](scope); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for resetting := true; resetting; {
+			select {
+			case <-done:
+				resetting = false
+			default:
+				scope.Reset(context.Background())
+			}
+		}
+		close(errs)
+		for err := range errs {
+			t.Errorf("unexpected error from Resolve: %v", err)
+		}
+	})
+
+	t.Run("can run alongside methods that read the cache", func(t *testing.T) {
+		for name, opts := range map[string][]ProviderOption{
+			"default store": nil,
+			"custom store":  {WithInstanceStore(NewInstanceStore)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				scope := newProvider(t, opts...).NewScope()
+				readers := []func(){
+					func() { _ = scope.String() },
+					func() { _ = scope.InstantiatedTypes() },
+					func() { _ = scope.Evict(reflect.TypeFor[*resetValue]()) },
+					func() { _ = scope.Dump(io.Discard) },
+					func() { _, _ = Peek[*resetValue](scope) },
+					func() { _, _ = Resolve[*resetValue](scope) },
+				}
+				var wg sync.WaitGroup
+				for _, read := range readers {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for range 50 {
+							read()
+						}
+					}()
+				}
+				for range 50 {
+					scope.Reset(context.Background())
+				}
+				wg.Wait()
+				if errs := scope.Close(context.Background()); len(errs) != 0 {
+					t.Fatalf("unexpected errors from Close: %v", errs)
+				}
+			})
+		}
+	})
+}
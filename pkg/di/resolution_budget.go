@@ -0,0 +1,151 @@
+package di
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// budgetTopTypes is the number of types a ResolutionBudgetExceeded lists.
+const budgetTopTypes = 5
+
+// WithResolutionBudget limits the number of resolutions a new scope performs, including the
+// resolutions of dependencies made by factories, to catch bugs such as a loop that resolves a
+// [Transient] type on every iteration. Once the scope has performed budget resolutions every
+// further resolution fails with a [ResolutionBudgetExceeded], and the first such failure is
+// reported to the provider's [Observer] as a [ResolutionBudgetTripped]. A budget of 0 or less,
+// which is the default, means the scope's resolutions are not limited. The budget applies only
+// to the scope it is given to and not to the scopes created from it.
+func WithResolutionBudget(budget int) ScopeOption {
+	return func(options *scopeOptions) {
+		options.resolutionBudget = budget
+	}
+}
+
+// ErrResolutionBudgetExceeded is returned when a scope resolves more types than its budget allows.
+var ErrResolutionBudgetExceeded = errors.New("resolution budget exceeded")
+
+// A ResolutionBudgetExceeded is an [error] indicating that a scope was asked to perform more
+// resolutions than the budget given by [WithResolutionBudget]. Calling [errors.Is] with a
+// ResolutionBudgetExceeded and [ErrResolutionBudgetExceeded] returns true.
+type ResolutionBudgetExceeded struct {
+
+	// ID is the identifier of the scope.
+	ID string
+
+	// Budget is the number of resolutions the scope is allowed.
+	Budget int
+
+	// Count is the number of resolutions the scope has been asked to perform, including the one
+	// that failed.
+	Count int
+
+	// TopTypes are the types the scope has been asked to resolve most often, most frequent first,
+	// with at most five types.
+	TopTypes []ResolutionCount
+}
+
+// Error implements [error].
+func (err ResolutionBudgetExceeded) Error() string {
+	top := make([]string, 0, len(err.TopTypes))
+	for _, count := range err.TopTypes {
+		top = append(top, fmt.Sprintf("%v (%d)", count.Type, count.Count))
+	}
+	return fmt.Sprintf(
+		"scope %s exceeded its budget of %d resolutions with %d; most resolved: %s",
+		err.ID,
+		err.Budget,
+		err.Count,
+		strings.Join(top, ", "))
+}
+
+// Is indicates that a [ResolutionBudgetExceeded] is [ErrResolutionBudgetExceeded].
+func (ResolutionBudgetExceeded) Is(target error) bool {
+	return target == ErrResolutionBudgetExceeded
+}
+
+// Code returns "resolution_budget_exceeded", the code of a [ResolutionBudgetExceeded] in [ErrorCodes].
+func (ResolutionBudgetExceeded) Code() string {
+	return "resolution_budget_exceeded"
+}
+
+// A ResolutionBudgetTripped is an [Event] indicating that a scope exceeded the budget given by
+// [WithResolutionBudget] for the first time. It is reported once per scope.
+type ResolutionBudgetTripped struct {
+
+	// Exceeded is the error returned by the resolution that exceeded the budget.
+	Exceeded ResolutionBudgetExceeded
+}
+
+func (ResolutionBudgetTripped) event() {}
+
+// resolutionBudget counts the resolutions performed by a scope that was given a budget.
+type resolutionBudget struct {
+	budget  uint64
+	count   atomic.Uint64
+	types   sync.Map
+	tripped atomic.Bool
+}
+
+func newResolutionBudget(budget int) *resolutionBudget {
+	if budget <= 0 {
+		return nil
+	}
+	return &resolutionBudget{
+		budget: uint64(budget),
+	}
+}
+
+// spend counts a resolution of typ and returns a ResolutionBudgetExceeded if it exceeds the
+// budget.
+func (scope Scope) spend(typ reflect.Type) error {
+	budget := scope.budget
+	if budget == nil {
+		return nil
+	}
+	count, ok := budget.types.Load(typ)
+	if !ok {
+		count, _ = budget.types.LoadOrStore(typ, &atomic.Uint64{})
+	}
+	count.(*atomic.Uint64).Add(1)
+	total := budget.count.Add(1)
+	if total <= budget.budget {
+		return nil
+	}
+	err := ResolutionBudgetExceeded{
+		ID:       scope.id,
+		Budget:   int(budget.budget),
+		Count:    int(total),
+		TopTypes: budget.top(),
+	}
+	if budget.tripped.CompareAndSwap(false, true) {
+		scope.root.observe(ResolutionBudgetTripped{
+			Exceeded: err,
+		})
+	}
+	return err
+}
+
+// top returns the most resolved types, most frequent first with ties ordered by type name.
+func (budget *resolutionBudget) top() []ResolutionCount {
+	counts := []ResolutionCount{}
+	budget.types.Range(func(typ, count any) bool {
+		counts = append(counts, ResolutionCount{
+			Type:  typ.(reflect.Type),
+			Count: count.(*atomic.Uint64).Load(),
+		})
+		return true
+	})
+	slices.SortFunc(counts, func(a, b ResolutionCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return compareTypes(a.Type, b.Type)
+	})
+	return counts[:min(len(counts), budgetTopTypes)]
+}
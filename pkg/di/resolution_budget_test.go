@@ -0,0 +1,87 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type budgetRequest struct{}
+
+type budgetHandler struct {
+	Request *budgetRequest
+}
+
+func TestWithResolutionBudget(t *testing.T) {
+
+	newProvider := func(t *testing.T, observer Observer) RootProvider {
+		registry, err := RegisterType[*budgetRequest, *budgetRequest](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*budgetHandler, *budgetHandler](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		opts := []ProviderOption{}
+		if observer != nil {
+			opts = append(opts, WithObserver(observer))
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("fails resolutions after the budget is spent", func(t *testing.T) {
+		tripped := []ResolutionBudgetTripped{}
+		provider := newProvider(t, ObserverFunc(func(event Event) {
+			if event, ok := event.(ResolutionBudgetTripped); ok {
+				tripped = append(tripped, event)
+			}
+		}))
+		scope := provider.NewScope(WithResolutionBudget(5))
+		// Each handler resolves its request too, so two handlers and a request spend the budget.
+		for range 2 {
+			if _, err := Resolve[*budgetHandler](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		if _, err := Resolve[*budgetRequest](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		for count := 6; count <= 7; count++ {
+			_, err := Resolve[*budgetRequest](scope)
+			exceeded := ResolutionBudgetExceeded{}
+			if !errors.As(err, &exceeded) || !errors.Is(err, ErrResolutionBudgetExceeded) {
+				t.Fatalf("expected a ResolutionBudgetExceeded; got %v", err)
+			}
+			expected := ResolutionBudgetExceeded{
+				ID:     scope.ID(),
+				Budget: 5,
+				Count:  count,
+				TopTypes: []ResolutionCount{
+					{Type: reflect.TypeFor[*budgetRequest](), Count: uint64(count - 2)},
+					{Type: reflect.TypeFor[*budgetHandler](), Count: 2},
+				},
+			}
+			if !reflect.DeepEqual(exceeded, expected) {
+				t.Fatalf("expected %v; got %v", expected, exceeded)
+			}
+		}
+		if len(tripped) != 1 || tripped[0].Exceeded.Count != 6 {
+			t.Errorf("expected a single ResolutionBudgetTripped for the sixth resolution; got %v", tripped)
+		}
+	})
+
+	t.Run("is disabled by default and not inherited", func(t *testing.T) {
+		scope := newProvider(t, nil).NewScope(WithResolutionBudget(1))
+		child := scope.NewScope()
+		for range 10 {
+			if _, err := Resolve[*budgetHandler](child); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrResolutionFailed is returned when resolving a registered type fails, or when a factory fails
+// to resolve one of its dependencies.
+var ErrResolutionFailed = errors.New("resolution failed")
+
+// A ResolutionStep is a type that was being resolved when a resolution failed.
+type ResolutionStep struct {
+
+	// Type is the type being resolved.
+	Type reflect.Type
+
+	// Lifetime is the lifetime Type was registered with, or 0 if it is not registered.
+	Lifetime Lifetime
+
+	// Site is the file and line of the call that registered Type, if it is known.
+	Site string
+}
+
+// String implements [fmt.Stringer].
+func (step ResolutionStep) String() string {
+	msg := strings.Builder{}
+	fmt.Fprintf(&msg, "%v", step.Type)
+	if step.Lifetime == 0 {
+		msg.WriteString(" (unregistered)")
+	} else {
+		fmt.Fprintf(&msg, " (%v)", step.Lifetime)
+	}
+	if step.Site != "" {
+		fmt.Fprintf(&msg, " registered at %s", step.Site)
+	}
+	return msg.String()
+}
+
+// A ResolutionError is an [error] indicating that resolving a registered type failed, either
+// because its factory failed or because a dependency could not be resolved. It records the path of
+// types from the type that was requested to the one that failed. Calling [errors.Is] with a
+// ResolutionError and [ErrResolutionFailed] returns true, and [errors.Is] and [errors.As] also
+// match the error that caused it.
+//
+// The message of a ResolutionError is the message of its cause. Formatting a ResolutionError with
+// %+v produces a multi-line report that includes the resolution path.
+type ResolutionError struct {
+
+	// Path is the types that were being resolved when the resolution failed, starting with the
+	// requested type.
+	Path []ResolutionStep
+
+	// Err is the error returned while resolving the first type in Path.
+	Err error
+}
+
+// Error implements [error].
+func (err ResolutionError) Error() string {
+	return err.Err.Error()
+}
+
+// Is indicates that a [ResolutionError] is [ErrResolutionFailed].
+func (ResolutionError) Is(target error) bool {
+	return target == ErrResolutionFailed
+}
+
+// Unwrap gets the error returned while resolving the first type in the path.
+func (err ResolutionError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "resolution_error", the code of a [ResolutionError] in [ErrorCodes].
+func (ResolutionError) Code() string {
+	return "resolution_error"
+}
+
+// Format implements [fmt.Formatter]. The %+v verb produces a multi-line report with the message,
+// the resolution path with one type per line, and the underlying cause. Other verbs format the
+// message as they would for a string.
+func (err ResolutionError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		_, _ = io.WriteString(f, err.Error())
+		_, _ = io.WriteString(f, "\nresolution path:")
+		for _, step := range err.Path {
+			_, _ = fmt.Fprintf(f, "\n  %v", step)
+		}
+		_, _ = fmt.Fprintf(f, "\ncause: %v", err.cause())
+		return
+	}
+	if verb == 'q' {
+		_, _ = fmt.Fprintf(f, "%q", err.Error())
+		return
+	}
+	_, _ = io.WriteString(f, err.Error())
+}
+
+// LogValue implements [slog.LogValuer] by logging the resolution path and the underlying cause as
+// attributes.
+func (err ResolutionError) LogValue() slog.Value {
+	path := make([]string, 0, len(err.Path))
+	for _, step := range err.Path {
+		path = append(path, step.String())
+	}
+	return slog.GroupValue(
+		slog.Any("path", path),
+		slog.Any("cause", err.cause()))
+}
+
+// cause returns the error at the end of the resolution path without the wrappers that were added
+// as it was returned through each resolution and factory.
+func (err ResolutionError) cause() error {
+	cause := err.Err
+	for {
+		switch wrapper := cause.(type) {
+		case ResolutionError:
+			cause = wrapper.Err
+		case resolverError:
+			cause = wrapper.wrapped
+		case ConstructionError:
+			cause = wrapper.Err
+		default:
+			return cause
+		}
+	}
+}
+
+// resolutionFailed wraps err, which was returned while resolving typ, in a [ResolutionError] with
+// typ at the start of the path. The path of a ResolutionError err wraps, which was returned by a
+// nested resolution, is appended to typ.
+func resolutionFailed(err error, typ reflect.Type, registration registration, registered bool) error {
+	step := ResolutionStep{
+		Type: typ,
+	}
+	if registered {
+		step.Lifetime = registration.lifetime
+		step.Site = registration.site
+	}
+	path := []ResolutionStep{step}
+	var nested ResolutionError
+	if errors.As(err, &nested) {
+		if nested.Path[0].Type == typ {
+			path = nested.Path
+		} else {
+			path = append(path, nested.Path...)
+		}
+	}
+	return ResolutionError{
+		Path: path,
+		Err:  err,
+	}
+}
+
+// registrationSite returns the file and line of the call into the package that is registering a
+// type, skipping the frames of the package and of the garlic facade that wraps it.
+func registrationSite() string {
+	const pkgPrefix = "github.com/ttd2089/garlic/pkg/di."
+	const facadePrefix = "github.com/ttd2089/garlic/pkg/garlic."
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, pkgPrefix) ||
+			strings.HasPrefix(frame.Function, facadePrefix)
+		if !internal || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// ErrConstructionFailed is returned when the factory for a registered type returns an error.
+var ErrConstructionFailed = errors.New("construction failed")
+
+// A ConstructionError is an [error] indicating that the factory for a registered type returned an
+// error, including the default factory of a type registered with [RegisterType]. Calling
+// [errors.Is] with a ConstructionError and [ErrConstructionFailed] returns true, and [errors.Is]
+// and [errors.As] also match the error the factory returned.
+type ConstructionError struct {
+
+	// Type is the registered target type whose factory failed.
+	Type reflect.Type
+
+	// Lifetime is the lifetime Type was registered with.
+	Lifetime Lifetime
+
+	// Err is the error the factory returned.
+	Err error
+}
+
+// Error implements [error].
+func (err ConstructionError) Error() string {
+	return fmt.Sprintf("constructing %v (%v): %v", err.Type, err.Lifetime, err.Err)
+}
+
+// Is indicates that a [ConstructionError] is [ErrConstructionFailed].
+func (ConstructionError) Is(target error) bool {
+	return target == ErrConstructionFailed
+}
+
+// Unwrap gets the error the factory returned.
+func (err ConstructionError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "construction_error", the code of a [ConstructionError] in [ErrorCodes].
+func (ConstructionError) Code() string {
+	return "construction_error"
+}
+
+// LogValue implements [slog.LogValuer] by logging the type, lifetime, and the factory's error as
+// attributes.
+func (err ConstructionError) LogValue() slog.Value {
+	return slog.GroupValue(
+		typeAttr("type", err.Type),
+		slog.String("lifetime", err.Lifetime.String()),
+		slog.Any("cause", err.Err))
+}
+
+// construct creates an instance of typ using the registration's factory and wraps any error it
+// returns in a [ConstructionError]. Errors from the factories of inherited registrations, which
+// resolve the instance from the parent provider, are already wrapped by the parent.
+func (registration registration) construct(typ reflect.Type, resolver Resolver) (any, error) {
+	value, err := registration.factory(resolver)
+	if err != nil && !registration.inherited {
+		return nil, ConstructionError{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+			Err:      err,
+		}
+	}
+	return value, err
+}
@@ -0,0 +1,200 @@
+package di
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+type formatA struct {
+	B *formatB
+}
+
+type formatB struct {
+	C *formatC
+}
+
+type formatC struct{}
+
+type formatD struct{}
+
+type formatE struct {
+	D *formatD
+}
+
+// checkGolden compares actual, with the registration sites in test files replaced by a
+// placeholder, to the named golden file in testdata.
+func checkGolden(t *testing.T, name string, actual string) {
+	t.Helper()
+	actual = regexp.MustCompile(`(?:\S*/)?(\w+_test\.go):\d+`).
+		ReplaceAllString(actual, "${1}:LINE") + "\n"
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatalf("unexpected error from WriteFile: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error from ReadFile: %v", err)
+	}
+	if actual != string(expected) {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestResolutionError(t *testing.T) {
+
+	unknownTypeErr := func(t *testing.T) error {
+		registry, err := RegisterType[*formatA, *formatA](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*formatB, *formatB](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, err = Resolve[*formatA](provider)
+		return err
+	}
+
+	factoryErr := func(t *testing.T) error {
+		registry, err := RegisterType[*formatE, *formatE](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*formatD](registry, Singleton, func(Resolver) (*formatD, error) {
+			return nil, errors.New("connection refused")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, err = Resolve[*formatE](provider.NewScope())
+		return err
+	}
+
+	t.Run("records the resolution path", func(t *testing.T) {
+		err := unknownTypeErr(t)
+		var resolutionErr ResolutionError
+		if !errors.As(err, &resolutionErr) {
+			t.Fatalf("expected %v to be a ResolutionError", err)
+		}
+		expected := []reflect.Type{
+			reflect.TypeFor[*formatA](),
+			reflect.TypeFor[*formatB](),
+			reflect.TypeFor[*formatC](),
+		}
+		actual := []reflect.Type{}
+		for _, step := range resolutionErr.Path {
+			actual = append(actual, step.Type)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+		if !errors.Is(err, ErrResolutionFailed) || !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v and %v", err, ErrResolutionFailed, ErrUnknownType)
+		}
+	})
+
+	t.Run("%v stays terse", func(t *testing.T) {
+		err := unknownTypeErr(t)
+		if actual, expected := fmt.Sprintf("%v", err), err.Error(); actual != expected {
+			t.Fatalf("expected %q; got %q", expected, actual)
+		}
+		if actual, expected := fmt.Sprintf("%s", err), err.Error(); actual != expected {
+			t.Fatalf("expected %q; got %q", expected, actual)
+		}
+	})
+
+	t.Run("%+v reports an unknown type three levels deep", func(t *testing.T) {
+		checkGolden(t, "format_unknown_type.golden", fmt.Sprintf("%+v", unknownTypeErr(t)))
+	})
+
+	t.Run("%+v reports a factory error", func(t *testing.T) {
+		checkGolden(t, "format_factory_error.golden", fmt.Sprintf("%+v", factoryErr(t)))
+	})
+
+	t.Run("factory errors are wrapped in a ConstructionError for every lifetime", func(t *testing.T) {
+		expected := errors.New("connection refused")
+		factory := func(Resolver) (*formatD, error) {
+			return nil, expected
+		}
+		for _, lifetime := range []Lifetime{Transient, Scoped, Singleton, PerResolution} {
+			t.Run(lifetime.String(), func(t *testing.T) {
+				registry, err := RegisterFactory[*formatD](Registry{}, lifetime, factory)
+				if err != nil {
+					t.Fatalf("unexpected error from RegisterFactory: %v", err)
+				}
+				provider, err := registry.BuildRootProvider()
+				if err != nil {
+					t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+				}
+				_, err = provider.NewScope().Resolve(reflect.TypeFor[*formatD]())
+				assertConstructionError(t, err, expected, lifetime)
+			})
+		}
+
+		t.Run("shared singletons", func(t *testing.T) {
+			registry, err := RegisterFactory[*formatD](Registry{}, Singleton, factory)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			store := NewSingletonStore()
+			provider, err := registry.BuildRootProvider(WithSharedSingletons(store, reflect.TypeFor[*formatD]()))
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			_, err = provider.Resolve(reflect.TypeFor[*formatD]())
+			assertConstructionError(t, err, expected, Singleton)
+		})
+
+		t.Run("Refresh", func(t *testing.T) {
+			fail := false
+			registry, err := RegisterFactory[*formatD](Registry{}, Singleton, func(Resolver) (*formatD, error) {
+				if fail {
+					return nil, expected
+				}
+				return &formatD{}, nil
+			}, WithRefresh())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			fail = true
+			err = provider.Refresh(reflect.TypeFor[*formatD]())
+			assertConstructionError(t, err, expected, Singleton)
+		})
+	})
+}
+
+func assertConstructionError(t *testing.T, err error, expected error, lifetime Lifetime) {
+	t.Helper()
+	if !errors.Is(err, ErrConstructionFailed) || !errors.Is(err, expected) {
+		t.Fatalf("expected %v to be %v and %v", err, ErrConstructionFailed, expected)
+	}
+	var constructionErr ConstructionError
+	if !errors.As(err, &constructionErr) {
+		t.Fatalf("expected %v to be a ConstructionError", err)
+	}
+	if constructionErr.Type != reflect.TypeFor[*formatD]() || constructionErr.Lifetime != lifetime {
+		t.Fatalf("expected *formatD (%v); got %v (%v)", lifetime, constructionErr.Type, constructionErr.Lifetime)
+	}
+}
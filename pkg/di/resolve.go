@@ -3,10 +3,12 @@ package di
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 )
 
-// ErrNilResolver is returned when the [Resolve] function receives a nil [Resolver] argument.
+// ErrNilResolver is returned when the [Resolve] function or [ImportResolver] receives a nil
+// [Resolver] argument.
 var ErrNilResolver = errors.New("cannot resolve instances from nil Resolver")
 
 // ErrResolverError is returned when the [Resolve] function receives an error from a [Resolver].
@@ -31,6 +33,20 @@ func (err resolverError) Unwrap() error {
 	return err.wrapped
 }
 
+// Format implements [fmt.Formatter] so that %+v formats the underlying [error] with %+v, e.g. to
+// include the report of a [ResolutionError].
+func (err resolverError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		_, _ = fmt.Fprintf(f, "resolver error: %+v", err.wrapped)
+		return
+	}
+	if verb == 'q' {
+		_, _ = fmt.Fprintf(f, "%q", err.Error())
+		return
+	}
+	_, _ = io.WriteString(f, err.Error())
+}
+
 // ErrInvalidResolution is returned when the [Resolve] function receives a value from a [Resolver]
 // that cannot be assigned to the requested type.
 //
@@ -64,16 +80,53 @@ func (InvalidResolution) Is(target error) bool {
 	return target == ErrInvalidResolution
 }
 
+// Code returns "invalid_resolution", the code of an [InvalidResolution] in [ErrorCodes].
+func (InvalidResolution) Code() string {
+	return "invalid_resolution"
+}
+
+// ErrNilResolution is returned when the [Resolve] function or a default factory receives an
+// untyped nil from a [Resolver].
+//
+// NOTE: Like [ErrInvalidResolution], this error points to a broken implementation of [Resolver]
+// since an untyped nil is not a value of any type. A [Resolver] providing a nil pointer, map,
+// slice, etc. should return it as a value of the requested type.
+var ErrNilResolution = errors.New("Resolver returned nil")
+
+// A NilResolution is an [error] indicating that a [Resolver] returned an untyped nil. Calling
+// [errors.Is] with a [NilResolution] and [ErrNilResolution] returns true.
+type NilResolution struct {
+
+	// Type is the type that was requested from the [Resolver].
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NilResolution) Error() string {
+	return fmt.Sprintf("Resolver returned nil when %v was requested", err.Type)
+}
+
+// Is indicates that a [NilResolution] is [ErrNilResolution].
+func (NilResolution) Is(target error) bool {
+	return target == ErrNilResolution
+}
+
+// Code returns "nil_resolution", the code of a [NilResolution] in [ErrorCodes].
+func (NilResolution) Code() string {
+	return "nil_resolution"
+}
+
 // A Resolver resolves instances of a requested type.
 type Resolver interface {
 
 	// Resolve provides an instance of the requested type if one is registered. Implementations
-	// MUST ensure that the values returned are assignable to the requested type.
+	// MUST ensure that the values returned are assignable to the requested type, except that the
+	// type identifying a [Key] resolves to a value of the key's type.
 	Resolve(reflect.Type) (any, error)
 }
 
 // Resolve obtains an instance of the requested type from a [Resolver]. An [error] is returned when
-// the [Resolver] returns an [error] or a value that is not assignable to T.
+// the [Resolver] returns an [error], an untyped nil, or a value that is not assignable to T.
 func Resolve[T any](resolver Resolver) (T, error) {
 	if resolver == nil {
 		var zero T
@@ -88,6 +141,12 @@ func Resolve[T any](resolver Resolver) (T, error) {
 		return zero, resolverError{wrapped: err}
 	}
 
+	if resolved == nil {
+		return zero, NilResolution{
+			Type: typ,
+		}
+	}
+
 	typed, ok := resolved.(T)
 	if !ok {
 		return zero, InvalidResolution{
@@ -0,0 +1,126 @@
+package di
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ErrResolveAllUnsupported is returned when [ResolveAll] is called with a [Resolver] that cannot
+// enumerate the registrations for a type.
+var ErrResolveAllUnsupported = errors.New("resolver does not support ResolveAll")
+
+// A ResolveAllUnsupported is an [error] indicating that [ResolveAll] was called with a [Resolver]
+// that cannot enumerate the registrations for a type. Calling [errors.Is] with a
+// ResolveAllUnsupported and [ErrResolveAllUnsupported] returns true.
+type ResolveAllUnsupported struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err ResolveAllUnsupported) Error() string {
+	return fmt.Sprintf("cannot resolve every instance of %v: resolver does not support ResolveAll", err.Type)
+}
+
+// Is indicates that a [ResolveAllUnsupported] is [ErrResolveAllUnsupported].
+func (ResolveAllUnsupported) Is(target error) bool {
+	return target == ErrResolveAllUnsupported
+}
+
+// Code returns "resolve_all_unsupported", the code of a [ResolveAllUnsupported] in [ErrorCodes].
+func (ResolveAllUnsupported) Code() string {
+	return "resolve_all_unsupported"
+}
+
+// ResolveAll obtains an instance of T from each registration for T in resolver, which are the
+// registration of T itself and those under each [Key] for T; see [Scope.ResolveAll]. It returns a
+// [ResolveAllUnsupported] if resolver is not a [Scope], a [RootProvider], or another type with a
+// ResolveAll method like theirs.
+func ResolveAll[T any](resolver Resolver) ([]T, error) {
+	typ := reflect.TypeFor[T]()
+	allResolver, ok := resolver.(interface {
+		ResolveAll(reflect.Type) ([]any, error)
+	})
+	if !ok {
+		return nil, ResolveAllUnsupported{
+			Type: typ,
+		}
+	}
+	resolved, err := allResolver.ResolveAll(typ)
+	if err != nil {
+		return nil, resolverError{wrapped: err}
+	}
+	all := make([]T, 0, len(resolved))
+	for _, value := range resolved {
+		if value == nil {
+			return nil, NilResolution{
+				Type: typ,
+			}
+		}
+		typed, ok := value.(T)
+		if !ok {
+			return nil, InvalidResolution{
+				Requested: typ,
+				Returned:  reflect.TypeOf(value),
+			}
+		}
+		all = append(all, typed)
+	}
+	return all, nil
+}
+
+// ResolveAll resolves each registration for typ, which are the registration of typ itself and
+// those under each [Key] for typ, and returns the instances sorted by the priority given to the
+// registrations [WithPriority] and then in the order the registrations were made. The order is part
+// of the contract and does not depend on which instances were already cached. Replacing a
+// registration moves it to the end of those with its priority. ResolveAll returns an empty slice if there
+// are no registrations for typ and stops at the first error.
+func (scope Scope) ResolveAll(typ reflect.Type) ([]any, error) {
+	if err := scope.checkInitialized("ResolveAll"); err != nil {
+		return nil, err
+	}
+	return resolveAll(scope, scope.root.registrations.load(), typ)
+}
+
+// ResolveAll resolves each registration for typ; see [Scope.ResolveAll].
+func (provider RootProvider) ResolveAll(typ reflect.Type) ([]any, error) {
+	if err := provider.checkInitialized("ResolveAll"); err != nil {
+		return nil, err
+	}
+	return resolveAll(provider, provider.registrations.load(), typ)
+}
+
+func resolveAll(resolver Resolver, registrations map[reflect.Type]registration, typ reflect.Type) ([]any, error) {
+	types := registeredFor(registrations, typ)
+	all := make([]any, 0, len(types))
+	for _, registered := range types {
+		value, err := resolver.Resolve(registered)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, value)
+	}
+	return all, nil
+}
+
+// registeredFor returns the types registered for typ, which are typ and the types of the keys for
+// typ, by priority and then in registration order.
+func registeredFor(registrations map[reflect.Type]registration, typ reflect.Type) []reflect.Type {
+	types := []reflect.Type{}
+	for registered := range registrations {
+		if registered == typ || isKeyType(registered) && registered.Field(0).Type == typ {
+			types = append(types, registered)
+		}
+	}
+	slices.SortFunc(types, func(a, b reflect.Type) int {
+		return cmp.Or(
+			cmp.Compare(registrations[a].priority, registrations[b].priority),
+			cmp.Compare(registrations[a].order, registrations[b].order),
+		)
+	})
+	return types
+}
@@ -0,0 +1,227 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type orderedMiddleware struct {
+	name string
+}
+
+func TestResolveAll(t *testing.T) {
+
+	auth := NewKey[*orderedMiddleware]("auth")
+	logging := NewKey[*orderedMiddleware]("logging")
+	metrics := NewKey[*orderedMiddleware]("metrics")
+
+	register := func(key Key[*orderedMiddleware], lifetime Lifetime, opts ...RegistrationOption) RegistrationFunc {
+		return func(registry Registry) (Registry, error) {
+			return RegisterKeyFactory(registry, key, lifetime, func(Resolver) (*orderedMiddleware, error) {
+				return &orderedMiddleware{name: key.Name()}, nil
+			}, opts...)
+		}
+	}
+	securityModule := func(registry Registry) (Registry, error) {
+		return register(auth, Scoped)(registry)
+	}
+	observabilityModule := func(registry Registry) (Registry, error) {
+		registry, err := register(logging, Singleton)(registry)
+		if err != nil {
+			return registry, err
+		}
+		return register(metrics, Transient)(registry)
+	}
+
+	newProvider := func(t *testing.T, modules ...RegistrationFunc) RootProvider {
+		registry := Registry{}
+		for _, module := range modules {
+			var err error
+			if registry, err = module(registry); err != nil {
+				t.Fatalf("unexpected error registering a module: %v", err)
+			}
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	names := func(middleware []*orderedMiddleware) []string {
+		names := make([]string, 0, len(middleware))
+		for _, m := range middleware {
+			names = append(names, m.name)
+		}
+		return names
+	}
+
+	t.Run("returns instances in registration order", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			modules  []RegistrationFunc
+			expected []string
+		}{
+			{
+				name:     "security first",
+				modules:  []RegistrationFunc{securityModule, observabilityModule},
+				expected: []string{"auth", "logging", "metrics"},
+			},
+			{
+				name:     "observability first",
+				modules:  []RegistrationFunc{observabilityModule, securityModule},
+				expected: []string{"logging", "metrics", "auth"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				scope := newProvider(t, tt.modules...).NewScope()
+				for range 3 {
+					all, err := ResolveAll[*orderedMiddleware](scope)
+					if err != nil {
+						t.Fatalf("unexpected error from ResolveAll: %v", err)
+					}
+					if !slices.Equal(names(all), tt.expected) {
+						t.Fatalf("expected %v; got %v", tt.expected, names(all))
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("sorts by priority before registration order", func(t *testing.T) {
+		recovery := NewKey[*orderedMiddleware]("recovery")
+		tracing := NewKey[*orderedMiddleware]("tracing")
+		compression := NewKey[*orderedMiddleware]("compression")
+		prioritizedModule := func(registry Registry) (Registry, error) {
+			registry, err := register(compression, Transient, WithPriority(1))(registry)
+			if err != nil {
+				return registry, err
+			}
+			registry, err = register(tracing, Singleton, WithPriority(-1))(registry)
+			if err != nil {
+				return registry, err
+			}
+			return register(recovery, Transient, WithPriority(-2))(registry)
+		}
+		tests := []struct {
+			name     string
+			modules  []RegistrationFunc
+			expected []string
+		}{
+			{
+				name:     "prioritized first",
+				modules:  []RegistrationFunc{prioritizedModule, securityModule, observabilityModule},
+				expected: []string{"recovery", "tracing", "auth", "logging", "metrics", "compression"},
+			},
+			{
+				name:     "prioritized last",
+				modules:  []RegistrationFunc{observabilityModule, securityModule, prioritizedModule},
+				expected: []string{"recovery", "tracing", "logging", "metrics", "auth", "compression"},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				scope := newProvider(t, tt.modules...).NewScope()
+				if _, err := ResolveKey(scope, tracing); err != nil {
+					t.Fatalf("unexpected error from ResolveKey: %v", err)
+				}
+				all, err := ResolveAll[*orderedMiddleware](scope)
+				if err != nil {
+					t.Fatalf("unexpected error from ResolveAll: %v", err)
+				}
+				if !slices.Equal(names(all), tt.expected) {
+					t.Fatalf("expected %v; got %v", tt.expected, names(all))
+				}
+				slice, err := Resolve[[]*orderedMiddleware](scope)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if !slices.Equal(names(slice), tt.expected) {
+					t.Fatalf("expected the slice to be %v; got %v", tt.expected, names(slice))
+				}
+			})
+		}
+	})
+
+	t.Run("keeps registration order among equal priorities", func(t *testing.T) {
+		first := NewKey[*orderedMiddleware]("first")
+		second := NewKey[*orderedMiddleware]("second")
+		provider := newProvider(t,
+			register(first, Transient, WithPriority(5)),
+			securityModule,
+			register(second, Transient, WithPriority(5)),
+		)
+		all, err := ResolveAll[*orderedMiddleware](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveAll: %v", err)
+		}
+		if expected := []string{"auth", "first", "second"}; !slices.Equal(names(all), expected) {
+			t.Fatalf("expected %v; got %v", expected, names(all))
+		}
+	})
+
+	t.Run("does not depend on which instances are cached", func(t *testing.T) {
+		scope := newProvider(t, securityModule, observabilityModule).NewScope()
+		if _, err := ResolveKey(scope, logging); err != nil {
+			t.Fatalf("unexpected error from ResolveKey: %v", err)
+		}
+		cached, err := ResolveKey(scope, auth)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveKey: %v", err)
+		}
+		all, err := ResolveAll[*orderedMiddleware](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveAll: %v", err)
+		}
+		if expected := []string{"auth", "logging", "metrics"}; !slices.Equal(names(all), expected) {
+			t.Fatalf("expected %v; got %v", expected, names(all))
+		}
+		if all[0] != cached {
+			t.Errorf("expected the cached auth middleware")
+		}
+	})
+
+	t.Run("includes the registration of the type itself", func(t *testing.T) {
+		provider := newProvider(t, securityModule, func(registry Registry) (Registry, error) {
+			return RegisterFactory[*orderedMiddleware](registry, Transient, func(Resolver) (*orderedMiddleware, error) {
+				return &orderedMiddleware{name: "default"}, nil
+			})
+		})
+		all, err := ResolveAll[*orderedMiddleware](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveAll: %v", err)
+		}
+		if expected := []string{"auth", "default"}; !slices.Equal(names(all), expected) {
+			t.Fatalf("expected %v; got %v", expected, names(all))
+		}
+	})
+
+	t.Run("returns an empty slice when nothing is registered", func(t *testing.T) {
+		all, err := ResolveAll[*orderedMiddleware](newProvider(t))
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveAll: %v", err)
+		}
+		if all == nil || len(all) != 0 {
+			t.Errorf("expected an empty slice; got %v", all)
+		}
+	})
+
+	t.Run("returns the first error", func(t *testing.T) {
+		_, err := ResolveAll[*orderedMiddleware](newProvider(t, securityModule))
+		if !errors.Is(err, ErrScopedValueRequestedFromRootProvider) {
+			t.Fatalf("expected %q; got %q", ErrScopedValueRequestedFromRootProvider, err)
+		}
+	})
+
+	t.Run("returns ResolveAllUnsupported for other resolvers", func(t *testing.T) {
+		resolver := ResolverFunc(func(reflect.Type) (any, error) {
+			return &orderedMiddleware{}, nil
+		})
+		if _, err := ResolveAll[*orderedMiddleware](resolver); !errors.Is(err, ErrResolveAllUnsupported) {
+			t.Fatalf("expected %q; got %q", ErrResolveAllUnsupported, err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrResolveNewUnsupported is returned when [ResolveNew] is called with a [Resolver] that cannot
+// create new instances regardless of their lifetimes.
+var ErrResolveNewUnsupported = errors.New("resolver does not support ResolveNew")
+
+// A ResolveNewUnsupported is an [error] indicating that [ResolveNew] was called with a [Resolver]
+// that cannot create new instances regardless of their lifetimes. Calling [errors.Is] with a
+// ResolveNewUnsupported and [ErrResolveNewUnsupported] returns true.
+type ResolveNewUnsupported struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err ResolveNewUnsupported) Error() string {
+	return fmt.Sprintf("cannot resolve a new instance of %v: resolver does not support ResolveNew", err.Type)
+}
+
+// Is indicates that a [ResolveNewUnsupported] is [ErrResolveNewUnsupported].
+func (ResolveNewUnsupported) Is(target error) bool {
+	return target == ErrResolveNewUnsupported
+}
+
+// Code returns "resolve_new_unsupported", the code of a [ResolveNewUnsupported] in [ErrorCodes].
+func (ResolveNewUnsupported) Code() string {
+	return "resolve_new_unsupported"
+}
+
+// A NewInstanceResolved is an [Event] indicating that a new instance of a type was created by
+// [Scope.ResolveNew] or [RootProvider.ResolveNew] without consulting the cache for its lifetime.
+type NewInstanceResolved struct {
+
+	// Type is the type that was resolved.
+	Type reflect.Type
+
+	// Lifetime is the lifetime Type is registered with.
+	Lifetime Lifetime
+}
+
+func (NewInstanceResolved) event() {}
+
+// ResolveNew obtains a new instance of T from resolver regardless of the lifetime T is registered
+// with; see [Scope.ResolveNew]. It returns a [ResolveNewUnsupported] if resolver is not a [Scope],
+// a [RootProvider], or another type with a ResolveNew method like theirs.
+func ResolveNew[T any](resolver Resolver) (T, error) {
+	var zero T
+	typ := reflect.TypeFor[T]()
+	newResolver, ok := resolver.(interface {
+		ResolveNew(reflect.Type) (any, error)
+	})
+	if !ok {
+		return zero, ResolveNewUnsupported{
+			Type: typ,
+		}
+	}
+	resolved, err := newResolver.ResolveNew(typ)
+	if err != nil {
+		return zero, resolverError{wrapped: err}
+	}
+	if resolved == nil {
+		return zero, NilResolution{
+			Type: typ,
+		}
+	}
+	typed, ok := resolved.(T)
+	if !ok {
+		return zero, InvalidResolution{
+			Requested: typ,
+			Returned:  reflect.TypeOf(resolved),
+		}
+	}
+	return typed, nil
+}
+
+// ResolveNew calls the factory registered for typ to create a new instance without consulting or
+// populating the cache for its lifetime, e.g. to get an isolated copy of a [Scoped] or [Singleton]
+// value. The factory of a Singleton registration is given the [RootProvider] as it would be for
+// Resolve, and a Scoped registration restricted with [WithScopeName] must still be resolved from a
+// scope with that name. Scope overrides made with [Scope.WithInstance] are not used.
+//
+// The new instance has no other owner, so if the registration owns its instances and is not
+// [Transient] the instance is closed when the scope is closed. Factories that rely on being called
+// only once, such as those for Singleton values that claim an exclusive resource, may not work
+// with ResolveNew; avoiding them is the caller's responsibility.
+func (scope Scope) ResolveNew(typ reflect.Type) (any, error) {
+	if err := scope.checkInitialized("ResolveNew"); err != nil {
+		return nil, err
+	}
+	if err := scope.acquireState(); err != nil {
+		return nil, err
+	}
+	defer scope.releaseState()
+	if err := scope.idle.enter(scope.id); err != nil {
+		return nil, err
+	}
+	defer scope.idle.exit()
+	if scope.constructing == nil {
+		scope.state.resetting.RLock()
+		defer scope.state.resetting.RUnlock()
+	}
+	registration, ok := scope.root.registrations.get(typ)
+	if !ok {
+		var err error
+		registration, ok, err = scope.root.autoRegistration(typ)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, scope.root.registrations.unknownType(typ)
+	}
+	if registration.lifetime == Scoped && registration.scopeName != "" && registration.scopeName != scope.name {
+		return nil, ScopeNameMismatch{
+			Type:     typ,
+			Required: registration.scopeName,
+			Actual:   scope.name,
+		}
+	}
+	if registration.lifetime == Singleton || registration.inherited {
+		return scope.root.resolveNew(typ, registration, &scope.state.cleanups)
+	}
+	return newInstance(typ, registration, scope.constructingType(typ), &scope.state.cleanups, scope.root)
+}
+
+// ResolveNew calls the factory registered for typ to create a new instance without consulting or
+// populating the cache for its lifetime; see [Scope.ResolveNew]. It returns a
+// [ScopedValueRequestedFromRootProvider] for [Scoped] registrations. The new instance of a
+// registration that owns its instances and is not [Transient] is closed when the provider is
+// closed.
+func (provider RootProvider) ResolveNew(typ reflect.Type) (any, error) {
+	if err := provider.checkInitialized("ResolveNew"); err != nil {
+		return nil, err
+	}
+	registration, ok := provider.registrations.get(typ)
+	if !ok {
+		var err error
+		registration, ok, err = provider.autoRegistration(typ)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, provider.registrations.unknownType(typ)
+	}
+	if registration.lifetime == Scoped {
+		return nil, ScopedValueRequestedFromRootProvider{
+			Type: typ,
+		}
+	}
+	return provider.resolveNew(typ, registration, provider.cleanups)
+}
+
+// resolveNew creates a new instance of typ using the provider as the resolver, or the ancestor
+// the registration was inherited from, and defers closing it to cleanups.
+func (provider RootProvider) resolveNew(
+	typ reflect.Type,
+	registration registration,
+	cleanups *deferredCleanups,
+) (any, error) {
+	for registration.inherited && provider.parent != nil {
+		provider = *provider.parent
+		registration, _ = provider.registrations.get(typ)
+	}
+	return newInstance(typ, registration, provider.constructingType(typ), cleanups, provider)
+}
+
+// newInstance creates a new instance of typ using registration's factory with resolver, defers
+// closing it to cleanups if it has no other owner, and reports it to provider's observer.
+func newInstance(
+	typ reflect.Type,
+	registration registration,
+	resolver Resolver,
+	cleanups *deferredCleanups,
+	provider RootProvider,
+) (any, error) {
+	value, err := provider.timed(typ, registration).construct(typ, resolver)
+	if err != nil {
+		return nil, resolutionFailed(err, typ, registration, true)
+	}
+	if registration.owned && registration.lifetime != Transient {
+		cleanups.add(typ, closeFunc(value))
+	}
+	provider.observe(NewInstanceResolved{
+		Type:     typ,
+		Lifetime: registration.lifetime,
+	})
+	return value, nil
+}
+
+// closeFunc returns a cleanup that closes value if it implements [ContextCloser] or [Closer], or
+// nil if it implements neither.
+func closeFunc(value any) func(context.Context) error {
+	switch closer := value.(type) {
+	case ContextCloser:
+		return closer.Close
+	case Closer:
+		return func(context.Context) error {
+			return closer.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveNew(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, lifetime)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	for _, lifetime := range []Lifetime{Scoped, Singleton} {
+		t.Run("creates a new "+lifetime.String()+" instance without caching it", func(t *testing.T) {
+			scope := newProvider(t, lifetime).NewScope()
+			fresh, err := ResolveNew[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveNew: %v", err)
+			}
+			cached, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if fresh == cached {
+				t.Fatalf("expected ResolveNew not to populate the cache")
+			}
+			second, err := ResolveNew[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveNew: %v", err)
+			}
+			if second == fresh || second == cached {
+				t.Fatalf("expected ResolveNew to create a new instance each time")
+			}
+			again, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if again != cached {
+				t.Fatalf("expected ResolveNew not to replace the cached instance")
+			}
+		})
+
+		t.Run("closes the new "+lifetime.String()+" instance with the scope", func(t *testing.T) {
+			provider := newProvider(t, lifetime)
+			scope := provider.NewScope()
+			fresh, err := ResolveNew[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveNew: %v", err)
+			}
+			if err := scope.CloseJoined(context.Background()); err != nil {
+				t.Fatalf("unexpected error from CloseJoined: %v", err)
+			}
+			if !fresh.closed {
+				t.Fatalf("expected the new instance to be closed with the scope")
+			}
+		})
+	}
+
+	t.Run("closes new Singleton instances from the provider with the provider", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		fresh, err := ResolveNew[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveNew: %v", err)
+		}
+		if err := provider.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if !fresh.closed {
+			t.Fatalf("expected the new instance to be closed with the provider")
+		}
+	})
+
+	t.Run("creates inherited Singleton instances with the parent's factory", func(t *testing.T) {
+		parent := newProvider(t, Singleton)
+		child, err := parent.NewChildProvider(Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from NewChildProvider: %v", err)
+		}
+		shared, err := Resolve[*mockCloser](parent)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		scope := child.NewScope()
+		fresh, err := ResolveNew[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveNew: %v", err)
+		}
+		if fresh == shared {
+			t.Fatalf("expected a new instance rather than the parent's")
+		}
+		if err := scope.CloseJoined(context.Background()); err != nil {
+			t.Fatalf("unexpected error from CloseJoined: %v", err)
+		}
+		if shared.closed {
+			t.Fatalf("expected the parent's instance not to be closed")
+		}
+	})
+
+	t.Run("returns ScopedValueRequestedFromRootProvider for Scoped registrations from the provider", func(t *testing.T) {
+		_, err := ResolveNew[*mockCloser](newProvider(t, Scoped))
+		if !errors.Is(err, ErrScopedValueRequestedFromRootProvider) {
+			t.Fatalf("expected %v to be %v", err, ErrScopedValueRequestedFromRootProvider)
+		}
+	})
+
+	t.Run("returns UnknownType for unregistered types", func(t *testing.T) {
+		_, err := ResolveNew[*gadget](newProvider(t, Singleton).NewScope())
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+
+	t.Run("reports new instances to the observer", func(t *testing.T) {
+		var events []NewInstanceResolved
+		provider := newProvider(t, Singleton, WithObserver(ObserverFunc(func(event Event) {
+			if resolved, ok := event.(NewInstanceResolved); ok {
+				events = append(events, resolved)
+			}
+		})))
+		if _, err := ResolveNew[*mockCloser](provider.NewScope()); err != nil {
+			t.Fatalf("unexpected error from ResolveNew: %v", err)
+		}
+		expected := []NewInstanceResolved{{Type: reflect.TypeFor[*mockCloser](), Lifetime: Singleton}}
+		if !reflect.DeepEqual(events, expected) {
+			t.Fatalf("expected %v; got %v", expected, events)
+		}
+	})
+
+	t.Run("returns ResolveNewUnsupported for other resolvers", func(t *testing.T) {
+		_, err := ResolveNew[*mockCloser](ResolverFunc(func(reflect.Type) (any, error) {
+			return &mockCloser{}, nil
+		}))
+		if !errors.Is(err, ErrResolveNewUnsupported) {
+			t.Fatalf("expected %v to be %v", err, ErrResolveNewUnsupported)
+		}
+	})
+}
@@ -56,6 +56,37 @@ func TestResolve(t *testing.T) {
 		}
 	})
 
+	t.Run("returns ErrNilResolution when the Resolver returns nil", func(t *testing.T) {
+		resolver := mockResolver{}
+		resolver.returns(nil, nil)
+		_, err := Resolve[interface{}](&resolver)
+		if !errors.Is(err, ErrNilResolution) {
+			t.Fatalf("expected %v; got %v", ErrNilResolution, err)
+		}
+		if errors.Is(err, ErrInvalidResolution) {
+			t.Fatalf("expected %v not to be %v", err, ErrInvalidResolution)
+		}
+		var nilResolution NilResolution
+		if !errors.As(err, &nilResolution) {
+			t.Fatalf("expected %v to be %T", err, nilResolution)
+		}
+		if expected := reflect.TypeFor[interface{}](); nilResolution.Type != expected {
+			t.Errorf("expected err.Type to be %v; got %v", expected, nilResolution.Type)
+		}
+	})
+
+	t.Run("returns a typed nil when the Resolver returns one", func(t *testing.T) {
+		resolver := mockResolver{}
+		resolver.returns((*struct{})(nil), nil)
+		actual, err := Resolve[*struct{}](&resolver)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual != nil {
+			t.Errorf("expected nil; got %v", actual)
+		}
+	})
+
 	t.Run("returns the resolved value when its assignable to requested type", func(t *testing.T) {
 		expected := &struct{}{}
 		resolver := mockResolver{}
@@ -0,0 +1,48 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+)
+
+// A ResolverFunc is a function that implements [Resolver].
+type ResolverFunc func(reflect.Type) (any, error)
+
+// Resolve implements [Resolver] by calling the function.
+func (fn ResolverFunc) Resolve(typ reflect.Type) (any, error) {
+	return fn(typ)
+}
+
+// CombineResolvers returns a [Resolver] that tries each of the given resolvers in order and returns
+// the first result that is not an [UnknownType] for the requested type. Any other error is returned
+// immediately, including an UnknownType for a dependency of the requested type, since the resolver
+// that knows the requested type failed to construct it. If every resolver misses, the UnknownType
+// from the last one is returned. Nil resolvers are skipped.
+func CombineResolvers(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(typ reflect.Type) (any, error) {
+		var miss error = UnknownType{
+			Type: typ,
+		}
+		for _, resolver := range resolvers {
+			if resolver == nil {
+				continue
+			}
+			value, err := resolver.Resolve(typ)
+			if !isMiss(err, typ) {
+				return value, err
+			}
+			miss = err
+		}
+		return nil, miss
+	})
+}
+
+// isMiss reports whether err indicates that typ itself is unknown to a resolver, as opposed to a
+// failure to resolve one of its dependencies.
+func isMiss(err error, typ reflect.Type) bool {
+	var unknownType UnknownType
+	return errors.As(err, &unknownType) &&
+		unknownType.Type == typ &&
+		!errors.Is(err, ErrResolutionFailed) &&
+		!errors.Is(err, ErrConstructionFailed)
+}
@@ -0,0 +1,133 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestResolverFunc(t *testing.T) {
+
+	t.Run("Resolve calls the function", func(t *testing.T) {
+		resolver := ResolverFunc(func(typ reflect.Type) (any, error) {
+			return "example", nil
+		})
+		actual, err := Resolve[string](resolver)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != "example" {
+			t.Fatalf("expected %q; got %q", "example", actual)
+		}
+	})
+}
+
+func TestCombineResolvers(t *testing.T) {
+
+	stringType := reflect.TypeFor[string]()
+
+	unknown := ResolverFunc(func(typ reflect.Type) (any, error) {
+		return nil, UnknownType{Type: typ}
+	})
+
+	value := func(v any) Resolver {
+		return ResolverFunc(func(reflect.Type) (any, error) {
+			return v, nil
+		})
+	}
+
+	failing := func(err error) Resolver {
+		return ResolverFunc(func(reflect.Type) (any, error) {
+			return nil, err
+		})
+	}
+
+	t.Run("returns the first resolution", func(t *testing.T) {
+		actual, err := CombineResolvers(value("first"), value("second")).Resolve(stringType)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != "first" {
+			t.Fatalf("expected %q; got %q", "first", actual)
+		}
+	})
+
+	t.Run("tries the next resolver on UnknownType", func(t *testing.T) {
+		actual, err := CombineResolvers(unknown, nil, value("second")).Resolve(stringType)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != "second" {
+			t.Fatalf("expected %q; got %q", "second", actual)
+		}
+	})
+
+	t.Run("tries the next resolver on a wrapped UnknownType for the requested type", func(t *testing.T) {
+		wrapped := failing(fmt.Errorf("cache miss: %w", UnknownTypeNearMisses{
+			UnknownType: UnknownType{Type: stringType},
+		}))
+		actual, err := CombineResolvers(wrapped, value("second")).Resolve(stringType)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != "second" {
+			t.Fatalf("expected %q; got %q", "second", actual)
+		}
+	})
+
+	t.Run("stops at other errors", func(t *testing.T) {
+		expected := errors.New("expected error")
+		_, err := CombineResolvers(failing(expected), value("second")).Resolve(stringType)
+		if !errors.Is(err, expected) {
+			t.Fatalf("expected %v to be %v", err, expected)
+		}
+	})
+
+	t.Run("stops at UnknownType for another type", func(t *testing.T) {
+		dependency := reflect.TypeFor[int]()
+		_, err := CombineResolvers(failing(UnknownType{Type: dependency}), value("second")).Resolve(stringType)
+		var unknownType UnknownType
+		if !errors.As(err, &unknownType) || unknownType.Type != dependency {
+			t.Fatalf("expected an UnknownType for %v; got %v", dependency, err)
+		}
+	})
+
+	t.Run("stops when a provider fails to resolve a dependency", func(t *testing.T) {
+		type dependent struct {
+			Missing *int
+		}
+		registry, err := RegisterType[*dependent, *dependent](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		fallback := value(&dependent{})
+		_, err = CombineResolvers(provider, fallback).Resolve(reflect.TypeFor[*dependent]())
+		if !errors.Is(err, ErrResolutionFailed) {
+			t.Fatalf("expected %v to be %v", err, ErrResolutionFailed)
+		}
+	})
+
+	t.Run("returns the last UnknownType when every resolver misses", func(t *testing.T) {
+		last := UnknownTypeNearMisses{
+			UnknownType: UnknownType{Type: stringType},
+			Near:        []reflect.Type{reflect.TypeFor[*string]()},
+		}
+		_, err := CombineResolvers(unknown, failing(last)).Resolve(stringType)
+		var nearMisses UnknownTypeNearMisses
+		if !errors.As(err, &nearMisses) {
+			t.Fatalf("expected the last UnknownType; got %v", err)
+		}
+	})
+
+	t.Run("returns UnknownType without resolvers", func(t *testing.T) {
+		_, err := CombineResolvers().Resolve(stringType)
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+}
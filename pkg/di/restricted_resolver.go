@@ -0,0 +1,68 @@
+package di
+
+import (
+	"context"
+	"reflect"
+)
+
+// WithRestrictedResolvers makes the provider pass factories a restricted [Resolver] instead of
+// the [Scope] or [RootProvider] resolving their values, so that a factory cannot create scopes,
+// add values to a scope, or close it. The restricted resolver supports [Resolve], [Peek],
+// [ResolveNew], [ResolveAll], and deferring cleanups like [Scope.Defer], but asserting it to a
+// Scope or RootProvider fails. Registrations given [WithScopeAccess] still receive the Scope or
+// RootProvider. Restricted resolvers are planned to become the default in a future release.
+func WithRestrictedResolvers() ProviderOption {
+	return func(options *providerOptions) {
+		options.restrictedResolvers = true
+	}
+}
+
+// WithScopeAccess exempts a registration from [WithRestrictedResolvers] so that its factory
+// receives the [Scope] or [RootProvider] resolving its value, for the rare factory that needs to
+// control scopes, e.g. one that creates a child scope for a background worker.
+func WithScopeAccess() RegistrationOption {
+	return func(r *registration) {
+		r.scopeAccess = true
+	}
+}
+
+// factoryResolver is the part of a Scope or RootProvider that factories may use when resolvers
+// are restricted.
+type factoryResolver interface {
+	Resolver
+	Defer(cleanup func(context.Context) error)
+	Peek(typ reflect.Type) (any, bool)
+	ResolveNew(typ reflect.Type) (any, error)
+	ResolveAll(typ reflect.Type) ([]any, error)
+}
+
+// restrictedResolver is the Resolver given to factories by providers built
+// WithRestrictedResolvers. Embedding the interface exposes only its methods.
+type restrictedResolver struct {
+	factoryResolver
+}
+
+// restricted returns registration with its factory given a restricted resolver if the provider
+// restricts resolvers and the registration was not given WithScopeAccess.
+func (provider RootProvider) restricted(registration registration) registration {
+	if !provider.options.restrictedResolvers || registration.scopeAccess {
+		return registration
+	}
+	factory := registration.factory
+	registration.factory = func(resolver Resolver) (any, error) {
+		if full, ok := resolver.(factoryResolver); ok {
+			resolver = restrictedResolver{full}
+		}
+		return factory(resolver)
+	}
+	return registration
+}
+
+// unrestricted returns the Scope or RootProvider a restricted resolver was created from, or
+// resolver itself if it is not restricted.
+func unrestricted(resolver Resolver) Resolver {
+	if restricted, ok := resolver.(restrictedResolver); ok {
+		return restricted.factoryResolver
+	}
+	return resolver
+}
@@ -0,0 +1,126 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type factoryProbe struct {
+	Resolver Resolver
+}
+
+type probeDependency struct{}
+
+func TestWithRestrictedResolvers(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, opts []RegistrationOption, providerOpts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*probeDependency, *probeDependency](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*factoryProbe](registry, lifetime, func(resolver Resolver) (*factoryProbe, error) {
+			if _, err := Resolve[*probeDependency](resolver); err != nil {
+				return nil, err
+			}
+			return &factoryProbe{Resolver: resolver}, nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(providerOpts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	isScopeOrProvider := func(resolver Resolver) bool {
+		switch resolver.(type) {
+		case Scope, RootProvider:
+			return true
+		}
+		return false
+	}
+
+	t.Run("factories receive the scope by default", func(t *testing.T) {
+		probe, err := Resolve[*factoryProbe](newProvider(t, Scoped, nil).NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok := probe.Resolver.(Scope); !ok {
+			t.Fatalf("expected the factory to receive a Scope; got %T", probe.Resolver)
+		}
+	})
+
+	t.Run("factories receive a restricted resolver", func(t *testing.T) {
+		for _, lifetime := range []Lifetime{Singleton, Scoped, Transient} {
+			provider := newProvider(t, lifetime, nil, WithRestrictedResolvers())
+			probe, err := Resolve[*factoryProbe](provider.NewScope())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if isScopeOrProvider(probe.Resolver) {
+				t.Errorf("expected the %v factory not to receive a Scope or RootProvider", lifetime)
+			}
+		}
+	})
+
+	t.Run("restricted resolvers support the sanctioned methods", func(t *testing.T) {
+		provider := newProvider(t, Scoped, nil, WithRestrictedResolvers())
+		scope := provider.NewScope()
+		probe, err := Resolve[*factoryProbe](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := ResolveNew[*probeDependency](probe.Resolver); err != nil {
+			t.Errorf("unexpected error from ResolveNew: %v", err)
+		}
+		if _, err := ResolveAll[*probeDependency](probe.Resolver); err != nil {
+			t.Errorf("unexpected error from ResolveAll: %v", err)
+		}
+		if _, ok := Peek[*factoryProbe](probe.Resolver); !ok {
+			t.Errorf("expected Peek to find the scope's instance")
+		}
+		deferrer, ok := probe.Resolver.(interface {
+			Defer(func(context.Context) error)
+		})
+		if !ok {
+			t.Fatalf("expected the restricted resolver to support Defer")
+		}
+		ran := false
+		deferrer.Defer(func(context.Context) error {
+			ran = true
+			return nil
+		})
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !ran {
+			t.Errorf("expected the deferred cleanup to run when the scope closed")
+		}
+	})
+
+	t.Run("WithScopeAccess exempts a registration", func(t *testing.T) {
+		provider := newProvider(t, Scoped, []RegistrationOption{WithScopeAccess()}, WithRestrictedResolvers())
+		probe, err := Resolve[*factoryProbe](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, ok := probe.Resolver.(Scope); !ok {
+			t.Fatalf("expected the factory to receive a Scope; got %T", probe.Resolver)
+		}
+		for _, info := range provider.Registrations() {
+			if expected := info.Type == reflect.TypeFor[*factoryProbe](); info.ScopeAccess != expected {
+				t.Errorf("expected ScopeAccess %v for %v; got %v", expected, info.Type, info.ScopeAccess)
+			}
+		}
+	})
+
+	t.Run("reports the option in the settings", func(t *testing.T) {
+		provider := newProvider(t, Scoped, nil, WithRestrictedResolvers())
+		if !provider.Settings().RestrictedResolvers {
+			t.Errorf("expected the settings to report RestrictedResolvers")
+		}
+	})
+}
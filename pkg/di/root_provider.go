@@ -1,9 +1,14 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ErrUnknownType is returned when an attempt is made to resolve a value from a provider but the
@@ -29,6 +34,11 @@ func (err UnknownType) Is(target error) bool {
 	return target == ErrUnknownType
 }
 
+// Code returns "unknown_type", the code of an [UnknownType] in [ErrorCodes].
+func (UnknownType) Code() string {
+	return "unknown_type"
+}
+
 // ErrScopedValueRequestedFromRootProvider is returned when an attempt is made to resolve a scoped
 // value from a [RootProvider].
 var ErrScopedValueRequestedFromRootProvider = errors.New("RootProvider cannot resolve a scoped value")
@@ -53,40 +63,513 @@ func (err ScopedValueRequestedFromRootProvider) Is(target error) bool {
 	return target == ErrScopedValueRequestedFromRootProvider
 }
 
+// Code returns "scoped_value_requested_from_root_provider", the code of a [ScopedValueRequestedFromRootProvider] in [ErrorCodes].
+func (ScopedValueRequestedFromRootProvider) Code() string {
+	return "scoped_value_requested_from_root_provider"
+}
+
+// ErrInternal is returned when a provider meets a state that should be impossible, such as a
+// registration whose [Lifetime] is not defined.
+var ErrInternal = errors.New("internal error")
+
+// An InternalError is an [error] indicating that a provider met a state that should be impossible
+// when resolving a value, such as a registration with a [Lifetime] that is not defined or an
+// auto-resolved type it cannot create a default factory for. Registrations are validated when
+// they are added so this indicates a bug in garlic. Calling [errors.Is] with an [InternalError]
+// and [ErrInternal] returns true, and [errors.Is] and [errors.As] also match the underlying error.
+type InternalError struct {
+
+	// Type is the type that was being resolved.
+	Type reflect.Type
+
+	// Lifetime is the undefined lifetime of the registration for [InternalError.Type], if that is
+	// the problem the provider met.
+	Lifetime Lifetime
+
+	// Err is the error describing the problem the provider met, if it is not an undefined
+	// lifetime.
+	Err error
+}
+
+// Error implements [error].
+func (err InternalError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf(
+			"internal error resolving %v: %v; please open an issue at https://github.com/ttd2089/garlic/issues/new",
+			err.Type,
+			err.Err)
+	}
+	return fmt.Sprintf(
+		"internal error: registration for %v has undefined lifetime %d; please open an issue at https://github.com/ttd2089/garlic/issues/new",
+		err.Type,
+		int(err.Lifetime))
+}
+
+// Is indicates that an [InternalError] is [ErrInternal].
+func (err InternalError) Is(target error) bool {
+	return target == ErrInternal
+}
+
+// Unwrap returns the underlying error, if any.
+func (err InternalError) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "internal_error", the code of an [InternalError] in [ErrorCodes].
+func (InternalError) Code() string {
+	return "internal_error"
+}
+
+// providerIDs is the source of identifiers for root providers.
+var providerIDs atomic.Uint64
+
 // A RootProvider is a [Provider] that can resolve [Transient] and [Singleton] values.
+//
+// A RootProvider must be created using [Registry.BuildRootProvider]. The methods of an
+// uninitialized RootProvider, such as the zero value, return an [UninitializedProvider] where they
+// can return an error, and the scopes it creates are uninitialized.
 type RootProvider struct {
-	registrations map[reflect.Type]registration
+	id            string
+	scopeIDs      *atomic.Uint64
+	options       *providerOptions
+	registrations *registrationTable
 	singletons    *instanceMap
+	cleanups      *deferredCleanups
+	closeState    *closeState
+	abandoned     *abandonedClosers
+	frozen        *atomic.Bool
+	skippedFields *skippedFields
+	autoResolved  *autoRegistrations
+	leakedScopes  *leakedScopes
+	openScopes    *openScopes
+	counts        *resolutionCounts
+
+	// transientClosers records the Transient types that have been reported as needing to be
+	// closed by their callers.
+	transientClosers *transientClosers
+
+	// transients records the Transient types resolved from the provider when transient tracking is
+	// enabled.
+	transients *typeSet
+
+	// abandonedFactories tracks the factories that exceeded their timeouts until they return.
+	abandonedFactories *abandonedClosers
+
+	// inFlight counts the top-level resolutions from the provider and its scopes so that evictions
+	// can wait for them.
+	inFlight *resolutionsInFlight
+
+	// timedOutFactories holds the timeouts of the abandoned factories when resolutions fail fast
+	// after a timeout.
+	timedOutFactories *timedOutFactories
+
+	// scopePool holds the states of closed scopes for reuse if the provider pools scopes.
+	scopePool *sync.Pool
+
+	// metrics holds the sinks that receive the measurements of the provider and its scopes.
+	metrics *metricsSinks
+
+	// hosted holds the start order of the provider's hosted services and those that were started.
+	hosted *hostedServices
+
+	// idleScopes closes the provider's scopes that were created WithIdleTimeout once they are idle.
+	idleScopes *idleSweeper
+
+	// fallbackValues holds the values from the fallback resolver when they are cached.
+	fallbackValues *sync.Map
+
+	// parent is the provider the provider was created from using NewChildProvider, if any.
+	parent *RootProvider
+
+	// expectedScopedInstances is the number of Scoped registrations, which is the default capacity
+	// for the instances held by a scope.
+	expectedScopedInstances int
+
+	// constructing is the type whose factory this copy of the provider was passed to, if any.
+	constructing reflect.Type
+
+	// path holds the types being constructed by the resolution this copy of the provider is part
+	// of when provenance is recorded, and is nil otherwise.
+	path *resolutionPath
+
+	// resolution holds the PerResolution values for the top-level resolution this copy of the
+	// provider is part of, if any.
+	resolution *instanceMap
+
+	// release holds the cleanups deferred by the factory for an instance resolved with
+	// ResolveReleasable, if this copy of the provider is resolving one.
+	release *releaseTracker
+
+	// ctx is the context given to ResolveCtx for the resolution this copy of the provider is part
+	// of, if any.
+	ctx context.Context
 }
 
 // NewScope creates a new [Scope] which can resolve [Scoped] values as well as [Transient]
 // and [Singleton] values.
-func (provider RootProvider) NewScope() Scope {
-	return Scope{
-		root:         provider,
-		scopedValues: &instanceMap{},
+func (provider RootProvider) NewScope(opts ...ScopeOption) Scope {
+	return provider.newScope(nil, newScopeOptions(opts))
+}
+
+// NewNamedScope creates a new [Scope] with the given name. Named scopes can resolve [Scoped] values
+// whose registrations were restricted to the name using [WithScopeName] as well as those that were
+// not restricted to any name.
+func (provider RootProvider) NewNamedScope(name string) Scope {
+	return provider.newScope(nil, scopeOptions{name: name})
+}
+
+// newScope creates a new scope. If parent is not nil the new scope records it as its parent and
+// inherits its overrides, and its scoped values if the options say so.
+func (provider RootProvider) newScope(parent *Scope, options scopeOptions) Scope {
+	if !provider.initialized() || (parent != nil && !parent.initialized()) {
+		return Scope{}
+	}
+	provider.constructing = nil
+	provider.path = nil
+	provider.ctx = nil
+	if parent != nil {
+		if err := parent.acquireState(); err != nil {
+			return Scope{}
+		}
+		defer parent.releaseState()
+	}
+	state := provider.newScopeState()
+	capacity := provider.expectedScopedInstances
+	if options.expectedInstances > 0 {
+		capacity = options.expectedInstances
+	}
+	state.scopedValues.init(provider.options.newInstanceStore, capacity)
+	parentID := ""
+	var inherited []*scopeState
+	if parent != nil {
+		parent.state.pinned.Store(true)
+		parentID = parent.id
+		state.overrides.parent = &parent.state.overrides
+		if options.inheritScopedValues {
+			inherited = append([]*scopeState{parent.state}, parent.inherited...)
+		}
+	}
+	scope := Scope{
+		id:         provider.id + "/" + strconv.FormatUint(provider.scopeIDs.Add(1), 10),
+		parent:     parentID,
+		name:       options.name,
+		root:       provider,
+		state:      state,
+		generation: state.generation.Load(),
+		inherited:  inherited,
+
+		closeTimeout: options.closeTimeout,
+		budget:       newResolutionBudget(options.resolutionBudget),
+		idle:         newIdleTracker(options.idleTimeout),
+	}
+	scope.leak = provider.trackLeaks(scope)
+	provider.track(scope)
+	provider.trackIdle(scope)
+	provider.metrics.scopeOpened()
+	return scope
+}
+
+// NewScopeWithContext creates a new [Scope] that closes itself when ctx is done unless it has
+// already been closed. The automatic close is given a deadline of closeTimeout, or no deadline if
+// closeTimeout is 0 or less, and its errors are passed to the callbacks registered with
+// [Scope.OnClose] and to the provider's [WithCloseErrorHandler] since there is no caller to
+// return them to.
+func (provider RootProvider) NewScopeWithContext(ctx context.Context, closeTimeout time.Duration) Scope {
+	return provider.newScopeWithContext(ctx, nil, closeTimeout)
+}
+
+func (provider RootProvider) newScopeWithContext(
+	ctx context.Context,
+	parent *Scope,
+	closeTimeout time.Duration,
+) Scope {
+	scope := provider.newScope(parent, scopeOptions{})
+	if !scope.initialized() {
+		return scope
+	}
+	closing := scope.state.closeState.closingDone()
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeCtx := context.WithoutCancel(ctx)
+			if closeTimeout > 0 {
+				var cancel context.CancelFunc
+				closeCtx, cancel = context.WithTimeout(closeCtx, closeTimeout)
+				defer cancel()
+			}
+			_ = scope.Close(closeCtx, DelegateErrors())
+		case <-closing:
+		}
+	}()
+	return scope
+}
+
+// observe passes event to the provider's [Observer], if it has one.
+func (provider RootProvider) observe(event Event) {
+	if provider.options.observer != nil {
+		provider.options.observer.Observe(event)
 	}
 }
 
-// Resolve returns an instance of the requested type if it was registered as a Transient or
-// Singleton value.
+// ID returns an identifier for the provider that is unique within the process. The identifiers of
+// the scopes created from the provider begin with it.
+func (provider RootProvider) ID() string {
+	return provider.id
+}
+
+// String implements [fmt.Stringer] by summarizing the provider for debugging. The parent of a
+// child provider is the provider it was created from.
+func (provider RootProvider) String() string {
+	if !provider.initialized() {
+		return "RootProvider(uninitialized)"
+	}
+	if provider.parent != nil {
+		return fmt.Sprintf(
+			"RootProvider(id=%s, parent=%s, instances=%d)",
+			provider.id,
+			provider.parent.id,
+			provider.singletons.len())
+	}
+	return fmt.Sprintf("RootProvider(id=%s, instances=%d)", provider.id, provider.singletons.len())
+}
+
+// lineage returns the IDs of the provider and the providers it is a child of, starting with the
+// outermost, separated by slashes.
+func (provider RootProvider) lineage() string {
+	if provider.parent == nil {
+		return provider.id
+	}
+	return provider.parent.lineage() + "/" + provider.id
+}
+
+// Resolve returns an instance of the requested type if it was registered with a lifetime whose
+// [LifetimeStrategy] can resolve it without a [Scope], such as Transient or Singleton.
+//
+// A slice type that is not registered is resolved as a slice of an instance from each
+// registration for its element type, in the order [ResolveAll] returns them, and a map type with
+// string keys that is not registered is resolved as a map of an instance from each [Key] for its
+// element type under the key's name. Each element has the lifetime of its own registration. If
+// there are no registrations for the element type the collection is an [UnknownType] unless the
+// provider was built [WithEmptyCollections].
 func (provider RootProvider) Resolve(typ reflect.Type) (any, error) {
-	registration, ok := provider.registrations[typ]
+	if err := provider.checkInitialized("Resolve"); err != nil {
+		return nil, err
+	}
+	value, err := provider.resolve(typ)
+	provider.metrics.resolved(typ, err)
+	return value, err
+}
+
+func (provider RootProvider) resolve(typ reflect.Type) (any, error) {
+	if provider.constructing == nil {
+		defer provider.inFlight.exit(provider.inFlight.enter())
+	}
+	provider.countResolution(typ)
+	if provider.constructing != nil {
+		// Only the factory for the instance being released defers cleanups to it.
+		provider.release = nil
+	}
+	if provider.resolution == nil && provider.registrations.hasPerResolution() {
+		provider.resolution = &instanceMap{}
+		defer provider.closeResolution(typ, provider.resolution)
+	}
+	rewritten, err := provider.rewrite(typ, provider.constructing, provider.path)
+	if err != nil {
+		if provider.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration{}, false)
+		}
+		return nil, err
+	}
+	typ = rewritten
+	registration, ok := provider.registrations.get(typ)
+	if !ok {
+		value, found, err := provider.resolveCollection(provider, typ)
+		if err == nil && !found {
+			value, found, err = provider.resolveFallback(typ)
+		}
+		if err == nil && !found {
+			registration, ok, err = provider.autoRegistration(typ)
+		}
+		if err != nil {
+			if provider.constructing != nil {
+				return nil, resolutionFailed(err, typ, registration, false)
+			}
+			return nil, err
+		}
+		if found {
+			return value, nil
+		}
+	}
 	if !ok {
-		return nil, UnknownType{
-			Type: typ,
+		err := provider.registrations.unknownType(typ)
+		if provider.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration, false)
+		}
+		return nil, err
+	}
+	if registration.deprecated {
+		if err := provider.resolveDeprecated(typ, registration, provider.constructing, provider.path); err != nil {
+			return nil, resolutionFailed(err, typ, registration, true)
+		}
+	}
+	definition, ok := lookupLifetime(registration.lifetime)
+	if !ok {
+		err := InternalError{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+		}
+		if provider.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration, false)
 		}
+		return nil, err
 	}
-	switch registration.lifetime {
-	case Transient:
-		return registration.factory(provider)
-	case Scoped:
-		return nil, ScopedValueRequestedFromRootProvider{
-			Type: typ,
+	value, err := definition.strategy.Resolve(
+		newEntry(typ, provider.timed(typ, registration)),
+		CacheSet{root: provider},
+		provider.constructingType(typ))
+	if err != nil {
+		return nil, resolutionFailed(err, typ, registration, true)
+	}
+	provider.observeTransient(typ, registration, value)
+	provider.trackTransient(provider.transients, typ, registration)
+	return value, nil
+}
+
+// singletonsFor returns the cache that holds the provider's instance of typ, which is a
+// [SingletonStore] for types shared using [WithSharedSingletons].
+func (provider RootProvider) singletonsFor(typ reflect.Type) *instanceMap {
+	if store, ok := provider.options.sharedSingletons[typ]; ok {
+		return &store.instances
+	}
+	return provider.singletons
+}
+
+// Defer registers a cleanup function to be run when the provider is closed. Deferred cleanups run
+// alongside the closers for the provider's values and in the reverse of the order they were
+// deferred. Factories for [Singleton] values can defer cleanups for resources they create by
+// asserting that the [Resolver] they receive implements Defer.
+func (provider RootProvider) Defer(cleanup func(context.Context) error) {
+	if !provider.initialized() {
+		return
+	}
+	if provider.release != nil {
+		provider.release.add(cleanup)
+		return
+	}
+	provider.cleanups.add(provider.constructing, cleanup)
+}
+
+func (provider RootProvider) constructingType(typ reflect.Type) RootProvider {
+	provider.constructing = typ
+	if provider.options.provenance {
+		provider.path = provider.path.extend(typ)
+	}
+	return provider
+}
+
+// Close stops the provider's hosted services that are still running, as
+// [RootProvider.StopHostedServices] does, and then closes all of the [Singleton] values owned by
+// the provider that implement [ContextCloser] or [Closer] and runs any cleanups deferred with
+// [RootProvider.Defer]. Close gives up on any values that have not finished closing when ctx is
+// done and leaves them to finish in the background; see [RootProvider.AbandonedClosers]. When
+// Close gives up it adds an [IncompleteClose] listing the values that were not confirmed closed to
+// the errors it returns.
+//
+// Instances registered with [RegisterInstance] are not owned by the provider unless they were
+// registered using [WithOwnership].
+//
+// Callbacks registered with [RootProvider.OnClose] are invoked once the values have been closed.
+// Only the first call to Close closes the provider; subsequent calls return no errors.
+//
+// Close returns the errors from [RootProvider.CloseReport].
+func (provider RootProvider) Close(ctx context.Context, opts ...CloseOption) []error {
+	return provider.CloseReport(ctx, opts...).Errors()
+}
+
+// CloseReport closes the provider in the same way as [RootProvider.Close] and returns a
+// [CloseReport] describing how each value and deferred cleanup was handled; see
+// [Scope.CloseReport]. Only the first call closes the provider; subsequent calls return an empty
+// report.
+func (provider RootProvider) CloseReport(ctx context.Context, opts ...CloseOption) CloseReport {
+	if err := provider.checkInitialized("Close"); err != nil {
+		return CloseReport{
+			errs: []error{err},
 		}
-	case Singleton:
-		return provider.singletons.resolve(typ, registration.factory, provider)
-	default:
-		panic("this code should be unreachable: please open a an issue at https://github.com/ttd2089/stahp/issues/new")
+	}
+	if !provider.closeState.begin() {
+		return CloseReport{}
+	}
+	stopErrs := provider.stopHostedServices(ctx)
+	provider.idleScopes.shutdown()
+	provider.singletons.expiry.stop()
+	entries := provider.singletons.entries()
+	registrations := provider.registrations.load()
+	options := newCloseOptions(provider.options, opts)
+	report := closeReport(
+		ctx,
+		ownedEntries(entries, registrations),
+		unownedClosers(entries, registrations),
+		provider.cleanups.take(),
+		provider.abandoned,
+		options)
+	report.errs = provider.closeState.finish(append(stopErrs, report.errs...))
+	provider.metrics.closed(false, report)
+	provider.handleCloseErrors(rootProvenanceScope, options, report)
+	return report
+}
+
+// AbandonedClosers returns the closers that had not finished when a call to Close on the
+// provider, or on any [Scope] created from it, gave up on them, including those that were yet to
+// start, and that have not finished since.
+// Closers are given a context that is cancelled when Close gives up so cooperative closers stop
+// promptly, but [Closer] values cannot be interrupted and will keep running until they return.
+func (provider RootProvider) AbandonedClosers() []AbandonedCloser {
+	if !provider.initialized() {
+		return nil
+	}
+	return provider.abandoned.snapshot()
+}
+
+// CloseJoined calls [RootProvider.Close] and returns the errors it produced joined with [errors.Join], or
+// nil if there were none. Each joined error identifies the value or cleanup that failed, so
+// [errors.As] can still find a specific closer's failure.
+func (provider RootProvider) CloseJoined(ctx context.Context, opts ...CloseOption) error {
+	return errors.Join(provider.Close(ctx, opts...)...)
+}
+
+// OnClose registers a callback to be invoked with the errors from [RootProvider.Close] once the
+// provider has finished closing, e.g. to run process shutdown hooks. Callbacks are invoked exactly
+// once, in the order they were registered, and a callback registered after the provider has closed
+// is invoked immediately. A callback that panics during Close adds an [OnClosePanic] to the errors
+// Close returns; panics from callbacks invoked immediately are recovered and discarded.
+func (provider RootProvider) OnClose(callback func(closeErrors []error)) {
+	if err := provider.checkInitialized("OnClose"); err != nil {
+		if callback != nil {
+			_ = invokeOnClose(callback, []error{err})
+		}
+		return
+	}
+	provider.closeState.onClose(callback)
+}
+
+// closeResolution closes the owned PerResolution values created for a top-level resolution of typ.
+// Since the resolution has returned there is no caller to report errors to so they are reported
+// to the provider's Observer as a PerResolutionCloseFailed.
+func (provider RootProvider) closeResolution(typ reflect.Type, resolution *instanceMap) {
+	entries := ownedEntries(resolution.entries(), provider.registrations.load())
+	if len(entries) == 0 {
+		return
+	}
+	errs := closeValues(
+		context.Background(),
+		entries,
+		nil,
+		provider.abandoned,
+		newCloseOptions(provider.options, nil))
+	if len(errs) > 0 {
+		provider.observe(PerResolutionCloseFailed{
+			Type:   typ,
+			Errors: errs,
+		})
 	}
 }
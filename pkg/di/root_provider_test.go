@@ -1,9 +1,12 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestRootProvider(t *testing.T) {
@@ -29,6 +32,45 @@ func TestRootProvider(t *testing.T) {
 			}
 		})
 
+		t.Run("returns InternalError for a registration with an undefined lifetime", func(t *testing.T) {
+			provider, err := withUndefinedLifetime(t).BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			_, err = provider.Resolve(reflect.TypeFor[*widget]())
+			if !errors.Is(err, ErrInternal) {
+				t.Fatalf("expected %q; got %q", ErrInternal, err)
+			}
+			var internalError InternalError
+			if !errors.As(err, &internalError) {
+				t.Fatalf("expected %v to be %T", err, internalError)
+			}
+			if typ := reflect.TypeFor[*widget](); internalError.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, internalError.Type)
+			}
+			if internalError.Lifetime != undefinedLifetime {
+				t.Errorf("expected err.Lifetime to be %d; got %d", undefinedLifetime, internalError.Lifetime)
+			}
+		})
+
+		t.Run("returns InternalError for a dependency with an undefined lifetime", func(t *testing.T) {
+			type widgetHolder struct {
+				Widget *widget
+			}
+			registry, err := RegisterType[*widgetHolder, *widgetHolder](withUndefinedLifetime(t), Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			_, err = provider.Resolve(reflect.TypeFor[*widgetHolder]())
+			if !errors.Is(err, ErrInternal) {
+				t.Fatalf("expected %q; got %q", ErrInternal, err)
+			}
+		})
+
 		// distinctCapableStruct is required to observe whether pointers point to the same instance
 		// or not because pointers to zero-length structs can be equal even when the pointed values
 		// are distinct.
@@ -100,5 +142,373 @@ func TestRootProvider(t *testing.T) {
 				t.Fatalf("instances are not the same: %p %p", a, b)
 			}
 		})
+		t.Run("per-resolution instances are shared within a single resolution", func(t *testing.T) {
+			registry, err := RegisterType[*perResolutionGraph, *perResolutionGraph](Registry{}, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*mockCloser, *mockCloser](registry, PerResolution)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			for name, resolver := range map[string]Resolver{"provider": provider, "scope": provider.NewScope()} {
+				a, err := Resolve[*perResolutionGraph](resolver)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				b, err := Resolve[*perResolutionGraph](resolver)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if a.First != a.Second {
+					t.Errorf("expected the %s to share the instance within a resolution", name)
+				}
+				if a.First == b.First {
+					t.Errorf("expected the %s not to share the instance across resolutions", name)
+				}
+				if !a.First.closed {
+					t.Errorf("expected the %s to close the instance when the resolution returned", name)
+				}
+			}
+		})
+
+		t.Run("singletons can depend on other singletons", func(t *testing.T) {
+			registry, err := RegisterType[*verifySingleton, *verifySingleton](Registry{}, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*verifyTransient, *verifyTransient](registry, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*mockCloser, *mockCloser](registry, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			value, err := Resolve[*verifySingleton](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			dependency, err := Resolve[*mockCloser](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if value.Transient.Scoped != dependency {
+				t.Fatalf("expected the dependency to be the provider's singleton")
+			}
+		})
+	})
+
+	t.Run("Close", func(t *testing.T) {
+
+		t.Run("closes owned singleton values", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			closer, err := Resolve[*mockCloser](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := provider.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		})
+
+		t.Run("does not close singleton values registered WithoutOwnership", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton, WithoutOwnership())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			closer, err := Resolve[*mockCloser](provider)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := provider.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if closer.closed {
+				t.Fatalf("closer was closed")
+			}
+		})
+
+		t.Run("runs deferred cleanups", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			ran := false
+			provider.Defer(func(context.Context) error {
+				ran = true
+				return nil
+			})
+			if errs := provider.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !ran {
+				t.Fatalf("deferred cleanup was not run")
+			}
+		})
+
+		t.Run("invokes OnClose callbacks exactly once", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			invocations := 0
+			provider.OnClose(func([]error) {
+				invocations++
+			})
+			_ = provider.Close(context.Background())
+			_ = provider.Close(context.Background())
+			if invocations != 1 {
+				t.Fatalf("expected 1 invocation; got %d", invocations)
+			}
+		})
+
+		t.Run("does not close registered instances by default", func(t *testing.T) {
+			closer := &mockCloser{}
+			registry, err := RegisterInstance[*mockCloser](Registry{}, closer)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterInstance: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			if _, err := Resolve[*mockCloser](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := provider.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if closer.closed {
+				t.Fatalf("closer was closed")
+			}
+		})
+
+		t.Run("closes registered instances WithOwnership", func(t *testing.T) {
+			closer := &mockCloser{}
+			registry, err := RegisterInstance[*mockCloser](Registry{}, closer, WithOwnership())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterInstance: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			if _, err := Resolve[*mockCloser](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := provider.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		})
+	})
+
+	t.Run("NewScopeWithContext", func(t *testing.T) {
+
+		t.Run("closes the scope when the context is done", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			scope := provider.NewScopeWithContext(ctx, time.Second)
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			closeErrorsCh := make(chan []error, 1)
+			scope.OnClose(func(closeErrors []error) {
+				closeErrorsCh <- closeErrors
+			})
+			cancel()
+			select {
+			case closeErrors := <-closeErrorsCh:
+				if len(closeErrors) != 1 || !errors.Is(closeErrors[0], expectedErr) {
+					t.Fatalf("expected OnClose to receive %v; got %v", expectedErr, closeErrors)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("scope was not closed when the context was done")
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+		})
+
+		t.Run("does not leak goroutines when the scope is closed first", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			baseline := runtime.NumGoroutine()
+			for range 100 {
+				scope := provider.NewScopeWithContext(ctx, time.Second)
+				if errs := scope.Close(context.Background()); len(errs) != 0 {
+					t.Fatalf("unexpected errors from Close: %v", errs)
+				}
+			}
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > baseline {
+				t.Fatalf("expected at most %d goroutines; got %d", baseline, n)
+			}
+		})
 	})
+
+	t.Run("Freeze", func(t *testing.T) {
+
+		t.Run("prevents mutation but not resolution", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildMutableProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildMutableProvider: %v", err)
+			}
+			if err := provider.Freeze(); err != nil {
+				t.Fatalf("unexpected error from Freeze: %v", err)
+			}
+			if !provider.Frozen() {
+				t.Fatalf("expected provider to be frozen")
+			}
+			if err := provider.Swap(reflect.TypeFor[*mockCloser](), registry); !errors.Is(err, ErrProviderFrozen) {
+				t.Fatalf("expected %v to be %v", err, ErrProviderFrozen)
+			}
+			scope := provider.NewScope()
+			if err := WithInstance(scope, &mockCloser{}); !errors.Is(err, ErrProviderFrozen) {
+				t.Fatalf("expected %v to be %v", err, ErrProviderFrozen)
+			}
+			if err := WithInstance(scope, &mockContextCloser{}); err != nil {
+				t.Fatalf("unexpected error from WithInstance for an unregistered type: %v", err)
+			}
+			closer, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		})
+
+		t.Run("does not freeze a provider that fails verification", func(t *testing.T) {
+			registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			if err := provider.Freeze(); !errors.Is(err, ErrVerificationFailed) {
+				t.Fatalf("expected %v to be %v", err, ErrVerificationFailed)
+			}
+			if provider.Frozen() {
+				t.Fatalf("expected provider not to be frozen")
+			}
+		})
+	})
+
+	t.Run("AbandonedClosers", func(t *testing.T) {
+
+		t.Run("reports closers that Close gave up on until they finish", func(t *testing.T) {
+			closer := &releasableCloser{
+				release: make(chan struct{}),
+			}
+			registry, err := RegisterFactory[*releasableCloser](Registry{}, Scoped, func(Resolver) (*releasableCloser, error) {
+				return closer, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := scope.Resolve(reflect.TypeFor[*releasableCloser]()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			_ = scope.Close(ctx)
+			abandoned := provider.AbandonedClosers()
+			if len(abandoned) != 1 {
+				t.Fatalf("expected 1 abandoned closer; got %d (%v)", len(abandoned), abandoned)
+			}
+			if typ := reflect.TypeFor[*releasableCloser](); abandoned[0].Type != typ {
+				t.Errorf("expected abandoned[0].Type to be %v; got %v", typ, abandoned[0].Type)
+			}
+			close(closer.release)
+			deadline := time.Now().Add(time.Second)
+			for len(provider.AbandonedClosers()) != 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if abandoned := provider.AbandonedClosers(); len(abandoned) != 0 {
+				t.Fatalf("expected no abandoned closers after release; got %v", abandoned)
+			}
+		})
+	})
+}
+
+// undefinedLifetime is a [Lifetime] that is never defined.
+const undefinedLifetime = Lifetime(-1)
+
+// withUndefinedLifetime returns a registry with a registration for *widget whose lifetime has been
+// replaced with [undefinedLifetime], which the registration functions would reject.
+func withUndefinedLifetime(t *testing.T) Registry {
+	t.Helper()
+	registry, err := RegisterType[*widget, *widget](Registry{}, Transient)
+	if err != nil {
+		t.Fatalf("unexpected error from RegisterType: %v", err)
+	}
+	typ := reflect.TypeFor[*widget]()
+	registration := registry.registrations[typ]
+	registration.lifetime = undefinedLifetime
+	registry.registrations[typ] = registration
+	return registry
+}
+
+type perResolutionGraph struct {
+	First  *mockCloser
+	Second *mockCloser
 }
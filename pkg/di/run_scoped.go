@@ -0,0 +1,33 @@
+package di
+
+import (
+	"context"
+	"errors"
+)
+
+// RunScoped creates a new [Scope] from provider, calls fn with it, and closes the scope with ctx
+// once fn returns. The error from fn is returned joined with any errors from closing the scope
+// using [errors.Join]. The scope is closed even if fn panics, after which the panic continues.
+//
+// RunScoped is the recommended way to handle a unit of work such as a request or a message with
+// its own scope since it guarantees the scope is torn down.
+func RunScoped(ctx context.Context, provider RootProvider, fn func(Scope) error) (err error) {
+	scope := provider.NewScope()
+	defer func() {
+		err = errors.Join(err, scope.CloseJoined(ctx))
+	}()
+	return fn(scope)
+}
+
+// WithScope creates a new [Scope] from provider, calls fn with it, and closes the scope with ctx
+// once fn returns. The result from fn is always returned, even if closing the scope fails, along
+// with the error from fn joined with any errors from closing the scope using [errors.Join]; it is
+// up to the caller to decide whether a result is usable when only the close failed. The scope is
+// closed even if fn panics, after which the panic continues.
+func WithScope[T any](ctx context.Context, provider RootProvider, fn func(Scope) (T, error)) (result T, err error) {
+	scope := provider.NewScope()
+	defer func() {
+		err = errors.Join(err, scope.CloseJoined(ctx))
+	}()
+	return fn(scope)
+}
@@ -0,0 +1,234 @@
+package di
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRunScoped(t *testing.T) {
+
+	t.Run("closes the scope after fn returns", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		var closer *mockCloser
+		err = RunScoped(context.Background(), provider, func(scope Scope) error {
+			closer, err = Resolve[*mockCloser](scope)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RunScoped: %v", err)
+		}
+		if !closer.closed {
+			t.Fatalf("closer was not closed")
+		}
+	})
+
+	t.Run("joins the error from fn with errors from Close", func(t *testing.T) {
+		expectedErr := errors.New("expected error")
+		expectedCloseErr := errors.New("expected close error")
+		registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+			return &errorContextCloser{
+				err: expectedCloseErr,
+			}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		err = RunScoped(context.Background(), provider, func(scope Scope) error {
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			return expectedErr
+		})
+		if !errors.Is(err, expectedErr) {
+			t.Fatalf("expected %v to be %v", err, expectedErr)
+		}
+		if !errors.Is(err, expectedCloseErr) {
+			t.Fatalf("expected %v to be %v", err, expectedCloseErr)
+		}
+	})
+
+	t.Run("closes the scope before propagating a panic from fn", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		var closer *mockCloser
+		defer func() {
+			if v := recover(); v != "expected panic" {
+				t.Fatalf("expected RunScoped to panic with %q; got %v", "expected panic", v)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		}()
+		_ = RunScoped(context.Background(), provider, func(scope Scope) error {
+			closer, err = Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			panic("expected panic")
+		})
+	})
+}
+
+func TestWithScope(t *testing.T) {
+
+	t.Run("returns the result from fn and closes the scope", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		closer, err := WithScope(context.Background(), provider, func(scope Scope) (*mockCloser, error) {
+			return Resolve[*mockCloser](scope)
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from WithScope: %v", err)
+		}
+		if closer == nil {
+			t.Fatalf("expected WithScope to return the result from fn")
+		}
+		if !closer.closed {
+			t.Fatalf("closer was not closed")
+		}
+	})
+
+	t.Run("returns the result from fn when Close fails", func(t *testing.T) {
+		expectedCloseErr := errors.New("expected close error")
+		registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+			return &errorContextCloser{
+				err: expectedCloseErr,
+			}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		result, err := WithScope(context.Background(), provider, func(scope Scope) (string, error) {
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			return "result", nil
+		})
+		if !errors.Is(err, expectedCloseErr) {
+			t.Fatalf("expected %v to be %v", err, expectedCloseErr)
+		}
+		if result != "result" {
+			t.Fatalf("expected result to be %q; got %q", "result", result)
+		}
+	})
+
+	t.Run("closes the scope before propagating a panic from fn", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		var closer *mockCloser
+		defer func() {
+			if v := recover(); v != "expected panic" {
+				t.Fatalf("expected WithScope to panic with %q; got %v", "expected panic", v)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		}()
+		_, _ = WithScope(context.Background(), provider, func(scope Scope) (int, error) {
+			closer, err = Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			panic("expected panic")
+		})
+	})
+}
+
+type exampleUsers struct{}
+
+func (exampleUsers) Name(id string) string {
+	return "user " + id
+}
+
+type exampleOrders struct{}
+
+func (exampleOrders) Count(string) int {
+	return 3
+}
+
+type exampleRequestLog struct {
+	entries []string
+}
+
+type exampleResponse struct {
+	Name   string `json:"name"`
+	Orders int    `json:"orders"`
+}
+
+func ExampleWithScope() {
+	registry, _ := RegisterType[*exampleUsers, *exampleUsers](Registry{}, Singleton)
+	registry, _ = RegisterType[*exampleOrders, *exampleOrders](registry, Singleton)
+	registry, _ = RegisterType[*exampleRequestLog, *exampleRequestLog](registry, Scoped)
+	provider, _ := registry.BuildRootProvider()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, err := WithScope(r.Context(), provider, func(scope Scope) (exampleResponse, error) {
+			users, err := Resolve[*exampleUsers](scope)
+			if err != nil {
+				return exampleResponse{}, err
+			}
+			orders, err := Resolve[*exampleOrders](scope)
+			if err != nil {
+				return exampleResponse{}, err
+			}
+			log, err := Resolve[*exampleRequestLog](scope)
+			if err != nil {
+				return exampleResponse{}, err
+			}
+			id := r.URL.Query().Get("id")
+			log.entries = append(log.entries, "lookup "+id)
+			return exampleResponse{
+				Name:   users.Name(id),
+				Orders: orders.Count(id),
+			}, nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?id=42", nil))
+	fmt.Print(recorder.Body.String())
+	// Output: {"name":"user 42","orders":3}
+}
@@ -2,93 +2,483 @@ package di
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrScopeNameMismatch is returned when an attempt is made to resolve a [Scoped] value that was
+// registered using [WithScopeName] from a [Scope] with a different name.
+var ErrScopeNameMismatch = errors.New("scoped value cannot be resolved from a scope with a different name")
+
+// A ScopeNameMismatch is an [error] indicating that an attempt was made to resolve a [Scoped] value
+// that was registered using [WithScopeName] from a [Scope] with a different name. Calling
+// [errors.Is] with a ScopeNameMismatch and [ErrScopeNameMismatch] returns true.
+type ScopeNameMismatch struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Required is the scope name the registration was restricted to.
+	Required string
+
+	// Actual is the name of the scope the value was requested from, which is empty for unnamed
+	// scopes.
+	Actual string
+}
+
+// Error implements [error].
+func (err ScopeNameMismatch) Error() string {
+	return fmt.Sprintf(
+		"scoped value of type %v requires a scope named %q but was requested from scope named %q",
+		err.Type,
+		err.Required,
+		err.Actual)
+}
+
+// Is indicates that a [ScopeNameMismatch] is [ErrScopeNameMismatch].
+func (ScopeNameMismatch) Is(target error) bool {
+	return target == ErrScopeNameMismatch
+}
+
+// Code returns "scope_name_mismatch", the code of a [ScopeNameMismatch] in [ErrorCodes].
+func (ScopeNameMismatch) Code() string {
+	return "scope_name_mismatch"
+}
+
+// ErrScopeClosed is returned when an attempt is made to reset a [Scope] after it has been closed,
+// to resolve from a scope that has expired, or to use a pooled scope after it has been closed.
+var ErrScopeClosed = errors.New("scope has been closed")
+
+// A ScopeClosed is an [error] indicating that an attempt was made to reset a [Scope] after it was
+// closed, to resolve from a scope that expired after its [WithIdleTimeout], or to use a scope after
+// it was closed and its state was returned to the pool of a provider built [WithScopePooling].
+// Calling [errors.Is] with a ScopeClosed and [ErrScopeClosed] returns true.
+type ScopeClosed struct {
+
+	// ID is the identifier of the closed scope.
+	ID string
+}
+
+// Error implements [error].
+func (err ScopeClosed) Error() string {
+	return fmt.Sprintf("scope %s has been closed", err.ID)
+}
+
+// Is indicates that a [ScopeClosed] is [ErrScopeClosed].
+func (ScopeClosed) Is(target error) bool {
+	return target == ErrScopeClosed
+}
+
+// Code returns "scope_closed", the code of a [ScopeClosed] in [ErrorCodes].
+func (ScopeClosed) Code() string {
+	return "scope_closed"
+}
+
 // A Scope is a [Provider] that can resolve [Scoped] values in addition to [Transient] and
 // [Singleton] values. A Scope will create a single instance of a value for a type registered
+//
+// A Scope must be created from an initialized [RootProvider] or another Scope. The methods of an
+// uninitialized Scope, such as the zero value, return an [UninitializedScope] where they can
+// return an error, and the scopes it creates are uninitialized.
 type Scope struct {
-	root         RootProvider
-	scopedValues *instanceMap
+	id     string
+	parent string
+	name   string
+	root   RootProvider
+	state  *scopeState
+
+	// generation is the generation of the state when the scope was created, which no longer
+	// matches once the state of a pooled scope has been recycled.
+	generation uint64
+
+	// inherited holds the state of the ancestors the scope inherits scoped values from, nearest
+	// first.
+	inherited []*scopeState
+
+	// ownsRoot is true for a scope created by Fork, whose provider holds the extra registrations
+	// and must be closed along with the scope.
+	ownsRoot bool
+
+	// closeTimeout is the time CloseWithDefault allows for closing the scope.
+	closeTimeout time.Duration
+
+	// budget counts the scope's resolutions if it was given a resolution budget.
+	budget *resolutionBudget
+
+	// idle tracks the use of the scope if it was given an idle timeout.
+	idle *idleTracker
+
+	// leak reports the scope if it is garbage collected without being closed, if leak detection
+	// is enabled.
+	leak *leakTracker
+
+	// constructing is the type whose factory this copy of the scope was passed to, if any.
+	constructing reflect.Type
+
+	// path holds the types being constructed by the resolution this copy of the scope is part of
+	// when provenance is recorded, and is nil otherwise.
+	path *resolutionPath
+
+	// resolution holds the PerResolution values for the top-level resolution this copy of the
+	// scope is part of, if any.
+	resolution *instanceMap
+
+	// release holds the cleanups deferred by the factory for an instance resolved with
+	// ResolveReleasable, if this copy of the scope is resolving one.
+	release *releaseTracker
+
+	// ctx is the context given to ResolveCtx for the resolution this copy of the scope is part of,
+	// if any.
+	ctx context.Context
+}
+
+// scopeState holds the mutable state of a scope, shared by each copy of the scope. The state of a
+// pooled scope is reused after the scope is closed, so each operation on a pooled scope acquires
+// the state to check that its generation still matches that of the scope.
+type scopeState struct {
+	scopedValues instanceMap
+	transients   typeSet
+	overrides    scopeOverrides
+	cleanups     deferredCleanups
+	closeState   closeState
+
+	// generation is incremented when the state of a pooled scope is recycled, and users counts the
+	// operations that have acquired the state.
+	generation atomic.Uint64
+	users      atomic.Int64
+
+	// pinned is true once other scopes have been created from the scope, since they keep pointers
+	// to its state, so that the state is never recycled.
+	pinned atomic.Bool
+
+	// resetting is held for writing while the scope is reset and for reading by each top-level
+	// resolution and eviction so that they never see a partly reset scope.
+	resetting sync.RWMutex
 }
 
 // NewScope creates a new [Scope] which can resolve [Scoped] values as well as [Transient]
 // and [Singleton] values.
-func (scope Scope) NewScope() Scope {
-	return scope.root.NewScope()
+func (scope Scope) NewScope(opts ...ScopeOption) Scope {
+	return scope.root.newScope(&scope, newScopeOptions(opts))
+}
+
+// NewNamedScope creates a new [Scope] with the given name; see [RootProvider.NewNamedScope].
+func (scope Scope) NewNamedScope(name string) Scope {
+	return scope.root.newScope(&scope, scopeOptions{
+		name: name,
+	})
+}
+
+// NewChildScope creates a new [Scope] whose parent is the scope in the same way as
+// [Scope.NewScope]. Use [InheritScopedValues] to make the child share the scoped values its
+// parent has already created.
+func (scope Scope) NewChildScope(opts ...ScopeOption) Scope {
+	return scope.NewScope(opts...)
+}
+
+// NewScopeWithContext creates a new [Scope] that closes itself when ctx is done; see
+// [RootProvider.NewScopeWithContext].
+func (scope Scope) NewScopeWithContext(ctx context.Context, closeTimeout time.Duration) Scope {
+	return scope.root.newScopeWithContext(ctx, &scope, closeTimeout)
+}
+
+// ID returns an identifier for the scope that is assigned when the scope is created and is never
+// reused by another scope created from the same [RootProvider].
+func (scope Scope) ID() string {
+	return scope.id
+}
+
+// Name returns the name the scope was created with, or an empty string for unnamed scopes.
+func (scope Scope) Name() string {
+	return scope.name
+}
+
+// String implements [fmt.Stringer] by summarizing the scope for debugging. The parent of a scope
+// is the scope it was created from, if any. The summary of a scope created from a child provider
+// also lists the IDs of the provider and its ancestors, starting with the outermost, so that it
+// shows which provider layer the scope came from. The summary of a pooled scope whose state has
+// been recycled says that it is closed instead of counting its instances.
+func (scope Scope) String() string {
+	if !scope.initialized() {
+		return "Scope(uninitialized)"
+	}
+	instances := "closed"
+	if scope.acquireState() == nil {
+		instances = fmt.Sprintf("instances=%d", scope.state.scopedValues.len())
+		scope.releaseState()
+	}
+	parent := scope.parent
+	if parent == "" {
+		parent = scope.root.id
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Scope(id=%s, ", scope.id)
+	if scope.name != "" {
+		fmt.Fprintf(&b, "name=%s, ", scope.name)
+	}
+	fmt.Fprintf(&b, "parent=%s, ", parent)
+	if scope.root.parent != nil {
+		fmt.Fprintf(&b, "provider=%s, ", scope.root.lineage())
+	}
+	fmt.Fprintf(&b, "%s)", instances)
+	return b.String()
 }
 
-// Resolve returns an instance of the requested type if it was registered.
+// Resolve returns an instance of the requested type if it was registered. Unregistered slice and
+// map types are resolved from the registrations for their elements as described by
+// [RootProvider.Resolve].
 func (scope Scope) Resolve(typ reflect.Type) (any, error) {
-	registration, ok := scope.root.registrations[typ]
-	if ok && registration.lifetime == Scoped {
-		return scope.scopedValues.resolve(typ, registration.factory, scope)
+	if err := scope.checkInitialized("Resolve"); err != nil {
+		return nil, err
 	}
-	return scope.root.Resolve(typ)
+	value, err := scope.resolve(typ)
+	scope.root.metrics.resolved(typ, err)
+	return value, err
 }
 
-// A ContextCloser is a value that can be closed with a [context.Context].
-type ContextCloser interface {
-	Close(context.Context) error
+func (scope Scope) resolve(typ reflect.Type) (any, error) {
+	if err := scope.acquireState(); err != nil {
+		return nil, err
+	}
+	defer scope.releaseState()
+	if err := scope.idle.enter(scope.id); err != nil {
+		return nil, err
+	}
+	defer scope.idle.exit()
+	if scope.constructing == nil {
+		scope.state.resetting.RLock()
+		defer scope.state.resetting.RUnlock()
+		defer scope.root.inFlight.exit(scope.root.inFlight.enter())
+	}
+	scope.root.countResolution(typ)
+	if err := scope.spend(typ); err != nil {
+		return nil, err
+	}
+	if scope.constructing != nil {
+		// Only the factory for the instance being released defers cleanups to it.
+		scope.release = nil
+	}
+	if instance, ok := scope.state.overrides.resolve(typ); ok {
+		return instance, nil
+	}
+	if scope.resolution == nil && scope.root.registrations.hasPerResolution() {
+		scope.resolution = &instanceMap{}
+		defer scope.root.closeResolution(typ, scope.resolution)
+	}
+	rewritten, err := scope.root.rewrite(typ, scope.constructing, scope.path)
+	if err != nil {
+		if scope.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration{}, false)
+		}
+		return nil, err
+	}
+	typ = rewritten
+	registration, ok := scope.root.registrations.get(typ)
+	if !ok {
+		value, found, err := scope.root.resolveCollection(scope, typ)
+		if err == nil && !found {
+			value, found, err = scope.root.resolveFallback(typ)
+		}
+		if err == nil && !found {
+			registration, ok, err = scope.root.autoRegistration(typ)
+		}
+		if err != nil {
+			if scope.constructing != nil {
+				return nil, resolutionFailed(err, typ, registration, false)
+			}
+			return nil, err
+		}
+		if found {
+			return value, nil
+		}
+	}
+	if !ok {
+		err := scope.root.registrations.unknownType(typ)
+		if scope.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration, false)
+		}
+		return nil, err
+	}
+	if registration.deprecated {
+		if err := scope.root.resolveDeprecated(typ, registration, scope.constructing, scope.path); err != nil {
+			return nil, resolutionFailed(err, typ, registration, true)
+		}
+	}
+	definition, ok := lookupLifetime(registration.lifetime)
+	if !ok {
+		err := InternalError{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+		}
+		if scope.constructing != nil {
+			return nil, resolutionFailed(err, typ, registration, false)
+		}
+		return nil, err
+	}
+	root := scope.root
+	root.resolution = scope.resolution
+	root.path = scope.path
+	root.ctx = scope.ctx
+	value, err := definition.strategy.Resolve(
+		newEntry(typ, scope.root.timed(typ, registration)),
+		CacheSet{root: root, scope: &scope},
+		scope.constructingType(typ))
+	if err != nil {
+		return nil, resolutionFailed(err, typ, registration, true)
+	}
+	scope.root.observeTransient(typ, registration, value)
+	scope.root.trackTransient(&scope.state.transients, typ, registration)
+	return value, nil
 }
 
-// A Closer is a value that can be closed.
-type Closer interface {
-	Close() error
+// Defer registers a cleanup function to be run when the scope is closed. Deferred cleanups run
+// alongside the closers for the scope's values and in the reverse of the order they were
+// deferred. Factories can defer cleanups for resources they create by asserting that the
+// [Resolver] they receive implements Defer. Cleanups deferred on a pooled scope whose state has
+// been recycled are ignored.
+func (scope Scope) Defer(cleanup func(context.Context) error) {
+	if !scope.initialized() || scope.acquireState() != nil {
+		return
+	}
+	defer scope.releaseState()
+	if scope.release != nil {
+		scope.release.add(cleanup)
+		return
+	}
+	scope.state.cleanups.add(scope.constructing, cleanup)
 }
 
-func (scope Scope) Close(ctx context.Context) []error {
+func (scope Scope) constructingType(typ reflect.Type) Scope {
+	scope.constructing = typ
+	if scope.root.options.provenance {
+		scope.path = scope.path.extend(typ)
+	}
+	return scope
+}
 
-	values := scope.scopedValues.values()
-	contextClosers := make([]ContextCloser, 0, len(values))
-	closers := make([]Closer, 0, len(values))
-	for _, value := range values {
-		if contextCloser, ok := value.(ContextCloser); ok {
-			contextClosers = append(contextClosers, contextCloser)
+// inheritedValue returns the [Scoped] value of typ held by the nearest ancestor the scope inherits
+// from, skipping ancestors that have begun closing.
+func (scope Scope) inheritedValue(typ reflect.Type) (any, bool) {
+	for _, ancestor := range scope.inherited {
+		if ancestor.closeState.isClosing() {
 			continue
 		}
-		if closer, ok := value.(Closer); ok {
-			closers = append(closers, closer)
+		if value, ok := ancestor.scopedValues.get(typ); ok {
+			return value, true
 		}
 	}
+	return nil, false
+}
 
-	n := len(contextClosers) + len(closers)
-	closeErrorsCh := make(chan error, n)
-	closeErrors := make([]error, 0, n)
-
-	wg := sync.WaitGroup{}
-	wg.Add(n)
-	wgDone := make(chan struct{})
-	go func() {
-		defer close(wgDone)
-		wg.Wait()
-	}()
-
-	for _, contextCloser := range contextClosers {
-		go func() {
-			defer wg.Done()
-			closeErrorsCh <- contextCloser.Close(ctx)
-		}()
-	}
-
-	for _, closer := range closers {
-		go func() {
-			defer wg.Done()
-			closeErrorsCh <- closer.Close()
-		}()
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return closeErrors
-		case <-wgDone:
-			return closeErrors
-		case err := <-closeErrorsCh:
-			if err != nil {
-				closeErrors = append(closeErrors, err)
-			}
+// Close closes all of the [Scoped] values owned by the scope that implement [ContextCloser] or
+// [Closer] and runs any cleanups deferred with [Scope.Defer]. Close gives up on any values that
+// have not finished closing when ctx is done, leaving them to finish in the background; see
+// [RootProvider.AbandonedClosers]. When Close gives up it adds an [IncompleteClose] listing the
+// values that were not confirmed closed to the errors it returns.
+//
+// Callbacks registered with [Scope.OnClose] are invoked, and the OnScopeClose method of each
+// [LifetimeStrategy] is called, once the values have been closed. Only the first call to Close
+// closes the scope; subsequent calls return no errors. If the provider was built
+// [WithScopePooling] the scope's state is returned to the pool once the scope has closed.
+//
+// Close returns the errors from [Scope.CloseReport].
+func (scope Scope) Close(ctx context.Context, opts ...CloseOption) []error {
+	return scope.CloseReport(ctx, opts...).Errors()
+}
+
+// CloseReport closes the scope in the same way as [Scope.Close] and returns a [CloseReport]
+// describing how each value and deferred cleanup was handled, e.g. for diagnosing slow or failed
+// shutdowns. The report lists the values the scope does not own that implement [ContextCloser] or
+// [Closer] as skipped. Only the first call closes the scope; subsequent calls return an empty
+// report.
+func (scope Scope) CloseReport(ctx context.Context, opts ...CloseOption) CloseReport {
+	if err := scope.checkInitialized("Close"); err != nil {
+		return CloseReport{
+			errs: []error{err},
+		}
+	}
+	if scope.acquireState() != nil {
+		return CloseReport{}
+	}
+	if !scope.state.closeState.begin() {
+		scope.releaseState()
+		return CloseReport{}
+	}
+	scope.leak.untrack()
+	scope.root.untrack(scope.id)
+	scope.idle.close()
+	scope.root.idleScopes.untrack(scope)
+	scope.state.scopedValues.expiry.stop()
+	entries := scope.state.scopedValues.entries()
+	registrations := scope.root.registrations.load()
+	options := newCloseOptions(scope.root.options, opts)
+	report := closeReport(
+		ctx,
+		ownedEntries(entries, registrations),
+		unownedClosers(entries, registrations),
+		scope.state.cleanups.take(),
+		scope.root.abandoned,
+		options)
+	if scope.ownsRoot && !report.Halted {
+		report = report.merge(scope.root.CloseReport(ctx, append(slices.Clip(opts), withoutDelegation)...))
+	}
+	report.errs = scope.state.closeState.finish(report.errs)
+	scope.root.metrics.closed(true, report)
+	scope.root.handleCloseErrors(scope.id, options, report)
+	for _, definition := range *lifetimes.Load() {
+		definition.strategy.OnScopeClose(scope)
+	}
+	scope.releaseState()
+	scope.recycle()
+	return report
+}
+
+// CloseWithDefault closes the scope in the same way as [Scope.Close] using a context that is not
+// cancelled but expires after the timeout given by [WithCloseTimeout] when the scope was created,
+// or that never expires if no timeout was given. It avoids closing a scope with a context that is
+// already done, such as the context of a request that has finished.
+func (scope Scope) CloseWithDefault(opts ...CloseOption) []error {
+	ctx := context.Background()
+	if scope.closeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, scope.closeTimeout)
+		defer cancel()
+	}
+	return scope.Close(ctx, opts...)
+}
+
+// CloseJoined calls [Scope.Close] and returns the errors it produced joined with [errors.Join], or
+// nil if there were none. Each joined error identifies the value or cleanup that failed, so
+// [errors.As] can still find a specific closer's failure.
+func (scope Scope) CloseJoined(ctx context.Context, opts ...CloseOption) error {
+	return errors.Join(scope.Close(ctx, opts...)...)
+}
+
+// OnClose registers a callback to be invoked with the errors from [Scope.Close] once the scope has
+// finished closing. Callbacks are invoked exactly once, in the order they were registered, and a
+// callback registered after the scope has closed is invoked immediately. A callback that panics
+// during Close adds an [OnClosePanic] to the errors Close returns; panics from callbacks invoked
+// immediately are recovered and discarded. Callbacks registered on a pooled scope whose state has
+// been recycled are invoked immediately with no errors.
+func (scope Scope) OnClose(callback func(closeErrors []error)) {
+	if err := scope.checkInitialized("OnClose"); err != nil {
+		if callback != nil {
+			_ = invokeOnClose(callback, []error{err})
+		}
+		return
+	}
+	if scope.acquireState() != nil {
+		if callback != nil {
+			_ = invokeOnClose(callback, nil)
 		}
+		return
 	}
+	defer scope.releaseState()
+	scope.state.closeState.onClose(callback)
 }
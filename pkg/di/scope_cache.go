@@ -0,0 +1,230 @@
+package di
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A ScopeCacheOption configures optional behavior for a [ScopeCache].
+type ScopeCacheOption func(*scopeCacheOptions)
+
+type scopeCacheOptions struct {
+	maxScopes    int
+	idleTTL      time.Duration
+	closeTimeout time.Duration
+	onEvictError func(key string, closeErrors []error)
+}
+
+// WithMaxScopes limits the number of scopes a [ScopeCache] holds at once. When a new scope would
+// exceed the limit the least recently used scope is evicted. A limit of 0 or less means the number
+// of scopes is unlimited, which is the default.
+func WithMaxScopes(limit int) ScopeCacheOption {
+	return func(options *scopeCacheOptions) {
+		options.maxScopes = limit
+	}
+}
+
+// WithIdleTTL makes a [ScopeCache] evict scopes that have not been returned by
+// [ScopeCache.ScopeFor] for at least ttl. A ttl of 0 or less means scopes are never evicted for
+// being idle, which is the default.
+func WithIdleTTL(ttl time.Duration) ScopeCacheOption {
+	return func(options *scopeCacheOptions) {
+		options.idleTTL = ttl
+	}
+}
+
+// WithEvictionCloseTimeout sets the deadline for closing a scope evicted from a [ScopeCache]. A
+// timeout of 0 or less means evicted scopes are closed without a deadline, which is the default.
+func WithEvictionCloseTimeout(timeout time.Duration) ScopeCacheOption {
+	return func(options *scopeCacheOptions) {
+		options.closeTimeout = timeout
+	}
+}
+
+// WithEvictionErrorHandler sets a function to be called with the key and the errors from closing
+// a scope evicted from a [ScopeCache] when closing it fails. Evicted scopes are closed in the
+// background so there is no caller to return the errors to; without a handler they are passed to
+// the provider's [WithCloseErrorHandler].
+func WithEvictionErrorHandler(handler func(key string, closeErrors []error)) ScopeCacheOption {
+	return func(options *scopeCacheOptions) {
+		options.onEvictError = handler
+	}
+}
+
+// A ScopeCache holds long-lived scopes by key, e.g. one scope per tenant in a multi-tenant
+// service, creating them on demand and closing them when they are evicted. A ScopeCache must be
+// created with [NewScopeCache] and is safe for concurrent use.
+type ScopeCache struct {
+	provider RootProvider
+	options  scopeCacheOptions
+
+	mu      sync.Mutex
+	closed  bool
+	entries map[string]*scopeCacheEntry
+	uses    uint64
+
+	evictions sync.WaitGroup
+	stop      chan struct{}
+}
+
+type scopeCacheEntry struct {
+	scope    Scope
+	lastUsed time.Time
+
+	// lastUse orders the entries by when they were last used since lastUsed may not distinguish
+	// uses that happen in quick succession.
+	lastUse uint64
+}
+
+// NewScopeCache creates a [ScopeCache] that creates its scopes from provider. If the cache is
+// given an idle TTL with [WithIdleTTL] it checks for idle scopes in the background until it is
+// closed with [ScopeCache.Close].
+func NewScopeCache(provider RootProvider, opts ...ScopeCacheOption) *ScopeCache {
+	options := scopeCacheOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	cache := &ScopeCache{
+		provider: provider,
+		options:  options,
+		entries:  make(map[string]*scopeCacheEntry),
+		stop:     make(chan struct{}),
+	}
+	if options.idleTTL > 0 {
+		go cache.evictIdleScopes()
+	}
+	return cache
+}
+
+// ScopeFor returns the scope for key, creating it if the cache does not hold one. Concurrent calls
+// for the same key return the same scope. A scope that has been evicted is closed, so callers
+// should call ScopeFor for each unit of work rather than holding on to the scope it returns.
+//
+// After the cache has been closed ScopeFor returns a new scope that is not held by the cache and
+// must be closed by the caller.
+func (cache *ScopeCache) ScopeFor(key string) Scope {
+	cache.mu.Lock()
+	if cache.closed {
+		cache.mu.Unlock()
+		return cache.provider.NewScope()
+	}
+	now := time.Now()
+	cache.uses++
+	if entry, ok := cache.entries[key]; ok {
+		entry.lastUsed = now
+		entry.lastUse = cache.uses
+		cache.mu.Unlock()
+		return entry.scope
+	}
+	scope := cache.provider.NewScope()
+	cache.entries[key] = &scopeCacheEntry{
+		scope:    scope,
+		lastUsed: now,
+		lastUse:  cache.uses,
+	}
+	evicted := map[string]Scope{}
+	for cache.options.maxScopes > 0 && len(cache.entries) > cache.options.maxScopes {
+		lruKey := ""
+		var lru *scopeCacheEntry
+		for key, entry := range cache.entries {
+			if lru == nil || entry.lastUse < lru.lastUse {
+				lruKey, lru = key, entry
+			}
+		}
+		delete(cache.entries, lruKey)
+		evicted[lruKey] = lru.scope
+	}
+	cache.evictions.Add(len(evicted))
+	cache.mu.Unlock()
+	for key, scope := range evicted {
+		cache.evict(key, scope)
+	}
+	return scope
+}
+
+// Close closes every scope held by the cache with ctx, waits for scopes that were already evicted
+// to finish closing, and stops checking for idle scopes. Only the first call to Close closes the
+// cache; subsequent calls return no errors.
+func (cache *ScopeCache) Close(ctx context.Context) []error {
+	cache.mu.Lock()
+	if cache.closed {
+		cache.mu.Unlock()
+		return nil
+	}
+	cache.closed = true
+	entries := cache.entries
+	cache.entries = nil
+	cache.mu.Unlock()
+	close(cache.stop)
+
+	closeErrorsCh := make(chan []error, len(entries))
+	for _, entry := range entries {
+		go func() {
+			closeErrorsCh <- entry.scope.Close(ctx)
+		}()
+	}
+	closeErrors := []error{}
+	for range entries {
+		closeErrors = append(closeErrors, <-closeErrorsCh...)
+	}
+
+	evicted := make(chan struct{})
+	go func() {
+		cache.evictions.Wait()
+		close(evicted)
+	}()
+	select {
+	case <-evicted:
+	case <-ctx.Done():
+		closeErrors = append(closeErrors, ctx.Err())
+	}
+	return closeErrors
+}
+
+// evict closes an evicted scope in the background. The caller must add the eviction to
+// cache.evictions while holding cache.mu so that Close waits for it.
+func (cache *ScopeCache) evict(key string, scope Scope) {
+	go func() {
+		defer cache.evictions.Done()
+		ctx, cancel := context.WithCancel(context.Background())
+		if cache.options.closeTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, cache.options.closeTimeout)
+		}
+		defer cancel()
+		if cache.options.onEvictError == nil {
+			_ = scope.Close(ctx, DelegateErrors())
+			return
+		}
+		if errs := scope.Close(ctx); len(errs) > 0 {
+			cache.options.onEvictError(key, errs)
+		}
+	}()
+}
+
+// evictIdleScopes periodically evicts the scopes that have been idle for longer than the idle TTL
+// until the cache is closed.
+func (cache *ScopeCache) evictIdleScopes() {
+	ticker := time.NewTicker(max(cache.options.idleTTL/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cache.stop:
+			return
+		case now := <-ticker.C:
+			cache.mu.Lock()
+			evicted := map[string]Scope{}
+			for key, entry := range cache.entries {
+				if now.Sub(entry.lastUsed) >= cache.options.idleTTL {
+					delete(cache.entries, key)
+					evicted[key] = entry.scope
+				}
+			}
+			cache.evictions.Add(len(evicted))
+			cache.mu.Unlock()
+			for key, scope := range evicted {
+				cache.evict(key, scope)
+			}
+		}
+	}
+}
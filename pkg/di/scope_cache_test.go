@@ -0,0 +1,176 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScopeCache(t *testing.T) {
+
+	t.Run("ScopeFor", func(t *testing.T) {
+
+		t.Run("returns the same scope for the same key", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			cache := NewScopeCache(provider)
+			defer cache.Close(context.Background())
+			if a, b := cache.ScopeFor("a"), cache.ScopeFor("a"); a.ID() != b.ID() {
+				t.Fatalf("expected the same scope for the same key; got %v and %v", a, b)
+			}
+			if a, b := cache.ScopeFor("a"), cache.ScopeFor("b"); a.ID() == b.ID() {
+				t.Fatalf("expected different scopes for different keys; got %v twice", a)
+			}
+		})
+
+		t.Run("creates exactly one scope for concurrent calls with a new key", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			cache := NewScopeCache(provider)
+			defer cache.Close(context.Background())
+			ids := make([]string, 100)
+			wg := sync.WaitGroup{}
+			for i := range ids {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ids[i] = cache.ScopeFor("key").ID()
+				}()
+			}
+			wg.Wait()
+			for _, id := range ids {
+				if id != ids[0] {
+					t.Fatalf("expected every call to return the same scope; got %q and %q", ids[0], id)
+				}
+			}
+		})
+
+		t.Run("evicts and closes the least recently used scope WithMaxScopes", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			cache := NewScopeCache(provider, WithMaxScopes(2))
+			defer cache.Close(context.Background())
+			closed := make(chan string, 3)
+			for _, key := range []string{"a", "b"} {
+				cache.ScopeFor(key).OnClose(func([]error) {
+					closed <- key
+				})
+			}
+			cache.ScopeFor("a")
+			cache.ScopeFor("c")
+			select {
+			case key := <-closed:
+				if key != "b" {
+					t.Fatalf("expected scope %q to be evicted; got %q", "b", key)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected an evicted scope to be closed")
+			}
+		})
+
+		t.Run("evicts and closes idle scopes WithIdleTTL", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			cache := NewScopeCache(provider, WithIdleTTL(10*time.Millisecond))
+			defer cache.Close(context.Background())
+			scope := cache.ScopeFor("a")
+			closed := make(chan struct{})
+			scope.OnClose(func([]error) {
+				close(closed)
+			})
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+				t.Fatalf("expected an idle scope to be closed")
+			}
+			if next := cache.ScopeFor("a"); next.ID() == scope.ID() {
+				t.Fatalf("expected a new scope after eviction")
+			}
+		})
+
+		t.Run("reports errors from closing evicted scopes", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			type eviction struct {
+				key  string
+				errs []error
+			}
+			evictions := make(chan eviction, 1)
+			cache := NewScopeCache(
+				provider,
+				WithMaxScopes(1),
+				WithEvictionCloseTimeout(time.Second),
+				WithEvictionErrorHandler(func(key string, errs []error) {
+					evictions <- eviction{key, errs}
+				}))
+			defer cache.Close(context.Background())
+			if _, err := Resolve[*errorContextCloser](cache.ScopeFor("a")); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			cache.ScopeFor("b")
+			select {
+			case evicted := <-evictions:
+				if evicted.key != "a" {
+					t.Fatalf("expected errors for key %q; got %q", "a", evicted.key)
+				}
+				if len(evicted.errs) != 1 || !errors.Is(evicted.errs[0], expectedErr) {
+					t.Fatalf("expected errors to be [%v]; got %v", expectedErr, evicted.errs)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected eviction errors to be reported")
+			}
+		})
+	})
+
+	t.Run("Close", func(t *testing.T) {
+
+		t.Run("closes every scope", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			cache := NewScopeCache(provider)
+			for _, key := range []string{"a", "b", "c"} {
+				if _, err := Resolve[*errorContextCloser](cache.ScopeFor(key)); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+			if errs := cache.Close(context.Background()); len(errs) != 3 {
+				t.Fatalf("expected 3 errors, got %d (%v)", len(errs), errs)
+			}
+			if errs := cache.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+		})
+	})
+}
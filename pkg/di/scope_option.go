@@ -0,0 +1,52 @@
+package di
+
+import "time"
+
+// A ScopeOption configures optional behavior for a new [Scope].
+type ScopeOption func(*scopeOptions)
+
+type scopeOptions struct {
+	name                string
+	inheritScopedValues bool
+	expectedInstances   int
+	closeTimeout        time.Duration
+	resolutionBudget    int
+	idleTimeout         time.Duration
+}
+
+func newScopeOptions(opts []ScopeOption) scopeOptions {
+	options := scopeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// InheritScopedValues makes a scope created with [Scope.NewChildScope] reuse the [Scoped] values
+// that its parent, or any ancestor it inherits from, has already created instead of creating its
+// own. Values the child creates itself remain local to the child and are closed when the child is
+// closed; inherited values are only closed by the scope that created them. Once an ancestor has
+// begun closing its values are no longer inherited, so the child creates its own instead.
+func InheritScopedValues() ScopeOption {
+	return func(options *scopeOptions) {
+		options.inheritScopedValues = true
+	}
+}
+
+// WithExpectedInstances sets the number of [Scoped] instances a new scope is expected to hold so
+// that the storage for them is allocated once at the right size. By default a scope allocates
+// storage for as many instances as there are [Scoped] registrations. A count of 0 or less uses the
+// default.
+func WithExpectedInstances(count int) ScopeOption {
+	return func(options *scopeOptions) {
+		options.expectedInstances = count
+	}
+}
+
+// WithCloseTimeout sets the time [Scope.CloseWithDefault] allows for closing the scope. A timeout
+// of 0 or less, which is the default, means closing the scope is not given a deadline.
+func WithCloseTimeout(timeout time.Duration) ScopeOption {
+	return func(options *scopeOptions) {
+		options.closeTimeout = timeout
+	}
+}
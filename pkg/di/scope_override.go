@@ -0,0 +1,195 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrAlreadyResolved is returned when an attempt is made to override a type in a [Scope] that has
+// already resolved it.
+var ErrAlreadyResolved = errors.New("type has already been resolved in the scope")
+
+// An AlreadyResolved is an [error] indicating that an attempt was made to override a type in a
+// [Scope] that has already resolved it. Calling [errors.Is] with an AlreadyResolved and
+// [ErrAlreadyResolved] returns true.
+type AlreadyResolved struct {
+
+	// Type is the type that could not be overridden.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err AlreadyResolved) Error() string {
+	return fmt.Sprintf("cannot override type %v after it has been resolved in the scope", err.Type)
+}
+
+// Is indicates that an [AlreadyResolved] is [ErrAlreadyResolved].
+func (AlreadyResolved) Is(target error) bool {
+	return target == ErrAlreadyResolved
+}
+
+// Code returns "already_resolved", the code of an [AlreadyResolved] in [ErrorCodes].
+func (AlreadyResolved) Code() string {
+	return "already_resolved"
+}
+
+// ErrNilInstance is returned when [Scope.WithInstance] or [RootProvider.SetSingleton] is given a
+// nil instance.
+var ErrNilInstance = errors.New("instance is nil")
+
+// A NilInstance is an [error] indicating that [Scope.WithInstance] or [RootProvider.SetSingleton]
+// was given a nil instance, which would fail every resolution of the type with a [NilResolution].
+// Calling [errors.Is] with a NilInstance and [ErrNilInstance] returns true.
+type NilInstance struct {
+
+	// Type is the type the instance was given for.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err NilInstance) Error() string {
+	return fmt.Sprintf("instance of %v is nil", err.Type)
+}
+
+// Is indicates that a [NilInstance] is [ErrNilInstance].
+func (NilInstance) Is(target error) bool {
+	return target == ErrNilInstance
+}
+
+// Code returns "nil_instance", the code of a [NilInstance] in [ErrorCodes].
+func (NilInstance) Code() string {
+	return "nil_instance"
+}
+
+// WithInstance is a generic wrapper for [Scope.WithInstance] that overrides T.
+func WithInstance[T any](scope Scope, instance T) error {
+	return scope.WithInstance(reflect.TypeFor[T](), instance)
+}
+
+// WithInstance makes subsequent resolutions of typ from the scope, and from any [Scope] created
+// from it, return instance regardless of how typ was registered or whether it was registered at
+// all. The override does not affect the [RootProvider] or any other scope, and the scope does not
+// own instance so it is not closed when the scope is closed.
+//
+// WithInstance returns a [NilInstance] if instance is nil, an [InvalidImplementation] if instance
+// is not assignable to typ, and an [AlreadyResolved] if typ has already been resolved from the
+// scope, since the values resolved before the override would not be consistent with those
+// resolved after it. It returns a [ProviderFrozen] if typ is registered and the provider has been
+// frozen with [RootProvider.Freeze]. Types that are not registered can still be given instances,
+// since doing so does not change the registrations that were verified, e.g. to make the current
+// request available to a request's scope.
+func (scope Scope) WithInstance(typ reflect.Type, instance any) error {
+	instance, err := assignableInstance(typ, instance)
+	if err != nil {
+		return err
+	}
+	if err := scope.checkInitialized("WithInstance"); err != nil {
+		return err
+	}
+	if err := scope.acquireState(); err != nil {
+		return err
+	}
+	defer scope.releaseState()
+	if _, registered := scope.root.registrations.get(typ); registered {
+		if err := scope.root.checkMutable("WithInstance"); err != nil {
+			return err
+		}
+	}
+	return scope.state.overrides.set(typ, instance)
+}
+
+// assignableInstance returns instance if it is assignable to typ, a [NilInstance] if it is nil,
+// and an [InvalidImplementation] otherwise.
+func assignableInstance(typ reflect.Type, instance any) (any, error) {
+	if instance == nil {
+		return nil, NilInstance{
+			Type: typ,
+		}
+	}
+	if impl := reflect.TypeOf(instance); !impl.AssignableTo(typ) {
+		return nil, InvalidImplementation{
+			Type:   impl,
+			Target: typ,
+		}
+	}
+	return instance, nil
+}
+
+// scopeOverrides holds the instances that override registrations in a scope and the types the
+// scope has resolved. Overrides are inherited from the scope a scope was created from.
+type scopeOverrides struct {
+	parent    *scopeOverrides
+	mu        sync.RWMutex
+	instances map[reflect.Type]any
+	resolved  map[reflect.Type]struct{}
+}
+
+func (o *scopeOverrides) set(typ reflect.Type, instance any) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.resolved[typ]; ok {
+		return AlreadyResolved{
+			Type: typ,
+		}
+	}
+	if o.instances == nil {
+		o.instances = make(map[reflect.Type]any)
+	}
+	o.instances[typ] = instance
+	return nil
+}
+
+// resolve records that typ has been resolved and returns its override, if any. Only the first
+// resolution of a type takes the write lock so concurrent resolutions from a scope don't contend.
+func (o *scopeOverrides) resolve(typ reflect.Type) (any, bool) {
+	o.mu.RLock()
+	_, seen := o.resolved[typ]
+	instance, ok := o.instances[typ]
+	o.mu.RUnlock()
+	if !seen {
+		o.mu.Lock()
+		if o.resolved == nil {
+			o.resolved = make(map[reflect.Type]struct{})
+		}
+		o.resolved[typ] = struct{}{}
+		instance, ok = o.instances[typ]
+		o.mu.Unlock()
+	}
+	if ok {
+		return instance, true
+	}
+	for ancestor := o.parent; ancestor != nil; ancestor = ancestor.parent {
+		if instance, ok := ancestor.get(typ); ok {
+			return instance, true
+		}
+	}
+	return nil, false
+}
+
+// peek returns the override for typ, if any, without recording that typ has been resolved.
+func (o *scopeOverrides) peek(typ reflect.Type) (any, bool) {
+	for overrides := o; overrides != nil; overrides = overrides.parent {
+		if instance, ok := overrides.get(typ); ok {
+			return instance, true
+		}
+	}
+	return nil, false
+}
+
+func (o *scopeOverrides) get(typ reflect.Type) (any, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	instance, ok := o.instances[typ]
+	return instance, ok
+}
+
+// reset removes every override and resolved type while keeping the allocated storage for reuse.
+func (o *scopeOverrides) reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.parent = nil
+	clear(o.instances)
+	clear(o.resolved)
+}
@@ -0,0 +1,106 @@
+package di
+
+import (
+	"sync"
+)
+
+// WithScopePooling makes the provider reuse the state of closed scopes for new scopes to reduce the
+// allocations made for each [Scope], e.g. for a server that creates a scope for every request. A
+// pooled scope's state, including the storage for its [Scoped] values, is returned to the pool
+// once the scope has closed, after which every copy of the scope behaves as closed: Resolve and
+// the other methods that can fail return a [ScopeClosed], Close returns no errors, Defer does
+// nothing, OnClose invokes its callback immediately, and NewScope returns an uninitialized scope.
+//
+// The state of a scope that was used as the parent of other scopes is never returned to the pool,
+// since its children keep using it, and neither is the state of a scope that is still in use, e.g.
+// by a resolution that was in progress when the scope was closed.
+func WithScopePooling() ProviderOption {
+	return func(options *providerOptions) {
+		options.scopePooling = true
+	}
+}
+
+// newScopePool returns the pool of scope states for a provider, or nil if it is built without
+// WithScopePooling.
+func newScopePool(options *providerOptions) *sync.Pool {
+	if !options.scopePooling {
+		return nil
+	}
+	return &sync.Pool{
+		New: func() any {
+			return &scopeState{}
+		},
+	}
+}
+
+// newScopeState returns the state for a new scope, taken from the pool if the provider pools
+// scopes.
+func (provider RootProvider) newScopeState() *scopeState {
+	if provider.scopePool == nil {
+		return &scopeState{}
+	}
+	return provider.scopePool.Get().(*scopeState)
+}
+
+// acquireState keeps the state of a pooled scope from being recycled until releaseState is called,
+// and returns a [ScopeClosed] if it has already been recycled. It does nothing for scopes that are
+// not pooled.
+func (scope Scope) acquireState() error {
+	if scope.root.scopePool == nil {
+		return nil
+	}
+	if !scope.state.acquire(scope.generation) {
+		return ScopeClosed{
+			ID: scope.id,
+		}
+	}
+	return nil
+}
+
+// releaseState allows the state acquired by acquireState to be recycled.
+func (scope Scope) releaseState() {
+	if scope.root.scopePool != nil {
+		scope.state.release()
+	}
+}
+
+// recycle returns the state of a pooled scope that has closed to the pool unless it is pinned by
+// the scopes created from it or is still in use.
+func (scope Scope) recycle() {
+	state := scope.state
+	if scope.root.scopePool == nil || state.pinned.Load() {
+		return
+	}
+	// Incrementing the generation before checking for users means that an operation that acquires
+	// the state afterwards sees the new generation, so the state is only reused once nothing that
+	// acquired it under the old generation can still be using it.
+	state.generation.Add(1)
+	if state.users.Load() != 0 {
+		return
+	}
+	state.scopedValues.recycle()
+	if scope.root.options.transientTracking {
+		// Only tracking adds to the set, and clearing it allocates.
+		state.transients.reset()
+	}
+	state.overrides.reset()
+	state.cleanups.cleanups = nil
+	state.closeState = closeState{}
+	scope.root.scopePool.Put(state)
+}
+
+// acquire records a use of the state by a copy of the scope from generation and reports whether
+// the state still belongs to it. A state in use is not recycled.
+func (s *scopeState) acquire(generation uint64) bool {
+	s.users.Add(1)
+	if s.generation.Load() != generation {
+		s.users.Add(-1)
+		return false
+	}
+	return true
+}
+
+// release ends a use of the state recorded by acquire.
+func (s *scopeState) release() {
+	s.users.Add(-1)
+}
@@ -0,0 +1,282 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type pooledRequest struct {
+	id int
+}
+
+type pooledHandler struct {
+	Closer *mockCloser
+}
+
+func TestScopePooling(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(append(opts, WithScopePooling())...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	// recycled creates and closes scopes until one of them has its state reused by the next scope,
+	// since the pool may drop the states it is given, and returns both.
+	recycled := func(t *testing.T, provider RootProvider) (stale Scope, scope Scope) {
+		for range 100 {
+			stale := provider.NewScope()
+			if errs := stale.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			scope := provider.NewScope()
+			if scope.state == stale.state {
+				return stale, scope
+			}
+			scope.Close(context.Background())
+		}
+		t.Fatalf("expected a closed scope's state to be reused")
+		return Scope{}, Scope{}
+	}
+
+	t.Run("closes values before reusing the state", func(t *testing.T) {
+		provider := newProvider(t)
+		for range 10 {
+			scope := provider.NewScope()
+			closer, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if closer.closed {
+				t.Fatalf("expected a new scoped value for each scope")
+			}
+			if types := scope.InstantiatedTypes(); len(types) != 1 {
+				t.Fatalf("expected 1 instantiated type; got %v", types)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("expected Close to close the scoped value")
+			}
+		}
+	})
+
+	t.Run("starts reused states without overrides or cleanups", func(t *testing.T) {
+		provider := newProvider(t)
+		var cleanups int
+		for range 10 {
+			scope := provider.NewScope()
+			if _, ok := Peek[*pooledRequest](scope); ok {
+				t.Fatalf("expected no override from a previous scope")
+			}
+			if err := WithInstance(scope, &pooledRequest{}); err != nil {
+				t.Fatalf("unexpected error from WithInstance: %v", err)
+			}
+			scope.Defer(func(context.Context) error {
+				cleanups++
+				return nil
+			})
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+		}
+		if cleanups != 10 {
+			t.Fatalf("expected each cleanup to run once; got %d runs", cleanups)
+		}
+	})
+
+	t.Run("rejects use of a scope after its state was recycled", func(t *testing.T) {
+		provider := newProvider(t)
+		stale, scope := recycled(t, provider)
+		defer scope.Close(context.Background())
+		typ := reflect.TypeFor[*mockCloser]()
+		operations := map[string]func() error{
+			"Resolve": func() error {
+				_, err := stale.Resolve(typ)
+				return err
+			},
+			"ResolveNew": func() error {
+				_, err := stale.ResolveNew(typ)
+				return err
+			},
+			"WithInstance": func() error {
+				return stale.WithInstance(reflect.TypeFor[*pooledRequest](), &pooledRequest{})
+			},
+			"Evict": func() error {
+				return stale.Evict(typ)
+			},
+			"Reset": func() error {
+				return errors.Join(stale.Reset(context.Background())...)
+			},
+			"Dump": func() error {
+				return stale.Dump(io.Discard)
+			},
+			"Provenance": func() error {
+				_, _, err := stale.Provenance(&mockCloser{})
+				return err
+			},
+			"Fork": func() error {
+				_, err := stale.Fork()
+				return err
+			},
+		}
+		for name, operation := range operations {
+			err := operation()
+			var scopeClosed ScopeClosed
+			if !errors.As(err, &scopeClosed) || scopeClosed.ID != stale.ID() {
+				t.Errorf("expected %s to return a ScopeClosed for scope %s; got %v", name, stale.ID(), err)
+			}
+		}
+		if child := stale.NewScope(); child.initialized() {
+			t.Errorf("expected NewScope to return an uninitialized scope")
+		}
+		called := false
+		stale.OnClose(func(errs []error) {
+			called = len(errs) == 0
+		})
+		if !called {
+			t.Errorf("expected OnClose to invoke the callback immediately without errors")
+		}
+		if _, ok := Peek[*pooledRequest](scope); ok {
+			t.Errorf("expected the stale scope not to give the new scope an override")
+		}
+	})
+
+	t.Run("does not let a stale copy close the scope reusing its state", func(t *testing.T) {
+		provider := newProvider(t)
+		stale, scope := recycled(t, provider)
+		closer, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		ran := false
+		stale.Defer(func(context.Context) error {
+			ran = true
+			return nil
+		})
+		if errs := stale.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if closer.closed {
+			t.Fatalf("expected the stale scope not to close the new scope's values")
+		}
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !closer.closed || ran {
+			t.Fatalf("expected the new scope to close its own values and not the stale cleanup")
+		}
+	})
+
+	t.Run("does not recycle the state of a parent scope", func(t *testing.T) {
+		provider := newProvider(t)
+		parent := provider.NewScope()
+		request := &pooledRequest{id: 1}
+		if err := WithInstance(parent, request); err != nil {
+			t.Fatalf("unexpected error from WithInstance: %v", err)
+		}
+		child := parent.NewScope()
+		defer child.Close(context.Background())
+		if errs := parent.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		for range 10 {
+			scope := provider.NewScope()
+			if scope.state == parent.state {
+				t.Fatalf("expected the parent's state not to be reused")
+			}
+			scope.Close(context.Background())
+		}
+		resolved, err := Resolve[*pooledRequest](child)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if resolved != request {
+			t.Fatalf("expected the child to resolve its parent's override")
+		}
+	})
+
+	t.Run("does not recycle a state that is in use", func(t *testing.T) {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		started, proceed := make(chan struct{}), make(chan struct{})
+		registry, err = RegisterFactory[*pooledHandler](registry, Transient, func(resolver Resolver) (*pooledHandler, error) {
+			close(started)
+			<-proceed
+			closer, err := Resolve[*mockCloser](resolver)
+			if err != nil {
+				return nil, err
+			}
+			return &pooledHandler{Closer: closer}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithScopePooling())
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		scope := provider.NewScope()
+		errs := make(chan error, 1)
+		go func() {
+			_, err := Resolve[*pooledHandler](scope)
+			errs <- err
+		}()
+		<-started
+		if errs := scope.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		for range 10 {
+			next := provider.NewScope()
+			if next.state == scope.state {
+				t.Fatalf("expected the state of a scope in use not to be reused")
+			}
+			next.Close(context.Background())
+		}
+		close(proceed)
+		if err := <-errs; !errors.Is(err, ErrScopeClosed) {
+			t.Fatalf("expected the resolution to fail with %q; got %v", ErrScopeClosed, err)
+		}
+	})
+
+	t.Run("keeps stale copies used concurrently away from new scopes", func(t *testing.T) {
+		provider := newProvider(t)
+		var wg sync.WaitGroup
+		for range 20 {
+			stale := provider.NewScope()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					_ = WithInstance(stale, &pooledRequest{})
+					_, _ = Resolve[*mockCloser](stale)
+					stale.Defer(func(context.Context) error {
+						return errors.New("stale cleanup")
+					})
+				}
+			}()
+			stale.Close(context.Background())
+			scope := provider.NewScope()
+			if _, ok := Peek[*pooledRequest](scope); ok {
+				t.Errorf("expected no override from a stale scope")
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Errorf("expected no cleanups from a stale scope; got %v", errs)
+			}
+		}
+		wg.Wait()
+	})
+}
@@ -3,7 +3,12 @@ package di
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 )
@@ -12,6 +17,21 @@ func TestScope(t *testing.T) {
 
 	t.Run("Resolve", func(t *testing.T) {
 
+		t.Run("returns InternalError for a registration with an undefined lifetime", func(t *testing.T) {
+			provider, err := withUndefinedLifetime(t).BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			_, err = provider.NewScope().Resolve(reflect.TypeFor[*widget]())
+			var internalError InternalError
+			if !errors.As(err, &internalError) {
+				t.Fatalf("expected %v to be %T", err, internalError)
+			}
+			if internalError.Lifetime != undefinedLifetime {
+				t.Errorf("expected err.Lifetime to be %d; got %d", undefinedLifetime, internalError.Lifetime)
+			}
+		})
+
 		t.Run("returns UnknownType for unknown type", func(t *testing.T) {
 			expectedType := reflect.TypeFor[struct{}]()
 			provider, err := Registry{}.BuildRootProvider()
@@ -161,12 +181,31 @@ func TestScope(t *testing.T) {
 				t.Fatalf("instances are the same: %p %p", a, b)
 			}
 		})
-	})
 
-	t.Run("Close", func(t *testing.T) {
+		t.Run("resolves scoped values restricted WithScopeName from scopes with that name", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped, WithScopeName("request"))
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*mockContextCloser, *mockContextCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewNamedScope("request")
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if _, err := Resolve[*mockContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		})
 
-		t.Run("closes ContextCloser values", func(t *testing.T) {
-			registry, err := RegisterType[*mockContextCloser, *mockContextCloser](Registry{}, Scoped)
+		t.Run("returns ScopeNameMismatch for scoped values restricted to another name", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped, WithScopeName("request"))
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
@@ -174,30 +213,77 @@ func TestScope(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			scope := provider.NewScope()
-			resolved, err := scope.Resolve(reflect.TypeFor[*mockContextCloser]())
+			for _, scope := range []Scope{provider.NewNamedScope("job"), provider.NewScope()} {
+				_, err := scope.Resolve(reflect.TypeFor[*mockCloser]())
+				if !errors.Is(err, ErrScopeNameMismatch) {
+					t.Fatalf("expected %v to be %v", err, ErrScopeNameMismatch)
+				}
+				var mismatch ScopeNameMismatch
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("expected %v to be %T", err, mismatch)
+				}
+				if mismatch.Required != "request" || mismatch.Actual != scope.Name() {
+					t.Fatalf("expected mismatch between %q and %q; got %v", "request", scope.Name(), mismatch)
+				}
+			}
+		})
+
+		t.Run("passes the scope to transient factories", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterFactory[*mockContextCloser](registry, Transient, func(r Resolver) (*mockContextCloser, error) {
+				if _, err := Resolve[*mockCloser](r); err != nil {
+					return nil, err
+				}
+				return &mockContextCloser{}, nil
+			})
 			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			if _, err := provider.NewScope().Resolve(reflect.TypeFor[*mockContextCloser]()); err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			closer, ok := resolved.(*mockContextCloser)
-			if !ok {
-				t.Fatalf("expected Resolve to return %T; got %T", closer, resolved)
+		})
+	})
+
+	t.Run("WithExpectedInstances", func(t *testing.T) {
+
+		t.Run("defaults to the number of scoped registrations", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
-			if errs := scope.Close(context.Background()); len(errs) != 0 {
-				t.Fatalf("unexpected errors from Close: %v", errs)
+			registry, err = RegisterType[*mockContextCloser, *mockContextCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
-			if !closer.closed {
-				t.Fatalf("closer was not closed")
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			if capacity := provider.NewScope().state.scopedValues.defaults.capacity; capacity != 2 {
+				t.Fatalf("expected capacity to be 2; got %d", capacity)
+			}
+			scope := provider.NewScope(WithExpectedInstances(8))
+			if capacity := scope.state.scopedValues.defaults.capacity; capacity != 8 {
+				t.Fatalf("expected capacity to be 8; got %d", capacity)
+			}
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
 		})
+	})
 
-		t.Run("returns errors from ContextCloser values", func(t *testing.T) {
-			expectedErr := errors.New("expected error")
-			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
-				return &errorContextCloser{
-					err: expectedErr,
-				}, nil
-			})
+	t.Run("NewChildScope", func(t *testing.T) {
+
+		t.Run("creates fresh scoped values by default", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
@@ -205,53 +291,73 @@ func TestScope(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			scope := provider.NewScope()
-			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser]())
+			parent := provider.NewScope()
+			parentCloser, err := Resolve[*mockCloser](parent)
 			if err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			errs := scope.Close(context.Background())
-			if len(errs) != 1 {
-				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			childCloser, err := Resolve[*mockCloser](parent.NewChildScope())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			if !errors.Is(errs[0], expectedErr) {
-				t.Fatalf("expected errs[0] to be %v; got %v", expectedErr, errs[0])
+			if childCloser == parentCloser {
+				t.Fatalf("expected the child to create its own scoped value")
 			}
 		})
 
-		t.Run("closes Closer values", func(t *testing.T) {
+		t.Run("reuses scoped values from ancestors with InheritScopedValues", func(t *testing.T) {
 			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
+			registry, err = RegisterType[*mockContextCloser, *mockContextCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
 			provider, err := registry.BuildRootProvider()
 			if err != nil {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			scope := provider.NewScope()
-			resolved, err := scope.Resolve(reflect.TypeFor[*mockCloser]())
+			parent := provider.NewScope()
+			parentCloser, err := Resolve[*mockCloser](parent)
 			if err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			closer, ok := resolved.(*mockCloser)
-			if !ok {
-				t.Fatalf("expected Resolve to return %T; got %T", closer, resolved)
+			child := parent.NewChildScope(InheritScopedValues())
+			grandchild := child.NewChildScope(InheritScopedValues())
+			grandchildCloser, err := Resolve[*mockCloser](grandchild)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			if errs := scope.Close(context.Background()); len(errs) != 0 {
+			if grandchildCloser != parentCloser {
+				t.Fatalf("expected the grandchild to reuse the parent's scoped value")
+			}
+			childContextCloser, err := Resolve[*mockContextCloser](child)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := child.Close(context.Background()); len(errs) != 0 {
 				t.Fatalf("unexpected errors from Close: %v", errs)
 			}
-			if !closer.closed {
-				t.Fatalf("closer was not closed")
+			if !childContextCloser.closed {
+				t.Fatalf("expected the child to close the values it created")
+			}
+			if parentCloser.closed {
+				t.Fatalf("expected the child not to close inherited values")
+			}
+			if _, err := Resolve[*mockContextCloser](parent); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := parent.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !parentCloser.closed {
+				t.Fatalf("expected the parent to close the values it created")
 			}
 		})
 
-		t.Run("returns errors from Closer values", func(t *testing.T) {
-			expectedErr := errors.New("expected error")
-			registry, err := RegisterFactory[*errorCloser](Registry{}, Scoped, func(Resolver) (*errorCloser, error) {
-				return &errorCloser{
-					err: expectedErr,
-				}, nil
-			})
+		t.Run("stops inheriting scoped values from closed ancestors", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
@@ -259,47 +365,42 @@ func TestScope(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			scope := provider.NewScope()
-			_, err = scope.Resolve(reflect.TypeFor[*errorCloser]())
+			parent := provider.NewScope()
+			parentCloser, err := Resolve[*mockCloser](parent)
 			if err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			errs := scope.Close(context.Background())
-			if len(errs) != 1 {
-				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
-			}
-			if !errors.Is(errs[0], expectedErr) {
-				t.Fatalf("expected errs[0] to be %v; got %v", expectedErr, errs[0])
+			child := parent.NewChildScope(InheritScopedValues())
+			if errs := parent.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
 			}
-		})
-
-		t.Run("returns multiple errors from ContextCloser and Closer values", func(t *testing.T) {
-			expectedErrs := []error{
-				errors.New("first expected error"),
-				errors.New("second expected error"),
-				errors.New("third expected error"),
+			if peeked, ok := Peek[*mockCloser](child); ok {
+				t.Fatalf("expected Peek to report false; got %v", peeked)
 			}
-			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
-				return &errorContextCloser{
-					err: expectedErrs[0],
-				}, nil
-			})
+			childCloser, err := Resolve[*mockCloser](child)
 			if err != nil {
-				t.Fatalf("unexpected error from RegisterType: %v", err)
+				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			registry, err = RegisterFactory[*errorCloser](registry, Scoped, func(Resolver) (*errorCloser, error) {
-				return &errorCloser{
-					err: expectedErrs[1],
-				}, nil
-			})
+			if childCloser == parentCloser || childCloser.closed {
+				t.Fatalf("expected the child to create its own value once the parent closed")
+			}
+			if errs := child.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !childCloser.closed {
+				t.Fatalf("expected the child to close the value it created")
+			}
+		})
+	})
+
+	t.Run("WithInstance", func(t *testing.T) {
+
+		t.Run("overrides the registration for the scope and its descendants", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
-			registry, err = RegisterFactory[*errorContextCloser2](registry, Scoped, func(Resolver) (*errorContextCloser2, error) {
-				return &errorContextCloser2{
-					err: expectedErrs[2],
-				}, nil
-			})
+			registry, err = RegisterType[*struct{ Closer *mockCloser }, *struct{ Closer *mockCloser }](registry, Scoped)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
@@ -308,56 +409,85 @@ func TestScope(t *testing.T) {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
 			scope := provider.NewScope()
-			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser]())
-			if err != nil {
-				t.Fatalf("unexpected error from Resolve: %v", err)
+			override := &mockCloser{}
+			if err := WithInstance(scope, override); err != nil {
+				t.Fatalf("unexpected error from WithInstance: %v", err)
+			}
+			for _, resolver := range []Scope{scope, scope.NewScope()} {
+				closer, err := Resolve[*mockCloser](resolver)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if closer != override {
+					t.Fatalf("expected Resolve to return the override")
+				}
+				dependent, err := Resolve[*struct{ Closer *mockCloser }](resolver)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if dependent.Closer != override {
+					t.Fatalf("expected dependencies to be resolved as the override")
+				}
 			}
-			_, err = scope.Resolve(reflect.TypeFor[*errorCloser]())
-			if err != nil {
-				t.Fatalf("unexpected error from Resolve: %v", err)
+			for _, resolver := range []Resolver{provider, provider.NewScope()} {
+				closer, err := Resolve[*mockCloser](resolver)
+				if err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if closer == override {
+					t.Fatalf("expected the override not to leak out of the scope")
+				}
 			}
-			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser2]())
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if override.closed {
+				t.Fatalf("expected the override not to be closed")
+			}
+		})
+
+		t.Run("returns InvalidImplementation for unassignable instances", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
 			if err != nil {
-				t.Fatalf("unexpected error from Resolve: %v", err)
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			errs := scope.Close(context.Background())
-			if len(errs) != len(expectedErrs) {
-				t.Fatalf("expected %d error, got %d (%v)", len(expectedErrs), len(errs), errs)
+			err = provider.NewScope().WithInstance(reflect.TypeFor[*mockCloser](), &mockContextCloser{})
+			if !errors.Is(err, ErrInvalidImplementation) {
+				t.Fatalf("expected %v to be %v", err, ErrInvalidImplementation)
 			}
-			found := 0
-			for _, err := range errs {
-				for _, expectedErr := range expectedErrs {
-					if errors.Is(err, expectedErr) {
-						found++
-						continue
-					}
+		})
+
+		t.Run("returns NilInstance for nil instances", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			for _, typ := range []reflect.Type{reflect.TypeFor[Closer](), reflect.TypeFor[mockCloser]()} {
+				err = provider.NewScope().WithInstance(typ, nil)
+				var nilInstance NilInstance
+				if !errors.As(err, &nilInstance) || nilInstance.Type != typ {
+					t.Fatalf("expected a NilInstance for %v; got %v", typ, err)
 				}
 			}
-			if found != len(expectedErrs) {
-				t.Fatalf("expected errs to be %v; got %v", expectedErrs, errs)
+			if err := WithInstance[Closer](provider.NewScope(), nil); !errors.Is(err, ErrNilInstance) {
+				t.Fatalf("expected %v to be %v", err, ErrNilInstance)
 			}
 		})
 
-		t.Run("gives up on blocking calls when context is Done", func(t *testing.T) {
-			unexpectedErrs := []error{
-				errors.New("first unexpected error"),
-				errors.New("second unexpected error"),
-			}
-			registry, err := RegisterFactory[*blockingContextCloser](Registry{}, Scoped, func(Resolver) (*blockingContextCloser, error) {
-				return &blockingContextCloser{
-					blockTime: time.Second,
-					err:       unexpectedErrs[0],
-				}, nil
-			})
+		t.Run("names both types in InvalidImplementation", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
 			if err != nil {
-				t.Fatalf("unexpected error from RegisterType: %v", err)
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
-			registry, err = RegisterFactory[*blockingCloser](registry, Scoped, func(Resolver) (*blockingCloser, error) {
-				return &blockingCloser{
-					blockTime: time.Second,
-					err:       unexpectedErrs[1],
-				}, nil
-			})
+			err = provider.NewScope().WithInstance(reflect.TypeFor[mockCloser](), &mockCloser{})
+			var invalid InvalidImplementation
+			if !errors.As(err, &invalid) || invalid.Type != reflect.TypeFor[*mockCloser]() {
+				t.Fatalf("expected an InvalidImplementation for *mockCloser; got %v", err)
+			}
+		})
+
+		t.Run("returns AlreadyResolved for types resolved from the scope", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Transient)
 			if err != nil {
 				t.Fatalf("unexpected error from RegisterType: %v", err)
 			}
@@ -366,30 +496,995 @@ func TestScope(t *testing.T) {
 				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
 			}
 			scope := provider.NewScope()
-			_, err = scope.Resolve(reflect.TypeFor[*blockingContextCloser]())
-			if err != nil {
+			if _, err := Resolve[*mockCloser](scope); err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			_, err = scope.Resolve(reflect.TypeFor[*blockingCloser]())
+			err = WithInstance(scope, &mockCloser{})
+			if !errors.Is(err, ErrAlreadyResolved) {
+				t.Fatalf("expected %v to be %v", err, ErrAlreadyResolved)
+			}
+		})
+	})
+
+	t.Run("ID", func(t *testing.T) {
+
+		t.Run("is unique and stable for each scope", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			seen := map[string]bool{}
+			for range 100 {
+				scope := provider.NewScope()
+				id := scope.ID()
+				if seen[id] {
+					t.Fatalf("expected scope IDs to be unique; got %q twice", id)
+				}
+				seen[id] = true
+				if scope.ID() != id {
+					t.Fatalf("expected scope ID to be stable; got %q then %q", id, scope.ID())
+				}
+			}
+		})
+
+		t.Run("is shared with the resolver passed to factories", func(t *testing.T) {
+			var factoryID string
+			registry, err := RegisterFactory[*mockCloser](Registry{}, Scoped, func(resolver Resolver) (*mockCloser, error) {
+				factoryID = resolver.(Scope).ID()
+				return &mockCloser{}, nil
+			})
 			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := Resolve[*mockCloser](scope); err != nil {
 				t.Fatalf("unexpected error from Resolve: %v", err)
 			}
-			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
-			defer cancel()
-			if errs := scope.Close(ctx); len(errs) != 0 {
-				t.Fatalf("unexpected errors from Close: %v", errs)
+			if factoryID != scope.ID() {
+				t.Fatalf("expected factory to receive scope %q; got %q", scope.ID(), factoryID)
 			}
 		})
 	})
-}
 
-type mockContextCloser struct {
-	closed bool
-}
+	t.Run("String", func(t *testing.T) {
 
-func (m *mockContextCloser) Close(context.Context) error {
-	m.closed = true
-	return nil
+		t.Run("summarizes the scope", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			parent := provider.NewScope()
+			scope := parent.NewScope()
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			expected := fmt.Sprintf("Scope(id=%s, parent=%s, instances=1)", scope.ID(), parent.ID())
+			if s := scope.String(); s != expected {
+				t.Fatalf("expected String to return %q; got %q", expected, s)
+			}
+		})
+
+		t.Run("lists the provider layers of a scope from a child provider", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			child, err := provider.NewChildProvider(Registry{})
+			if err != nil {
+				t.Fatalf("unexpected error from NewChildProvider: %v", err)
+			}
+			scope := child.NewNamedScope("admin")
+			expected := fmt.Sprintf(
+				"Scope(id=%s, name=admin, parent=%s, provider=%s/%s, instances=0)",
+				scope.ID(),
+				child.ID(),
+				provider.ID(),
+				child.ID())
+			if s := scope.String(); s != expected {
+				t.Fatalf("expected String to return %q; got %q", expected, s)
+			}
+			expected = fmt.Sprintf("RootProvider(id=%s, parent=%s, instances=0)", child.ID(), provider.ID())
+			if s := child.String(); s != expected {
+				t.Fatalf("expected String to return %q; got %q", expected, s)
+			}
+		})
+	})
+
+	t.Run("Close", func(t *testing.T) {
+
+		t.Run("closes ContextCloser values", func(t *testing.T) {
+			registry, err := RegisterType[*mockContextCloser, *mockContextCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			resolved, err := scope.Resolve(reflect.TypeFor[*mockContextCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			closer, ok := resolved.(*mockContextCloser)
+			if !ok {
+				t.Fatalf("expected Resolve to return %T; got %T", closer, resolved)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		})
+
+		t.Run("returns errors from ContextCloser values", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			errs := scope.Close(context.Background())
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			if !errors.Is(errs[0], expectedErr) {
+				t.Fatalf("expected errs[0] to be %v; got %v", expectedErr, errs[0])
+			}
+			var closerErr CloserError
+			if !errors.As(errs[0], &closerErr) {
+				t.Fatalf("expected %v to be %T", errs[0], closerErr)
+			}
+			if typ := reflect.TypeFor[*errorContextCloser](); closerErr.Type != typ {
+				t.Fatalf("expected closerErr.Type to be %v; got %v", typ, closerErr.Type)
+			}
+		})
+
+		t.Run("closes Closer values", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			resolved, err := scope.Resolve(reflect.TypeFor[*mockCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			closer, ok := resolved.(*mockCloser)
+			if !ok {
+				t.Fatalf("expected Resolve to return %T; got %T", closer, resolved)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !closer.closed {
+				t.Fatalf("closer was not closed")
+			}
+		})
+
+		t.Run("does not close values registered WithoutOwnership", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped, WithoutOwnership())
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			closer, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if closer.closed {
+				t.Fatalf("closer was closed")
+			}
+		})
+
+		t.Run("returns errors from Closer values", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorCloser](Registry{}, Scoped, func(Resolver) (*errorCloser, error) {
+				return &errorCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			_, err = scope.Resolve(reflect.TypeFor[*errorCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			errs := scope.Close(context.Background())
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			if !errors.Is(errs[0], expectedErr) {
+				t.Fatalf("expected errs[0] to be %v; got %v", expectedErr, errs[0])
+			}
+		})
+
+		t.Run("returns multiple errors from ContextCloser and Closer values", func(t *testing.T) {
+			expectedErrs := []error{
+				errors.New("first expected error"),
+				errors.New("second expected error"),
+				errors.New("third expected error"),
+			}
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErrs[0],
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterFactory[*errorCloser](registry, Scoped, func(Resolver) (*errorCloser, error) {
+				return &errorCloser{
+					err: expectedErrs[1],
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterFactory[*errorContextCloser2](registry, Scoped, func(Resolver) (*errorContextCloser2, error) {
+				return &errorContextCloser2{
+					err: expectedErrs[2],
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			_, err = scope.Resolve(reflect.TypeFor[*errorCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			_, err = scope.Resolve(reflect.TypeFor[*errorContextCloser2]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			errs := scope.Close(context.Background())
+			if len(errs) != len(expectedErrs) {
+				t.Fatalf("expected %d error, got %d (%v)", len(expectedErrs), len(errs), errs)
+			}
+			found := 0
+			for _, err := range errs {
+				for _, expectedErr := range expectedErrs {
+					if errors.Is(err, expectedErr) {
+						found++
+						continue
+					}
+				}
+			}
+			if found != len(expectedErrs) {
+				t.Fatalf("expected errs to be %v; got %v", expectedErrs, errs)
+			}
+		})
+
+		t.Run("runs deferred cleanups in reverse order", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			order := []int{}
+			for i := range 3 {
+				scope.Defer(func(context.Context) error {
+					order = append(order, i)
+					return nil
+				})
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if expected := []int{2, 1, 0}; !reflect.DeepEqual(order, expected) {
+				t.Fatalf("expected cleanups to run in order %v; got %v", expected, order)
+			}
+		})
+
+		t.Run("returns DeferredCleanupError identifying the registration that deferred a cleanup", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*mockCloser](Registry{}, Scoped, func(r Resolver) (*mockCloser, error) {
+				deferrer, ok := r.(interface {
+					Defer(func(context.Context) error)
+				})
+				if !ok {
+					t.Fatalf("expected %T to implement Defer", r)
+				}
+				deferrer.Defer(func(context.Context) error {
+					return expectedErr
+				})
+				return &mockCloser{}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := scope.Resolve(reflect.TypeFor[*mockCloser]()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			errs := scope.Close(context.Background())
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			if !errors.Is(errs[0], ErrDeferredCleanup) {
+				t.Fatalf("expected errs[0] to be %v; got %v", ErrDeferredCleanup, errs[0])
+			}
+			if !errors.Is(errs[0], expectedErr) {
+				t.Fatalf("expected errs[0] to be %v; got %v", expectedErr, errs[0])
+			}
+			var cleanupErr DeferredCleanupError
+			if !errors.As(errs[0], &cleanupErr) {
+				t.Fatalf("expected %v to be %T", errs[0], cleanupErr)
+			}
+			if typ := reflect.TypeFor[*mockCloser](); cleanupErr.Type != typ {
+				t.Errorf("expected err.Type to be %v; got %v", typ, cleanupErr.Type)
+			}
+		})
+
+		t.Run("invokes OnClose callbacks in order with the close errors", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			registry, err := RegisterFactory[*errorCloser](Registry{}, Scoped, func(Resolver) (*errorCloser, error) {
+				return &errorCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := scope.Resolve(reflect.TypeFor[*errorCloser]()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			order := []int{}
+			for i := range 3 {
+				scope.OnClose(func(closeErrors []error) {
+					if len(closeErrors) != 1 || !errors.Is(closeErrors[0], expectedErr) {
+						t.Errorf("expected closeErrors to be [%v]; got %v", expectedErr, closeErrors)
+					}
+					order = append(order, i)
+				})
+			}
+			_ = scope.Close(context.Background())
+			_ = scope.Close(context.Background())
+			if expected := []int{0, 1, 2}; !reflect.DeepEqual(order, expected) {
+				t.Fatalf("expected callbacks to be invoked in order %v; got %v", expected, order)
+			}
+		})
+
+		t.Run("invokes OnClose callbacks registered after Close immediately", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			_ = scope.Close(context.Background())
+			invoked := false
+			scope.OnClose(func([]error) {
+				invoked = true
+			})
+			if !invoked {
+				t.Fatalf("callback was not invoked")
+			}
+		})
+
+		t.Run("returns OnClosePanic when an OnClose callback panics", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			scope.OnClose(func([]error) {
+				panic("expected panic")
+			})
+			invoked := false
+			scope.OnClose(func([]error) {
+				invoked = true
+			})
+			errs := scope.Close(context.Background())
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			if !errors.Is(errs[0], ErrOnClosePanic) {
+				t.Fatalf("expected errs[0] to be %v; got %v", ErrOnClosePanic, errs[0])
+			}
+			if !invoked {
+				t.Fatalf("callback after panicking callback was not invoked")
+			}
+		})
+
+		t.Run("returns errors from every closer when all closers finish", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			expectedErr := errors.New("expected error")
+			for range 100 {
+				scope := provider.NewScope()
+				for range 10 {
+					scope.Defer(func(context.Context) error {
+						return expectedErr
+					})
+				}
+				if errs := scope.Close(context.Background()); len(errs) != 10 {
+					t.Fatalf("expected 10 errors, got %d (%v)", len(errs), errs)
+				}
+			}
+		})
+
+		t.Run("returns errors produced before the context is Done", func(t *testing.T) {
+			provider, err := Registry{}.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			expectedErr := errors.New("expected error")
+			for range 100 {
+				scope := provider.NewScope()
+				ctx, cancel := context.WithCancel(context.Background())
+				// Deferred cleanups run sequentially in reverse order so the errors from the
+				// other cleanups are reported before this one cancels the context.
+				scope.Defer(func(context.Context) error {
+					cancel()
+					return nil
+				})
+				for range 10 {
+					scope.Defer(func(context.Context) error {
+						return expectedErr
+					})
+				}
+				// The cancelling cleanup may not be confirmed as finished before Close gives up so
+				// only count the errors from the other cleanups.
+				errs := scope.Close(ctx)
+				n := 0
+				for _, err := range errs {
+					if errors.Is(err, expectedErr) {
+						n++
+					}
+				}
+				if n != 10 {
+					t.Fatalf("expected 10 errors, got %d (%v)", n, errs)
+				}
+			}
+		})
+
+		t.Run("runs fast closers and keeps their errors when the context is already Done", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			ran := make(chan struct{}, 2)
+			registry, err := RegisterFactory[*signalingCloser](Registry{}, Scoped, func(Resolver) (*signalingCloser, error) {
+				return &signalingCloser{err: expectedErr, ran: ran}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = RegisterFactory[*signalingContextCloser](registry, Scoped, func(Resolver) (*signalingContextCloser, error) {
+				return &signalingContextCloser{err: expectedErr, ran: ran}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for range 100 {
+				scope := provider.NewScope()
+				if _, err := Resolve[*signalingCloser](scope); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				if _, err := Resolve[*signalingContextCloser](scope); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				report := scope.CloseReport(ctx)
+				for range 2 {
+					select {
+					case <-ran:
+					case <-time.After(5 * time.Second):
+						t.Fatalf("expected every closer to run")
+					}
+				}
+				// A closer that finished before Close gave up must report its error, and any other
+				// closer must be reported as abandoned rather than silently dropped.
+				for _, entry := range report.Entries {
+					if entry.Outcome != AbandonedAtDeadline && !errors.Is(entry.Err, expectedErr) {
+						t.Fatalf("expected %v to be abandoned or to report %v; got %+v", entry.Type, expectedErr, entry)
+					}
+				}
+			}
+		})
+
+		t.Run("does not leak goroutines for cooperative closers it gives up on", func(t *testing.T) {
+			started := make(chan struct{})
+			registry, err := RegisterFactory[*startedContextCloser](Registry{}, Scoped, func(Resolver) (*startedContextCloser, error) {
+				return &startedContextCloser{started: started}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			baseline := runtime.NumGoroutine()
+			for range 100 {
+				scope := provider.NewScope()
+				if _, err := Resolve[*startedContextCloser](scope); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+				// The context is only cancelled once the closer is running and blocked on it, so
+				// the closer's goroutine exists and must stop on its own.
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					<-started
+					cancel()
+				}()
+				_ = scope.Close(ctx)
+			}
+			leaked := func() bool {
+				return runtime.NumGoroutine() > baseline || len(provider.AbandonedClosers()) != 0
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for leaked() && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > baseline {
+				t.Fatalf("expected at most %d goroutines; got %d", baseline, n)
+			}
+			if abandoned := provider.AbandonedClosers(); len(abandoned) != 0 {
+				t.Fatalf("expected the abandoned closers to finish; got %v", abandoned)
+			}
+		})
+
+		t.Run("returns CloserTimeout for closers that exceed WithPerCloserTimeout", func(t *testing.T) {
+			closer := &releasableCloser{
+				release: make(chan struct{}),
+			}
+			defer close(closer.release)
+			registry, err := RegisterFactory[*releasableCloser](Registry{}, Scoped, func(Resolver) (*releasableCloser, error) {
+				return closer, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = RegisterType[*cooperativeContextCloser, *cooperativeContextCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*mockCloser, *mockCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			for _, typ := range []reflect.Type{
+				reflect.TypeFor[*releasableCloser](),
+				reflect.TypeFor[*cooperativeContextCloser](),
+				reflect.TypeFor[*mockCloser](),
+			} {
+				if _, err := scope.Resolve(typ); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+			errs := scope.Close(context.Background(), WithPerCloserTimeout(10*time.Millisecond))
+			if len(errs) != 2 {
+				t.Fatalf("expected 2 errors, got %d (%v)", len(errs), errs)
+			}
+			timedOut := map[reflect.Type]bool{}
+			for _, err := range errs {
+				var closerTimeout CloserTimeout
+				if !errors.As(err, &closerTimeout) {
+					t.Fatalf("expected %v to be %T", err, closerTimeout)
+				}
+				if closerTimeout.Elapsed < closerTimeout.Timeout {
+					t.Errorf("expected err.Elapsed to be at least %v; got %v", closerTimeout.Timeout, closerTimeout.Elapsed)
+				}
+				timedOut[closerTimeout.Type] = true
+			}
+			if !timedOut[reflect.TypeFor[*releasableCloser]()] || !timedOut[reflect.TypeFor[*cooperativeContextCloser]()] {
+				t.Fatalf("expected timeouts for %v and %v; got %v",
+					reflect.TypeFor[*releasableCloser](),
+					reflect.TypeFor[*cooperativeContextCloser](),
+					errs)
+			}
+		})
+
+		t.Run("gives up at the context deadline before per-closer timeouts", func(t *testing.T) {
+			closer := &releasableCloser{
+				release: make(chan struct{}),
+			}
+			defer close(closer.release)
+			registry, err := RegisterFactory[*releasableCloser](Registry{}, Scoped, func(Resolver) (*releasableCloser, error) {
+				return closer, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := scope.Resolve(reflect.TypeFor[*releasableCloser]()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			errs := scope.Close(ctx, WithPerCloserTimeout(time.Hour))
+			if len(errs) != 1 || !errors.Is(errs[0], ErrCloseTimeout) {
+				t.Fatalf("expected 1 error to be %v; got %v", ErrCloseTimeout, errs)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("expected Close to return at the context deadline; took %v", elapsed)
+			}
+		})
+
+		t.Run("CloseJoined joins errors from every closer", func(t *testing.T) {
+			expectedErr := errors.New("expected error")
+			expectedErr2 := errors.New("expected error 2")
+			registry, err := RegisterFactory[*errorContextCloser](Registry{}, Scoped, func(Resolver) (*errorContextCloser, error) {
+				return &errorContextCloser{
+					err: expectedErr,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = RegisterFactory[*errorContextCloser2](registry, Scoped, func(Resolver) (*errorContextCloser2, error) {
+				return &errorContextCloser2{
+					err: expectedErr2,
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if _, err := Resolve[*errorContextCloser2](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			err = scope.CloseJoined(context.Background())
+			if !errors.Is(err, expectedErr) || !errors.Is(err, expectedErr2) {
+				t.Fatalf("expected %v to be %v and %v", err, expectedErr, expectedErr2)
+			}
+			var closerErr CloserError
+			if !errors.As(err, &closerErr) {
+				t.Fatalf("expected %v to be %T", err, closerErr)
+			}
+		})
+
+		t.Run("CloseJoined returns nil when there are no errors", func(t *testing.T) {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			if _, err := Resolve[*mockCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if err := scope.CloseJoined(context.Background()); err != nil {
+				t.Fatalf("unexpected error from CloseJoined: %v", err)
+			}
+		})
+
+		t.Run("closes values in reverse creation order WithCloseConcurrency of 1", func(t *testing.T) {
+			log := &closeLog{}
+			registry, err := registerRecordingCloser[int](Registry{}, log)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = registerRecordingCloser[string](registry, log)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = registerRecordingCloser[bool](registry, log)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			expected := []reflect.Type{
+				reflect.TypeFor[*recordingCloser[bool]](),
+				reflect.TypeFor[*recordingCloser[int]](),
+				reflect.TypeFor[*recordingCloser[string]](),
+			}
+			for _, typ := range slices.Backward(expected) {
+				if _, err := scope.Resolve(typ); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+			if errs := scope.Close(context.Background(), WithCloseConcurrency(1)); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if !reflect.DeepEqual(log.closed, expected) {
+				t.Fatalf("expected values to be closed in order %v; got %v", expected, log.closed)
+			}
+		})
+
+		t.Run("closes at most the default number of values at once", func(t *testing.T) {
+			log := &closeLog{
+				delay: 5 * time.Millisecond,
+			}
+			registry := Registry{}
+			var err error
+			for _, register := range []func(Registry, *closeLog) (Registry, error){
+				registerRecordingCloser[int],
+				registerRecordingCloser[string],
+				registerRecordingCloser[bool],
+				registerRecordingCloser[float64],
+				registerRecordingCloser[byte],
+			} {
+				if registry, err = register(registry, log); err != nil {
+					t.Fatalf("unexpected error from RegisterFactory: %v", err)
+				}
+			}
+			provider, err := registry.BuildRootProvider(WithDefaultCloseConcurrency(2))
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			for typ := range provider.registrations.load() {
+				if _, err := scope.Resolve(typ); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+			if errs := scope.Close(context.Background()); len(errs) != 0 {
+				t.Fatalf("unexpected errors from Close: %v", errs)
+			}
+			if len(log.closed) != 5 {
+				t.Fatalf("expected 5 values to be closed; got %d", len(log.closed))
+			}
+			if log.maxConcurrent > 2 {
+				t.Fatalf("expected at most 2 concurrent closers; got %d", log.maxConcurrent)
+			}
+		})
+
+		t.Run("gives up at the context deadline WithCloseConcurrency of 1", func(t *testing.T) {
+			first := &releasableCloser{
+				release: make(chan struct{}),
+			}
+			registry, err := RegisterFactory[*releasableCloser](Registry{}, Scoped, func(Resolver) (*releasableCloser, error) {
+				return first, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterFactory: %v", err)
+			}
+			registry, err = RegisterType[*mockCloser, *mockCloser](registry, Scoped)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			second, err := Resolve[*mockCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if _, err := scope.Resolve(reflect.TypeFor[*releasableCloser]()); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			errs := scope.Close(ctx, WithCloseConcurrency(1))
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			var incomplete IncompleteClose
+			if !errors.As(errs[0], &incomplete) {
+				t.Fatalf("expected %v to be %T", errs[0], incomplete)
+			}
+			expected := []reflect.Type{
+				reflect.TypeFor[*releasableCloser](),
+				reflect.TypeFor[*mockCloser](),
+			}
+			if !reflect.DeepEqual(incomplete.Types, expected) {
+				t.Fatalf("expected incomplete.Types to be %v; got %v", expected, incomplete.Types)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("expected Close to return at the context deadline; took %v", elapsed)
+			}
+			if abandoned := provider.AbandonedClosers(); len(abandoned) != 2 {
+				t.Fatalf("expected 2 abandoned closers; got %v", abandoned)
+			}
+			close(first.release)
+			deadline := time.Now().Add(5 * time.Second)
+			for len(provider.AbandonedClosers()) != 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected the abandoned closers to finish; got %v", provider.AbandonedClosers())
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if !second.closed {
+				t.Fatalf("expected the value queued behind the deadline to be closed")
+			}
+		})
+
+		t.Run("reports incomplete close when context is Done", func(t *testing.T) {
+			unexpectedErrs := []error{
+				errors.New("first unexpected error"),
+				errors.New("second unexpected error"),
+			}
+			registry, err := RegisterFactory[*blockingContextCloser](Registry{}, Scoped, func(Resolver) (*blockingContextCloser, error) {
+				return &blockingContextCloser{
+					blockTime: time.Second,
+					err:       unexpectedErrs[0],
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterFactory[*blockingCloser](registry, Scoped, func(Resolver) (*blockingCloser, error) {
+				return &blockingCloser{
+					blockTime: time.Second,
+					err:       unexpectedErrs[1],
+				}, nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider()
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			scope := provider.NewScope()
+			_, err = scope.Resolve(reflect.TypeFor[*blockingContextCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			_, err = scope.Resolve(reflect.TypeFor[*blockingCloser]())
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			errs := scope.Close(ctx)
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d (%v)", len(errs), errs)
+			}
+			if !errors.Is(errs[0], ErrCloseTimeout) {
+				t.Fatalf("expected errs[0] to be %v; got %v", ErrCloseTimeout, errs[0])
+			}
+			if !errors.Is(errs[0], context.DeadlineExceeded) {
+				t.Fatalf("expected errs[0] to be %v; got %v", context.DeadlineExceeded, errs[0])
+			}
+			var incomplete IncompleteClose
+			if !errors.As(errs[0], &incomplete) {
+				t.Fatalf("expected %v to be %T", errs[0], incomplete)
+			}
+			if len(incomplete.Types) != 2 {
+				t.Fatalf("expected 2 types not to be confirmed closed; got %v", incomplete.Types)
+			}
+		})
+	})
+}
+
+func TestScopeCloseWithDefault(t *testing.T) {
+
+	newProvider := func(t *testing.T) RootProvider {
+		registry, err := RegisterFactory[*cooperativeContextCloser](Registry{}, Scoped, func(Resolver) (*cooperativeContextCloser, error) {
+			return &cooperativeContextCloser{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("closes the scope with the timeout it was created with", func(t *testing.T) {
+		scope := newProvider(t).NewScope(WithCloseTimeout(10 * time.Millisecond))
+		if _, err := Resolve[*cooperativeContextCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		err := errors.Join(scope.CloseWithDefault()...)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+		if errors.Is(err, ErrCloseContextAlreadyDone) {
+			t.Fatalf("expected the context not to be done when Close was called; got %v", err)
+		}
+	})
+
+	t.Run("reports a context that was already done when Close was called", func(t *testing.T) {
+		scope := newProvider(t).NewScope()
+		if _, err := Resolve[*cooperativeContextCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := scope.CloseJoined(ctx)
+		var alreadyDone CloseContextAlreadyDone
+		if !errors.As(err, &alreadyDone) || !errors.Is(err, ErrCloseContextAlreadyDone) {
+			t.Fatalf("expected %v to include a CloseContextAlreadyDone", err)
+		}
+		if !errors.Is(alreadyDone.Err, context.Canceled) {
+			t.Fatalf("expected %v to be %v", alreadyDone.Err, context.Canceled)
+		}
+		if !errors.Is(err, ErrCloseTimeout) {
+			t.Fatalf("expected %v to include an IncompleteClose", err)
+		}
+	})
+}
+
+type mockContextCloser struct {
+	closed bool
+}
+
+func (m *mockContextCloser) Close(context.Context) error {
+	m.closed = true
+	return nil
 }
 
 type mockCloser struct {
@@ -409,6 +1504,26 @@ func (m *errorContextCloser) Close(context.Context) error {
 	return m.err
 }
 
+type signalingCloser struct {
+	err error
+	ran chan<- struct{}
+}
+
+func (m *signalingCloser) Close() error {
+	m.ran <- struct{}{}
+	return m.err
+}
+
+type signalingContextCloser struct {
+	err error
+	ran chan<- struct{}
+}
+
+func (m *signalingContextCloser) Close(context.Context) error {
+	m.ran <- struct{}{}
+	return m.err
+}
+
 type errorCloser struct {
 	err error
 }
@@ -433,6 +1548,69 @@ func (m *blockingContextCloser) Close(context.Context) error {
 	return m.err
 }
 
+type cooperativeContextCloser struct {
+	//lint:ignore U1000 Field enabled type to be distinct
+	x int
+}
+
+func (m *cooperativeContextCloser) Close(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// A startedContextCloser signals when it starts closing and then blocks until its context is done.
+type startedContextCloser struct {
+	started chan<- struct{}
+}
+
+func (m *startedContextCloser) Close(ctx context.Context) error {
+	m.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type closeLog struct {
+	mu            sync.Mutex
+	delay         time.Duration
+	closed        []reflect.Type
+	concurrent    int
+	maxConcurrent int
+}
+
+type recordingCloser[T any] struct {
+	log *closeLog
+}
+
+func (m *recordingCloser[T]) Close() error {
+	m.log.mu.Lock()
+	m.log.concurrent++
+	m.log.maxConcurrent = max(m.log.maxConcurrent, m.log.concurrent)
+	m.log.mu.Unlock()
+	time.Sleep(m.log.delay)
+	m.log.mu.Lock()
+	defer m.log.mu.Unlock()
+	m.log.concurrent--
+	m.log.closed = append(m.log.closed, reflect.TypeFor[*recordingCloser[T]]())
+	return nil
+}
+
+func registerRecordingCloser[T any](registry Registry, log *closeLog) (Registry, error) {
+	return RegisterFactory[*recordingCloser[T]](registry, Scoped, func(Resolver) (*recordingCloser[T], error) {
+		return &recordingCloser[T]{
+			log: log,
+		}, nil
+	})
+}
+
+type releasableCloser struct {
+	release chan struct{}
+}
+
+func (m *releasableCloser) Close() error {
+	<-m.release
+	return nil
+}
+
 type blockingCloser struct {
 	blockTime time.Duration
 	err       error
@@ -442,3 +1620,93 @@ func (m *blockingCloser) Close(context.Context) error {
 	<-time.After(m.blockTime)
 	return m.err
 }
+
+func BenchmarkScope(b *testing.B) {
+	registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+	if err != nil {
+		b.Fatalf("unexpected error from RegisterType: %v", err)
+	}
+	registry, err = RegisterType[*mockContextCloser, *mockContextCloser](registry, Scoped)
+	if err != nil {
+		b.Fatalf("unexpected error from RegisterType: %v", err)
+	}
+	for _, pooling := range []bool{false, true} {
+		opts := []ProviderOption{}
+		if pooling {
+			opts = append(opts, WithScopePooling())
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			b.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		b.Run(fmt.Sprintf("pooling=%t/resolve nothing", pooling), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				scope := provider.NewScope()
+				_ = scope.Close(context.Background())
+			}
+		})
+		b.Run(fmt.Sprintf("pooling=%t/resolve two scoped values", pooling), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				scope := provider.NewScope()
+				_, _ = Resolve[*mockCloser](scope)
+				_, _ = Resolve[*mockContextCloser](scope)
+				_ = scope.Close(context.Background())
+			}
+		})
+	}
+}
+
+func BenchmarkScopeParallelResolve(b *testing.B) {
+	registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Scoped)
+	if err != nil {
+		b.Fatalf("unexpected error from RegisterType: %v", err)
+	}
+	provider, err := registry.BuildRootProvider()
+	if err != nil {
+		b.Fatalf("unexpected error from BuildRootProvider: %v", err)
+	}
+	scope := provider.NewScope()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = Resolve[*mockCloser](scope)
+		}
+	})
+}
+
+func BenchmarkScopeCapacity(b *testing.B) {
+	registry := Registry{}
+	var err error
+	for _, register := range []func(Registry, Lifetime, ...RegistrationOption) (Registry, error){
+		RegisterType[*[1]byte, *[1]byte],
+		RegisterType[*[2]byte, *[2]byte],
+		RegisterType[*[3]byte, *[3]byte],
+		RegisterType[*[4]byte, *[4]byte],
+		RegisterType[*[5]byte, *[5]byte],
+		RegisterType[*[6]byte, *[6]byte],
+		RegisterType[*[7]byte, *[7]byte],
+		RegisterType[*[8]byte, *[8]byte],
+	} {
+		if registry, err = register(registry, Scoped); err != nil {
+			b.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+	}
+	provider, err := registry.BuildRootProvider()
+	if err != nil {
+		b.Fatalf("unexpected error from BuildRootProvider: %v", err)
+	}
+	types := slices.Collect(maps.Keys(provider.registrations.load()))
+	for _, expected := range []int{1, 8} {
+		b.Run(fmt.Sprintf("expected instances=%d", expected), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				scope := provider.NewScope(WithExpectedInstances(expected))
+				for _, typ := range types {
+					_, _ = scope.Resolve(typ)
+				}
+			}
+		})
+	}
+}
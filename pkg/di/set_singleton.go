@@ -0,0 +1,153 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotSingleton is returned when an attempt is made to set the instance of a type that is not
+// registered as a [Singleton].
+var ErrNotSingleton = errors.New("type is not registered as a singleton")
+
+// A NotSingleton is an [error] indicating that an attempt was made to set the instance of a type
+// that is not registered as a [Singleton]. Calling [errors.Is] with a NotSingleton and
+// [ErrNotSingleton] returns true.
+type NotSingleton struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Lifetime is the lifetime the type is registered with.
+	Lifetime Lifetime
+}
+
+// Error implements [error].
+func (err NotSingleton) Error() string {
+	return fmt.Sprintf("type %v is registered as %v, not as a singleton", err.Type, err.Lifetime)
+}
+
+// Is indicates that a [NotSingleton] is [ErrNotSingleton].
+func (NotSingleton) Is(target error) bool {
+	return target == ErrNotSingleton
+}
+
+// Code returns "not_singleton", the code of a [NotSingleton] in [ErrorCodes].
+func (NotSingleton) Code() string {
+	return "not_singleton"
+}
+
+// ErrSingletonConstructed is returned when an attempt is made to set the instance of a [Singleton]
+// that the provider has already constructed.
+var ErrSingletonConstructed = errors.New("singleton has already been constructed")
+
+// A SingletonConstructed is an [error] indicating that an attempt was made to set the instance of a
+// [Singleton] that the provider has already constructed. Calling [errors.Is] with a
+// SingletonConstructed and [ErrSingletonConstructed] returns true.
+type SingletonConstructed struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+}
+
+// Error implements [error].
+func (err SingletonConstructed) Error() string {
+	return fmt.Sprintf("cannot set the instance of type %v after it has been constructed", err.Type)
+}
+
+// Is indicates that a [SingletonConstructed] is [ErrSingletonConstructed].
+func (SingletonConstructed) Is(target error) bool {
+	return target == ErrSingletonConstructed
+}
+
+// Code returns "singleton_constructed", the code of a [SingletonConstructed] in [ErrorCodes].
+func (SingletonConstructed) Code() string {
+	return "singleton_constructed"
+}
+
+// A SetSingletonOption configures optional behavior for a single call to
+// [RootProvider.SetSingleton].
+type SetSingletonOption func(*setSingletonOptions)
+
+type setSingletonOptions struct {
+	force bool
+}
+
+// Force makes [RootProvider.SetSingleton] replace an instance the provider has already
+// constructed. Values that resolved the replaced instance keep using it.
+func Force() SetSingletonOption {
+	return func(options *setSingletonOptions) {
+		options.force = true
+	}
+}
+
+// SetSingletonFor is a generic wrapper for [RootProvider.SetSingleton] that sets the instance of T.
+func SetSingletonFor[T any](provider RootProvider, value T, opts ...SetSingletonOption) error {
+	return provider.SetSingleton(reflect.TypeFor[T](), value, opts...)
+}
+
+// SetSingleton makes value the instance of the [Singleton] registered for typ, e.g. to substitute
+// a fake for one value in a test while keeping the rest of the real wiring. Like an instance
+// registered with [RegisterInstance], value is not owned by the provider and is not closed when
+// the provider is closed.
+//
+// SetSingleton returns an [UnknownType] if typ is not registered, a [NotSingleton] if it is not
+// registered as a Singleton, a [NilInstance] if value is nil, an [InvalidImplementation] if value
+// is not assignable to typ, and a [ProviderFrozen] if the provider has been frozen with [RootProvider.Freeze]. It returns a
+// [SingletonConstructed] if the provider has already constructed an instance of typ, since values
+// resolved before the change would not be consistent with those resolved after it, unless [Force]
+// is given. A replaced instance the provider owns is closed in the background and the result is
+// reported to the provider's [Observer] as a [DisplacedSingletonClosed].
+func (provider RootProvider) SetSingleton(typ reflect.Type, value any, opts ...SetSingletonOption) error {
+	if err := provider.checkInitialized("SetSingleton"); err != nil {
+		return err
+	}
+	if err := provider.checkMutable("SetSingleton"); err != nil {
+		return err
+	}
+	registration_, ok := provider.registrations.get(typ)
+	if !ok {
+		return UnknownType{
+			Type: typ,
+		}
+	}
+	if registration_.lifetime != Singleton {
+		return NotSingleton{
+			Type:     typ,
+			Lifetime: registration_.lifetime,
+		}
+	}
+	value, err := assignableInstance(typ, value)
+	if err != nil {
+		return err
+	}
+	options := setSingletonOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	old, replaced, stored := provider.singletonsFor(typ).replace(typ, value, options.force)
+	if !stored {
+		return SingletonConstructed{
+			Type: typ,
+		}
+	}
+	impl := reflect.TypeOf(value)
+	replacement := registration{
+		lifetime: Singleton,
+		impl:     impl,
+		factory: func(Resolver) (any, error) {
+			return value, nil
+		},
+		scopeName: registration_.scopeName,
+		priority:  registration_.priority,
+		order:     registration_.order,
+	}
+	previous, _ := provider.registrations.swap(typ, replacement)
+	if store, ok := provider.options.sharedSingletons[typ]; ok {
+		store.record(typ, replacement)
+	}
+	if replaced && previous.owned && !previous.inherited {
+		go provider.closeDisplaced(typ, old, 0)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetSingleton(t *testing.T) {
+
+	newProvider := func(t *testing.T, lifetime Lifetime, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[Closer, *mockCloser](Registry{}, lifetime)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("resolves the value instead of constructing one", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		expected := &errorCloser{}
+		if err := SetSingletonFor[Closer](provider, expected); err != nil {
+			t.Fatalf("unexpected error from SetSingletonFor: %v", err)
+		}
+		actual, err := Resolve[Closer](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if actual != expected {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("does not close the value when the provider is closed", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		value := &mockCloser{}
+		if err := SetSingletonFor[Closer](provider, value); err != nil {
+			t.Fatalf("unexpected error from SetSingletonFor: %v", err)
+		}
+		if errs := provider.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if value.closed {
+			t.Fatalf("expected the value not to be closed")
+		}
+	})
+
+	t.Run("returns UnknownType for unregistered types", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		err := SetSingletonFor[*mockCloser](provider, &mockCloser{})
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to be %v", err, ErrUnknownType)
+		}
+	})
+
+	t.Run("returns NotSingleton for other lifetimes", func(t *testing.T) {
+		provider := newProvider(t, Scoped)
+		err := SetSingletonFor[Closer](provider, &mockCloser{})
+		var notSingleton NotSingleton
+		if !errors.As(err, &notSingleton) {
+			t.Fatalf("expected %v to be %T", err, notSingleton)
+		}
+		if notSingleton.Lifetime != Scoped {
+			t.Fatalf("expected err.Lifetime to be %v; got %v", Scoped, notSingleton.Lifetime)
+		}
+	})
+
+	t.Run("returns InvalidImplementation for unassignable values", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		err := provider.SetSingleton(reflect.TypeFor[Closer](), "not a closer")
+		if !errors.Is(err, ErrInvalidImplementation) {
+			t.Fatalf("expected %v to be %v", err, ErrInvalidImplementation)
+		}
+	})
+
+	t.Run("returns ProviderFrozen after Freeze", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		if err := provider.Freeze(); err != nil {
+			t.Fatalf("unexpected error from Freeze: %v", err)
+		}
+		err := SetSingletonFor[Closer](provider, &mockCloser{})
+		if !errors.Is(err, ErrProviderFrozen) {
+			t.Fatalf("expected %v to be %v", err, ErrProviderFrozen)
+		}
+	})
+
+	t.Run("returns SingletonConstructed after the singleton was resolved", func(t *testing.T) {
+		provider := newProvider(t, Singleton)
+		if _, err := Resolve[Closer](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		err := SetSingletonFor[Closer](provider, &errorCloser{})
+		if !errors.Is(err, ErrSingletonConstructed) {
+			t.Fatalf("expected %v to be %v", err, ErrSingletonConstructed)
+		}
+	})
+
+	t.Run("Force replaces and closes a constructed singleton", func(t *testing.T) {
+		events := make(chan Event, 1)
+		provider := newProvider(t, Singleton, WithObserver(ObserverFunc(func(event Event) {
+			events <- event
+		})))
+		replaced, err := Resolve[Closer](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		expected := &errorCloser{}
+		if err := SetSingletonFor[Closer](provider, expected, Force()); err != nil {
+			t.Fatalf("unexpected error from SetSingletonFor: %v", err)
+		}
+		if actual, err := Resolve[Closer](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		} else if actual != expected {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+		select {
+		case event := <-events:
+			if _, ok := event.(DisplacedSingletonClosed); !ok {
+				t.Fatalf("expected %T; got %T", DisplacedSingletonClosed{}, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the replaced singleton to be closed")
+		}
+		if !replaced.(*mockCloser).closed {
+			t.Fatalf("expected the replaced singleton to be closed")
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package di
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownPhaseTimeout is the time [CloseOnSignal] allows for each [ShutdownPhase] unless
+// [WithPhaseTimeout] is given.
+const DefaultShutdownPhaseTimeout = 10 * time.Second
+
+// A ShutdownPhase is a step of the shutdown performed by [CloseOnSignal]. The phases run in the
+// order they are declared.
+type ShutdownPhase int
+
+const (
+
+	// ShutdownStoppingHostedServices stops the provider's hosted services that have been started,
+	// in the reverse of the order they were started; see [RootProvider.StopHostedServices].
+	ShutdownStoppingHostedServices ShutdownPhase = iota
+
+	// ShutdownClosingScopes closes the scopes created from the provider that have not been closed,
+	// starting with the most recently created. The scopes are only known to the provider if it was
+	// built with [WithScopeTracking]; otherwise the phase does nothing.
+	ShutdownClosingScopes
+
+	// ShutdownClosingProvider closes the provider.
+	ShutdownClosingProvider
+)
+
+// String implements [fmt.Stringer] by returning the name of the phase.
+func (phase ShutdownPhase) String() string {
+	switch phase {
+	case ShutdownStoppingHostedServices:
+		return "StoppingHostedServices"
+	case ShutdownClosingScopes:
+		return "ClosingScopes"
+	case ShutdownClosingProvider:
+		return "ClosingProvider"
+	default:
+		return "Unknown"
+	}
+}
+
+// A SecondSignal is what [CloseOnSignal] does when it receives another signal while shutting
+// down.
+type SecondSignal int
+
+const (
+
+	// IgnoreSecondSignal lets the shutdown continue as if the signal had not been received.
+	IgnoreSecondSignal SecondSignal = iota
+
+	// AbortOnSecondSignal cancels the context of the phase that is running, so that closers that
+	// honor it give up, and skips the phases that have not started.
+	AbortOnSecondSignal
+)
+
+// A ShutdownOption configures optional behavior for [CloseOnSignal].
+type ShutdownOption func(*shutdownOptions)
+
+type shutdownOptions struct {
+	signals      []os.Signal
+	timeouts     map[ShutdownPhase]time.Duration
+	secondSignal SecondSignal
+
+	// received, if set, is the channel the signals are received from instead of one registered
+	// with signal.Notify.
+	received chan os.Signal
+}
+
+func newShutdownOptions(opts []ShutdownOption) shutdownOptions {
+	options := shutdownOptions{
+		signals:  []os.Signal{os.Interrupt, syscall.SIGTERM},
+		timeouts: map[ShutdownPhase]time.Duration{},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+func (options shutdownOptions) timeout(phase ShutdownPhase) time.Duration {
+	if timeout, ok := options.timeouts[phase]; ok {
+		return timeout
+	}
+	return DefaultShutdownPhaseTimeout
+}
+
+// WithShutdownSignals sets the signals that start the shutdown. By default the shutdown is
+// started by [os.Interrupt] and SIGTERM. Giving no signals means the shutdown is only started by
+// calling the function returned by [CloseOnSignal].
+func WithShutdownSignals(signals ...os.Signal) ShutdownOption {
+	return func(options *shutdownOptions) {
+		options.signals = signals
+	}
+}
+
+// WithPhaseTimeout sets the time allowed for phase. A timeout of 0 or less means the phase is not
+// given a deadline.
+func WithPhaseTimeout(phase ShutdownPhase, timeout time.Duration) ShutdownOption {
+	return func(options *shutdownOptions) {
+		options.timeouts[phase] = timeout
+	}
+}
+
+// WithSecondSignal sets what happens when another signal is received during the shutdown. By
+// default it is ignored.
+func WithSecondSignal(behavior SecondSignal) ShutdownOption {
+	return func(options *shutdownOptions) {
+		options.secondSignal = behavior
+	}
+}
+
+// withSignalChannel makes CloseOnSignal receive its signals from received instead of installing a
+// handler, so that tests can send them without signalling the process.
+func withSignalChannel(received chan os.Signal) ShutdownOption {
+	return func(options *shutdownOptions) {
+		options.received = received
+	}
+}
+
+// A ShutdownStarted is an [Event] indicating that [CloseOnSignal] started shutting the provider
+// down.
+type ShutdownStarted struct {
+
+	// Signal is the signal that started the shutdown, or nil if it was started by calling the
+	// function returned by CloseOnSignal.
+	Signal os.Signal
+}
+
+func (ShutdownStarted) event() {}
+
+// A ShutdownPhaseCompleted is an [Event] indicating that a [ShutdownPhase] finished.
+type ShutdownPhaseCompleted struct {
+
+	// Phase is the phase that finished.
+	Phase ShutdownPhase
+
+	// Duration is how long the phase took.
+	Duration time.Duration
+
+	// Errors are the errors from the phase, if any.
+	Errors []error
+}
+
+func (ShutdownPhaseCompleted) event() {}
+
+// A ShutdownAborted is an [Event] indicating that [CloseOnSignal] received another signal during
+// the shutdown and aborted it because of [AbortOnSecondSignal].
+type ShutdownAborted struct {
+
+	// Signal is the signal that aborted the shutdown.
+	Signal os.Signal
+
+	// Skipped are the phases that were not started.
+	Skipped []ShutdownPhase
+}
+
+func (ShutdownAborted) event() {}
+
+// CloseOnSignal installs a handler that shuts the provider down when the process receives one of
+// the signals set with [WithShutdownSignals]. The shutdown runs each [ShutdownPhase] in order with
+// the timeout set for it by [WithPhaseTimeout], and reports its progress and errors to the
+// provider's [Observer] with a [ShutdownStarted] and a [ShutdownPhaseCompleted] for each phase.
+// Once the shutdown finishes the handler is uninstalled, so another signal has its usual effect.
+//
+// The returned stop function uninstalls the handler and runs the same shutdown if it has not
+// run yet, e.g. when the service is stopped by other means, and returns once the shutdown has
+// finished. It is safe to call more than once and concurrently with a signal-triggered shutdown,
+// which it waits for.
+//
+//	stop := di.CloseOnSignal(provider)
+//	defer stop()
+func CloseOnSignal(provider RootProvider, opts ...ShutdownOption) (stop func()) {
+	options := newShutdownOptions(opts)
+	ctx, abort := context.WithCancel(context.Background())
+	s := &shutdown{
+		provider: provider,
+		options:  options,
+		ctx:      ctx,
+		abort:    abort,
+		signals:  options.received,
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if s.signals == nil {
+		s.signals = make(chan os.Signal, 1)
+		if len(options.signals) > 0 {
+			signal.Notify(s.signals, options.signals...)
+		}
+	}
+	if len(options.signals) > 0 {
+		go s.watch()
+	}
+	return s.stop
+}
+
+type shutdown struct {
+	provider RootProvider
+	options  shutdownOptions
+
+	// ctx is cancelled to abort the shutdown.
+	ctx   context.Context
+	abort context.CancelFunc
+
+	signals  chan os.Signal
+	stopOnce sync.Once
+	stopped  chan struct{}
+	once     sync.Once
+	done     chan struct{}
+
+	// started is the number of phases that have started.
+	phaseMu sync.Mutex
+	started int
+}
+
+// watch waits for a signal to start the shutdown, and then for signals received during it, until
+// the shutdown finishes or stop is called.
+func (s *shutdown) watch() {
+	select {
+	case sig := <-s.signals:
+		go s.run(sig)
+	case <-s.stopped:
+		return
+	}
+	for {
+		select {
+		case sig := <-s.signals:
+			if s.options.secondSignal == AbortOnSecondSignal {
+				s.provider.observe(ShutdownAborted{
+					Signal:  sig,
+					Skipped: s.pendingPhases(),
+				})
+				s.abort()
+			}
+		case <-s.done:
+			s.uninstall()
+			return
+		case <-s.stopped:
+			return
+		}
+	}
+}
+
+func (s *shutdown) stop() {
+	s.uninstall()
+	s.run(nil)
+}
+
+func (s *shutdown) uninstall() {
+	s.stopOnce.Do(func() {
+		signal.Stop(s.signals)
+		close(s.stopped)
+	})
+}
+
+// run shuts the provider down unless it has already been shut down, and returns once it has.
+func (s *shutdown) run(sig os.Signal) {
+	s.once.Do(func() {
+		defer close(s.done)
+		defer s.abort()
+		s.provider.observe(ShutdownStarted{
+			Signal: sig,
+		})
+		for _, phase := range shutdownPhases {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.runPhase(phase)
+		}
+	})
+}
+
+// shutdownPhases are the phases of the shutdown in order.
+var shutdownPhases = []ShutdownPhase{
+	ShutdownStoppingHostedServices,
+	ShutdownClosingScopes,
+	ShutdownClosingProvider,
+}
+
+// pendingPhases returns the phases that have not started.
+func (s *shutdown) pendingPhases() []ShutdownPhase {
+	s.phaseMu.Lock()
+	defer s.phaseMu.Unlock()
+	return shutdownPhases[s.started:]
+}
+
+func (s *shutdown) runPhase(phase ShutdownPhase) {
+	s.phaseMu.Lock()
+	s.started++
+	s.phaseMu.Unlock()
+	ctx := s.ctx
+	if timeout := s.options.timeout(phase); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	var errs []error
+	switch phase {
+	case ShutdownStoppingHostedServices:
+		errs = s.provider.stopHostedServices(ctx)
+	case ShutdownClosingScopes:
+		if s.provider.options.scopeTracking {
+			errs = s.provider.openScopes.closeAll(ctx)
+		}
+	case ShutdownClosingProvider:
+		errs = s.provider.Close(ctx)
+	}
+	s.provider.observe(ShutdownPhaseCompleted{
+		Phase:    phase,
+		Duration: time.Since(start),
+		Errors:   errs,
+	})
+}
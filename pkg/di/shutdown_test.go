@@ -0,0 +1,208 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type shutdownLog struct {
+	mu     sync.Mutex
+	closed []string
+	events []Event
+}
+
+func (l *shutdownLog) add(closed string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = append(l.closed, closed)
+}
+
+func (l *shutdownLog) Observe(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *shutdownLog) snapshot() ([]string, []Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.closed), slices.Clone(l.events)
+}
+
+type shutdownConn struct {
+	log     *shutdownLog
+	started chan struct{}
+}
+
+func (c *shutdownConn) Close(ctx context.Context) error {
+	if c.started != nil {
+		close(c.started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	c.log.add("conn")
+	return nil
+}
+
+type shutdownPool struct {
+	log *shutdownLog
+}
+
+func (p *shutdownPool) Close() error {
+	p.log.add("pool")
+	return nil
+}
+
+func TestCloseOnSignal(t *testing.T) {
+
+	newProvider := func(t *testing.T, blocking bool) (RootProvider, *shutdownLog, chan struct{}) {
+		log := &shutdownLog{}
+		var started chan struct{}
+		if blocking {
+			started = make(chan struct{})
+		}
+		registry, err := RegisterFactory[*shutdownConn](Registry{}, Scoped, func(Resolver) (*shutdownConn, error) {
+			return &shutdownConn{log: log, started: started}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*shutdownPool](registry, Singleton, func(Resolver) (*shutdownPool, error) {
+			return &shutdownPool{log: log}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithScopeTracking(), WithObserver(log))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[*shutdownPool](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if _, err := Resolve[*shutdownConn](provider.NewScope()); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		return provider, log, started
+	}
+
+	phases := func(events []Event) []ShutdownPhase {
+		var phases []ShutdownPhase
+		for _, event := range events {
+			if completed, ok := event.(ShutdownPhaseCompleted); ok {
+				phases = append(phases, completed.Phase)
+			}
+		}
+		return phases
+	}
+
+	t.Run("stop stops the hosted services and closes the open scopes and then the provider", func(t *testing.T) {
+		provider, log, _ := newProvider(t, false)
+		stop := CloseOnSignal(provider)
+		stop()
+		stop()
+		closed, events := log.snapshot()
+		if !slices.Equal(closed, []string{"conn", "pool"}) {
+			t.Fatalf("expected the scope to be closed before the provider; got %v", closed)
+		}
+		if started, ok := events[0].(ShutdownStarted); !ok || started.Signal != nil {
+			t.Fatalf("expected the shutdown to start without a signal; got %#v", events[0])
+		}
+		expected := []ShutdownPhase{ShutdownStoppingHostedServices, ShutdownClosingScopes, ShutdownClosingProvider}
+		if actual := phases(events); !slices.Equal(actual, expected) {
+			t.Fatalf("expected phases %v; got %v", expected, actual)
+		}
+		if scopes, _ := provider.OpenScopes(); len(scopes) != 0 {
+			t.Fatalf("expected no open scopes; got %v", scopes)
+		}
+	})
+
+	t.Run("shuts down when a signal is received", func(t *testing.T) {
+		provider, log, _ := newProvider(t, false)
+		closed := make(chan struct{})
+		provider.OnClose(func([]error) {
+			close(closed)
+		})
+		signals := make(chan os.Signal, 1)
+		stop := CloseOnSignal(provider, withSignalChannel(signals))
+		defer stop()
+		signals <- os.Interrupt
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the provider to be closed")
+		}
+		stop()
+		_, events := log.snapshot()
+		if started, ok := events[0].(ShutdownStarted); !ok || started.Signal != os.Interrupt {
+			t.Fatalf("expected the shutdown to start with %v; got %#v", os.Interrupt, events[0])
+		}
+	})
+
+	t.Run("gives each phase its timeout", func(t *testing.T) {
+		provider, log, _ := newProvider(t, true)
+		CloseOnSignal(provider, WithPhaseTimeout(ShutdownClosingScopes, 10*time.Millisecond))()
+		closed, events := log.snapshot()
+		if !slices.Equal(closed, []string{"pool"}) {
+			t.Fatalf("expected the provider to be closed after the scopes timed out; got %v", closed)
+		}
+		completed := events[2].(ShutdownPhaseCompleted)
+		if !errors.Is(errors.Join(completed.Errors...), context.DeadlineExceeded) {
+			t.Fatalf("expected %v to include %v", completed.Errors, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("aborts on a second signal if configured", func(t *testing.T) {
+		provider, log, started := newProvider(t, true)
+		signals := make(chan os.Signal, 1)
+		stop := CloseOnSignal(
+			provider,
+			WithPhaseTimeout(ShutdownClosingScopes, 0),
+			WithSecondSignal(AbortOnSecondSignal),
+			withSignalChannel(signals))
+		aborted := func() (ShutdownAborted, bool) {
+			_, events := log.snapshot()
+			for _, event := range events {
+				if event, ok := event.(ShutdownAborted); ok {
+					return event, true
+				}
+			}
+			return ShutdownAborted{}, false
+		}
+		signals <- os.Interrupt
+		<-started
+		signals <- os.Interrupt
+		deadline := time.Now().Add(5 * time.Second)
+		for _, ok := aborted(); !ok; _, ok = aborted() {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the shutdown to be aborted")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		stop()
+		if closed, _ := log.snapshot(); len(closed) != 0 {
+			t.Fatalf("expected the provider not to be closed; got %v", closed)
+		}
+		if aborted, _ := aborted(); !slices.Equal(aborted.Skipped, []ShutdownPhase{ShutdownClosingProvider}) {
+			t.Fatalf("expected the provider phase to be skipped; got %#v", aborted)
+		}
+	})
+
+	t.Run("ShutdownPhase.String names the phases", func(t *testing.T) {
+		for phase, expected := range map[ShutdownPhase]string{
+			ShutdownStoppingHostedServices: "StoppingHostedServices",
+			ShutdownClosingScopes:          "ClosingScopes",
+			ShutdownClosingProvider:        "ClosingProvider",
+			ShutdownPhase(-1):              "Unknown",
+		} {
+			if actual := phase.String(); actual != expected {
+				t.Errorf("expected %q; got %q", expected, actual)
+			}
+		}
+	})
+}
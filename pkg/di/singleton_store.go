@@ -0,0 +1,81 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"sync"
+)
+
+// A SingletonStore holds [Singleton] values shared by several providers, e.g. a database
+// connection pool shared by the providers for two servers in the same process. Providers share
+// the values for the types given to [WithSharedSingletons] with the store. A SingletonStore must be
+// created using [NewSingletonStore].
+type SingletonStore struct {
+	instances  instanceMap
+	closeState closeState
+	abandoned  abandonedClosers
+
+	mu sync.Mutex
+
+	// registrations holds the registrations the instances were created from, which determine
+	// whether the store owns them.
+	registrations map[reflect.Type]registration
+}
+
+// NewSingletonStore creates an empty [SingletonStore].
+func NewSingletonStore() *SingletonStore {
+	return &SingletonStore{
+		registrations: map[reflect.Type]registration{},
+	}
+}
+
+// resolve returns the store's instance of typ, constructing it from registration if there isn't
+// one yet.
+func (store *SingletonStore) resolve(typ reflect.Type, registration registration, resolver Resolver) (any, error) {
+	return store.instances.resolve(typ, func(resolver Resolver) (any, error) {
+		value, err := registration.construct(typ, resolver)
+		if err == nil {
+			store.record(typ, registration)
+		}
+		return value, err
+	}, resolver)
+}
+
+// record records the registration the store's instance of typ was created from.
+func (store *SingletonStore) record(typ reflect.Type, registration registration) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.registrations[typ] = registration
+}
+
+// Close closes the values in the store that implement [ContextCloser] or [Closer] and that the
+// providers that created them owned. The providers sharing the store do not close its values, so
+// Close should be called once those providers have been closed. Close gives up on any values that
+// have not finished closing when ctx is done in the same way as [RootProvider.Close]. Only the
+// first call to Close closes the store; subsequent calls return no errors.
+//
+// Cleanups that factories for shared values defer with [RootProvider.Defer] belong to the provider
+// that constructed the value and are run when that provider is closed.
+func (store *SingletonStore) Close(ctx context.Context, opts ...CloseOption) []error {
+	if !store.closeState.begin() {
+		return nil
+	}
+	store.instances.expiry.stop()
+	store.mu.Lock()
+	registrations := maps.Clone(store.registrations)
+	store.mu.Unlock()
+	return store.closeState.finish(closeValues(
+		ctx,
+		ownedEntries(store.instances.entries(), registrations),
+		nil,
+		&store.abandoned,
+		newCloseOptions(&providerOptions{}, opts)))
+}
+
+// CloseJoined calls [SingletonStore.Close] and returns the errors it produced joined with
+// [errors.Join], or nil if there were none.
+func (store *SingletonStore) CloseJoined(ctx context.Context, opts ...CloseOption) error {
+	return errors.Join(store.Close(ctx, opts...)...)
+}
@@ -0,0 +1,83 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSingletonStore(t *testing.T) {
+
+	build := func(t *testing.T, store *SingletonStore, constructions *atomic.Int32) RootProvider {
+		registry, err := RegisterFactory[*mockCloser](Registry{}, Singleton, func(Resolver) (*mockCloser, error) {
+			constructions.Add(1)
+			return &mockCloser{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterType[*verifyTransient, *verifyTransient](registry, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithSharedSingletons(
+			store,
+			reflect.TypeFor[*mockCloser](),
+			reflect.TypeFor[*verifyTransient]()))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("constructs shared values once across providers", func(t *testing.T) {
+		store := NewSingletonStore()
+		constructions := &atomic.Int32{}
+		providers := []RootProvider{build(t, store, constructions), build(t, store, constructions)}
+		values := make([]*verifyTransient, 16)
+		var wg sync.WaitGroup
+		for i := range values {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := Resolve[*verifyTransient](providers[i%len(providers)])
+				if err != nil {
+					t.Errorf("unexpected error from Resolve: %v", err)
+				}
+				values[i] = value
+			}()
+		}
+		wg.Wait()
+		if n := constructions.Load(); n != 1 {
+			t.Fatalf("expected 1 construction; got %d", n)
+		}
+		for _, value := range values {
+			if value != values[0] {
+				t.Fatalf("expected every provider to resolve the same instance")
+			}
+		}
+	})
+
+	t.Run("the store closes shared values instead of the providers", func(t *testing.T) {
+		store := NewSingletonStore()
+		provider := build(t, store, &atomic.Int32{})
+		value, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if errs := provider.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if value.closed {
+			t.Fatalf("expected the provider not to close the shared value")
+		}
+		if errs := store.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if !value.closed {
+			t.Fatalf("expected the store to close the shared value")
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A StructTreeOption configures optional behavior for [RegisterStructTree].
+type StructTreeOption func(*structTreeOptions)
+
+type structTreeOptions struct {
+	lifetime Lifetime
+	skip     map[reflect.Type]bool
+}
+
+// WithFieldLifetime sets the lifetime [RegisterStructTree] registers the types of fields with,
+// which is [Transient] by default.
+func WithFieldLifetime(lifetime Lifetime) StructTreeOption {
+	return func(options *structTreeOptions) {
+		options.lifetime = lifetime
+	}
+}
+
+// SkipTypes makes [RegisterStructTree] leave types unregistered and not walk their fields, e.g. to
+// register them explicitly. SkipTypes can be given more than once to skip more types.
+func SkipTypes(types ...reflect.Type) StructTreeOption {
+	return func(options *structTreeOptions) {
+		for _, typ := range types {
+			options.skip[typ] = true
+		}
+	}
+}
+
+// A StructTree summarizes what [RegisterStructTree] did.
+type StructTree struct {
+
+	// Registered are the types that were registered, starting with the root and then in the order
+	// their fields were found.
+	Registered []reflect.Type
+
+	// Unregistered are the types of fields that were neither registered already nor registered by
+	// RegisterStructTree, such as interfaces, in the order they were found. They still need to be
+	// registered before the root can be resolved.
+	Unregistered []reflect.Type
+}
+
+// String implements [fmt.Stringer] by listing the registered and unregistered types, e.g. for
+// logging.
+func (tree StructTree) String() string {
+	names := func(types []reflect.Type) string {
+		if len(types) == 0 {
+			return "none"
+		}
+		names := make([]string, 0, len(types))
+		for _, typ := range types {
+			names = append(names, typ.String())
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("registered %s; unregistered %s", names(tree.Registered), names(tree.Unregistered))
+}
+
+// RegisterStructTree registers Root with its default factory, as [RegisterType] would, and then
+// walks its exported fields, registering each pointer-to-struct type it finds with its default
+// factory and walking its fields in turn. Types that are already registered or given to
+// [SkipTypes] are neither registered nor walked, and each type is visited once, so cycles end.
+// Fields of other types, such as interfaces, are reported in [StructTree.Unregistered] so they
+// can be registered explicitly. RegisterStructTree is a convenience for bootstrapping an
+// application, not a replacement for explicit registrations. If it returns an error the registry
+// is unchanged.
+func RegisterStructTree[Root any](
+	registry Registry,
+	lifetime Lifetime,
+	opts ...StructTreeOption,
+) (Registry, StructTree, error) {
+	options := structTreeOptions{
+		lifetime: Transient,
+		skip:     map[reflect.Type]bool{},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	root := reflect.TypeFor[Root]()
+	tree := StructTree{
+		Registered:   []reflect.Type{},
+		Unregistered: []reflect.Type{},
+	}
+	registrations := map[reflect.Type]registration{}
+	visited := map[reflect.Type]bool{}
+
+	var visit func(typ reflect.Type, lifetime Lifetime) error
+	visit = func(typ reflect.Type, lifetime Lifetime) error {
+		visited[typ] = true
+		registration_, err := defaultRegistration(typ, lifetime)
+		if err != nil {
+			return err
+		}
+		registrations[typ] = registration_
+		tree.Registered = append(tree.Registered, typ)
+		for _, field := range exportedFields(typ) {
+			if visited[field.Type] || options.skip[field.Type] {
+				continue
+			}
+			if _, ok := registry.registrations[field.Type]; ok {
+				continue
+			}
+			if !isStructPointer(field.Type) {
+				visited[field.Type] = true
+				tree.Unregistered = append(tree.Unregistered, field.Type)
+				continue
+			}
+			if err := visit(field.Type, options.lifetime); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(root, lifetime); err != nil {
+		return registry, StructTree{}, err
+	}
+
+	for _, typ := range tree.Registered {
+		registry = addRegistration(registry, typ, registrations[typ])
+	}
+	return registry, tree, nil
+}
+
+// defaultRegistration creates a registration of typ with its default factory.
+func defaultRegistration(typ reflect.Type, lifetime Lifetime) (registration, error) {
+	if err := validateRegistrationTypes(typ, typ); err != nil {
+		return registration{}, err
+	}
+	factory, err := getDefaultFactory(typ, defaultFactoryOptions{})
+	if err != nil {
+		return registration{}, err
+	}
+	registration_ := newRegistration(lifetime, typ, factory, true, nil)
+	if err := validateLifetime(typ, lifetime, registration_.sharedValue); err != nil {
+		return registration{}, err
+	}
+	registration_.dependencies = defaultFactoryDependencies(typ)
+	registration_.source = sourceDefault
+	return registration_, nil
+}
+
+func isStructPointer(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Pointer && typ.Elem().Kind() == reflect.Struct
+}
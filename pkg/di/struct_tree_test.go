@@ -0,0 +1,101 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type treeNotifier interface {
+	Notify(string)
+}
+
+type treeNode struct {
+	Next *treeNode
+}
+
+type treeRepo struct {
+	Node *treeNode
+}
+
+type treeService struct {
+	Repo     *treeRepo
+	Notifier treeNotifier
+	Clock    *treeClock
+}
+
+type treeClock struct{}
+
+type treeApp struct {
+	Service *treeService
+	Repo    *treeRepo
+}
+
+func TestRegisterStructTree(t *testing.T) {
+
+	t.Run("registers the root and the pointer-to-struct types it depends on", func(t *testing.T) {
+		registry, tree, err := RegisterStructTree[*treeApp](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterStructTree: %v", err)
+		}
+		expected := []reflect.Type{
+			reflect.TypeFor[*treeApp](),
+			reflect.TypeFor[*treeService](),
+			reflect.TypeFor[*treeRepo](),
+			reflect.TypeFor[*treeNode](),
+			reflect.TypeFor[*treeClock](),
+		}
+		if !slices.Equal(tree.Registered, expected) {
+			t.Errorf("expected %v to be registered; got %v", expected, tree.Registered)
+		}
+		if unregistered := []reflect.Type{reflect.TypeFor[treeNotifier]()}; !slices.Equal(tree.Unregistered, unregistered) {
+			t.Errorf("expected %v to be unregistered; got %v", unregistered, tree.Unregistered)
+		}
+		lifetimes := map[reflect.Type]Lifetime{}
+		for _, info := range registry.Registrations() {
+			lifetimes[info.Type] = info.Lifetime
+		}
+		if lifetimes[reflect.TypeFor[*treeApp]()] != Singleton || lifetimes[reflect.TypeFor[*treeRepo]()] != Transient {
+			t.Errorf("expected a singleton root with transient fields; got %v", lifetimes)
+		}
+		if expected := "registered *di.treeApp, *di.treeService, *di.treeRepo, *di.treeNode, *di.treeClock; unregistered di.treeNotifier"; tree.String() != expected {
+			t.Errorf("expected %q; got %q", expected, tree.String())
+		}
+	})
+
+	t.Run("skips registered and skipped types and uses the field lifetime", func(t *testing.T) {
+		registry, err := RegisterInstance[*treeRepo](Registry{}, &treeRepo{})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, tree, err := RegisterStructTree[*treeApp](
+			registry,
+			Scoped,
+			WithFieldLifetime(Scoped),
+			SkipTypes(reflect.TypeFor[*treeClock]()))
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterStructTree: %v", err)
+		}
+		expected := []reflect.Type{reflect.TypeFor[*treeApp](), reflect.TypeFor[*treeService]()}
+		if !slices.Equal(tree.Registered, expected) {
+			t.Errorf("expected %v to be registered; got %v", expected, tree.Registered)
+		}
+		for _, info := range registry.Registrations() {
+			if info.Type == reflect.TypeFor[*treeService]() && info.Lifetime != Scoped {
+				t.Errorf("expected the service to be scoped; got %v", info.Lifetime)
+			}
+		}
+	})
+
+	t.Run("leaves the registry unchanged when the root cannot be registered", func(t *testing.T) {
+		registry := Registry{}
+		_, _, err := RegisterStructTree[treeApp](registry, Singleton)
+		if !errors.Is(err, ErrUnsharableType) {
+			t.Fatalf("expected %q; got %q", ErrUnsharableType, err)
+		}
+		if len(registry.Registrations()) != 0 {
+			t.Errorf("expected the registry to be unchanged")
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNilSupply is returned when [Supply] is given a nil value.
+var ErrNilSupply = errors.New("supplied value is nil")
+
+// A NilSupply is an [error] indicating that [Supply] was given a nil value, which has no type to
+// register it under. Calling [errors.Is] with a NilSupply and [ErrNilSupply] returns true.
+type NilSupply struct {
+
+	// Index is the position of the nil value among the values given to Supply.
+	Index int
+}
+
+// Error implements [error].
+func (err NilSupply) Error() string {
+	return fmt.Sprintf("supplied value %d is nil", err.Index)
+}
+
+// Is indicates that a [NilSupply] is [ErrNilSupply].
+func (NilSupply) Is(target error) bool {
+	return target == ErrNilSupply
+}
+
+// Code returns "nil_supply", the code of a [NilSupply] in [ErrorCodes].
+func (NilSupply) Code() string {
+	return "nil_supply"
+}
+
+// ErrDuplicateSupply is returned when [Supply] is given more than one value for the same type.
+var ErrDuplicateSupply = errors.New("more than one value supplied for a type")
+
+// A DuplicateSupply is an [error] indicating that [Supply] was given more than one value to
+// register under the same type. Calling [errors.Is] with a DuplicateSupply and
+// [ErrDuplicateSupply] returns true.
+type DuplicateSupply struct {
+
+	// Type is the type both values would be registered under.
+	Type reflect.Type
+
+	// Index is the position of the second value among the values given to Supply.
+	Index int
+
+	// First is the position of the first value among the values given to Supply.
+	First int
+}
+
+// Error implements [error].
+func (err DuplicateSupply) Error() string {
+	return fmt.Sprintf("supplied values %d and %d are both registered as %v", err.First, err.Index, err.Type)
+}
+
+// Is indicates that a [DuplicateSupply] is [ErrDuplicateSupply].
+func (DuplicateSupply) Is(target error) bool {
+	return target == ErrDuplicateSupply
+}
+
+// Code returns "duplicate_supply", the code of a [DuplicateSupply] in [ErrorCodes].
+func (DuplicateSupply) Code() string {
+	return "duplicate_supply"
+}
+
+// An InvalidSupply is an [error] indicating that a value given to [Supply] cannot be registered,
+// e.g. because it is not assignable to the type given to [As]. Calling [errors.Is] or
+// [errors.As] with an InvalidSupply matches the error from registering the value, such as an
+// [InvalidImplementation] or an [UnsharableType].
+type InvalidSupply struct {
+
+	// Index is the position of the value among the values given to Supply.
+	Index int
+
+	// Err is the error from registering the value.
+	Err error
+}
+
+// Error implements [error].
+func (err InvalidSupply) Error() string {
+	return fmt.Sprintf("supplied value %d: %v", err.Index, err.Err)
+}
+
+// Unwrap returns the error from registering the value.
+func (err InvalidSupply) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "invalid_supply", the code of an [InvalidSupply] in [ErrorCodes].
+func (InvalidSupply) Code() string {
+	return "invalid_supply"
+}
+
+// asType is a value given to Supply that is registered under a type other than its own.
+type asType struct {
+	value any
+	typ   reflect.Type
+}
+
+// As wraps value so that [Supply] registers it under typ, typically an interface it implements,
+// rather than under its dynamic type.
+func As(value any, typ reflect.Type) any {
+	return asType{
+		value: value,
+		typ:   typ,
+	}
+}
+
+// Supply registers each value as the [Singleton] instance of its dynamic type, or of the type
+// given to [As], as [RegisterInstance] would. Like other instances, supplied values are not owned
+// by the provider so they are not closed when it is closed. Supply returns a [NilSupply] for
+// a nil value, a [DuplicateSupply] for two values registered under the same type, and an
+// [InvalidSupply] for a value that cannot be registered; if it returns an error the registry is
+// unchanged.
+func Supply(registry Registry, values ...any) (Registry, error) {
+	targets := make([]reflect.Type, len(values))
+	registrations := make([]registration, len(values))
+	seen := map[reflect.Type]int{}
+	for i, value := range values {
+		target := reflect.TypeOf(value)
+		if as, ok := value.(asType); ok {
+			value, target = as.value, as.typ
+		}
+		impl := reflect.TypeOf(value)
+		if impl == nil || target == nil {
+			return registry, NilSupply{
+				Index: i,
+			}
+		}
+		if first, ok := seen[target]; ok {
+			return registry, DuplicateSupply{
+				Type:  target,
+				Index: i,
+				First: first,
+			}
+		}
+		seen[target] = i
+		if err := validateRegistrationTypes(target, impl); err != nil {
+			return registry, InvalidSupply{
+				Index: i,
+				Err:   err,
+			}
+		}
+		reg := newRegistration(
+			Singleton,
+			impl,
+			func(Resolver) (any, error) {
+				return value, nil
+			},
+			false,
+			nil)
+		reg.source = sourceInstance
+		if err := validateLifetime(impl, Singleton, reg.sharedValue); err != nil {
+			return registry, InvalidSupply{
+				Index: i,
+				Err:   err,
+			}
+		}
+		targets[i] = target
+		registrations[i] = reg
+	}
+	for i, target := range targets {
+		registry = addRegistration(registry, target, registrations[i])
+	}
+	return registry, nil
+}
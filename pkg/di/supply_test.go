@@ -0,0 +1,108 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type suppliedConfig struct {
+	name string
+}
+
+type suppliedGreeter interface {
+	Greet() string
+}
+
+type suppliedCloser struct {
+	closed bool
+}
+
+func (c *suppliedCloser) Greet() string {
+	return "hello"
+}
+
+func (c *suppliedCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSupply(t *testing.T) {
+
+	t.Run("registers values under their dynamic types or the types given to As", func(t *testing.T) {
+		config := &suppliedConfig{name: "config"}
+		greeter := &suppliedCloser{}
+		registry, err := Supply(Registry{}, config, As(greeter, reflect.TypeFor[suppliedGreeter]()))
+		if err != nil {
+			t.Fatalf("unexpected error from Supply: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if resolved, err := Resolve[*suppliedConfig](provider); err != nil || resolved != config {
+			t.Errorf("expected the supplied config; got %v, %v", resolved, err)
+		}
+		if resolved, err := Resolve[suppliedGreeter](provider); err != nil || resolved != greeter {
+			t.Errorf("expected the supplied greeter; got %v, %v", resolved, err)
+		}
+		if _, err := Resolve[*suppliedCloser](provider); !errors.Is(err, ErrUnknownType) {
+			t.Errorf("expected %q; got %q", ErrUnknownType, err)
+		}
+		for _, info := range provider.Registrations() {
+			if info.Lifetime != Singleton || info.Owned {
+				t.Errorf("expected an unowned singleton; got %+v", info)
+			}
+		}
+		if errs := provider.Close(context.Background()); len(errs) > 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		if greeter.closed {
+			t.Errorf("expected the supplied value not to be closed")
+		}
+	})
+
+	t.Run("returns NilSupply for a nil value", func(t *testing.T) {
+		for _, value := range []any{nil, As(nil, reflect.TypeFor[suppliedGreeter]())} {
+			_, err := Supply(Registry{}, &suppliedConfig{}, value)
+			var nilSupply NilSupply
+			if !errors.As(err, &nilSupply) || !errors.Is(err, ErrNilSupply) {
+				t.Fatalf("expected %T; got %v", nilSupply, err)
+			}
+			if nilSupply.Index != 1 {
+				t.Errorf("expected index 1; got %d", nilSupply.Index)
+			}
+		}
+	})
+
+	t.Run("returns DuplicateSupply for two values of the same type", func(t *testing.T) {
+		registry := Registry{}
+		_, err := Supply(registry, &suppliedConfig{}, &suppliedCloser{}, &suppliedConfig{})
+		var duplicate DuplicateSupply
+		if !errors.As(err, &duplicate) || !errors.Is(err, ErrDuplicateSupply) {
+			t.Fatalf("expected %T; got %v", duplicate, err)
+		}
+		if duplicate.First != 0 || duplicate.Index != 2 || duplicate.Type != reflect.TypeFor[*suppliedConfig]() {
+			t.Errorf("unexpected error %+v", duplicate)
+		}
+		if len(registry.Registrations()) != 0 {
+			t.Errorf("expected the registry to be unchanged")
+		}
+	})
+
+	t.Run("returns InvalidSupply for a value that cannot be registered", func(t *testing.T) {
+		_, err := Supply(Registry{}, As(&suppliedConfig{}, reflect.TypeFor[suppliedGreeter]()))
+		var invalid InvalidSupply
+		if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalidImplementation) {
+			t.Fatalf("expected %T wrapping %q; got %v", invalid, ErrInvalidImplementation, err)
+		}
+		_, err = Supply(Registry{}, &suppliedConfig{}, suppliedConfig{})
+		if !errors.As(err, &invalid) || !errors.Is(err, ErrUnsharableType) {
+			t.Fatalf("expected %T wrapping %q; got %v", invalid, ErrUnsharableType, err)
+		}
+		if invalid.Index != 1 {
+			t.Errorf("expected index 1; got %d", invalid.Index)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrNoTargets is returned when an attempt is made to register a [TargetAwareFactory] for no
+// target types.
+var ErrNoTargets = errors.New("factory must be registered for at least one target type")
+
+// A TargetAwareFactory is a function that makes instances of T for the requested target type using
+// a Resolver to initialize dependencies, e.g. a logger named after the type it is for.
+type TargetAwareFactory[T any] func(requested reflect.Type, r Resolver) (T, error)
+
+// RegisterTargetAwareFactory registers factory as the implementation of each of targets, giving it
+// the target being resolved each time it is called. During field injection that is the type of the
+// field being injected. Each target is a registration of its own, so [Scoped] and [Singleton]
+// instances are cached per target, and each is listed by [Registry.Registrations] and the other
+// introspection methods with the shared factory's implementation type and registration site.
+// RegisterTargetAwareFactory returns the same errors as [RegisterFactory] for the first target
+// that cannot be registered, in which case the registry is unchanged, and [ErrNoTargets] if
+// targets is empty.
+func RegisterTargetAwareFactory[Impl any](
+	registry Registry,
+	lifetime Lifetime,
+	factory TargetAwareFactory[Impl],
+	targets ...reflect.Type,
+) (Registry, error) {
+
+	if len(targets) == 0 {
+		return registry, ErrNoTargets
+	}
+
+	if factory == nil {
+		return registry, ErrNilFactory
+	}
+
+	impl := reflect.TypeFor[Impl]()
+	registrations := make([]registration, len(targets))
+	for i, target := range targets {
+		if err := validateRegistrationTypes(target, impl); err != nil {
+			return registry, err
+		}
+		registration_ := newRegistration(
+			lifetime,
+			impl,
+			func(resolver Resolver) (any, error) {
+				return factory(target, resolver)
+			},
+			true,
+			nil)
+		registration_.source = sourceTargetAware
+		if err := validateLifetime(impl, lifetime, registration_.sharedValue || isFunc(impl)); err != nil {
+			return registry, err
+		}
+		registrations[i] = registration_
+	}
+
+	for i, target := range targets {
+		registry = addRegistration(registry, target, registrations[i])
+	}
+	return registry, nil
+}
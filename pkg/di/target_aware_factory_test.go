@@ -0,0 +1,125 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type namedLogger struct {
+	name string
+}
+
+func (logger *namedLogger) Name() string {
+	return logger.name
+}
+
+type ordersLogger interface {
+	Name() string
+}
+
+type paymentsLogger interface {
+	Name() string
+}
+
+type loggedService struct {
+	Logger paymentsLogger
+}
+
+func TestRegisterTargetAwareFactory(t *testing.T) {
+
+	var calls int
+	factory := func(requested reflect.Type, r Resolver) (*namedLogger, error) {
+		calls++
+		return &namedLogger{name: requested.String()}, nil
+	}
+	targets := []reflect.Type{reflect.TypeFor[ordersLogger](), reflect.TypeFor[paymentsLogger]()}
+
+	newProvider := func(t *testing.T, lifetime Lifetime) RootProvider {
+		registry, err := RegisterTargetAwareFactory(Registry{}, lifetime, factory, targets...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterTargetAwareFactory: %v", err)
+		}
+		registry, err = RegisterType[*loggedService, *loggedService](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("gives the factory the requested target", func(t *testing.T) {
+		provider := newProvider(t, Transient)
+		orders, err := Resolve[ordersLogger](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if expected := "di.ordersLogger"; orders.Name() != expected {
+			t.Errorf("expected a logger named %s; got %s", expected, orders.Name())
+		}
+		service, err := Resolve[*loggedService](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if expected := "di.paymentsLogger"; service.Logger.Name() != expected {
+			t.Errorf("expected an injected logger named %s; got %s", expected, service.Logger.Name())
+		}
+	})
+
+	t.Run("caches instances per target", func(t *testing.T) {
+		calls = 0
+		provider := newProvider(t, Singleton)
+		for range 2 {
+			for _, target := range targets {
+				if _, err := provider.Resolve(target); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+		}
+		if calls != 2 {
+			t.Errorf("expected the factory to be called once per target; got %d calls", calls)
+		}
+		orders, _ := Resolve[ordersLogger](provider)
+		payments, _ := Resolve[paymentsLogger](provider)
+		if orders == payments {
+			t.Errorf("expected a distinct instance for each target")
+		}
+	})
+
+	t.Run("lists each target with the shared factory", func(t *testing.T) {
+		graph := newProvider(t, Scoped).graph()
+		for _, target := range targets {
+			found := false
+			for _, node := range graph.Nodes {
+				if node.Type == target.String() {
+					found = true
+					if node.Factory != "target-aware" || node.Impl != qualifiedTypeName(reflect.TypeFor[*namedLogger]()) {
+						t.Errorf("unexpected node %+v", node)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("expected a node for %v", target)
+			}
+		}
+	})
+
+	t.Run("returns InvalidImplementation when Impl cannot be assigned to a target", func(t *testing.T) {
+		registry, err := RegisterTargetAwareFactory(Registry{}, Transient, factory, targets[0], reflect.TypeFor[*loggedService]())
+		if !errors.Is(err, ErrInvalidImplementation) {
+			t.Fatalf("expected %q; got %q", ErrInvalidImplementation, err)
+		}
+		if len(registry.Registrations()) != 0 {
+			t.Errorf("expected the registry to be unchanged")
+		}
+	})
+
+	t.Run("returns ErrNoTargets without targets", func(t *testing.T) {
+		if _, err := RegisterTargetAwareFactory(Registry{}, Transient, factory); !errors.Is(err, ErrNoTargets) {
+			t.Fatalf("expected %q; got %q", ErrNoTargets, err)
+		}
+	})
+}
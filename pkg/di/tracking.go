@@ -0,0 +1,304 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithScopeTracking makes the provider keep a record of the scopes created from it that have not
+// been closed yet for [RootProvider.OpenScopes], e.g. to see how many requests are in flight and
+// how long they have been running. Without it scopes are not tracked at all.
+func WithScopeTracking() ProviderOption {
+	return func(options *providerOptions) {
+		options.scopeTracking = true
+	}
+}
+
+// WithResolutionCounts makes the provider count the number of times each type is resolved from it
+// and the scopes created from it for [RootProvider.ResolutionCounts]. Resolutions made by
+// factories for their dependencies are counted too. Without it resolutions are not counted at
+// all.
+func WithResolutionCounts() ProviderOption {
+	return func(options *providerOptions) {
+		options.resolutionCounts = true
+	}
+}
+
+// WithTransientTracking makes the provider record the types of the [Transient] values resolved
+// from it and the scopes created from it for [RootProvider.InstantiatedTransients] and
+// [Scope.InstantiatedTransients]. Transient values are not cached, so without it there is no
+// record of them at all.
+func WithTransientTracking() ProviderOption {
+	return func(options *providerOptions) {
+		options.transientTracking = true
+	}
+}
+
+// An OpenScope describes a [Scope] that has not been closed; see [WithScopeTracking].
+type OpenScope struct {
+
+	// ID is the identifier of the scope.
+	ID string
+
+	// Name is the name of the scope, which is empty for unnamed scopes.
+	Name string
+
+	// Parent is the identifier of the scope the scope was created from, if any.
+	Parent string
+
+	// Created is the time the scope was created.
+	Created time.Time
+}
+
+// OpenScopes returns the scopes created from the provider that have not been closed, ordered by
+// creation time. It reports false if the provider was not built with [WithScopeTracking]. Scopes
+// that are garbage collected without being closed remain open unless the provider was also built
+// with [WithLeakDetection].
+func (provider RootProvider) OpenScopes() ([]OpenScope, bool) {
+	if !provider.initialized() || !provider.options.scopeTracking {
+		return nil, false
+	}
+	return provider.openScopes.list(), true
+}
+
+// A ResolutionCount is the number of times a type has been resolved; see [WithResolutionCounts].
+type ResolutionCount struct {
+
+	// Type is the requested type.
+	Type reflect.Type
+
+	// Count is the number of times Type has been resolved.
+	Count uint64
+}
+
+// ResolutionCounts returns the number of times each type has been resolved from the provider and
+// the scopes created from it, ordered by type name. Every resolution is counted, including those
+// that fail. It reports false if the provider was not built with [WithResolutionCounts].
+func (provider RootProvider) ResolutionCounts() ([]ResolutionCount, bool) {
+	if !provider.initialized() || !provider.options.resolutionCounts {
+		return nil, false
+	}
+	return provider.counts.list(), true
+}
+
+// InstantiatedSingletons returns the types of the [Singleton] values the provider has
+// constructed, including those it inherits from its parent, ordered by type name. It does not
+// resolve anything.
+func (provider RootProvider) InstantiatedSingletons() []reflect.Type {
+	if !provider.initialized() {
+		return nil
+	}
+	registrations := provider.registrations.load()
+	types := []reflect.Type{}
+	for _, typ := range sortedTypes(registrations) {
+		if registrations[typ].lifetime == Singleton && provider.singletonInstantiated(typ) {
+			types = append(types, typ)
+		}
+	}
+	return types
+}
+
+// InstantiatedTransients returns the types of the [Transient] values that have been resolved from
+// the provider, as opposed to its scopes, ordered by type name. It reports false if the provider
+// was not built with [WithTransientTracking].
+func (provider RootProvider) InstantiatedTransients() ([]reflect.Type, bool) {
+	if !provider.initialized() || !provider.options.transientTracking {
+		return nil, false
+	}
+	return provider.transients.list(), true
+}
+
+// InstantiatedTypes returns the types of the values currently cached by the scope, ordered by type
+// name. It includes neither the values the scope inherits from its parent nor the [Singleton] and
+// [Transient] values resolved from it; see [RootProvider.InstantiatedSingletons] and
+// [Scope.InstantiatedTransients]. The result is a snapshot which later resolutions do not change.
+// It does not resolve anything, and it returns nil for a pooled scope whose state has been
+// recycled.
+func (scope Scope) InstantiatedTypes() []reflect.Type {
+	if !scope.initialized() || scope.acquireState() != nil {
+		return nil
+	}
+	defer scope.releaseState()
+	types := []reflect.Type{}
+	for _, entry := range scope.state.scopedValues.entries() {
+		types = append(types, entry.typ)
+	}
+	slices.SortFunc(types, compareTypes)
+	return types
+}
+
+// InstantiatedTransients returns the types of the [Transient] values that have been resolved from
+// the scope, ordered by type name. It reports false if the provider was not built with
+// [WithTransientTracking].
+func (scope Scope) InstantiatedTransients() ([]reflect.Type, bool) {
+	if !scope.initialized() || !scope.root.options.transientTracking {
+		return nil, false
+	}
+	if scope.acquireState() != nil {
+		return nil, true
+	}
+	defer scope.releaseState()
+	return scope.state.transients.list(), true
+}
+
+func (provider RootProvider) singletonInstantiated(typ reflect.Type) bool {
+	_, ok := provider.Peek(typ)
+	return ok
+}
+
+// openScopes records the scopes that have not been closed when scope tracking is enabled.
+type openScopes struct {
+	mu     sync.Mutex
+	scopes map[string]openScope
+}
+
+// openScope is a scope that has not been closed. The scope is a copy without the leak tracker of
+// the original, so keeping it does not keep the original from being reported as leaked.
+type openScope struct {
+	info  OpenScope
+	scope Scope
+}
+
+// track records scope as open if scope tracking is enabled.
+func (provider RootProvider) track(scope Scope) {
+	if !provider.options.scopeTracking {
+		return
+	}
+	provider.openScopes.mu.Lock()
+	defer provider.openScopes.mu.Unlock()
+	if provider.openScopes.scopes == nil {
+		provider.openScopes.scopes = make(map[string]openScope)
+	}
+	tracked := scope
+	tracked.leak = nil
+	provider.openScopes.scopes[scope.id] = openScope{
+		info: OpenScope{
+			ID:      scope.id,
+			Name:    scope.name,
+			Parent:  scope.parent,
+			Created: time.Now(),
+		},
+		scope: tracked,
+	}
+}
+
+// untrack records that the scope with the given ID is no longer open.
+func (provider RootProvider) untrack(id string) {
+	if !provider.options.scopeTracking {
+		return
+	}
+	provider.openScopes.mu.Lock()
+	defer provider.openScopes.mu.Unlock()
+	delete(provider.openScopes.scopes, id)
+}
+
+func (o *openScopes) list() []OpenScope {
+	sorted := o.sorted()
+	scopes := make([]OpenScope, 0, len(sorted))
+	for _, open := range sorted {
+		scopes = append(scopes, open.info)
+	}
+	return scopes
+}
+
+// closeAll closes the scopes that are open, starting with the most recently created so that
+// scopes are closed before the scopes they were created from, and returns the errors.
+func (o *openScopes) closeAll(ctx context.Context) []error {
+	sorted := o.sorted()
+	var errs []error
+	for _, open := range slices.Backward(sorted) {
+		errs = append(errs, open.scope.Close(ctx)...)
+	}
+	return errs
+}
+
+// sorted returns the open scopes ordered by creation time.
+func (o *openScopes) sorted() []openScope {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	scopes := make([]openScope, 0, len(o.scopes))
+	for _, scope := range o.scopes {
+		scopes = append(scopes, scope)
+	}
+	slices.SortFunc(scopes, func(a, b openScope) int {
+		if c := a.info.Created.Compare(b.info.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.info.ID, b.info.ID)
+	})
+	return scopes
+}
+
+// trackTransient records typ in transients if it is a Transient type and transient tracking is
+// enabled.
+func (provider RootProvider) trackTransient(transients *typeSet, typ reflect.Type, registration registration) {
+	if provider.options.transientTracking && registration.lifetime == Transient {
+		transients.add(typ)
+	}
+}
+
+// typeSet is a set of types that is safe for concurrent use. Its zero value is ready to use.
+type typeSet struct {
+	types sync.Map
+}
+
+func (s *typeSet) add(typ reflect.Type) {
+	s.types.Store(typ, struct{}{})
+}
+
+// list returns the types in the set ordered by type name.
+func (s *typeSet) list() []reflect.Type {
+	types := []reflect.Type{}
+	s.types.Range(func(typ, _ any) bool {
+		types = append(types, typ.(reflect.Type))
+		return true
+	})
+	slices.SortFunc(types, compareTypes)
+	return types
+}
+
+func (s *typeSet) reset() {
+	s.types.Clear()
+}
+
+// compareTypes orders types by name.
+func compareTypes(a reflect.Type, b reflect.Type) int {
+	return strings.Compare(a.String(), b.String())
+}
+
+// resolutionCounts counts the resolutions of each type when resolution counting is enabled.
+type resolutionCounts struct {
+	counts sync.Map
+}
+
+// countResolution counts a resolution of typ if resolution counting is enabled.
+func (provider RootProvider) countResolution(typ reflect.Type) {
+	if !provider.options.resolutionCounts {
+		return
+	}
+	count, ok := provider.counts.counts.Load(typ)
+	if !ok {
+		count, _ = provider.counts.counts.LoadOrStore(typ, &atomic.Uint64{})
+	}
+	count.(*atomic.Uint64).Add(1)
+}
+
+func (r *resolutionCounts) list() []ResolutionCount {
+	counts := []ResolutionCount{}
+	r.counts.Range(func(typ, count any) bool {
+		counts = append(counts, ResolutionCount{
+			Type:  typ.(reflect.Type),
+			Count: count.(*atomic.Uint64).Load(),
+		})
+		return true
+	})
+	slices.SortFunc(counts, func(a, b ResolutionCount) int {
+		return strings.Compare(a.Type.String(), b.Type.String())
+	})
+	return counts
+}
@@ -0,0 +1,242 @@
+package di
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestTracking(t *testing.T) {
+
+	newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*errorContextCloser, *errorContextCloser](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("OpenScopes", func(t *testing.T) {
+
+		t.Run("returns the scopes that have not been closed", func(t *testing.T) {
+			provider := newProvider(t, WithScopeTracking())
+			first := provider.NewScope()
+			second := first.NewNamedScope("request")
+			provider.NewScope().Close(context.Background())
+			scopes, ok := provider.OpenScopes()
+			if !ok {
+				t.Fatalf("expected scope tracking to be enabled")
+			}
+			if len(scopes) != 2 || scopes[0].ID != first.ID() || scopes[1].ID != second.ID() {
+				t.Fatalf("expected scopes %s and %s to be open; got %v", first.ID(), second.ID(), scopes)
+			}
+			if scopes[1].Name != "request" || scopes[1].Parent != first.ID() {
+				t.Fatalf("unexpected scope %+v", scopes[1])
+			}
+		})
+
+		t.Run("reports false unless enabled", func(t *testing.T) {
+			provider := newProvider(t)
+			provider.NewScope()
+			if scopes, ok := provider.OpenScopes(); ok || scopes != nil {
+				t.Fatalf("expected no open scopes; got %v, %v", scopes, ok)
+			}
+		})
+	})
+
+	t.Run("ResolutionCounts", func(t *testing.T) {
+
+		t.Run("counts the resolutions of each type", func(t *testing.T) {
+			provider := newProvider(t, WithResolutionCounts())
+			scope := provider.NewScope()
+			for _, resolver := range []Resolver{provider, scope, scope} {
+				if _, err := Resolve[*mockCloser](resolver); err != nil {
+					t.Fatalf("unexpected error from Resolve: %v", err)
+				}
+			}
+			if _, err := Resolve[string](scope); err == nil {
+				t.Fatalf("expected an error resolving an unregistered type")
+			}
+			counts, ok := provider.ResolutionCounts()
+			if !ok {
+				t.Fatalf("expected resolution counting to be enabled")
+			}
+			expected := []ResolutionCount{
+				{Type: reflect.TypeFor[*mockCloser](), Count: 3},
+				{Type: reflect.TypeFor[string](), Count: 1},
+			}
+			if !reflect.DeepEqual(counts, expected) {
+				t.Fatalf("expected %v; got %v", expected, counts)
+			}
+		})
+
+		t.Run("reports false unless enabled", func(t *testing.T) {
+			provider := newProvider(t)
+			if _, err := Resolve[*mockCloser](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if counts, ok := provider.ResolutionCounts(); ok || counts != nil {
+				t.Fatalf("expected no counts; got %v, %v", counts, ok)
+			}
+		})
+	})
+
+	t.Run("InstantiatedSingletons", func(t *testing.T) {
+
+		t.Run("returns the singletons that have been constructed", func(t *testing.T) {
+			provider := newProvider(t)
+			if types := provider.InstantiatedSingletons(); len(types) != 0 {
+				t.Fatalf("expected no singletons to be instantiated; got %v", types)
+			}
+			if _, err := Resolve[*mockCloser](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			expected := []reflect.Type{reflect.TypeFor[*mockCloser]()}
+			if types := provider.InstantiatedSingletons(); !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected %v; got %v", expected, types)
+			}
+		})
+
+		t.Run("includes the singletons a child provider inherits", func(t *testing.T) {
+			parent := newProvider(t)
+			child, err := parent.NewChildProvider(Registry{})
+			if err != nil {
+				t.Fatalf("unexpected error from NewChildProvider: %v", err)
+			}
+			if _, err := Resolve[*mockCloser](parent); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			expected := []reflect.Type{reflect.TypeFor[*mockCloser]()}
+			if types := child.InstantiatedSingletons(); !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected %v; got %v", expected, types)
+			}
+		})
+	})
+	t.Run("InstantiatedTypes", func(t *testing.T) {
+
+		t.Run("returns a snapshot of the types cached by the scope", func(t *testing.T) {
+			scope := newProvider(t).NewScope()
+			if types := scope.InstantiatedTypes(); len(types) != 0 {
+				t.Fatalf("expected no types to be instantiated; got %v", types)
+			}
+			if _, err := Resolve[*errorContextCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			types := scope.InstantiatedTypes()
+			expected := []reflect.Type{reflect.TypeFor[*errorContextCloser]()}
+			if !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected %v; got %v", expected, types)
+			}
+			if err := scope.Evict(reflect.TypeFor[*errorContextCloser]()); err != nil {
+				t.Fatalf("unexpected error from Evict: %v", err)
+			}
+			if !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected the snapshot to be unchanged; got %v", types)
+			}
+			if types := scope.InstantiatedTypes(); len(types) != 0 {
+				t.Fatalf("expected no types after evicting; got %v", types)
+			}
+		})
+
+		t.Run("excludes singletons and the values of the parent", func(t *testing.T) {
+			parent := newProvider(t).NewScope()
+			if _, err := Resolve[*errorContextCloser](parent); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			child := parent.NewChildScope()
+			if _, err := Resolve[*mockCloser](child); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if types := child.InstantiatedTypes(); len(types) != 0 {
+				t.Fatalf("expected no types to be instantiated; got %v", types)
+			}
+		})
+	})
+
+	t.Run("InstantiatedTransients", func(t *testing.T) {
+
+		newProvider := func(t *testing.T, opts ...ProviderOption) RootProvider {
+			registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			registry, err = RegisterType[*tracked, *tracked](registry, Transient)
+			if err != nil {
+				t.Fatalf("unexpected error from RegisterType: %v", err)
+			}
+			provider, err := registry.BuildRootProvider(opts...)
+			if err != nil {
+				t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+			}
+			return provider
+		}
+
+		t.Run("records the transient types resolved from the provider and each scope", func(t *testing.T) {
+			provider := newProvider(t, WithTransientTracking())
+			resolved := provider.NewScope()
+			untouched := provider.NewScope()
+			if _, err := Resolve[*tracked](resolved); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if _, err := Resolve[*mockCloser](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			expected := []reflect.Type{reflect.TypeFor[*tracked]()}
+			if types, ok := resolved.InstantiatedTransients(); !ok || !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected %v; got %v, %v", expected, types, ok)
+			}
+			if types, ok := untouched.InstantiatedTransients(); !ok || len(types) != 0 {
+				t.Fatalf("expected no transients; got %v, %v", types, ok)
+			}
+			if types, ok := provider.InstantiatedTransients(); !ok || len(types) != 0 {
+				t.Fatalf("expected no transients; got %v, %v", types, ok)
+			}
+			if _, err := Resolve[*tracked](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if types, ok := provider.InstantiatedTransients(); !ok || !reflect.DeepEqual(types, expected) {
+				t.Fatalf("expected %v; got %v, %v", expected, types, ok)
+			}
+		})
+
+		t.Run("reports false unless enabled", func(t *testing.T) {
+			provider := newProvider(t)
+			scope := provider.NewScope()
+			if _, err := Resolve[*tracked](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if types, ok := scope.InstantiatedTransients(); ok || types != nil {
+				t.Fatalf("expected no transients; got %v, %v", types, ok)
+			}
+			if types, ok := provider.InstantiatedTransients(); ok || types != nil {
+				t.Fatalf("expected no transients; got %v, %v", types, ok)
+			}
+		})
+
+		t.Run("is safe under concurrent resolution", func(t *testing.T) {
+			scope := newProvider(t, WithTransientTracking()).NewScope()
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _ = Resolve[*tracked](scope)
+					scope.InstantiatedTransients()
+					scope.InstantiatedTypes()
+				}()
+			}
+			wg.Wait()
+		})
+	})
+}
+
+type tracked struct{}
@@ -0,0 +1,195 @@
+package di
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithTTL makes a [Singleton] or [Scoped] registration expire its instance once the instance is
+// older than ttl. Expiry is checked lazily: the first resolution after the instance expires
+// constructs a new instance and replaces the old one, while concurrent resolutions keep getting
+// the old instance until the replacement is in place. If constructing the new instance fails the
+// old instance is returned and the next resolution tries again.
+//
+// An expired instance the provider owns is closed in the background, after the grace period given
+// by [WithExpiryGracePeriod] if any, and the result is reported to the provider's [Observer] as a
+// [DisplacedSingletonClosed]. WithTTL has no effect on registrations with other lifetimes.
+func WithTTL(ttl time.Duration) RegistrationOption {
+	return func(r *registration) {
+		r.ttl = ttl
+	}
+}
+
+// WithExpiryGracePeriod delays closing an instance that expired because of [WithTTL] to give code
+// that resolved it before it expired time to finish using it.
+func WithExpiryGracePeriod(grace time.Duration) RegistrationOption {
+	return func(r *registration) {
+		r.expiryGrace = grace
+	}
+}
+
+// WithBackgroundRefresh makes a registration that uses [WithTTL] replace its instance in the
+// background shortly before it expires, once 80% of the TTL has passed, so that resolutions do not
+// wait for the replacement to be constructed. The background replacement stops when the
+// [RootProvider] or [Scope] holding the instance is closed.
+func WithBackgroundRefresh() RegistrationOption {
+	return func(r *registration) {
+		r.backgroundRefresh = true
+	}
+}
+
+// expiryState tracks when the instances of registrations using WithTTL were created, which of them
+// are being replaced, and the timers for replacing them in the background.
+type expiryState struct {
+	mu         sync.Mutex
+	created    map[reflect.Type]time.Time
+	rebuilding map[reflect.Type]bool
+	timers     map[reflect.Type]*time.Timer
+	stopped    bool
+
+	// inflight tracks the replacements in progress so that stop can wait for them.
+	inflight sync.WaitGroup
+}
+
+// age returns the age of the instance of typ, starting the clock if it has not been started.
+func (s *expiryState) age(typ reflect.Type) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created, ok := s.created[typ]
+	if !ok {
+		if s.created == nil {
+			s.created = make(map[reflect.Type]time.Time)
+		}
+		s.created[typ] = time.Now()
+		return 0
+	}
+	return time.Since(created)
+}
+
+// forget discards the creation time of the instance of typ because it was replaced or removed.
+func (s *expiryState) forget(typ reflect.Type) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.created, typ)
+}
+
+// beginRebuild reports whether the caller may replace the instance of typ, which is false if
+// another caller is already replacing it or if the owner of the instance is closing.
+func (s *expiryState) beginRebuild(typ reflect.Type) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped || s.rebuilding[typ] {
+		return false
+	}
+	s.inflight.Add(1)
+	if s.rebuilding == nil {
+		s.rebuilding = make(map[reflect.Type]bool)
+	}
+	s.rebuilding[typ] = true
+	return true
+}
+
+func (s *expiryState) endRebuild(typ reflect.Type) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rebuilding, typ)
+	s.inflight.Done()
+}
+
+// schedule calls fn after delay unless a call is already scheduled for typ or the timers have been
+// stopped.
+func (s *expiryState) schedule(typ reflect.Type, delay time.Duration, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped || s.timers[typ] != nil {
+		return
+	}
+	if s.timers == nil {
+		s.timers = make(map[reflect.Type]*time.Timer)
+	}
+	s.timers[typ] = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		delete(s.timers, typ)
+		s.mu.Unlock()
+		fn()
+	})
+}
+
+// stop stops the scheduled calls, prevents new calls and replacements, and waits for the
+// replacements in progress so that the instances can be closed without racing them.
+func (s *expiryState) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	for typ, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, typ)
+	}
+	s.mu.Unlock()
+	s.inflight.Wait()
+}
+
+// reset stops the scheduled calls and discards the state so it can be reused.
+func (s *expiryState) reset() {
+	s.stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.created)
+	clear(s.rebuilding)
+	s.stopped = false
+}
+
+// expire returns value, which was resolved from m for entry, unless it has outlived the TTL of
+// entry in which case it replaces the instance in m with a new one and returns that. Replaced
+// instances that the provider owns are closed in the background.
+func (provider RootProvider) expire(
+	m *instanceMap,
+	entry Registration,
+	resolver Resolver,
+	value any,
+	err error,
+) (any, error) {
+	ttl := entry.registration.ttl
+	if err != nil || ttl <= 0 {
+		return value, err
+	}
+	age := m.expiry.age(entry.Type)
+	if entry.registration.backgroundRefresh {
+		provider.scheduleRefresh(m, entry, resolver, max(ttl*4/5-age, 0))
+	}
+	if age < ttl {
+		return value, nil
+	}
+	if fresh, ok := provider.rebuild(m, entry, resolver); ok {
+		return fresh, nil
+	}
+	return value, nil
+}
+
+// rebuild replaces the instance of entry in m unless another caller is already doing so.
+func (provider RootProvider) rebuild(m *instanceMap, entry Registration, resolver Resolver) (any, bool) {
+	if !m.expiry.beginRebuild(entry.Type) {
+		return nil, false
+	}
+	defer m.expiry.endRebuild(entry.Type)
+	fresh, err := entry.New(resolver)
+	if err != nil {
+		return nil, false
+	}
+	old, replaced, _ := m.replace(entry.Type, fresh, true)
+	m.expiry.age(entry.Type)
+	if replaced && entry.registration.owned {
+		go provider.closeDisplaced(entry.Type, old, entry.registration.expiryGrace)
+	}
+	return fresh, true
+}
+
+// scheduleRefresh schedules the instance of entry in m to be replaced after delay, and the
+// replacement to be replaced in turn.
+func (provider RootProvider) scheduleRefresh(m *instanceMap, entry Registration, resolver Resolver, delay time.Duration) {
+	m.expiry.schedule(entry.Type, delay, func() {
+		if _, ok := provider.rebuild(m, entry, resolver); ok {
+			provider.scheduleRefresh(m, entry, resolver, entry.registration.ttl*4/5)
+		}
+	})
+}
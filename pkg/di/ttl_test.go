@@ -0,0 +1,121 @@
+package di
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+
+	build := func(t *testing.T, lifetime Lifetime, constructions *atomic.Int32, opts ...RegistrationOption) (RootProvider, chan Event) {
+		events := make(chan Event, 16)
+		registry, err := RegisterFactory[*mockCloser](Registry{}, lifetime, func(Resolver) (*mockCloser, error) {
+			constructions.Add(1)
+			time.Sleep(time.Millisecond)
+			return &mockCloser{}, nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(WithObserver(ObserverFunc(func(event Event) {
+			events <- event
+		})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider, events
+	}
+
+	t.Run("replaces expired singletons and closes them", func(t *testing.T) {
+		provider, events := build(t, Singleton, &atomic.Int32{}, WithTTL(20*time.Millisecond))
+		old, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if value, err := Resolve[*mockCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		} else if value != old {
+			t.Fatalf("expected the instance to be reused before it expires")
+		}
+		time.Sleep(30 * time.Millisecond)
+		fresh, err := Resolve[*mockCloser](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if fresh == old {
+			t.Fatalf("expected the expired instance to be replaced")
+		}
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the expired instance to be closed")
+		}
+		if !old.closed {
+			t.Fatalf("expected the expired instance to be closed")
+		}
+	})
+
+	t.Run("replaces expired scoped values", func(t *testing.T) {
+		provider, _ := build(t, Scoped, &atomic.Int32{}, WithTTL(20*time.Millisecond))
+		scope := provider.NewScope()
+		old, err := Resolve[*mockCloser](scope)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if fresh, err := Resolve[*mockCloser](scope); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		} else if fresh == old {
+			t.Fatalf("expected the expired instance to be replaced")
+		}
+	})
+
+	t.Run("rebuilds once when resolved concurrently", func(t *testing.T) {
+		constructions := &atomic.Int32{}
+		provider, _ := build(t, Singleton, constructions, WithTTL(20*time.Millisecond))
+		if _, err := Resolve[*mockCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		var wg sync.WaitGroup
+		for range 16 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := Resolve[*mockCloser](provider); err != nil {
+					t.Errorf("unexpected error from Resolve: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := constructions.Load(); n != 2 {
+			t.Fatalf("expected 2 constructions; got %d", n)
+		}
+	})
+
+	t.Run("WithBackgroundRefresh replaces instances before they expire", func(t *testing.T) {
+		constructions := &atomic.Int32{}
+		provider, _ := build(t, Singleton, constructions, WithTTL(50*time.Millisecond), WithBackgroundRefresh())
+		if _, err := Resolve[*mockCloser](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for constructions.Load() < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for the background refresh")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if errs := provider.Close(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from Close: %v", errs)
+		}
+		closed := constructions.Load()
+		time.Sleep(100 * time.Millisecond)
+		if n := constructions.Load(); n != closed {
+			t.Fatalf("expected background refreshes to stop after Close; got %d more", n-closed)
+		}
+	})
+}
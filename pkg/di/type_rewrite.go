@@ -0,0 +1,216 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// WithTypeRewrite makes the provider resolve to whenever from is requested, including for the
+// fields of structs built by default factories, e.g. so that code asking for an interface that is
+// being replaced receives an adapter for its successor during a migration. Rewrites apply before
+// the registrations are consulted, so a registration of from is ignored, but after the overrides
+// of a [Scope]. A rewritten type is rewritten again if there is a rewrite for it. Each rewrite is
+// reported to the provider's [Observer] as a [TypeRewritten].
+//
+// WithTypeRewrite can be given more than once to rewrite more types; giving two rewrites for the
+// same type is a conflict. [Registry.BuildRootProvider] returns an [InvalidTypeRewrite] unless to
+// is registered and assignable to from, and a [TypeRewriteCycle] if the rewrites form a cycle.
+func WithTypeRewrite(from reflect.Type, to reflect.Type) ProviderOption {
+	return func(options *providerOptions) {
+		if options.rewrites == nil {
+			options.rewrites = map[reflect.Type]reflect.Type{}
+		}
+		if existing, ok := options.rewrites[from]; ok && existing != to {
+			options.conflict(
+				fmt.Sprintf("given rewrites of %v to %v and %v", from, existing, to),
+				"WithTypeRewrite")
+		}
+		options.rewrites[from] = to
+	}
+}
+
+// WithTypeRewriteFunc is [WithTypeRewrite] for rewrites that are computed: rewrite is called with
+// each requested type that no rewrite given to WithTypeRewrite applies to and returns the type to
+// resolve instead, or nil or the requested type itself to resolve it as usual. Computed rewrites
+// cannot be validated when the provider is built, so a rewrite to a type that is not assignable
+// to the requested type fails the resolution with an [InvalidTypeRewrite]. WithTypeRewriteFunc
+// can be given more than once; the functions are called in order until one rewrites the type.
+func WithTypeRewriteFunc(rewrite func(reflect.Type) reflect.Type) ProviderOption {
+	return func(options *providerOptions) {
+		if rewrite != nil {
+			options.rewriteFuncs = append(options.rewriteFuncs, rewrite)
+		}
+	}
+}
+
+// A TypeRewritten is an [Event] indicating that a requested type was resolved as another type
+// because of [WithTypeRewrite] or [WithTypeRewriteFunc].
+type TypeRewritten struct {
+
+	// From is the requested type.
+	From reflect.Type
+
+	// To is the type that was resolved instead.
+	To reflect.Type
+
+	// Path holds the types being constructed when From was requested, starting with the type
+	// requested from the provider, if the provider was built [WithProvenance]. Otherwise it holds
+	// only the type whose factory requested From. Path is empty when From was requested directly.
+	Path []reflect.Type
+}
+
+func (TypeRewritten) event() {}
+
+// ErrInvalidTypeRewrite is returned when a type is rewritten to a type that cannot replace it.
+var ErrInvalidTypeRewrite = errors.New("invalid type rewrite")
+
+// An InvalidTypeRewrite is an [error] indicating that a rewrite given to [WithTypeRewrite] or
+// [WithTypeRewriteFunc] rewrites a type to one that is not assignable to it, or, for
+// WithTypeRewrite, that is not registered. Calling [errors.Is] with an InvalidTypeRewrite and
+// [ErrInvalidTypeRewrite] returns true.
+type InvalidTypeRewrite struct {
+
+	// From is the rewritten type.
+	From reflect.Type
+
+	// To is the type From is rewritten to.
+	To reflect.Type
+
+	// Reason describes why the rewrite is invalid.
+	Reason string
+}
+
+// Error implements [error].
+func (err InvalidTypeRewrite) Error() string {
+	return fmt.Sprintf("cannot rewrite %v to %v: %s", err.From, err.To, err.Reason)
+}
+
+// Is indicates that an [InvalidTypeRewrite] is [ErrInvalidTypeRewrite].
+func (InvalidTypeRewrite) Is(target error) bool {
+	return target == ErrInvalidTypeRewrite
+}
+
+// Code returns "invalid_type_rewrite", the code of an [InvalidTypeRewrite] in [ErrorCodes].
+func (InvalidTypeRewrite) Code() string {
+	return "invalid_type_rewrite"
+}
+
+// ErrTypeRewriteCycle is returned when type rewrites form a cycle.
+var ErrTypeRewriteCycle = errors.New("type rewrites form a cycle")
+
+// A TypeRewriteCycle is an [error] indicating that the rewrites given to [WithTypeRewrite] and
+// [WithTypeRewriteFunc] rewrite a type back to itself. Calling [errors.Is] with a
+// TypeRewriteCycle and [ErrTypeRewriteCycle] returns true.
+type TypeRewriteCycle struct {
+
+	// Types are the types in the cycle, starting and ending with the same type.
+	Types []reflect.Type
+}
+
+// Error implements [error].
+func (err TypeRewriteCycle) Error() string {
+	return fmt.Sprintf("type rewrites form a cycle: %v", err.Types)
+}
+
+// Is indicates that a [TypeRewriteCycle] is [ErrTypeRewriteCycle].
+func (TypeRewriteCycle) Is(target error) bool {
+	return target == ErrTypeRewriteCycle
+}
+
+// Code returns "type_rewrite_cycle", the code of a [TypeRewriteCycle] in [ErrorCodes].
+func (TypeRewriteCycle) Code() string {
+	return "type_rewrite_cycle"
+}
+
+// validateRewrites checks that the rewrites given to WithTypeRewrite replace their types with
+// registered types that are assignable to them and do not form cycles.
+func validateRewrites(rewrites map[reflect.Type]reflect.Type, registrations map[reflect.Type]registration) error {
+	for from, to := range rewrites {
+		if from == nil || to == nil {
+			return InvalidTypeRewrite{
+				From:   from,
+				To:     to,
+				Reason: "types cannot be nil",
+			}
+		}
+	}
+	for _, from := range slices.SortedFunc(maps.Keys(rewrites), compareTypes) {
+		to := rewrites[from]
+		if !to.AssignableTo(from) {
+			return InvalidTypeRewrite{
+				From:   from,
+				To:     to,
+				Reason: fmt.Sprintf("%v is not assignable to %v", to, from),
+			}
+		}
+		if _, ok := registrations[to]; !ok {
+			return InvalidTypeRewrite{
+				From:   from,
+				To:     to,
+				Reason: fmt.Sprintf("%v is not registered", to),
+			}
+		}
+		chain := []reflect.Type{from}
+		for next, ok := to, true; ok; next, ok = rewrites[next] {
+			if i := slices.Index(chain, next); i >= 0 {
+				return TypeRewriteCycle{
+					Types: append(chain[i:], next),
+				}
+			}
+			chain = append(chain, next)
+		}
+	}
+	return nil
+}
+
+// rewrite returns the type to resolve when typ is requested, reporting each rewrite to the
+// provider's observer.
+func (provider RootProvider) rewrite(typ reflect.Type, constructing reflect.Type, path *resolutionPath) (reflect.Type, error) {
+	options := provider.options
+	if len(options.rewrites) == 0 && len(options.rewriteFuncs) == 0 {
+		return typ, nil
+	}
+	chain := []reflect.Type{typ}
+	for {
+		to, ok := options.rewrites[typ]
+		if !ok {
+			to = nil
+			for _, rewrite := range options.rewriteFuncs {
+				if to = rewrite(typ); to != nil && to != typ {
+					break
+				}
+			}
+			if to == nil || to == typ {
+				return typ, nil
+			}
+			if !to.AssignableTo(typ) {
+				return nil, InvalidTypeRewrite{
+					From:   typ,
+					To:     to,
+					Reason: fmt.Sprintf("%v is not assignable to %v", to, typ),
+				}
+			}
+		}
+		if i := slices.Index(chain, to); i >= 0 {
+			return nil, TypeRewriteCycle{
+				Types: append(chain[i:], to),
+			}
+		}
+		chain = append(chain, to)
+		if options.observer != nil {
+			event := TypeRewritten{
+				From: typ,
+				To:   to,
+				Path: path.types(),
+			}
+			if path == nil && constructing != nil {
+				event.Path = []reflect.Type{constructing}
+			}
+			provider.observe(event)
+		}
+		typ = to
+	}
+}
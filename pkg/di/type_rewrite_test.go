@@ -0,0 +1,202 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type oldUserStore interface {
+	User(id int) string
+}
+
+type newUserStore interface {
+	oldUserStore
+	Users() []string
+}
+
+type userStoreAdapter struct{}
+
+func (userStoreAdapter) User(int) string {
+	return "adapted"
+}
+
+func (userStoreAdapter) Users() []string {
+	return nil
+}
+
+type legacyUserStore struct{}
+
+func (legacyUserStore) User(int) string {
+	return "legacy"
+}
+
+// userStoreAlias has the same methods as oldUserStore so each is assignable to the other.
+type userStoreAlias interface {
+	User(id int) string
+}
+
+type userHandler struct {
+	Store oldUserStore
+}
+
+func TestWithTypeRewrite(t *testing.T) {
+
+	oldType := reflect.TypeFor[oldUserStore]()
+	newType := reflect.TypeFor[newUserStore]()
+
+	newRegistry := func(t *testing.T) Registry {
+		registry, err := RegisterType[oldUserStore, legacyUserStore](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[newUserStore, userStoreAdapter](registry, Singleton, AllowSharedValue())
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*userHandler, *userHandler](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		return registry
+	}
+
+	t.Run("resolves the new type for requests and fields", func(t *testing.T) {
+		events := []TypeRewritten{}
+		provider, err := newRegistry(t).BuildRootProvider(
+			WithTypeRewrite(oldType, newType),
+			WithObserver(ObserverFunc(func(event Event) {
+				if event, ok := event.(TypeRewritten); ok {
+					events = append(events, event)
+				}
+			})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		store, err := Resolve[oldUserStore](provider.NewScope())
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if store.User(1) != "adapted" {
+			t.Errorf("expected the adapter; got %v", store.User(1))
+		}
+		handler, err := Resolve[*userHandler](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if handler.Store.User(1) != "adapted" {
+			t.Errorf("expected the handler to get the adapter; got %v", handler.Store.User(1))
+		}
+		expected := []TypeRewritten{
+			{From: oldType, To: newType},
+			{From: oldType, To: newType, Path: []reflect.Type{reflect.TypeFor[*userHandler]()}},
+		}
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("expected events %v; got %v", expected, events)
+		}
+		if !provider.Settings().TypeRewrites {
+			t.Errorf("expected the settings to include TypeRewrites")
+		}
+	})
+
+	t.Run("applies computed rewrites", func(t *testing.T) {
+		provider, err := newRegistry(t).BuildRootProvider(WithTypeRewriteFunc(func(typ reflect.Type) reflect.Type {
+			if typ == oldType {
+				return newType
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		store, err := Resolve[oldUserStore](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if store.User(1) != "adapted" {
+			t.Errorf("expected the adapter; got %v", store.User(1))
+		}
+	})
+
+	t.Run("rejects computed rewrites to unassignable types", func(t *testing.T) {
+		provider, err := newRegistry(t).BuildRootProvider(WithTypeRewriteFunc(func(typ reflect.Type) reflect.Type {
+			if typ == newType {
+				return oldType
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if _, err := Resolve[newUserStore](provider); !errors.Is(err, ErrInvalidTypeRewrite) {
+			t.Errorf("expected %q; got %q", ErrInvalidTypeRewrite, err)
+		}
+	})
+
+	t.Run("detects cycles in computed rewrites", func(t *testing.T) {
+		aliasType := reflect.TypeFor[userStoreAlias]()
+		provider, err := newRegistry(t).BuildRootProvider(WithTypeRewriteFunc(func(typ reflect.Type) reflect.Type {
+			switch typ {
+			case oldType:
+				return aliasType
+			case aliasType:
+				return oldType
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		_, err = Resolve[oldUserStore](provider)
+		cycle := TypeRewriteCycle{}
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected a TypeRewriteCycle; got %v", err)
+		}
+		if expected := []reflect.Type{oldType, aliasType, oldType}; !slices.Equal(cycle.Types, expected) {
+			t.Errorf("expected %v; got %v", expected, cycle.Types)
+		}
+	})
+
+	t.Run("validates rewrites when building the provider", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			opts     []ProviderOption
+			expected error
+		}{
+			{
+				name:     "unregistered",
+				opts:     []ProviderOption{WithTypeRewrite(oldType, reflect.TypeFor[userStoreAdapter]())},
+				expected: ErrInvalidTypeRewrite,
+			},
+			{
+				name:     "unassignable",
+				opts:     []ProviderOption{WithTypeRewrite(newType, oldType)},
+				expected: ErrInvalidTypeRewrite,
+			},
+			{
+				name: "cycle",
+				opts: []ProviderOption{
+					WithTypeRewrite(oldType, newType),
+					WithTypeRewrite(newType, newType),
+				},
+				expected: ErrTypeRewriteCycle,
+			},
+			{
+				name: "conflict",
+				opts: []ProviderOption{
+					WithTypeRewrite(oldType, newType),
+					WithTypeRewrite(oldType, oldType),
+				},
+				expected: ErrConflictingOptions,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := newRegistry(t).BuildRootProvider(tt.opts...); !errors.Is(err, tt.expected) {
+					t.Errorf("expected %q; got %q", tt.expected, err)
+				}
+			})
+		}
+	})
+}
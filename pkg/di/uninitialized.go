@@ -0,0 +1,99 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUninitializedProvider is returned when an attempt is made to use a [RootProvider] that was not
+// created using [Registry.BuildRootProvider], such as the zero value.
+var ErrUninitializedProvider = errors.New("provider is uninitialized")
+
+// An UninitializedProvider is an [error] indicating that an attempt was made to use a
+// [RootProvider] that was not created using [Registry.BuildRootProvider], such as the zero value.
+// Calling [errors.Is] with an UninitializedProvider and [ErrUninitializedProvider] returns true.
+type UninitializedProvider struct {
+
+	// Operation is the name of the attempted operation, e.g. "Resolve".
+	Operation string
+}
+
+// Error implements [error].
+func (err UninitializedProvider) Error() string {
+	return fmt.Sprintf(
+		"cannot %s: RootProvider is uninitialized; providers must be created using Registry.BuildRootProvider",
+		err.Operation)
+}
+
+// Is indicates that an [UninitializedProvider] is [ErrUninitializedProvider].
+func (UninitializedProvider) Is(target error) bool {
+	return target == ErrUninitializedProvider
+}
+
+// Code returns "uninitialized_provider", the code of an [UninitializedProvider] in [ErrorCodes].
+func (UninitializedProvider) Code() string {
+	return "uninitialized_provider"
+}
+
+// ErrUninitializedScope is returned when an attempt is made to use a [Scope] that was not created
+// from an initialized [RootProvider] using [RootProvider.NewScope] or a related method, such as
+// the zero value.
+var ErrUninitializedScope = errors.New("scope is uninitialized")
+
+// An UninitializedScope is an [error] indicating that an attempt was made to use a [Scope] that was
+// not created from an initialized [RootProvider] using [RootProvider.NewScope] or a related
+// method, such as the zero value. Calling [errors.Is] with an UninitializedScope and
+// [ErrUninitializedScope] returns true.
+type UninitializedScope struct {
+
+	// Operation is the name of the attempted operation, e.g. "Resolve".
+	Operation string
+}
+
+// Error implements [error].
+func (err UninitializedScope) Error() string {
+	return fmt.Sprintf(
+		"cannot %s: Scope is uninitialized; scopes must be created using RootProvider.NewScope",
+		err.Operation)
+}
+
+// Is indicates that an [UninitializedScope] is [ErrUninitializedScope].
+func (UninitializedScope) Is(target error) bool {
+	return target == ErrUninitializedScope
+}
+
+// Code returns "uninitialized_scope", the code of an [UninitializedScope] in [ErrorCodes].
+func (UninitializedScope) Code() string {
+	return "uninitialized_scope"
+}
+
+// initialized reports whether the provider was created using Registry.BuildRootProvider.
+func (provider RootProvider) initialized() bool {
+	return provider.registrations != nil
+}
+
+// checkInitialized returns an [UninitializedProvider] for operation if the provider is
+// uninitialized.
+func (provider RootProvider) checkInitialized(operation string) error {
+	if !provider.initialized() {
+		return UninitializedProvider{
+			Operation: operation,
+		}
+	}
+	return nil
+}
+
+// initialized reports whether the scope was created from an initialized provider.
+func (scope Scope) initialized() bool {
+	return scope.state != nil
+}
+
+// checkInitialized returns an [UninitializedScope] for operation if the scope is uninitialized.
+func (scope Scope) checkInitialized(operation string) error {
+	if !scope.initialized() {
+		return UninitializedScope{
+			Operation: operation,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUninitialized(t *testing.T) {
+
+	typ := reflect.TypeFor[*mockCloser]()
+
+	t.Run("RootProvider", func(t *testing.T) {
+
+		provider := RootProvider{}
+
+		errorCases := map[string]func() error{
+			"Resolve": func() error {
+				_, err := provider.Resolve(typ)
+				return err
+			},
+			"Close": func() error {
+				return errors.Join(provider.Close(context.Background())...)
+			},
+			"CloseJoined": func() error {
+				return provider.CloseJoined(context.Background())
+			},
+			"OnClose": func() error {
+				var errs []error
+				provider.OnClose(func(closeErrors []error) {
+					errs = closeErrors
+				})
+				return errors.Join(errs...)
+			},
+			"Verify":  provider.Verify,
+			"Freeze":  provider.Freeze,
+			"Refresh": func() error { return provider.Refresh(typ) },
+			"SetSingleton": func() error {
+				return provider.SetSingleton(typ, &mockCloser{})
+			},
+			"NewChildProvider": func() error {
+				_, err := provider.NewChildProvider(Registry{})
+				return err
+			},
+			"Swap": func() error {
+				return MutableProvider{}.Swap(typ, Registry{})
+			},
+		}
+		for name, call := range errorCases {
+			t.Run(name+" returns UninitializedProvider", func(t *testing.T) {
+				if err := call(); !errors.Is(err, ErrUninitializedProvider) {
+					t.Fatalf("expected %v to be %v", err, ErrUninitializedProvider)
+				}
+			})
+		}
+
+		t.Run("other methods do not panic", func(t *testing.T) {
+			provider.Defer(func(context.Context) error { return nil })
+			if provider.Frozen() {
+				t.Errorf("expected Frozen to be false")
+			}
+			if id := provider.ID(); id != "" {
+				t.Errorf("expected an empty ID; got %q", id)
+			}
+			if s := provider.String(); s != "RootProvider(uninitialized)" {
+				t.Errorf("unexpected String: %q", s)
+			}
+			if abandoned := provider.AbandonedClosers(); len(abandoned) != 0 {
+				t.Errorf("expected no abandoned closers; got %v", abandoned)
+			}
+		})
+
+		t.Run("creates uninitialized scopes", func(t *testing.T) {
+			scopes := map[string]Scope{
+				"NewScope":            provider.NewScope(),
+				"NewNamedScope":       provider.NewNamedScope("name"),
+				"NewScopeWithContext": provider.NewScopeWithContext(context.Background(), time.Second),
+			}
+			for name, scope := range scopes {
+				if _, err := scope.Resolve(typ); !errors.Is(err, ErrUninitializedScope) {
+					t.Errorf("expected %v from a scope created by %s to be %v", err, name, ErrUninitializedScope)
+				}
+			}
+		})
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+
+		scope := Scope{}
+
+		errorCases := map[string]func() error{
+			"Resolve": func() error {
+				_, err := scope.Resolve(typ)
+				return err
+			},
+			"Close": func() error {
+				return errors.Join(scope.Close(context.Background())...)
+			},
+			"CloseJoined": func() error {
+				return scope.CloseJoined(context.Background())
+			},
+			"OnClose": func() error {
+				var errs []error
+				scope.OnClose(func(closeErrors []error) {
+					errs = closeErrors
+				})
+				return errors.Join(errs...)
+			},
+			"WithInstance": func() error {
+				return scope.WithInstance(typ, &mockCloser{})
+			},
+		}
+		for name, call := range errorCases {
+			t.Run(name+" returns UninitializedScope", func(t *testing.T) {
+				if err := call(); !errors.Is(err, ErrUninitializedScope) {
+					t.Fatalf("expected %v to be %v", err, ErrUninitializedScope)
+				}
+			})
+		}
+
+		t.Run("other methods do not panic", func(t *testing.T) {
+			scope.Defer(func(context.Context) error { return nil })
+			if id := scope.ID(); id != "" {
+				t.Errorf("expected an empty ID; got %q", id)
+			}
+			if name := scope.Name(); name != "" {
+				t.Errorf("expected an empty Name; got %q", name)
+			}
+			if s := scope.String(); s != "Scope(uninitialized)" {
+				t.Errorf("unexpected String: %q", s)
+			}
+		})
+
+		t.Run("creates uninitialized scopes", func(t *testing.T) {
+			scopes := map[string]Scope{
+				"NewScope":            scope.NewScope(),
+				"NewNamedScope":       scope.NewNamedScope("name"),
+				"NewChildScope":       scope.NewChildScope(),
+				"NewScopeWithContext": scope.NewScopeWithContext(context.Background(), time.Second),
+			}
+			for name, child := range scopes {
+				if _, err := child.Resolve(typ); !errors.Is(err, ErrUninitializedScope) {
+					t.Errorf("expected %v from a scope created by %s to be %v", err, name, ErrUninitializedScope)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,361 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrVerificationFailed is returned when verifying the registrations of a [Registry] or
+// [RootProvider] finds problems.
+var ErrVerificationFailed = errors.New("verification failed")
+
+// A VerificationFailed is an [error] indicating that verifying the registrations of a [Registry] or
+// [RootProvider] found problems or warnings. Calling [errors.Is] with a VerificationFailed and
+// [ErrVerificationFailed] returns true, and [errors.Is] and [errors.As] also match the individual
+// problems and warnings.
+type VerificationFailed struct {
+
+	// Problems are the problems that were found, e.g. [MissingDependency], [DependencyCycle], and
+	// [CaptiveDependency] errors.
+	Problems []error
+
+	// Warnings are the findings that do not make resolutions fail but are likely to be mistakes,
+	// e.g. [UndisposedTransient] errors.
+	Warnings []error
+}
+
+// Error implements [error].
+func (err VerificationFailed) Error() string {
+	msg := strings.Builder{}
+	if len(err.Problems) > 0 {
+		fmt.Fprintf(&msg, "verification found %d problem(s):", len(err.Problems))
+		for _, problem := range err.Problems {
+			fmt.Fprintf(&msg, "\n  - %v", problem)
+		}
+	}
+	if len(err.Warnings) > 0 {
+		if msg.Len() > 0 {
+			msg.WriteString("\n")
+		}
+		fmt.Fprintf(&msg, "verification found %d warning(s):", len(err.Warnings))
+		for _, warning := range err.Warnings {
+			fmt.Fprintf(&msg, "\n  - %v", warning)
+		}
+	}
+	return msg.String()
+}
+
+// WarningsOnly reports whether verification found warnings but no problems.
+func (err VerificationFailed) WarningsOnly() bool {
+	return len(err.Problems) == 0 && len(err.Warnings) > 0
+}
+
+// Is indicates that a [VerificationFailed] is [ErrVerificationFailed].
+func (VerificationFailed) Is(target error) bool {
+	return target == ErrVerificationFailed
+}
+
+// Unwrap gets the problems and warnings that were found.
+func (err VerificationFailed) Unwrap() []error {
+	return append(slices.Clone(err.Problems), err.Warnings...)
+}
+
+// Code returns "verification_failed", the code of a [VerificationFailed] in [ErrorCodes].
+func (VerificationFailed) Code() string {
+	return "verification_failed"
+}
+
+// ErrMissingDependency is returned when verification finds a registration that depends on a type
+// that is not registered.
+var ErrMissingDependency = errors.New("dependency is not registered")
+
+// A MissingDependency is an [error] indicating that verification found a registration that
+// depends on a type that is not registered. Calling [errors.Is] with a MissingDependency and
+// [ErrMissingDependency] returns true.
+type MissingDependency struct {
+
+	// Type is the registered type with the missing dependency.
+	Type reflect.Type
+
+	// Dependency is the type that is not registered.
+	Dependency reflect.Type
+}
+
+// Error implements [error].
+func (err MissingDependency) Error() string {
+	return fmt.Sprintf("%v depends on %v which is not registered", err.Type, err.Dependency)
+}
+
+// Is indicates that a [MissingDependency] is [ErrMissingDependency].
+func (MissingDependency) Is(target error) bool {
+	return target == ErrMissingDependency
+}
+
+// Code returns "missing_dependency", the code of a [MissingDependency] in [ErrorCodes].
+func (MissingDependency) Code() string {
+	return "missing_dependency"
+}
+
+// ErrDependencyCycle is returned when verification finds registrations that depend on each other.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// A DependencyCycle is an [error] indicating that verification found registrations that depend on
+// each other. Calling [errors.Is] with a DependencyCycle and [ErrDependencyCycle] returns true.
+type DependencyCycle struct {
+
+	// Cycle is the types in the cycle, in dependency order, starting and ending with the same type.
+	Cycle []reflect.Type
+}
+
+// Error implements [error].
+func (err DependencyCycle) Error() string {
+	types := make([]string, 0, len(err.Cycle))
+	for _, typ := range err.Cycle {
+		types = append(types, typ.String())
+	}
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(types, " -> "))
+}
+
+// Is indicates that a [DependencyCycle] is [ErrDependencyCycle].
+func (DependencyCycle) Is(target error) bool {
+	return target == ErrDependencyCycle
+}
+
+// Code returns "dependency_cycle", the code of a [DependencyCycle] in [ErrorCodes].
+func (DependencyCycle) Code() string {
+	return "dependency_cycle"
+}
+
+// ErrCaptiveDependency is returned when verification finds a registration that depends on a
+// registration with a shorter lifetime, directly or through [Transient] registrations: a
+// [Singleton] that depends on a [Scoped] or [PerResolution] registration, or a Scoped registration
+// that depends on a PerResolution registration.
+var ErrCaptiveDependency = errors.New("value depends on a shorter-lived value")
+
+// A CaptiveDependency is an [error] indicating that verification found a registration that depends
+// on a registration with a shorter lifetime, directly or through [Transient] registrations. Calling
+// [errors.Is] with a CaptiveDependency and [ErrCaptiveDependency] returns true.
+type CaptiveDependency struct {
+
+	// Type is the longer-lived type.
+	Type reflect.Type
+
+	// Lifetime is the lifetime of Type.
+	Lifetime Lifetime
+
+	// Dependency is the shorter-lived type it depends on.
+	Dependency reflect.Type
+
+	// DependencyLifetime is the lifetime of Dependency.
+	DependencyLifetime Lifetime
+}
+
+// Error implements [error].
+func (err CaptiveDependency) Error() string {
+	return fmt.Sprintf(
+		"%v %v depends on %v %v",
+		strings.ToLower(err.Lifetime.String()),
+		err.Type,
+		strings.ToLower(err.DependencyLifetime.String()),
+		err.Dependency)
+}
+
+// Is indicates that a [CaptiveDependency] is [ErrCaptiveDependency].
+func (CaptiveDependency) Is(target error) bool {
+	return target == ErrCaptiveDependency
+}
+
+// Code returns "captive_dependency", the code of a [CaptiveDependency] in [ErrorCodes].
+func (CaptiveDependency) Code() string {
+	return "captive_dependency"
+}
+
+// Verify checks the registrations in the registry for problems that would cause resolutions to
+// fail and returns a [VerificationFailed] describing any it finds. Only the dependencies of types
+// registered with [RegisterType] are known, so registrations using a [Factory] are only checked
+// as dependencies of other registrations.
+//
+// Verify also returns a VerificationFailed for warnings, which do not make resolutions fail: an
+// [UndisposedTransient] for each [Transient] registration whose instances must be closed by their
+// callers, and a [DeprecatedDependency] for each known dependency on a registration marked
+// [WithDeprecated]. Use [VerificationFailed.WarningsOnly] to tell them apart from problems.
+func (r Registry) Verify() error {
+	return verifyRegistrations(r.registrations)
+}
+
+// Verify checks the provider's registrations for problems; see [Registry.Verify]. The
+// [Singleton] values a child provider inherits are resolved by its parent, so Verify on a child
+// provider also reports the problems in its ancestors' registrations. Verify on a provider built
+// with [WithAutoResolve] also reports an [AutoResolved] for each type it has resolved without a
+// registration.
+func (provider RootProvider) Verify() error {
+	if err := provider.checkInitialized("Verify"); err != nil {
+		return err
+	}
+	problems := registrationProblems(provider.registrations.load())
+	for parent := provider.parent; parent != nil; parent = parent.parent {
+		for _, problem := range registrationProblems(parent.registrations.load()) {
+			if !slices.ContainsFunc(problems, func(err error) bool {
+				return err.Error() == problem.Error()
+			}) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	problems = append(problems, provider.autoResolvedProblems()...)
+	return verificationResult(problems, registrationWarnings(provider.registrations.load()))
+}
+
+func verifyRegistrations(registrations map[reflect.Type]registration) error {
+	return verificationResult(registrationProblems(registrations), registrationWarnings(registrations))
+}
+
+// registrationWarnings returns the warnings found in registrations.
+func registrationWarnings(registrations map[reflect.Type]registration) []error {
+	return append(findUndisposedTransients(registrations), findDeprecatedDependencies(registrations)...)
+}
+
+func verificationResult(problems []error, warnings []error) error {
+	if len(problems) > 0 || len(warnings) > 0 {
+		return VerificationFailed{
+			Problems: problems,
+			Warnings: warnings,
+		}
+	}
+	return nil
+}
+
+// registrationProblems returns the problems found in registrations.
+func registrationProblems(registrations map[reflect.Type]registration) []error {
+	types := sortedTypes(registrations)
+	problems := []error{}
+	for _, typ := range types {
+		for _, dependency := range registrations[typ].dependencies {
+			if _, ok := registrations[dependency]; !ok {
+				problems = append(problems, MissingDependency{
+					Type:       typ,
+					Dependency: dependency,
+				})
+			}
+		}
+	}
+	for _, typ := range types {
+		problems = append(problems, findCaptiveDependencies(registrations, typ)...)
+	}
+	problems = append(problems, findDependencyCycles(registrations, types)...)
+	return problems
+}
+
+func sortedTypes(registrations map[reflect.Type]registration) []reflect.Type {
+	types := make([]reflect.Type, 0, len(registrations))
+	for typ := range registrations {
+		types = append(types, typ)
+	}
+	slices.SortFunc(types, func(a, b reflect.Type) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return types
+}
+
+// captiveLifetimes maps each lifetime to the lifetimes its values must not depend on.
+var captiveLifetimes = map[Lifetime][]Lifetime{
+	Singleton: {Scoped, PerResolution},
+	Scoped:    {PerResolution},
+}
+
+// findCaptiveDependencies finds the registrations with shorter lifetimes than the registration
+// for typ that it depends on directly or through [Transient] registrations.
+func findCaptiveDependencies(registrations map[reflect.Type]registration, typ reflect.Type) []error {
+	problems := []error{}
+	lifetime := registrations[typ].lifetime
+	captive := captiveLifetimes[lifetime]
+	if len(captive) == 0 {
+		return problems
+	}
+	visited := map[reflect.Type]bool{}
+	pending := slices.Clone(registrations[typ].dependencies)
+	for len(pending) > 0 {
+		dependency := pending[0]
+		pending = pending[1:]
+		if visited[dependency] {
+			continue
+		}
+		visited[dependency] = true
+		registration, ok := registrations[dependency]
+		if !ok {
+			continue
+		}
+		if slices.Contains(captive, registration.lifetime) {
+			problems = append(problems, CaptiveDependency{
+				Type:               typ,
+				Lifetime:           lifetime,
+				Dependency:         dependency,
+				DependencyLifetime: registration.lifetime,
+			})
+		} else if registration.lifetime == Transient {
+			pending = append(pending, registration.dependencies...)
+		}
+	}
+	return problems
+}
+
+// findDependencyCycles finds the cycles in the known dependencies between the registrations.
+func findDependencyCycles(registrations map[reflect.Type]registration, types []reflect.Type) []error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	problems := []error{}
+	states := map[reflect.Type]int{}
+	path := []reflect.Type{}
+	var visit func(reflect.Type)
+	visit = func(typ reflect.Type) {
+		switch states[typ] {
+		case visiting:
+			start := slices.Index(path, typ)
+			cycle := append(slices.Clone(path[start:]), typ)
+			problems = append(problems, DependencyCycle{
+				Cycle: cycle,
+			})
+			return
+		case visited:
+			return
+		}
+		registration, ok := registrations[typ]
+		if !ok {
+			return
+		}
+		states[typ] = visiting
+		path = append(path, typ)
+		for _, dependency := range registration.dependencies {
+			visit(dependency)
+		}
+		path = path[:len(path)-1]
+		states[typ] = visited
+	}
+	for _, typ := range types {
+		visit(typ)
+	}
+	return problems
+}
+
+// defaultFactoryDependencies returns the types the default factory for typ resolves.
+func defaultFactoryDependencies(typ reflect.Type) []reflect.Type {
+	switch typ.Kind() {
+	case reflect.Struct:
+		dependencies := []reflect.Type{}
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.IsExported() {
+				dependencies = append(dependencies, field.Type)
+			}
+		}
+		return dependencies
+	case reflect.Pointer:
+		return defaultFactoryDependencies(typ.Elem())
+	}
+	return nil
+}
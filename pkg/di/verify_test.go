@@ -0,0 +1,139 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type verifyCycleA struct {
+	B *verifyCycleB
+}
+
+type verifyCycleB struct {
+	A *verifyCycleA
+}
+
+type verifySingleton struct {
+	Transient *verifyTransient
+}
+
+type verifyTransient struct {
+	Scoped *mockCloser
+}
+
+func TestVerify(t *testing.T) {
+
+	t.Run("succeeds when every dependency is registered", func(t *testing.T) {
+		registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		if err := registry.Verify(); err != nil {
+			t.Fatalf("unexpected error from Verify: %v", err)
+		}
+	})
+
+	t.Run("returns MissingDependency for unregistered dependencies", func(t *testing.T) {
+		registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		err = registry.Verify()
+		if !errors.Is(err, ErrVerificationFailed) {
+			t.Fatalf("expected %v to be %v", err, ErrVerificationFailed)
+		}
+		var missing MissingDependency
+		if !errors.As(err, &missing) {
+			t.Fatalf("expected %v to contain %T", err, missing)
+		}
+		expected := MissingDependency{
+			Type:       reflect.TypeFor[*verifyTransient](),
+			Dependency: reflect.TypeFor[*mockCloser](),
+		}
+		if missing != expected {
+			t.Fatalf("expected %v; got %v", expected, missing)
+		}
+	})
+
+	t.Run("returns DependencyCycle for registrations that depend on each other", func(t *testing.T) {
+		registry, err := RegisterType[*verifyCycleA, *verifyCycleA](Registry{}, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*verifyCycleB, *verifyCycleB](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		err = registry.Verify()
+		var cycle DependencyCycle
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected %v to contain %T", err, cycle)
+		}
+		expected := []reflect.Type{
+			reflect.TypeFor[*verifyCycleA](),
+			reflect.TypeFor[*verifyCycleB](),
+			reflect.TypeFor[*verifyCycleA](),
+		}
+		if !reflect.DeepEqual(cycle.Cycle, expected) {
+			t.Fatalf("expected cycle %v; got %v", expected, cycle.Cycle)
+		}
+	})
+
+	t.Run("returns CaptiveDependency for singletons that depend on scoped values", func(t *testing.T) {
+		registry, err := RegisterType[*verifySingleton, *verifySingleton](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*verifyTransient, *verifyTransient](registry, Transient)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		err = registry.Verify()
+		var captive CaptiveDependency
+		if !errors.As(err, &captive) {
+			t.Fatalf("expected %v to contain %T", err, captive)
+		}
+		expected := CaptiveDependency{
+			Type:               reflect.TypeFor[*verifySingleton](),
+			Lifetime:           Singleton,
+			Dependency:         reflect.TypeFor[*mockCloser](),
+			DependencyLifetime: Scoped,
+		}
+		if captive != expected {
+			t.Fatalf("expected %v; got %v", expected, captive)
+		}
+	})
+	t.Run("returns CaptiveDependency for scoped values that depend on per-resolution values", func(t *testing.T) {
+		registry, err := RegisterType[*verifyTransient, *verifyTransient](Registry{}, Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterType[*mockCloser, *mockCloser](registry, PerResolution)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		err = registry.Verify()
+		var captive CaptiveDependency
+		if !errors.As(err, &captive) {
+			t.Fatalf("expected %v to contain %T", err, captive)
+		}
+		expected := CaptiveDependency{
+			Type:               reflect.TypeFor[*verifyTransient](),
+			Lifetime:           Scoped,
+			Dependency:         reflect.TypeFor[*mockCloser](),
+			DependencyLifetime: PerResolution,
+		}
+		if captive != expected {
+			t.Fatalf("expected %v; got %v", expected, captive)
+		}
+	})
+}
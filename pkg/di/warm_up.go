@@ -0,0 +1,162 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrWarmUpFailed is returned when [RootProvider.WarmUp] fails to construct a [Singleton].
+var ErrWarmUpFailed = errors.New("singleton warm-up failed")
+
+// A WarmUpFailed is an [error] indicating that [RootProvider.WarmUp] failed to construct a
+// [Singleton]. Calling [errors.Is] with a WarmUpFailed and [ErrWarmUpFailed] returns true, and
+// [errors.Is] and [errors.As] also match the error from resolving it.
+type WarmUpFailed struct {
+
+	// Type is the type that could not be warmed up.
+	Type reflect.Type
+
+	// Err is the error from resolving Type.
+	Err error
+}
+
+// Error implements [error].
+func (err WarmUpFailed) Error() string {
+	return fmt.Sprintf("warming up %v: %v", err.Type, err.Err)
+}
+
+// Is indicates that a [WarmUpFailed] is [ErrWarmUpFailed].
+func (WarmUpFailed) Is(target error) bool {
+	return target == ErrWarmUpFailed
+}
+
+// Unwrap gets the error from resolving the type.
+func (err WarmUpFailed) Unwrap() error {
+	return err.Err
+}
+
+// Code returns "warm_up_failed", the code of a [WarmUpFailed] in [ErrorCodes].
+func (WarmUpFailed) Code() string {
+	return "warm_up_failed"
+}
+
+// A SingletonWarmedUp is an [Event] indicating that [RootProvider.WarmUp] finished resolving a
+// [Singleton].
+type SingletonWarmedUp struct {
+
+	// Type is the type that was warmed up.
+	Type reflect.Type
+
+	// Duration is how long resolving Type took.
+	Duration time.Duration
+
+	// Err is the error from resolving Type, if any.
+	Err error
+}
+
+func (SingletonWarmedUp) event() {}
+
+// WithWarmUpConcurrency sets the number of singletons [RootProvider.WarmUp] resolves at once. A
+// limit of 0 or less resolves every singleton at once. By default singletons are resolved one at a
+// time.
+func WithWarmUpConcurrency(limit int) ProviderOption {
+	return func(options *providerOptions) {
+		if limit <= 0 {
+			limit = -1
+		}
+		if options.reapplied("WithWarmUpConcurrency") && options.warmUpConcurrency != limit {
+			options.conflict(
+				fmt.Sprintf("given limits of %d and %d", options.warmUpConcurrency, limit),
+				"WithWarmUpConcurrency")
+		}
+		options.warmUpConcurrency = limit
+	}
+}
+
+// WarmUp resolves the [Singleton] values for types so that their construction cost is paid up
+// front rather than by the first resolution that needs them, or every Singleton registration if no
+// types are given. Each resolution is reported to the provider's [Observer] as a
+// [SingletonWarmedUp]; see [WithWarmUpConcurrency] to resolve them in parallel.
+//
+// WarmUp resolves every type it can and returns the failures joined with [errors.Join]: an
+// [UnknownType] for a type that is not registered, a [NotSingleton] for a type registered with
+// another lifetime, and a [WarmUpFailed] for a type that could not be constructed. WarmUp stops
+// starting new resolutions once ctx is done and includes the error from ctx.
+func (provider RootProvider) WarmUp(ctx context.Context, types ...reflect.Type) error {
+	if err := provider.checkInitialized("WarmUp"); err != nil {
+		return err
+	}
+	provider.constructing = nil
+	provider.path = nil
+	provider.ctx = nil
+	if len(types) == 0 {
+		registrations := provider.registrations.load()
+		for _, typ := range sortedTypes(registrations) {
+			if registrations[typ].lifetime == Singleton {
+				types = append(types, typ)
+			}
+		}
+	}
+
+	limit := provider.options.warmUpConcurrency
+	if limit == 0 {
+		limit = 1
+	} else if limit < 0 {
+		limit = len(types)
+	}
+	errs := make([]error, len(types)+1)
+	semaphore := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i, typ := range types {
+		select {
+		case <-ctx.Done():
+		case semaphore <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			errs[len(types)] = ctx.Err()
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = provider.warmUp(typ)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// warmUp resolves the Singleton value for typ and reports the result to the observer.
+func (provider RootProvider) warmUp(typ reflect.Type) error {
+	registration, ok := provider.registrations.get(typ)
+	if !ok {
+		return UnknownType{
+			Type: typ,
+		}
+	}
+	if registration.lifetime != Singleton {
+		return NotSingleton{
+			Type:     typ,
+			Lifetime: registration.lifetime,
+		}
+	}
+	start := time.Now()
+	_, err := provider.Resolve(typ)
+	if err != nil {
+		err = WarmUpFailed{
+			Type: typ,
+			Err:  err,
+		}
+	}
+	provider.observe(SingletonWarmedUp{
+		Type:     typ,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type warmUpClient struct{}
+
+func TestWarmUp(t *testing.T) {
+
+	newRegistry := func(t *testing.T) Registry {
+		registry, err := RegisterType[*mockCloser, *mockCloser](Registry{}, Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = RegisterFactory[*warmUpClient](registry, Singleton, func(Resolver) (*warmUpClient, error) {
+			return &warmUpClient{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = RegisterFactory[*errorContextCloser](registry, Scoped, func(Resolver) (*errorContextCloser, error) {
+			return &errorContextCloser{}, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		return registry
+	}
+
+	instantiated := func(provider RootProvider) []reflect.Type {
+		var types []reflect.Type
+		for _, entry := range provider.singletons.entries() {
+			types = append(types, entry.typ)
+		}
+		return types
+	}
+
+	typ := reflect.TypeFor[*mockCloser]()
+
+	t.Run("constructs the listed singletons", func(t *testing.T) {
+		provider, err := newRegistry(t).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.WarmUp(context.Background(), typ); err != nil {
+			t.Fatalf("unexpected error from WarmUp: %v", err)
+		}
+		if types := instantiated(provider); len(types) != 1 || types[0] != typ {
+			t.Fatalf("expected only %v to be instantiated; got %v", typ, types)
+		}
+	})
+
+	t.Run("constructs every singleton when no types are given", func(t *testing.T) {
+		var mu sync.Mutex
+		var warmed []reflect.Type
+		provider, err := newRegistry(t).BuildRootProvider(
+			WithWarmUpConcurrency(0),
+			WithObserver(ObserverFunc(func(event Event) {
+				if event, ok := event.(SingletonWarmedUp); ok {
+					mu.Lock()
+					defer mu.Unlock()
+					if event.Err != nil {
+						t.Errorf("unexpected error warming up %v: %v", event.Type, event.Err)
+					}
+					warmed = append(warmed, event.Type)
+				}
+			})))
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.WarmUp(context.Background()); err != nil {
+			t.Fatalf("unexpected error from WarmUp: %v", err)
+		}
+		if len(warmed) != 2 {
+			t.Fatalf("expected 2 singletons to be warmed up; got %v", warmed)
+		}
+	})
+
+	t.Run("returns an error for each type that cannot be warmed up", func(t *testing.T) {
+		expected := errors.New("expected error")
+		registry, err := RegisterFactory[*warmUpClient](newRegistry(t), Singleton, func(Resolver) (*warmUpClient, error) {
+			return nil, expected
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		err = provider.WarmUp(
+			context.Background(),
+			typ,
+			reflect.TypeFor[*warmUpClient](),
+			reflect.TypeFor[*errorContextCloser](),
+			reflect.TypeFor[string]())
+		var warmUpFailed WarmUpFailed
+		if !errors.As(err, &warmUpFailed) || !errors.Is(err, expected) {
+			t.Fatalf("expected %v to include a WarmUpFailed wrapping %v", err, expected)
+		}
+		if warmUpFailed.Type != reflect.TypeFor[*warmUpClient]() {
+			t.Fatalf("expected type %v; got %v", reflect.TypeFor[*warmUpClient](), warmUpFailed.Type)
+		}
+		var notSingleton NotSingleton
+		if !errors.As(err, &notSingleton) || notSingleton.Lifetime != Scoped {
+			t.Fatalf("expected %v to include a NotSingleton for a Scoped registration", err)
+		}
+		if !errors.Is(err, ErrUnknownType) {
+			t.Fatalf("expected %v to include %v", err, ErrUnknownType)
+		}
+		if types := instantiated(provider); len(types) != 1 || types[0] != typ {
+			t.Fatalf("expected %v to be instantiated; got %v", typ, types)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		provider, err := newRegistry(t).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := provider.WarmUp(ctx, typ); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %v to be %v", err, context.Canceled)
+		}
+		if types := instantiated(provider); len(types) != 0 {
+			t.Fatalf("expected no singletons to be instantiated; got %v", types)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package dicli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// ErrFlagsNotParsed is returned when an attempt is made to register a struct bound to flags that
+// have not been parsed.
+var ErrFlagsNotParsed = errors.New("flags have not been parsed")
+
+// A FlagsNotParsed is an [error] indicating that the [di.RegistrationFunc] returned by [BindFlags]
+// was called before its flag set was parsed. Calling [errors.Is] with a FlagsNotParsed and
+// [ErrFlagsNotParsed] returns true.
+type FlagsNotParsed struct {
+
+	// Type is the struct type bound to the flags.
+	Type reflect.Type
+
+	// FlagSet is the name of the flag set.
+	FlagSet string
+}
+
+// Error implements [error].
+func (err FlagsNotParsed) Error() string {
+	return fmt.Sprintf("cannot register %v before flag set %q has been parsed", err.Type, err.FlagSet)
+}
+
+// Is indicates that a [FlagsNotParsed] is [ErrFlagsNotParsed].
+func (FlagsNotParsed) Is(target error) bool {
+	return target == ErrFlagsNotParsed
+}
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	valueType    = reflect.TypeFor[flag.Value]()
+)
+
+// BindFlags declares a flag in fs for each exported field of the struct T and returns a
+// [di.RegistrationFunc] that registers a *T holding the values parsed into them as an instance,
+// which must be called after fs has been parsed.
+//
+// The name of a field's flag is the value of its flag tag or, without one, its name in kebab case,
+// e.g. ListenAddr becomes listen-addr, and fields tagged flag:"-" are skipped. The usage tag sets
+// the flag's usage message and the default tag sets its default value:
+//
+//	type ServeConfig struct {
+//		ListenAddr string        `usage:"the address to listen on" default:":8080"`
+//		Timeout    time.Duration `flag:"t" usage:"the request timeout" default:"5s"`
+//	}
+//
+// Fields may be of a type whose pointer implements [flag.Value], a [time.Duration], or a type whose
+// underlying type is a bool, string, float64, int, int64, uint, or uint64. BindFlags panics if T
+// is not a struct, a field has any other type, or a default value is invalid, since these are
+// programming errors. The registration function returns a [FlagsNotParsed] if fs has not been
+// parsed.
+func BindFlags[T any](fs *flag.FlagSet) di.RegistrationFunc {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("dicli: BindFlags requires a struct type; got %v", typ))
+	}
+	config := new(T)
+	bindFields(fs, reflect.ValueOf(config).Elem())
+	return func(registry di.Registry) (di.Registry, error) {
+		if !fs.Parsed() {
+			return di.Registry{}, FlagsNotParsed{
+				Type:    typ,
+				FlagSet: fs.Name(),
+			}
+		}
+		return di.RegisterInstance[*T](registry, config)
+	}
+}
+
+func bindFields(fs *flag.FlagSet, config reflect.Value) {
+	typ := config.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := field.Tag.Lookup("flag")
+		if name == "-" {
+			continue
+		}
+		if !ok {
+			name = kebabCase(field.Name)
+		}
+		usage := field.Tag.Get("usage")
+		if !bindField(fs, config.Field(i).Addr(), name, usage) {
+			panic(fmt.Sprintf("dicli: field %s of %v has unsupported type %v", field.Name, typ, field.Type))
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			f := fs.Lookup(name)
+			if err := f.Value.Set(def); err != nil {
+				panic(fmt.Sprintf("dicli: invalid default %q for field %s of %v: %v", def, field.Name, typ, err))
+			}
+			f.DefValue = f.Value.String()
+		}
+	}
+}
+
+// bindField declares a flag named name that sets the value ptr points to and reports whether the
+// type of the value is supported.
+func bindField(fs *flag.FlagSet, ptr reflect.Value, name string, usage string) bool {
+	if ptr.Type().Implements(valueType) {
+		fs.Var(ptr.Interface().(flag.Value), name, usage)
+		return true
+	}
+	if ptr.Type().Elem() == durationType {
+		fs.DurationVar(ptr.Interface().(*time.Duration), name, 0, usage)
+		return true
+	}
+	as := func(p any) any {
+		return ptr.Convert(reflect.TypeOf(p)).Interface()
+	}
+	switch ptr.Type().Elem().Kind() {
+	case reflect.Bool:
+		fs.BoolVar(as((*bool)(nil)).(*bool), name, false, usage)
+	case reflect.String:
+		fs.StringVar(as((*string)(nil)).(*string), name, "", usage)
+	case reflect.Float64:
+		fs.Float64Var(as((*float64)(nil)).(*float64), name, 0, usage)
+	case reflect.Int:
+		fs.IntVar(as((*int)(nil)).(*int), name, 0, usage)
+	case reflect.Int64:
+		fs.Int64Var(as((*int64)(nil)).(*int64), name, 0, usage)
+	case reflect.Uint:
+		fs.UintVar(as((*uint)(nil)).(*uint), name, 0, usage)
+	case reflect.Uint64:
+		fs.Uint64Var(as((*uint64)(nil)).(*uint64), name, 0, usage)
+	default:
+		return false
+	}
+	return true
+}
+
+// kebabCase converts a Go identifier to kebab case, e.g. HTTPPort becomes http-port.
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package dicli
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type level string
+
+type tags []string
+
+func (t *tags) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *tags) Set(value string) error {
+	*t = append(*t, value)
+	return nil
+}
+
+type serveConfig struct {
+	ListenAddr string        `usage:"the address to listen on" default:":8080"`
+	Timeout    time.Duration `flag:"t" default:"5s"`
+	Verbose    bool
+	HTTPPort   int
+	Level      level
+	Tags       tags
+	Ignored    chan int `flag:"-"`
+	unexported string
+}
+
+func TestBindFlags(t *testing.T) {
+
+	newFlagSet := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		return fs
+	}
+
+	resolve := func(t *testing.T, reg di.RegistrationFunc) serveConfig {
+		registry, err := reg(di.Registry{})
+		if err != nil {
+			t.Fatalf("unexpected error from the registration function: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		config, err := di.Resolve[*serveConfig](provider)
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		return *config
+	}
+
+	t.Run("registers the parsed flags", func(t *testing.T) {
+		fs := newFlagSet()
+		reg := BindFlags[serveConfig](fs)
+		err := fs.Parse([]string{"-listen-addr", ":9090", "-t", "3s", "-verbose", "-http-port", "80", "-level", "debug", "-tags", "a", "-tags", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error from Parse: %v", err)
+		}
+		expected := serveConfig{
+			ListenAddr: ":9090",
+			Timeout:    3 * time.Second,
+			Verbose:    true,
+			HTTPPort:   80,
+			Level:      "debug",
+			Tags:       tags{"a", "b"},
+		}
+		if config := resolve(t, reg); !reflect.DeepEqual(config, expected) {
+			t.Fatalf("expected %+v; got %+v", expected, config)
+		}
+	})
+
+	t.Run("applies the defaults and usage", func(t *testing.T) {
+		fs := newFlagSet()
+		reg := BindFlags[serveConfig](fs)
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("unexpected error from Parse: %v", err)
+		}
+		config := resolve(t, reg)
+		if config.ListenAddr != ":8080" || config.Timeout != 5*time.Second {
+			t.Fatalf("expected the defaults; got %+v", config)
+		}
+		f := fs.Lookup("listen-addr")
+		if f.Usage != "the address to listen on" || f.DefValue != ":8080" {
+			t.Fatalf("unexpected flag %+v", f)
+		}
+		for _, name := range []string{"ignored", "unexported"} {
+			if fs.Lookup(name) != nil {
+				t.Errorf("expected no flag named %s", name)
+			}
+		}
+	})
+
+	t.Run("returns FlagsNotParsed before parsing", func(t *testing.T) {
+		_, err := BindFlags[serveConfig](newFlagSet())(di.Registry{})
+		if !errors.Is(err, ErrFlagsNotParsed) {
+			t.Fatalf("expected %q; got %q", ErrFlagsNotParsed, err)
+		}
+		var notParsed FlagsNotParsed
+		if !errors.As(err, &notParsed) {
+			t.Fatalf("expected %v to be %T", err, notParsed)
+		}
+		if typ := reflect.TypeFor[serveConfig](); notParsed.Type != typ || notParsed.FlagSet != "serve" {
+			t.Errorf("expected %v and flag set serve; got %+v", typ, notParsed)
+		}
+	})
+
+	t.Run("panics for unsupported types", func(t *testing.T) {
+		type unsupported struct {
+			Ratio float32
+		}
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(r.(string), "Ratio") {
+				t.Fatalf("expected a panic naming the field; got %v", r)
+			}
+		}()
+		BindFlags[unsupported](newFlagSet())
+	})
+}
+
+func TestKebabCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Verbose":    "verbose",
+		"ListenAddr": "listen-addr",
+		"HTTPPort":   "http-port",
+		"UserID":     "user-id",
+		"V2Endpoint": "v2-endpoint",
+	} {
+		if actual := kebabCase(name); actual != expected {
+			t.Errorf("expected %s to become %s; got %s", name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package dicli
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// DefaultTeardownTimeout is the time [Run] allows for closing the command's scope unless
+// [WithTeardownTimeout] is given.
+const DefaultTeardownTimeout = 10 * time.Second
+
+// An Option configures optional behavior for [Run].
+type Option func(*options)
+
+type options struct {
+	signals         []os.Signal
+	teardownTimeout time.Duration
+	errorOutput     io.Writer
+}
+
+func newOptions(opts []Option) options {
+	options := options{
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+		teardownTimeout: DefaultTeardownTimeout,
+		errorOutput:     os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithSignals sets the signals that cancel the context given to the command. By default the
+// command is cancelled by [os.Interrupt] and SIGTERM. Giving no signals disables cancellation by
+// signals.
+func WithSignals(signals ...os.Signal) Option {
+	return func(options *options) {
+		options.signals = signals
+	}
+}
+
+// WithTeardownTimeout sets the time allowed for closing the command's scope once the command
+// returns. The timeout is independent of the command's context, which has been cancelled by then
+// if the command was interrupted. A timeout of 0 or less means closing the scope is not given a
+// deadline.
+func WithTeardownTimeout(timeout time.Duration) Option {
+	return func(options *options) {
+		options.teardownTimeout = timeout
+	}
+}
+
+// WithErrorOutput sets the writer that [Run] writes the command's errors to. By default the
+// errors are written to [os.Stderr].
+func WithErrorOutput(w io.Writer) Option {
+	return func(options *options) {
+		options.errorOutput = w
+	}
+}
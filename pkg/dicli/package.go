@@ -0,0 +1,3 @@
+// Package dicli integrates the di package with command line programs by creating a [di.Scope] for
+// each command invocation and binding flags into configuration structs.
+package dicli
@@ -0,0 +1,123 @@
+package dicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// The exit codes [Run] returns.
+const (
+
+	// ExitOK is the exit code of a command that succeeded.
+	ExitOK = 0
+
+	// ExitFailure is the exit code of a command that failed, or whose scope could not be closed,
+	// unless the error is an [ExitError].
+	ExitFailure = 1
+
+	// ExitInterrupted is the exit code of a command that failed after it was cancelled by a
+	// signal, following the shell convention of 128 plus the number of SIGINT.
+	ExitInterrupted = 130
+)
+
+// An ExitError is an [error] that makes [Run] return Code as the exit code. The message of an
+// ExitError is the message of Err, and [Run] writes nothing for an ExitError without one.
+type ExitError struct {
+
+	// Code is the exit code.
+	Code int
+
+	// Err is the error that caused the command to fail, if any.
+	Err error
+}
+
+// Error implements [error].
+func (err ExitError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("exit code %d", err.Code)
+	}
+	return err.Err.Error()
+}
+
+// Unwrap gets the error that caused the command to fail.
+func (err ExitError) Unwrap() error {
+	return err.Err
+}
+
+// Run creates a [di.Scope] from provider, calls fn with it and a context that is cancelled when
+// the process receives one of the signals set with [WithSignals], and closes the scope once fn
+// returns, including when fn panics. The scope is closed with a deadline of the teardown timeout
+// set with [WithTeardownTimeout], even if the context was cancelled.
+//
+// Run returns the exit code for the command, which is meant to be passed to [os.Exit] after any
+// other deferred work is done:
+//
+//	os.Exit(dicli.Run(context.Background(), provider, serve))
+//
+// The exit code is [ExitOK] if fn succeeded and the scope was closed, the Code of the first
+// [ExitError] in the errors from fn and closing the scope if there is one, [ExitInterrupted] if fn
+// failed after a signal was received, and [ExitFailure] otherwise. The errors are written to the
+// writer set with [WithErrorOutput].
+func Run(
+	ctx context.Context,
+	provider di.RootProvider,
+	fn func(context.Context, di.Scope) error,
+	opts ...Option,
+) int {
+	options := newOptions(opts)
+	signalled, stop := notifyContext(ctx, options.signals)
+	defer stop()
+	err := run(signalled, provider, fn, options)
+	if silent, ok := err.(ExitError); err != nil && !(ok && silent.Err == nil) {
+		fmt.Fprintln(options.errorOutput, err)
+	}
+	var exit ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exit):
+		return exit.Code
+	case signalled.Err() != nil && ctx.Err() == nil:
+		return ExitInterrupted
+	default:
+		return ExitFailure
+	}
+}
+
+// notifyContext is [signal.NotifyContext] except that it ignores every signal when none are given.
+func notifyContext(ctx context.Context, signals []os.Signal) (context.Context, context.CancelFunc) {
+	if len(signals) == 0 {
+		return context.WithCancel(ctx)
+	}
+	return signal.NotifyContext(ctx, signals...)
+}
+
+func run(
+	ctx context.Context,
+	provider di.RootProvider,
+	fn func(context.Context, di.Scope) error,
+	options options,
+) (err error) {
+	scope := provider.NewScope()
+	defer func() {
+		if closeErr := closeScope(ctx, scope, options); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}()
+	return fn(ctx, scope)
+}
+
+func closeScope(ctx context.Context, scope di.Scope, options options) error {
+	ctx = context.WithoutCancel(ctx)
+	if options.teardownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.teardownTimeout)
+		defer cancel()
+	}
+	return scope.CloseJoined(ctx)
+}
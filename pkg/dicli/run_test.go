@@ -0,0 +1,172 @@
+package dicli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type events struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *events) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
+
+type database struct {
+	Events *events
+	err    error
+}
+
+func (d *database) Close() error {
+	d.Events.add("database closed")
+	return d.err
+}
+
+type server struct {
+	Database *database
+}
+
+func (s *server) Close() error {
+	s.Database.Events.add("server closed")
+	return nil
+}
+
+func TestRun(t *testing.T) {
+
+	newProvider := func(t *testing.T, closeErr error) (di.RootProvider, *events) {
+		recorded := &events{}
+		registry, err := di.RegisterInstance[*events](di.Registry{}, recorded)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, err = di.RegisterFactory[*database](registry, di.Scoped, func(r di.Resolver) (*database, error) {
+			events, err := di.Resolve[*events](r)
+			return &database{Events: events, err: closeErr}, err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		registry, err = di.RegisterType[*server, *server](registry, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider, recorded
+	}
+
+	t.Run("closes the scope in reverse order before returning", func(t *testing.T) {
+		provider, recorded := newProvider(t, nil)
+		var output bytes.Buffer
+		code := Run(context.Background(), provider, func(ctx context.Context, scope di.Scope) error {
+			if _, err := di.Resolve[*server](scope); err != nil {
+				return err
+			}
+			recorded.add("command done")
+			return nil
+		}, WithErrorOutput(&output))
+		if code != ExitOK {
+			t.Fatalf("expected exit code %d; got %d: %s", ExitOK, code, output.String())
+		}
+		expected := []string{"command done", "server closed", "database closed"}
+		if events := recorded.list(); !slices.Equal(events, expected) {
+			t.Fatalf("expected %v; got %v", expected, events)
+		}
+	})
+
+	t.Run("maps errors to exit codes", func(t *testing.T) {
+		failure := errors.New("failure")
+		for name, test := range map[string]struct {
+			err      error
+			closeErr error
+			code     int
+			output   string
+		}{
+			"error":               {err: failure, code: ExitFailure, output: "failure"},
+			"ExitError":           {err: ExitError{Code: 3, Err: failure}, code: 3, output: "failure"},
+			"silent ExitError":    {err: ExitError{Code: 2}, code: 2},
+			"close error":         {closeErr: failure, code: ExitFailure, output: "failure"},
+			"ExitError and close": {err: ExitError{Code: 4, Err: failure}, closeErr: failure, code: 4, output: "failure"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				provider, _ := newProvider(t, test.closeErr)
+				var output bytes.Buffer
+				code := Run(context.Background(), provider, func(ctx context.Context, scope di.Scope) error {
+					if _, err := di.Resolve[*database](scope); err != nil {
+						return err
+					}
+					return test.err
+				}, WithErrorOutput(&output))
+				if code != test.code {
+					t.Errorf("expected exit code %d; got %d", test.code, code)
+				}
+				if !strings.Contains(output.String(), test.output) || (test.output == "") != (output.Len() == 0) {
+					t.Errorf("expected output %q; got %q", test.output, output.String())
+				}
+			})
+		}
+	})
+
+	t.Run("closes the scope when the command panics", func(t *testing.T) {
+		provider, recorded := newProvider(t, nil)
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Fatalf("expected the panic to continue; got %v", r)
+				}
+			}()
+			Run(context.Background(), provider, func(ctx context.Context, scope di.Scope) error {
+				if _, err := di.Resolve[*database](scope); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+		if events := recorded.list(); !slices.Equal(events, []string{"database closed"}) {
+			t.Fatalf("expected the database to be closed; got %v", events)
+		}
+	})
+
+	t.Run("cancels the command when a signal is received", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("sending signals to the process is not supported on windows")
+		}
+		provider, _ := newProvider(t, nil)
+		code := Run(context.Background(), provider, func(ctx context.Context, scope di.Scope) error {
+			process, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			if err := process.Signal(syscall.SIGUSR1); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithSignals(syscall.SIGUSR1), WithErrorOutput(&bytes.Buffer{}))
+		if code != ExitInterrupted {
+			t.Fatalf("expected exit code %d; got %d", ExitInterrupted, code)
+		}
+	})
+}
@@ -0,0 +1,403 @@
+package dihttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// A DebugOption configures optional behavior for [DebugHandler].
+type DebugOption func(*debugOptions)
+
+type debugOptions struct {
+	allowed []string
+	denied  []string
+}
+
+// WithAllowedTypes restricts the types [DebugHandler] describes to those whose names match at
+// least one of patterns. Patterns use the syntax of [path.Match] and are matched against the name
+// of the type as formatted by [reflect.Type.String], e.g. "*payments.Client"; note that a leading
+// "*" in a pattern also matches the "*" of a pointer type. WithAllowedTypes can be given more than
+// once to allow more types.
+func WithAllowedTypes(patterns ...string) DebugOption {
+	return func(options *debugOptions) {
+		options.allowed = append(options.allowed, patterns...)
+	}
+}
+
+// WithDeniedTypes hides the types whose names match any of patterns from [DebugHandler], e.g. to
+// keep the names of sensitive types out of debug output. Patterns are matched the same way as for
+// [WithAllowedTypes], and a type that matches both is hidden.
+func WithDeniedTypes(patterns ...string) DebugOption {
+	return func(options *debugOptions) {
+		options.denied = append(options.denied, patterns...)
+	}
+}
+
+// DebugHandler returns an [http.Handler] that serves read-only snapshots of the state of provider
+// for debugging. It is meant to be mounted under a prefix, e.g.
+//
+//	mux.Handle("/debug/di/", http.StripPrefix("/debug/di", dihttp.DebugHandler(provider)))
+//
+// and serves the following paths in response to GET requests:
+//
+//   - /registrations: the registrations the provider uses; see [di.RootProvider.Registrations].
+//   - /singletons: each [di.Singleton] registration and whether its value has been constructed,
+//     with where the value came from when the provider was built with [di.WithProvenance].
+//   - /scopes: the scopes that have not been closed and their ages, when the provider was built
+//     with [di.WithScopeTracking].
+//   - /resolutions: the number of times each type has been resolved, when the provider was built
+//     with [di.WithResolutionCounts].
+//   - /graph: the known dependencies between the registrations as JSON.
+//   - /graph.dot: the known dependencies between the registrations in the Graphviz DOT language.
+//   - /options: the options the provider was built with; see [di.RootProvider.Settings].
+//
+// Every response other than /graph.dot is JSON. Serving a request never resolves anything from the
+// provider. DebugHandler panics if a pattern given to [WithAllowedTypes] or [WithDeniedTypes] is
+// malformed.
+func DebugHandler(provider di.RootProvider, opts ...DebugOption) http.Handler {
+	options := debugOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	for _, pattern := range append(append([]string{}, options.allowed...), options.denied...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic(fmt.Sprintf("dihttp: DebugHandler given malformed type pattern %q: %v", pattern, err))
+		}
+	}
+	debug := debugHandler{
+		provider: provider,
+		options:  options,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", debug.index)
+	mux.HandleFunc("GET /registrations", debug.registrations)
+	mux.HandleFunc("GET /singletons", debug.singletons)
+	mux.HandleFunc("GET /scopes", debug.scopes)
+	mux.HandleFunc("GET /resolutions", debug.resolutions)
+	mux.HandleFunc("GET /graph", debug.graph)
+	mux.HandleFunc("GET /graph.dot", debug.graphDOT)
+	mux.HandleFunc("GET /options", debug.settings)
+	return mux
+}
+
+type debugHandler struct {
+	provider di.RootProvider
+	options  debugOptions
+}
+
+// visible reports whether typ may be described in debug output.
+func (options debugOptions) visible(typ reflect.Type) bool {
+	name := typ.String()
+	for _, pattern := range options.denied {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(options.allowed) == 0 {
+		return true
+	}
+	for _, pattern := range options.allowed {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// registrationInfos returns the registrations whose types are visible.
+func (debug debugHandler) registrationInfos() []di.RegistrationInfo {
+	infos := []di.RegistrationInfo{}
+	for _, info := range debug.provider.Registrations() {
+		if debug.options.visible(info.Type) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+func (debug debugHandler) index(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, map[string][]string{
+		"endpoints": {"registrations", "singletons", "scopes", "resolutions", "graph", "graph.dot", "options"},
+	})
+}
+
+type debugRegistration struct {
+	Type         string   `json:"type"`
+	Impl         string   `json:"impl"`
+	Lifetime     string   `json:"lifetime"`
+	ScopeName    string   `json:"scopeName,omitempty"`
+	Owned        bool     `json:"owned"`
+	SharedValue  bool     `json:"sharedValue"`
+	Dependencies []string `json:"dependencies"`
+	Deprecated   bool     `json:"deprecated,omitempty"`
+	Deprecation  string   `json:"deprecation,omitempty"`
+	ScopeAccess  bool     `json:"scopeAccess,omitempty"`
+	Imported     bool     `json:"imported,omitempty"`
+}
+
+func (debug debugHandler) registrations(w http.ResponseWriter, r *http.Request) {
+	registrations := []debugRegistration{}
+	for _, info := range debug.registrationInfos() {
+		registrations = append(registrations, debugRegistration{
+			Type:         info.Type.String(),
+			Impl:         info.Impl.String(),
+			Lifetime:     info.Lifetime.String(),
+			ScopeName:    info.ScopeName,
+			Owned:        info.Owned,
+			SharedValue:  info.SharedValue,
+			Dependencies: debug.typeNames(info.Dependencies),
+			Deprecated:   info.Deprecated,
+			Deprecation:  info.Deprecation,
+			ScopeAccess:  info.ScopeAccess,
+			Imported:     info.Imported,
+		})
+	}
+	writeJSON(w, r, registrations)
+}
+
+type debugSingleton struct {
+	Type         string           `json:"type"`
+	Instantiated bool             `json:"instantiated"`
+	Provenance   *debugProvenance `json:"provenance,omitempty"`
+}
+
+type debugProvenance struct {
+	Scope   string    `json:"scope"`
+	Created time.Time `json:"created"`
+	Path    []string  `json:"path"`
+}
+
+func (debug debugHandler) singletons(w http.ResponseWriter, r *http.Request) {
+	provenance := map[reflect.Type]*debugProvenance{}
+	records, _ := debug.provider.Provenances()
+	for _, record := range records {
+		provenance[record.Registration.Type] = &debugProvenance{
+			Scope:   record.Scope,
+			Created: record.Created,
+			Path:    debug.typeNames(record.Path),
+		}
+	}
+	singletons := []debugSingleton{}
+	for _, info := range debug.registrationInfos() {
+		if info.Lifetime == di.Singleton {
+			_, instantiated := debug.provider.Peek(info.Type)
+			singletons = append(singletons, debugSingleton{
+				Type:         info.Type.String(),
+				Instantiated: instantiated,
+				Provenance:   provenance[info.Type],
+			})
+		}
+	}
+	writeJSON(w, r, singletons)
+}
+
+type debugScopes struct {
+	Tracking bool         `json:"tracking"`
+	Count    int          `json:"count"`
+	Scopes   []debugScope `json:"scopes"`
+}
+
+type debugScope struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Parent  string    `json:"parent,omitempty"`
+	Created time.Time `json:"created"`
+	Age     string    `json:"age"`
+}
+
+func (debug debugHandler) scopes(w http.ResponseWriter, r *http.Request) {
+	open, tracking := debug.provider.OpenScopes()
+	scopes := debugScopes{
+		Tracking: tracking,
+		Count:    len(open),
+		Scopes:   []debugScope{},
+	}
+	now := time.Now()
+	for _, scope := range open {
+		scopes.Scopes = append(scopes.Scopes, debugScope{
+			ID:      scope.ID,
+			Name:    scope.Name,
+			Parent:  scope.Parent,
+			Created: scope.Created,
+			Age:     now.Sub(scope.Created).String(),
+		})
+	}
+	writeJSON(w, r, scopes)
+}
+
+type debugSettings struct {
+	Summary                  string   `json:"summary"`
+	DefaultCloseConcurrency  int      `json:"defaultCloseConcurrency"`
+	DefaultFactoryTimeout    string   `json:"defaultFactoryTimeout"`
+	WarmUpConcurrency        int      `json:"warmUpConcurrency"`
+	Observer                 bool     `json:"observer"`
+	InstanceStore            bool     `json:"instanceStore"`
+	SharedSingletons         []string `json:"sharedSingletons"`
+	ScopePooling             bool     `json:"scopePooling"`
+	BestEffortFieldInjection bool     `json:"bestEffortFieldInjection"`
+	AutoResolve              bool     `json:"autoResolve"`
+	LeakDetection            bool     `json:"leakDetection"`
+	ScopeTracking            bool     `json:"scopeTracking"`
+	ResolutionCounts         bool     `json:"resolutionCounts"`
+	TransientTracking        bool     `json:"transientTracking"`
+	StrictDisposal           bool     `json:"strictDisposal"`
+	Provenance               bool     `json:"provenance"`
+	EmptyCollections         bool     `json:"emptyCollections"`
+	TypeRewrites             bool     `json:"typeRewrites"`
+	DeprecationErrors        bool     `json:"deprecationErrors"`
+	DeprecationLog           bool     `json:"deprecationLog"`
+	RestrictedResolvers      bool     `json:"restrictedResolvers"`
+	CloseErrorHandler        bool     `json:"closeErrorHandler"`
+	FallbackResolver         bool     `json:"fallbackResolver"`
+	CacheFallbackValues      bool     `json:"cacheFallbackValues"`
+}
+
+func (debug debugHandler) settings(w http.ResponseWriter, r *http.Request) {
+	settings := debug.provider.Settings()
+	shared := []string{}
+	for _, typ := range settings.SharedSingletons {
+		if debug.options.visible(typ) {
+			shared = append(shared, typ.String())
+		}
+	}
+	writeJSON(w, r, debugSettings{
+		Summary:                  settings.String(),
+		DefaultCloseConcurrency:  settings.DefaultCloseConcurrency,
+		DefaultFactoryTimeout:    settings.DefaultFactoryTimeout.String(),
+		WarmUpConcurrency:        settings.WarmUpConcurrency,
+		Observer:                 settings.Observer,
+		InstanceStore:            settings.InstanceStore,
+		SharedSingletons:         shared,
+		ScopePooling:             settings.ScopePooling,
+		BestEffortFieldInjection: settings.BestEffortFieldInjection,
+		AutoResolve:              settings.AutoResolve,
+		LeakDetection:            settings.LeakDetection,
+		ScopeTracking:            settings.ScopeTracking,
+		ResolutionCounts:         settings.ResolutionCounts,
+		TransientTracking:        settings.TransientTracking,
+		StrictDisposal:           settings.StrictDisposal,
+		Provenance:               settings.Provenance,
+		EmptyCollections:         settings.EmptyCollections,
+		TypeRewrites:             settings.TypeRewrites,
+		DeprecationErrors:        settings.DeprecationErrors,
+		DeprecationLog:           settings.DeprecationLog,
+		RestrictedResolvers:      settings.RestrictedResolvers,
+		CloseErrorHandler:        settings.CloseErrorHandler,
+		FallbackResolver:         settings.FallbackResolver,
+		CacheFallbackValues:      settings.CacheFallbackValues,
+	})
+}
+
+type debugResolutions struct {
+	Counting bool                   `json:"counting"`
+	Counts   []debugResolutionCount `json:"counts"`
+}
+
+type debugResolutionCount struct {
+	Type  string `json:"type"`
+	Count uint64 `json:"count"`
+}
+
+func (debug debugHandler) resolutions(w http.ResponseWriter, r *http.Request) {
+	counts, counting := debug.provider.ResolutionCounts()
+	resolutions := debugResolutions{
+		Counting: counting,
+		Counts:   []debugResolutionCount{},
+	}
+	for _, count := range counts {
+		if debug.options.visible(count.Type) {
+			resolutions.Counts = append(resolutions.Counts, debugResolutionCount{
+				Type:  count.Type.String(),
+				Count: count.Count,
+			})
+		}
+	}
+	writeJSON(w, r, resolutions)
+}
+
+type debugGraph struct {
+	Nodes []debugNode `json:"nodes"`
+	Edges []debugEdge `json:"edges"`
+}
+
+type debugNode struct {
+	Type     string `json:"type"`
+	Lifetime string `json:"lifetime"`
+}
+
+type debugEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// dependencyGraph returns the visible registrations and the known dependencies between them. A
+// dependency on a visible type that is not registered is included as an edge without a node.
+func (debug debugHandler) dependencyGraph() debugGraph {
+	graph := debugGraph{
+		Nodes: []debugNode{},
+		Edges: []debugEdge{},
+	}
+	for _, info := range debug.registrationInfos() {
+		graph.Nodes = append(graph.Nodes, debugNode{
+			Type:     info.Type.String(),
+			Lifetime: info.Lifetime.String(),
+		})
+		for _, dependency := range debug.typeNames(info.Dependencies) {
+			graph.Edges = append(graph.Edges, debugEdge{
+				From: info.Type.String(),
+				To:   dependency,
+			})
+		}
+	}
+	return graph
+}
+
+func (debug debugHandler) graph(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, debug.dependencyGraph())
+}
+
+func (debug debugHandler) graphDOT(w http.ResponseWriter, _ *http.Request) {
+	graph := debug.dependencyGraph()
+	dot := strings.Builder{}
+	dot.WriteString("digraph dependencies {\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&dot, "\t%s [label=%s];\n",
+			strconv.Quote(node.Type),
+			strconv.Quote(node.Type+"\n"+node.Lifetime))
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&dot, "\t%s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	dot.WriteString("}\n")
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	_, _ = w.Write([]byte(dot.String()))
+}
+
+// typeNames returns the names of the visible types.
+func (debug debugHandler) typeNames(types []reflect.Type) []string {
+	names := []string{}
+	for _, typ := range types {
+		if debug.options.visible(typ) {
+			names = append(names, typ.String())
+		}
+	}
+	return names
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, value any) {
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(body, '\n'))
+}
@@ -0,0 +1,193 @@
+package dihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type debugConfig struct{}
+
+type debugClient struct {
+	Config *debugConfig
+}
+
+type debugSecret struct{}
+
+func TestDebugHandler(t *testing.T) {
+
+	newDebugProvider := func(t *testing.T, opts ...di.ProviderOption) di.RootProvider {
+		registry, err := di.RegisterType[*debugConfig, *debugConfig](di.Registry{}, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*debugClient, *debugClient](registry, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*debugSecret, *debugSecret](registry, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider(opts...)
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	get := func(t *testing.T, handler http.Handler, path string, body any) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status %d from %s; got %d", http.StatusOK, path, recorder.Code)
+		}
+		if body != nil {
+			if err := json.Unmarshal(recorder.Body.Bytes(), body); err != nil {
+				t.Fatalf("unexpected error from json.Unmarshal: %v", err)
+			}
+		}
+		return recorder
+	}
+
+	t.Run("serves the registrations", func(t *testing.T) {
+		var registrations []debugRegistration
+		get(t, DebugHandler(newDebugProvider(t)), "/registrations", &registrations)
+		if len(registrations) != 3 {
+			t.Fatalf("expected 3 registrations; got %v", registrations)
+		}
+		client := registrations[0]
+		if client.Type != "*dihttp.debugClient" || client.Lifetime != "Singleton" {
+			t.Fatalf("unexpected registration %+v", client)
+		}
+		if len(client.Dependencies) != 1 || client.Dependencies[0] != "*dihttp.debugConfig" {
+			t.Fatalf("expected the client to depend on the config; got %v", client.Dependencies)
+		}
+	})
+
+	t.Run("serves singleton status without constructing singletons", func(t *testing.T) {
+		provider := newDebugProvider(t)
+		if _, err := di.Resolve[*debugConfig](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		handler := DebugHandler(provider)
+		var singletons []debugSingleton
+		get(t, handler, "/singletons", &singletons)
+		get(t, handler, "/graph", nil)
+		get(t, handler, "/graph.dot", nil)
+		expected := []debugSingleton{
+			{Type: "*dihttp.debugClient", Instantiated: false},
+			{Type: "*dihttp.debugConfig", Instantiated: true},
+		}
+		if len(singletons) != len(expected) || singletons[0] != expected[0] || singletons[1] != expected[1] {
+			t.Fatalf("expected %v; got %v", expected, singletons)
+		}
+		if types := provider.InstantiatedSingletons(); len(types) != 1 {
+			t.Fatalf("expected the handler not to construct anything; got %v", types)
+		}
+	})
+
+	t.Run("serves singleton provenance when it is recorded", func(t *testing.T) {
+		provider := newDebugProvider(t, di.WithProvenance())
+		if _, err := di.Resolve[*debugClient](provider); err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		var singletons []debugSingleton
+		get(t, DebugHandler(provider), "/singletons", &singletons)
+		if len(singletons) != 2 {
+			t.Fatalf("expected 2 singletons; got %v", singletons)
+		}
+		config := singletons[1].Provenance
+		if config == nil {
+			t.Fatalf("expected the config to have provenance; got %+v", singletons[1])
+		}
+		if config.Scope != "root" {
+			t.Errorf("expected the config to come from the root; got %q", config.Scope)
+		}
+		expected := []string{"*dihttp.debugClient", "*dihttp.debugConfig"}
+		if !slices.Equal(config.Path, expected) {
+			t.Errorf("expected path %v; got %v", expected, config.Path)
+		}
+	})
+
+	t.Run("serves open scopes when tracking is enabled", func(t *testing.T) {
+		provider := newDebugProvider(t, di.WithScopeTracking())
+		open := provider.NewNamedScope("request")
+		provider.NewScope().Close(context.Background())
+		var scopes debugScopes
+		get(t, DebugHandler(provider), "/scopes", &scopes)
+		if !scopes.Tracking || scopes.Count != 1 || scopes.Scopes[0].ID != open.ID() || scopes.Scopes[0].Name != "request" {
+			t.Fatalf("expected only scope %s to be open; got %+v", open.ID(), scopes)
+		}
+
+		get(t, DebugHandler(newDebugProvider(t)), "/scopes", &scopes)
+		if scopes.Tracking {
+			t.Fatalf("expected tracking to be reported as disabled")
+		}
+	})
+
+	t.Run("serves the options the provider was built with", func(t *testing.T) {
+		var settings debugSettings
+		get(t, DebugHandler(newDebugProvider(t, di.WithScopeTracking(), di.WithWarmUpConcurrency(4))), "/options", &settings)
+		if !settings.ScopeTracking || settings.WarmUpConcurrency != 4 || settings.Summary != "WarmUpConcurrency=4, ScopeTracking" {
+			t.Fatalf("unexpected settings %+v", settings)
+		}
+	})
+
+	t.Run("serves resolution counts when counting is enabled", func(t *testing.T) {
+		provider := newDebugProvider(t, di.WithResolutionCounts())
+		for range 2 {
+			if _, err := di.Resolve[*debugClient](provider); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}
+		var resolutions debugResolutions
+		get(t, DebugHandler(provider), "/resolutions", &resolutions)
+		expected := []debugResolutionCount{
+			{Type: "*dihttp.debugClient", Count: 2},
+			{Type: "*dihttp.debugConfig", Count: 1},
+		}
+		if !resolutions.Counting || len(resolutions.Counts) != 2 ||
+			resolutions.Counts[0] != expected[0] || resolutions.Counts[1] != expected[1] {
+			t.Fatalf("expected %v; got %+v", expected, resolutions)
+		}
+	})
+
+	t.Run("serves the dependency graph as DOT", func(t *testing.T) {
+		body := get(t, DebugHandler(newDebugProvider(t)), "/graph.dot", nil).Body.String()
+		if !strings.Contains(body, `"*dihttp.debugClient" -> "*dihttp.debugConfig";`) {
+			t.Fatalf("expected the graph to include the client's dependency; got:\n%s", body)
+		}
+	})
+
+	t.Run("hides types that are denied or not allowed", func(t *testing.T) {
+		provider := newDebugProvider(t)
+		var registrations []debugRegistration
+		get(t, DebugHandler(provider, WithDeniedTypes("*dihttp.debugSecret")), "/registrations", &registrations)
+		for _, registration := range registrations {
+			if registration.Type == "*dihttp.debugSecret" {
+				t.Fatalf("expected the denied type to be hidden; got %v", registrations)
+			}
+		}
+
+		var graph debugGraph
+		get(t, DebugHandler(provider, WithAllowedTypes("*dihttp.debugClient")), "/graph", &graph)
+		if len(graph.Nodes) != 1 || len(graph.Edges) != 0 {
+			t.Fatalf("expected only the allowed type without edges to hidden types; got %+v", graph)
+		}
+	})
+
+	t.Run("rejects requests other than GET", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		DebugHandler(newDebugProvider(t)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/registrations", nil))
+		if recorder.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("expected status %d; got %d", http.StatusMethodNotAllowed, recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package dihttp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// ErrNoScope is returned when a handler created with [Handler] serves a request that does not
+// have a [di.Scope] because the handler is not wrapped by [Middleware].
+var ErrNoScope = errors.New("request has no scope; the handler must be wrapped by dihttp.Middleware")
+
+// A HandlerOption configures optional behavior for [Handler].
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	errorWriter func(http.ResponseWriter, *http.Request, error)
+}
+
+// WithErrorWriter sets the function that writes the response when [Handler] fails, either because
+// the request has no scope or its dependencies cannot be resolved, or because the function given
+// to Handler returns an error. By default, or if writer is nil, the error is written to the
+// standard logger and the response is a 500 Internal Server Error.
+func WithErrorWriter(writer func(w http.ResponseWriter, r *http.Request, err error)) HandlerOption {
+	return func(options *handlerOptions) {
+		options.errorWriter = writer
+	}
+}
+
+// Handler returns an [http.Handler] that calls fn with an instance of Deps for each request. Deps
+// must be a struct, or a pointer to a struct, whose exported fields are resolved from the request's
+// [di.Scope] the same way as the default factory for Deps; see [di.GetDefaultFactory]. The handler
+// must be wrapped by [Middleware] so that requests have a scope.
+//
+// If the request has no scope, Deps cannot be resolved, or fn returns an error, the error is passed
+// to the error writer set with [WithErrorWriter], which by default writes it to the standard logger
+// and responds with a 500 Internal Server Error. Handler panics if Deps has no default factory.
+func Handler[Deps any](
+	fn func(w http.ResponseWriter, r *http.Request, deps Deps) error,
+	opts ...HandlerOption,
+) http.Handler {
+	factory, err := di.GetDefaultFactory[Deps]()
+	if err != nil {
+		panic(fmt.Sprintf("dihttp: Handler cannot resolve dependencies: %v", err))
+	}
+	options := handlerOptions{
+		errorWriter: writeError,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.errorWriter == nil {
+		options.errorWriter = writeError
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, ok := ScopeFromRequest(r)
+		if !ok {
+			options.errorWriter(w, r, ErrNoScope)
+			return
+		}
+		deps, err := factory(scope)
+		if err != nil {
+			options.errorWriter(w, r, fmt.Errorf("resolving %T: %w", deps, err))
+			return
+		}
+		if err := fn(w, r, deps); err != nil {
+			options.errorWriter(w, r, err)
+		}
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("dihttp: %s %s failed: %v", r.Method, r.URL.Path, err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
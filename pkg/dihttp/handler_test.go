@@ -0,0 +1,141 @@
+package dihttp
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type handlerDeps struct {
+	Closer *requestCloser
+}
+
+type missingDeps struct {
+	Missing *strings.Builder
+}
+
+func TestHandler(t *testing.T) {
+
+	serve := func(handler http.Handler) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder
+	}
+
+	captureLog := func(t *testing.T) *bytes.Buffer {
+		var buf bytes.Buffer
+		writer, flags := log.Writer(), log.Flags()
+		log.SetOutput(&buf)
+		log.SetFlags(0)
+		t.Cleanup(func() {
+			log.SetOutput(writer)
+			log.SetFlags(flags)
+		})
+		return &buf
+	}
+
+	t.Run("resolves the dependencies from the request's scope", func(t *testing.T) {
+		handler := Middleware(newProvider(t))(Handler(func(w http.ResponseWriter, r *http.Request, deps handlerDeps) error {
+			if deps.Closer == nil {
+				t.Errorf("expected the dependencies to be resolved")
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}))
+		if res := serve(handler); res.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d; got %d", http.StatusNoContent, res.Code)
+		}
+	})
+
+	t.Run("passes returned errors to the error writer", func(t *testing.T) {
+		expected := errors.New("handler failed")
+		var actual error
+		handler := Middleware(newProvider(t))(Handler(
+			func(w http.ResponseWriter, r *http.Request, deps *handlerDeps) error {
+				return expected
+			},
+			WithErrorWriter(func(w http.ResponseWriter, r *http.Request, err error) {
+				actual = err
+				w.WriteHeader(http.StatusTeapot)
+			})))
+		if res := serve(handler); res.Code != http.StatusTeapot {
+			t.Fatalf("expected status %d; got %d", http.StatusTeapot, res.Code)
+		}
+		if actual != expected {
+			t.Fatalf("expected %v; got %v", expected, actual)
+		}
+	})
+
+	t.Run("passes missing scopes and resolution failures to the error writer", func(t *testing.T) {
+		var actual []error
+		writer := WithErrorWriter(func(w http.ResponseWriter, r *http.Request, err error) {
+			actual = append(actual, err)
+			w.WriteHeader(http.StatusTeapot)
+		})
+		handlers := []http.Handler{
+			Handler(func(w http.ResponseWriter, r *http.Request, deps handlerDeps) error {
+				return nil
+			}, writer),
+			Middleware(newProvider(t))(Handler(func(w http.ResponseWriter, r *http.Request, deps missingDeps) error {
+				t.Errorf("expected the function not to be called")
+				return nil
+			}, writer)),
+		}
+		for _, handler := range handlers {
+			if res := serve(handler); res.Code != http.StatusTeapot {
+				t.Fatalf("expected status %d; got %d", http.StatusTeapot, res.Code)
+			}
+		}
+		if len(actual) != 2 || !errors.Is(actual[0], ErrNoScope) || !errors.Is(actual[1], di.ErrUnknownType) {
+			t.Fatalf("expected %q and %q; got %v", ErrNoScope, di.ErrUnknownType, actual)
+		}
+	})
+
+	t.Run("responds with 500 and logs returned errors with a nil error writer", func(t *testing.T) {
+		logs := captureLog(t)
+		handler := Middleware(newProvider(t))(Handler(
+			func(w http.ResponseWriter, r *http.Request, deps handlerDeps) error {
+				return errors.New("handler failed")
+			},
+			WithErrorWriter(nil)))
+		if res := serve(handler); res.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d; got %d", http.StatusInternalServerError, res.Code)
+		}
+		if !strings.Contains(logs.String(), "handler failed") {
+			t.Fatalf("expected the log to include the error; got %q", logs.String())
+		}
+	})
+
+	t.Run("responds with 500 and logs resolution failures", func(t *testing.T) {
+		logs := captureLog(t)
+		handler := Middleware(newProvider(t))(Handler(func(w http.ResponseWriter, r *http.Request, deps missingDeps) error {
+			t.Errorf("expected the function not to be called")
+			return nil
+		}))
+		if res := serve(handler); res.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d; got %d", http.StatusInternalServerError, res.Code)
+		}
+		if !strings.Contains(logs.String(), "strings.Builder") {
+			t.Fatalf("expected the log to identify the unresolved type; got %q", logs.String())
+		}
+	})
+
+	t.Run("responds with 500 without Middleware", func(t *testing.T) {
+		logs := captureLog(t)
+		handler := Handler(func(w http.ResponseWriter, r *http.Request, deps handlerDeps) error {
+			return nil
+		})
+		if res := serve(handler); res.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d; got %d", http.StatusInternalServerError, res.Code)
+		}
+		if !strings.Contains(logs.String(), ErrNoScope.Error()) {
+			t.Fatalf("expected the log to include %q; got %q", ErrNoScope, logs.String())
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package dihttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// Middleware returns middleware that creates a [di.Scope] from provider for each request, stores it
+// in the request's context using [di.NewContext] for the handler to retrieve with [ScopeFromRequest]
+// or [di.FromContext], and closes it once the handler returns, including when the handler panics.
+//
+// The scope resolves the request passed to the next handler for *http.Request, its context for
+// [context.Context], and w for [http.ResponseWriter] using [di.Scope.WithInstance], so factories and
+// the fields of default factories can depend on them. Resolving them from a scope that was not
+// created for a request returns a [di.UnknownType] unless they are registered. Middleware that
+// wraps the response writer can make the scope resolve the wrapper with [ReplaceResponseWriter].
+func Middleware(provider di.RootProvider, opts ...Option) func(http.Handler) http.Handler {
+	options := newOptions(opts)
+	options.delegateCloseErrors = !options.closeErrorHandlerSet && provider.Settings().CloseErrorHandler
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var scope di.Scope
+			if options.scopeName != "" {
+				scope = provider.NewNamedScope(options.scopeName)
+			} else {
+				scope = provider.NewScope()
+			}
+			defer closeScope(r, scope, options)
+			r = r.WithContext(di.NewContext(r.Context(), scope))
+			if err := provideRequest(scope, w, r); err != nil {
+				writeError(w, r, fmt.Errorf("providing the request to its scope: %w", err))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ReplaceResponseWriter makes the scope of r resolve w for [http.ResponseWriter], e.g. for
+// middleware that wraps the response writer to record the status code. It returns [ErrNoScope] if r
+// has no scope and a [di.AlreadyResolved] if the scope has already resolved the response writer.
+func ReplaceResponseWriter(r *http.Request, w http.ResponseWriter) error {
+	scope, ok := ScopeFromRequest(r)
+	if !ok {
+		return ErrNoScope
+	}
+	return di.WithInstance(scope, w)
+}
+
+func provideRequest(scope di.Scope, w http.ResponseWriter, r *http.Request) error {
+	if err := di.WithInstance(scope, r); err != nil {
+		return err
+	}
+	if err := di.WithInstance(scope, r.Context()); err != nil {
+		return err
+	}
+	return di.WithInstance(scope, w)
+}
+
+// MiddlewareWithProvider returns [Middleware] for a route group that needs different wiring from
+// the rest of the application, e.g. to decorate every service used by admin routes. It creates a
+// child provider from base using overrides once, with [di.RootProvider.NewChildProvider], and
+// creates the scopes for the group's requests from the child, so their String methods show the
+// layer they came from.
+//
+// The child provider is verified with [di.RootProvider.Verify], so MiddlewareWithProvider returns
+// an error rather than a middleware if the child cannot be created or its registrations have
+// problems, and a mistake in the wiring surfaces when the mux is built rather than on the first
+// request. Verification warnings are ignored.
+//
+// The returned close function closes the child provider with [di.RootProvider.Close], closing the
+// [di.Singleton] values the child created. It should be called once the server has shut down and
+// before base is closed.
+func MiddlewareWithProvider(
+	base di.RootProvider,
+	overrides di.Registry,
+	opts ...Option,
+) (func(http.Handler) http.Handler, func(context.Context) []error, error) {
+	child, err := base.NewChildProvider(overrides)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dihttp: creating the child provider: %w", err)
+	}
+	var failed di.VerificationFailed
+	if err := child.Verify(); err != nil && !(errors.As(err, &failed) && failed.WarningsOnly()) {
+		return nil, nil, fmt.Errorf("dihttp: verifying the child provider: %w", err)
+	}
+	closeChild := func(ctx context.Context) []error {
+		return child.Close(ctx)
+	}
+	return Middleware(child, opts...), closeChild, nil
+}
+
+func closeScope(r *http.Request, scope di.Scope, options options) {
+	ctx := context.WithoutCancel(r.Context())
+	if options.closeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.closeTimeout)
+		defer cancel()
+	}
+	if options.delegateCloseErrors {
+		_ = scope.Close(ctx, di.DelegateErrors())
+		return
+	}
+	if errs := scope.Close(ctx); len(errs) > 0 && options.onCloseError != nil {
+		options.onCloseError(r, errs)
+	}
+}
+
+// ScopeFromContext returns the [di.Scope] that [Middleware] stored in ctx, if any. It is equivalent
+// to [di.FromContext].
+func ScopeFromContext(ctx context.Context) (di.Scope, bool) {
+	return di.FromContext(ctx)
+}
+
+// ScopeFromRequest returns the [di.Scope] that [Middleware] created for r, if any.
+func ScopeFromRequest(r *http.Request) (di.Scope, bool) {
+	return ScopeFromContext(r.Context())
+}
@@ -0,0 +1,377 @@
+package dihttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type requestCloser struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *requestCloser) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *requestCloser) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+type slowCloser struct{}
+
+func (slowCloser) Close(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newProvider(t *testing.T, opts ...di.ProviderOption) di.RootProvider {
+	registry, err := di.RegisterType[*requestCloser, *requestCloser](di.Registry{}, di.Scoped)
+	if err != nil {
+		t.Fatalf("unexpected error from RegisterType: %v", err)
+	}
+	registry, err = di.RegisterFactory[*slowCloser](registry, di.Scoped, func(di.Resolver) (*slowCloser, error) {
+		return &slowCloser{}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from RegisterFactory: %v", err)
+	}
+	provider, err := registry.BuildRootProvider(opts...)
+	if err != nil {
+		t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+	}
+	return provider
+}
+
+func TestMiddleware(t *testing.T) {
+
+	t.Run("closes the request's scope after the handler returns", func(t *testing.T) {
+		var resolved *requestCloser
+		handler := Middleware(newProvider(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, ok := ScopeFromRequest(r)
+			if !ok {
+				t.Fatalf("expected the request to have a scope")
+			}
+			var err error
+			resolved, err = di.Resolve[*requestCloser](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if resolved.isClosed() {
+				t.Fatalf("expected the scope to be open while the handler runs")
+			}
+		}))
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		res, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error from Get: %v", err)
+		}
+		res.Body.Close()
+		if !resolved.isClosed() {
+			t.Fatalf("expected the scope to be closed after the handler returned")
+		}
+	})
+
+	t.Run("closes the request's scope when the handler panics", func(t *testing.T) {
+		var resolved *requestCloser
+		handler := Middleware(newProvider(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			resolved, _ = di.Resolve[*requestCloser](scope)
+			panic("handler failed")
+		}))
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected the panic to propagate")
+				}
+			}()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+		if resolved == nil || !resolved.isClosed() {
+			t.Fatalf("expected the scope to be closed after the handler panicked")
+		}
+	})
+
+	t.Run("reports slow closers to the close error handler", func(t *testing.T) {
+		var closeErrors []error
+		handler := Middleware(
+			newProvider(t),
+			WithCloseTimeout(10*time.Millisecond),
+			WithCloseErrorHandler(func(r *http.Request, errs []error) {
+				closeErrors = errs
+			}),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			if _, err := di.Resolve[*slowCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}))
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		cancel()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if !errors.Is(errors.Join(closeErrors...), context.DeadlineExceeded) {
+			t.Fatalf("expected %v to include %v", closeErrors, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("passes close errors to the provider's close error handler by default", func(t *testing.T) {
+		var reports []di.CloseReport
+		provider := newProvider(t, di.WithCloseErrorHandler(func(scopeID string, report di.CloseReport) {
+			reports = append(reports, report)
+		}))
+		handler := Middleware(
+			provider,
+			WithCloseTimeout(10*time.Millisecond),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			if _, err := di.Resolve[*slowCloser](scope); err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if len(reports) != 1 || !errors.Is(errors.Join(reports[0].Errors()...), context.DeadlineExceeded) {
+			t.Fatalf("expected one report including %v; got %v", context.DeadlineExceeded, reports)
+		}
+	})
+
+	t.Run("WithScopeName creates named scopes", func(t *testing.T) {
+		handler := Middleware(newProvider(t), WithScopeName("request"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			if scope.Name() != "request" {
+				t.Errorf("expected scope name %q; got %q", "request", scope.Name())
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("stores the scope with di.NewContext", func(t *testing.T) {
+		handler := Middleware(newProvider(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := di.ResolveFromContext[*requestCloser](r.Context()); err != nil {
+				t.Errorf("unexpected error from ResolveFromContext: %v", err)
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+type requestInfo struct {
+	Request *http.Request
+	Writer  http.ResponseWriter
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+}
+
+func TestMiddlewareProvidesTheRequest(t *testing.T) {
+
+	newInfoProvider := func(t *testing.T) di.RootProvider {
+		registry, err := di.RegisterType[*requestInfo, *requestInfo](di.Registry{}, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("resolves the request, its context, and the response writer", func(t *testing.T) {
+		handler := Middleware(newInfoProvider(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			info, err := di.Resolve[*requestInfo](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if info.Request != r {
+				t.Errorf("expected the current request to be resolved")
+			}
+			if info.Writer != w {
+				t.Errorf("expected the response writer to be resolved")
+			}
+			ctx, err := di.Resolve[context.Context](scope)
+			if err != nil {
+				t.Fatalf("unexpected error from Resolve: %v", err)
+			}
+			if ctx != r.Context() {
+				t.Errorf("expected the request's context to be resolved")
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("does not provide the request to other scopes", func(t *testing.T) {
+		scope := newInfoProvider(t).NewScope()
+		if _, err := di.Resolve[*http.Request](scope); !errors.Is(err, di.ErrUnknownType) {
+			t.Fatalf("expected %q; got %q", di.ErrUnknownType, err)
+		}
+	})
+
+	t.Run("ReplaceResponseWriter provides a wrapping writer", func(t *testing.T) {
+		wrap := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				recorder := &statusRecorder{ResponseWriter: w}
+				if err := ReplaceResponseWriter(r, recorder); err != nil {
+					t.Fatalf("unexpected error from ReplaceResponseWriter: %v", err)
+				}
+				next.ServeHTTP(recorder, r)
+			})
+		}
+		provider, err := di.Registry{}.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		if err := provider.Freeze(); err != nil {
+			t.Fatalf("unexpected error from Freeze: %v", err)
+		}
+		handler := Middleware(provider)(wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer, err := di.ResolveFromContext[http.ResponseWriter](r.Context())
+			if err != nil {
+				t.Fatalf("unexpected error from ResolveFromContext: %v", err)
+			}
+			if _, ok := writer.(*statusRecorder); !ok {
+				t.Errorf("expected the wrapping writer to be resolved; got %T", writer)
+			}
+			if err := ReplaceResponseWriter(r, w); !errors.Is(err, di.ErrAlreadyResolved) {
+				t.Errorf("expected %q; got %q", di.ErrAlreadyResolved, err)
+			}
+		})))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("ReplaceResponseWriter returns ErrNoScope without a scope", func(t *testing.T) {
+		err := ReplaceResponseWriter(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		if !errors.Is(err, ErrNoScope) {
+			t.Fatalf("expected %q; got %q", ErrNoScope, err)
+		}
+	})
+}
+
+type routeLabel struct {
+	name string
+}
+
+type auditLog struct{}
+
+type auditedService struct {
+	Log *auditLog
+}
+
+func TestMiddlewareWithProvider(t *testing.T) {
+
+	newBase := func(t *testing.T) di.RootProvider {
+		registry, err := di.RegisterInstance[*routeLabel](di.Registry{}, &routeLabel{name: "public"})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		return provider
+	}
+
+	t.Run("creates scopes from a child provider with the overrides", func(t *testing.T) {
+		base := newBase(t)
+		overrides, err := di.RegisterInstance[*routeLabel](di.Registry{}, &routeLabel{name: "admin"})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		middleware, _, err := MiddlewareWithProvider(base, overrides)
+		if err != nil {
+			t.Fatalf("unexpected error from MiddlewareWithProvider: %v", err)
+		}
+		var labels []string
+		var descriptions []string
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			label, err := di.Resolve[*routeLabel](scope)
+			if err != nil {
+				t.Errorf("unexpected error from Resolve: %v", err)
+				return
+			}
+			labels = append(labels, label.name)
+			descriptions = append(descriptions, scope.String())
+		})
+		mux := http.NewServeMux()
+		mux.Handle("/admin/", middleware(handler))
+		mux.Handle("/", Middleware(base)(handler))
+		for _, path := range []string{"/admin/users", "/users"} {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		if !slices.Equal(labels, []string{"admin", "public"}) {
+			t.Fatalf("expected the admin and public labels; got %v", labels)
+		}
+		layer := "provider=" + base.ID() + "/"
+		if !strings.Contains(descriptions[0], layer) || strings.Contains(descriptions[1], "provider=") {
+			t.Fatalf("expected only the admin scope to show the provider layer %q; got %v", layer, descriptions)
+		}
+	})
+
+	t.Run("returns a function that closes the child's singletons", func(t *testing.T) {
+		base := newBase(t)
+		overrides, err := di.RegisterType[*requestCloser, *requestCloser](di.Registry{}, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		middleware, closeChild, err := MiddlewareWithProvider(base, overrides)
+		if err != nil {
+			t.Fatalf("unexpected error from MiddlewareWithProvider: %v", err)
+		}
+		var closer *requestCloser
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, _ := ScopeFromRequest(r)
+			closer, err = di.Resolve[*requestCloser](scope)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if err != nil {
+			t.Fatalf("unexpected error from Resolve: %v", err)
+		}
+		if closer.isClosed() {
+			t.Fatalf("expected the singleton to outlive the request")
+		}
+		if errs := closeChild(context.Background()); len(errs) != 0 {
+			t.Fatalf("unexpected errors from closing the child: %v", errs)
+		}
+		if !closer.isClosed() {
+			t.Fatalf("expected closing the child to close its singleton")
+		}
+	})
+
+	t.Run("returns an error for overrides with problems", func(t *testing.T) {
+		overrides, err := di.RegisterType[*auditedService, *auditedService](di.Registry{}, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		middleware, closeChild, err := MiddlewareWithProvider(newBase(t), overrides)
+		if !errors.Is(err, di.ErrVerificationFailed) {
+			t.Fatalf("expected %q; got %q", di.ErrVerificationFailed, err)
+		}
+		if middleware != nil || closeChild != nil {
+			t.Fatalf("expected no middleware or close function")
+		}
+	})
+
+	t.Run("returns an error for an uninitialized base", func(t *testing.T) {
+		if _, _, err := MiddlewareWithProvider(di.RootProvider{}, di.Registry{}); !errors.Is(err, di.ErrUninitializedProvider) {
+			t.Fatalf("expected %q; got %q", di.ErrUninitializedProvider, err)
+		}
+	})
+}
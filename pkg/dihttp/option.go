@@ -0,0 +1,66 @@
+package dihttp
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultCloseTimeout is the time [Middleware] allows for closing a request's scope unless
+// [WithCloseTimeout] is given.
+const DefaultCloseTimeout = 5 * time.Second
+
+// An Option configures optional behavior for [Middleware].
+type Option func(*options)
+
+type options struct {
+	closeTimeout time.Duration
+	onCloseError func(*http.Request, []error)
+	scopeName    string
+
+	// closeErrorHandlerSet is true if WithCloseErrorHandler was given, and delegateCloseErrors is
+	// true if it was not and the provider has a close error handler.
+	closeErrorHandlerSet bool
+	delegateCloseErrors  bool
+}
+
+func newOptions(opts []Option) options {
+	options := options{
+		closeTimeout: DefaultCloseTimeout,
+		onCloseError: logCloseErrors,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithCloseTimeout sets the time allowed for closing a request's scope once the handler returns.
+// The timeout is independent of the request's context, which has usually been cancelled by then.
+// A timeout of 0 or less means closing the scope is not given a deadline.
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(options *options) {
+		options.closeTimeout = timeout
+	}
+}
+
+// WithCloseErrorHandler sets a function to call with the errors from closing a request's scope,
+// if there are any. By default the errors are passed to the provider's
+// [di.WithCloseErrorHandler] if it has one and written to the standard logger otherwise.
+func WithCloseErrorHandler(handler func(r *http.Request, closeErrors []error)) Option {
+	return func(options *options) {
+		options.onCloseError = handler
+		options.closeErrorHandlerSet = true
+	}
+}
+
+// WithScopeName makes [Middleware] create named scopes; see [di.RootProvider.NewNamedScope].
+func WithScopeName(name string) Option {
+	return func(options *options) {
+		options.scopeName = name
+	}
+}
+
+func logCloseErrors(r *http.Request, closeErrors []error) {
+	log.Printf("dihttp: closing the scope for %s %s failed: %v", r.Method, r.URL.Path, closeErrors)
+}
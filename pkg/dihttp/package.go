@@ -0,0 +1,3 @@
+// Package dihttp integrates the di package with net/http by creating a [di.Scope] for each
+// request.
+package dihttp
@@ -0,0 +1,114 @@
+package garlic
+
+import (
+	"errors"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+// A Lifetime is a [di.Lifetime].
+type Lifetime = di.Lifetime
+
+const (
+	// Transient is [di.Transient].
+	Transient = di.Transient
+
+	// Scoped is [di.Scoped].
+	Scoped = di.Scoped
+
+	// Singleton is [di.Singleton].
+	Singleton = di.Singleton
+
+	// PerResolution is [di.PerResolution].
+	PerResolution = di.PerResolution
+)
+
+// An App is the [di.RootProvider] built from a [Container]. Its NewScope method creates the
+// [Scope] values for request-scoped work and its Close method closes it.
+type App = di.RootProvider
+
+// A Scope is a [di.Scope] created from an [App].
+type Scope = di.Scope
+
+// A Container accumulates registrations in a [di.Registry] along with the errors they return, so
+// a program can register everything and check for errors once when it calls [Build]. A Container
+// is not safe for concurrent use.
+type Container struct {
+	registry di.Registry
+	errs     []error
+}
+
+// New returns an empty [Container].
+func New() *Container {
+	return &Container{}
+}
+
+// Registry returns the [di.Registry] holding the container's registrations.
+func (c *Container) Registry() di.Registry {
+	return c.registry
+}
+
+// Err returns the errors from the container's registrations joined with [errors.Join], or nil if
+// there were none.
+func (c *Container) Err() error {
+	return errors.Join(c.errs...)
+}
+
+func (c *Container) register(registry di.Registry, err error) {
+	c.registry = registry
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+// Add registers Impl as the implementation of Target using [di.RegisterType].
+func Add[Target any, Impl any](c *Container, lifetime Lifetime, opts ...di.RegistrationOption) {
+	c.register(di.RegisterType[Target, Impl](c.registry, lifetime, opts...))
+}
+
+// AddFactory registers factory as the implementation of Target using [di.RegisterFactory].
+func AddFactory[Target any, Impl any](
+	c *Container,
+	lifetime Lifetime,
+	factory di.Factory[Impl],
+	opts ...di.RegistrationOption,
+) {
+	c.register(di.RegisterFactory[Target](c.registry, lifetime, factory, opts...))
+}
+
+// AddInstance registers instance as the [Singleton] value of Target using [di.RegisterInstance].
+func AddInstance[Target any, Impl any](c *Container, instance Impl, opts ...di.RegistrationOption) {
+	c.register(di.RegisterInstance[Target](c.registry, instance, opts...))
+}
+
+// Build returns the errors from the container's registrations, as returned by [Container.Err], or
+// builds an [App] from its registry using [di.Registry.BuildRootProvider].
+func Build(c *Container, opts ...di.ProviderOption) (App, error) {
+	if err := c.Err(); err != nil {
+		return App{}, err
+	}
+	return c.registry.BuildRootProvider(opts...)
+}
+
+// MustBuild is like [Build] but panics with the error if there is one.
+func MustBuild(c *Container, opts ...di.ProviderOption) App {
+	app, err := Build(c, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return app
+}
+
+// Get resolves T from resolver, such as an [App] or [Scope], using [di.Resolve].
+func Get[T any](resolver di.Resolver) (T, error) {
+	return di.Resolve[T](resolver)
+}
+
+// MustGet is like [Get] but panics with the error if there is one.
+func MustGet[T any](resolver di.Resolver) T {
+	value, err := Get[T](resolver)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -0,0 +1,179 @@
+package garlic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type config struct {
+	Name string
+}
+
+type englishGreeter struct {
+	Config *config
+}
+
+func (g *englishGreeter) Greet() string {
+	return "hello " + g.Config.Name
+}
+
+type request struct {
+	Greeter greeter
+}
+
+type counter struct {
+	n int
+}
+
+func TestContainer(t *testing.T) {
+
+	newCounter := func(di.Resolver) (*counter, error) {
+		return &counter{}, nil
+	}
+
+	facade := func() *Container {
+		c := New()
+		AddInstance[*config](c, &config{Name: "garlic"})
+		Add[greeter, *englishGreeter](c, Singleton)
+		Add[*request, *request](c, Scoped)
+		AddFactory[*counter](c, Transient, newCounter)
+		return c
+	}
+
+	verbose := func(t *testing.T) di.Registry {
+		registry, err := di.RegisterInstance[*config](di.Registry{}, &config{Name: "garlic"})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		registry, err = di.RegisterType[greeter, *englishGreeter](registry, di.Singleton)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterType[*request, *request](registry, di.Scoped)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterType: %v", err)
+		}
+		registry, err = di.RegisterFactory[*counter](registry, di.Transient, newCounter)
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterFactory: %v", err)
+		}
+		return registry
+	}
+
+	graph := func(t *testing.T, app App) di.Graph {
+		var buf bytes.Buffer
+		if err := app.GraphJSON(&buf); err != nil {
+			t.Fatalf("unexpected error from GraphJSON: %v", err)
+		}
+		var graph di.Graph
+		if err := json.Unmarshal(buf.Bytes(), &graph); err != nil {
+			t.Fatalf("unexpected error from json.Unmarshal: %v", err)
+		}
+		return graph
+	}
+
+	t.Run("makes the same registrations as the verbose form", func(t *testing.T) {
+		c := facade()
+		if err := c.Err(); err != nil {
+			t.Fatalf("unexpected error from Err: %v", err)
+		}
+		got, want := c.Registry().Registrations(), verbose(t).Registrations()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected registrations %+v; got %+v", want, got)
+		}
+	})
+
+	t.Run("builds a provider that resolves like the verbose form", func(t *testing.T) {
+		app := MustBuild(facade())
+		defer app.Close(context.Background())
+		provider, err := verbose(t).BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		defer provider.Close(context.Background())
+
+		if got, want := MustGet[greeter](app).Greet(), "hello garlic"; got != want {
+			t.Fatalf("expected %q; got %q", want, got)
+		}
+		if MustGet[greeter](app) != MustGet[greeter](app) {
+			t.Fatalf("expected the Singleton to be reused")
+		}
+		scope := app.NewScope()
+		defer scope.Close(context.Background())
+		if MustGet[*request](scope) != MustGet[*request](scope) {
+			t.Fatalf("expected the Scoped value to be reused within a scope")
+		}
+		if MustGet[*counter](scope) == MustGet[*counter](scope) {
+			t.Fatalf("expected a new Transient value for each resolution")
+		}
+
+		_, appErr := Get[*request](app)
+		_, providerErr := di.Resolve[*request](provider)
+		if appErr == nil || providerErr == nil || appErr.Error() != providerErr.Error() {
+			t.Fatalf("expected the errors %v and %v to match", appErr, providerErr)
+		}
+	})
+
+	t.Run("records the sites of the calls to the facade", func(t *testing.T) {
+		app := MustBuild(facade())
+		defer app.Close(context.Background())
+		for _, node := range graph(t, app).Nodes {
+			if !strings.Contains(node.Site, "container_test.go:") {
+				t.Errorf("expected the site of %s to be in the test; got %q", node.ID, node.Site)
+			}
+		}
+	})
+
+	t.Run("accumulates registration errors until Build", func(t *testing.T) {
+		c := New()
+		Add[*config, *config](c, Lifetime(99))
+		Add[*counter, config](c, Singleton)
+		_, wantFirst := di.RegisterType[*config, *config](di.Registry{}, Lifetime(99))
+		_, wantSecond := di.RegisterType[*counter, config](di.Registry{}, Singleton)
+
+		_, err := Build(c)
+		if err == nil {
+			t.Fatalf("expected an error from Build")
+		}
+		if !errors.Is(err, di.ErrUndefinedLifetime) || !errors.Is(err, di.ErrInvalidImplementation) {
+			t.Fatalf("expected %v to be %v and %v", err, di.ErrUndefinedLifetime, di.ErrInvalidImplementation)
+		}
+		if want := errors.Join(wantFirst, wantSecond).Error(); err.Error() != want {
+			t.Fatalf("expected %q; got %q", want, err.Error())
+		}
+	})
+
+	t.Run("MustBuild and MustGet panic with the error", func(t *testing.T) {
+		mustPanic := func(t *testing.T, target error, fn func()) {
+			t.Helper()
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, target) {
+					t.Fatalf("expected a panic with %v; got %v", target, err)
+				}
+			}()
+			fn()
+		}
+		c := New()
+		Add[*config, *config](c, Lifetime(99))
+		mustPanic(t, di.ErrUndefinedLifetime, func() {
+			MustBuild(c)
+		})
+		app := MustBuild(New())
+		defer app.Close(context.Background())
+		mustPanic(t, di.ErrUnknownType, func() {
+			MustGet[*config](app)
+		})
+	})
+}
@@ -0,0 +1,13 @@
+// Package garlic is a compact facade over the di package for small programs and quick starts. A
+// [Container] collects registrations and their errors until it is built, and every function
+// delegates to the di function of the same purpose, so the resulting providers, scopes, and
+// errors are those of the di package:
+//
+//	c := garlic.New()
+//	garlic.Add[Greeter, *greeter](c, garlic.Singleton)
+//	app := garlic.MustBuild(c)
+//	defer app.Close(context.Background())
+//	g := garlic.MustGet[Greeter](app)
+//
+// Programs that outgrow the facade can use [Container.Registry] and the di package directly.
+package garlic
@@ -0,0 +1,40 @@
+// Command garlicgen generates typed accessors for the services in a [garlicgen.Manifest]. It is
+// meant to be run by go generate from the package the manifest describes:
+//
+//	//go:generate go run github.com/ttd2089/garlic/pkg/garlicgen/cmd/garlicgen -manifest garlic.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ttd2089/garlic/pkg/garlicgen"
+)
+
+func main() {
+	manifestPath := flag.String("manifest", "garlic.json", "the path of the manifest to read")
+	outPath := flag.String("out", "garlic_gen.go", "the path of the file to write")
+	flag.Parse()
+	if err := run(*manifestPath, *outPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(manifestPath string, outPath string) error {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	manifest, err := garlicgen.ReadManifest(f)
+	if err != nil {
+		return err
+	}
+	source, err := garlicgen.Generate(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, source, 0o644)
+}
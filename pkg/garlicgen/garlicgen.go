@@ -0,0 +1,291 @@
+// Package garlicgen generates typed accessors for the services registered by a wiring package from
+// a manifest, so that code can call GetUserService(scope) rather than
+// di.Resolve[*svc.UserService](scope) and have the type checked by the compiler.
+//
+// A manifest is a JSON document such as
+//
+//	{
+//	  "package": "wiring",
+//	  "registry": "NewRegistry",
+//	  "imports": {"svc": "example.com/app/svc"},
+//	  "services": [
+//	    {"name": "UserService", "type": "*svc.UserService"}
+//	  ],
+//	  "deps": "Deps"
+//	}
+//
+// For each service the generated code has a function Get<name> that resolves the service's type
+// from a di.Resolver. If deps is set it also has a struct with a field for each service and a
+// function Resolve<deps> that resolves all of them. Every generated file has a function
+// VerifyGarlicManifest that reports the services a di.Registry does not register and, if registry
+// names a function in the package with the signature func() (di.Registry, error), an init
+// function that panics when run by a test binary if the manifest has drifted from the registry it
+// returns.
+//
+// The generated code only uses the public API of the di package and is the same every time it is
+// generated from the same manifest. The garlicgen command is meant to be run by go generate:
+//
+//	//go:generate go run github.com/ttd2089/garlic/pkg/garlicgen/cmd/garlicgen -manifest garlic.json
+package garlicgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// A Manifest lists the services to generate accessors for.
+type Manifest struct {
+
+	// Package is the name of the package the generated file belongs to.
+	Package string `json:"package"`
+
+	// Registry is the name of a function in the package with the signature
+	// func() (di.Registry, error) that builds the registry the manifest describes, if any.
+	Registry string `json:"registry,omitempty"`
+
+	// Imports maps the package names used in the types of the services to their import paths.
+	Imports map[string]string `json:"imports,omitempty"`
+
+	// Services are the services to generate accessors for.
+	Services []Service `json:"services"`
+
+	// Deps is the name of the struct to generate with a field for each service, if any.
+	Deps string `json:"deps,omitempty"`
+}
+
+// A Service is a registered type to generate an accessor for.
+type Service struct {
+
+	// Name is the name of the service, which is used in the name of its accessor and as the name
+	// of its field in the deps struct.
+	Name string `json:"name"`
+
+	// Type is the registered type of the service as a Go type expression, e.g. "*svc.UserService".
+	Type string `json:"type"`
+}
+
+// reservedNames are the package names the generated code imports.
+var reservedNames = []string{"di", "errors", "fmt", "reflect", "testing"}
+
+// ReadManifest reads a JSON [Manifest] from r and validates it.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var manifest Manifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("garlicgen: reading manifest: %w", err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// Validate returns an error describing each problem with the manifest, joined with [errors.Join].
+func (manifest Manifest) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("garlicgen: "+format, args...))
+	}
+	if !token.IsIdentifier(manifest.Package) {
+		invalid("package name %q is not an identifier", manifest.Package)
+	}
+	if manifest.Registry != "" && !token.IsIdentifier(manifest.Registry) {
+		invalid("registry function name %q is not an identifier", manifest.Registry)
+	}
+	if manifest.Deps != "" && !isExported(manifest.Deps) {
+		invalid("deps struct name %q is not an exported identifier", manifest.Deps)
+	}
+	for name, path := range manifest.Imports {
+		if !token.IsIdentifier(name) || name == "_" {
+			invalid("import name %q is not an identifier", name)
+		}
+		if slices.Contains(reservedNames, name) {
+			invalid("import name %q is used by the generated code", name)
+		}
+		if path == "" {
+			invalid("import %q has no path", name)
+		}
+	}
+	if len(manifest.Services) == 0 {
+		invalid("manifest has no services")
+	}
+	names := map[string]bool{}
+	for _, service := range manifest.Services {
+		if !isExported(service.Name) {
+			invalid("service name %q is not an exported identifier", service.Name)
+		}
+		if names[service.Name] {
+			invalid("service name %q is used more than once", service.Name)
+		}
+		names[service.Name] = true
+		expr, err := parser.ParseExpr(service.Type)
+		if err != nil {
+			invalid("type %q of service %s is not a type expression: %v", service.Type, service.Name, err)
+			continue
+		}
+		for _, qualifier := range qualifiers(expr) {
+			if _, ok := manifest.Imports[qualifier]; !ok {
+				invalid("type %q of service %s uses package %s which is not imported", service.Type, service.Name, qualifier)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Generate returns the formatted source of the accessors for the services in manifest, ordered by
+// name.
+func Generate(manifest Manifest) ([]byte, error) {
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	services := slices.Clone(manifest.Services)
+	slices.SortFunc(services, func(a, b Service) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	imports := make([]generatedImport, 0, len(manifest.Imports))
+	for name, path := range manifest.Imports {
+		imports = append(imports, generatedImport{
+			Name: name,
+			Path: path,
+		})
+	}
+	slices.SortFunc(imports, func(a, b generatedImport) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	var b bytes.Buffer
+	if err := generatedFile.Execute(&b, generatedData{
+		Manifest: manifest,
+		Services: services,
+		Imports:  imports,
+	}); err != nil {
+		return nil, fmt.Errorf("garlicgen: generating code: %w", err)
+	}
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("garlicgen: formatting generated code: %w", err)
+	}
+	return source, nil
+}
+
+// qualifiers returns the package names used to qualify types in expr.
+func qualifiers(expr ast.Expr) []string {
+	var names []string
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				names = append(names, ident.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+func isExported(name string) bool {
+	return token.IsIdentifier(name) && token.IsExported(name)
+}
+
+type generatedImport struct {
+	Name string
+	Path string
+}
+
+type generatedData struct {
+	Manifest
+	Services []Service
+	Imports  []generatedImport
+}
+
+var generatedFile = template.Must(template.New("garlic_gen.go").Parse(`// Code generated by garlicgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+{{- if .Registry}}
+	"testing"
+{{- end}}
+
+	"github.com/ttd2089/garlic/pkg/di"
+{{- range .Imports}}
+	{{.Name}} "{{.Path}}"
+{{- end}}
+)
+{{range .Services}}
+// Get{{.Name}} resolves the {{.Name}} service, a {{.Type}}, from r.
+func Get{{.Name}}(r di.Resolver) ({{.Type}}, error) {
+	return di.Resolve[{{.Type}}](r)
+}
+{{end}}
+{{- if .Deps}}
+// {{.Deps}} holds every service in the garlicgen manifest.
+type {{.Deps}} struct {
+{{- range .Services}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+
+// Resolve{{.Deps}} resolves every service in the garlicgen manifest from r.
+func Resolve{{.Deps}}(r di.Resolver) ({{.Deps}}, error) {
+	var deps {{.Deps}}
+	var err error
+{{- range .Services}}
+	if deps.{{.Name}}, err = Get{{.Name}}(r); err != nil {
+		return {{$.Deps}}{}, err
+	}
+{{- end}}
+	return deps, nil
+}
+{{end}}
+// garlicManifest holds the types of the services in the garlicgen manifest.
+var garlicManifest = []reflect.Type{
+{{- range .Services}}
+	reflect.TypeFor[{{.Type}}](),
+{{- end}}
+}
+
+// VerifyGarlicManifest returns an error for each service in the garlicgen manifest that registry
+// does not register, joined with [errors.Join].
+func VerifyGarlicManifest(registry di.Registry) error {
+	registered := map[reflect.Type]bool{}
+	for _, info := range registry.Registrations() {
+		registered[info.Type] = true
+	}
+	var errs []error
+	for _, typ := range garlicManifest {
+		if !registered[typ] {
+			errs = append(errs, fmt.Errorf("manifest service %v is not registered", typ))
+		}
+	}
+	return errors.Join(errs...)
+}
+{{- if .Registry}}
+
+func init() {
+	if !testing.Testing() {
+		return
+	}
+	registry, err := {{.Registry}}()
+	if err != nil {
+		panic(fmt.Sprintf("garlicgen: building the registry to verify the manifest: %v", err))
+	}
+	if err := VerifyGarlicManifest(registry); err != nil {
+		panic(fmt.Sprintf("garlicgen: the manifest has drifted from the registry:\n%v", err))
+	}
+}
+{{- end}}
+`))
@@ -0,0 +1,117 @@
+package garlicgen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+
+	t.Run("reproduces the generated example", func(t *testing.T) {
+		dir := filepath.Join("internal", "example", "wiring")
+		f, err := os.Open(filepath.Join(dir, "garlic.json"))
+		if err != nil {
+			t.Fatalf("unexpected error from Open: %v", err)
+		}
+		defer f.Close()
+		manifest, err := ReadManifest(f)
+		if err != nil {
+			t.Fatalf("unexpected error from ReadManifest: %v", err)
+		}
+		source, err := Generate(manifest)
+		if err != nil {
+			t.Fatalf("unexpected error from Generate: %v", err)
+		}
+		expected, err := os.ReadFile(filepath.Join(dir, "garlic_gen.go"))
+		if err != nil {
+			t.Fatalf("unexpected error from ReadFile: %v", err)
+		}
+		if string(source) != string(expected) {
+			t.Fatalf("expected the generated example to be up to date; run go generate in %s", dir)
+		}
+	})
+
+	t.Run("does not depend on the order of the manifest", func(t *testing.T) {
+		manifest := Manifest{
+			Package: "wiring",
+			Services: []Service{
+				{Name: "B", Type: "*int"},
+				{Name: "A", Type: "string"},
+			},
+		}
+		first, err := Generate(manifest)
+		if err != nil {
+			t.Fatalf("unexpected error from Generate: %v", err)
+		}
+		manifest.Services[0], manifest.Services[1] = manifest.Services[1], manifest.Services[0]
+		second, err := Generate(manifest)
+		if err != nil {
+			t.Fatalf("unexpected error from Generate: %v", err)
+		}
+		if string(first) != string(second) {
+			t.Fatalf("expected the same code; got:\n%s\nand:\n%s", first, second)
+		}
+	})
+
+	t.Run("omits the deps struct and verification init unless they are requested", func(t *testing.T) {
+		source, err := Generate(Manifest{
+			Package:  "wiring",
+			Services: []Service{{Name: "Name", Type: "string"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error from Generate: %v", err)
+		}
+		for _, unexpected := range []string{"\"testing\"", "func init()", "struct {"} {
+			if strings.Contains(string(source), unexpected) {
+				t.Errorf("expected no %s in:\n%s", unexpected, source)
+			}
+		}
+	})
+}
+
+func TestManifest(t *testing.T) {
+
+	t.Run("ReadManifest rejects unknown fields", func(t *testing.T) {
+		_, err := ReadManifest(strings.NewReader(`{"package": "wiring", "servcies": []}`))
+		if err == nil {
+			t.Fatalf("expected an error for an unknown field")
+		}
+	})
+
+	t.Run("Validate reports every problem", func(t *testing.T) {
+		manifest := Manifest{
+			Package:  "not a package",
+			Registry: "1",
+			Imports:  map[string]string{"fmt": "example.com/fmt"},
+			Services: []Service{
+				{Name: "user", Type: "*svc.User"},
+				{Name: "Dup", Type: "int"},
+				{Name: "Dup", Type: "map[string"},
+			},
+			Deps: "deps",
+		}
+		err := manifest.Validate()
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		for _, expected := range []string{
+			`package name "not a package"`,
+			`registry function name "1"`,
+			`deps struct name "deps"`,
+			`import name "fmt" is used by the generated code`,
+			`service name "user"`,
+			`uses package svc which is not imported`,
+			`service name "Dup" is used more than once`,
+			`type "map[string" of service Dup`,
+		} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected the error to mention %s; got:\n%v", expected, err)
+			}
+		}
+		if _, err := Generate(manifest); err == nil {
+			t.Errorf("expected Generate to validate the manifest")
+		}
+	})
+}
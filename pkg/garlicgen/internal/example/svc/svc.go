@@ -0,0 +1,12 @@
+// Package svc holds the services wired up by the garlicgen example.
+package svc
+
+// Config configures the services.
+type Config struct {
+	DSN string
+}
+
+// UserService looks up users.
+type UserService struct {
+	Config *Config
+}
@@ -0,0 +1,19 @@
+// Package wiring registers the services of the garlicgen example and holds the accessors
+// generated for them.
+package wiring
+
+import (
+	"github.com/ttd2089/garlic/pkg/di"
+	"github.com/ttd2089/garlic/pkg/garlicgen/internal/example/svc"
+)
+
+//go:generate go run github.com/ttd2089/garlic/pkg/garlicgen/cmd/garlicgen -manifest garlic.json
+
+// NewRegistry returns the registry for the example services.
+func NewRegistry() (di.Registry, error) {
+	registry, err := di.RegisterInstance[*svc.Config](di.Registry{}, &svc.Config{DSN: "memory"})
+	if err != nil {
+		return di.Registry{}, err
+	}
+	return di.RegisterType[*svc.UserService, *svc.UserService](registry, di.Singleton)
+}
@@ -0,0 +1,44 @@
+package wiring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ttd2089/garlic/pkg/di"
+	"github.com/ttd2089/garlic/pkg/garlicgen/internal/example/svc"
+)
+
+func TestGenerated(t *testing.T) {
+
+	t.Run("ResolveDeps resolves every service", func(t *testing.T) {
+		registry, err := NewRegistry()
+		if err != nil {
+			t.Fatalf("unexpected error from NewRegistry: %v", err)
+		}
+		provider, err := registry.BuildRootProvider()
+		if err != nil {
+			t.Fatalf("unexpected error from BuildRootProvider: %v", err)
+		}
+		deps, err := ResolveDeps(provider)
+		if err != nil {
+			t.Fatalf("unexpected error from ResolveDeps: %v", err)
+		}
+		if deps.UserService == nil || deps.UserService.Config != deps.Config {
+			t.Fatalf("expected the user service to use the config; got %+v", deps)
+		}
+	})
+
+	t.Run("VerifyGarlicManifest reports services that are not registered", func(t *testing.T) {
+		registry, err := di.RegisterInstance[*svc.Config](di.Registry{}, &svc.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error from RegisterInstance: %v", err)
+		}
+		err = VerifyGarlicManifest(registry)
+		if err == nil || !strings.Contains(err.Error(), "*svc.UserService") {
+			t.Fatalf("expected an error for *svc.UserService; got %v", err)
+		}
+		if strings.Contains(err.Error(), "*svc.Config") {
+			t.Fatalf("expected no error for the registered *svc.Config; got %v", err)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+// Package garlicvet provides an [analysis.Analyzer] that reports mistakes in the use of the di
+// package that would otherwise only be found when the registrations are used at runtime.
+//
+// The analyzer reports:
+//   - calls to RegisterType, RegisterFactory, and RegisterInstance whose Impl type is not
+//     assignable to the Target type or is an interface type,
+//   - registrations of unsharable types with a constant lifetime other than Transient that do not
+//     use AllowSharedValue, and
+//   - calls to Resolve for types that no registration visible to the analyzer provides.
+//
+// Registrations are only visible to the analyzer in the package being analyzed and the packages it
+// imports, so the check for unregistered types is best-effort and is only made in packages that
+// can see at least one registration.
+//
+// The analyzer can be run with go vet using the garlicvet command:
+//
+//	go install github.com/ttd2089/garlic/pkg/garlicvet/cmd/garlicvet@latest
+//	go vet -vettool=$(which garlicvet) ./...
+package garlicvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const diPath = "github.com/ttd2089/garlic/pkg/di"
+
+// The values of the di.Lifetime constants the analyzer uses.
+const (
+	transient = 1
+	singleton = 3
+)
+
+// Analyzer reports mismatches between registrations and resolutions using the di package.
+var Analyzer = &analysis.Analyzer{
+	Name:      "garlicvet",
+	Doc:       "report mismatched di registrations and resolutions of unregistered types",
+	URL:       "https://pkg.go.dev/github.com/ttd2089/garlic/pkg/garlicvet",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(registrations)},
+}
+
+// registrations is a package fact listing the target types a package registers.
+type registrations struct {
+	Types []string
+}
+
+// AFact implements [analysis.Fact].
+func (*registrations) AFact() {}
+
+func (r *registrations) String() string {
+	return "registrations"
+}
+
+// A diCall is a call to one of the generic functions in the di package.
+type diCall struct {
+	call     *ast.CallExpr
+	name     string
+	typeArgs *types.TypeList
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	calls := []diCall{}
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		if call, ok := asDICall(pass, node.(*ast.CallExpr)); ok {
+			calls = append(calls, call)
+		}
+	})
+
+	registered := map[string]bool{}
+	for _, call := range calls {
+		switch call.name {
+		case "RegisterType", "RegisterFactory", "RegisterInstance":
+			if call.typeArgs.Len() == 2 && checkRegistration(pass, call) {
+				registered[types.TypeString(call.typeArgs.At(0), nil)] = true
+			}
+		}
+	}
+	if len(registered) > 0 {
+		fact := &registrations{}
+		for typ := range registered {
+			fact.Types = append(fact.Types, typ)
+		}
+		sort.Strings(fact.Types)
+		pass.ExportPackageFact(fact)
+	}
+	for _, fact := range pass.AllPackageFacts() {
+		for _, typ := range fact.Fact.(*registrations).Types {
+			registered[typ] = true
+		}
+	}
+
+	if len(registered) == 0 {
+		return nil, nil
+	}
+	for _, call := range calls {
+		if call.name != "Resolve" || call.typeArgs.Len() != 1 {
+			continue
+		}
+		typ := call.typeArgs.At(0)
+		if containsTypeParam(typ) || registered[types.TypeString(typ, nil)] {
+			continue
+		}
+		pass.Reportf(call.call.Pos(), "Resolve[%s]: no visible registration provides %s",
+			types.TypeString(typ, qualifier(pass)), types.TypeString(typ, qualifier(pass)))
+	}
+	return nil, nil
+}
+
+// asDICall returns the di function called by call and its type arguments, if call calls a generic
+// function from the di package.
+func asDICall(pass *analysis.Pass, call *ast.CallExpr) (diCall, bool) {
+	fun := ast.Unparen(call.Fun)
+	switch expr := fun.(type) {
+	case *ast.IndexExpr:
+		fun = expr.X
+	case *ast.IndexListExpr:
+		fun = expr.X
+	}
+	var ident *ast.Ident
+	switch expr := fun.(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return diCall{}, false
+	}
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != diPath {
+		return diCall{}, false
+	}
+	instance, ok := pass.TypesInfo.Instances[ident]
+	if !ok {
+		return diCall{}, false
+	}
+	return diCall{
+		call:     call,
+		name:     fn.Name(),
+		typeArgs: instance.TypeArgs,
+	}, true
+}
+
+// checkRegistration reports the problems with a registration and returns false if it has any.
+func checkRegistration(pass *analysis.Pass, call diCall) bool {
+	target, impl := call.typeArgs.At(0), call.typeArgs.At(1)
+	if containsTypeParam(target) || containsTypeParam(impl) {
+		return false
+	}
+	q := qualifier(pass)
+	if types.IsInterface(impl) {
+		pass.Reportf(call.call.Pos(), "%s: implementation type %s is not a concrete type",
+			call.name, types.TypeString(impl, q))
+		return false
+	}
+	if !types.AssignableTo(impl, target) {
+		pass.Reportf(call.call.Pos(), "%s: implementation type %s is not assignable to target type %s",
+			call.name, types.TypeString(impl, q), types.TypeString(target, q))
+		return false
+	}
+
+	lifetime, ok := registrationLifetime(pass, call)
+	if !ok || lifetime == transient || isSharable(impl) || allowsSharedValue(pass, call) {
+		return true
+	}
+	pass.Reportf(call.call.Pos(),
+		"%s: unsharable type %s cannot be registered with a lifetime other than Transient; "+
+			"use AllowSharedValue if sharing copies of the value is safe",
+		call.name, types.TypeString(impl, q))
+	return false
+}
+
+// registrationLifetime returns the lifetime of a registration if it is a constant.
+func registrationLifetime(pass *analysis.Pass, call diCall) (int64, bool) {
+	if call.name == "RegisterInstance" {
+		// Instances are always Singletons.
+		return singleton, true
+	}
+	if len(call.call.Args) < 2 {
+		return 0, false
+	}
+	value := pass.TypesInfo.Types[call.call.Args[1]].Value
+	if value == nil || value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(value)
+}
+
+// allowsSharedValue reports whether the options given to a registration include a call to
+// di.AllowSharedValue.
+func allowsSharedValue(pass *analysis.Pass, call diCall) bool {
+	// The options follow the factory for RegisterFactory and the lifetime or instance otherwise.
+	first := 2
+	if call.name == "RegisterFactory" {
+		first = 3
+	}
+	if len(call.call.Args) <= first {
+		return false
+	}
+	return slices.ContainsFunc(call.call.Args[first:], func(arg ast.Expr) bool {
+		optCall, ok := ast.Unparen(arg).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		var ident *ast.Ident
+		switch fun := ast.Unparen(optCall.Fun).(type) {
+		case *ast.Ident:
+			ident = fun
+		case *ast.SelectorExpr:
+			ident = fun.Sel
+		default:
+			return false
+		}
+		fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+		return ok && fn.Pkg() != nil && fn.Pkg().Path() == diPath && fn.Name() == "AllowSharedValue"
+	})
+}
+
+// isSharable matches the di package's definition of a sharable type.
+func isSharable(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Chan:
+		return true
+	}
+	return false
+}
+
+func containsTypeParam(typ types.Type) bool {
+	switch typ := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return containsTypeParam(typ.Elem())
+	case *types.Slice:
+		return containsTypeParam(typ.Elem())
+	case *types.Array:
+		return containsTypeParam(typ.Elem())
+	case *types.Chan:
+		return containsTypeParam(typ.Elem())
+	case *types.Map:
+		return containsTypeParam(typ.Key()) || containsTypeParam(typ.Elem())
+	case *types.Named:
+		args := typ.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if containsTypeParam(args.At(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func qualifier(pass *analysis.Pass) types.Qualifier {
+	return types.RelativeTo(pass.Pkg)
+}
@@ -0,0 +1,22 @@
+package garlicvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+
+	t.Run("reports invalid registrations", func(t *testing.T) {
+		analysistest.Run(t, analysistest.TestData(), Analyzer, "registrations")
+	})
+
+	t.Run("reports resolutions of types without a visible registration", func(t *testing.T) {
+		analysistest.Run(t, analysistest.TestData(), Analyzer, "resolutions")
+	})
+
+	t.Run("does not report resolutions when no registrations are visible", func(t *testing.T) {
+		analysistest.Run(t, analysistest.TestData(), Analyzer, "unregistered")
+	})
+}
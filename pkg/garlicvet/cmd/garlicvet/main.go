@@ -0,0 +1,13 @@
+// Command garlicvet runs the [garlicvet.Analyzer] standalone or with go vet:
+//
+//	go vet -vettool=$(which garlicvet) ./...
+package main
+
+import (
+	"github.com/ttd2089/garlic/pkg/garlicvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(garlicvet.Analyzer)
+}
@@ -0,0 +1,42 @@
+// Package di is a stub of the di package for the analyzer tests.
+package di
+
+import "reflect"
+
+type Lifetime int
+
+const (
+	Transient Lifetime = iota + 1
+	Scoped
+	Singleton
+	PerResolution
+)
+
+type Registry struct{}
+
+type Resolver interface {
+	Resolve(reflect.Type) (any, error)
+}
+
+type Factory[T any] func(Resolver) (T, error)
+
+type RegistrationOption func()
+
+func AllowSharedValue() RegistrationOption { return nil }
+
+func RegisterType[Target any, Impl any](registry Registry, lifetime Lifetime, opts ...RegistrationOption) (Registry, error) {
+	return registry, nil
+}
+
+func RegisterFactory[Target any, Impl any](registry Registry, lifetime Lifetime, factory Factory[Impl], opts ...RegistrationOption) (Registry, error) {
+	return registry, nil
+}
+
+func RegisterInstance[Target any, Impl any](registry Registry, instance Impl, opts ...RegistrationOption) (Registry, error) {
+	return registry, nil
+}
+
+func Resolve[T any](resolver Resolver) (T, error) {
+	var zero T
+	return zero, nil
+}
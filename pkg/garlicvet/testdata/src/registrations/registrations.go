@@ -0,0 +1,39 @@
+package registrations // want package:"registrations"
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+type Config struct {
+	Name string
+}
+
+type Service interface {
+	Serve()
+}
+
+type server struct{}
+
+func (*server) Serve() {}
+
+func Register(registry di.Registry) {
+	_, _ = di.RegisterType[Service, *server](registry, di.Singleton)
+	_, _ = di.RegisterType[Service, server](registry, di.Transient)      // want `RegisterType: implementation type server is not assignable to target type Service`
+	_, _ = di.RegisterType[io.Writer, io.Writer](registry, di.Transient) // want `RegisterType: implementation type io.Writer is not a concrete type`
+	_, _ = di.RegisterFactory[io.Writer, *strings.Builder](registry, di.Scoped, nil)
+	_, _ = di.RegisterFactory[io.Reader, *strings.Builder](registry, di.Scoped, nil) // want `RegisterFactory: implementation type \*strings.Builder is not assignable to target type io.Reader`
+
+	_, _ = di.RegisterType[Config, Config](registry, di.Transient)
+	_, _ = di.RegisterType[Config, Config](registry, di.Scoped) // want `RegisterType: unsharable type Config cannot be registered with a lifetime other than Transient`
+	_, _ = di.RegisterType[Config, Config](registry, di.Singleton, di.AllowSharedValue())
+	_, _ = di.RegisterFactory[[]string, []string](registry, di.PerResolution, nil) // want `RegisterFactory: unsharable type \[\]string cannot be registered`
+	_, _ = di.RegisterFactory[chan int, chan int](registry, di.Singleton, nil)
+	_, _ = di.RegisterInstance[Config](registry, Config{}) // want `RegisterInstance: unsharable type Config cannot be registered`
+	_, _ = di.RegisterInstance[*Config](registry, &Config{})
+
+	var lifetime di.Lifetime
+	_, _ = di.RegisterType[Config, Config](registry, lifetime)
+}
@@ -0,0 +1,22 @@
+package resolutions
+
+import (
+	"io"
+
+	"registrations"
+
+	"github.com/ttd2089/garlic/pkg/di"
+)
+
+func Resolve(resolver di.Resolver) {
+	_, _ = di.Resolve[registrations.Service](resolver)
+	_, _ = di.Resolve[io.Writer](resolver)
+	_, _ = di.Resolve[*registrations.Config](resolver)
+	_, _ = di.Resolve[io.Reader](resolver) // want `Resolve\[io.Reader\]: no visible registration provides io.Reader`
+	_, _ = di.Resolve[string](resolver)    // want `Resolve\[string\]: no visible registration provides string`
+	registrations.Register(di.Registry{})
+}
+
+func ResolveGeneric[T any](resolver di.Resolver) (T, error) {
+	return di.Resolve[T](resolver)
+}
@@ -0,0 +1,8 @@
+package unregistered
+
+import "github.com/ttd2089/garlic/pkg/di"
+
+// Resolve is not reported because no registrations are visible to this package.
+func Resolve(resolver di.Resolver) {
+	_, _ = di.Resolve[string](resolver)
+}